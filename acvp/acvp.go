@@ -0,0 +1,284 @@
+// Package acvp reads NIST ACVP test vector sets (JSON) and writes the matching responses,
+// so this implementation can be driven by an ACVP client.
+//
+// Only AFT (algorithm functional tests) with 128 bit keys are supported, for ACVP-AES-ECB,
+// ACVP-AES-CBC, ACVP-AES-CTR and ACVP-AES-GCM. GCM groups must use external 96 bit IVs and
+// 128 bit tags, the only ones SealGCM takes.
+// The message format is described in https://pages.nist.gov/ACVP/draft-celi-acvp-symmetric.html
+package acvp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("Unsupported algorithm")
+	ErrUnsupportedTest      = errors.New("Unsupported test")
+)
+
+type VectorSet struct {
+	VsID       int         `json:"vsId"`
+	Algorithm  string      `json:"algorithm"`
+	Revision   string      `json:"revision"`
+	TestGroups []TestGroup `json:"testGroups"`
+}
+
+type TestGroup struct {
+	TgID      int        `json:"tgId"`
+	TestType  string     `json:"testType"`
+	Direction string     `json:"direction"`
+	KeyLen    int        `json:"keyLen"`
+	Tests     []TestCase `json:"tests"`
+
+	// GCM
+	IVGen  string `json:"ivGen,omitempty"`
+	IVLen  int    `json:"ivLen,omitempty"`
+	TagLen int    `json:"tagLen,omitempty"`
+}
+
+type TestCase struct {
+	TcID int    `json:"tcId"`
+	Key  string `json:"key"`
+	IV   string `json:"iv,omitempty"`
+	PT   string `json:"pt,omitempty"`
+	CT   string `json:"ct,omitempty"`
+	AAD  string `json:"aad,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+type Response struct {
+	VsID       int             `json:"vsId"`
+	Algorithm  string          `json:"algorithm"`
+	Revision   string          `json:"revision"`
+	TestGroups []GroupResponse `json:"testGroups"`
+}
+
+type GroupResponse struct {
+	TgID  int            `json:"tgId"`
+	Tests []CaseResponse `json:"tests"`
+}
+
+type CaseResponse struct {
+	TcID int    `json:"tcId"`
+	PT   string `json:"pt,omitempty"`
+	CT   string `json:"ct,omitempty"`
+	Tag  string `json:"tag,omitempty"`
+	// TestPassed is false when a GCM tag doesn't match, there is no plaintext then.
+	TestPassed *bool `json:"testPassed,omitempty"`
+}
+
+// Decode reads a vector set. ACVP servers wrap it in an array with the protocol version first,
+// both the wrapped and the bare object are accepted.
+func Decode(b []byte) (*VectorSet, error) {
+	b = bytes.TrimSpace(b)
+
+	if len(b) > 0 && b[0] == '[' {
+		var messages []json.RawMessage
+		if err := json.Unmarshal(b, &messages); err != nil {
+			return nil, err
+		}
+		if len(messages) != 2 {
+			return nil, fmt.Errorf("expected [version, vector set], got %d elements", len(messages))
+		}
+		b = messages[1]
+	}
+
+	var vs VectorSet
+	if err := json.Unmarshal(b, &vs); err != nil {
+		return nil, err
+	}
+	return &vs, nil
+}
+
+// Encode writes the response wrapped with the protocol version, like the vector set it answers.
+func Encode(r *Response) ([]byte, error) {
+	return json.MarshalIndent([]any{map[string]string{"acvVersion": "1.0"}, r}, "", "  ")
+}
+
+// Process reads a vector set from r and writes the response to w.
+func Process(r io.Reader, w io.Writer) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	vs, err := Decode(b)
+	if err != nil {
+		return err
+	}
+
+	resp, err := Solve(vs)
+	if err != nil {
+		return err
+	}
+
+	out, err := Encode(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(out)
+	return err
+}
+
+func modeFor(algorithm string) (aesgo.Mode, error) {
+	switch algorithm {
+	case "ACVP-AES-ECB":
+		return aesgo.ECB, nil
+	case "ACVP-AES-CBC":
+		return aesgo.CBC, nil
+	case "ACVP-AES-CTR":
+		return aesgo.CTR, nil
+	case "ACVP-AES-GCM":
+		return aesgo.GCM, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+}
+
+// Solve computes the answer to every test case.
+func Solve(vs *VectorSet) (*Response, error) {
+	mode, err := modeFor(vs.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{VsID: vs.VsID, Algorithm: vs.Algorithm, Revision: vs.Revision}
+
+	for _, group := range vs.TestGroups {
+		if group.TestType != "AFT" || group.KeyLen != 128 {
+			return nil, fmt.Errorf("%w: group %d is %s with %d bit keys", ErrUnsupportedTest, group.TgID, group.TestType, group.KeyLen)
+		}
+
+		encrypt := group.Direction == "encrypt"
+		if !encrypt && group.Direction != "decrypt" {
+			return nil, fmt.Errorf("%w: unknown direction %q", ErrUnsupportedTest, group.Direction)
+		}
+		if mode == aesgo.GCM && (group.IVLen != 8*aesgo.GCMNonceSize || group.TagLen != 8*aesgo.GCMTagSize || encrypt && group.IVGen != "external") {
+			return nil, fmt.Errorf("%w: group %d has a %d bit %s IV and a %d bit tag", ErrUnsupportedTest, group.TgID, group.IVLen, group.IVGen, group.TagLen)
+		}
+
+		solve := solveCase
+		if mode == aesgo.GCM {
+			solve = solveGCM
+		}
+
+		g := GroupResponse{TgID: group.TgID}
+		for _, test := range group.Tests {
+			c, err := solve(mode, encrypt, test)
+			if err != nil {
+				return nil, fmt.Errorf("group %d, test %d: %w", group.TgID, test.TcID, err)
+			}
+			g.Tests = append(g.Tests, c)
+		}
+		resp.TestGroups = append(resp.TestGroups, g)
+	}
+
+	return resp, nil
+}
+
+func solveCase(mode aesgo.Mode, encrypt bool, test TestCase) (CaseResponse, error) {
+	k, err := hex.DecodeString(test.Key)
+	if err != nil {
+		return CaseResponse{}, err
+	}
+	if len(k) != 16 {
+		return CaseResponse{}, aesgo.ErrUnsupportedKeySize
+	}
+
+	iv, err := hex.DecodeString(test.IV)
+	if err != nil {
+		return CaseResponse{}, err
+	}
+
+	a, err := aesgo.NewCipher(key.NewKey([16]byte(k)),
+		aesgo.WithPadding(aesgo.NoPadding),
+		aesgo.WithRandReader(bytes.NewReader(iv)),
+		aesgo.WithInsecureModes(),
+	)
+	if err != nil {
+		return CaseResponse{}, err
+	}
+
+	r := CaseResponse{TcID: test.TcID}
+
+	if encrypt {
+		pt, err := hex.DecodeString(test.PT)
+		if err != nil {
+			return r, err
+		}
+		encrypted, err := a.Encrypt(mode, pt)
+		if err != nil {
+			return r, err
+		}
+		r.CT = strings.ToUpper(hex.EncodeToString(encrypted[len(iv):]))
+		return r, nil
+	}
+
+	ct, err := hex.DecodeString(test.CT)
+	if err != nil {
+		return r, err
+	}
+	decrypted, err := a.Decrypt(mode, append(iv, ct...))
+	if err != nil {
+		return r, err
+	}
+	r.PT = strings.ToUpper(hex.EncodeToString(decrypted))
+
+	return r, nil
+}
+
+// solveGCM answers a GCM case. The ciphertext and the tag are apart in both directions, and a
+// decryption with a wrong tag is a result, not an error.
+func solveGCM(_ aesgo.Mode, encrypt bool, test TestCase) (CaseResponse, error) {
+	r := CaseResponse{TcID: test.TcID}
+
+	var fields [5][]byte
+	for i, v := range []string{test.Key, test.IV, test.AAD, test.PT, test.CT + test.Tag} {
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return r, err
+		}
+		fields[i] = b
+	}
+	k, iv, aad, pt, sealed := fields[0], fields[1], fields[2], fields[3], fields[4]
+	if len(k) != 16 {
+		return r, aesgo.ErrUnsupportedKeySize
+	}
+
+	a, err := aesgo.NewCipher(key.NewKey([16]byte(k)))
+	if err != nil {
+		return r, err
+	}
+
+	if encrypt {
+		sealed, err := a.SealGCM(iv, pt, aad)
+		if err != nil {
+			return r, err
+		}
+		r.CT = strings.ToUpper(hex.EncodeToString(sealed[:len(pt)]))
+		r.Tag = strings.ToUpper(hex.EncodeToString(sealed[len(pt):]))
+		return r, nil
+	}
+
+	decrypted, err := a.OpenGCM(iv, sealed, aad)
+	if errors.Is(err, aesgo.ErrAuthentication) {
+		passed := false
+		r.TestPassed = &passed
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	r.PT = strings.ToUpper(hex.EncodeToString(decrypted))
+
+	return r, nil
+}
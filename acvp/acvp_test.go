@@ -0,0 +1,157 @@
+package acvp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const request = `[
+  {"acvVersion": "1.0"},
+  {
+    "vsId": 42,
+    "algorithm": "ACVP-AES-CBC",
+    "revision": "1.0",
+    "testGroups": [
+      {
+        "tgId": 1, "testType": "AFT", "direction": "encrypt", "keyLen": 128,
+        "tests": [
+          {"tcId": 1, "key": "1F8E4973953F3FB0BD6B16662E9A3C17", "iv": "2FE2B333CEDA8F98F4A99B40D2CD34A8", "pt": "45CF12964FC824AB76616AE2F4BF0822"}
+        ]
+      },
+      {
+        "tgId": 2, "testType": "AFT", "direction": "decrypt", "keyLen": 128,
+        "tests": [
+          {"tcId": 2, "key": "1F8E4973953F3FB0BD6B16662E9A3C17", "iv": "2FE2B333CEDA8F98F4A99B40D2CD34A8", "ct": "0F61C4D44C5147C03C195AD7E2CC12B2"}
+        ]
+      }
+    ]
+  }
+]`
+
+func TestProcess(t *testing.T) {
+	var out bytes.Buffer
+	if err := Process(strings.NewReader(request), &out); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	resp, err := Decode(out.Bytes())
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if resp.VsID != 42 || len(resp.TestGroups) != 2 {
+		t.Fatalf("Unexpected response: %s", out.String())
+	}
+
+	if !strings.Contains(out.String(), `"ct": "0F61C4D44C5147C03C195AD7E2CC12B2"`) {
+		t.Errorf("Missing ciphertext in response: %s", out.String())
+	}
+
+	if !strings.Contains(out.String(), `"pt": "45CF12964FC824AB76616AE2F4BF0822"`) {
+		t.Errorf("Missing plaintext in response: %s", out.String())
+	}
+}
+
+func TestSolveECBAndCTR(t *testing.T) {
+	tests := []struct {
+		name string
+
+		vs VectorSet
+
+		expected string
+	}{
+		{
+			name: "ECB GFSbox",
+
+			vs: VectorSet{Algorithm: "ACVP-AES-ECB", TestGroups: []TestGroup{{
+				TgID: 1, TestType: "AFT", Direction: "encrypt", KeyLen: 128,
+				Tests: []TestCase{{TcID: 1, Key: "00000000000000000000000000000000", PT: "F34481EC3CC627BACD5DC3FB08F273E6"}},
+			}}},
+
+			expected: "0336763E966D92595A567CC9CE537F5E",
+		},
+		{
+			name: "CTR SP 800-38A F.5.1 first block",
+
+			vs: VectorSet{Algorithm: "ACVP-AES-CTR", TestGroups: []TestGroup{{
+				TgID: 1, TestType: "AFT", Direction: "encrypt", KeyLen: 128,
+				Tests: []TestCase{{TcID: 1, Key: "2B7E151628AED2A6ABF7158809CF4F3C", IV: "F0F1F2F3F4F5F6F7F8F9FAFBFCFDFEFF", PT: "6BC1BEE22E409F96E93D7E117393172A"}},
+			}}},
+
+			expected: "874D6191B620E3261BEF6864990DB6CE",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := Solve(&test.vs)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if ct := resp.TestGroups[0].Tests[0].CT; ct != test.expected {
+				t.Errorf("Got: %s, Expected: %s", ct, test.expected)
+			}
+		})
+	}
+}
+
+// GCM spec test case 4: https://csrc.nist.rip/groups/ST/toolkit/BCM/documents/proposedmodes/gcm/gcm-spec.pdf
+func TestSolveGCM(t *testing.T) {
+	gcmCase := TestCase{
+		Key: "FEFFE9928665731C6D6A8F9467308308",
+		IV:  "CAFEBABEFACEDBADDECAF888",
+		AAD: "FEEDFACEDEADBEEFFEEDFACEDEADBEEFABADDAD2",
+	}
+	pt := "D9313225F88406E5A55909C5AFF5269A86A7A9531534F7DA2E4C303D8A318A721C3C0C95956809532FCF0E2449A6B525B16AEDF5AA0DE657BA637B39"
+	ct := "42831EC2217774244B7221B784D0D49CE3AA212F2C02A4E035C17E2329ACA12E21D514B25466931C7D8F6A5AAC84AA051BA30B396A0AAC973D58E091"
+	tag := "5BC94FBC3221A5DB94FAE95AE7121A47"
+
+	encrypt, decrypt, tampered := gcmCase, gcmCase, gcmCase
+	encrypt.TcID, encrypt.PT = 1, pt
+	decrypt.TcID, decrypt.CT, decrypt.Tag = 2, ct, tag
+	tampered.TcID, tampered.CT, tampered.Tag = 3, ct, "5BC94FBC3221A5DB94FAE95AE7121A48"
+
+	group := func(id int, direction string, tests ...TestCase) TestGroup {
+		return TestGroup{TgID: id, TestType: "AFT", Direction: direction, KeyLen: 128, IVGen: "external", IVLen: 96, TagLen: 128, Tests: tests}
+	}
+
+	resp, err := Solve(&VectorSet{Algorithm: "ACVP-AES-GCM", TestGroups: []TestGroup{
+		group(1, "encrypt", encrypt),
+		group(2, "decrypt", decrypt, tampered),
+	}})
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if r := resp.TestGroups[0].Tests[0]; r.CT != ct || r.Tag != tag {
+		t.Errorf("Got: %s %s, Expected: %s %s", r.CT, r.Tag, ct, tag)
+	}
+	if r := resp.TestGroups[1].Tests[0]; r.PT != pt || r.TestPassed != nil {
+		t.Errorf("Got: %s, Expected: %s", r.PT, pt)
+	}
+	if r := resp.TestGroups[1].Tests[1]; r.PT != "" || r.TestPassed == nil || *r.TestPassed {
+		t.Errorf("Expected testPassed false, got %+v", r)
+	}
+
+	// only the IV and tag sizes SealGCM takes
+	short := group(3, "encrypt", encrypt)
+	short.TagLen = 96
+	if _, err := Solve(&VectorSet{Algorithm: "ACVP-AES-GCM", TestGroups: []TestGroup{short}}); !errors.Is(err, ErrUnsupportedTest) {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedTest, err)
+	}
+}
+
+func TestSolveUnsupported(t *testing.T) {
+	_, err := Solve(&VectorSet{Algorithm: "ACVP-AES-XTS"})
+	if !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedAlgorithm, err)
+	}
+
+	_, err = Solve(&VectorSet{Algorithm: "ACVP-AES-ECB", TestGroups: []TestGroup{{TgID: 1, TestType: "MCT", KeyLen: 128}}})
+	if !errors.Is(err, ErrUnsupportedTest) {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedTest, err)
+	}
+}
@@ -1,9 +1,19 @@
 package aesgo
 
 import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
+	"io"
+	"log/slog"
+	"math/bits"
+	"sync"
 
 	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/nonce"
+	"github.com/mario-areias/aes-go/primitives"
+	"github.com/mario-areias/aes-go/subtle"
 )
 
 type Mode int
@@ -12,16 +22,61 @@ const (
 	ECB = iota
 	CBC
 	CTR
+	GCM
 )
 
-func New(key key.Key) AES {
-	s := key.Len()
+var (
+	ErrUnsupportedKeySize = errors.New("Unsupported key size")
+	ErrInvalidIV          = errors.New("IV must have 16 bytes")
+	ErrInvalidMode        = errors.New("Invalid mode")
+	ErrInvalidPadding     = errors.New("Invalid padding")
+)
+
+// New is kept for convenience in tests and demos. It panics on an unsupported key or option,
+// use NewCipher when a bad key must not crash the process.
+func New(key key.Key, opts ...Option) AES {
+	a, err := NewCipher(key, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return *a
+}
+
+func NewCipher(k key.Key, opts ...Option) (*AES, error) {
+	var a *AES
+
+	if !SelfTestPassed() {
+		return nil, ErrSelfTestFailed
+	}
+
+	if k.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	s := k.Len()
 	switch s {
 	case 128 / 8:
-		return AES{key, 10, 0, make([][16]byte, 11)}
+		a = &AES{key: k, rounds: 10, roundKeys: make([][16]byte, 11)}
+	case 256 / 8:
+		a = &AES{key: k, rounds: 14, roundKeys: make([][16]byte, 15)}
 	default:
-		panic("Unsupported key size")
+		return nil, ErrUnsupportedKeySize
 	}
+
+	a.mode = CBC
+	a.padding = PKCS7
+	a.rand = rand.Reader
+	a.parallelism = 1
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if err := a.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
 }
 
 type AES struct {
@@ -30,42 +85,99 @@ type AES struct {
 
 	currentRound int
 	roundKeys    [][16]byte
-}
-
+	// words are the same round keys as uint32, the way the word oriented rounds use them
+	words []uint32
+
+	mode         Mode
+	padding      Padding
+	rand         io.Reader
+	parallelism  int
+	constantTime bool
+	fault        *Fault
+	tracer       Tracer
+	budget       *Budget
+	crossCheck   cipher.Block
+	bitsliced    bool
+	// insecureModes allows ECB
+	insecureModes bool
+	policy        Policy
+	// nonces seen by Strict decryption, shared with the clones
+	nonces   *nonce.Tracker
+	logger   *slog.Logger
+	metrics  Metrics
+	progress func(processed, total int64)
+
+	// expanded is set once the round keys are generated, they only depend on the key
+	expanded bool
+	// slices is the WithBitsliced engine, created with the round keys
+	slices *bitsliced
+
+	counterLayout CounterLayout
+}
+
+// clone returns a copy with its own round keys, so it can encrypt blocks in another goroutine.
+func (a *AES) clone() *AES {
+	c := *a
+	c.roundKeys = append([][16]byte{}, a.roundKeys...)
+	c.words = append([]uint32{}, a.words...)
+	return &c
+}
+
+// expandKeys generates the round keys the first time they are needed.
+func (a *AES) expandKeys() {
+	if !a.expanded {
+		a.generateAllKeys()
+	}
+}
+
+// generateAllKeys expands the key into the words w[i] of FIPS-197 section 5.2. Like crypto/aes the
+// words are uint32, so nothing is allocated per word. Round key r is w[4r] to w[4r+3].
 func (a *AES) generateAllKeys() {
-	a.currentRound = 0
+	k := a.key.GetBytes()
+	nk := len(k) / 4
 
-	for i := 0; i <= a.rounds; i++ {
-		k := a.generateNewRoundKey()
-		a.roundKeys[i] = k
-		a.nextRound()
+	n := len(a.roundKeys) * 4
+	if len(a.words) != n {
+		a.words = make([]uint32, n)
 	}
-}
+	w := a.words
 
-func (a *AES) generateNewRoundKey() [16]byte {
-	if a.currentRound == 0 {
-		return [16]byte(a.key.GetBytes())
+	// the first round keys are the key itself, one for AES-128 and two for AES-256
+	for i := 0; i < nk && i < n; i++ {
+		w[i] = binary.BigEndian.Uint32(k[4*i:])
 	}
 
-	previousRoundKey := a.roundKeys[a.currentRound-1]
-
-	w0 := previousRoundKey[0:4]
-	w1 := previousRoundKey[4:8]
-	w2 := previousRoundKey[8:12]
-	w3 := previousRoundKey[12:16]
-
-	t := rotWord([4]byte(w3))
-	t = subWord([4]byte(t))
-	t = rcon(a.currentRound, [4]byte(t))
+	for i := nk; i < n; i++ {
+		t := w[i-1]
+		switch {
+		case i%nk == 0:
+			t = a.subWord(bits.RotateLeft32(t, 8)) ^ uint32(primitives.Rcon(i / nk)[0])<<24
+		case nk > 6 && i%nk == 4:
+			// AES-256 substitutes in the middle of its 8 words too, without rotating or rcon
+			t = a.subWord(t)
+		}
+		w[i] = w[i-nk] ^ t
+	}
 
-	w4 := xor([4]byte(w0), [4]byte(t))
-	w5 := xor([4]byte(w4), [4]byte(w1))
-	w6 := xor([4]byte(w5), [4]byte(w2))
-	w7 := xor([4]byte(w6), [4]byte(w3))
+	for r := range a.roundKeys {
+		for j := 0; j < 4; j++ {
+			binary.BigEndian.PutUint32(a.roundKeys[r][4*j:], w[4*r+j])
+		}
+	}
 
-	roundKey := append(w4, append(w5, append(w6, w7...)...)...)
+	a.expanded = true
+	a.logKeyExpansion()
+}
 
-	return [16]byte(roundKey)
+func (a *AES) subWord(word uint32) uint32 {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], word)
+	if a.constantTime {
+		b = subWordConstantTime(b)
+	} else {
+		b = primitives.SubWord(b)
+	}
+	return binary.BigEndian.Uint32(b[:])
 }
 
 func (a *AES) nextRound() {
@@ -76,61 +188,149 @@ func (a *AES) previousRound() {
 	a.currentRound--
 }
 
+// Seal encrypts using the mode configured with WithMode.
+func (a *AES) Seal(plaintext []byte) ([]byte, error) {
+	return a.Encrypt(a.mode, plaintext)
+}
+
+// Open decrypts using the mode configured with WithMode.
+func (a *AES) Open(encrypted []byte) ([]byte, error) {
+	return a.Decrypt(a.mode, encrypted)
+}
+
+// Destroy wipes the expanded round keys and the key itself. Encrypt and Decrypt return key.ErrDestroyed afterwards.
+func (a *AES) Destroy() {
+	for i := range a.roundKeys {
+		clear(a.roundKeys[i][:])
+	}
+	clear(a.words)
+	if a.slices != nil {
+		a.slices.destroy()
+		a.slices = nil
+	}
+	a.expanded = false
+	a.key.Destroy()
+}
+
 func (a *AES) Encrypt(mode Mode, plaintext []byte) ([]byte, error) {
+	start := a.startTimer()
+	encrypted, err := a.encrypt(mode, plaintext)
+	a.logMessage(OpEncrypt, mode, encrypted, len(plaintext), err)
+	a.observe(OpEncrypt, mode, len(plaintext), start, err)
+	return encrypted, err
+}
+
+func (a *AES) Decrypt(mode Mode, encrypted []byte) ([]byte, error) {
+	start := a.startTimer()
+	plaintext, err := a.decrypt(mode, encrypted)
+	a.logMessage(OpDecrypt, mode, encrypted, len(encrypted), err)
+	a.observe(OpDecrypt, mode, len(plaintext), start, err)
+	return plaintext, err
+}
+
+func (a *AES) encrypt(mode Mode, plaintext []byte) ([]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if err := a.allowMode(mode); err != nil {
+		return nil, err
+	}
+
+	// GCM is counted by SealGCM
+	if mode == ECB || mode == CBC || mode == CTR {
+		if err := a.spend(1, len(plaintext)); err != nil {
+			return nil, err
+		}
+	}
+
 	switch mode {
 	case ECB:
-		return a.encryptECB(plaintext), nil
+		return a.encryptECB(plaintext)
 	case CBC:
-		return a.encryptCBC(plaintext, key.Bit128().GetBytes()), nil
+		iv, err := a.randomBlock()
+		if err != nil {
+			return nil, err
+		}
+		return a.encryptCBC(plaintext, iv)
 	case CTR:
-		return a.encryptCTR(plaintext, key.Bit128().GetBytes()), nil
+		nonce, err := a.ctrIV()
+		if err != nil {
+			return nil, err
+		}
+		return a.encryptCTR(plaintext, nonce)
+	case GCM:
+		return a.encryptGCM(plaintext)
 	}
 
-	return nil, errors.New("Invalid mode")
+	return nil, ErrInvalidMode
 }
 
-func (a *AES) Decrypt(mode Mode, encrypted []byte) ([]byte, error) {
+func (a *AES) decrypt(mode Mode, encrypted []byte) ([]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if err := a.allowMode(mode); err != nil {
+		return nil, err
+	}
+
+	if err := a.validateCiphertext(mode, encrypted); err != nil {
+		return nil, err
+	}
+	if err := a.checkBody(mode, encrypted); err != nil {
+		return nil, err
+	}
+
 	switch mode {
 	case ECB:
-		return a.decryptECB(encrypted), nil
+		return a.decryptECB(encrypted)
 	case CBC:
-		if len(encrypted) < 16*2 {
-			return nil, errors.New("Invalid encrypted text. Must have at least 2 blocks: iv + encrypted block")
+		if err := a.useNonce(encrypted[:16]); err != nil {
+			return nil, err
 		}
 		return a.decryptCBC(encrypted[16:], encrypted[:16])
 	case CTR:
-		if len(encrypted) <= 16 {
-			return nil, errors.New("Invalid encrypted text. Must have at least 2 blocks: nonce + encrypted block")
+		if err := a.useNonce(encrypted[:16]); err != nil {
+			return nil, err
 		}
 		// CTR encryption is the same as decryption
-		d := a.encryptCTR(encrypted[16:], encrypted[:16])
+		d, err := a.encryptCTR(encrypted[16:], encrypted[:16])
+		if err != nil {
+			return nil, err
+		}
 
 		// nonce is the first 16 bytes, so remove it before returning
 		return d[16:], nil
+	case GCM:
+		return a.openGCM(encrypted[:GCMNonceSize], encrypted[GCMNonceSize:])
 	}
 
-	return nil, errors.New("Invalid mode")
+	return nil, ErrInvalidMode
 }
 
-func (a *AES) encryptECB(plainText []byte) []byte {
-	blocks := createBlocks(plainText)
-
-	r := make([]byte, 0)
-	for _, block := range blocks {
-		cipherBlock := a.EncryptBlock([16]byte(block))
-		c := convertMatrixToArray(cipherBlock)
-		s := c[:]
-		r = append(r, s...)
+func (a *AES) encryptECB(plainText []byte) ([]byte, error) {
+	blocks, err := a.createBlocks(plainText)
+	if err != nil {
+		return nil, err
 	}
 
-	return r
+	r := make([]byte, len(blocks)*16)
+	a.forEachBlock(len(blocks), func(c *AES, i int) {
+		cipherBlock := c.EncryptBlock([16]byte(blocks[i]))
+		b := primitives.FromState(cipherBlock)
+		copy(r[i*16:], b[:])
+	})
+
+	return r, nil
 }
 
-func (a *AES) encryptCBC(plainText []byte, iv []byte) []byte {
-	blocks := createBlocks(plainText)
+func (a *AES) encryptCBC(plainText []byte, iv []byte) ([]byte, error) {
+	blocks, err := a.createBlocks(plainText)
+	if err != nil {
+		return nil, err
+	}
 
 	if len(iv) != 16 {
-		panic("IV must have 16 bytes")
+		return nil, ErrInvalidIV
 	}
 
 	r := make([]byte, 0)
@@ -140,58 +340,119 @@ func (a *AES) encryptCBC(plainText []byte, iv []byte) []byte {
 		block = xorBytes(block, previousCipherBlock)
 		cipherBlock := a.EncryptBlock([16]byte(block))
 
-		c := convertMatrixToArray(cipherBlock)
+		c := primitives.FromState(cipherBlock)
 		s := c[:]
 		r = append(r, s...)
 
 		previousCipherBlock = s
 	}
 
-	return append(iv, r...)
+	return append(iv, r...), nil
 }
 
-func (a *AES) encryptCTR(plainText []byte, counter []byte) []byte {
+func (a *AES) encryptCTR(plainText []byte, counter []byte) ([]byte, error) {
 	blocks := split(plainText)
 
-	r := make([]byte, len(counter))
+	r := make([]byte, len(counter)+len(plainText))
 	copy(r, counter)
+	offset := len(counter)
+
+	// incrementCounter changes the slice in place, copy it so the caller's nonce is left alone
+	counter = append([]byte{}, counter...)
+
+	// counters are computed upfront so the blocks can be encrypted in any order
+	counters := make([][]byte, len(blocks))
+	for i := range blocks {
+		counters[i] = append([]byte{}, counter...)
+		// exhausted only matters when there is another block
+		if err := incrementCounter(counter, a.counterLayout); err != nil && i < len(blocks)-1 {
+			return nil, err
+		}
+	}
 
-	for _, block := range blocks {
-		cipherBlock := a.EncryptBlock([16]byte(counter))
+	if a.bitsliced {
+		a.bitslicedCTR(blocks, counters, r[offset:])
+		return r, nil
+	}
 
-		c := convertMatrixToArray(cipherBlock)
-		s := c[:]
+	a.forEachBlock(len(blocks), func(c *AES, i int) {
+		cipherBlock := c.EncryptBlock([16]byte(counters[i]))
+
+		b := primitives.FromState(cipherBlock)
+
+		xored := xorBytes(blocks[i], b[:])
+		copy(r[offset+i*16:], xored)
+	})
 
-		xored := xorBytes(block, s)
-		r = append(r, xored...)
+	return r, nil
+}
 
-		counter = addOneToByteSlice(counter)
+// bitslicedCTR encrypts the counters 8 at a time and XORs them with the blocks into out.
+func (a *AES) bitslicedCTR(blocks, counters [][]byte, out []byte) {
+	a.expandKeys()
+	if a.slices == nil {
+		a.slices = newBitsliced(a.roundKeys)
 	}
 
-	return r
+	a.forEachBlock((len(blocks)+7)/8, func(c *AES, n int) {
+		var keystream [8][16]byte
+		for i := range keystream {
+			if 8*n+i < len(counters) {
+				keystream[i] = [16]byte(counters[8*n+i])
+			}
+		}
+		in := keystream
+
+		c.slices.encrypt8(&keystream)
+
+		for i := 0; i < 8 && 8*n+i < len(blocks); i++ {
+			if c.crossCheck != nil {
+				c.checkEncryptBlock(in[i], primitives.ToState(keystream[i]))
+			}
+			copy(out[(8*n+i)*16:], xorBytes(blocks[8*n+i], keystream[i][:]))
+		}
+	})
 }
 
-// Careful that's a really weak implementation just for learning purposes.
-// A proper implementation would check for overflows.
-// This NIST document explains in details how to do it on Appendix B.1:
-// https://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38a.pdf
-func addOneToByteSlice(b []byte) []byte {
-	for i := len(b) - 1; i >= 0; i-- {
-		if b[i] < 255 {
-			b[i]++
-			return b
+// forEachBlock calls fn for every block index. When parallelism is enabled the indexes are spread
+// across goroutines, each one with its own clone of the cipher because EncryptBlock isn't thread safe.
+func (a *AES) forEachBlock(n int, fn func(c *AES, i int)) {
+	workers := min(a.parallelism, n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(a, i)
 		}
-		b[i] = 0 // Reset current byte to 0 if it was 255
+		return
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(c *AES, w int) {
+			defer wg.Done()
+			for i := w; i < n; i += workers {
+				fn(c, i)
+			}
+		}(a.clone(), w)
+	}
+	wg.Wait()
+}
+
+// randomBlock returns 16 random bytes to be used as IV or nonce.
+// key.Bit128 would do the same but it panics if the random source fails.
+func (a *AES) randomBlock() ([]byte, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(a.rand, b); err != nil {
+		return nil, err
 	}
-	// If we overflowed all bytes, we need to add a new byte at the start
-	return append([]byte{1}, b...)
+	return b, nil
 }
 
 func (a *AES) decryptCBC(encrypted []byte, iv []byte) ([]byte, error) {
 	blocks := split(encrypted)
 
 	if len(iv) != 16 {
-		panic("IV must have 16 bytes")
+		return nil, ErrInvalidIV
 	}
 
 	r := make([]byte, 0)
@@ -199,7 +460,7 @@ func (a *AES) decryptCBC(encrypted []byte, iv []byte) ([]byte, error) {
 
 	for _, block := range blocks {
 		cipherBlock := a.DecryptBlock([16]byte(block))
-		c := convertMatrixToArray(cipherBlock)
+		c := primitives.FromState(cipherBlock)
 		s := c[:]
 
 		s = xorBytes(s, previousCipherBlock)
@@ -208,16 +469,34 @@ func (a *AES) decryptCBC(encrypted []byte, iv []byte) ([]byte, error) {
 		previousCipherBlock = block
 	}
 
-	b, err := RemovePadding(r)
-	if err != nil {
-		return nil, err
+	return a.removePadding(r)
+}
+
+// createBlocks splits the plaintext in blocks of 16 bytes, padding the last one if needed.
+func (a *AES) createBlocks(b []byte) ([][]byte, error) {
+	if a.padding == NoPadding {
+		if len(b)%16 != 0 {
+			return nil, ErrNotBlockAligned
+		}
+		return split(b), nil
 	}
+	return createBlocks(b), nil
+}
 
-	return b, nil
+func (a *AES) removePadding(b []byte) ([]byte, error) {
+	if a.padding == NoPadding {
+		return b, nil
+	}
+	return RemovePadding(b)
 }
 
 func createBlocks(b []byte) [][]byte {
 	blocks := split(b)
+	if len(blocks) == 0 {
+		// empty plaintext is a full block of padding
+		return [][]byte{padding(nil)}
+	}
+
 	last := blocks[len(blocks)-1]
 	paddedLast := padding(last)
 
@@ -233,50 +512,47 @@ func createBlocks(b []byte) [][]byte {
 	return blocks
 }
 
-func (a *AES) decryptECB(encrypted []byte) []byte {
+func (a *AES) decryptECB(encrypted []byte) ([]byte, error) {
 	blocks := split(encrypted)
 
-	r := make([]byte, 0)
-	for _, block := range blocks {
-		cipherBlock := a.DecryptBlock([16]byte(block))
-		c := convertMatrixToArray(cipherBlock)
-		s := c[:]
-		r = append(r, s...)
-	}
-
-	// ignoring error to make the code simpler
-	b, err := RemovePadding(r)
-	if err != nil {
-		panic(err)
-	}
+	r := make([]byte, len(blocks)*16)
+	a.forEachBlock(len(blocks), func(c *AES, i int) {
+		cipherBlock := c.DecryptBlock([16]byte(blocks[i]))
+		b := primitives.FromState(cipherBlock)
+		copy(r[i*16:], b[:])
+	})
 
-	return b
+	return a.removePadding(r)
 }
 
 func RemovePadding(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, ErrInvalidPadding
+	}
+
 	blocks := split(b)
 
 	last := blocks[len(blocks)-1]
-	p := b[len(b)-1]
+	p := int(b[len(b)-1])
 
 	// padding byte must be between 1 and 16
 	// 0 is invalid because it would mean no padding which means the padding byte should be 16
-	if p == 0 || int(p) > len(last) {
-		return nil, errors.New("Invalid padding")
-	}
+	valid := subtle.ConstantTimeLessOrEq(1, p) & subtle.ConstantTimeLessOrEq(p, len(last))
 
-	begin := len(last) - int(p)
-	if begin < 0 {
-		return nil, errors.New("Invalid padding")
+	// every byte of the block is checked, stopping at the first bad one would tell how much of the
+	// padding was right, which is what padding oracle attacks need
+	begin := len(last) - p
+	for i := range last {
+		inPadding := subtle.ConstantTimeLessOrEq(begin, i)
+		same := subtle.ConstantTimeByteEq(last[i], byte(p))
+		valid &= subtle.ConstantTimeSelect(inPadding, same, 1)
 	}
 
-	for i := begin; i < len(last); i++ {
-		if last[i] != p {
-			return nil, errors.New("Invalid padding")
-		}
+	if valid != 1 {
+		return nil, ErrInvalidPadding
 	}
 
-	last = last[:len(last)-int(p)]
+	last = last[:len(last)-p]
 	blocks[len(blocks)-1] = last
 
 	return join(blocks), nil
@@ -326,24 +602,47 @@ func padding(block []byte) []byte {
 }
 
 func (a *AES) EncryptBlock(b [16]byte) [4][4]byte {
-	a.generateAllKeys()
+	a.expandKeys()
+
+	if a.wordRounds() {
+		block := primitives.ToState(a.encryptBlockWords(b))
+		if a.crossCheck != nil {
+			a.checkEncryptBlock(b, block)
+		}
+		return block
+	}
+
 	a.currentRound = 0
 
-	block := convertArrayToMatrix(b)
+	block := primitives.ToState(b)
 
 	for j := 0; j <= a.rounds; j++ {
+		block = a.injectFault(block)
 		block = a.encryptRound(block)
 		a.nextRound()
 	}
 
+	if a.crossCheck != nil {
+		a.checkEncryptBlock(b, block)
+	}
+
 	return block
 }
 
 func (a *AES) DecryptBlock(b [16]byte) [4][4]byte {
-	a.generateAllKeys()
+	a.expandKeys()
+
+	if a.wordRounds() {
+		block := primitives.ToState(a.decryptBlockWords(b))
+		if a.crossCheck != nil {
+			a.checkDecryptBlock(b, block)
+		}
+		return block
+	}
+
 	a.currentRound = a.rounds
 
-	block := convertArrayToMatrix(b)
+	block := primitives.ToState(b)
 
 	// Decrypting works in reverse order
 	for j := a.rounds; j >= 0; j-- {
@@ -351,157 +650,126 @@ func (a *AES) DecryptBlock(b [16]byte) [4][4]byte {
 		a.previousRound()
 	}
 
+	if a.crossCheck != nil {
+		a.checkDecryptBlock(b, block)
+	}
+
 	return block
 }
 
 func (a *AES) encryptRound(state [4][4]byte) [4][4]byte {
-	key := convertArrayToMatrix(a.roundKeys[a.currentRound])
+	key := primitives.ToState(a.roundKeys[a.currentRound])
 
 	if a.currentRound == 0 {
-		r := addRoundKey(state, key)
+		r := primitives.AddRoundKey(state, key)
+		if a.tracer != nil {
+			a.tracer.AfterAddRoundKey(a.currentRound, primitives.FromState(r), a.roundKeys[a.currentRound])
+		}
 		return r
 	}
 
-	r := subMatrix(state)
-	r = shiftRows(r)
+	r := a.subMatrix(state)
+	if a.tracer != nil {
+		a.tracer.AfterSubBytes(a.currentRound, primitives.FromState(r))
+	}
+
+	r = primitives.ShiftRows(r)
+	if a.tracer != nil {
+		a.tracer.AfterShiftRows(a.currentRound, primitives.FromState(r))
+	}
 
 	if a.currentRound < a.rounds {
 		// mix columns don't apply to the last round
-		r = mixColumns(r)
+		r = a.mixColumns(r)
+		if a.tracer != nil {
+			a.tracer.AfterMixColumns(a.currentRound, primitives.FromState(r))
+		}
 	}
 
-	r = addRoundKey(r, key)
+	r = primitives.AddRoundKey(r, key)
+	if a.tracer != nil {
+		a.tracer.AfterAddRoundKey(a.currentRound, primitives.FromState(r), a.roundKeys[a.currentRound])
+	}
 
 	return r
 }
 
 func (a *AES) decryptRound(state [4][4]byte) [4][4]byte {
-	key := convertArrayToMatrix(a.roundKeys[a.currentRound])
+	key := primitives.ToState(a.roundKeys[a.currentRound])
 
 	if a.currentRound == a.rounds {
-		r := addRoundKey(state, key)
+		r := primitives.AddRoundKey(state, key)
 		return r
 	}
 
-	r := invShiftRows(state)
-	r = invSubMatrix(r)
-	r = addRoundKey(r, key)
+	r := primitives.InvShiftRows(state)
+	r = a.invSubMatrix(r)
+	r = primitives.AddRoundKey(r, key)
 
 	if a.currentRound > 0 {
 		// invmix columns don't apply to the last round
-		r = invMixColumns(r)
+		r = a.invMixColumns(r)
 	}
 
 	return r
 }
 
-func addRoundKey(state [4][4]byte, key [4][4]byte) [4][4]byte {
-	return xorMatrix(state, key)
+func (a *AES) subMatrix(word [4][4]byte) [4][4]byte {
+	if a.constantTime {
+		return subMatrixConstantTime(word, primitives.SBox())
+	}
+	return primitives.SubBytes(word)
 }
 
-func subMatrix(word [4][4]byte) [4][4]byte {
-	var s [4][4]byte
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			s[i][j] = sBox()[word[i][j]]
-		}
+func (a *AES) invSubMatrix(word [4][4]byte) [4][4]byte {
+	if a.constantTime {
+		return subMatrixConstantTime(word, primitives.InvSBox())
 	}
-	return s
+	return primitives.InvSubBytes(word)
 }
 
-func invSubMatrix(word [4][4]byte) [4][4]byte {
-	var s [4][4]byte
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			s[i][j] = invSBox()[word[i][j]]
-		}
+// mixColumns avoids the multiplication tables in constant time mode, they are indexed by the state too.
+func (a *AES) mixColumns(state [4][4]byte) [4][4]byte {
+	if a.constantTime {
+		return primitives.MixColumnsGMul(state)
 	}
-	return s
+	return primitives.MixColumns(state)
 }
 
-func shiftRows(state [4][4]byte) [4][4]byte {
-	var s [4][4]byte
-	s[0] = state[0]
-
-	s[1] = [4]byte{state[1][1], state[1][2], state[1][3], state[1][0]}
-	s[2] = [4]byte{state[2][2], state[2][3], state[2][0], state[2][1]}
-	s[3] = [4]byte{state[3][3], state[3][0], state[3][1], state[3][2]}
-
-	return s
+func (a *AES) invMixColumns(state [4][4]byte) [4][4]byte {
+	if a.constantTime {
+		return primitives.InvMixColumnsGMul(state)
+	}
+	return primitives.InvMixColumns(state)
 }
 
-func invShiftRows(state [4][4]byte) [4][4]byte {
+// subMatrixConstantTime reads every entry of the table for every byte, keeping only the one we want.
+// This way the memory accesses are always the same regardless of the state.
+func subMatrixConstantTime(word [4][4]byte, table [256]byte) [4][4]byte {
 	var s [4][4]byte
-	s[0] = state[0]
-
-	s[1] = [4]byte{state[1][3], state[1][0], state[1][1], state[1][2]}
-	s[2] = [4]byte{state[2][2], state[2][3], state[2][0], state[2][1]}
-	s[3] = [4]byte{state[3][1], state[3][2], state[3][3], state[3][0]}
-
-	return s
-}
-
-func convertArrayToMatrix(b [16]byte) [4][4]byte {
-	var r [4][4]byte
-
-	r[0] = [4]byte{b[0], b[4], b[8], b[12]}
-	r[1] = [4]byte{b[1], b[5], b[9], b[13]}
-	r[2] = [4]byte{b[2], b[6], b[10], b[14]}
-	r[3] = [4]byte{b[3], b[7], b[11], b[15]}
-
-	return r
-}
-
-func convertMatrixToArray(m [4][4]byte) [16]byte {
-	var r [16]byte
-	r[0] = m[0][0]
-	r[1] = m[1][0]
-	r[2] = m[2][0]
-	r[3] = m[3][0]
-	r[4] = m[0][1]
-	r[5] = m[1][1]
-	r[6] = m[2][1]
-	r[7] = m[3][1]
-	r[8] = m[0][2]
-	r[9] = m[1][2]
-	r[10] = m[2][2]
-	r[11] = m[3][2]
-	r[12] = m[0][3]
-	r[13] = m[1][3]
-	r[14] = m[2][3]
-	r[15] = m[3][3]
-	return r
-}
-
-func rotWord(word [4]byte) []byte {
-	newWord := make([]byte, 4)
-	newWord[0] = word[1]
-	newWord[1] = word[2]
-	newWord[2] = word[3]
-	newWord[3] = word[0]
-
-	return newWord
-}
-
-func subWord(word [4]byte) []byte {
-	s := make([]byte, 4)
 	for i := 0; i < 4; i++ {
-		s[i] = sBox()[word[i]]
+		for j := 0; j < 4; j++ {
+			s[i][j] = lookupConstantTime(table, word[i][j])
+		}
 	}
 	return s
 }
 
-func rcon(round int, word [4]byte) []byte {
-	r := rconTable[round-1] // this is to avoid overflows
-	return xor(word, r)
+func lookupConstantTime(table [256]byte, x byte) byte {
+	var r byte
+	for i := 0; i < 256; i++ {
+		mask := byte(subtle.ConstantTimeByteEq(byte(i), x)) * 0xff
+		r |= table[i] & mask
+	}
+	return r
 }
 
-func xor(a, b [4]byte) []byte {
-	x := make([]byte, 4)
+func subWordConstantTime(word [4]byte) [4]byte {
+	var s [4]byte
 	for i := 0; i < 4; i++ {
-		x[i] = a[i] ^ b[i]
+		s[i] = lookupConstantTime(primitives.SBox(), word[i])
 	}
-	return x
+	return s
 }
 
 func xorBytes(a, b []byte) []byte {
@@ -516,26 +784,3 @@ func xorBytes(a, b []byte) []byte {
 	}
 	return x
 }
-
-func xorMatrix(a, b [4][4]byte) [4][4]byte {
-	var x [4][4]byte
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			x[i][j] = a[i][j] ^ b[i][j]
-		}
-	}
-	return x
-}
-
-var rconTable = [10][4]byte{
-	{0x01, 0x00, 0x00, 0x00},
-	{0x02, 0x00, 0x00, 0x00},
-	{0x04, 0x00, 0x00, 0x00},
-	{0x08, 0x00, 0x00, 0x00},
-	{0x10, 0x00, 0x00, 0x00},
-	{0x20, 0x00, 0x00, 0x00},
-	{0x40, 0x00, 0x00, 0x00},
-	{0x80, 0x00, 0x00, 0x00},
-	{0x1B, 0x00, 0x00, 0x00},
-	{0x36, 0x00, 0x00, 0x00},
-}
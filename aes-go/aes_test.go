@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
 )
 
 func TestEncryptBlock(t *testing.T) {
@@ -103,7 +104,7 @@ func TestEncryptBlock(t *testing.T) {
 			}
 
 			if output != test.expected {
-				a := convertMatrixToArray(output)
+				a := primitives.FromState(output)
 				fmt.Printf("Got: %02x\n", a)
 				fmt.Printf("Got: %02x\n", output)
 				fmt.Printf("Expected: %02x\n", test.expected)
@@ -174,6 +175,34 @@ func TestRemovePadding(t *testing.T) {
 
 			block: []byte{0x32, 0x43, 0xf6, 0x06},
 
+			error: true,
+		},
+		{
+			name: "bad first byte of the padding",
+
+			block: []byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0x03, 0x04, 0x04, 0x04},
+
+			error: true,
+		},
+		{
+			name: "bad middle byte of the padding",
+
+			block: []byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0x04, 0x04, 0x05, 0x04},
+
+			error: true,
+		},
+		{
+			name: "padding larger than the block",
+
+			block: []byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0xe0, 0x37, 0x07, 0x11},
+
+			error: true,
+		},
+		{
+			name: "empty block",
+
+			block: []byte{},
+
 			error: true,
 		},
 	}
@@ -254,16 +283,23 @@ func TestEncryptionECB(t *testing.T) {
 			key := key.NewKey([16]byte([]byte(test.key)))
 			aes := New(key)
 
-			var output []byte
 			var result string
 
 			if test.encryption {
-				output = aes.encryptECB([]byte(test.input))
+				output, err := aes.encryptECB([]byte(test.input))
+				if err != nil {
+					t.Errorf("Expected nil, got %v", err)
+					t.FailNow()
+				}
 				result = hex.EncodeToString(output)
 			} else {
 				b := make([]byte, len(test.input)/2)
 				hex.Decode(b, []byte(test.input))
-				output = aes.decryptECB(b)
+				output, err := aes.decryptECB(b)
+				if err != nil {
+					t.Errorf("Expected nil, got %v", err)
+					t.FailNow()
+				}
 				result = string(output)
 			}
 
@@ -345,11 +381,14 @@ func TestEncryptionCBC(t *testing.T) {
 			key := key.NewKey([16]byte([]byte(test.key)))
 			aes := New(key)
 
-			var output []byte
 			var result string
 
 			if test.encryption {
-				output = aes.encryptCBC([]byte(test.input), []byte(test.iv))
+				output, err := aes.encryptCBC([]byte(test.input), []byte(test.iv))
+				if err != nil {
+					t.Errorf("Expected nil, got %v", err)
+					t.FailNow()
+				}
 				result = hex.EncodeToString(output)
 			} else {
 				b := make([]byte, len(test.input)/2)
@@ -380,3 +419,129 @@ func TestEncryptionCBC(t *testing.T) {
 		})
 	}
 }
+
+type fakeKey struct {
+	material []byte
+}
+
+func (k fakeKey) GetBytes() []byte {
+	return k.material
+}
+
+func (k fakeKey) Len() int {
+	return len(k.material)
+}
+
+func (k fakeKey) Destroy() {}
+
+func (k fakeKey) Destroyed() bool {
+	return false
+}
+
+func (k fakeKey) Fingerprint() [32]byte {
+	return [32]byte{}
+}
+
+func TestAES256(t *testing.T) {
+	// FIPS-197 Appendix C.3
+	var material [32]byte
+	for i := range material {
+		material[i] = byte(i)
+	}
+	input := [16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+	expected := "8ea2b7ca516745bfeafc49904b496089"
+
+	for _, constantTime := range []bool{false, true} {
+		var opts []Option
+		if constantTime {
+			opts = append(opts, WithConstantTime())
+		}
+
+		a, err := NewCipher(key.NewKey256(material), opts...)
+		if err != nil {
+			t.Fatalf("Expected nil, got %v", err)
+		}
+		if a.Rounds() != 14 {
+			t.Errorf("Expected 14 rounds, got %d", a.Rounds())
+		}
+
+		got := primitives.FromState(a.EncryptBlock(input))
+		if hex.EncodeToString(got[:]) != expected {
+			t.Errorf("Got: %x, Expected: %s", got, expected)
+		}
+		if primitives.FromState(a.DecryptBlock(got)) != input {
+			t.Errorf("Expected decryption to return the input")
+		}
+	}
+}
+
+func TestNewCipher(t *testing.T) {
+	_, err := NewCipher(fakeKey{material: make([]byte, 24)})
+	if err != ErrUnsupportedKeySize {
+		t.Errorf("Expected %v, got %v", ErrUnsupportedKeySize, err)
+	}
+
+	aes, err := NewCipher(key.NewKey([16]byte([]byte("128bitsforkeysss"))))
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	_, err = aes.encryptCBC([]byte("short iv"), []byte("1234"))
+	if err != ErrInvalidIV {
+		t.Errorf("Expected %v, got %v", ErrInvalidIV, err)
+	}
+}
+
+func TestDestroy(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	aes := New(k)
+
+	encrypted, err := aes.Encrypt(CBC, []byte("Let's test if this is working!"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	// GetBytes panics once the key is destroyed
+	material := k.GetBytes()
+	aes.Destroy()
+
+	if !slices.Equal(material, make([]byte, 16)) {
+		t.Errorf("Key material was not wiped: %02x", material)
+	}
+
+	for _, roundKey := range aes.roundKeys {
+		if roundKey != [16]byte{} {
+			t.Errorf("Round key was not wiped: %02x", roundKey)
+		}
+	}
+
+	if _, err := aes.Encrypt(CBC, []byte("again")); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+
+	if _, err := aes.Decrypt(CBC, encrypted); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+
+	if _, err := NewCipher(k); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
+
+func TestCTRDoesNotModifyInput(t *testing.T) {
+	aes := New(key.NewKey([16]byte([]byte("128bitsforkeysss"))))
+
+	encrypted, err := aes.Encrypt(CTR, []byte("Let's test if this is working!"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	original := slices.Clone(encrypted)
+	if _, err := aes.Decrypt(CTR, encrypted); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+
+	if !slices.Equal(encrypted, original) {
+		t.Errorf("Decrypt changed the nonce. Got: %02x, Expected: %02x", encrypted[:16], original[:16])
+	}
+}
@@ -0,0 +1,78 @@
+package aesgo
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// EncryptBatch encrypts every plaintext like Encrypt and returns the ciphertexts in the same order.
+// The key schedule is expanded once for the whole batch instead of once per message, which is most
+// of the cost when the messages are small.
+//
+// With WithParallelism the messages (not the blocks) are spread across goroutines. IVs and nonces
+// are read upfront in order, so the output doesn't depend on the parallelism.
+func (a *AES) EncryptBatch(mode Mode, plaintexts [][]byte) ([][]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	ivSize, err := batchIVSize(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	ivs := make([]byte, len(plaintexts)*ivSize)
+	if _, err := io.ReadFull(a.rand, ivs); err != nil {
+		return nil, err
+	}
+
+	a.expandKeys()
+
+	encrypted := make([][]byte, len(plaintexts))
+	errs := make([]error, len(plaintexts))
+
+	workers := min(a.parallelism, len(plaintexts))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(c *AES, w int) {
+			defer wg.Done()
+
+			// the goroutines are per message, blocks of a message are encrypted sequentially
+			c.parallelism = 1
+
+			// Encrypt reads the IV from here, reusing the reader saves an allocation per message
+			iv := bytes.NewReader(nil)
+			c.rand = iv
+
+			for i := w; i < len(plaintexts); i += workers {
+				iv.Reset(ivs[i*ivSize : (i+1)*ivSize])
+				encrypted[i], errs[i] = c.Encrypt(mode, plaintexts[i])
+			}
+		}(a.clone(), w)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return encrypted, nil
+}
+
+// batchIVSize is how many random bytes Encrypt reads for each message.
+func batchIVSize(mode Mode) (int, error) {
+	switch mode {
+	case ECB:
+		return 0, nil
+	case CBC, CTR:
+		return 16, nil
+	case GCM:
+		return GCMNonceSize, nil
+	}
+	return 0, ErrInvalidMode
+}
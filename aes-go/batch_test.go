@@ -0,0 +1,125 @@
+package aesgo
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestEncryptBatch(t *testing.T) {
+	k := key.Bit128()
+
+	plaintexts := make([][]byte, 20)
+	for i := range plaintexts {
+		plaintexts[i] = bytes.Repeat([]byte{byte(i)}, i*3)
+	}
+
+	tests := []struct {
+		name string
+
+		mode        Mode
+		parallelism int
+	}{
+		{
+			name: "ECB",
+
+			mode:        ECB,
+			parallelism: 1,
+		},
+		{
+			name: "CBC",
+
+			mode:        CBC,
+			parallelism: 1,
+		},
+		{
+			name: "CTR in parallel",
+
+			mode:        CTR,
+			parallelism: 4,
+		},
+		{
+			name: "GCM in parallel",
+
+			mode:        GCM,
+			parallelism: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			batch, _ := NewCipher(k, WithRandReader(rand.New(rand.NewSource(1))), WithParallelism(test.parallelism), WithInsecureModes())
+			single, _ := NewCipher(k, WithRandReader(rand.New(rand.NewSource(1))), WithInsecureModes())
+
+			encrypted, err := batch.EncryptBatch(test.mode, plaintexts)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			if len(encrypted) != len(plaintexts) {
+				t.Fatalf("Expected %d ciphertexts, got %d", len(plaintexts), len(encrypted))
+			}
+
+			// the same as encrypting one by one, with the IVs read in the same order
+			for i, p := range plaintexts {
+				expected, err := single.Encrypt(test.mode, p)
+				if err != nil {
+					t.Fatalf("Error encrypting: %s", err)
+				}
+				if !bytes.Equal(encrypted[i], expected) {
+					t.Errorf("Message %d: Got: %x, Expected: %x", i, encrypted[i], expected)
+				}
+
+				decrypted, err := single.Decrypt(test.mode, encrypted[i])
+				if err != nil {
+					t.Fatalf("Error decrypting message %d: %s", i, err)
+				}
+				if !bytes.Equal(decrypted, p) {
+					t.Errorf("Message %d: Got: %x, Expected: %x", i, decrypted, p)
+				}
+			}
+		})
+	}
+}
+
+func TestEncryptBatchBudget(t *testing.T) {
+	b := NewBudget(Limits{Messages: 3})
+	a, _ := NewCipher(key.Bit128(), WithBudget(b), WithParallelism(2))
+
+	if _, err := a.EncryptBatch(GCM, [][]byte{{1}, {2}}); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if u := b.Usage(); u.Messages != 2 || u.Bytes != 2 {
+		t.Errorf("Expected 2 messages and 2 bytes, got %+v", u)
+	}
+
+	if _, err := a.EncryptBatch(GCM, [][]byte{{1}, {2}}); err != ErrBudgetExhausted {
+		t.Errorf("Expected %v, got %v", ErrBudgetExhausted, err)
+	}
+}
+
+func TestEncryptBatchErrors(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+	if encrypted, err := a.EncryptBatch(CBC, nil); err != nil || len(encrypted) != 0 {
+		t.Errorf("Expected an empty batch, got %d ciphertexts and %v", len(encrypted), err)
+	}
+
+	if _, err := a.EncryptBatch(Mode(10), [][]byte{{1}}); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// not enough randomness for the second IV
+	a, _ = NewCipher(key.Bit128(), WithRandReader(bytes.NewReader(make([]byte, 20))))
+	if _, err := a.EncryptBatch(CBC, [][]byte{{1}, {2}}); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	k := key.Bit128()
+	a, _ = NewCipher(k)
+	k.Destroy()
+	if _, err := a.EncryptBatch(CBC, [][]byte{{1}}); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
@@ -0,0 +1,128 @@
+package aesgo
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+func TestBitslicedSubBytes(t *testing.T) {
+	// the 256 inputs fill two sets of 8 blocks
+	for half := 0; half < 2; half++ {
+		var blocks [8][16]byte
+		for k := range blocks {
+			for i := range blocks[k] {
+				blocks[k][i] = byte(half*128 + k*16 + i)
+			}
+		}
+
+		s := pack(&blocks)
+		s.subBytes()
+		unpack(&s, &blocks)
+
+		sbox := primitives.SBox()
+		for k := range blocks {
+			for i, got := range blocks[k] {
+				x := half*128 + k*16 + i
+				if got != sbox[x] {
+					t.Errorf("S(%02x). Expected %02x, got %02x", x, sbox[x], got)
+				}
+			}
+		}
+	}
+}
+
+func TestBitslicedEncrypt(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, size := range []int{16, 32} {
+		for _, rounds := range []int{1, 2, 10, 14} {
+			k, _ := key.Random(size, key.WithRandReader(r))
+			if rounds > k.Len()/4+6 {
+				continue
+			}
+			a, _ := NewWithRounds(k, rounds)
+			b := newBitsliced(a.RoundKeys())
+
+			var blocks [8][16]byte
+			for i := range blocks {
+				r.Read(blocks[i][:])
+			}
+			in := blocks
+
+			b.encrypt8(&blocks)
+
+			for i := range blocks {
+				if expected := primitives.FromState(a.EncryptBlock(in[i])); blocks[i] != expected {
+					t.Errorf("AES-%d with %d rounds, block %d. Expected %x, got %x", size*8, rounds, i, expected, blocks[i])
+				}
+			}
+		}
+	}
+}
+
+func TestBitslicedCTR(t *testing.T) {
+	k := key.Bit128()
+	r := rand.New(rand.NewSource(1))
+
+	for _, parallelism := range []int{1, 3} {
+		// shorter than 8 blocks, exactly 8, and partial groups of 8
+		for _, size := range []int{0, 5, 128, 200, 1000} {
+			plaintext := make([]byte, size)
+			r.Read(plaintext)
+			nonce := make([]byte, 16)
+			r.Read(nonce)
+
+			bitsliced, _ := NewCipher(k, WithBitsliced(), WithParallelism(parallelism), WithCrossCheck(), WithRandReader(bytes.NewReader(nonce)))
+			scalar, _ := NewCipher(k, WithRandReader(bytes.NewReader(nonce)))
+
+			got, err := bitsliced.Encrypt(CTR, plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			expected, _ := scalar.Encrypt(CTR, plaintext)
+			if !bytes.Equal(got, expected) {
+				t.Errorf("%d bytes with parallelism %d. Got: %x, Expected: %x", size, parallelism, got, expected)
+			}
+
+			decrypted, err := bitsliced.Decrypt(CTR, got)
+			if err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("%d bytes with parallelism %d. Got: %x, Expected: %x (%v)", size, parallelism, decrypted, plaintext, err)
+			}
+		}
+	}
+}
+
+func TestBitslicedInvalid(t *testing.T) {
+	if _, err := NewCipher(key.Bit128(), WithBitsliced(), WithTracer(nopTracer{})); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+	if _, err := NewCipher(key.Bit128(), WithBitsliced(), WithFault(Fault{Round: 9})); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+}
+
+// go test -bench CTR ./aes-go
+func BenchmarkCTR(b *testing.B) {
+	benchmarkCTR(b)
+}
+
+func BenchmarkCTRBitsliced(b *testing.B) {
+	benchmarkCTR(b, WithBitsliced())
+}
+
+func BenchmarkCTRConstantTime(b *testing.B) {
+	benchmarkCTR(b, WithConstantTime())
+}
+
+func benchmarkCTR(b *testing.B, opts ...Option) {
+	a, _ := NewCipher(key.Bit128(), opts...)
+	plaintext := make([]byte, 4096)
+	b.SetBytes(int64(len(plaintext)))
+	for i := 0; i < b.N; i++ {
+		a.Encrypt(CTR, plaintext)
+	}
+}
@@ -0,0 +1,52 @@
+package aesgo
+
+import (
+	"crypto/cipher"
+	"sync"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// block adapts AES to crypto/cipher.Block so it can be used by constructions that only need
+// the raw block cipher, like key wrapping. The mutex is needed because EncryptBlock keeps state.
+type block struct {
+	mu sync.Mutex
+	a  *AES
+}
+
+func NewBlock(k key.Key) (cipher.Block, error) {
+	a, err := NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return &block{a: a}, nil
+}
+
+func (b *block) BlockSize() int {
+	return 16
+}
+
+func (b *block) Encrypt(dst, src []byte) {
+	if len(src) < 16 || len(dst) < 16 {
+		panic("aesgo: input not full block")
+	}
+
+	b.mu.Lock()
+	c := primitives.FromState(b.a.EncryptBlock([16]byte(src)))
+	b.mu.Unlock()
+
+	copy(dst, c[:])
+}
+
+func (b *block) Decrypt(dst, src []byte) {
+	if len(src) < 16 || len(dst) < 16 {
+		panic("aesgo: input not full block")
+	}
+
+	b.mu.Lock()
+	c := primitives.FromState(b.a.DecryptBlock([16]byte(src)))
+	b.mu.Unlock()
+
+	copy(dst, c[:])
+}
@@ -0,0 +1,90 @@
+package aesgo
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrBudgetExhausted = errors.New("Key usage limit reached, the key must be replaced")
+
+// Limits caps how much a single key encrypts. Past these numbers the security bounds of the modes
+// don't hold anymore, e.g. random 96 bit GCM nonces start colliding. Zero means no limit.
+type Limits struct {
+	Messages uint64
+	Bytes    uint64
+}
+
+// DefaultLimits returns the usual limits for mode:
+//   - GCM: 2^32 messages, the limit for random nonces in NIST SP 800-38D section 8.3.
+//   - CBC and CTR: 2^48 messages and 2^48 blocks. Random 128 bit IVs and the keystream blocks
+//     stay far from the 2^64 birthday bound.
+//   - ECB: the same as CBC, not that ECB should be used for anything.
+func DefaultLimits(mode Mode) Limits {
+	if mode == GCM {
+		return Limits{Messages: 1 << 32, Bytes: 1 << 48 * 16}
+	}
+	return Limits{Messages: 1 << 48, Bytes: 1 << 48 * 16}
+}
+
+type Usage struct {
+	Messages uint64
+	Bytes    uint64
+}
+
+// Budget counts what was encrypted with one key. Share the same Budget between every cipher
+// created with that key, see WithBudget. It is safe for concurrent use.
+type Budget struct {
+	mu     sync.Mutex
+	limits Limits
+	used   Usage
+}
+
+func NewBudget(limits Limits) *Budget {
+	return &Budget{limits: limits}
+}
+
+// Usage returns the counters so far, for monitoring.
+func (b *Budget) Usage() Usage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.used
+}
+
+func (b *Budget) Limits() Limits {
+	return b.limits
+}
+
+// Exhausted is true once there is no room for another message.
+func (b *Budget) Exhausted() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.exceeds(1, 0)
+}
+
+// spend counts a message and its bytes, or returns ErrBudgetExhausted and counts nothing.
+func (b *Budget) spend(messages, bytes uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.exceeds(messages, bytes) {
+		return ErrBudgetExhausted
+	}
+	b.used.Messages += messages
+	b.used.Bytes += bytes
+	return nil
+}
+
+func (b *Budget) exceeds(messages, bytes uint64) bool {
+	return b.limits.Messages != 0 && b.used.Messages+messages > b.limits.Messages ||
+		b.limits.Bytes != 0 && b.used.Bytes+bytes > b.limits.Bytes
+}
+
+// spend is a no-op without a budget.
+func (a *AES) spend(messages, bytes int) error {
+	if a.budget == nil {
+		return nil
+	}
+	return a.budget.spend(uint64(messages), uint64(bytes))
+}
@@ -0,0 +1,138 @@
+package aesgo
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name string
+
+		mode   Mode
+		limits Limits
+		sizes  []int
+
+		// how many of the messages are encrypted before the limit
+		allowed int
+		usage   Usage
+	}{
+		{name: "message limit CBC", mode: CBC, limits: Limits{Messages: 2}, sizes: []int{10, 10, 10}, allowed: 2, usage: Usage{Messages: 2, Bytes: 20}},
+		{name: "message limit GCM", mode: GCM, limits: Limits{Messages: 2}, sizes: []int{10, 10, 10}, allowed: 2, usage: Usage{Messages: 2, Bytes: 20}},
+		{name: "byte limit CTR", mode: CTR, limits: Limits{Bytes: 100}, sizes: []int{60, 40, 1}, allowed: 2, usage: Usage{Messages: 2, Bytes: 100}},
+		{name: "too big is not counted", mode: ECB, limits: Limits{Bytes: 100}, sizes: []int{60, 50, 50}, allowed: 1, usage: Usage{Messages: 1, Bytes: 60}},
+		{name: "no limits", mode: CBC, sizes: []int{1000, 1000, 1000}, allowed: 3, usage: Usage{Messages: 3, Bytes: 3000}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := NewBudget(test.limits)
+			a, _ := NewCipher(key.Bit128(), WithBudget(b), WithInsecureModes())
+
+			for i, size := range test.sizes {
+				_, err := a.Encrypt(test.mode, make([]byte, size))
+				if i < test.allowed && err != nil {
+					t.Fatalf("Message %d: Error encrypting: %s", i, err)
+				}
+				if i >= test.allowed && err != ErrBudgetExhausted {
+					t.Errorf("Message %d: Expected %v, got %v", i, ErrBudgetExhausted, err)
+				}
+			}
+
+			if u := b.Usage(); u != test.usage {
+				t.Errorf("Expected %v, got %v", test.usage, u)
+			}
+		})
+	}
+}
+
+// Decryption is free, it doesn't use up nonces or IVs.
+func TestBudgetDecrypt(t *testing.T) {
+	k := key.Bit128()
+	b := NewBudget(Limits{Messages: 1})
+	a, _ := NewCipher(k, WithBudget(b))
+
+	encrypted, err := a.Encrypt(GCM, []byte("once"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if !b.Exhausted() {
+		t.Errorf("Expected the budget to be exhausted")
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := a.Decrypt(GCM, encrypted); err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+	}
+	if _, err := a.SealGCM(make([]byte, GCMNonceSize), nil, nil); err != ErrBudgetExhausted {
+		t.Errorf("Expected %v, got %v", ErrBudgetExhausted, err)
+	}
+}
+
+func TestBudgetStream(t *testing.T) {
+	b := NewBudget(Limits{Bytes: 32})
+	a, _ := NewCipher(key.Bit128(), WithBudget(b))
+
+	w, err := a.NewEncryptWriter(&bytes.Buffer{}, &Ciphertext{Mode: CTR})
+	if err != nil {
+		t.Fatalf("Error creating writer: %s", err)
+	}
+	if _, err := w.Write(make([]byte, 32)); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	if _, err := w.Write(make([]byte, 1)); err != ErrBudgetExhausted {
+		t.Errorf("Expected %v, got %v", ErrBudgetExhausted, err)
+	}
+
+	if u := b.Usage(); u != (Usage{Messages: 1, Bytes: 32}) {
+		t.Errorf("Expected %v, got %v", Usage{Messages: 1, Bytes: 32}, u)
+	}
+}
+
+// Every cipher using the key shares the budget.
+func TestBudgetShared(t *testing.T) {
+	k := key.Bit128()
+	b := NewBudget(Limits{Messages: 100})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 200)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a, _ := NewCipher(k, WithBudget(b))
+			for j := 0; j < 50; j++ {
+				_, err := a.Encrypt(GCM, []byte("message"))
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	exhausted := 0
+	for err := range errs {
+		if err == ErrBudgetExhausted {
+			exhausted++
+		}
+	}
+	if exhausted != 100 {
+		t.Errorf("Expected %v, got %v", 100, exhausted)
+	}
+	if u := b.Usage(); u.Messages != 100 {
+		t.Errorf("Expected %v, got %v", 100, u.Messages)
+	}
+}
+
+func TestDefaultLimits(t *testing.T) {
+	if l := DefaultLimits(GCM); l.Messages != 1<<32 {
+		t.Errorf("Expected %v, got %v", uint64(1<<32), l.Messages)
+	}
+	if l := DefaultLimits(CBC); l.Messages <= DefaultLimits(GCM).Messages {
+		t.Errorf("Expected CBC to allow more messages than GCM, got %v", l.Messages)
+	}
+}
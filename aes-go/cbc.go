@@ -0,0 +1,99 @@
+package aesgo
+
+import (
+	"errors"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var ErrFinished = errors.New("Encryption already finished")
+
+// CBCEncrypter encrypts a CBC message that arrives in pieces, e.g. from a socket. It keeps the
+// last ciphertext block (the chaining value) and the bytes that don't fill a block yet between calls.
+//
+// Everything returned by Update and Finish put together is the same as Encrypt(CBC), IV first,
+// so it can be decrypted with Decrypt(CBC).
+type CBCEncrypter struct {
+	a    *AES
+	iv   []byte
+	prev []byte
+	// plaintext waiting for a full block
+	buf      []byte
+	started  bool
+	finished bool
+}
+
+// NewCBCEncrypter generates the IV with the random source of the cipher.
+func (a *AES) NewCBCEncrypter() (*CBCEncrypter, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	iv, err := a.randomBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// the bytes are counted as they come in
+	if err := a.spend(1, 0); err != nil {
+		return nil, err
+	}
+
+	return &CBCEncrypter{a: a, iv: iv, prev: iv}, nil
+}
+
+// Update encrypts the full blocks it has so far. The first call also returns the IV.
+func (e *CBCEncrypter) Update(p []byte) ([]byte, error) {
+	if e.finished {
+		return nil, ErrFinished
+	}
+	if err := e.a.spend(0, len(p)); err != nil {
+		return nil, err
+	}
+
+	e.buf = append(e.buf, p...)
+	full := len(e.buf) / 16 * 16
+
+	r := e.encryptBlocks(e.buf[:full])
+	e.buf = append(e.buf[:0], e.buf[full:]...)
+
+	return r, nil
+}
+
+// Finish pads and encrypts what is left. With NoPadding the total length must be a multiple of 16.
+func (e *CBCEncrypter) Finish() ([]byte, error) {
+	if e.finished {
+		return nil, ErrFinished
+	}
+
+	last, err := e.a.createBlocks(e.buf)
+	if err != nil {
+		return nil, err
+	}
+	e.finished = true
+	clear(e.buf)
+
+	return e.encryptBlocks(join(last)), nil
+}
+
+func (e *CBCEncrypter) IV() []byte {
+	return append([]byte{}, e.iv...)
+}
+
+func (e *CBCEncrypter) encryptBlocks(b []byte) []byte {
+	r := make([]byte, 0, 16+len(b))
+	if !e.started {
+		r = append(r, e.iv...)
+		e.started = true
+	}
+
+	for _, block := range split(b) {
+		block = xorBytes(block, e.prev)
+		c := primitives.FromState(e.a.EncryptBlock([16]byte(block)))
+		r = append(r, c[:]...)
+		e.prev = c[:]
+	}
+
+	return r
+}
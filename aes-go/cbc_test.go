@@ -0,0 +1,101 @@
+package aesgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCBCEncrypter(t *testing.T) {
+	k := key.Bit128()
+	iv := bytes.Repeat([]byte{0x24}, 16)
+
+	for _, size := range []int{0, 1, 15, 16, 17, 32, 100, 1000} {
+		plaintext := bytes.Repeat([]byte("0123456789abcdefghij"), size/20+1)[:size]
+
+		a, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+		expected, err := a.Encrypt(CBC, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		// the same message in pieces of several sizes
+		for _, piece := range []int{1, 5, 16, 33} {
+			a, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+			e, err := a.NewCBCEncrypter()
+			if err != nil {
+				t.Fatalf("Error creating encrypter: %s", err)
+			}
+
+			var encrypted []byte
+			for p := plaintext; len(p) > 0; {
+				n := min(len(p), piece)
+				out, err := e.Update(p[:n])
+				if err != nil {
+					t.Fatalf("Error updating: %s", err)
+				}
+				encrypted = append(encrypted, out...)
+				p = p[n:]
+			}
+
+			out, err := e.Finish()
+			if err != nil {
+				t.Fatalf("Error finishing: %s", err)
+			}
+			encrypted = append(encrypted, out...)
+
+			if !bytes.Equal(encrypted, expected) {
+				t.Errorf("size %d piece %d: Got: %x, Expected: %x", size, piece, encrypted, expected)
+			}
+		}
+	}
+}
+
+func TestCBCEncrypterOutput(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+	e, _ := a.NewCBCEncrypter()
+
+	// nothing to encrypt yet, only the IV comes out
+	out, _ := e.Update([]byte("short"))
+	if !bytes.Equal(out, e.IV()) {
+		t.Errorf("Got: %x, Expected: %x", out, e.IV())
+	}
+
+	out, _ = e.Update([]byte(" and now a full block"))
+	if len(out) != 16 {
+		t.Errorf("Expected %v, got %v", 16, len(out))
+	}
+
+	out, _ = e.Finish()
+	if len(out) != 16 {
+		t.Errorf("Expected %v, got %v", 16, len(out))
+	}
+
+	if _, err := e.Update([]byte("more")); err != ErrFinished {
+		t.Errorf("Expected %v, got %v", ErrFinished, err)
+	}
+	if _, err := e.Finish(); err != ErrFinished {
+		t.Errorf("Expected %v, got %v", ErrFinished, err)
+	}
+}
+
+func TestCBCEncrypterNoPadding(t *testing.T) {
+	a, _ := NewCipher(key.Bit128(), WithPadding(NoPadding))
+	e, _ := a.NewCBCEncrypter()
+
+	e.Update(make([]byte, 20))
+	if _, err := e.Finish(); err != ErrNotBlockAligned {
+		t.Errorf("Expected %v, got %v", ErrNotBlockAligned, err)
+	}
+
+	// the rest of the block can still come
+	e.Update(make([]byte, 12))
+	out, err := e.Finish()
+	if err != nil {
+		t.Fatalf("Error finishing: %s", err)
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected %v, got %v", 0, len(out))
+	}
+}
@@ -0,0 +1,262 @@
+package aesgo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const CiphertextVersion = 1
+
+// magic is written at the start of every marshaled Ciphertext, so it is easy to tell it apart from raw bytes
+var magic = [2]byte{'A', 'G'}
+
+// flags for the optional fields
+const (
+	flagKeyID byte = 1 << iota
+	flagKDF
+	flagEpoch
+	flagCompression
+
+	knownFlags = flagKeyID | flagKDF | flagEpoch | flagCompression
+)
+
+var ErrInvalidCiphertext = errors.New("Invalid ciphertext envelope")
+
+// Ciphertext keeps the IV/nonce apart from the encrypted body, so callers don't need to know
+// the first 16 bytes of the output are the IV.
+//
+// The binary format is:
+//
+//	magic (2 bytes) | version (1 byte) | mode (1 byte) | flags (1 byte) |
+//	[uvarint len(KeyID) | KeyID] | [uvarint len(KDFParams) | KDFParams] | [uvarint Epoch] |
+//	[Compression (1 byte)] | uvarint len(IV) | IV | uvarint len(Tag) | Tag | Body
+//
+// Flags say which optional fields (in brackets) are present.
+type Ciphertext struct {
+	Version byte
+	Mode    Mode
+	// KeyID is optional, it tells which key of a keyring encrypted the body.
+	KeyID string
+	// KDFParams is optional, it is a marshaled key.KDFParams when the key was derived from a passphrase.
+	KDFParams []byte
+	// Epoch is optional, it tells which key of a ratchet encrypted the body. 0 is not written.
+	Epoch uint64
+	// Compression is optional, it tells the plaintext was compressed before it was encrypted.
+	// Only the streaming API compresses, see Compression.
+	Compression Compression
+	IV          []byte
+	Body        []byte
+	// Tag is the authentication tag, only GCM has one.
+	Tag []byte
+}
+
+func (c *Ciphertext) Marshal() []byte {
+	b := make([]byte, 0, 6+5*binary.MaxVarintLen64+len(c.KeyID)+len(c.KDFParams)+len(c.IV)+len(c.Tag)+len(c.Body))
+
+	var flags byte
+	if c.KeyID != "" {
+		flags |= flagKeyID
+	}
+	if len(c.KDFParams) > 0 {
+		flags |= flagKDF
+	}
+	if c.Epoch != 0 {
+		flags |= flagEpoch
+	}
+	if c.Compression != NoCompression {
+		flags |= flagCompression
+	}
+
+	b = append(b, magic[:]...)
+	b = append(b, c.Version, byte(c.Mode), flags)
+
+	if flags&flagKeyID != 0 {
+		b = binary.AppendUvarint(b, uint64(len(c.KeyID)))
+		b = append(b, c.KeyID...)
+	}
+
+	if flags&flagKDF != 0 {
+		b = binary.AppendUvarint(b, uint64(len(c.KDFParams)))
+		b = append(b, c.KDFParams...)
+	}
+
+	if flags&flagEpoch != 0 {
+		b = binary.AppendUvarint(b, c.Epoch)
+	}
+
+	if flags&flagCompression != 0 {
+		b = append(b, byte(c.Compression))
+	}
+
+	b = binary.AppendUvarint(b, uint64(len(c.IV)))
+	b = append(b, c.IV...)
+
+	b = binary.AppendUvarint(b, uint64(len(c.Tag)))
+	b = append(b, c.Tag...)
+
+	return append(b, c.Body...)
+}
+
+func Unmarshal(b []byte) (*Ciphertext, error) {
+	if len(b) < 5 || b[0] != magic[0] || b[1] != magic[1] {
+		return nil, ErrInvalidCiphertext
+	}
+
+	c := &Ciphertext{Version: b[2], Mode: Mode(b[3])}
+	flags := b[4]
+	if c.Version != CiphertextVersion || flags&^knownFlags != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	rest := b[5:]
+
+	if flags&flagKeyID != 0 {
+		id, r, err := readField(rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(id) == 0 {
+			return nil, ErrInvalidCiphertext
+		}
+		c.KeyID = string(id)
+		rest = r
+	}
+
+	if flags&flagKDF != 0 {
+		params, r, err := readField(rest)
+		if err != nil {
+			return nil, err
+		}
+		if len(params) == 0 {
+			return nil, ErrInvalidCiphertext
+		}
+		c.KDFParams = params
+		rest = r
+	}
+
+	if flags&flagEpoch != 0 {
+		epoch, n := binary.Uvarint(rest)
+		if n <= 0 || epoch == 0 {
+			return nil, ErrInvalidCiphertext
+		}
+		c.Epoch = epoch
+		rest = rest[n:]
+	}
+
+	if flags&flagCompression != 0 {
+		if len(rest) == 0 || !Compression(rest[0]).valid() {
+			return nil, ErrInvalidCiphertext
+		}
+		c.Compression = Compression(rest[0])
+		rest = rest[1:]
+	}
+
+	iv, rest, err := readField(rest)
+	if err != nil {
+		return nil, err
+	}
+	c.IV = iv
+
+	tag, rest, err := readField(rest)
+	if err != nil {
+		return nil, err
+	}
+	c.Tag = tag
+
+	c.Body = rest
+
+	return c, nil
+}
+
+// readField reads a uvarint length followed by that many bytes.
+func readField(b []byte) ([]byte, []byte, error) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || l > uint64(len(b)-n) {
+		return nil, nil, ErrInvalidCiphertext
+	}
+
+	b = b[n:]
+	if l == 0 {
+		return nil, b, nil
+	}
+	return b[:l], b[l:], nil
+}
+
+// EncryptCiphertext works like Encrypt but returns the result as a Ciphertext.
+func (a *AES) EncryptCiphertext(mode Mode, plaintext []byte) (*Ciphertext, error) {
+	encrypted, err := a.Encrypt(mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Ciphertext{Version: CiphertextVersion, Mode: mode}
+
+	switch mode {
+	case CBC, CTR:
+		c.IV = encrypted[:16]
+		c.Body = encrypted[16:]
+	case GCM:
+		c.IV = encrypted[:GCMNonceSize]
+		c.Body = encrypted[GCMNonceSize : len(encrypted)-GCMTagSize]
+		c.Tag = encrypted[len(encrypted)-GCMTagSize:]
+	default:
+		c.Body = encrypted
+	}
+
+	return c, nil
+}
+
+// DecryptCiphertext decrypts a Ciphertext using the mode recorded on it. A compressed body is
+// decompressed, up to MaxDecompressedSize.
+func (a *AES) DecryptCiphertext(c *Ciphertext) ([]byte, error) {
+	if c.Version != CiphertextVersion {
+		return nil, ErrInvalidCiphertext
+	}
+	if a.policy == Strict && c.Mode != GCM && len(c.Tag) > 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	if a.policy == Strict && len(c.Body) == 0 {
+		return nil, fmt.Errorf("%w: only the IV or nonce is there", ErrEmptyCiphertext)
+	}
+
+	if c.Compression != NoCompression {
+		uncompressed := *c
+		uncompressed.Compression = NoCompression
+		compressed, err := a.DecryptCiphertext(&uncompressed)
+		if err != nil {
+			return nil, err
+		}
+		return decompress(c.Compression, compressed, MaxDecompressedSize)
+	}
+
+	switch c.Mode {
+	case ECB:
+		if len(c.IV) != 0 {
+			return nil, ErrInvalidCiphertext
+		}
+		return a.Decrypt(ECB, c.Body)
+	case CBC, CTR:
+		if len(c.IV) != 16 {
+			return nil, ErrInvalidIV
+		}
+		encrypted := make([]byte, 0, len(c.IV)+len(c.Body))
+		encrypted = append(encrypted, c.IV...)
+		encrypted = append(encrypted, c.Body...)
+		return a.Decrypt(c.Mode, encrypted)
+	case GCM:
+		if len(c.IV) != GCMNonceSize {
+			return nil, ErrInvalidNonce
+		}
+		if len(c.Tag) != GCMTagSize {
+			return nil, ErrInvalidCiphertext
+		}
+		encrypted := make([]byte, 0, len(c.IV)+len(c.Body)+len(c.Tag))
+		encrypted = append(encrypted, c.IV...)
+		encrypted = append(encrypted, c.Body...)
+		encrypted = append(encrypted, c.Tag...)
+		return a.Decrypt(GCM, encrypted)
+	}
+
+	return nil, ErrInvalidMode
+}
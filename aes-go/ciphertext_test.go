@@ -0,0 +1,109 @@
+package aesgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCiphertextRoundTrip(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	aes := New(k, WithInsecureModes())
+
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, mode := range []Mode{ECB, CBC, CTR} {
+		c, err := aes.EncryptCiphertext(mode, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		if mode != ECB && len(c.IV) != 16 {
+			t.Errorf("Expected IV with 16 bytes, got %d", len(c.IV))
+		}
+
+		parsed, err := Unmarshal(c.Marshal())
+		if err != nil {
+			t.Fatalf("Error unmarshaling: %s", err)
+		}
+
+		if parsed.Mode != mode || !bytes.Equal(parsed.IV, c.IV) || !bytes.Equal(parsed.Body, c.Body) {
+			t.Errorf("Unmarshaled envelope differs. Got: %+v, Expected: %+v", parsed, c)
+		}
+
+		decrypted, err := aes.DecryptCiphertext(parsed)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+		}
+	}
+}
+
+func TestCiphertextOptionalFields(t *testing.T) {
+	c := &Ciphertext{Version: CiphertextVersion, Mode: CTR, KeyID: "2024-01", KDFParams: []byte{1, 2, 3}, Epoch: 300, IV: make([]byte, 16), Body: []byte("body"), Tag: []byte("tag")}
+
+	parsed, err := Unmarshal(c.Marshal())
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %s", err)
+	}
+
+	if parsed.KeyID != c.KeyID || parsed.Epoch != c.Epoch || !bytes.Equal(parsed.KDFParams, c.KDFParams) || !bytes.Equal(parsed.Tag, c.Tag) || !bytes.Equal(parsed.Body, c.Body) {
+		t.Errorf("Got: %+v, Expected: %+v", parsed, c)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{
+			name:  "empty",
+			input: []byte{},
+		},
+		{
+			name:  "wrong magic",
+			input: []byte{'X', 'G', 1, 1, 0, 0, 0},
+		},
+		{
+			name:  "unknown version",
+			input: []byte{'A', 'G', 9, 1, 0, 0, 0},
+		},
+		{
+			name:  "unknown flags",
+			input: []byte{'A', 'G', 1, 1, 0x80, 0, 0},
+		},
+		{
+			name:  "empty key id",
+			input: []byte{'A', 'G', 1, 1, 1, 0, 0, 0},
+		},
+		{
+			name:  "zero epoch",
+			input: []byte{'A', 'G', 1, 1, 4, 0, 0, 0},
+		},
+		{
+			name:  "missing epoch",
+			input: []byte{'A', 'G', 1, 1, 4},
+		},
+		{
+			name:  "IV length past the end",
+			input: []byte{'A', 'G', 1, 1, 0, 16, 1, 2, 3},
+		},
+		{
+			name:  "missing tag length",
+			input: []byte{'A', 'G', 1, 1, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Unmarshal(test.input); err != ErrInvalidCiphertext {
+				t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package aesgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// MaxDecompressedSize is the most DecryptCiphertext decompresses, it holds the whole plaintext in
+// memory. A few KB of gzip can expand to GBs, so without a limit a small body is enough to take
+// all the memory. The streaming API has no limit, it never holds more than a buffer.
+const MaxDecompressedSize = 1 << 30
+
+var ErrDecompressedTooLarge = errors.New("Decompressed plaintext is larger than MaxDecompressedSize")
+
+// Compression compresses the plaintext before it is encrypted, ciphertext doesn't compress. It is
+// opt in, set it on the Ciphertext given to NewEncryptWriter, EncryptStream or EncryptFile. It is
+// recorded in the header and decryption undoes it without being asked.
+//
+// Compressing before encrypting leaks information through the length. The size of the
+// ciphertext tells how well the plaintext compressed, so an attacker who can put some of their
+// own text next to a secret (a cookie in a request, a token in a page) and see the size can guess
+// the secret byte by byte: when the guess is right it repeats the secret and the output is
+// shorter. That is the CRIME and BREACH attacks on TLS and HTTP. Only compress data where nobody
+// who sees the ciphertext controls any of the plaintext, like backups.
+//
+// Only gzip is there, zstd isn't in the standard library.
+type Compression byte
+
+const (
+	NoCompression Compression = iota
+	Gzip
+)
+
+func (c Compression) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Gzip:
+		return "gzip"
+	}
+	return "unknown"
+}
+
+// valid reports if c is an algorithm that can be recorded in a header.
+func (c Compression) valid() bool {
+	return c == Gzip
+}
+
+// decompress stops after limit bytes, MaxDecompressedSize outside of tests.
+func decompress(c Compression, compressed []byte, limit int64) ([]byte, error) {
+	if !c.valid() {
+		return nil, ErrInvalidCiphertext
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+
+	// one more byte tells a plaintext of exactly limit bytes from a larger one
+	b, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(b)) > limit {
+		clear(b)
+		return nil, ErrDecompressedTooLarge
+	}
+	return b, nil
+}
+
+// writerFunc and readerFunc put a compressor in front of the encryption without a buffer between
+// them.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,154 @@
+package aesgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCompression(t *testing.T) {
+	a, _ := NewCipher(key.Bit128(), WithInsecureModes())
+
+	for _, mode := range []Mode{ECB, CBC, CTR} {
+		for _, size := range []int{0, 1, 17, 100000} {
+			plaintext := bytes.Repeat([]byte("0123456789abcdefghij"), size/20+1)[:size]
+
+			var encrypted bytes.Buffer
+			c := &Ciphertext{Mode: mode, KeyID: "compressed", Compression: Gzip}
+			if err := a.EncryptParallel(context.Background(), &encrypted, bytes.NewReader(plaintext), c, 4); err != nil {
+				t.Fatalf("mode %d size %d: Error encrypting: %s", mode, size, err)
+			}
+
+			// repeated text shrinks
+			if size == 100000 && encrypted.Len() > size/10 {
+				t.Errorf("mode %d: Expected less than %d bytes, got %d", mode, size/10, encrypted.Len())
+			}
+
+			// the one shot API decompresses too
+			one, err := Unmarshal(encrypted.Bytes())
+			if err != nil {
+				t.Fatalf("Error unmarshaling: %s", err)
+			}
+			if one.Compression != Gzip {
+				t.Errorf("Expected %v, got %v", Gzip, one.Compression)
+			}
+			decrypted, err := a.DecryptCiphertext(one)
+			if err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("mode %d size %d: one shot decryption differs (%v)", mode, size, err)
+			}
+
+			r := bytes.NewReader(encrypted.Bytes())
+			parsed, err := ReadHeader(r)
+			if err != nil {
+				t.Fatalf("Error reading header: %s", err)
+			}
+			if parsed.Compression != Gzip || parsed.KeyID != "compressed" {
+				t.Errorf("Unexpected header: %+v", parsed)
+			}
+
+			var streamed bytes.Buffer
+			if err := a.DecryptParallel(context.Background(), &streamed, r, parsed, 4); err != nil {
+				t.Fatalf("mode %d size %d: Error decrypting: %s", mode, size, err)
+			}
+			if !bytes.Equal(streamed.Bytes(), plaintext) {
+				t.Errorf("mode %d size %d: streamed decryption differs", mode, size)
+			}
+		}
+	}
+}
+
+func TestCompressionErrors(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+
+	if _, err := a.NewEncryptWriter(&bytes.Buffer{}, &Ciphertext{Mode: CTR, Compression: 7}); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+
+	var encrypted bytes.Buffer
+	w, _ := a.NewEncryptWriter(&encrypted, &Ciphertext{Mode: CTR, Compression: Gzip})
+	w.Write([]byte("Let's test if this is working!"))
+	w.Close()
+
+	// the compression byte comes right after the flags without the optional fields before it
+	unknown := append([]byte{}, encrypted.Bytes()...)
+	unknown[5] = 7
+	if _, err := Unmarshal(unknown); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+	if _, err := ReadHeader(bytes.NewReader(unknown)); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	// the gzip trailer is missing
+	truncated := encrypted.Bytes()[:encrypted.Len()-4]
+	r := bytes.NewReader(truncated)
+	c, _ := ReadHeader(r)
+	dr, _ := a.NewDecryptReader(c, r)
+	if _, err := io.ReadAll(dr); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+
+	// nothing after the header, not even the gzip header
+	r = bytes.NewReader(encrypted.Bytes()[:len(c.Marshal())])
+	c, _ = ReadHeader(r)
+	dr, _ = a.NewDecryptReader(c, r)
+	if _, err := io.ReadAll(dr); err != ErrTruncatedCiphertext {
+		t.Errorf("Expected %v, got %v", ErrTruncatedCiphertext, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(path, []byte("in place"), 0600)
+	if err := a.EncryptFileInPlace(path, &Ciphertext{Mode: CTR, Compression: Gzip}); err != ErrInPlaceMode {
+		t.Errorf("Expected %v, got %v", ErrInPlaceMode, err)
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(make([]byte, 4096))
+	zw.Close()
+
+	// 4 KB of zeros compress to a few bytes, a bomb is the same with more zeros
+	if _, err := decompress(Gzip, compressed.Bytes(), 4095); err != ErrDecompressedTooLarge {
+		t.Errorf("Expected %v, got %v", ErrDecompressedTooLarge, err)
+	}
+
+	b, err := decompress(Gzip, compressed.Bytes(), 4096)
+	if err != nil || len(b) != 4096 {
+		t.Errorf("Expected 4096 bytes, got %d (%v)", len(b), err)
+	}
+}
+
+func TestCompressedFile(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+	dir := t.TempDir()
+	plaintext := bytes.Repeat([]byte("file content "), 1000)
+
+	src := filepath.Join(dir, "plain")
+	os.WriteFile(src, plaintext, 0600)
+
+	if err := a.EncryptFile(filepath.Join(dir, "secret"), src, &Ciphertext{Mode: CTR, Compression: Gzip}); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	encrypted, _ := os.ReadFile(filepath.Join(dir, "secret"))
+	h, err := Inspect(encrypted)
+	if err != nil || h.Compression != Gzip {
+		t.Errorf("Expected %v, got %v (%v)", Gzip, h.Compression, err)
+	}
+
+	if err := a.DecryptFile(filepath.Join(dir, "decrypted"), filepath.Join(dir, "secret")); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	decrypted, _ := os.ReadFile(filepath.Join(dir, "decrypted"))
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected the plaintext back, got %d bytes", len(decrypted))
+	}
+}
@@ -0,0 +1,85 @@
+package aesgo
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// CounterLayout says how the 16 byte CTR counter block is split between nonce and counter,
+// see NIST SP 800-38A appendix B: https://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38a.pdf
+type CounterLayout int
+
+const (
+	// Counter128 increments the whole block as a 128 bit big endian number, wrapping around
+	// to zero. It is what crypto/cipher and OpenSSL do.
+	Counter128 CounterLayout = iota
+	// Counter32 is a 96 bit nonce followed by a 32 bit big endian counter starting at 1, like
+	// RFC 3686 and GCM. The nonce is never touched, a message longer than the 2^32 blocks left
+	// fails with ErrCounterExhausted instead of wrapping.
+	Counter32
+)
+
+var ErrCounterExhausted = errors.New("CTR counter space is exhausted")
+
+// WithCounterLayout sets how CTR increments the counter block. Defaults to Counter128.
+// Both sides must use the same layout, it isn't recorded in the ciphertext.
+func WithCounterLayout(l CounterLayout) Option {
+	return func(a *AES) {
+		a.counterLayout = l
+	}
+}
+
+// ctrIV generates the initial counter block for the layout.
+func (a *AES) ctrIV() ([]byte, error) {
+	iv, err := a.randomBlock()
+	if err != nil {
+		return nil, err
+	}
+	if a.counterLayout == Counter32 {
+		binary.BigEndian.PutUint32(iv[12:], 1)
+	}
+	return iv, nil
+}
+
+// incrementCounter adds one to the counter block in place.
+func incrementCounter(counter []byte, layout CounterLayout) error {
+	if layout == Counter32 {
+		c := binary.BigEndian.Uint32(counter[12:]) + 1
+		binary.BigEndian.PutUint32(counter[12:], c)
+		if c == 0 {
+			return ErrCounterExhausted
+		}
+		return nil
+	}
+
+	for i := len(counter) - 1; i >= 0; i-- {
+		counter[i]++
+		if counter[i] != 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// addCounter adds n to the counter block in place, the same as calling incrementCounter n times.
+func addCounter(counter []byte, n uint64, layout CounterLayout) error {
+	if layout == Counter32 {
+		c := uint64(binary.BigEndian.Uint32(counter[12:])) + n
+		if c > 0xffffffff {
+			return ErrCounterExhausted
+		}
+		binary.BigEndian.PutUint32(counter[12:], uint32(c))
+		return nil
+	}
+
+	// the low 64 bits and then the carry into the high 64 bits, wrapping around at 2^128
+	lo := binary.BigEndian.Uint64(counter[8:])
+	hi := binary.BigEndian.Uint64(counter[:8])
+	sum := lo + n
+	if sum < lo {
+		hi++
+	}
+	binary.BigEndian.PutUint64(counter[:8], hi)
+	binary.BigEndian.PutUint64(counter[8:], sum)
+	return nil
+}
@@ -0,0 +1,141 @@
+package aesgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestIncrementCounter(t *testing.T) {
+	tests := []struct {
+		name string
+
+		layout  CounterLayout
+		counter string
+
+		expected string
+		err      error
+	}{
+		{name: "128 bit", layout: Counter128, counter: "000102030405060708090a0b0c0d0e0f", expected: "000102030405060708090a0b0c0d0e10"},
+		{name: "128 bit carry", layout: Counter128, counter: "000102030405060708090a0bffffffff", expected: "000102030405060708090a0c00000000"},
+		{name: "128 bit wraps around", layout: Counter128, counter: "ffffffffffffffffffffffffffffffff", expected: "00000000000000000000000000000000"},
+		{name: "32 bit", layout: Counter32, counter: "000102030405060708090a0b00000001", expected: "000102030405060708090a0b00000002"},
+		{name: "32 bit doesn't carry into the nonce", layout: Counter32, counter: "000102030405060708090a0bffffffff", expected: "000102030405060708090a0b00000000", err: ErrCounterExhausted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			counter, _ := hex.DecodeString(test.counter)
+
+			err := incrementCounter(counter, test.layout)
+			if err != test.err {
+				t.Errorf("Expected %v, got %v", test.err, err)
+			}
+			if got := hex.EncodeToString(counter); got != test.expected {
+				t.Errorf("Got: %s, Expected: %s", got, test.expected)
+			}
+		})
+	}
+}
+
+// Crossing 2^128 used to grow the counter to 17 bytes.
+func TestCTRWrapsAround(t *testing.T) {
+	k := key.Bit128()
+	iv := bytes.Repeat([]byte{0xff}, 16)
+	iv[15] = 0xfe
+	plaintext := bytes.Repeat([]byte("wrap"), 20)
+
+	a, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+	encrypted, err := a.Encrypt(CTR, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	block, _ := aes.NewCipher(k.GetBytes())
+	expected := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(expected, plaintext)
+
+	if !bytes.Equal(encrypted[16:], expected) {
+		t.Errorf("Got: %x, Expected: %x", encrypted[16:], expected)
+	}
+
+	// the streaming API and the keystream reader wrap in the same way
+	var streamed bytes.Buffer
+	a, _ = NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+	w, _ := a.NewEncryptWriter(&streamed, &Ciphertext{Mode: CTR})
+	w.Write(plaintext)
+	w.Close()
+	if !bytes.HasSuffix(streamed.Bytes(), expected) {
+		t.Errorf("Streamed: Got: %x, Expected: %x", streamed.Bytes(), expected)
+	}
+
+	r, _ := NewKeystreamReader(k, iv)
+	keystream := make([]byte, len(plaintext))
+	io.ReadFull(r, keystream)
+	if xored := xorBytes(plaintext, keystream); !bytes.Equal(xored, expected) {
+		t.Errorf("Keystream: Got: %x, Expected: %x", xored, expected)
+	}
+}
+
+func TestCounter32(t *testing.T) {
+	k := key.Bit128()
+	a, err := NewCipher(k, WithCounterLayout(Counter32))
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+
+	encrypted, err := a.Encrypt(CTR, []byte("counter starts at one"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if counter := encrypted[12:16]; !bytes.Equal(counter, []byte{0, 0, 0, 1}) {
+		t.Errorf("Got: %x, Expected: %x", counter, []byte{0, 0, 0, 1})
+	}
+
+	decrypted, err := a.Decrypt(CTR, encrypted)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(decrypted) != "counter starts at one" {
+		t.Errorf("Got: %s, Expected: %s", decrypted, "counter starts at one")
+	}
+
+	// two blocks are left before the counter wraps
+	iv := append(bytes.Repeat([]byte{7}, 12), 0xff, 0xff, 0xff, 0xfe)
+
+	tests := []struct {
+		name string
+
+		size     int
+		expected error
+	}{
+		{name: "fits", size: 32, expected: nil},
+		{name: "one byte too many", size: 33, expected: ErrCounterExhausted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := a.Decrypt(CTR, append(iv, make([]byte, test.size)...)); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+
+			a, _ := NewCipher(k, WithCounterLayout(Counter32))
+			r, err := a.NewDecryptReader(&Ciphertext{Mode: CTR, IV: iv}, bytes.NewReader(make([]byte, test.size)))
+			if err != nil {
+				t.Fatalf("Error creating reader: %s", err)
+			}
+			if _, err := io.ReadAll(r); err != test.expected {
+				t.Errorf("Streamed: Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	if _, err := NewCipher(k, WithCounterLayout(CounterLayout(5))); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+}
@@ -0,0 +1,69 @@
+package aesgo
+
+import (
+	"crypto/aes"
+	"fmt"
+	"strings"
+
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// WithCrossCheck computes every EncryptBlock and DecryptBlock again with crypto/aes and panics when
+// the results differ. The panic message has the key, the round keys and, for encryption, the state
+// after every step. It's meant for debugging changes to the round functions and makes everything
+// slower. It can't be combined with reduced rounds or a fault, they differ from AES on purpose.
+func WithCrossCheck() Option {
+	return func(a *AES) {
+		// the key size was already checked, crypto/aes accepts it
+		a.crossCheck, _ = aes.NewCipher(a.key.GetBytes())
+	}
+}
+
+// standardRounds is what crypto/aes does for the key size.
+func (a *AES) standardRounds() bool {
+	return a.rounds == a.key.Len()/4+6
+}
+
+func (a *AES) checkEncryptBlock(in [16]byte, out [4][4]byte) {
+	var expected [16]byte
+	a.crossCheck.Encrypt(expected[:], in[:])
+
+	if got := primitives.FromState(out); got != expected {
+		panic(a.crossCheckDump("EncryptBlock", in, got, expected))
+	}
+}
+
+func (a *AES) checkDecryptBlock(in [16]byte, out [4][4]byte) {
+	var expected [16]byte
+	a.crossCheck.Decrypt(expected[:], in[:])
+
+	if got := primitives.FromState(out); got != expected {
+		panic(a.crossCheckDump("DecryptBlock", in, got, expected))
+	}
+}
+
+func (a *AES) crossCheckDump(op string, in, got, expected [16]byte) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "aesgo: %s differs from crypto/aes\n", op)
+	fmt.Fprintf(&b, "key:      %x\n", a.key.GetBytes())
+	fmt.Fprintf(&b, "input:    %x\n", in)
+	fmt.Fprintf(&b, "got:      %x\n", got)
+	fmt.Fprintf(&b, "expected: %x\n", expected)
+
+	for i, rk := range a.RoundKeys() {
+		fmt.Fprintf(&b, "round key %2d: %x\n", i, rk)
+	}
+
+	if op == "EncryptBlock" {
+		// without the check, or this would panic again
+		c := a.clone()
+		c.crossCheck = nil
+		if trace, err := c.EncryptBlockTrace(in); err == nil {
+			for _, s := range trace.Steps {
+				fmt.Fprintf(&b, "round %2d %-11s %x\n", s.Round, s.Name, s.State)
+			}
+		}
+	}
+
+	return b.String()
+}
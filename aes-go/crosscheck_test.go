@@ -0,0 +1,61 @@
+package aesgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCrossCheck(t *testing.T) {
+	for _, k := range []key.Key{key.Bit128(), key.Bit256()} {
+		a, err := NewCipher(k, WithCrossCheck(), WithInsecureModes())
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+
+		plaintext := bytes.Repeat([]byte("cross check "), 10)
+		for _, mode := range []Mode{ECB, CBC, CTR, GCM} {
+			encrypted, err := a.Encrypt(mode, plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			decrypted, err := a.Decrypt(mode, encrypted)
+			if err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Got: %s, Expected: %s (%v)", decrypted, plaintext, err)
+			}
+		}
+	}
+}
+
+func TestCrossCheckMismatch(t *testing.T) {
+	a, _ := NewCipher(key.Bit128(), WithCrossCheck())
+	// WithFault can't be combined with the check, this is how a broken round function looks like
+	a.fault = &Fault{Round: 5, Row: 1, Column: 2, Mask: 0x01}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Expected a panic, got nil")
+		}
+
+		dump, _ := r.(string)
+		for _, expected := range []string{"EncryptBlock differs from crypto/aes", "round key 10:", "round  5 SubBytes"} {
+			if !strings.Contains(dump, expected) {
+				t.Errorf("Expected %q in:\n%s", expected, dump)
+			}
+		}
+	}()
+
+	a.EncryptBlock([16]byte{})
+}
+
+func TestCrossCheckInvalid(t *testing.T) {
+	if _, err := NewCipher(key.Bit128(), WithCrossCheck(), WithFault(Fault{Round: 9})); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+	if _, err := NewWithRounds(key.Bit128(), 4, WithCrossCheck()); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+}
@@ -0,0 +1,29 @@
+package aesgo
+
+// Fault simulates a fault injection (a glitch or a laser on a smart card) during encryption.
+// It XORs Mask into one byte of the state at the start of Round, before SubBytes.
+// It is only meant for fault attack demos, see attacks/dfa.
+type Fault struct {
+	Round int
+	// Row and Column of the byte in the state matrix, the byte at index Column*4 + Row of the input block.
+	Row, Column int
+	Mask        byte
+}
+
+// WithFault makes every EncryptBlock apply the fault. Decryption is not affected.
+func WithFault(f Fault) Option {
+	return func(a *AES) {
+		a.fault = &f
+	}
+}
+
+func (f *Fault) valid(rounds int) bool {
+	return f.Round >= 1 && f.Round <= rounds && f.Row >= 0 && f.Row < 4 && f.Column >= 0 && f.Column < 4
+}
+
+func (a *AES) injectFault(state [4][4]byte) [4][4]byte {
+	if a.fault != nil && a.fault.Round == a.currentRound {
+		state[a.fault.Row][a.fault.Column] ^= a.fault.Mask
+	}
+	return state
+}
@@ -0,0 +1,314 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// The file API produces the same bytes as EncryptStream, header first and then the body. CTR files
+// are mapped in memory and encrypted in chunks straight from the input pages to the output pages,
+// without the copies and the read and write calls of the streaming path. The other modes chain
+// every block to the one before and are left to EncryptStream and DecryptStream, like compressed
+// files.
+//
+// Where there is no mmap, or it fails, the files are read in memory instead. platform.Fallbacks
+// tells when that happened and why.
+
+var ErrInPlaceMode = errors.New("Only CTR without compression can be encrypted in place, the rest changes the size")
+
+// fileChunk is how much of a mapped file is encrypted at a time, with WithParallelism. A multiple
+// of 16 so every chunk starts at a counter, and of the page size.
+const fileChunk = 4 << 20
+
+// EncryptFile encrypts the file src into dst. Mode, KeyID, KDFParams, Epoch and Compression are taken from c
+// like NewEncryptWriter, and the IV is stored in c. dst is written to a temporary file next to it
+// and only renamed on success.
+func (a *AES) EncryptFile(dst, src string, c *Ciphertext) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return writeFile(dst, func(out *os.File) error {
+			return a.EncryptStream(context.Background(), out, in, c)
+		})
+	}
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	header, err := a.fileHeader(c, size)
+	if err != nil {
+		return err
+	}
+	h := int64(len(header))
+
+	return writeFile(dst, func(out *os.File) error {
+		if err := out.Truncate(h + size); err != nil {
+			return err
+		}
+
+		src, err := mapFile(in, size, false)
+		if err != nil {
+			return err
+		}
+		defer src.close()
+
+		dst, err := mapFile(out, h+size, true)
+		if err != nil {
+			return err
+		}
+		defer dst.close()
+
+		copy(dst.b, header)
+
+		p := a.newProgress()
+		p.total = size
+		for start := 0; start < len(src.b); start += fileChunk {
+			end := min(start+fileChunk, len(src.b))
+			if err := a.xorChunk(dst.b[h+int64(start):h+int64(end)], src.b[start:end], c.IV, start); err != nil {
+				return err
+			}
+			p.add(end - start)
+		}
+
+		return dst.close()
+	})
+}
+
+// DecryptFile decrypts the file src, created by EncryptFile or EncryptStream, into dst. dst is
+// written to a temporary file next to it and only renamed on success.
+func (a *AES) DecryptFile(dst, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	c, err := ReadHeader(in)
+	if err != nil {
+		return err
+	}
+
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return writeFile(dst, func(out *os.File) error {
+			return a.DecryptStream(context.Background(), out, in, c)
+		})
+	}
+
+	// checks the key, the mode and the IV, and records the nonce with Strict
+	if _, err := a.NewDecryptReader(c, in); err != nil {
+		return err
+	}
+
+	h, err := in.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	return writeFile(dst, func(out *os.File) error {
+		if err := out.Truncate(size - h); err != nil {
+			return err
+		}
+
+		src, err := mapFile(in, size, false)
+		if err != nil {
+			return err
+		}
+		defer src.close()
+
+		dst, err := mapFile(out, size-h, true)
+		if err != nil {
+			return err
+		}
+		defer dst.close()
+
+		body := src.b[h:]
+		p := a.newProgress()
+		p.total = int64(len(body))
+		for start := 0; start < len(body); start += fileChunk {
+			end := min(start+fileChunk, len(body))
+			if err := a.xorChunk(dst.b[start:end], body[start:end], c.IV, start); err != nil {
+				return err
+			}
+			p.add(end - start)
+		}
+
+		return dst.close()
+	})
+}
+
+// EncryptFileInPlace encrypts the file at path into itself with CTR, without a second copy on
+// disk. It isn't atomic: when it fails or the process dies half way the file is neither plaintext
+// nor ciphertext, use EncryptFile when there is no backup.
+func (a *AES) EncryptFileInPlace(path string, c *Ciphertext) error {
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return ErrInPlaceMode
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	header, err := a.fileHeader(c, size)
+	if err != nil {
+		return err
+	}
+	h := int64(len(header))
+
+	if err := f.Truncate(h + size); err != nil {
+		return err
+	}
+	m, err := mapFile(f, h+size, true)
+	if err != nil {
+		f.Truncate(size)
+		return err
+	}
+	defer m.close()
+
+	// the body moves up by the size of the header, so like memmove it goes from the end and
+	// never overwrites what isn't encrypted yet
+	p := a.newProgress()
+	p.total = size
+	for end := int(size); end > 0; {
+		start := (end - 1) / fileChunk * fileChunk
+		if err := a.xorChunk(m.b[h+int64(start):h+int64(end)], m.b[start:end], c.IV, start); err != nil {
+			return err
+		}
+		p.add(end - start)
+		end = start
+	}
+	copy(m.b, header)
+
+	if err := m.close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// DecryptFileInPlace decrypts the file at path, created by EncryptFileInPlace or with CTR by
+// EncryptFile, into itself. It isn't atomic either.
+func (a *AES) DecryptFileInPlace(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c, err := ReadHeader(f)
+	if err != nil {
+		return err
+	}
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return ErrInPlaceMode
+	}
+	if _, err := a.NewDecryptReader(c, f); err != nil {
+		return err
+	}
+
+	h, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+
+	m, err := mapFile(f, size, true)
+	if err != nil {
+		return err
+	}
+	defer m.close()
+
+	// the body moves down to where the header was, from the start this time
+	body := int(size - h)
+	p := a.newProgress()
+	p.total = int64(body)
+	for start := 0; start < body; start += fileChunk {
+		end := min(start+fileChunk, body)
+		if err := a.xorChunk(m.b[start:end], m.b[h+int64(start):h+int64(end)], c.IV, start); err != nil {
+			return err
+		}
+		p.add(end - start)
+	}
+
+	if err := m.close(); err != nil {
+		return err
+	}
+	if err := f.Truncate(size - h); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// fileHeader goes through NewEncryptWriter, which checks the key and the mode, generates the IV
+// and counts the message, and counts the size of the file against the budget.
+func (a *AES) fileHeader(c *Ciphertext, size int64) ([]byte, error) {
+	var header bytes.Buffer
+	if _, err := a.NewEncryptWriter(&header, c); err != nil {
+		return nil, err
+	}
+	if err := a.spend(0, int(size)); err != nil {
+		return nil, err
+	}
+	return header.Bytes(), nil
+}
+
+// xorChunk encrypts or decrypts src, which starts offset bytes into the body, into dst.
+// src is fully read before dst is written, so they can overlap.
+func (a *AES) xorChunk(dst, src, iv []byte, offset int) error {
+	counter := append([]byte{}, iv...)
+	if err := addCounter(counter, uint64(offset/16), a.counterLayout); err != nil {
+		return err
+	}
+
+	out, err := a.encryptCTR(src, counter)
+	if err != nil {
+		return err
+	}
+	copy(dst, out[16:])
+	return nil
+}
+
+// writeFile calls write with a temporary file next to path and renames it to path on success.
+func writeFile(path string, write func(f *os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
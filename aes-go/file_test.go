@@ -0,0 +1,130 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestFile(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	k := key.Bit128()
+	a, _ := NewCipher(k, WithParallelism(4))
+
+	tests := []struct {
+		name string
+
+		mode Mode
+		size int
+	}{
+		{
+			name: "CTR over several chunks",
+
+			mode: CTR,
+			size: fileChunk + 35,
+		},
+		{
+			name: "CTR empty",
+
+			mode: CTR,
+			size: 0,
+		},
+		{
+			name: "CBC goes through the stream",
+
+			mode: CBC,
+			size: 1000,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			plaintext := make([]byte, test.size)
+			r.Read(plaintext)
+			os.WriteFile(filepath.Join(dir, "plain"), plaintext, 0o600)
+
+			c := &Ciphertext{Mode: test.mode, KeyID: "id"}
+			if err := a.EncryptFile(filepath.Join(dir, "enc"), filepath.Join(dir, "plain"), c); err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+
+			// the same bytes as the streaming API with the same IV
+			encrypted, _ := os.ReadFile(filepath.Join(dir, "enc"))
+			stream, _ := NewCipher(k, WithRandReader(bytes.NewReader(c.IV)))
+			var expected bytes.Buffer
+			stream.EncryptStream(context.Background(), &expected, bytes.NewReader(plaintext), &Ciphertext{Mode: test.mode, KeyID: "id"})
+			if !bytes.Equal(encrypted, expected.Bytes()) {
+				t.Errorf("Expected the bytes of EncryptStream, got %d bytes instead of %d", len(encrypted), expected.Len())
+			}
+
+			if err := a.DecryptFile(filepath.Join(dir, "dec"), filepath.Join(dir, "enc")); err != nil {
+				t.Fatalf("Error decrypting: %s", err)
+			}
+			decrypted, _ := os.ReadFile(filepath.Join(dir, "dec"))
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Decrypted %d bytes, expected %d", len(decrypted), len(plaintext))
+			}
+		})
+	}
+}
+
+func TestFileInPlace(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+	path := filepath.Join(t.TempDir(), "file")
+	plaintext := bytes.Repeat([]byte("in place "), 100000)
+	os.WriteFile(path, plaintext, 0o600)
+
+	c := &Ciphertext{Mode: CTR}
+	if err := a.EncryptFileInPlace(path, c); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	encrypted, _ := os.ReadFile(path)
+	r := bytes.NewReader(encrypted)
+	header, err := ReadHeader(r)
+	if err != nil || !bytes.Equal(header.IV, c.IV) || r.Len() != len(plaintext) {
+		t.Fatalf("Expected the header and %d bytes, got %d bytes (%v)", len(plaintext), r.Len(), err)
+	}
+	body, _ := a.Decrypt(CTR, append(append([]byte{}, c.IV...), encrypted[len(encrypted)-r.Len():]...))
+	if !bytes.Equal(body, plaintext) {
+		t.Errorf("Expected the body to decrypt to the plaintext")
+	}
+
+	if err := a.DecryptFileInPlace(path); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	decrypted, _ := os.ReadFile(path)
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Decrypted %d bytes, expected %d", len(decrypted), len(plaintext))
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plain")
+	os.WriteFile(path, []byte("plaintext"), 0o600)
+
+	a, _ := NewCipher(key.Bit128())
+	if err := a.EncryptFileInPlace(path, &Ciphertext{Mode: CBC}); err != ErrInPlaceMode {
+		t.Errorf("Expected %v, got %v", ErrInPlaceMode, err)
+	}
+	if err := a.EncryptFile(filepath.Join(dir, "enc"), path, &Ciphertext{Mode: ECB}); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+
+	// nothing is left behind on failure
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the plaintext, got %d files", len(entries))
+	}
+
+	if err := a.DecryptFile(filepath.Join(dir, "dec"), path); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+}
@@ -0,0 +1,85 @@
+package aesgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Run with: go test -fuzz=FuzzDecryptCBC ./aes-go
+// Without -fuzz only the seed corpus runs, as a normal test.
+
+func FuzzDecryptCBC(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(make([]byte, 16))
+	f.Add(make([]byte, 33))
+	f.Add(bytes.Repeat([]byte{0x10}, 48))
+
+	aes := New(key.NewKey([16]byte([]byte("128bitsforkeysss"))))
+
+	f.Fuzz(func(t *testing.T, encrypted []byte) {
+		decrypted, err := aes.Decrypt(CBC, encrypted)
+		if err != nil {
+			return
+		}
+
+		// PKCS7 padding is canonical, so encrypting again with the same IV must give the same bytes back
+		again, err := aes.encryptCBC(decrypted, encrypted[:16])
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		if !bytes.Equal(again, encrypted) {
+			t.Errorf("Got: %02x, Expected: %02x", again, encrypted)
+		}
+	})
+}
+
+func FuzzRemovePadding(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x01})
+	f.Add([]byte{0x00})
+	f.Add(bytes.Repeat([]byte{0x10}, 16))
+	f.Add(bytes.Repeat([]byte{0x11}, 17))
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		unpadded, err := RemovePadding(b)
+		if err != nil {
+			return
+		}
+
+		if len(unpadded) >= len(b) || !bytes.Equal(unpadded, b[:len(unpadded)]) {
+			t.Errorf("Unpadded output must be a prefix of the input. Got: %02x, input: %02x", unpadded, b)
+		}
+	})
+}
+
+func FuzzCTRRoundtrip(f *testing.F) {
+	f.Add([]byte{}, make([]byte, 16))
+	f.Add([]byte("Let's test if this is working!"), bytes.Repeat([]byte{0xff}, 16))
+
+	aes := New(key.NewKey([16]byte([]byte("128bitsforkeysss"))))
+
+	f.Fuzz(func(t *testing.T, plaintext, nonce []byte) {
+		if len(nonce) != 16 {
+			return
+		}
+
+		encrypted, err := aes.encryptCTR(plaintext, nonce)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		if len(encrypted) != len(nonce)+len(plaintext) {
+			t.Fatalf("Expected %d bytes, got %d", len(nonce)+len(plaintext), len(encrypted))
+		}
+
+		decrypted, err := aes.Decrypt(CTR, encrypted)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %02x, Expected: %02x", decrypted, plaintext)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package aesgo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mario-areias/aes-go/ghash"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+// GCM is CTR with a GHASH authentication tag, as defined in NIST SP 800-38D:
+// https://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38d.pdf
+//
+// Encrypt(GCM, ...) returns nonce (12 bytes) + ciphertext + tag (16 bytes). SealGCM and OpenGCM
+// take the nonce and the additional data explicitly.
+//
+// Never reuse a nonce with the same key. Besides leaking the XOR of the plain texts like CTR,
+// two messages with the same nonce leak the GHASH key and tags can be forged (see attacks/gcm).
+
+const (
+	GCMNonceSize = 12
+	GCMTagSize   = 16
+)
+
+var (
+	ErrInvalidNonce   = errors.New("GCM nonce must have 12 bytes")
+	ErrAuthentication = errors.New("Message authentication failed")
+)
+
+// SealGCM encrypts plaintext and authenticates it together with additionalData, which isn't encrypted.
+// It returns the ciphertext followed by the tag.
+func (a *AES) SealGCM(nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if len(nonce) != GCMNonceSize {
+		return nil, ErrInvalidNonce
+	}
+	if err := a.spend(1, len(plaintext)); err != nil {
+		return nil, err
+	}
+
+	h, j0 := a.gcmInit(nonce)
+
+	encrypted := a.gcmCTR(j0, plaintext)
+	tag := a.gcmTag(h, j0, additionalData, encrypted)
+
+	return append(encrypted, tag[:]...), nil
+}
+
+// OpenGCM checks the tag and decrypts. Nothing is decrypted when the tag doesn't match.
+func (a *AES) OpenGCM(nonce, encrypted, additionalData []byte) ([]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if len(nonce) != GCMNonceSize {
+		return nil, ErrInvalidNonce
+	}
+	if len(encrypted) < GCMTagSize {
+		return nil, fmt.Errorf("%w: must have at least %d bytes for the tag, got %d", ErrTruncatedCiphertext, GCMTagSize, len(encrypted))
+	}
+
+	body := encrypted[:len(encrypted)-GCMTagSize]
+	tag := encrypted[len(encrypted)-GCMTagSize:]
+
+	h, j0 := a.gcmInit(nonce)
+
+	expected := a.gcmTag(h, j0, additionalData, body)
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		return nil, ErrAuthentication
+	}
+
+	return a.gcmCTR(j0, body), nil
+}
+
+// openGCM is OpenGCM for a whole message, the nonce is recorded under Strict.
+func (a *AES) openGCM(nonce, sealed []byte) ([]byte, error) {
+	plaintext, err := a.OpenGCM(nonce, sealed, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.useNonce(nonce); err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+func (a *AES) encryptGCM(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, GCMNonceSize)
+	if _, err := io.ReadFull(a.rand, nonce); err != nil {
+		return nil, err
+	}
+
+	sealed, err := a.SealGCM(nonce, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, sealed...), nil
+}
+
+// gcmInit returns the hash key H (the encryption of a zero block) and the first counter block J0.
+// For 96 bit nonces J0 is the nonce followed by 1.
+func (a *AES) gcmInit(nonce []byte) ([16]byte, [16]byte) {
+	h := primitives.FromState(a.EncryptBlock([16]byte{}))
+
+	var j0 [16]byte
+	copy(j0[:], nonce)
+	j0[15] = 1
+
+	return h, j0
+}
+
+// gcmCTR is CTR starting at J0 + 1. Only the last 32 bits of the counter are incremented.
+func (a *AES) gcmCTR(j0 [16]byte, in []byte) []byte {
+	out := make([]byte, len(in))
+	counter := j0
+
+	for i := 0; i < len(in); i += 16 {
+		inc32(&counter)
+		keystream := primitives.FromState(a.EncryptBlock(counter))
+
+		end := min(i+16, len(in))
+		for j := i; j < end; j++ {
+			out[j] = in[j] ^ keystream[j-i]
+		}
+	}
+
+	return out
+}
+
+func inc32(counter *[16]byte) {
+	c := binary.BigEndian.Uint32(counter[12:])
+	binary.BigEndian.PutUint32(counter[12:], c+1)
+}
+
+// gcmTag is GHASH(H, A, C) XOR E(K, J0).
+func (a *AES) gcmTag(h, j0 [16]byte, additionalData, encrypted []byte) [16]byte {
+	s := ghash.GCM(h, additionalData, encrypted)
+	e := primitives.FromState(a.EncryptBlock(j0))
+
+	var tag [16]byte
+	for i := range tag {
+		tag[i] = s[i] ^ e[i]
+	}
+	return tag
+}
@@ -0,0 +1,185 @@
+package aesgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestGCMKnownAnswer(t *testing.T) {
+	// test cases 2 and 4 of the GCM specification (McGrew and Viega)
+	tests := []struct {
+		name string
+
+		key            string
+		nonce          string
+		plaintext      string
+		additionalData string
+
+		ciphertext string
+		tag        string
+	}{
+		{
+			name: "zero key and block",
+
+			key:       "00000000000000000000000000000000",
+			nonce:     "000000000000000000000000",
+			plaintext: "00000000000000000000000000000000",
+
+			ciphertext: "0388dace60b6a392f328c2b971b2fe78",
+			tag:        "ab6e47d42cec13bdf53a67b21257bddf",
+		},
+		{
+			name: "partial block with additional data",
+
+			key:            "feffe9928665731c6d6a8f9467308308",
+			nonce:          "cafebabefacedbaddecaf888",
+			plaintext:      "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+			additionalData: "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+
+			ciphertext: "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091",
+			tag:        "5bc94fbc3221a5db94fae95ae7121a47",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := New(key.NewKey([16]byte(decodeHex(t, test.key))))
+			nonce := decodeHex(t, test.nonce)
+			ad := decodeHex(t, test.additionalData)
+
+			sealed, err := a.SealGCM(nonce, decodeHex(t, test.plaintext), ad)
+			if err != nil {
+				t.Fatalf("Error sealing: %s", err)
+			}
+
+			expected := test.ciphertext + test.tag
+			if got := hex.EncodeToString(sealed); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+
+			opened, err := a.OpenGCM(nonce, sealed, ad)
+			if err != nil {
+				t.Fatalf("Error opening: %s", err)
+			}
+			if hex.EncodeToString(opened) != test.plaintext {
+				t.Errorf("Expected %s, got %x", test.plaintext, opened)
+			}
+		})
+	}
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestGCMStd(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		k := make([]byte, 16)
+		nonce := make([]byte, GCMNonceSize)
+		plaintext := make([]byte, r.Intn(100))
+		ad := make([]byte, r.Intn(40))
+		r.Read(k)
+		r.Read(nonce)
+		r.Read(plaintext)
+		r.Read(ad)
+
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := gcm.Seal(nil, nonce, plaintext, ad)
+
+		a := New(key.NewKey([16]byte(k)))
+		got, err := a.SealGCM(nonce, plaintext, ad)
+		if err != nil {
+			t.Fatalf("Error sealing: %s", err)
+		}
+
+		if !bytes.Equal(got, expected) {
+			t.Fatalf("Plaintext %x, additional data %x. Expected %x, got %x", plaintext, ad, expected, got)
+		}
+	}
+}
+
+func TestGCMTampering(t *testing.T) {
+	a := New(key.Bit128())
+
+	encrypted, err := a.Encrypt(GCM, []byte("Let's test if this is working!"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	decrypted, err := a.Decrypt(GCM, encrypted)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(decrypted) != "Let's test if this is working!" {
+		t.Errorf("Got: %s, Expected: Let's test if this is working!", decrypted)
+	}
+
+	// flipping any bit, of the nonce, the body or the tag, must be detected
+	for _, i := range []int{0, GCMNonceSize, len(encrypted) - 1} {
+		tampered := append([]byte{}, encrypted...)
+		tampered[i] ^= 1
+
+		if _, err := a.Decrypt(GCM, tampered); err != ErrAuthentication {
+			t.Errorf("Byte %d. Expected %v, got %v", i, ErrAuthentication, err)
+		}
+	}
+
+	nonce := encrypted[:GCMNonceSize]
+	if _, err := a.OpenGCM(nonce, encrypted[GCMNonceSize:], []byte("other data")); err != ErrAuthentication {
+		t.Errorf("Expected %v, got %v", ErrAuthentication, err)
+	}
+
+	if _, err := a.SealGCM(make([]byte, 16), nil, nil); err != ErrInvalidNonce {
+		t.Errorf("Expected %v, got %v", ErrInvalidNonce, err)
+	}
+
+	if _, err := a.Decrypt(GCM, make([]byte, 20)); !errors.Is(err, ErrTruncatedCiphertext) {
+		t.Errorf("Expected %v, got %v", ErrTruncatedCiphertext, err)
+	}
+}
+
+func TestGCMCiphertext(t *testing.T) {
+	a := New(key.Bit128())
+
+	c, err := a.EncryptCiphertext(GCM, []byte("envelope"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	if len(c.IV) != GCMNonceSize || len(c.Tag) != GCMTagSize {
+		t.Errorf("Expected %d bytes nonce and %d bytes tag, got %d and %d", GCMNonceSize, GCMTagSize, len(c.IV), len(c.Tag))
+	}
+
+	parsed, err := Unmarshal(c.Marshal())
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %s", err)
+	}
+
+	decrypted, err := a.DecryptCiphertext(parsed)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(decrypted) != "envelope" {
+		t.Errorf("Got: %s, Expected: envelope", decrypted)
+	}
+}
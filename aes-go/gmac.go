@@ -0,0 +1,19 @@
+package aesgo
+
+// GMAC is GCM without plaintext: it only authenticates data, nothing is encrypted.
+// The tag is GHASH(H, data, {}) XOR E(K, J0), so it shares everything with GCM, including the
+// rule that a nonce must never be used twice with the same key.
+
+// GMAC returns the 16 byte tag of data.
+func (a *AES) GMAC(nonce, data []byte) ([]byte, error) {
+	return a.SealGCM(nonce, nil, data)
+}
+
+// VerifyGMAC returns ErrAuthentication when tag isn't the GMAC of data. The comparison is constant time.
+func (a *AES) VerifyGMAC(nonce, data, tag []byte) error {
+	if len(tag) != GCMTagSize {
+		return ErrAuthentication
+	}
+	_, err := a.OpenGCM(nonce, tag, data)
+	return err
+}
@@ -0,0 +1,69 @@
+package aesgo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// gcmEncryptExtIV128.rsp from the NIST GCM test vectors, PTlen = 0 and AADlen = 128
+func TestGMACKnownAnswer(t *testing.T) {
+	k, _ := hex.DecodeString("77be63708971c4e240d1cb79e8d77feb")
+	nonce, _ := hex.DecodeString("e0e00f19fed7ba0136a797f3")
+	data, _ := hex.DecodeString("7a43ec1d9c0a5a78a0b16533a6213cab")
+	expected := "209fcc8d3675ed938e9c7166709dd946"
+
+	a, _ := NewCipher(key.NewKey([16]byte(k)))
+	tag, err := a.GMAC(nonce, data)
+	if err != nil {
+		t.Fatalf("Error computing tag: %s", err)
+	}
+	if got := hex.EncodeToString(tag); got != expected {
+		t.Errorf("Got: %s, Expected: %s", got, expected)
+	}
+}
+
+func TestGMAC(t *testing.T) {
+	k := key.Bit128()
+	nonce := make([]byte, GCMNonceSize)
+	data := []byte("authenticated but not encrypted")
+
+	a, _ := NewCipher(k)
+	tag, err := a.GMAC(nonce, data)
+	if err != nil {
+		t.Fatalf("Error computing tag: %s", err)
+	}
+
+	block, _ := aes.NewCipher(k.GetBytes())
+	gcm, _ := cipher.NewGCM(block)
+	if expected := gcm.Seal(nil, nonce, nil, data); string(tag) != string(expected) {
+		t.Errorf("Got: %x, Expected: %x", tag, expected)
+	}
+
+	tests := []struct {
+		name string
+
+		nonce []byte
+		data  []byte
+		tag   []byte
+
+		expected error
+	}{
+		{name: "valid", nonce: nonce, data: data, tag: tag, expected: nil},
+		{name: "modified data", nonce: nonce, data: []byte("authenticated but not encrypted!"), tag: tag, expected: ErrAuthentication},
+		{name: "other nonce", nonce: append(make([]byte, GCMNonceSize-1), 1), data: data, tag: tag, expected: ErrAuthentication},
+		{name: "short tag", nonce: nonce, data: data, tag: tag[:12], expected: ErrAuthentication},
+		{name: "short nonce", nonce: nonce[:8], data: data, tag: tag, expected: ErrInvalidNonce},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := a.VerifyGMAC(test.nonce, test.data, test.tag); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
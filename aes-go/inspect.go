@@ -0,0 +1,63 @@
+package aesgo
+
+import "github.com/mario-areias/aes-go/key"
+
+// Header describes an encrypted envelope without decrypting it.
+type Header struct {
+	// Format is the envelope, "aes-go" for a marshaled Ciphertext.
+	Format  string
+	Version byte
+	Mode    Mode
+	// IV is the IV or nonce.
+	IV    []byte
+	KeyID string
+	// KDF is set when the key was derived from a passphrase.
+	KDF         *key.KDFParams
+	Epoch       uint64
+	Compression Compression
+	// TagSize is the size of the authentication tag of every chunk, 0 without one.
+	TagSize int
+
+	// HeaderSize is the number of bytes before the payload, a Ciphertext has the tag there.
+	// PayloadSize is the size of the encrypted payload without the tags: the plaintext and the
+	// padding.
+	HeaderSize  int
+	PayloadSize int
+
+	// ChunkSize is the plaintext size of every chunk but the last. It is 0 when the payload is
+	// encrypted as a whole like in a Ciphertext, which is a single chunk.
+	ChunkSize int
+	Chunks    int
+}
+
+// Inspect parses a marshaled Ciphertext and reports what it says about itself. Nothing is
+// decrypted or authenticated, so don't trust any of it before decrypting.
+func Inspect(ciphertext []byte) (Header, error) {
+	c, err := Unmarshal(ciphertext)
+	if err != nil {
+		return Header{}, err
+	}
+
+	h := Header{
+		Format:      "aes-go",
+		Version:     c.Version,
+		Mode:        c.Mode,
+		IV:          c.IV,
+		KeyID:       c.KeyID,
+		Epoch:       c.Epoch,
+		Compression: c.Compression,
+		TagSize:     len(c.Tag),
+		HeaderSize:  len(ciphertext) - len(c.Body),
+		PayloadSize: len(c.Body),
+		Chunks:      1,
+	}
+
+	if len(c.KDFParams) > 0 {
+		h.KDF = &key.KDFParams{}
+		if err := h.KDF.UnmarshalBinary(c.KDFParams); err != nil {
+			return Header{}, err
+		}
+	}
+
+	return h, nil
+}
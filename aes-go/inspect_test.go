@@ -0,0 +1,106 @@
+package aesgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestInspect(t *testing.T) {
+	k := key.Bit128()
+	params, _ := key.NewScryptParams()
+	kdf, _ := params.MarshalBinary()
+	plaintext := []byte("Let's test if this is working!")
+
+	tests := []struct {
+		name string
+
+		mode  Mode
+		keyID string
+		kdf   []byte
+		epoch uint64
+
+		ivSize, tagSize, payloadSize int
+	}{
+		{
+			name: "ECB",
+
+			mode: ECB,
+
+			payloadSize: 32,
+		},
+		{
+			name: "CBC with a key ID",
+
+			mode:  CBC,
+			keyID: "2024-01",
+
+			ivSize:      16,
+			payloadSize: 32,
+		},
+		{
+			name: "GCM with everything",
+
+			mode:  GCM,
+			keyID: "2024-01",
+			kdf:   kdf,
+			epoch: 7,
+
+			ivSize:      GCMNonceSize,
+			tagSize:     GCMTagSize,
+			payloadSize: len(plaintext),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, _ := NewCipher(k, WithInsecureModes())
+			c, err := a.EncryptCiphertext(test.mode, plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			c.KeyID, c.KDFParams, c.Epoch = test.keyID, test.kdf, test.epoch
+			b := c.Marshal()
+
+			h, err := Inspect(b)
+			if err != nil {
+				t.Fatalf("Error inspecting: %s", err)
+			}
+
+			if h.Format != "aes-go" || h.Version != CiphertextVersion || h.Mode != test.mode || h.KeyID != test.keyID || h.Epoch != test.epoch {
+				t.Errorf("Got: %+v", h)
+			}
+			if !bytes.Equal(h.IV, c.IV) || len(h.IV) != test.ivSize {
+				t.Errorf("Got: %x, Expected: %x", h.IV, c.IV)
+			}
+			if h.TagSize != test.tagSize {
+				t.Errorf("Expected %d, got %d", test.tagSize, h.TagSize)
+			}
+			if h.PayloadSize != test.payloadSize || h.HeaderSize+h.PayloadSize != len(b) {
+				t.Errorf("Expected a payload of %d bytes in %d, got %d after a %d byte header", test.payloadSize, len(b), h.PayloadSize, h.HeaderSize)
+			}
+			if h.ChunkSize != 0 || h.Chunks != 1 {
+				t.Errorf("Expected a single chunk, got %d of %d bytes", h.Chunks, h.ChunkSize)
+			}
+
+			if test.kdf == nil && h.KDF != nil {
+				t.Errorf("Expected no KDF, got %+v", h.KDF)
+			}
+			if test.kdf != nil && (h.KDF == nil || h.KDF.KDF != key.KDFScrypt || !bytes.Equal(h.KDF.Salt, params.Salt) || h.KDF.N != params.N) {
+				t.Errorf("Got: %+v, Expected: %+v", h.KDF, params)
+			}
+		})
+	}
+}
+
+func TestInspectInvalid(t *testing.T) {
+	if _, err := Inspect([]byte("not an envelope")); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	c := &Ciphertext{Version: CiphertextVersion, Mode: CTR, KDFParams: []byte{1, 2, 3}, IV: make([]byte, 16)}
+	if _, err := Inspect(c.Marshal()); err != key.ErrInvalidKDFParams {
+		t.Errorf("Expected %v, got %v", key.ErrInvalidKDFParams, err)
+	}
+}
@@ -0,0 +1,19 @@
+package aesgo
+
+import (
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// KeyCheckValue is the banking style KCV: encrypt a block of zeros and keep the first 3 bytes.
+// It lets two parties check they hold the same key without revealing it.
+func KeyCheckValue(k key.Key) ([3]byte, error) {
+	a, err := NewCipher(k)
+	if err != nil {
+		return [3]byte{}, err
+	}
+
+	c := primitives.FromState(a.EncryptBlock([16]byte{}))
+
+	return [3]byte(c[:3]), nil
+}
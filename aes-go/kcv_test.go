@@ -0,0 +1,34 @@
+package aesgo
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestKeyCheckValue(t *testing.T) {
+	// key from FIPS 197 Appendix A.1, a block of zeros encrypts to 7df76b0c1ab899b33e42f047b91b546f
+	material, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	k := key.NewKey([16]byte(material))
+
+	kcv, err := KeyCheckValue(k)
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if result := hex.EncodeToString(kcv[:]); result != "7df76b" {
+		t.Errorf("Got: %s, Expected: 7df76b", result)
+	}
+
+	fingerprint := k.Fingerprint()
+	expected := "d4ffb8b77f7d6b26196e9a070e983f6701a4c42dec813d4de1a535d20a7df536"
+	if result := hex.EncodeToString(fingerprint[:]); result != expected {
+		t.Errorf("Got: %s, Expected: %s", result, expected)
+	}
+
+	k.Destroy()
+	if _, err := KeyCheckValue(k); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
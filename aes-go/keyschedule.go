@@ -0,0 +1,52 @@
+package aesgo
+
+import (
+	"fmt"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// ExpandKey returns the round keys of k, from round 0 (the key itself) to the last round.
+func ExpandKey(k key.Key) ([][16]byte, error) {
+	a, err := NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return a.RoundKeys(), nil
+}
+
+// ExpandKeyWords returns the key schedule as the 4 byte words w[0], w[1], ... of FIPS-197 section 5.2,
+// which is how the walkthrough of Appendix A lists them.
+func ExpandKeyWords(k key.Key) ([][4]byte, error) {
+	roundKeys, err := ExpandKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	words := make([][4]byte, 0, len(roundKeys)*4)
+	for _, rk := range roundKeys {
+		for i := 0; i < 4; i++ {
+			words = append(words, [4]byte(rk[i*4:i*4+4]))
+		}
+	}
+	return words, nil
+}
+
+// Rounds returns the number of rounds, 10 for 128 bit keys and 14 for 256 bit keys unless set with NewWithRounds.
+func (a *AES) Rounds() int {
+	return a.rounds
+}
+
+// RoundKeys returns a copy of every round key.
+func (a *AES) RoundKeys() [][16]byte {
+	a.generateAllKeys()
+	return append([][16]byte{}, a.roundKeys...)
+}
+
+// RoundKey returns the key of one round, from 0 to Rounds().
+func (a *AES) RoundKey(round int) ([16]byte, error) {
+	if round < 0 || round > a.rounds {
+		return [16]byte{}, fmt.Errorf("Round must be between 0 and %d, got %d", a.rounds, round)
+	}
+	return a.RoundKeys()[round], nil
+}
@@ -0,0 +1,60 @@
+package aesgo
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestExpandKey(t *testing.T) {
+	// FIPS-197 Appendix A.1
+	k := key.NewKey([16]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c})
+
+	roundKeys, err := ExpandKey(k)
+	if err != nil {
+		t.Fatalf("Error expanding key: %s", err)
+	}
+
+	tests := []struct {
+		round    int
+		expected string
+	}{
+		{0, "2b7e151628aed2a6abf7158809cf4f3c"},
+		{1, "a0fafe1788542cb123a339392a6c7605"},
+		{5, "d4d1c6f87c839d87caf2b8bc11f915bc"},
+		{10, "d014f9a8c9ee2589e13f0cc8b6630ca6"},
+	}
+
+	if len(roundKeys) != 11 {
+		t.Fatalf("Expected 11 round keys, got %d", len(roundKeys))
+	}
+
+	a := New(k)
+	for _, test := range tests {
+		if got := hex.EncodeToString(roundKeys[test.round][:]); got != test.expected {
+			t.Errorf("Round %d. Expected %s, got %s", test.round, test.expected, got)
+		}
+
+		rk, err := a.RoundKey(test.round)
+		if err != nil {
+			t.Fatalf("Error getting round key: %s", err)
+		}
+		if rk != roundKeys[test.round] {
+			t.Errorf("Round %d. Expected %x, got %x", test.round, roundKeys[test.round], rk)
+		}
+	}
+
+	words, err := ExpandKeyWords(k)
+	if err != nil {
+		t.Fatalf("Error expanding key: %s", err)
+	}
+	// w[43] is the last word of Appendix A.1
+	if len(words) != 44 || hex.EncodeToString(words[43][:]) != "b6630ca6" {
+		t.Errorf("Expected 44 words ending with b6630ca6, got %d ending with %x", len(words), words[len(words)-1])
+	}
+
+	if _, err := a.RoundKey(11); err == nil {
+		t.Errorf("Expected error for round 11, got nil")
+	}
+}
@@ -0,0 +1,52 @@
+package aesgo
+
+import (
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// KeystreamReader produces the raw AES-CTR keystream: AES(nonce), AES(nonce+1), AES(nonce+2)...
+// CTR encryption is nothing more than XORing the plaintext with these bytes, which is why reusing
+// a nonce is fatal: the same keystream comes out again.
+//
+// Useful for masks and deterministic test data. It never returns an error, the stream is endless.
+type KeystreamReader struct {
+	a       *AES
+	counter []byte
+	// unused part of the current block
+	block []byte
+}
+
+// NewKeystreamReader starts the keystream at the 16 byte counter block nonce, the same as the IV of Encrypt(CTR).
+func NewKeystreamReader(k key.Key, nonce []byte) (*KeystreamReader, error) {
+	if len(nonce) != 16 {
+		return nil, ErrInvalidIV
+	}
+	if k.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	a, err := NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeystreamReader{a: a, counter: append([]byte{}, nonce...)}, nil
+}
+
+func (r *KeystreamReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.block) == 0 {
+			c := primitives.FromState(r.a.EncryptBlock([16]byte(r.counter)))
+			r.block = c[:]
+			// the 128 bit counter wraps around, so there is no error to return
+			incrementCounter(r.counter, Counter128)
+		}
+
+		c := copy(p[n:], r.block)
+		r.block = r.block[c:]
+		n += c
+	}
+	return n, nil
+}
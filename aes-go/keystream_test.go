@@ -0,0 +1,80 @@
+package aesgo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestKeystreamReader(t *testing.T) {
+	k := key.Bit128()
+	// the counter wraps in the last byte after a few blocks
+	nonce := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 0xfd}
+
+	block, _ := aes.NewCipher(k.GetBytes())
+	expected := make([]byte, 1000)
+	cipher.NewCTR(block, nonce).XORKeyStream(expected, expected)
+
+	for _, size := range []int{1, 7, 16, 33, 1000} {
+		r, err := NewKeystreamReader(k, nonce)
+		if err != nil {
+			t.Fatalf("Error creating reader: %s", err)
+		}
+
+		keystream := make([]byte, 1000)
+		for i := 0; i < len(keystream); i += size {
+			if _, err := io.ReadFull(r, keystream[i:min(i+size, len(keystream))]); err != nil {
+				t.Fatalf("Error reading: %s", err)
+			}
+		}
+
+		if !bytes.Equal(keystream, expected) {
+			t.Errorf("Reading %d bytes at a time: Got: %x, Expected: %x", size, keystream[:32], expected[:32])
+		}
+	}
+
+	// the nonce passed in is left alone
+	if nonce[15] != 0xfd {
+		t.Errorf("Nonce was modified: %x", nonce)
+	}
+}
+
+// Encrypting with CTR is the plaintext XOR the keystream.
+func TestKeystreamIsCTR(t *testing.T) {
+	k := key.Bit128()
+	nonce := bytes.Repeat([]byte{0x42}, 16)
+	plaintext := []byte("The quick brown fox jumps over the lazy dog")
+
+	a, _ := NewCipher(k, WithRandReader(bytes.NewReader(nonce)))
+	encrypted, err := a.Encrypt(CTR, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	r, _ := NewKeystreamReader(k, nonce)
+	keystream, err := io.ReadAll(io.LimitReader(iotest.OneByteReader(r), int64(len(plaintext))))
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+
+	if xored := xorBytes(plaintext, keystream); !bytes.Equal(xored, encrypted[16:]) {
+		t.Errorf("Got: %x, Expected: %x", xored, encrypted[16:])
+	}
+}
+
+func TestKeystreamReaderErrors(t *testing.T) {
+	if _, err := NewKeystreamReader(key.Bit128(), make([]byte, 12)); err != ErrInvalidIV {
+		t.Errorf("Expected %v, got %v", ErrInvalidIV, err)
+	}
+
+	k := key.Bit128()
+	k.Destroy()
+	if _, err := NewKeystreamReader(k, make([]byte, 16)); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
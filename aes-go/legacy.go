@@ -0,0 +1,51 @@
+package aesgo
+
+// Before Ciphertext, the output of Encrypt was all there was: iv || ciphertext for CBC and CTR,
+// nonce || ciphertext || tag for GCM and the raw blocks for ECB. Nothing in it says which mode
+// made it, so the mode has to be known to decrypt it.
+
+type Layout int
+
+const (
+	// LayoutLegacy is the raw output of Encrypt.
+	LayoutLegacy Layout = iota
+	// LayoutEnvelope is a marshaled Ciphertext.
+	LayoutEnvelope
+)
+
+// Sniff tells a marshaled Ciphertext from the raw output of Encrypt. It is an envelope when it
+// parses as one and the IV and tag have the sizes of its mode. A random IV passes all of that
+// with a probability below 2^-40.
+func Sniff(b []byte) Layout {
+	c, err := Unmarshal(b)
+	if err != nil {
+		return LayoutLegacy
+	}
+
+	switch {
+	case c.Mode == ECB && len(c.IV) == 0 && len(c.Tag) == 0,
+		(c.Mode == CBC || c.Mode == CTR) && len(c.IV) == 16 && len(c.Tag) == 0,
+		c.Mode == GCM && len(c.IV) == GCMNonceSize && len(c.Tag) == GCMTagSize:
+		return LayoutEnvelope
+	}
+	return LayoutLegacy
+}
+
+// DecryptLegacy decrypts the raw layout written by older versions, it's the same as Decrypt.
+func (a *AES) DecryptLegacy(mode Mode, ciphertext []byte) ([]byte, error) {
+	return a.Decrypt(mode, ciphertext)
+}
+
+// DecryptAny decrypts a marshaled Ciphertext or, when Sniff says it isn't one, the legacy layout
+// with mode. The mode of an envelope is the one recorded in it.
+func (a *AES) DecryptAny(mode Mode, ciphertext []byte) ([]byte, error) {
+	if Sniff(ciphertext) == LayoutLegacy {
+		return a.DecryptLegacy(mode, ciphertext)
+	}
+
+	c, err := Unmarshal(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return a.DecryptCiphertext(c)
+}
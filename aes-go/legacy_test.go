@@ -0,0 +1,69 @@
+package aesgo
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestDecryptAny(t *testing.T) {
+	a, _ := NewCipher(key.Bit128(), WithInsecureModes())
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, mode := range []Mode{ECB, CBC, CTR, GCM} {
+		legacy, err := a.Encrypt(mode, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		c, _ := a.EncryptCiphertext(mode, plaintext)
+		envelope := c.Marshal()
+
+		tests := []struct {
+			name string
+
+			ciphertext []byte
+
+			layout Layout
+		}{
+			{name: "legacy", ciphertext: legacy, layout: LayoutLegacy},
+			{name: "envelope", ciphertext: envelope, layout: LayoutEnvelope},
+		}
+
+		for _, test := range tests {
+			if got := Sniff(test.ciphertext); got != test.layout {
+				t.Errorf("Mode %d, %s. Expected %v, got %v", mode, test.name, test.layout, got)
+			}
+
+			got, err := a.DecryptAny(mode, test.ciphertext)
+			if err != nil {
+				t.Fatalf("Mode %d, %s. Error decrypting: %s", mode, test.name, err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("Mode %d, %s. Got: %s, Expected: %s", mode, test.name, got, plaintext)
+			}
+		}
+	}
+}
+
+func TestSniffLegacyThatLooksLikeAnEnvelope(t *testing.T) {
+	// an IV starting with the magic, version and mode, but the rest doesn't fit
+	iv := append([]byte{'A', 'G', CiphertextVersion, CBC, 0}, make([]byte, 11)...)
+	a, _ := NewCipher(key.Bit128(), WithRandReader(bytes.NewReader(iv)))
+
+	legacy, _ := a.Encrypt(CBC, []byte("Let's test if this is working!"))
+	if got := Sniff(legacy); got != LayoutLegacy {
+		t.Errorf("Expected %v, got %v", LayoutLegacy, got)
+	}
+
+	// random IVs
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		b := make([]byte, 48)
+		r.Read(b)
+		if got := Sniff(b); got != LayoutLegacy {
+			t.Fatalf("Expected %v, got %v for %x", LayoutLegacy, got, b)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package aesgo
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/nonce"
+)
+
+// WithLogger logs to l what happens to the cipher, to debug failures in production:
+//
+//   - key expansions and every Encrypt and Decrypt (with the Ciphertext and Batch functions built
+//     on them) with the mode, the IV or nonce and the size, at debug level
+//   - failures with a reason code (see Reason), at warn level
+//
+// The key, the round keys and the plaintext are never logged. IVs and nonces aren't secret, they
+// travel with the ciphertext.
+func WithLogger(l *slog.Logger) Option {
+	return func(a *AES) {
+		a.logger = l
+	}
+}
+
+// Reason returns a short code for an error of this package, "other" when it isn't one.
+func Reason(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrAuthentication):
+		return "authentication"
+	case errors.Is(err, ErrInvalidPadding):
+		return "padding"
+	case errors.Is(err, ErrEmptyCiphertext):
+		return "empty"
+	case errors.Is(err, ErrTruncatedCiphertext):
+		return "truncated"
+	case errors.Is(err, ErrMisalignedCiphertext), errors.Is(err, ErrNotBlockAligned):
+		return "misaligned"
+	case errors.Is(err, ErrInvalidIV), errors.Is(err, ErrInvalidNonce):
+		return "iv"
+	case errors.Is(err, nonce.ErrReuse):
+		return "replay"
+	case errors.Is(err, ErrInvalidCiphertext):
+		return "envelope"
+	case errors.Is(err, ErrInvalidMode), errors.Is(err, ErrInsecureMode):
+		return "mode"
+	case errors.Is(err, ErrBudgetExhausted):
+		return "budget"
+	case errors.Is(err, key.ErrDestroyed):
+		return "destroyed"
+	}
+	return "other"
+}
+
+func (a *AES) logKeyExpansion() {
+	if a.logger == nil {
+		return
+	}
+	a.logger.LogAttrs(context.Background(), slog.LevelDebug, "aesgo: key expanded",
+		slog.Int("key_bits", a.key.Len()*8),
+		slog.Int("rounds", a.rounds),
+	)
+}
+
+// logMessage logs an Encrypt or Decrypt. ciphertext is the output or the input, where the IV is,
+// and size is the size of the input.
+func (a *AES) logMessage(op Op, mode Mode, ciphertext []byte, size int, err error) {
+	if a.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("op", string(op)),
+		slog.String("mode", modeName(mode)),
+		slog.Int("size", size),
+	}
+	if iv := ivOf(mode, ciphertext); iv != nil {
+		attrs = append(attrs, slog.String("iv", hex.EncodeToString(iv)))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String("reason", Reason(err)), slog.String("error", err.Error()))
+		a.logger.LogAttrs(context.Background(), slog.LevelWarn, "aesgo: "+string(op)+" failed", attrs...)
+		return
+	}
+	a.logger.LogAttrs(context.Background(), slog.LevelDebug, "aesgo: "+string(op), attrs...)
+}
+
+// ivOf returns the IV or nonce at the start of a ciphertext, nil when there isn't one.
+func ivOf(mode Mode, ciphertext []byte) []byte {
+	n := 0
+	switch mode {
+	case CBC, CTR:
+		n = 16
+	case GCM:
+		n = GCMNonceSize
+	}
+	if n == 0 || len(ciphertext) < n {
+		return nil
+	}
+	return ciphertext[:n]
+}
+
+func modeName(mode Mode) string {
+	switch mode {
+	case ECB:
+		return "ECB"
+	case CBC:
+		return "CBC"
+	case CTR:
+		return "CTR"
+	case GCM:
+		return "GCM"
+	}
+	return "unknown"
+}
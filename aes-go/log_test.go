@@ -0,0 +1,81 @@
+package aesgo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestLogger(t *testing.T) {
+	k := key.Bit128()
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	a, _ := NewCipher(k, WithLogger(logger))
+
+	sealed, err := a.Encrypt(GCM, []byte("Let's test if this is working!"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := a.Decrypt(GCM, sealed); err != ErrAuthentication {
+		t.Fatalf("Expected %v, got %v", ErrAuthentication, err)
+	}
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("Error decoding %q: %s", line, err)
+		}
+		lines = append(lines, m)
+	}
+
+	expected := []map[string]any{
+		{"level": "DEBUG", "msg": "aesgo: key expanded", "key_bits": 128.0, "rounds": 10.0},
+		{"level": "DEBUG", "msg": "aesgo: encrypt", "mode": "GCM", "size": 30.0, "iv": hex.EncodeToString(sealed[:GCMNonceSize])},
+		{"level": "WARN", "msg": "aesgo: decrypt failed", "mode": "GCM", "reason": "authentication"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d:\n%s", len(expected), len(lines), out.String())
+	}
+	for i, e := range expected {
+		for field, v := range e {
+			if lines[i][field] != v {
+				t.Errorf("Line %d, %s. Expected %v, got %v", i, field, v, lines[i][field])
+			}
+		}
+	}
+
+	if strings.Contains(out.String(), hex.EncodeToString(k.GetBytes())) {
+		t.Errorf("The key was logged:\n%s", out.String())
+	}
+}
+
+func TestReason(t *testing.T) {
+	tests := []struct {
+		err error
+
+		expected string
+	}{
+		{nil, ""},
+		{ErrAuthentication, "authentication"},
+		{ErrInvalidPadding, "padding"},
+		{fmt.Errorf("%w: got 15 bytes", ErrTruncatedCiphertext), "truncated"},
+		{ErrInsecureMode, "mode"},
+		{key.ErrDestroyed, "destroyed"},
+		{errors.New("something else"), "other"},
+	}
+
+	for _, test := range tests {
+		if got := Reason(test.err); got != test.expected {
+			t.Errorf("%v. Expected %q, got %q", test.err, test.expected, got)
+		}
+	}
+}
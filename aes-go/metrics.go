@@ -0,0 +1,51 @@
+package aesgo
+
+import "time"
+
+// Op is what the cipher was asked to do.
+type Op string
+
+const (
+	OpEncrypt Op = "encrypt"
+	OpDecrypt Op = "decrypt"
+)
+
+// Event is one Encrypt or Decrypt, including the ones made by the Ciphertext and Batch functions.
+type Event struct {
+	Op   Op
+	Mode Mode
+	// Bytes is the size of the plaintext, 0 when decryption fails.
+	Bytes    int
+	Duration time.Duration
+	// Err is nil on success, Reason gives a short code for it.
+	Err error
+}
+
+// Metrics receives an Event after every message. Observe is called from the goroutine that
+// encrypts, it must be safe for concurrent use when the cipher is used through Batch or Pool.
+// See the prometheus subpackage for an implementation.
+type Metrics interface {
+	Observe(e Event)
+}
+
+// WithMetrics reports every message to m.
+func WithMetrics(m Metrics) Option {
+	return func(a *AES) {
+		a.metrics = m
+	}
+}
+
+// startTimer only reads the clock when someone is going to look at the duration.
+func (a *AES) startTimer() time.Time {
+	if a.metrics == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+func (a *AES) observe(op Op, mode Mode, n int, start time.Time, err error) {
+	if a.metrics == nil {
+		return
+	}
+	a.metrics.Observe(Event{Op: op, Mode: mode, Bytes: n, Duration: time.Since(start), Err: err})
+}
@@ -0,0 +1,47 @@
+package aesgo
+
+import (
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Observe(e Event) {
+	r.events = append(r.events, e)
+}
+
+func TestMetrics(t *testing.T) {
+	r := &recorder{}
+	a, _ := NewCipher(key.Bit128(), WithMetrics(r))
+	plaintext := []byte("Let's test if this is working!")
+
+	c, _ := a.EncryptCiphertext(CTR, plaintext)
+	if _, err := a.DecryptCiphertext(c); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if _, err := a.Decrypt(GCM, make([]byte, 40)); err != ErrAuthentication {
+		t.Fatalf("Expected %v, got %v", ErrAuthentication, err)
+	}
+
+	expected := []Event{
+		{Op: OpEncrypt, Mode: CTR, Bytes: len(plaintext)},
+		{Op: OpDecrypt, Mode: CTR, Bytes: len(plaintext)},
+		{Op: OpDecrypt, Mode: GCM, Err: ErrAuthentication},
+	}
+	if len(r.events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(r.events), r.events)
+	}
+	for i, e := range expected {
+		got := r.events[i]
+		if got.Op != e.Op || got.Mode != e.Mode || got.Bytes != e.Bytes || got.Err != e.Err {
+			t.Errorf("Event %d. Expected %+v, got %+v", i, e, got)
+		}
+		if got.Duration <= 0 {
+			t.Errorf("Event %d. Expected a duration, got %v", i, got.Duration)
+		}
+	}
+}
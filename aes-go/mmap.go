@@ -0,0 +1,42 @@
+package aesgo
+
+import (
+	"io"
+	"os"
+)
+
+// mapping is a file mapped in memory, writes to b go straight to the page cache. Where there is
+// no mmap, or it fails, the file is read in memory instead and written back on close.
+type mapping struct {
+	b []byte
+
+	// set when b is a copy of the file
+	f        *os.File
+	writable bool
+}
+
+// readFile is the fallback of mapFile. It works, but the point of the file API is lost: multi-GB
+// files need as much memory.
+func readFile(f *os.File, size int64, writable bool) (*mapping, error) {
+	b := make([]byte, size)
+	if _, err := f.ReadAt(b, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return &mapping{b: b, f: f, writable: writable}, nil
+}
+
+func (m *mapping) close() error {
+	if m.b == nil {
+		return nil
+	}
+	b := m.b
+	m.b = nil
+	if m.f == nil {
+		return unmap(b)
+	}
+	if !m.writable {
+		return nil
+	}
+	_, err := m.f.WriteAt(b, 0)
+	return err
+}
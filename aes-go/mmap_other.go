@@ -0,0 +1,20 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package aesgo
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/mario-areias/aes-go/platform"
+)
+
+func mapFile(f *os.File, size int64, writable bool) (*mapping, error) {
+	platform.Fallback(platform.Mmap, fmt.Sprintf("no mmap on %s, files are read in memory", runtime.GOOS))
+	return readFile(f, size, writable)
+}
+
+func unmap(b []byte) error {
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package aesgo
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/mario-areias/aes-go/platform"
+)
+
+func mapFile(f *os.File, size int64, writable bool) (*mapping, error) {
+	// mmap of 0 bytes fails
+	if size == 0 {
+		return &mapping{}, nil
+	}
+
+	prot := syscall.PROT_READ
+	if writable {
+		prot |= syscall.PROT_WRITE
+	}
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(size), prot, syscall.MAP_SHARED)
+	if err != nil {
+		// some file systems (FUSE, /proc) can't be mapped
+		platform.Fallback(platform.Mmap, fmt.Sprintf("mmap failed: %s, files are read in memory", err))
+		return readFile(f, size, writable)
+	}
+	return &mapping{b: b}, nil
+}
+
+func unmap(b []byte) error {
+	return syscall.Munmap(b)
+}
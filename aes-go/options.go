@@ -0,0 +1,110 @@
+package aesgo
+
+import (
+	"errors"
+	"io"
+)
+
+type Padding int
+
+const (
+	// PKCS7 pads the last block with the number of missing bytes, a full block of 0x10 is added
+	// when the plaintext is already a multiple of 16.
+	PKCS7 Padding = iota
+	// NoPadding expects the plaintext to be a multiple of 16 bytes. Useful for test vectors.
+	NoPadding
+)
+
+var (
+	ErrInvalidOption   = errors.New("Invalid option")
+	ErrNotBlockAligned = errors.New("Input must be a multiple of 16 bytes when padding is disabled")
+	ErrInsecureMode    = errors.New("ECB leaks patterns of the plaintext, it requires WithInsecureModes")
+)
+
+// Option configures the cipher returned by New and NewCipher.
+type Option func(*AES)
+
+// WithMode sets the mode used by Seal and Open. Defaults to CBC.
+func WithMode(mode Mode) Option {
+	return func(a *AES) {
+		a.mode = mode
+	}
+}
+
+// WithInsecureModes allows ECB, which is rejected with ErrInsecureMode otherwise. Equal plaintext
+// blocks give equal ciphertext blocks, it's only here for the attacks and the test vectors.
+func WithInsecureModes() Option {
+	return func(a *AES) {
+		a.insecureModes = true
+	}
+}
+
+// WithPadding sets the padding scheme used by ECB and CBC. Defaults to PKCS7.
+func WithPadding(p Padding) Option {
+	return func(a *AES) {
+		a.padding = p
+	}
+}
+
+// WithRandReader sets the source used to generate IVs and nonces. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(a *AES) {
+		a.rand = r
+	}
+}
+
+// WithParallelism sets how many goroutines can be used to encrypt independent blocks (ECB and CTR).
+// Defaults to 1, which means everything runs sequentially.
+func WithParallelism(n int) Option {
+	return func(a *AES) {
+		a.parallelism = n
+	}
+}
+
+// WithConstantTime replaces the S-box table lookups with a scan of the whole table,
+// so the memory access pattern doesn't depend on the secret bytes.
+// It is a lot slower, but it shows how cache-timing attacks are avoided.
+func WithConstantTime() Option {
+	return func(a *AES) {
+		a.constantTime = true
+	}
+}
+
+// WithBudget counts every encryption against b and fails with ErrBudgetExhausted once its limits
+// are reached. Decryption isn't counted.
+func WithBudget(b *Budget) Option {
+	return func(a *AES) {
+		a.budget = b
+	}
+}
+
+func (a *AES) validateOptions() error {
+	switch {
+	case a.mode != ECB && a.mode != CBC && a.mode != CTR && a.mode != GCM:
+		return ErrInvalidOption
+	case a.padding != PKCS7 && a.padding != NoPadding:
+		return ErrInvalidOption
+	case a.rand == nil:
+		return ErrInvalidOption
+	case a.parallelism < 1:
+		return ErrInvalidOption
+	case a.counterLayout != Counter128 && a.counterLayout != Counter32:
+		return ErrInvalidOption
+	case a.fault != nil && !a.fault.valid(a.rounds):
+		return ErrInvalidOption
+	case a.crossCheck != nil && (a.fault != nil || !a.standardRounds()):
+		return ErrInvalidOption
+	case a.bitsliced && (a.fault != nil || a.tracer != nil):
+		return ErrInvalidOption
+	case a.policy != Lenient && a.policy != Strict:
+		return ErrInvalidOption
+	}
+	return a.allowMode(a.mode)
+}
+
+func (a *AES) allowMode(mode Mode) error {
+	if mode == ECB && !a.insecureModes {
+		return ErrInsecureMode
+	}
+	return nil
+}
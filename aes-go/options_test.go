@@ -0,0 +1,177 @@
+package aesgo
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+func TestOptions(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	plaintext := []byte("The quick brown fox jumps over the lazy dog 1234")
+
+	tests := []struct {
+		name string
+
+		opts []Option
+	}{
+		{
+			name: "default options",
+		},
+		{
+			name: "ECB with parallelism",
+
+			opts: []Option{WithMode(ECB), WithParallelism(4), WithInsecureModes()},
+		},
+		{
+			name: "CTR with parallelism",
+
+			opts: []Option{WithMode(CTR), WithParallelism(3)},
+		},
+		{
+			name: "CBC constant time",
+
+			opts: []Option{WithMode(CBC), WithConstantTime()},
+		},
+		{
+			name: "CBC without padding",
+
+			opts: []Option{WithMode(CBC), WithPadding(NoPadding)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			aes, err := NewCipher(k, test.opts...)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			encrypted, err := aes.Seal(plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+
+			decrypted, err := aes.Open(encrypted)
+			if err != nil {
+				t.Fatalf("Error decrypting: %s", err)
+			}
+
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+			}
+		})
+	}
+}
+
+func TestOptionsMatchDefaults(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	plaintext := []byte("Let's test if this is working!")
+
+	iv := bytes.Repeat([]byte{0x42}, 16)
+
+	plain := New(k, WithRandReader(bytes.NewReader(iv)))
+	tuned := New(k, WithRandReader(bytes.NewReader(iv)), WithConstantTime())
+
+	a, err := plain.Encrypt(CBC, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	b, err := tuned.Encrypt(CBC, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	if !bytes.Equal(a, b) {
+		t.Errorf("Constant time output differs. Got: %02x, Expected: %02x", b, a)
+	}
+
+	if !bytes.Equal(a[:16], iv) {
+		t.Errorf("IV was not read from the rand reader. Got: %02x", a[:16])
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+
+	if _, err := NewCipher(k, WithParallelism(0)); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+
+	if _, err := NewCipher(k, WithMode(Mode(42))); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+
+	if _, err := NewCipher(k, WithFault(Fault{Round: 11})); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+
+	aes := New(k, WithPadding(NoPadding), WithInsecureModes())
+	if _, err := aes.Encrypt(ECB, []byte("not aligned")); err != ErrNotBlockAligned {
+		t.Errorf("Expected %v, got %v", ErrNotBlockAligned, err)
+	}
+}
+
+func TestFault(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	plaintext := [16]byte([]byte("single block!!!!"))
+
+	correct := New(k)
+	expected := primitives.FromState(correct.EncryptBlock(plaintext))
+
+	// a zero mask doesn't change anything
+	noop := New(k, WithFault(Fault{Round: 9, Mask: 0}))
+	if got := primitives.FromState(noop.EncryptBlock(plaintext)); got != expected {
+		t.Errorf("Expected %x, got %x", expected, got)
+	}
+
+	// a fault before round 9 changes exactly 4 bytes of the output (one column is mixed, then spread by ShiftRows)
+	faulty := New(k, WithFault(Fault{Round: 9, Row: 1, Column: 2, Mask: 0x42}))
+	got := primitives.FromState(faulty.EncryptBlock(plaintext))
+
+	diff := 0
+	for i := range got {
+		if got[i] != expected[i] {
+			diff++
+		}
+	}
+	if diff != 4 {
+		t.Errorf("Expected 4 different bytes, got %d (%x vs %x)", diff, got, expected)
+	}
+}
+
+func TestECBRequiresOptIn(t *testing.T) {
+	k := key.Bit128()
+
+	if _, err := NewCipher(k, WithMode(ECB)); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+
+	aes, _ := NewCipher(k)
+	if _, err := aes.Encrypt(ECB, []byte("YELLOW SUBMARINE")); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+	if _, err := aes.Decrypt(ECB, make([]byte, 16)); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+	if _, err := aes.NewEncryptWriter(io.Discard, &Ciphertext{Mode: ECB}); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+	if _, err := aes.NewDecryptReader(&Ciphertext{Mode: ECB}, bytes.NewReader(nil)); err != ErrInsecureMode {
+		t.Errorf("Expected %v, got %v", ErrInsecureMode, err)
+	}
+
+	// the safe modes don't need it
+	if _, err := aes.Encrypt(GCM, []byte("YELLOW SUBMARINE")); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	insecure, _ := NewCipher(k, WithInsecureModes())
+	if _, err := insecure.Encrypt(ECB, []byte("YELLOW SUBMARINE")); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
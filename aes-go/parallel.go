@@ -0,0 +1,164 @@
+package aesgo
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// CTR blocks don't depend on each other, so a stream can be cut in chunks of fileChunk bytes,
+// chunk i starts at counter IV + i*fileChunk/16, and the chunks encrypted by a pool of workers.
+// They finish in any order and are written in order, the output is the same as EncryptStream.
+
+// EncryptParallel is EncryptStream with the chunks of a CTR stream spread over workers goroutines.
+// At most 2*workers chunks are in memory. The other modes and compressed streams go through
+// EncryptStream.
+func (a *AES) EncryptParallel(ctx context.Context, dst io.Writer, src io.Reader, c *Ciphertext, workers int) error {
+	if workers < 1 {
+		return ErrInvalidOption
+	}
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return a.EncryptStream(ctx, dst, src, c)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if _, err := a.NewEncryptWriter(dst, c); err != nil {
+		return err
+	}
+	return a.xorParallel(ctx, dst, src, c.IV, workers, true)
+}
+
+// DecryptParallel is DecryptStream with the chunks spread like EncryptParallel.
+func (a *AES) DecryptParallel(ctx context.Context, dst io.Writer, src io.Reader, c *Ciphertext, workers int) error {
+	if workers < 1 {
+		return ErrInvalidOption
+	}
+	if c.Mode != CTR || c.Compression != NoCompression {
+		return a.DecryptStream(ctx, dst, src, c)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// checks the key, the mode and the IV, and records the nonce with Strict
+	if _, err := a.NewDecryptReader(c, src); err != nil {
+		return err
+	}
+	return a.xorParallel(ctx, dst, src, c.IV, workers, false)
+}
+
+type chunk struct {
+	index int
+	data  []byte
+	err   error
+}
+
+// xorParallel reads the chunks in one goroutine, XORs them with the keystream in workers and
+// writes them back in order in the calling goroutine. spend counts the bytes against the budget.
+func (a *AES) xorParallel(ctx context.Context, dst io.Writer, src io.Reader, iv []byte, workers int, spend bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.expandKeys()
+
+	// before the reader starts, it may seek
+	p := a.newProgress()
+	p.total = remaining(src)
+
+	jobs := make(chan *chunk)
+	results := make(chan *chunk, workers)
+	// taken by the reader and given back once the chunk is written, so a slow chunk doesn't let
+	// the others pile up
+	inFlight := make(chan struct{}, 2*workers)
+	readErr := make(chan error, 1)
+
+	go func() {
+		defer close(jobs)
+
+		for i := 0; ; i++ {
+			select {
+			case inFlight <- struct{}{}:
+			case <-ctx.Done():
+				readErr <- ctx.Err()
+				return
+			}
+
+			buf := make([]byte, fileChunk)
+			n, err := io.ReadFull(src, buf)
+			if n > 0 {
+				if spend {
+					if err := a.spend(0, n); err != nil {
+						readErr <- err
+						return
+					}
+				}
+				select {
+				case jobs <- &chunk{index: i, data: buf[:n]}:
+				case <-ctx.Done():
+					readErr <- ctx.Err()
+					return
+				}
+			}
+
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				readErr <- nil
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(c *AES) {
+			defer wg.Done()
+
+			// the goroutines are per chunk, blocks of a chunk are encrypted sequentially
+			c.parallelism = 1
+
+			for ch := range jobs {
+				ch.err = c.xorChunk(ch.data, ch.data, iv, ch.index*fileChunk)
+				select {
+				case results <- ch:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(a.clone())
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]*chunk)
+	next := 0
+	for ch := range results {
+		if ch.err != nil {
+			return ch.err
+		}
+		pending[ch.index] = ch
+
+		for ch, ok := pending[next]; ok; ch, ok = pending[next] {
+			delete(pending, next)
+			if _, err := dst.Write(ch.data); err != nil {
+				return err
+			}
+			p.add(len(ch.data))
+			<-inFlight
+			next++
+		}
+	}
+
+	if err := <-readErr; err != nil {
+		return err
+	}
+	// the workers drop their chunks when ctx is done
+	return ctx.Err()
+}
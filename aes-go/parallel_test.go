@@ -0,0 +1,75 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	k := key.Bit128()
+
+	for _, mode := range []Mode{CTR, CBC} {
+		for _, size := range []int{0, 1000, 2*fileChunk + 3} {
+			for _, workers := range []int{1, 4} {
+				plaintext := make([]byte, size)
+				r.Read(plaintext)
+				iv := make([]byte, 16)
+				r.Read(iv)
+
+				a, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+				var encrypted bytes.Buffer
+				if err := a.EncryptParallel(context.Background(), &encrypted, bytes.NewReader(plaintext), &Ciphertext{Mode: mode}, workers); err != nil {
+					t.Fatalf("Error encrypting: %s", err)
+				}
+
+				// chunks come back in order, so it's the same as one goroutine
+				stream, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+				var expected bytes.Buffer
+				stream.EncryptStream(context.Background(), &expected, bytes.NewReader(plaintext), &Ciphertext{Mode: mode})
+				if !bytes.Equal(encrypted.Bytes(), expected.Bytes()) {
+					t.Errorf("Mode %d, %d bytes with %d workers. Expected the bytes of EncryptStream", mode, size, workers)
+				}
+
+				src := bytes.NewReader(encrypted.Bytes())
+				c, _ := ReadHeader(src)
+				var decrypted bytes.Buffer
+				if err := a.DecryptParallel(context.Background(), &decrypted, src, c, workers); err != nil {
+					t.Fatalf("Error decrypting: %s", err)
+				}
+				if !bytes.Equal(decrypted.Bytes(), plaintext) {
+					t.Errorf("Mode %d, %d bytes with %d workers. Decrypted %d bytes, expected %d", mode, size, workers, decrypted.Len(), len(plaintext))
+				}
+			}
+		}
+	}
+}
+
+func TestParallelErrors(t *testing.T) {
+	a, _ := NewCipher(key.Bit128())
+	plaintext := make([]byte, 3*fileChunk)
+
+	if err := a.EncryptParallel(context.Background(), &bytes.Buffer{}, bytes.NewReader(plaintext), &Ciphertext{Mode: CTR}, 0); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var partial bytes.Buffer
+	src := &cancelReader{r: bytes.NewReader(plaintext), n: fileChunk, cancel: cancel}
+	if err := a.EncryptParallel(ctx, &partial, src, &Ciphertext{Mode: CTR}, 2); err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if partial.Len() >= len(plaintext) {
+		t.Errorf("Expected partial output, got %d bytes", partial.Len())
+	}
+
+	b := NewBudget(Limits{Bytes: 100})
+	limited, _ := NewCipher(key.Bit128(), WithBudget(b))
+	if err := limited.EncryptParallel(context.Background(), &bytes.Buffer{}, bytes.NewReader(plaintext), &Ciphertext{Mode: CTR}, 2); err != ErrBudgetExhausted {
+		t.Errorf("Expected %v, got %v", ErrBudgetExhausted, err)
+	}
+}
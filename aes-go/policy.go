@@ -0,0 +1,64 @@
+package aesgo
+
+import (
+	"fmt"
+
+	"github.com/mario-areias/aes-go/nonce"
+)
+
+// Policy says what decryption does with ciphertexts that decrypt fine but look wrong.
+//
+// PKCS7 padding is checked byte by byte under both policies, a plaintext length only has one
+// valid padding so there is nothing to relax. Unknown envelope fields are rejected under both
+// too, their length isn't known so the rest can't be parsed.
+type Policy int
+
+const (
+	// Lenient decrypts anything well formed. It's the default, the demos and attacks need it.
+	Lenient Policy = iota
+	// Strict also rejects:
+	//
+	//   - ciphertexts with only an IV or nonce, or with only a tag for GCM
+	//   - an IV or nonce that another ciphertext already used with the key, like a replayed
+	//     message. They are all kept in memory, see nonce.Tracker.
+	//   - a Ciphertext with fields its mode doesn't use, like a tag for CBC
+	Strict
+)
+
+// WithPolicy sets how decryption handles anomalies. Defaults to Lenient.
+func WithPolicy(p Policy) Option {
+	return func(a *AES) {
+		a.policy = p
+		if p == Strict {
+			a.nonces = nonce.NewTracker()
+		}
+	}
+}
+
+// checkBody rejects a ciphertext with nothing encrypted in it. The length was already validated.
+func (a *AES) checkBody(mode Mode, encrypted []byte) error {
+	if a.policy != Strict {
+		return nil
+	}
+
+	body := len(encrypted)
+	switch mode {
+	case CBC, CTR:
+		body -= 16
+	case GCM:
+		body -= GCMNonceSize + GCMTagSize
+	}
+	if body == 0 {
+		return fmt.Errorf("%w: only the IV or nonce is there", ErrEmptyCiphertext)
+	}
+	return nil
+}
+
+// useNonce records the IV or nonce of a ciphertext and fails with nonce.ErrReuse the second time.
+// GCM nonces are only recorded once the tag is verified, a forgery mustn't block the real message.
+func (a *AES) useNonce(iv []byte) error {
+	if a.policy != Strict {
+		return nil
+	}
+	return a.nonces.Use(a.key, iv)
+}
@@ -0,0 +1,149 @@
+package aesgo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/nonce"
+)
+
+func TestPolicy(t *testing.T) {
+	k := key.Bit128()
+	plaintext := []byte("Let's test if this is working!")
+
+	encrypt := func(mode Mode, plaintext []byte) []byte {
+		a, _ := NewCipher(k, WithPadding(NoPadding))
+		b, err := a.Encrypt(mode, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+
+		mode       Mode
+		ciphertext []byte
+		// decrypted twice, the second time is a replay
+		twice bool
+
+		strict error
+	}{
+		{
+			name: "CTR with only the nonce",
+
+			mode:       CTR,
+			ciphertext: encrypt(CTR, nil),
+
+			strict: ErrEmptyCiphertext,
+		},
+		{
+			name: "CBC with only the IV",
+
+			mode:       CBC,
+			ciphertext: encrypt(CBC, nil),
+
+			strict: ErrEmptyCiphertext,
+		},
+		{
+			name: "GCM with only the nonce and tag",
+
+			mode:       GCM,
+			ciphertext: encrypt(GCM, nil),
+
+			strict: ErrEmptyCiphertext,
+		},
+		{
+			name: "CTR replayed",
+
+			mode:       CTR,
+			ciphertext: encrypt(CTR, plaintext),
+			twice:      true,
+
+			strict: nonce.ErrReuse,
+		},
+		{
+			name: "GCM replayed",
+
+			mode:       GCM,
+			ciphertext: encrypt(GCM, plaintext),
+			twice:      true,
+
+			strict: nonce.ErrReuse,
+		},
+		{
+			name: "GCM decrypted once",
+
+			mode:       GCM,
+			ciphertext: encrypt(GCM, plaintext),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, policy := range []Policy{Lenient, Strict} {
+				a, _ := NewCipher(k, WithPadding(NoPadding), WithPolicy(policy))
+
+				_, err := a.Decrypt(test.mode, test.ciphertext)
+				if test.twice && err == nil {
+					_, err = a.Decrypt(test.mode, test.ciphertext)
+				}
+
+				var expected error
+				if policy == Strict {
+					expected = test.strict
+				}
+				if !errors.Is(err, expected) {
+					t.Errorf("Policy %d. Expected %v, got %v", policy, expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStrictForgeryDoesNotBurnTheNonce(t *testing.T) {
+	a, _ := NewCipher(key.Bit128(), WithPolicy(Strict))
+	sealed, _ := a.Encrypt(GCM, []byte("Let's test if this is working!"))
+
+	forged := bytes.Clone(sealed)
+	forged[len(forged)-1] ^= 1
+	if _, err := a.Decrypt(GCM, forged); err != ErrAuthentication {
+		t.Errorf("Expected %v, got %v", ErrAuthentication, err)
+	}
+
+	if _, err := a.Decrypt(GCM, sealed); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
+
+func TestStrictCiphertext(t *testing.T) {
+	k := key.Bit128()
+	lenient, _ := NewCipher(k)
+	strict, _ := NewCipher(k, WithPolicy(Strict))
+
+	c, _ := lenient.EncryptCiphertext(CTR, []byte("Let's test if this is working!"))
+	c.Tag = []byte("tag")
+
+	if _, err := lenient.DecryptCiphertext(c); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if _, err := strict.DecryptCiphertext(c); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	// the stream checks the IV too
+	c.Tag = nil
+	if _, err := strict.DecryptCiphertext(c); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+	if _, err := strict.NewDecryptReader(c, bytes.NewReader(c.Body)); err != nonce.ErrReuse {
+		t.Errorf("Expected %v, got %v", nonce.ErrReuse, err)
+	}
+
+	if _, err := NewCipher(k, WithPolicy(Policy(7))); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+}
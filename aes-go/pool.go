@@ -0,0 +1,63 @@
+package aesgo
+
+import (
+	"sync"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Pool hands out ciphers for the same key to concurrent goroutines. An AES isn't safe for
+// concurrent use, and creating one per request means expanding the key every time. The pool
+// expands it once and recycles the ciphers, with their round keys, through a sync.Pool.
+//
+// Every cipher shares the options of NewPool, so the reader of WithRandReader must be safe for
+// concurrent use. crypto/rand, the default, is.
+type Pool struct {
+	template *AES
+	pool     sync.Pool
+}
+
+func NewPool(k key.Key, opts ...Option) (*Pool, error) {
+	a, err := NewCipher(k, opts...)
+	if err != nil {
+		return nil, err
+	}
+	a.expandKeys()
+
+	p := &Pool{template: a}
+	p.pool.New = func() any {
+		return p.template.clone()
+	}
+	return p, nil
+}
+
+// Get returns a cipher ready to use. It must not be used after it is given back with Put. All the
+// ciphers share the key, calling Destroy on one of them is the same as Pool.Destroy.
+func (p *Pool) Get() *AES {
+	return p.pool.Get().(*AES)
+}
+
+// Put gives back a cipher returned by Get.
+func (p *Pool) Put(a *AES) {
+	p.pool.Put(a)
+}
+
+// Encrypt is Get, Encrypt and Put.
+func (p *Pool) Encrypt(mode Mode, plaintext []byte) ([]byte, error) {
+	a := p.Get()
+	defer p.Put(a)
+	return a.Encrypt(mode, plaintext)
+}
+
+// Decrypt is Get, Decrypt and Put.
+func (p *Pool) Decrypt(mode Mode, encrypted []byte) ([]byte, error) {
+	a := p.Get()
+	defer p.Put(a)
+	return a.Decrypt(mode, encrypted)
+}
+
+// Destroy wipes the key. Every cipher of the pool returns key.ErrDestroyed from then on. The round
+// keys of the idle ciphers are only released when the garbage collector clears the sync.Pool.
+func (p *Pool) Destroy() {
+	p.template.Destroy()
+}
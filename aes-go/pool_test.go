@@ -0,0 +1,73 @@
+package aesgo
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestPool(t *testing.T) {
+	k := key.Bit128()
+	p, err := NewPool(k)
+	if err != nil {
+		t.Fatalf("Error creating pool: %s", err)
+	}
+	a, _ := NewCipher(k)
+
+	// run with -race, every goroutine must get its own cipher
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				plaintext := []byte(fmt.Sprintf("record %d of goroutine %d", i, g))
+
+				encrypted, err := p.Encrypt(GCM, plaintext)
+				if err != nil {
+					t.Errorf("Error encrypting: %s", err)
+					return
+				}
+				decrypted, err := p.Decrypt(GCM, encrypted)
+				if err != nil || !bytes.Equal(decrypted, plaintext) {
+					t.Errorf("Got: %s, Expected: %s (%v)", decrypted, plaintext, err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	// ciphers from the pool are the same as a new one
+	c := p.Get()
+	encrypted, _ := c.Encrypt(CBC, []byte("hello"))
+	p.Put(c)
+
+	decrypted, err := a.Decrypt(CBC, encrypted)
+	if err != nil || string(decrypted) != "hello" {
+		t.Errorf("Got: %s, Expected: hello (%v)", decrypted, err)
+	}
+}
+
+func TestPoolDestroy(t *testing.T) {
+	p, _ := NewPool(key.Bit128())
+
+	c := p.Get()
+	p.Destroy()
+
+	if _, err := c.Encrypt(CBC, []byte("hello")); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+	if _, err := p.Encrypt(CBC, []byte("hello")); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
+
+func TestPoolInvalid(t *testing.T) {
+	if _, err := NewPool(key.Bit128(), WithParallelism(0)); err != ErrInvalidOption {
+		t.Errorf("Expected %v, got %v", ErrInvalidOption, err)
+	}
+}
@@ -0,0 +1,54 @@
+package aesgo
+
+import "io"
+
+// WithProgress calls fn as the streaming API consumes its input: the plaintext written to an
+// EncryptWriter and the ciphertext read by a DecryptReader. processed is the bytes so far, total is
+// -1 when the size isn't known. EncryptStream and DecryptStream know it when src has a Len method,
+// like bytes.Reader, or can seek, like a file. fn is called from the goroutine that encrypts, once
+// per Write or Read, so it should be quick.
+func WithProgress(fn func(processed, total int64)) Option {
+	return func(a *AES) {
+		a.progress = fn
+	}
+}
+
+type progress struct {
+	fn        func(processed, total int64)
+	processed int64
+	total     int64
+}
+
+func (a *AES) newProgress() progress {
+	return progress{fn: a.progress, total: -1}
+}
+
+func (p *progress) add(n int) {
+	if p.fn == nil || n == 0 {
+		return
+	}
+	p.processed += int64(n)
+	p.fn(p.processed, p.total)
+}
+
+// remaining is how many bytes are left in r, or -1.
+func remaining(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	}
+	return -1
+}
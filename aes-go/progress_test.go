@@ -0,0 +1,126 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+type progressCall struct {
+	processed, total int64
+}
+
+func TestProgress(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("a"), 3*streamChunk+5)
+
+	for _, mode := range []Mode{CBC, CTR} {
+		var calls []progressCall
+		a, _ := NewCipher(key.Bit128(), WithProgress(func(processed, total int64) {
+			calls = append(calls, progressCall{processed, total})
+		}))
+
+		var encrypted bytes.Buffer
+		if err := a.EncryptStream(context.Background(), &encrypted, bytes.NewReader(plaintext), &Ciphertext{Mode: mode}); err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		if len(calls) != 4 {
+			t.Fatalf("Mode %d. Expected 4 calls, got %d", mode, len(calls))
+		}
+		if last := calls[len(calls)-1]; last != (progressCall{int64(len(plaintext)), int64(len(plaintext))}) {
+			t.Errorf("Mode %d. Expected %d of %d, got %v", mode, len(plaintext), len(plaintext), last)
+		}
+
+		calls = nil
+		r := bytes.NewReader(encrypted.Bytes())
+		c, _ := ReadHeader(r)
+		body := int64(r.Len())
+
+		if err := a.DecryptStream(context.Background(), io.Discard, r, c); err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		for i := 1; i < len(calls); i++ {
+			if calls[i].processed <= calls[i-1].processed {
+				t.Errorf("Mode %d. Expected progress to grow, got %v", mode, calls)
+			}
+		}
+		if last := calls[len(calls)-1]; last != (progressCall{body, body}) {
+			t.Errorf("Mode %d. Expected %d of %d, got %v", mode, body, body, last)
+		}
+	}
+}
+
+func TestProgressUnknownSize(t *testing.T) {
+	var calls []progressCall
+	a, _ := NewCipher(key.Bit128(), WithProgress(func(processed, total int64) {
+		calls = append(calls, progressCall{processed, total})
+	}))
+
+	w, _ := a.NewEncryptWriter(io.Discard, &Ciphertext{Mode: CBC})
+	w.Write([]byte("0123456789"))
+	w.Write([]byte("0123456789"))
+	w.Close()
+
+	expected := []progressCall{{10, -1}, {20, -1}}
+	if len(calls) != len(expected) || calls[0] != expected[0] || calls[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, calls)
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.Write(make([]byte, 100))
+	f.Seek(30, io.SeekStart)
+
+	tests := []struct {
+		name string
+
+		r io.Reader
+
+		expected int64
+	}{
+		{
+			name: "len",
+
+			r: bytes.NewReader(make([]byte, 42)),
+
+			expected: 42,
+		},
+		{
+			name: "seeker",
+
+			r: f,
+
+			expected: 70,
+		},
+		{
+			name: "unknown",
+
+			r: io.LimitReader(bytes.NewReader(nil), 1),
+
+			expected: -1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := remaining(test.r); got != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+
+	// the position of the file isn't moved
+	if pos, _ := f.Seek(0, io.SeekCurrent); pos != 30 {
+		t.Errorf("Expected %v, got %v", 30, pos)
+	}
+}
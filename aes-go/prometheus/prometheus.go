@@ -0,0 +1,196 @@
+// Package prometheus collects the aesgo.Metrics of a cipher and serves them in the Prometheus text
+// format, so they can be scraped without adding the Prometheus client library as a dependency:
+//
+//	c := prometheus.New()
+//	a, err := aesgo.NewCipher(k, aesgo.WithMetrics(c))
+//	http.Handle("/metrics", c)
+//
+// The metrics are labeled by op (encrypt or decrypt) and mode:
+//
+//	aesgo_operations_total                  every message, failed or not
+//	aesgo_bytes_total                       plaintext bytes of the successful ones
+//	aesgo_failures_total                    failures, also labeled by reason (see aesgo.Reason)
+//	aesgo_operation_duration_seconds        a histogram of how long messages take
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+// DefaultBuckets are the upper bounds of the duration histogram in seconds, from 1µs to 1s.
+var DefaultBuckets = []float64{1e-6, 5e-6, 1e-5, 5e-5, 1e-4, 5e-4, 1e-3, 5e-3, 1e-2, 0.1, 1}
+
+var modeNames = map[aesgo.Mode]string{
+	aesgo.ECB: "ECB",
+	aesgo.CBC: "CBC",
+	aesgo.CTR: "CTR",
+	aesgo.GCM: "GCM",
+}
+
+type labels struct {
+	op   aesgo.Op
+	mode string
+}
+
+type failureLabels struct {
+	labels
+	reason string
+}
+
+type histogram struct {
+	// counts[i] is the number of observations <= buckets[i], the last one is +Inf
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Collector implements aesgo.Metrics and http.Handler. It is safe for concurrent use, one
+// Collector can be shared by several ciphers.
+type Collector struct {
+	buckets []float64
+
+	mu        sync.Mutex
+	ops       map[labels]uint64
+	bytes     map[labels]uint64
+	failures  map[failureLabels]uint64
+	durations map[labels]*histogram
+}
+
+func New() *Collector {
+	return NewWithBuckets(DefaultBuckets)
+}
+
+// NewWithBuckets uses other upper bounds for the duration histogram, in seconds and increasing.
+func NewWithBuckets(buckets []float64) *Collector {
+	return &Collector{
+		buckets:   slices.Clone(buckets),
+		ops:       make(map[labels]uint64),
+		bytes:     make(map[labels]uint64),
+		failures:  make(map[failureLabels]uint64),
+		durations: make(map[labels]*histogram),
+	}
+}
+
+func (c *Collector) Observe(e aesgo.Event) {
+	l := labels{op: e.Op, mode: modeNames[e.Mode]}
+	if l.mode == "" {
+		l.mode = "unknown"
+	}
+	seconds := e.Duration.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ops[l]++
+	if e.Err != nil {
+		c.failures[failureLabels{l, aesgo.Reason(e.Err)}]++
+	} else {
+		c.bytes[l] += uint64(e.Bytes)
+	}
+
+	h, ok := c.durations[l]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(c.buckets)+1)}
+		c.durations[l] = h
+	}
+	for i, b := range c.buckets {
+		if seconds <= b {
+			h.counts[i]++
+		}
+	}
+	h.counts[len(c.buckets)]++
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the text exposition format, sorted so the output is stable.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	writeCounter(cw, "aesgo_operations_total", "Messages encrypted or decrypted, failed or not.", c.ops)
+	writeCounter(cw, "aesgo_bytes_total", "Plaintext bytes of the successful messages.", c.bytes)
+
+	fmt.Fprintf(cw, "# HELP aesgo_failures_total Messages that failed, by reason.\n# TYPE aesgo_failures_total counter\n")
+	for _, l := range sortedKeys(c.failures, func(l failureLabels) string { return l.key() + l.reason }) {
+		fmt.Fprintf(cw, "aesgo_failures_total{%s,reason=%q} %d\n", l.labels, l.reason, c.failures[l])
+	}
+
+	fmt.Fprintf(cw, "# HELP aesgo_operation_duration_seconds Time to encrypt or decrypt a message.\n# TYPE aesgo_operation_duration_seconds histogram\n")
+	for _, l := range sortedKeys(c.durations, labels.key) {
+		h := c.durations[l]
+		for i, b := range c.buckets {
+			fmt.Fprintf(cw, "aesgo_operation_duration_seconds_bucket{%s,le=%q} %d\n", l, formatFloat(b), h.counts[i])
+		}
+		fmt.Fprintf(cw, "aesgo_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l, h.counts[len(c.buckets)])
+		fmt.Fprintf(cw, "aesgo_operation_duration_seconds_sum{%s} %s\n", l, formatFloat(h.sum))
+		fmt.Fprintf(cw, "aesgo_operation_duration_seconds_count{%s} %d\n", l, h.count)
+	}
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, cw.w.Flush()
+}
+
+func writeCounter(w io.Writer, name, help string, values map[labels]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, l := range sortedKeys(values, labels.key) {
+		fmt.Fprintf(w, "%s{%s} %d\n", name, l, values[l])
+	}
+}
+
+func (l labels) String() string {
+	return fmt.Sprintf("op=%q,mode=%q", l.op, l.mode)
+}
+
+func (l labels) key() string {
+	return string(l.op) + "/" + l.mode
+}
+
+func sortedKeys[K comparable, V any](m map[K]V, key func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b K) int { return strings.Compare(key(a), key(b)) })
+	return keys
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter keeps the first error, so the writes above don't need to check every one.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
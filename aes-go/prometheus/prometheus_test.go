@@ -0,0 +1,77 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewWithBuckets([]float64{0.001, 0.01})
+
+	c.Observe(aesgo.Event{Op: aesgo.OpEncrypt, Mode: aesgo.GCM, Bytes: 100, Duration: 500 * time.Microsecond})
+	c.Observe(aesgo.Event{Op: aesgo.OpEncrypt, Mode: aesgo.GCM, Bytes: 50, Duration: 5 * time.Millisecond})
+	c.Observe(aesgo.Event{Op: aesgo.OpDecrypt, Mode: aesgo.GCM, Duration: 20 * time.Millisecond, Err: aesgo.ErrAuthentication})
+
+	var b strings.Builder
+	if _, err := c.WriteTo(&b); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+
+	expected := `# HELP aesgo_operations_total Messages encrypted or decrypted, failed or not.
+# TYPE aesgo_operations_total counter
+aesgo_operations_total{op="decrypt",mode="GCM"} 1
+aesgo_operations_total{op="encrypt",mode="GCM"} 2
+# HELP aesgo_bytes_total Plaintext bytes of the successful messages.
+# TYPE aesgo_bytes_total counter
+aesgo_bytes_total{op="encrypt",mode="GCM"} 150
+# HELP aesgo_failures_total Messages that failed, by reason.
+# TYPE aesgo_failures_total counter
+aesgo_failures_total{op="decrypt",mode="GCM",reason="authentication"} 1
+# HELP aesgo_operation_duration_seconds Time to encrypt or decrypt a message.
+# TYPE aesgo_operation_duration_seconds histogram
+aesgo_operation_duration_seconds_bucket{op="decrypt",mode="GCM",le="0.001"} 0
+aesgo_operation_duration_seconds_bucket{op="decrypt",mode="GCM",le="0.01"} 0
+aesgo_operation_duration_seconds_bucket{op="decrypt",mode="GCM",le="+Inf"} 1
+aesgo_operation_duration_seconds_sum{op="decrypt",mode="GCM"} 0.02
+aesgo_operation_duration_seconds_count{op="decrypt",mode="GCM"} 1
+aesgo_operation_duration_seconds_bucket{op="encrypt",mode="GCM",le="0.001"} 1
+aesgo_operation_duration_seconds_bucket{op="encrypt",mode="GCM",le="0.01"} 2
+aesgo_operation_duration_seconds_bucket{op="encrypt",mode="GCM",le="+Inf"} 2
+aesgo_operation_duration_seconds_sum{op="encrypt",mode="GCM"} 0.0055
+aesgo_operation_duration_seconds_count{op="encrypt",mode="GCM"} 2
+`
+	if b.String() != expected {
+		t.Errorf("Got:\n%s\nExpected:\n%s", b.String(), expected)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	c := New()
+	a, _ := aesgo.NewCipher(key.Bit128(), aesgo.WithMetrics(c), aesgo.WithParallelism(4))
+
+	// Batch observes from several goroutines
+	messages := make([][]byte, 20)
+	for i := range messages {
+		messages[i] = []byte("Let's test if this is working!")
+	}
+	if _, err := a.EncryptBatch(aesgo.CTR, messages); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Got: %s", ct)
+	}
+	for _, line := range []string{`aesgo_operations_total{op="encrypt",mode="CTR"} 20`, `aesgo_bytes_total{op="encrypt",mode="CTR"} 600`} {
+		if !strings.Contains(w.Body.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package aesgo
+
+import (
+	"context"
+	"io"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Rekeying moves data from a key to another without the caller ever seeing the plaintext. The
+// mode is kept and a new IV or nonce is generated. The header gets newKeyID as its KeyID, an empty
+// one drops it. KDFParams and Epoch only make sense for the old key and are dropped too: a file
+// encrypted with a passphrase is rekeyed to a raw key. RekeyStream keeps the compression, Rekey
+// decompresses like DecryptCiphertext and doesn't compress again.
+
+// Rekey decrypts a marshaled Ciphertext with oldKey and encrypts it again with newKey. opts are
+// given to both ciphers, WithInsecureModes is needed for ECB.
+func Rekey(oldKey, newKey key.Key, newKeyID string, ciphertext []byte, opts ...Option) ([]byte, error) {
+	c, err := Unmarshal(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	from, err := NewCipher(oldKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	to, err := NewCipher(newKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := from.DecryptCiphertext(c)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+
+	rekeyed, err := to.EncryptCiphertext(c.Mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	rekeyed.KeyID = newKeyID
+
+	return rekeyed.Marshal(), nil
+}
+
+// RekeyStream is Rekey for a stream made by EncryptStream, c is the header returned by
+// ReadHeader. Only a chunk of the plaintext is in memory at a time. Like DecryptStream, CTR isn't
+// authenticated and CBC only fails on the padding at the end, so what is in dst must be discarded
+// on error.
+func RekeyStream(ctx context.Context, dst io.Writer, src io.Reader, c *Ciphertext, oldKey, newKey key.Key, newKeyID string, opts ...Option) error {
+	from, err := NewCipher(oldKey, opts...)
+	if err != nil {
+		return err
+	}
+	to, err := NewCipher(newKey, opts...)
+	if err != nil {
+		return err
+	}
+
+	dr, err := from.NewDecryptReader(c, src)
+	if err != nil {
+		return err
+	}
+	dr.progress.total = remaining(src)
+
+	return to.EncryptStream(ctx, dst, dr, &Ciphertext{Mode: c.Mode, KeyID: newKeyID, Compression: c.Compression})
+}
@@ -0,0 +1,96 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestRekey(t *testing.T) {
+	oldKey, newKey := key.Bit128(), key.Bit256()
+	from, _ := NewCipher(oldKey, WithInsecureModes())
+	to, _ := NewCipher(newKey, WithInsecureModes())
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, mode := range []Mode{ECB, CBC, CTR, GCM} {
+		c, err := from.EncryptCiphertext(mode, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		c.KeyID = "v1"
+		c.Epoch = 3
+
+		rekeyed, err := Rekey(oldKey, newKey, "v2", c.Marshal(), WithInsecureModes())
+		if err != nil {
+			t.Fatalf("Mode %d: Error rekeying: %s", mode, err)
+		}
+
+		parsed, err := Unmarshal(rekeyed)
+		if err != nil {
+			t.Fatalf("Error unmarshaling: %s", err)
+		}
+		if parsed.Mode != mode || parsed.KeyID != "v2" || parsed.Epoch != 0 {
+			t.Errorf("Mode %d: Unexpected header %+v", mode, parsed)
+		}
+		if mode != ECB && bytes.Equal(parsed.IV, c.IV) {
+			t.Errorf("Mode %d: Expected a new IV, got %x again", mode, parsed.IV)
+		}
+
+		decrypted, err := to.DecryptCiphertext(parsed)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Mode %d: Got: %s, Expected: %s (%v)", mode, decrypted, plaintext, err)
+		}
+	}
+
+	// the wrong old key doesn't produce anything
+	c, _ := from.EncryptCiphertext(GCM, plaintext)
+	if _, err := Rekey(key.Bit128(), newKey, "v2", c.Marshal()); err != ErrAuthentication {
+		t.Errorf("Expected %v, got %v", ErrAuthentication, err)
+	}
+	if _, err := Rekey(oldKey, newKey, "v2", []byte("not an envelope")); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+}
+
+func TestRekeyStream(t *testing.T) {
+	oldKey, newKey := key.Bit128(), key.Bit128()
+	from, _ := NewCipher(oldKey)
+	to, _ := NewCipher(newKey)
+	plaintext := bytes.Repeat([]byte("0123456789abcdefghij"), 10000)
+
+	for _, mode := range []Mode{CBC, CTR} {
+		var encrypted, rekeyed, decrypted bytes.Buffer
+		if err := from.EncryptStream(context.Background(), &encrypted, bytes.NewReader(plaintext), &Ciphertext{Mode: mode, KeyID: "v1"}); err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		c, err := ReadHeader(&encrypted)
+		if err != nil {
+			t.Fatalf("Error reading header: %s", err)
+		}
+		if err := RekeyStream(context.Background(), &rekeyed, &encrypted, c, oldKey, newKey, "v2"); err != nil {
+			t.Fatalf("Mode %d: Error rekeying: %s", mode, err)
+		}
+
+		c, err = ReadHeader(&rekeyed)
+		if err != nil {
+			t.Fatalf("Error reading header: %s", err)
+		}
+		if c.KeyID != "v2" || c.Mode != mode {
+			t.Errorf("Mode %d: Unexpected header %+v", mode, c)
+		}
+		if err := to.DecryptStream(context.Background(), &decrypted, &rekeyed, c); err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Errorf("Mode %d: rekeyed stream differs", mode)
+		}
+	}
+
+	// GCM envelopes aren't streams
+	if err := RekeyStream(context.Background(), &bytes.Buffer{}, bytes.NewReader(nil), &Ciphertext{Mode: GCM}, oldKey, newKey, ""); err != ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", ErrInvalidMode, err)
+	}
+}
@@ -0,0 +1,37 @@
+package aesgo
+
+import (
+	"errors"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+var ErrInvalidRounds = errors.New("Rounds must be between 1 and the rounds of the key size (10 or 14)")
+
+// NewWithRounds returns AES with only the given number of rounds. The last round still skips MixColumns,
+// so 10 rounds (14 for 256 bit keys) is the real AES.
+//
+// UNSAFE: this is for cryptanalysis exercises. With 4 rounds or fewer there are practical attacks
+// (square/integral, differential), never use a reduced cipher to protect anything.
+func NewWithRounds(k key.Key, rounds int, opts ...Option) (*AES, error) {
+	a, err := NewCipher(k, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rounds < 1 || rounds > a.rounds {
+		return nil, ErrInvalidRounds
+	}
+
+	// only the keys of the rounds we run are generated
+	a.rounds = rounds
+	a.roundKeys = make([][16]byte, rounds+1)
+	a.expanded = false
+
+	// the fault round depends on the number of rounds
+	if err := a.validateOptions(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
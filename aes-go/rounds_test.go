@@ -0,0 +1,119 @@
+package aesgo
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+func TestNewWithRounds(t *testing.T) {
+	k := key.NewKey([16]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c})
+	block := [16]byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0xe0, 0x37, 0x07, 0x34}
+
+	full := New(k)
+	all := full.RoundKeys()
+
+	t.Run("10 rounds is AES", func(t *testing.T) {
+		a, err := NewWithRounds(k, 10)
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+		if a.EncryptBlock(block) != full.EncryptBlock(block) {
+			t.Errorf("Expected the same block as AES")
+		}
+	})
+
+	t.Run("1 round", func(t *testing.T) {
+		a, err := NewWithRounds(k, 1)
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+
+		// the only round is also the last one, so no MixColumns
+		s := primitives.AddRoundKey(primitives.ToState(block), primitives.ToState(all[0]))
+		s = primitives.ShiftRows(primitives.SubBytes(s))
+		expected := primitives.AddRoundKey(s, primitives.ToState(all[1]))
+
+		if got := a.EncryptBlock(block); got != expected {
+			t.Errorf("Expected %x, got %x", expected, got)
+		}
+	})
+
+	t.Run("4 rounds", func(t *testing.T) {
+		a, err := NewWithRounds(k, 4, WithMode(CBC))
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+
+		if a.Rounds() != 4 {
+			t.Errorf("Expected 4 rounds, got %d", a.Rounds())
+		}
+
+		roundKeys := a.RoundKeys()
+		if len(roundKeys) != 5 {
+			t.Fatalf("Expected 5 round keys, got %d", len(roundKeys))
+		}
+		for i := range roundKeys {
+			if roundKeys[i] != all[i] {
+				t.Errorf("Round %d. Expected %x, got %x", i, all[i], roundKeys[i])
+			}
+		}
+
+		plaintext := []byte("reduced rounds still decrypt what they encrypt")
+		encrypted, err := a.Encrypt(CBC, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		decrypted, err := a.Decrypt(CBC, encrypted)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+		}
+	})
+}
+
+func TestNewWithRoundsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+
+		rounds int
+		opts   []Option
+
+		expected error
+	}{
+		{
+			name: "zero rounds",
+
+			rounds: 0,
+
+			expected: ErrInvalidRounds,
+		},
+		{
+			name: "more than AES",
+
+			rounds: 11,
+
+			expected: ErrInvalidRounds,
+		},
+		{
+			name: "fault after the last round",
+
+			rounds: 3,
+			opts:   []Option{WithFault(Fault{Round: 5, Mask: 1})},
+
+			expected: ErrInvalidOption,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWithRounds(key.Bit128(), tt.rounds, tt.opts...); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
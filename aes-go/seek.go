@@ -0,0 +1,142 @@
+package aesgo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// CTR turns every block into an independent keystream block, block i is encrypted with the
+// counter IV+i. So any part of the plaintext can be decrypted without touching what comes
+// before it, which is what makes random reads of an encrypted file possible.
+
+var ErrInvalidOffset = errors.New("Invalid offset")
+
+// DecryptAt decrypts length bytes of plaintext starting at offset from a CTR ciphertext, the IV
+// followed by the body as returned by Encrypt(CTR). Like io.ReaderAt it returns io.EOF together
+// with the bytes that were there when the ciphertext ends first.
+func (a *AES) DecryptAt(ciphertext io.ReaderAt, offset, length int64) ([]byte, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if offset < 0 || length < 0 || offset > math.MaxInt64-16 {
+		return nil, ErrInvalidOffset
+	}
+
+	iv := make([]byte, 16)
+	if _, err := ciphertext.ReadAt(iv, 0); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: must have at least 16 bytes for the nonce", ErrTruncatedCiphertext)
+		}
+		return nil, err
+	}
+	if length == 0 {
+		return []byte{}, nil
+	}
+
+	// read from the start of the block offset is in
+	skip := offset % 16
+	buf, err := readAt(ciphertext, 16+offset-skip, skip+min(length, math.MaxInt64-skip))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(buf)) <= skip {
+		return nil, io.EOF
+	}
+
+	if err := addCounter(iv, uint64(offset/16), a.counterLayout); err != nil {
+		return nil, err
+	}
+	decrypted, err := a.encryptCTR(buf, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := decrypted[16+skip:]
+	if int64(len(plaintext)) < length {
+		return plaintext, io.EOF
+	}
+	return plaintext, nil
+}
+
+// readChunk is how much readAt asks for at a time.
+const readChunk = 1 << 20
+
+// readAt reads up to n bytes at off, less when r ends first. n comes from the caller of DecryptAt
+// and can be anything, so the buffer only grows with what is really there.
+func readAt(r io.ReaderAt, off, n int64) ([]byte, error) {
+	var buf []byte
+	for int64(len(buf)) < n {
+		start := len(buf)
+		chunk := min(n-int64(start), readChunk)
+		buf = append(buf, make([]byte, chunk)...)
+
+		read, err := r.ReadAt(buf[start:], off+int64(start))
+		buf = buf[:start+read]
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if int64(read) < chunk {
+			return buf, nil
+		}
+	}
+	return buf, nil
+}
+
+// SeekableReader decrypts a CTR ciphertext from any position, e.g. an *os.File.
+type SeekableReader struct {
+	a          *AES
+	ciphertext io.ReaderAt
+	// plaintext size and the current position in it
+	size   int64
+	offset int64
+}
+
+// NewSeekableReader reads the ciphertext of size bytes, IV included, through DecryptAt.
+func (a *AES) NewSeekableReader(ciphertext io.ReaderAt, size int64) (*SeekableReader, error) {
+	if size < 16 {
+		return nil, fmt.Errorf("%w: must have at least 16 bytes for the nonce, got %d", ErrTruncatedCiphertext, size)
+	}
+	return &SeekableReader{a: a, ciphertext: ciphertext, size: size - 16}, nil
+}
+
+func (r *SeekableReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	plaintext, err := r.a.DecryptAt(r.ciphertext, r.offset, min(int64(len(p)), r.size-r.offset))
+	n := copy(p, plaintext)
+	r.offset += int64(n)
+
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (r *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, ErrInvalidOffset
+	}
+
+	if offset < 0 {
+		return 0, ErrInvalidOffset
+	}
+	r.offset = offset
+	return offset, nil
+}
+
+// Size is the size of the plaintext.
+func (r *SeekableReader) Size() int64 {
+	return r.size
+}
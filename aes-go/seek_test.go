@@ -0,0 +1,169 @@
+package aesgo
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestDecryptAt(t *testing.T) {
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+
+	for _, layout := range []CounterLayout{Counter128, Counter32} {
+		a, _ := NewCipher(key.Bit128(), WithCounterLayout(layout))
+		encrypted, err := a.Encrypt(CTR, plaintext)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		r := bytes.NewReader(encrypted)
+
+		tests := []struct {
+			name string
+
+			offset int64
+			length int64
+
+			expected []byte
+			err      error
+		}{
+			{name: "start", offset: 0, length: 16, expected: plaintext[:16]},
+			{name: "inside a block", offset: 5, length: 3, expected: plaintext[5:8]},
+			{name: "across blocks", offset: 30, length: 100, expected: plaintext[30:130]},
+			{name: "last byte", offset: 999, length: 1, expected: plaintext[999:]},
+			{name: "empty", offset: 500, length: 0, expected: []byte{}},
+			{name: "past the end", offset: 990, length: 20, expected: plaintext[990:], err: io.EOF},
+			{name: "after the end", offset: 1000, length: 1, err: io.EOF},
+			{name: "huge length", offset: 990, length: math.MaxInt64, expected: plaintext[990:], err: io.EOF},
+			{name: "huge offset", offset: math.MaxInt64, length: 1, err: ErrInvalidOffset},
+			{name: "negative offset", offset: -1, length: 1, err: ErrInvalidOffset},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				got, err := a.DecryptAt(r, test.offset, test.length)
+				if err != test.err {
+					t.Errorf("Expected %v, got %v", test.err, err)
+				}
+				if !bytes.Equal(got, test.expected) {
+					t.Errorf("Got: %x, Expected: %x", got, test.expected)
+				}
+			})
+		}
+	}
+}
+
+// The counter of a block far from the IV must carry into the upper bytes.
+func TestDecryptAtCarry(t *testing.T) {
+	k := key.Bit128()
+	iv := append(make([]byte, 8), bytes.Repeat([]byte{0xff}, 8)...)
+	plaintext := bytes.Repeat([]byte("carry"), 20)
+
+	a, _ := NewCipher(k, WithRandReader(bytes.NewReader(iv)))
+	encrypted, _ := a.Encrypt(CTR, plaintext)
+
+	got, err := a.DecryptAt(bytes.NewReader(encrypted), 40, 50)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if !bytes.Equal(got, plaintext[40:90]) {
+		t.Errorf("Got: %s, Expected: %s", got, plaintext[40:90])
+	}
+}
+
+func TestSeekableReader(t *testing.T) {
+	plaintext := bytes.Repeat([]byte("0123456789abcdefghij"), 50)
+
+	a, _ := NewCipher(key.Bit128())
+	encrypted, _ := a.Encrypt(CTR, plaintext)
+
+	r, err := a.NewSeekableReader(bytes.NewReader(encrypted), int64(len(encrypted)))
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+	if r.Size() != int64(len(plaintext)) {
+		t.Errorf("Expected %v, got %v", len(plaintext), r.Size())
+	}
+
+	// checks reading, seeking and the position with several read sizes
+	if err := iotest.TestReader(r, plaintext); err != nil {
+		t.Fatalf("Error testing reader: %s", err)
+	}
+
+	tests := []struct {
+		name string
+
+		offset int64
+		whence int
+
+		expected int64
+	}{
+		{name: "start", offset: 123, whence: io.SeekStart, expected: 123},
+		{name: "current", offset: 10, whence: io.SeekCurrent, expected: 133},
+		{name: "end", offset: -7, whence: io.SeekEnd, expected: int64(len(plaintext)) - 7},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pos, err := r.Seek(test.offset, test.whence)
+			if err != nil {
+				t.Fatalf("Error seeking: %s", err)
+			}
+			if pos != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, pos)
+			}
+
+			got, err := io.ReadAll(io.LimitReader(r, 5))
+			if err != nil {
+				t.Fatalf("Error reading: %s", err)
+			}
+			expected := plaintext[pos:min(pos+5, int64(len(plaintext)))]
+			if !bytes.Equal(got, expected) {
+				t.Errorf("Got: %s, Expected: %s", got, expected)
+			}
+			r.Seek(pos, io.SeekStart)
+		})
+	}
+
+	if _, err := r.Seek(-1, io.SeekStart); err != ErrInvalidOffset {
+		t.Errorf("Expected %v, got %v", ErrInvalidOffset, err)
+	}
+	if _, err := a.NewSeekableReader(bytes.NewReader(encrypted[:10]), 10); err == nil {
+		t.Errorf("Expected an error for a truncated ciphertext")
+	}
+}
+
+func TestAddCounter(t *testing.T) {
+	for _, layout := range []CounterLayout{Counter128, Counter32} {
+		for _, n := range []uint64{0, 1, 255, 256, 70000} {
+			start := bytes.Repeat([]byte{0xfe}, 16)
+			if layout == Counter32 {
+				start[12], start[13] = 0, 0
+			}
+
+			expected := append([]byte{}, start...)
+			for i := uint64(0); i < n; i++ {
+				incrementCounter(expected, layout)
+			}
+
+			got := append([]byte{}, start...)
+			if err := addCounter(got, n, layout); err != nil {
+				t.Fatalf("Error adding: %s", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("layout %d n %d: Got: %x, Expected: %x", layout, n, got, expected)
+			}
+		}
+	}
+
+	counter := append(make([]byte, 12), 0xff, 0xff, 0xff, 0xf0)
+	if err := addCounter(counter, 16, Counter32); err != ErrCounterExhausted {
+		t.Errorf("Expected %v, got %v", ErrCounterExhausted, err)
+	}
+}
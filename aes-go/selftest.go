@@ -0,0 +1,77 @@
+package aesgo
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var ErrSelfTestFailed = errors.New("AES self test failed, the package is disabled")
+
+type knownAnswer struct {
+	key        [16]byte
+	plaintext  [16]byte
+	ciphertext [16]byte
+}
+
+// selfTestVectors come from FIPS 197, Appendix B and Appendix C.1
+var selfTestVectors = []knownAnswer{
+	{
+		key:        [16]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c},
+		plaintext:  [16]byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0xe0, 0x37, 0x07, 0x34},
+		ciphertext: [16]byte{0x39, 0x25, 0x84, 0x1d, 0x02, 0xdc, 0x09, 0xfb, 0xdc, 0x11, 0x85, 0x97, 0x19, 0x6a, 0x0b, 0x32},
+	},
+	{
+		key:        [16]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+		plaintext:  [16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
+		ciphertext: [16]byte{0x69, 0xc4, 0xe0, 0xd8, 0x6a, 0x7b, 0x04, 0x30, 0xd8, 0xcd, 0xb7, 0x80, 0x70, 0xb4, 0xc5, 0x5a},
+	},
+}
+
+// selfTest runs the known answer tests the first time a cipher is created, like the power-on
+// self tests FIPS 140 modules do. If any of them fails, NewCipher returns ErrSelfTestFailed from then on.
+var selfTest = sync.OnceValue(func() bool {
+	return runSelfTest(selfTestVectors)
+})
+
+// SelfTestPassed runs the self test if it hasn't run yet and reports the result.
+func SelfTestPassed() bool {
+	return selfTest()
+}
+
+func runSelfTest(vectors []knownAnswer) bool {
+	for _, v := range vectors {
+		a := &AES{key: selfTestKey(v.key), rounds: 10, roundKeys: make([][16]byte, 11)}
+
+		if primitives.FromState(a.EncryptBlock(v.plaintext)) != v.ciphertext {
+			return false
+		}
+
+		if primitives.FromState(a.DecryptBlock(v.ciphertext)) != v.plaintext {
+			return false
+		}
+	}
+	return true
+}
+
+// selfTestKey is a minimal key.Key, the key package could be the one that is broken.
+type selfTestKey [16]byte
+
+func (k selfTestKey) GetBytes() []byte {
+	return k[:]
+}
+
+func (k selfTestKey) Len() int {
+	return len(k)
+}
+
+func (k selfTestKey) Destroy() {}
+
+func (k selfTestKey) Destroyed() bool {
+	return false
+}
+
+func (k selfTestKey) Fingerprint() [32]byte {
+	return [32]byte{}
+}
@@ -0,0 +1,16 @@
+package aesgo
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	if !SelfTestPassed() {
+		t.Fatalf("Self test failed")
+	}
+
+	broken := []knownAnswer{selfTestVectors[0]}
+	broken[0].ciphertext[0] ^= 1
+
+	if runSelfTest(broken) {
+		t.Errorf("Expected the self test to fail with a wrong ciphertext")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package aesgo
 
 import (
 	"bytes"
@@ -7,19 +7,23 @@ import (
 	"fmt"
 	"testing"
 
-	aesgo "github.com/mario-areias/aes-go/aes-go"
 	"github.com/mario-areias/aes-go/key"
 )
 
 func TestCBCStd(t *testing.T) {
-	k := key.Bit128()
+	for _, k := range []key.Key{key.Bit128(), key.Bit256()} {
+		testCBCStd(t, k)
+	}
+}
 
-	aes := aesgo.New(k)
+func testCBCStd(t *testing.T, k key.Key) {
+
+	aes := New(k)
 
 	plaintext := []byte("Let's test if this is working!")
 
 	// encrypt with our implementation and decrypt with std
-	cipher, err := aes.Encrypt(aesgo.CBC, plaintext)
+	cipher, err := aes.Encrypt(CBC, plaintext)
 	if err != nil {
 		t.Errorf("Error encrypting: %s", err)
 	}
@@ -40,7 +44,7 @@ func TestCBCStd(t *testing.T) {
 		t.Errorf("Error encrypting: %s", err)
 	}
 
-	decrypted, err = aes.Decrypt(aesgo.CBC, encrypted)
+	decrypted, err = aes.Decrypt(CBC, encrypted)
 	if err != nil {
 		t.Errorf("Error decrypting: %s", err)
 	}
@@ -53,12 +57,12 @@ func TestCBCStd(t *testing.T) {
 func TestCTRStd(t *testing.T) {
 	k := key.Bit128()
 
-	aes := aesgo.New(k)
+	aes := New(k)
 
 	plaintext := []byte("Let's test if this is working!")
 
 	// encrypt with our implementation and decrypt with std
-	cipher, err := aes.Encrypt(aesgo.CTR, plaintext)
+	cipher, err := aes.Encrypt(CTR, plaintext)
 	if err != nil {
 		t.Errorf("Error encrypting: %s", err)
 	}
@@ -79,7 +83,7 @@ func TestCTRStd(t *testing.T) {
 		t.Errorf("Error encrypting: %s", err)
 	}
 
-	decrypted, err = aes.Decrypt(aesgo.CTR, append(nonce, encrypted...))
+	decrypted, err = aes.Decrypt(CTR, append(nonce, encrypted...))
 	if err != nil {
 		t.Errorf("Error decrypting: %s", err)
 	}
@@ -89,31 +93,6 @@ func TestCTRStd(t *testing.T) {
 	}
 }
 
-func TestPaddingOracleAttackWithStdEncryption(t *testing.T) {
-	k := key.Bit128()
-	iv := key.Bit128().GetBytes()
-
-	plaintext := []byte("Let's test if this attack works!!")
-
-	o := Oracle{key: k}
-
-	stdEncrypted, err := stdCBCEncrypt(plaintext, k.GetBytes(), iv)
-	if err != nil {
-		t.Errorf("Error encrypting: %s", err)
-	}
-
-	decrypted := PaddingOracle(o, stdEncrypted)
-
-	unpadded, err := aesgo.RemovePadding(decrypted)
-	if err != nil {
-		t.Errorf("Error removing padding: %s", err)
-	}
-
-	if plaintextStr := string(plaintext); plaintextStr != string(unpadded) {
-		t.Errorf("Decrypted text does not match plaintext. Got: %s, Expected: %s", unpadded, plaintextStr)
-	}
-}
-
 // Function to stdCBCEncrypt plaintext using AES in CBC mode
 func stdCBCEncrypt(plainText, key, iv []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
@@ -0,0 +1,510 @@
+package aesgo
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var ErrClosed = errors.New("Stream already closed")
+
+// The streaming API produces exactly the same bytes as Ciphertext.Marshal, header first and then
+// the body, but without holding the whole plaintext in memory.
+
+// EncryptWriter encrypts everything written to it. Close must be called to write the last block.
+type EncryptWriter struct {
+	a    *AES
+	w    io.Writer
+	mode Mode
+
+	// CBC chaining value
+	prev []byte
+	// CTR counter, the unused part of the current keystream block and whether the
+	// counter ran out (only with Counter32)
+	counter   []byte
+	keystream []byte
+	exhausted bool
+
+	// plaintext waiting for a full block (ECB and CBC)
+	buf    []byte
+	closed bool
+
+	// compresses what is written before it is encrypted
+	zw *gzip.Writer
+
+	progress progress
+}
+
+// NewEncryptWriter writes the header of c to w and returns a writer that encrypts into w.
+// Mode, KeyID, KDFParams, Epoch and Compression are taken from c. The IV is generated and stored in c, Body and Tag are ignored.
+func (a *AES) NewEncryptWriter(w io.Writer, c *Ciphertext) (*EncryptWriter, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if err := a.allowMode(c.Mode); err != nil {
+		return nil, err
+	}
+	if c.Compression != NoCompression && !c.Compression.valid() {
+		return nil, ErrInvalidOption
+	}
+
+	ew := &EncryptWriter{a: a, w: w, mode: c.Mode, progress: a.newProgress()}
+
+	switch c.Mode {
+	case ECB:
+		c.IV = nil
+	case CBC, CTR:
+		newIV := a.randomBlock
+		if c.Mode == CTR {
+			newIV = a.ctrIV
+		}
+		iv, err := newIV()
+		if err != nil {
+			return nil, err
+		}
+		c.IV = iv
+		ew.prev = iv
+		ew.counter = append([]byte{}, iv...)
+	default:
+		return nil, ErrInvalidMode
+	}
+
+	// the bytes are counted as they are written
+	if err := a.spend(1, 0); err != nil {
+		return nil, err
+	}
+
+	header := &Ciphertext{Version: CiphertextVersion, Mode: c.Mode, KeyID: c.KeyID, KDFParams: c.KDFParams, Epoch: c.Epoch, Compression: c.Compression, IV: c.IV}
+	if _, err := w.Write(header.Marshal()); err != nil {
+		return nil, err
+	}
+
+	if c.Compression == Gzip {
+		ew.zw = gzip.NewWriter(writerFunc(ew.write))
+	}
+
+	return ew, nil
+}
+
+func (ew *EncryptWriter) Write(p []byte) (int, error) {
+	if ew.closed {
+		return 0, ErrClosed
+	}
+
+	w := io.Writer(writerFunc(ew.write))
+	if ew.zw != nil {
+		w = ew.zw
+	}
+	if _, err := w.Write(p); err != nil {
+		return 0, err
+	}
+
+	ew.progress.add(len(p))
+	return len(p), nil
+}
+
+// write encrypts p, after the compression.
+func (ew *EncryptWriter) write(p []byte) (int, error) {
+	if err := ew.a.spend(0, len(p)); err != nil {
+		return 0, err
+	}
+
+	if ew.mode == CTR {
+		encrypted, err := ew.xorKeyStream(p)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := ew.w.Write(encrypted); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	ew.buf = append(ew.buf, p...)
+
+	full := len(ew.buf) / 16 * 16
+	if full > 0 {
+		if _, err := ew.w.Write(ew.encryptBlocks(ew.buf[:full])); err != nil {
+			return 0, err
+		}
+		ew.buf = append(ew.buf[:0], ew.buf[full:]...)
+	}
+
+	return len(p), nil
+}
+
+// Close flushes the compression, then pads and encrypts the last block. It doesn't close the
+// underlying writer.
+func (ew *EncryptWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+
+	if ew.zw != nil {
+		if err := ew.zw.Close(); err != nil {
+			return err
+		}
+	}
+
+	if ew.mode == CTR {
+		return nil
+	}
+
+	last, err := ew.a.createBlocks(ew.buf)
+	if err != nil {
+		return err
+	}
+
+	_, err = ew.w.Write(ew.encryptBlocks(join(last)))
+	return err
+}
+
+func (ew *EncryptWriter) encryptBlocks(b []byte) []byte {
+	r := make([]byte, 0, len(b))
+
+	for _, block := range split(b) {
+		if ew.mode == CBC {
+			block = xorBytes(block, ew.prev)
+		}
+
+		c := primitives.FromState(ew.a.EncryptBlock([16]byte(block)))
+		r = append(r, c[:]...)
+		ew.prev = c[:]
+	}
+
+	return r
+}
+
+// xorKeyStream is shared by both directions of CTR.
+func (ew *EncryptWriter) xorKeyStream(p []byte) ([]byte, error) {
+	r := make([]byte, len(p))
+
+	for i := range p {
+		if len(ew.keystream) == 0 {
+			if ew.exhausted {
+				return nil, ErrCounterExhausted
+			}
+			c := primitives.FromState(ew.a.EncryptBlock([16]byte(ew.counter)))
+			ew.keystream = c[:]
+			ew.exhausted = incrementCounter(ew.counter, ew.a.counterLayout) != nil
+		}
+		r[i] = p[i] ^ ew.keystream[0]
+		ew.keystream = ew.keystream[1:]
+	}
+
+	return r, nil
+}
+
+// ReadHeader reads the envelope header from r, leaving r at the start of the body.
+// Tag must be empty, streams don't have one.
+func ReadHeader(r io.Reader) (*Ciphertext, error) {
+	var fixed [5]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	br := &byteReader{r: r}
+	header := fixed[:]
+
+	readField := func() error {
+		l, err := binary.ReadUvarint(br)
+		if err != nil || l > 1<<16 {
+			return ErrInvalidCiphertext
+		}
+		header = binary.AppendUvarint(header, l)
+
+		field := make([]byte, l)
+		if _, err := io.ReadFull(r, field); err != nil {
+			return ErrInvalidCiphertext
+		}
+		header = append(header, field...)
+		return nil
+	}
+
+	// the optional fields depend on the flags, then there are always IV and tag
+	flags := fixed[4]
+	if flags&^knownFlags != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	for flag := flagKeyID; flag <= flagKDF; flag <<= 1 {
+		if flags&flag == 0 {
+			continue
+		}
+		if err := readField(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the epoch is a plain uvarint, not a field with a length
+	if flags&flagEpoch != 0 {
+		epoch, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, ErrInvalidCiphertext
+		}
+		header = binary.AppendUvarint(header, epoch)
+	}
+
+	if flags&flagCompression != 0 {
+		compression, err := br.ReadByte()
+		if err != nil {
+			return nil, ErrInvalidCiphertext
+		}
+		header = append(header, compression)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := readField(); err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := Unmarshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Tag) != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return c, nil
+}
+
+// byteReader reads one byte at a time, so nothing past the header is consumed.
+type byteReader struct {
+	r io.Reader
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	_, err := io.ReadFull(b.r, buf[:])
+	return buf[0], err
+}
+
+// DecryptReader decrypts the body of a stream created by EncryptWriter.
+type DecryptReader struct {
+	a    *AES
+	r    io.Reader
+	mode Mode
+
+	prev []byte
+	ctr  *EncryptWriter
+
+	// ciphertext not decrypted yet and plaintext not returned yet
+	in  []byte
+	out []byte
+	eof bool
+
+	// decompresses what is decrypted, created on the first Read because it reads the gzip header
+	compression Compression
+	zr          *gzip.Reader
+
+	progress progress
+}
+
+// NewDecryptReader returns a reader with the plaintext of r. c is the header returned by ReadHeader.
+// A compressed stream is decompressed.
+func (a *AES) NewDecryptReader(c *Ciphertext, r io.Reader) (*DecryptReader, error) {
+	if a.key.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if err := a.allowMode(c.Mode); err != nil {
+		return nil, err
+	}
+
+	if c.Compression != NoCompression && !c.Compression.valid() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	dr := &DecryptReader{a: a, r: r, mode: c.Mode, compression: c.Compression, progress: a.newProgress()}
+
+	switch c.Mode {
+	case ECB:
+	case CBC, CTR:
+		if len(c.IV) != 16 {
+			return nil, ErrInvalidIV
+		}
+		if err := a.useNonce(c.IV); err != nil {
+			return nil, err
+		}
+		dr.prev = c.IV
+		// CTR decryption is the same as encryption
+		dr.ctr = &EncryptWriter{a: a, counter: append([]byte{}, c.IV...)}
+	default:
+		return nil, ErrInvalidMode
+	}
+
+	return dr, nil
+}
+
+func (dr *DecryptReader) Read(p []byte) (int, error) {
+	if dr.compression == NoCompression {
+		return dr.read(p)
+	}
+
+	if dr.zr == nil {
+		zr, err := gzip.NewReader(readerFunc(dr.read))
+		if err == io.EOF {
+			// there is always a gzip header, even for an empty plaintext
+			return 0, ErrTruncatedCiphertext
+		}
+		if err != nil {
+			return 0, err
+		}
+		dr.zr = zr
+	}
+	return dr.zr.Read(p)
+}
+
+// read decrypts into p, before the decompression.
+func (dr *DecryptReader) read(p []byte) (int, error) {
+	if dr.mode == CTR {
+		n, err := dr.r.Read(p)
+		decrypted, kerr := dr.ctr.xorKeyStream(p[:n])
+		if kerr != nil {
+			return 0, kerr
+		}
+		copy(p, decrypted)
+		dr.progress.add(n)
+		return n, err
+	}
+
+	for len(dr.out) == 0 {
+		if dr.eof {
+			return 0, io.EOF
+		}
+		if err := dr.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, dr.out)
+	dr.out = dr.out[n:]
+	return n, nil
+}
+
+// fill reads more ciphertext and decrypts every full block except the last one,
+// which is kept until EOF because it has the padding.
+func (dr *DecryptReader) fill() error {
+	chunk := make([]byte, 32*1024)
+	n, err := dr.r.Read(chunk)
+	dr.in = append(dr.in, chunk[:n]...)
+	dr.progress.add(n)
+
+	if err == io.EOF {
+		dr.eof = true
+
+		if len(dr.in)%16 != 0 {
+			return ErrMisalignedCiphertext
+		}
+		if len(dr.in) == 0 && dr.a.padding == PKCS7 {
+			return ErrTruncatedCiphertext
+		}
+
+		last, err := dr.a.removePadding(dr.decryptBlocks(dr.in))
+		if err != nil {
+			return err
+		}
+		dr.in = nil
+		dr.out = append(dr.out, last...)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// keep at least one block back
+	ready := (len(dr.in) - 1) / 16 * 16
+	if ready > 0 {
+		dr.out = append(dr.out, dr.decryptBlocks(dr.in[:ready])...)
+		dr.in = append(dr.in[:0], dr.in[ready:]...)
+	}
+
+	return nil
+}
+
+func (dr *DecryptReader) decryptBlocks(b []byte) []byte {
+	r := make([]byte, 0, len(b))
+
+	for _, block := range split(b) {
+		d := primitives.FromState(dr.a.DecryptBlock([16]byte(block)))
+		s := d[:]
+
+		if dr.mode == CBC {
+			s = xorBytes(s, dr.prev)
+			dr.prev = append([]byte{}, block...)
+		}
+		r = append(r, s...)
+	}
+
+	return r
+}
+
+// streamChunk is how much EncryptStream and DecryptStream read before checking the context.
+const streamChunk = 64 * 1024
+
+// EncryptStream encrypts src into dst like NewEncryptWriter, checking ctx between chunks of 64 KiB.
+// The size of src is given to WithProgress when it can be found.
+// When ctx is done it stops with ctx.Err() before the last block is written, what is in dst is
+// incomplete and must be discarded: write to a temporary file and only rename it on success.
+// A Read or Write that blocks isn't interrupted.
+func (a *AES) EncryptStream(ctx context.Context, dst io.Writer, src io.Reader, c *Ciphertext) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ew, err := a.NewEncryptWriter(dst, c)
+	if err != nil {
+		return err
+	}
+	ew.progress.total = remaining(src)
+
+	if err := copyContext(ctx, ew, src); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+// DecryptStream decrypts src into dst like NewDecryptReader, c is the header returned by
+// ReadHeader. It stops like EncryptStream and what is in dst must be discarded on error.
+func (a *AES) DecryptStream(ctx context.Context, dst io.Writer, src io.Reader, c *Ciphertext) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	dr, err := a.NewDecryptReader(c, src)
+	if err != nil {
+		return err
+	}
+	dr.progress.total = remaining(src)
+
+	return copyContext(ctx, dst, dr)
+}
+
+func copyContext(ctx context.Context, dst io.Writer, src io.Reader) error {
+	buf := make([]byte, streamChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
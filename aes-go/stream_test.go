@@ -0,0 +1,174 @@
+package aesgo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestStream(t *testing.T) {
+	aes := New(key.NewKey([16]byte([]byte("128bitsforkeysss"))), WithInsecureModes())
+
+	for _, mode := range []Mode{ECB, CBC, CTR} {
+		for _, size := range []int{0, 1, 15, 16, 17, 100, 4096, 100000} {
+			plaintext := bytes.Repeat([]byte("0123456789abcdefghij"), size/20+1)[:size]
+
+			var encrypted bytes.Buffer
+			header := &Ciphertext{Mode: mode, KeyID: "stream", Epoch: 200}
+
+			w, err := aes.NewEncryptWriter(&encrypted, header)
+			if err != nil {
+				t.Fatalf("Error creating writer: %s", err)
+			}
+
+			// write in odd sized pieces to exercise the buffering
+			for p := plaintext; len(p) > 0; {
+				n := min(len(p), 7)
+				if _, err := w.Write(p[:n]); err != nil {
+					t.Fatalf("Error writing: %s", err)
+				}
+				p = p[n:]
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Error closing: %s", err)
+			}
+
+			// the stream must be a valid envelope for the one shot API
+			c, err := Unmarshal(encrypted.Bytes())
+			if err != nil {
+				t.Fatalf("Error unmarshaling: %s", err)
+			}
+			decrypted, err := aes.DecryptCiphertext(c)
+			if err != nil {
+				t.Fatalf("mode %d size %d: Error decrypting: %s", mode, size, err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("mode %d size %d: one shot decryption differs", mode, size)
+			}
+
+			r := iotest.HalfReader(bytes.NewReader(encrypted.Bytes()))
+			parsed, err := ReadHeader(r)
+			if err != nil {
+				t.Fatalf("Error reading header: %s", err)
+			}
+			if parsed.KeyID != "stream" || parsed.Epoch != 200 || parsed.Mode != mode {
+				t.Errorf("Unexpected header: %+v", parsed)
+			}
+
+			dr, err := aes.NewDecryptReader(parsed, r)
+			if err != nil {
+				t.Fatalf("Error creating reader: %s", err)
+			}
+
+			streamed, err := io.ReadAll(dr)
+			if err != nil {
+				t.Fatalf("mode %d size %d: Error reading: %s", mode, size, err)
+			}
+			if !bytes.Equal(streamed, plaintext) {
+				t.Errorf("mode %d size %d: streamed decryption differs", mode, size)
+			}
+		}
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	aes := New(key.NewKey([16]byte([]byte("128bitsforkeysss"))))
+
+	var encrypted bytes.Buffer
+	w, err := aes.NewEncryptWriter(&encrypted, &Ciphertext{Mode: CBC})
+	if err != nil {
+		t.Fatalf("Error creating writer: %s", err)
+	}
+	w.Write([]byte("Let's test if this is working!"))
+	w.Close()
+
+	if _, err := w.Write([]byte("more")); err != ErrClosed {
+		t.Errorf("Expected %v, got %v", ErrClosed, err)
+	}
+
+	// drop the last byte
+	truncated := encrypted.Bytes()[:encrypted.Len()-1]
+	r := bytes.NewReader(truncated)
+
+	header, err := ReadHeader(r)
+	if err != nil {
+		t.Fatalf("Error reading header: %s", err)
+	}
+
+	dr, err := aes.NewDecryptReader(header, r)
+	if err != nil {
+		t.Fatalf("Error creating reader: %s", err)
+	}
+
+	if _, err := io.ReadAll(dr); err != ErrMisalignedCiphertext {
+		t.Errorf("Expected %v, got %v", ErrMisalignedCiphertext, err)
+	}
+
+	if _, err := ReadHeader(bytes.NewReader([]byte("AG"))); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+}
+
+// cancelReader cancels the context once n bytes were read.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (c *cancelReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n -= n
+	if c.n <= 0 {
+		c.cancel()
+	}
+	return n, err
+}
+
+func TestStreamContext(t *testing.T) {
+	aes := New(key.Bit128())
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 3*streamChunk/16)
+
+	for _, mode := range []Mode{CBC, CTR} {
+		var encrypted bytes.Buffer
+		if err := aes.EncryptStream(context.Background(), &encrypted, bytes.NewReader(plaintext), &Ciphertext{Mode: mode}); err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		r := bytes.NewReader(encrypted.Bytes())
+		c, _ := ReadHeader(r)
+		var decrypted bytes.Buffer
+		if err := aes.DecryptStream(context.Background(), &decrypted, r, c); err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plaintext) {
+			t.Errorf("Mode %d. Decrypted %d bytes, expected %d", mode, decrypted.Len(), len(plaintext))
+		}
+	}
+
+	// cancelled after the first chunk, the rest and the padding are never written
+	ctx, cancel := context.WithCancel(context.Background())
+	var partial bytes.Buffer
+	src := &cancelReader{r: bytes.NewReader(plaintext), n: streamChunk, cancel: cancel}
+	if err := aes.EncryptStream(ctx, &partial, src, &Ciphertext{Mode: CBC}); err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if partial.Len() >= len(plaintext) {
+		t.Errorf("Expected partial output, got %d bytes", partial.Len())
+	}
+
+	expired, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	var nothing bytes.Buffer
+	if err := aes.EncryptStream(expired, &nothing, bytes.NewReader(plaintext), &Ciphertext{Mode: CTR}); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if nothing.Len() != 0 {
+		t.Errorf("Expected nothing written, got %d bytes", nothing.Len())
+	}
+}
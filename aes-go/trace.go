@@ -0,0 +1,100 @@
+package aesgo
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// Trace is the record of one EncryptBlockTrace: the round keys and the state after every step.
+// It marshals to JSON with the blocks as hex strings, for notebooks and visualisation tools.
+type Trace struct {
+	Input     HexBlock   `json:"input"`
+	Output    HexBlock   `json:"output"`
+	RoundKeys []HexBlock `json:"roundKeys"`
+	Steps     []Step     `json:"steps"`
+}
+
+// Step is the state after one transformation. Name is SubBytes, ShiftRows, MixColumns or AddRoundKey.
+type Step struct {
+	Round int      `json:"round"`
+	Name  string   `json:"name"`
+	State HexBlock `json:"state"`
+}
+
+// HexBlock is a block in the input byte order, it is a hex string in JSON.
+type HexBlock [16]byte
+
+func (b HexBlock) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b[:])), nil
+}
+
+func (b *HexBlock) UnmarshalText(text []byte) error {
+	d, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	if len(d) != len(b) {
+		return fmt.Errorf("Block must have %d bytes, got %d", len(b), len(d))
+	}
+	copy(b[:], d)
+	return nil
+}
+
+// EncryptBlockTrace encrypts b like EncryptBlock and returns every intermediate state.
+// A Tracer set with WithTracer is still called.
+func (a *AES) EncryptBlockTrace(b [16]byte) (Trace, error) {
+	if a.key.Destroyed() {
+		return Trace{}, key.ErrDestroyed
+	}
+
+	r := &traceRecorder{next: a.tracer, trace: Trace{Input: b}}
+
+	c := a.clone()
+	c.tracer = r
+	out := c.EncryptBlock(b)
+
+	r.trace.Output = HexBlock(primitives.FromState(out))
+	return r.trace, nil
+}
+
+// traceRecorder builds the Trace and passes every call to the next tracer.
+type traceRecorder struct {
+	next  Tracer
+	trace Trace
+}
+
+func (r *traceRecorder) AfterSubBytes(round int, state [16]byte) {
+	r.add(round, "SubBytes", state)
+	if r.next != nil {
+		r.next.AfterSubBytes(round, state)
+	}
+}
+
+func (r *traceRecorder) AfterShiftRows(round int, state [16]byte) {
+	r.add(round, "ShiftRows", state)
+	if r.next != nil {
+		r.next.AfterShiftRows(round, state)
+	}
+}
+
+func (r *traceRecorder) AfterMixColumns(round int, state [16]byte) {
+	r.add(round, "MixColumns", state)
+	if r.next != nil {
+		r.next.AfterMixColumns(round, state)
+	}
+}
+
+func (r *traceRecorder) AfterAddRoundKey(round int, state, roundKey [16]byte) {
+	r.trace.RoundKeys = append(r.trace.RoundKeys, roundKey)
+	r.add(round, "AddRoundKey", state)
+	if r.next != nil {
+		r.next.AfterAddRoundKey(round, state, roundKey)
+	}
+}
+
+func (r *traceRecorder) add(round int, name string, state [16]byte) {
+	r.trace.Steps = append(r.trace.Steps, Step{Round: round, Name: name, State: state})
+}
@@ -0,0 +1,77 @@
+package aesgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestEncryptBlockTrace(t *testing.T) {
+	// FIPS-197 Appendix C.1
+	k := key.NewKey([16]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f})
+	block := [16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}
+
+	var out bytes.Buffer
+	a := New(k, WithTracer(&FIPSTracer{W: &out}))
+
+	trace, err := a.EncryptBlockTrace(block)
+	if err != nil {
+		t.Fatalf("Error tracing: %s", err)
+	}
+
+	if len(trace.RoundKeys) != 11 {
+		t.Errorf("Expected 11 round keys, got %d", len(trace.RoundKeys))
+	}
+	// 1 step for round 0, 4 for rounds 1 to 9 and 3 for round 10
+	if len(trace.Steps) != 1+9*4+3 {
+		t.Errorf("Expected %d steps, got %d", 1+9*4+3, len(trace.Steps))
+	}
+	if trace.Steps[len(trace.Steps)-1].State != trace.Output {
+		t.Errorf("Expected the last step to be the output")
+	}
+
+	// the tracer set with WithTracer still sees everything
+	if !strings.HasSuffix(out.String(), fipsTraceEnd) {
+		t.Errorf("Got:\n%s\nExpected suffix:\n%s", out.String(), fipsTraceEnd)
+	}
+
+	b, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("Error marshaling: %s", err)
+	}
+
+	expected := []string{
+		`"input":"00112233445566778899aabbccddeeff"`,
+		`"output":"69c4e0d86a7b0430d8cdb78070b4c55a"`,
+		`{"round":1,"name":"SubBytes","state":"63cab7040953d051cd60e0e7ba70e18c"}`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(b), e) {
+			t.Errorf("Expected JSON to contain %s, got %s", e, b)
+		}
+	}
+
+	var decoded Trace
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("Error unmarshaling: %s", err)
+	}
+	if decoded.Output != trace.Output || len(decoded.Steps) != len(trace.Steps) || decoded.RoundKeys[10] != trace.RoundKeys[10] {
+		t.Errorf("Expected the same trace after unmarshaling")
+	}
+}
+
+func TestEncryptBlockTraceErrors(t *testing.T) {
+	a := New(key.Bit128())
+	a.Destroy()
+	if _, err := a.EncryptBlockTrace([16]byte{}); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+
+	var b HexBlock
+	if err := json.Unmarshal([]byte(`"0011"`), &b); err == nil {
+		t.Errorf("Expected error for a short block, got nil")
+	}
+}
@@ -0,0 +1,80 @@
+package aesgo
+
+import (
+	"fmt"
+	"io"
+)
+
+// Tracer is called with the state after every step of EncryptBlock, so the intermediate values can be
+// compared with FIPS-197 Appendix B and C. States and keys are in the same byte order as the input block.
+//
+// Round 0 only has AddRoundKey and round 10 has no MixColumns. When parallelism is enabled the calls
+// can come from different goroutines.
+type Tracer interface {
+	AfterSubBytes(round int, state [16]byte)
+	AfterShiftRows(round int, state [16]byte)
+	AfterMixColumns(round int, state [16]byte)
+	AfterAddRoundKey(round int, state, roundKey [16]byte)
+}
+
+// WithTracer sets a Tracer for every block encrypted.
+func WithTracer(t Tracer) Option {
+	return func(a *AES) {
+		a.tracer = t
+	}
+}
+
+// FIPSTracer prints the states in the format of FIPS-197 Appendix C:
+//
+//	round[ 0].input    00112233445566778899aabbccddeeff
+//	round[ 0].k_sch    000102030405060708090a0b0c0d0e0f
+//	round[ 1].start    00102030405060708090a0b0c0d0e0f0
+//	round[ 1].s_box    63cab7040953d051cd60e0e7ba70e18c
+//	...
+//	round[10].output   69c4e0d86a7b0430d8cdb78070b4c55a
+type FIPSTracer struct {
+	W io.Writer
+	// Rounds is the number of rounds, to know when to print the output. Defaults to 10, set it when
+	// tracing a cipher from NewWithRounds.
+	Rounds int
+}
+
+func (f *FIPSTracer) AfterSubBytes(round int, state [16]byte) {
+	f.print(round, "s_box", state[:])
+}
+
+func (f *FIPSTracer) AfterShiftRows(round int, state [16]byte) {
+	f.print(round, "s_row", state[:])
+}
+
+func (f *FIPSTracer) AfterMixColumns(round int, state [16]byte) {
+	f.print(round, "m_col", state[:])
+}
+
+func (f *FIPSTracer) AfterAddRoundKey(round int, state, roundKey [16]byte) {
+	rounds := f.Rounds
+	if rounds == 0 {
+		rounds = 10
+	}
+
+	if round == 0 {
+		// the input is not passed to the tracer, but it is easy to get back
+		var input [16]byte
+		for i := range input {
+			input[i] = state[i] ^ roundKey[i]
+		}
+		f.print(round, "input", input[:])
+	}
+
+	f.print(round, "k_sch", roundKey[:])
+
+	if round == rounds {
+		f.print(round, "output", state[:])
+	} else {
+		f.print(round+1, "start", state[:])
+	}
+}
+
+func (f *FIPSTracer) print(round int, step string, state []byte) {
+	fmt.Fprintf(f.W, "round[%2d].%-9s%x\n", round, step, state)
+}
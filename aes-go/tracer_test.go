@@ -0,0 +1,49 @@
+package aesgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// FIPS-197 Appendix C.1, the first and last rounds
+const fipsTrace = `round[ 0].input    00112233445566778899aabbccddeeff
+round[ 0].k_sch    000102030405060708090a0b0c0d0e0f
+round[ 1].start    00102030405060708090a0b0c0d0e0f0
+round[ 1].s_box    63cab7040953d051cd60e0e7ba70e18c
+round[ 1].s_row    6353e08c0960e104cd70b751bacad0e7
+round[ 1].m_col    5f72641557f5bc92f7be3b291db9f91a
+round[ 1].k_sch    d6aa74fdd2af72fadaa678f1d6ab76fe
+round[ 2].start    89d810e8855ace682d1843d8cb128fe4
+`
+
+const fipsTraceEnd = `round[10].start    bd6e7c3df2b5779e0b61216e8b10b689
+round[10].s_box    7a9f102789d5f50b2beffd9f3dca4ea7
+round[10].s_row    7ad5fda789ef4e272bca100b3d9ff59f
+round[10].k_sch    13111d7fe3944a17f307a78b4d2b30c5
+round[10].output   69c4e0d86a7b0430d8cdb78070b4c55a
+`
+
+func TestFIPSTracer(t *testing.T) {
+	var out bytes.Buffer
+
+	k := key.NewKey([16]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f})
+	a := New(k, WithTracer(&FIPSTracer{W: &out}))
+
+	a.EncryptBlock([16]byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff})
+
+	got := out.String()
+	if !strings.HasPrefix(got, fipsTrace) {
+		t.Errorf("Got:\n%s\nExpected prefix:\n%s", got, fipsTrace)
+	}
+	if !strings.HasSuffix(got, fipsTraceEnd) {
+		t.Errorf("Got:\n%s\nExpected suffix:\n%s", got, fipsTraceEnd)
+	}
+
+	// input and k_sch for round 0, then 5 lines for rounds 1 to 9 and 5 lines for round 10
+	if lines := strings.Count(got, "\n"); lines != 2+1+9*5+4 {
+		t.Errorf("Expected %d lines, got %d", 2+1+9*5+4, lines)
+	}
+}
@@ -0,0 +1,51 @@
+package aesgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrEmptyCiphertext      = errors.New("Empty ciphertext")
+	ErrTruncatedCiphertext  = errors.New("Truncated ciphertext")
+	ErrMisalignedCiphertext = errors.New("Ciphertext is not a multiple of 16 bytes")
+)
+
+// validateCiphertext checks the length of the encrypted text before any block is touched.
+// Without this, a short or misaligned input would make the [16]byte conversions panic.
+func (a *AES) validateCiphertext(mode Mode, encrypted []byte) error {
+	l := len(encrypted)
+	if l == 0 {
+		return ErrEmptyCiphertext
+	}
+
+	switch mode {
+	case ECB:
+		if l%16 != 0 {
+			return fmt.Errorf("%w: got %d bytes", ErrMisalignedCiphertext, l)
+		}
+	case CBC:
+		// iv + at least one block, unless there is no padding and the plaintext was empty
+		minLen := 16 * 2
+		if a.padding == NoPadding {
+			minLen = 16
+		}
+		if l < minLen {
+			return fmt.Errorf("%w: must have at least %d bytes (iv + encrypted blocks), got %d", ErrTruncatedCiphertext, minLen, l)
+		}
+		if l%16 != 0 {
+			return fmt.Errorf("%w: got %d bytes", ErrMisalignedCiphertext, l)
+		}
+	case CTR:
+		// CTR doesn't need full blocks, but the nonce must be there
+		if l < 16 {
+			return fmt.Errorf("%w: must have at least 16 bytes for the nonce, got %d", ErrTruncatedCiphertext, l)
+		}
+	case GCM:
+		if l < GCMNonceSize+GCMTagSize {
+			return fmt.Errorf("%w: must have at least %d bytes (nonce + tag), got %d", ErrTruncatedCiphertext, GCMNonceSize+GCMTagSize, l)
+		}
+	}
+
+	return nil
+}
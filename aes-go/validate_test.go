@@ -0,0 +1,102 @@
+package aesgo
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestDecryptValidation(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	aes := New(k, WithInsecureModes())
+
+	tests := []struct {
+		name string
+
+		mode  Mode
+		input []byte
+
+		expected error
+	}{
+		{
+			name: "ECB empty",
+
+			mode:  ECB,
+			input: []byte{},
+
+			expected: ErrEmptyCiphertext,
+		},
+		{
+			name: "ECB misaligned",
+
+			mode:  ECB,
+			input: make([]byte, 20),
+
+			expected: ErrMisalignedCiphertext,
+		},
+		{
+			name: "CBC only iv",
+
+			mode:  CBC,
+			input: make([]byte, 16),
+
+			expected: ErrTruncatedCiphertext,
+		},
+		{
+			name: "CBC misaligned",
+
+			mode:  CBC,
+			input: make([]byte, 33),
+
+			expected: ErrMisalignedCiphertext,
+		},
+		{
+			name: "CTR empty",
+
+			mode:  CTR,
+			input: nil,
+
+			expected: ErrEmptyCiphertext,
+		},
+		{
+			name: "CTR truncated nonce",
+
+			mode:  CTR,
+			input: make([]byte, 15),
+
+			expected: ErrTruncatedCiphertext,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := aes.Decrypt(test.mode, test.input)
+			if !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestEmptyPlaintext(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	aes := New(k, WithInsecureModes())
+
+	for _, mode := range []Mode{ECB, CBC, CTR} {
+		encrypted, err := aes.Encrypt(mode, []byte{})
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		decrypted, err := aes.Decrypt(mode, encrypted)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+
+		if !bytes.Equal(decrypted, []byte{}) {
+			t.Errorf("Expected empty plaintext, got %02x", decrypted)
+		}
+	}
+}
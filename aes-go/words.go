@@ -0,0 +1,107 @@
+package aesgo
+
+import (
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// The word oriented rounds keep the state in four uint32, one per column with the first row in the
+// top byte, and the round keys in a.words. It's how crypto/aes does it (without its T-tables), and
+// it doesn't allocate or copy [4][4]byte matrices around. EncryptBlock and DecryptBlock use them
+// unless something needs the state after every step: a tracer, a fault or the constant time mode.
+
+var sbox, invSBox = primitives.SBox(), primitives.InvSBox()
+
+func (a *AES) wordRounds() bool {
+	return a.tracer == nil && a.fault == nil && !a.constantTime
+}
+
+func (a *AES) encryptBlockWords(b [16]byte) [16]byte {
+	w := a.words
+
+	s0 := binary.BigEndian.Uint32(b[0:]) ^ w[0]
+	s1 := binary.BigEndian.Uint32(b[4:]) ^ w[1]
+	s2 := binary.BigEndian.Uint32(b[8:]) ^ w[2]
+	s3 := binary.BigEndian.Uint32(b[12:]) ^ w[3]
+
+	for r := 1; r <= a.rounds; r++ {
+		// SubBytes and ShiftRows at once: row i of column c comes from column c+i
+		t0 := subShift(s0, s1, s2, s3, &sbox)
+		t1 := subShift(s1, s2, s3, s0, &sbox)
+		t2 := subShift(s2, s3, s0, s1, &sbox)
+		t3 := subShift(s3, s0, s1, s2, &sbox)
+
+		if r < a.rounds {
+			t0, t1, t2, t3 = mixColumn(t0), mixColumn(t1), mixColumn(t2), mixColumn(t3)
+		}
+
+		s0, s1, s2, s3 = t0^w[4*r], t1^w[4*r+1], t2^w[4*r+2], t3^w[4*r+3]
+	}
+
+	var out [16]byte
+	binary.BigEndian.PutUint32(out[0:], s0)
+	binary.BigEndian.PutUint32(out[4:], s1)
+	binary.BigEndian.PutUint32(out[8:], s2)
+	binary.BigEndian.PutUint32(out[12:], s3)
+	return out
+}
+
+func (a *AES) decryptBlockWords(b [16]byte) [16]byte {
+	w := a.words
+	last := 4 * a.rounds
+
+	s0 := binary.BigEndian.Uint32(b[0:]) ^ w[last]
+	s1 := binary.BigEndian.Uint32(b[4:]) ^ w[last+1]
+	s2 := binary.BigEndian.Uint32(b[8:]) ^ w[last+2]
+	s3 := binary.BigEndian.Uint32(b[12:]) ^ w[last+3]
+
+	for r := a.rounds - 1; r >= 0; r-- {
+		// InvShiftRows and InvSubBytes: row i of column c comes from column c-i
+		t0 := subShift(s0, s3, s2, s1, &invSBox) ^ w[4*r]
+		t1 := subShift(s1, s0, s3, s2, &invSBox) ^ w[4*r+1]
+		t2 := subShift(s2, s1, s0, s3, &invSBox) ^ w[4*r+2]
+		t3 := subShift(s3, s2, s1, s0, &invSBox) ^ w[4*r+3]
+
+		if r > 0 {
+			t0, t1, t2, t3 = invMixColumn(t0), invMixColumn(t1), invMixColumn(t2), invMixColumn(t3)
+		}
+
+		s0, s1, s2, s3 = t0, t1, t2, t3
+	}
+
+	var out [16]byte
+	binary.BigEndian.PutUint32(out[0:], s0)
+	binary.BigEndian.PutUint32(out[4:], s1)
+	binary.BigEndian.PutUint32(out[8:], s2)
+	binary.BigEndian.PutUint32(out[12:], s3)
+	return out
+}
+
+// subShift builds a column taking row 0 from c0, row 1 from c1 and so on, through the table.
+func subShift(c0, c1, c2, c3 uint32, table *[256]byte) uint32 {
+	return uint32(table[c0>>24])<<24 |
+		uint32(table[c1>>16&0xff])<<16 |
+		uint32(table[c2>>8&0xff])<<8 |
+		uint32(table[c3&0xff])
+}
+
+// xtime multiplies the 4 bytes of w by {02} at once.
+func xtime(w uint32) uint32 {
+	return (w&0x7f7f7f7f)<<1 ^ (w>>7&0x01010101)*0x1b
+}
+
+// mixColumn multiplies a column by {03}x^3 + {01}x^2 + {01}x + {02}. Row 0 is
+// {02}b0 ^ {03}b1 ^ b2 ^ b3, and rotating the word by a byte moves b1 to row 0.
+func mixColumn(w uint32) uint32 {
+	x := xtime(w)
+	return x ^ bits.RotateLeft32(x^w, 8) ^ bits.RotateLeft32(w, 16) ^ bits.RotateLeft32(w, 24)
+}
+
+// invMixColumn uses that InvMixColumns is MixColumns after adding {04}(b0 ^ b2) to rows 0 and 2 and
+// {04}(b1 ^ b3) to rows 1 and 3, from The Design of Rijndael section 4.1.3.
+func invMixColumn(w uint32) uint32 {
+	t := xtime(xtime(w))
+	return mixColumn(w ^ t ^ bits.RotateLeft32(t, 16))
+}
@@ -0,0 +1,88 @@
+package aesgo
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// nopTracer makes EncryptBlock go through the step by step rounds.
+type nopTracer struct{}
+
+func (nopTracer) AfterSubBytes(int, [16]byte)              {}
+func (nopTracer) AfterShiftRows(int, [16]byte)             {}
+func (nopTracer) AfterMixColumns(int, [16]byte)            {}
+func (nopTracer) AfterAddRoundKey(int, [16]byte, [16]byte) {}
+
+func TestWordRounds(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, size := range []int{16, 32} {
+		for _, rounds := range []int{1, 2, 9, 10, 14} {
+			k, _ := key.Random(size, key.WithRandReader(r))
+			if rounds > k.Len()/4+6 {
+				continue
+			}
+
+			words, _ := NewWithRounds(k, rounds)
+			steps, _ := NewWithRounds(k, rounds, WithTracer(nopTracer{}))
+			if !words.wordRounds() || steps.wordRounds() {
+				t.Fatal("Unexpected rounds selected")
+			}
+
+			for i := 0; i < 50; i++ {
+				var b [16]byte
+				r.Read(b[:])
+
+				if got, expected := words.EncryptBlock(b), steps.EncryptBlock(b); got != expected {
+					t.Fatalf("AES-%d with %d rounds, encrypting %x. Expected %x, got %x", size*8, rounds, b, expected, got)
+				}
+				if got, expected := words.DecryptBlock(b), steps.DecryptBlock(b); got != expected {
+					t.Fatalf("AES-%d with %d rounds, decrypting %x. Expected %x, got %x", size*8, rounds, b, expected, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWordRoundsDontAllocate(t *testing.T) {
+	a, _ := NewCipher(key.Bit256())
+	a.EncryptBlock([16]byte{})
+
+	encrypt := testing.AllocsPerRun(100, func() { a.EncryptBlock([16]byte{1}) })
+	decrypt := testing.AllocsPerRun(100, func() { a.DecryptBlock([16]byte{1}) })
+	expand := testing.AllocsPerRun(100, a.generateAllKeys)
+
+	if encrypt != 0 || decrypt != 0 || expand != 0 {
+		t.Errorf("Expected no allocations, got %v encrypting, %v decrypting and %v expanding the key", encrypt, decrypt, expand)
+	}
+}
+
+// go test -bench Block -benchmem ./aes-go
+func BenchmarkEncryptBlock(b *testing.B) {
+	benchmarkBlock(b, key.Bit128())
+}
+
+func BenchmarkEncryptBlockSteps(b *testing.B) {
+	benchmarkBlock(b, key.Bit128(), WithTracer(nopTracer{}))
+}
+
+func BenchmarkExpandKey(b *testing.B) {
+	a, _ := NewCipher(key.Bit256())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.generateAllKeys()
+	}
+}
+
+func benchmarkBlock(b *testing.B, k key.Key, opts ...Option) {
+	a, _ := NewCipher(k, opts...)
+	var block [16]byte
+	b.SetBytes(16)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		block = primitives.FromState(a.EncryptBlock(block))
+	}
+}
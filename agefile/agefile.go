@@ -0,0 +1,227 @@
+// Package agefile is a small file encryption format inspired by age (https://age-encryption.org/v1).
+// It isn't compatible with age, it only borrows the design using the pieces of this repository.
+//
+// A file has a text header followed by the binary payload:
+//
+//	aes-go/age/v1
+//	-> scrypt <salt> <work factor>
+//	<wrapped file key>
+//	-> raw <key id>
+//	<wrapped file key>
+//	--- <header MAC>
+//	nonce (16 bytes) | chunk | chunk | ...
+//
+// A random 16 byte file key is wrapped for every recipient (see Passphrase and RawKey). The header
+// MAC is HMAC-SHA256 of the header up to "---", with a key derived from the file key, so recipients
+// can't be added or removed.
+//
+// The payload key is derived from the file key and the nonce. The plaintext is split in 64 KiB chunks,
+// each one encrypted with AES-GCM. The GCM nonce of a chunk is its number (11 bytes, big endian)
+// and a last byte that is 1 only for the final chunk, so chunks can't be reordered, dropped, or the
+// file truncated at a chunk boundary.
+package agefile
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	intro     = "aes-go/age/v1\n"
+	macPrefix = "---"
+
+	fileKeySize = 16
+	nonceSize   = 16
+
+	// maxLine stops a header without new lines from being read forever
+	maxLine = 1024
+)
+
+var (
+	ErrInvalidHeader = errors.New("Invalid aes-go/age header")
+	ErrNoRecipients  = errors.New("At least one recipient is required")
+	ErrNoIdentity    = errors.New("No identity matched any of the recipients")
+	ErrHeaderMAC     = errors.New("Header MAC doesn't match")
+)
+
+// Encrypt writes the header to dst and returns a writer for the plaintext. Close must be called
+// to write the final chunk.
+func Encrypt(dst io.Writer, recipients ...Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	fileKey := make([]byte, fileKeySize)
+	if _, err := io.ReadFull(rand.Reader, fileKey); err != nil {
+		return nil, err
+	}
+
+	var header bytes.Buffer
+	header.WriteString(intro)
+	for _, r := range recipients {
+		s, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		writeStanza(&header, s)
+	}
+	header.WriteString(macPrefix)
+
+	mac, err := headerMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header.WriteString(" " + base64.RawStdEncoding.EncodeToString(mac) + "\n")
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	header.Write(nonce)
+
+	if _, err := dst.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return newWriter(fileKey, nonce, dst)
+}
+
+// Decrypt reads the header, finds the file key with one of the identities and returns a reader of
+// the plaintext. Every chunk is authenticated before it is returned, an error from Read means the
+// file was modified or truncated.
+func Decrypt(src io.Reader, identities ...Identity) (io.Reader, error) {
+	br := bufio.NewReader(src)
+
+	stanzas, signed, mac, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, err := unwrap(stanzas, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := headerMAC(fileKey, signed)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return nil, ErrHeaderMAC
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		return nil, ErrInvalidHeader
+	}
+
+	return newReader(fileKey, nonce, br)
+}
+
+func unwrap(stanzas []*Stanza, identities []Identity) ([]byte, error) {
+	for _, id := range identities {
+		for _, s := range stanzas {
+			fileKey, err := id.Unwrap(s)
+			if errors.Is(err, ErrIncorrectIdentity) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(fileKey) != fileKeySize {
+				return nil, ErrInvalidHeader
+			}
+			return fileKey, nil
+		}
+	}
+	return nil, ErrNoIdentity
+}
+
+func headerMAC(fileKey, header []byte) ([]byte, error) {
+	k, err := key.HKDF(sha256.New, fileKey, nil, []byte("header"), 32)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, k)
+	mac.Write(header)
+	return mac.Sum(nil), nil
+}
+
+func writeStanza(w *bytes.Buffer, s *Stanza) {
+	w.WriteString("-> " + s.Type)
+	for _, a := range s.Args {
+		w.WriteString(" " + a)
+	}
+	w.WriteString("\n" + base64.RawStdEncoding.EncodeToString(s.Body) + "\n")
+}
+
+// readHeader returns the stanzas, the bytes covered by the MAC and the MAC.
+func readHeader(br *bufio.Reader) ([]*Stanza, []byte, []byte, error) {
+	var signed bytes.Buffer
+
+	line, err := readLine(br)
+	if err != nil || line+"\n" != intro {
+		return nil, nil, nil, ErrInvalidHeader
+	}
+	signed.WriteString(intro)
+
+	var stanzas []*Stanza
+	for {
+		line, err := readLine(br)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		if rest, ok := strings.CutPrefix(line, macPrefix+" "); ok {
+			mac, err := base64.RawStdEncoding.DecodeString(rest)
+			if err != nil || len(stanzas) == 0 {
+				return nil, nil, nil, ErrInvalidHeader
+			}
+			signed.WriteString(macPrefix)
+			return stanzas, signed.Bytes(), mac, nil
+		}
+
+		fields := strings.Split(line, " ")
+		if fields[0] != "->" || len(fields) < 2 {
+			return nil, nil, nil, ErrInvalidHeader
+		}
+
+		bodyLine, err := readLine(br)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		body, err := base64.RawStdEncoding.DecodeString(bodyLine)
+		if err != nil {
+			return nil, nil, nil, ErrInvalidHeader
+		}
+
+		stanzas = append(stanzas, &Stanza{Type: fields[1], Args: fields[2:], Body: body})
+		signed.WriteString(line + "\n" + bodyLine + "\n")
+	}
+}
+
+func readLine(br *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return "", ErrInvalidHeader
+		}
+		if b == '\n' {
+			return string(line), nil
+		}
+		if len(line) == maxLine {
+			return "", ErrInvalidHeader
+		}
+		line = append(line, b)
+	}
+}
@@ -0,0 +1,249 @@
+package agefile
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// a low work factor keeps the tests fast
+func testPassphrase(p string) *Passphrase {
+	r := NewPassphrase(p)
+	r.SetWorkFactor(10)
+	return r
+}
+
+func encrypt(t *testing.T, plaintext []byte, recipients ...Recipient) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	w, err := Encrypt(&out, recipients...)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error closing: %s", err)
+	}
+
+	// a deferred Close after this one does nothing
+	n := out.Len()
+	if err := w.Close(); err != nil || out.Len() != n {
+		t.Fatalf("Expected nil and %d bytes, got %v and %d bytes", n, err, out.Len())
+	}
+	if _, err := w.Write(plaintext); err != aesgo.ErrClosed {
+		t.Fatalf("Expected %v, got %v", aesgo.ErrClosed, err)
+	}
+	return out.Bytes()
+}
+
+func decrypt(encrypted []byte, identities ...Identity) ([]byte, error) {
+	r, err := Decrypt(bytes.NewReader(encrypted), identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := key.Bit128()
+
+	random := make([]byte, 3*ChunkSize+100)
+	rand.Read(random)
+
+	tests := []struct {
+		name string
+
+		plaintext []byte
+	}{
+		{name: "empty", plaintext: []byte{}},
+		{name: "small", plaintext: []byte("Let's test if this is working!")},
+		{name: "exactly one chunk", plaintext: random[:ChunkSize]},
+		{name: "exactly two chunks", plaintext: random[:2*ChunkSize]},
+		{name: "several chunks", plaintext: random},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted := encrypt(t, test.plaintext, NewRawKey(k), testPassphrase("correct horse"))
+
+			for _, id := range []Identity{NewRawKey(k), testPassphrase("correct horse")} {
+				decrypted, err := decrypt(encrypted, id)
+				if err != nil {
+					t.Fatalf("Error decrypting: %s", err)
+				}
+				if !bytes.Equal(decrypted, test.plaintext) {
+					t.Errorf("Got %d bytes, Expected %d bytes", len(decrypted), len(test.plaintext))
+				}
+			}
+		})
+	}
+}
+
+func TestHeader(t *testing.T) {
+	encrypted := encrypt(t, []byte("hello"), NewRawKey(key.Bit128()), testPassphrase("secret"))
+
+	lines := strings.SplitN(string(encrypted), "\n", 7)
+	if lines[0] != "aes-go/age/v1" {
+		t.Errorf("Got: %s, Expected: %s", lines[0], "aes-go/age/v1")
+	}
+	if !strings.HasPrefix(lines[1], "-> raw ") {
+		t.Errorf("Expected a raw stanza, got %s", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "-> scrypt ") || !strings.HasSuffix(lines[3], " 10") {
+		t.Errorf("Expected a scrypt stanza, got %s", lines[3])
+	}
+	if !strings.HasPrefix(lines[5], "--- ") {
+		t.Errorf("Expected the header MAC, got %s", lines[5])
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	k := key.Bit128()
+
+	plaintext := make([]byte, 2*ChunkSize+10)
+	encrypted := encrypt(t, plaintext, NewRawKey(k))
+	headerSize := bytes.Index(encrypted, []byte("\n---")) + 1
+	headerSize += bytes.IndexByte(encrypted[headerSize:], '\n') + 1 + nonceSize
+
+	change := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte{}, encrypted...))
+	}
+
+	tests := []struct {
+		name string
+
+		encrypted []byte
+		identity  Identity
+
+		expected error
+	}{
+		{
+			name: "wrong key",
+
+			encrypted: encrypted,
+			identity:  NewRawKey(key.Bit128()),
+
+			expected: ErrNoIdentity,
+		},
+		{
+			name: "wrong passphrase",
+
+			encrypted: encrypt(t, plaintext, testPassphrase("secret")),
+			identity:  testPassphrase("other"),
+
+			expected: ErrNoIdentity,
+		},
+		{
+			name: "not a file",
+
+			encrypted: []byte("hello\n"),
+			identity:  NewRawKey(k),
+
+			expected: ErrInvalidHeader,
+		},
+		{
+			name: "removed recipient",
+
+			encrypted: removeStanza(encrypt(t, []byte("hello"), NewRawKey(k), testPassphrase("secret")), 1),
+			identity:  NewRawKey(k),
+
+			expected: ErrHeaderMAC,
+		},
+		{
+			name: "tampered MAC",
+
+			encrypted: change(func(b []byte) []byte {
+				start := bytes.Index(b, []byte("\n--- ")) + 5
+				mac, _ := base64.RawStdEncoding.DecodeString(string(b[start : headerSize-nonceSize-1]))
+				mac[0] ^= 1
+				copy(b[start:], base64.RawStdEncoding.EncodeToString(mac))
+				return b
+			}),
+			identity: NewRawKey(k),
+
+			expected: ErrHeaderMAC,
+		},
+		{
+			name: "tampered chunk",
+
+			encrypted: change(func(b []byte) []byte { b[headerSize+ChunkSize+100] ^= 1; return b }),
+			identity:  NewRawKey(k),
+
+			expected: ErrChunk,
+		},
+		{
+			name: "swapped chunks",
+
+			encrypted: change(func(b []byte) []byte {
+				first := append([]byte{}, b[headerSize:headerSize+encryptedChunkSize]...)
+				copy(b[headerSize:], b[headerSize+encryptedChunkSize:headerSize+2*encryptedChunkSize])
+				copy(b[headerSize+encryptedChunkSize:], first)
+				return b
+			}),
+			identity: NewRawKey(k),
+
+			expected: ErrChunk,
+		},
+		{
+			name: "truncated at a chunk boundary",
+
+			encrypted: encrypted[:headerSize+2*encryptedChunkSize],
+			identity:  NewRawKey(k),
+
+			expected: ErrTruncated,
+		},
+		{
+			name: "truncated in the middle of a chunk",
+
+			encrypted: encrypted[:len(encrypted)-1],
+			identity:  NewRawKey(k),
+
+			expected: ErrChunk,
+		},
+		{
+			name: "no payload",
+
+			encrypted: encrypted[:headerSize],
+			identity:  NewRawKey(k),
+
+			expected: ErrTruncated,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decrypt(test.encrypted, test.identity); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// removeStanza drops the i-th stanza, the other ones still unwrap the file key.
+func removeStanza(encrypted []byte, i int) []byte {
+	lines := bytes.SplitAfter(encrypted, []byte("\n"))
+	lines = append(lines[:1+2*i], lines[3+2*i:]...)
+	return bytes.Join(lines, nil)
+}
+
+func TestEncryptErrors(t *testing.T) {
+	if _, err := Encrypt(io.Discard); err != ErrNoRecipients {
+		t.Errorf("Expected %v, got %v", ErrNoRecipients, err)
+	}
+
+	p := NewPassphrase("secret")
+	p.SetWorkFactor(30)
+	if _, err := Encrypt(io.Discard, p); err != key.ErrInvalidScryptParams {
+		t.Errorf("Expected %v, got %v", key.ErrInvalidScryptParams, err)
+	}
+}
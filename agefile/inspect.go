@@ -0,0 +1,66 @@
+package agefile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"strconv"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Inspect reads the header of a file like aesgo.Inspect does for a Ciphertext. The IV is the
+// payload nonce, KeyID the first raw recipient and KDF the first scrypt one. The header MAC
+// can't be checked without the file key, so nothing in it is authenticated.
+func Inspect(b []byte) (aesgo.Header, error) {
+	r := bytes.NewReader(b)
+	br := bufio.NewReader(r)
+
+	stanzas, _, _, err := readHeader(br)
+	if err != nil {
+		return aesgo.Header{}, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := io.ReadFull(br, nonce); err != nil {
+		return aesgo.Header{}, ErrInvalidHeader
+	}
+	payload := br.Buffered() + r.Len()
+	h := aesgo.Header{
+		Format:     "aes-go/age/v1",
+		Version:    1,
+		Mode:       aesgo.GCM,
+		IV:         nonce,
+		TagSize:    aesgo.GCMTagSize,
+		HeaderSize: len(b) - payload,
+		ChunkSize:  ChunkSize,
+	}
+
+	// every chunk is full but the last one, which can be empty but still has a tag
+	h.Chunks = max(1, (payload+encryptedChunkSize-1)/encryptedChunkSize)
+	h.PayloadSize = payload - h.Chunks*aesgo.GCMTagSize
+	if h.PayloadSize < 0 {
+		return aesgo.Header{}, ErrTruncated
+	}
+
+	for _, s := range stanzas {
+		switch {
+		case s.Type == "raw" && len(s.Args) == 1 && h.KeyID == "":
+			h.KeyID = s.Args[0]
+		case s.Type == "scrypt" && len(s.Args) == 2 && h.KDF == nil:
+			salt, err := base64.RawStdEncoding.DecodeString(s.Args[0])
+			if err != nil {
+				return aesgo.Header{}, ErrInvalidHeader
+			}
+			logN, err := strconv.Atoi(s.Args[1])
+			if err != nil || logN < 1 || logN > maxWorkFactor {
+				return aesgo.Header{}, ErrInvalidHeader
+			}
+			h.KDF = &key.KDFParams{KDF: key.KDFScrypt, Salt: salt, N: 1 << logN, R: 8, P: 1}
+		}
+	}
+
+	return h, nil
+}
@@ -0,0 +1,107 @@
+package agefile
+
+import (
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestInspect(t *testing.T) {
+	k := key.Bit128()
+	random := make([]byte, 2*ChunkSize+100)
+
+	tests := []struct {
+		name string
+
+		plaintext  []byte
+		recipients []Recipient
+
+		chunks  int
+		keyID   bool
+		scryptN int
+	}{
+		{
+			name: "empty with a raw key",
+
+			plaintext:  []byte{},
+			recipients: []Recipient{NewRawKey(k)},
+
+			chunks: 1,
+			keyID:  true,
+		},
+		{
+			name: "exactly one chunk with a passphrase",
+
+			plaintext:  random[:ChunkSize],
+			recipients: []Recipient{testPassphrase("correct horse")},
+
+			chunks:  1,
+			scryptN: 1 << 10,
+		},
+		{
+			name: "several chunks with both",
+
+			plaintext:  random,
+			recipients: []Recipient{NewRawKey(k), testPassphrase("correct horse")},
+
+			chunks:  3,
+			keyID:   true,
+			scryptN: 1 << 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted := encrypt(t, test.plaintext, test.recipients...)
+
+			h, err := Inspect(encrypted)
+			if err != nil {
+				t.Fatalf("Error inspecting: %s", err)
+			}
+
+			if h.Format != "aes-go/age/v1" || h.Mode != aesgo.GCM || len(h.IV) != nonceSize || h.ChunkSize != ChunkSize {
+				t.Errorf("Got: %+v", h)
+			}
+			if h.Chunks != test.chunks {
+				t.Errorf("Expected %d chunks, got %d", test.chunks, h.Chunks)
+			}
+			if h.PayloadSize != len(test.plaintext) {
+				t.Errorf("Expected %d, got %d", len(test.plaintext), h.PayloadSize)
+			}
+			if h.HeaderSize+h.PayloadSize+h.Chunks*h.TagSize != len(encrypted) {
+				t.Errorf("Expected the sizes to add up to %d, got %+v", len(encrypted), h)
+			}
+
+			if test.keyID != (h.KeyID == NewRawKey(k).id()) {
+				t.Errorf("Got key ID %q", h.KeyID)
+			}
+			if test.scryptN == 0 && h.KDF != nil || test.scryptN != 0 && (h.KDF == nil || h.KDF.N != test.scryptN || len(h.KDF.Salt) != 16) {
+				t.Errorf("Got: %+v", h.KDF)
+			}
+		})
+	}
+}
+
+func TestInspectInvalid(t *testing.T) {
+	encrypted := encrypt(t, []byte("data"), NewRawKey(key.Bit128()))
+
+	tests := []struct {
+		name string
+
+		b   []byte
+		err error
+	}{
+		{name: "not an age file", b: []byte("hello\n"), err: ErrInvalidHeader},
+		{name: "no nonce", b: encrypted[:len(encrypted)-4-16-16], err: ErrInvalidHeader},
+		{name: "truncated tag", b: encrypted[:len(encrypted)-4-1], err: ErrTruncated},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Inspect(test.b); err != test.err {
+				t.Errorf("Expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
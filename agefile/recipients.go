@@ -0,0 +1,163 @@
+package agefile
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/mario-areias/aes-go/envelope"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	// DefaultWorkFactor is log2 of the scrypt N, the same as key.DefaultScryptN.
+	DefaultWorkFactor = 15
+	// maxWorkFactor keeps a malicious header from asking for gigabytes of memory.
+	maxWorkFactor = 20
+)
+
+// ErrIncorrectIdentity is returned by an Identity when the stanza isn't for it.
+var ErrIncorrectIdentity = errors.New("Stanza doesn't match the identity")
+
+// Stanza is one recipient in the header: the type, its arguments and the wrapped file key.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// Recipient wraps the file key for someone that can decrypt the file.
+type Recipient interface {
+	Wrap(fileKey []byte) (*Stanza, error)
+}
+
+// Identity unwraps the file key from a stanza. It returns ErrIncorrectIdentity when the stanza
+// belongs to someone else.
+type Identity interface {
+	Unwrap(s *Stanza) ([]byte, error)
+}
+
+// Passphrase is both a Recipient and an Identity. The file key is wrapped with AES Key Wrap under
+// a key derived with scrypt:
+//
+//	-> scrypt <salt> <work factor>
+//	<wrapped file key>
+type Passphrase struct {
+	passphrase []byte
+	workFactor int
+}
+
+func NewPassphrase(passphrase string) *Passphrase {
+	return &Passphrase{passphrase: []byte(passphrase), workFactor: DefaultWorkFactor}
+}
+
+// SetWorkFactor changes log2 of the scrypt N used by Wrap. Unwrap uses the one in the stanza.
+func (p *Passphrase) SetWorkFactor(logN int) {
+	p.workFactor = logN
+}
+
+func (p *Passphrase) Wrap(fileKey []byte) (*Stanza, error) {
+	if p.workFactor < 1 || p.workFactor > maxWorkFactor {
+		return nil, key.ErrInvalidScryptParams
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	kek, err := p.kek(salt, p.workFactor)
+	if err != nil {
+		return nil, err
+	}
+	defer kek.Destroy()
+
+	wrapped, err := envelope.Wrap(kek, fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{base64.RawStdEncoding.EncodeToString(salt), strconv.Itoa(p.workFactor)}
+	return &Stanza{Type: "scrypt", Args: args, Body: wrapped}, nil
+}
+
+func (p *Passphrase) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "scrypt" {
+		return nil, ErrIncorrectIdentity
+	}
+	if len(s.Args) != 2 {
+		return nil, ErrInvalidHeader
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(s.Args[0])
+	if err != nil || len(salt) != 16 {
+		return nil, ErrInvalidHeader
+	}
+	logN, err := strconv.Atoi(s.Args[1])
+	if err != nil || logN < 1 || logN > maxWorkFactor {
+		return nil, ErrInvalidHeader
+	}
+
+	kek, err := p.kek(salt, logN)
+	if err != nil {
+		return nil, err
+	}
+	defer kek.Destroy()
+
+	fileKey, err := envelope.Unwrap(kek, s.Body)
+	if err != nil {
+		// the wrong passphrase fails the integrity check of the key wrap
+		return nil, ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+func (p *Passphrase) kek(salt []byte, logN int) (key.Key, error) {
+	material, err := key.Scrypt(p.passphrase, salt, 1<<logN, 8, 1, 16)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+
+	return key.NewKey([16]byte(material)), nil
+}
+
+// RawKey is both a Recipient and an Identity, the file key is wrapped directly with the key.
+// The argument is the start of the key fingerprint, so other keys skip the stanza without trying:
+//
+//	-> raw <key id>
+//	<wrapped file key>
+type RawKey struct {
+	k key.Key
+}
+
+func NewRawKey(k key.Key) *RawKey {
+	return &RawKey{k: k}
+}
+
+func (r *RawKey) Wrap(fileKey []byte) (*Stanza, error) {
+	wrapped, err := envelope.Wrap(r.k, fileKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Stanza{Type: "raw", Args: []string{r.id()}, Body: wrapped}, nil
+}
+
+func (r *RawKey) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "raw" || len(s.Args) != 1 || s.Args[0] != r.id() {
+		return nil, ErrIncorrectIdentity
+	}
+
+	fileKey, err := envelope.Unwrap(r.k, s.Body)
+	if err != nil {
+		return nil, ErrIncorrectIdentity
+	}
+	return fileKey, nil
+}
+
+func (r *RawKey) id() string {
+	fingerprint := r.k.Fingerprint()
+	return base64.RawStdEncoding.EncodeToString(fingerprint[:4])
+}
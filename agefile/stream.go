@@ -0,0 +1,178 @@
+package agefile
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// ChunkSize is the plaintext size of every chunk but the last.
+const ChunkSize = 64 * 1024
+
+const encryptedChunkSize = ChunkSize + aesgo.GCMTagSize
+
+var (
+	ErrChunk     = errors.New("Chunk authentication failed, the file was modified")
+	ErrTruncated = errors.New("File is truncated")
+)
+
+func payloadCipher(fileKey, nonce []byte) (*aesgo.AES, error) {
+	material, err := key.HKDF(sha256.New, fileKey, nonce, []byte("payload"), 16)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+
+	return aesgo.NewCipher(key.NewKey([16]byte(material)))
+}
+
+// chunkNonce is the chunk number in the first 11 bytes and the last chunk flag in the 12th.
+func chunkNonce(counter uint64, last bool) []byte {
+	var n [aesgo.GCMNonceSize]byte
+	for i := 10; i >= 3; i-- {
+		n[i] = byte(counter)
+		counter >>= 8
+	}
+	if last {
+		n[11] = 1
+	}
+	return n[:]
+}
+
+type writer struct {
+	a       *aesgo.AES
+	w       io.Writer
+	buf     []byte
+	counter uint64
+	closed  bool
+}
+
+func newWriter(fileKey, nonce []byte, w io.Writer) (*writer, error) {
+	a, err := payloadCipher(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &writer{a: a, w: w, buf: make([]byte, 0, ChunkSize)}, nil
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, aesgo.ErrClosed
+	}
+
+	n := len(p)
+	for len(p) > 0 {
+		// a full chunk is only flushed when more data comes, it could be the last one
+		if len(w.buf) == ChunkSize {
+			if err := w.flush(false); err != nil {
+				return 0, err
+			}
+		}
+
+		c := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+	}
+	return n, nil
+}
+
+// Close writes the final chunk. It doesn't close the underlying writer, and closing twice does
+// nothing like aesgo.EncryptWriter.
+func (w *writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.flush(true)
+}
+
+func (w *writer) flush(last bool) error {
+	encrypted, err := w.a.SealGCM(chunkNonce(w.counter, last), w.buf, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(encrypted); err != nil {
+		return err
+	}
+
+	w.buf = w.buf[:0]
+	w.counter++
+	return nil
+}
+
+type reader struct {
+	a       *aesgo.AES
+	r       *bufio.Reader
+	buf     []byte
+	chunk   []byte
+	counter uint64
+	done    bool
+	err     error
+}
+
+func newReader(fileKey, nonce []byte, r *bufio.Reader) (*reader, error) {
+	a, err := payloadCipher(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &reader{a: a, r: r, chunk: make([]byte, encryptedChunkSize)}, nil
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// next reads and opens one chunk. It is the last one when nothing follows it.
+func (r *reader) next() error {
+	n, err := io.ReadFull(r.r, r.chunk)
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		r.done = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.r.Peek(1); err == io.EOF {
+			r.done = true
+		}
+	}
+
+	if n < aesgo.GCMTagSize {
+		return ErrTruncated
+	}
+
+	// only the first chunk can be empty, an empty file has a single empty chunk
+	if r.done && n == aesgo.GCMTagSize && r.counter > 0 {
+		return ErrChunk
+	}
+
+	plaintext, err := r.a.OpenGCM(chunkNonce(r.counter, r.done), r.chunk[:n], nil)
+	if err != nil {
+		if r.done && n == encryptedChunkSize {
+			// a full chunk that isn't marked as last, the rest of the file is missing
+			if _, err := r.a.OpenGCM(chunkNonce(r.counter, false), r.chunk[:n], nil); err == nil {
+				return ErrTruncated
+			}
+		}
+		return ErrChunk
+	}
+
+	r.buf = plaintext
+	r.counter++
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package analysis measures how AES spreads changes, the avalanche effect.
+//
+// Flipping a single bit of the plain text (or of the key) should flip about half of the 128 output bits.
+// The tracer gives the state after every round, so it shows how many rounds that takes:
+// after round 1 only one column has changed, after round 2 the whole state has.
+// Together with aesgo.NewWithRounds it shows why reduced variants are weak.
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Diffusion is how many state bits differ after a round, over every single bit flip.
+type Diffusion struct {
+	Round int
+	Mean  float64
+	Min   int
+	Max   int
+}
+
+// PlaintextAvalanche flips each of the 128 bits of plaintext and compares the state of every round
+// with the state of plaintext. Round 0 is the initial AddRoundKey.
+func PlaintextAvalanche(k key.Key, plaintext [16]byte, rounds int) ([]Diffusion, error) {
+	base, err := trace(k, plaintext, rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	var traces [][][16]byte
+	for bit := 0; bit < 128; bit++ {
+		t, err := trace(k, Flip(plaintext, bit), rounds)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, t)
+	}
+
+	return diffusion(base, traces), nil
+}
+
+// KeyAvalanche flips each of the 128 bits of the key instead.
+func KeyAvalanche(k key.Key, plaintext [16]byte, rounds int) ([]Diffusion, error) {
+	if k.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	base, err := trace(k, plaintext, rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	material := [16]byte(k.GetBytes())
+
+	var traces [][][16]byte
+	for bit := 0; bit < 128; bit++ {
+		t, err := trace(key.NewKey(Flip(material, bit)), plaintext, rounds)
+		if err != nil {
+			return nil, err
+		}
+		traces = append(traces, t)
+	}
+
+	return diffusion(base, traces), nil
+}
+
+// Flip returns b with one bit flipped, bit 0 is the most significant bit of the first byte.
+func Flip(b [16]byte, bit int) [16]byte {
+	b[bit/8] ^= 0x80 >> (bit % 8)
+	return b
+}
+
+// Distance is the number of different bits (the Hamming distance).
+func Distance(a, b [16]byte) int {
+	d := 0
+	for i := range a {
+		d += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return d
+}
+
+// WriteTable prints one line per round with the statistics and a bar of the mean, 64 bits being
+// the ideal half of the state.
+func WriteTable(w io.Writer, d []Diffusion) error {
+	if _, err := fmt.Fprintf(w, "%-6s %6s %4s %4s\n", "round", "mean", "min", "max"); err != nil {
+		return err
+	}
+
+	for _, r := range d {
+		bar := strings.Repeat("#", int(r.Mean/2+0.5))
+		if _, err := fmt.Fprintf(w, "%-6d %6.2f %4d %4d  %s\n", r.Round, r.Mean, r.Min, r.Max, bar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffusion(base [][16]byte, traces [][][16]byte) []Diffusion {
+	d := make([]Diffusion, len(base))
+
+	for round := range base {
+		d[round] = Diffusion{Round: round, Min: 128}
+
+		total := 0
+		for _, t := range traces {
+			n := Distance(base[round], t[round])
+			total += n
+			d[round].Min = min(d[round].Min, n)
+			d[round].Max = max(d[round].Max, n)
+		}
+		d[round].Mean = float64(total) / float64(len(traces))
+	}
+
+	return d
+}
+
+// trace returns the state after every round.
+func trace(k key.Key, plaintext [16]byte, rounds int) ([][16]byte, error) {
+	r := &recorder{states: make([][16]byte, rounds+1)}
+
+	a, err := aesgo.NewWithRounds(k, rounds, aesgo.WithTracer(r))
+	if err != nil {
+		return nil, err
+	}
+	a.EncryptBlock(plaintext)
+
+	return r.states, nil
+}
+
+// recorder keeps the state at the end of each round.
+type recorder struct {
+	states [][16]byte
+}
+
+func (r *recorder) AfterSubBytes(round int, state [16]byte)   {}
+func (r *recorder) AfterShiftRows(round int, state [16]byte)  {}
+func (r *recorder) AfterMixColumns(round int, state [16]byte) {}
+
+func (r *recorder) AfterAddRoundKey(round int, state, roundKey [16]byte) {
+	r.states[round] = state
+}
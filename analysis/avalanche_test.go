@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestPlaintextAvalanche(t *testing.T) {
+	d, err := PlaintextAvalanche(key.Bit128(), [16]byte{}, 10)
+	if err != nil {
+		t.Fatalf("Error running analysis: %s", err)
+	}
+
+	if len(d) != 11 {
+		t.Fatalf("Expected 11 rounds, got %d", len(d))
+	}
+
+	// before SubBytes the flipped bit is the only difference
+	if d[0].Min != 1 || d[0].Max != 1 {
+		t.Errorf("Round 0. Expected exactly 1 bit, got min %d and max %d", d[0].Min, d[0].Max)
+	}
+
+	// after round 1 a single column (32 bits) at most
+	if d[1].Max > 32 {
+		t.Errorf("Round 1. Expected at most 32 bits, got %d", d[1].Max)
+	}
+
+	// from round 2 on, about half the state
+	for _, r := range d[2:] {
+		if r.Mean < 60 || r.Mean > 68 {
+			t.Errorf("Round %d. Expected a mean close to 64, got %.2f", r.Round, r.Mean)
+		}
+	}
+}
+
+func TestKeyAvalanche(t *testing.T) {
+	d, err := KeyAvalanche(key.Bit128(), [16]byte{}, 4)
+	if err != nil {
+		t.Fatalf("Error running analysis: %s", err)
+	}
+
+	if len(d) != 5 {
+		t.Fatalf("Expected 5 rounds, got %d", len(d))
+	}
+	if d[0].Min != 1 || d[0].Max != 1 {
+		t.Errorf("Round 0. Expected exactly 1 bit, got min %d and max %d", d[0].Min, d[0].Max)
+	}
+	if d[4].Mean < 60 || d[4].Mean > 68 {
+		t.Errorf("Round 4. Expected a mean close to 64, got %.2f", d[4].Mean)
+	}
+
+	k := key.Bit128()
+	k.Destroy()
+	if _, err := KeyAvalanche(k, [16]byte{}, 4); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
+
+func TestFlip(t *testing.T) {
+	b := Flip([16]byte{}, 0)
+	if b[0] != 0x80 {
+		t.Errorf("Expected 80, got %02x", b[0])
+	}
+
+	b = Flip(b, 127)
+	if b[15] != 0x01 || Distance(b, [16]byte{}) != 2 {
+		t.Errorf("Expected 2 bits set, got %x", b)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteTable(&out, []Diffusion{{Round: 0, Mean: 1, Min: 1, Max: 1}, {Round: 1, Mean: 20.5, Min: 12, Max: 32}})
+	if err != nil {
+		t.Fatalf("Error writing table: %s", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[2], "20.50") || !strings.HasSuffix(lines[2], strings.Repeat("#", 10)) {
+		t.Errorf("Unexpected line %q", lines[2])
+	}
+}
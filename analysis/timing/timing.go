@@ -0,0 +1,207 @@
+// Package timing measures how long EncryptBlock takes depending on which cache lines of the S-box
+// it reads, the leak behind cache-timing attacks (Bernstein, "Cache-timing attacks on AES", 2005).
+//
+// The S-box is a 256 byte table, 4 cache lines of 64 bytes. In the first round byte i of the state
+// is p[i] ^ k[i], so with the key known the plaintext can be chosen to make every first round
+// lookup hit the same line. Each line is a class of plaintexts. When a lookup takes longer for a
+// line that isn't cached, the classes take different times and the time tells something about
+// p ^ k, so about the key. With WithConstantTime every lookup reads the whole table and the
+// classes should be indistinguishable.
+//
+// The classes are compared with Welch's t-test like dudect does (Reparaz, Balasch and Verbauwhede,
+// "Dude, is my code constant time?", 2017): |t| above 4.5 is strong evidence of a leak. Expect
+// noise. On a modern CPU the whole table sits in L1 between two blocks, so the leak of the table
+// implementation can be too small to see without evicting the cache.
+package timing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"slices"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// LeakThreshold is the |t| dudect takes as a leak.
+const LeakThreshold = 4.5
+
+var ErrInvalidConfig = errors.New("Samples must be positive and LineSize must divide 256")
+
+type Config struct {
+	// Samples per class. Defaults to 10000.
+	Samples int
+	// LineSize is the cache line size in bytes, 256/LineSize is the number of classes. Defaults to 64.
+	LineSize int
+	// Seed makes the plaintexts reproducible.
+	Seed int64
+}
+
+// Distribution is the time of EncryptBlock for the plaintexts of one class.
+type Distribution struct {
+	// Line is the S-box cache line every first round lookup hits.
+	Line int
+
+	Samples []time.Duration
+
+	// in nanoseconds, without the slowest 10%
+	Mean, StdDev                       float64
+	Median, Percentile10, Percentile90 time.Duration
+}
+
+// Report has one distribution per class.
+type Report struct {
+	Classes []Distribution
+}
+
+// MaxT is the largest |t| between two classes, and which classes.
+func (r *Report) MaxT() (float64, int, int) {
+	var t float64
+	var a, b int
+	for i := range r.Classes {
+		for j := i + 1; j < len(r.Classes); j++ {
+			if w := math.Abs(WelchT(r.Classes[i], r.Classes[j])); w > t {
+				t, a, b = w, i, j
+			}
+		}
+	}
+	return t, a, b
+}
+
+// Leaks is whether any two classes differ by more than LeakThreshold.
+func (r *Report) Leaks() bool {
+	t, _, _ := r.MaxT()
+	return t > LeakThreshold
+}
+
+// Measure encrypts Samples blocks for every class with a cipher for k created with opts, like
+// aesgo.WithConstantTime(), and times every EncryptBlock.
+func Measure(k key.Key, c Config, opts ...aesgo.Option) (*Report, error) {
+	if c.Samples == 0 {
+		c.Samples = 10000
+	}
+	if c.LineSize == 0 {
+		c.LineSize = 64
+	}
+	if c.Samples < 0 || c.LineSize <= 0 || 256%c.LineSize != 0 {
+		return nil, ErrInvalidConfig
+	}
+
+	a, err := aesgo.NewCipher(k, opts...)
+	if err != nil {
+		return nil, err
+	}
+	material := k.GetBytes()
+
+	lines := 256 / c.LineSize
+	samples := make([][]time.Duration, lines)
+	r := rand.New(rand.NewSource(c.Seed))
+
+	// a first pass to warm up, then the classes are interleaved in random order so that noise
+	// (frequency scaling, other processes) is spread over all of them
+	order := make([]int, lines)
+	for i := range order {
+		order[i] = i
+	}
+	for n := -1; n < c.Samples; n++ {
+		r.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		for _, line := range order {
+			p := Plaintext(material, line, c.LineSize, r)
+
+			start := time.Now()
+			a.EncryptBlock(p)
+			elapsed := time.Since(start)
+
+			if n >= 0 {
+				samples[line] = append(samples[line], elapsed)
+			}
+		}
+	}
+
+	report := &Report{}
+	for line, s := range samples {
+		report.Classes = append(report.Classes, distribution(line, s))
+	}
+	return report, nil
+}
+
+// Plaintext returns a random plaintext whose first round S-box lookups, p[i] ^ k[i], all fall in
+// line. Only the first 16 bytes of the key are used, they are the first round key.
+func Plaintext(k []byte, line, lineSize int, r *rand.Rand) [16]byte {
+	var p [16]byte
+	for i := range p {
+		p[i] = k[i] ^ byte(line*lineSize+r.Intn(lineSize))
+	}
+	return p
+}
+
+// WelchT is Welch's t statistic of the means of two classes.
+func WelchT(a, b Distribution) float64 {
+	// the number of samples in the mean, without the slowest 10%
+	na, nb := float64(max(1, len(a.Samples)*9/10)), float64(max(1, len(b.Samples)*9/10))
+	se := math.Sqrt(a.StdDev*a.StdDev/na + b.StdDev*b.StdDev/nb)
+	if se == 0 {
+		return 0
+	}
+	return (a.Mean - b.Mean) / se
+}
+
+// WriteTable prints one line per class and the largest t.
+func WriteTable(w io.Writer, r *Report) error {
+	if _, err := fmt.Fprintf(w, "%-5s %10s %10s %10s %10s %10s\n", "line", "mean", "stddev", "p10", "median", "p90"); err != nil {
+		return err
+	}
+
+	for _, d := range r.Classes {
+		_, err := fmt.Fprintf(w, "%-5d %10.1f %10.1f %10s %10s %10s\n", d.Line, d.Mean, d.StdDev, d.Percentile10, d.Median, d.Percentile90)
+		if err != nil {
+			return err
+		}
+	}
+
+	t, a, b := r.MaxT()
+	verdict := "no leak found"
+	if t > LeakThreshold {
+		verdict = "leak"
+	}
+	_, err := fmt.Fprintf(w, "max |t| = %.2f between lines %d and %d: %s\n", t, a, b, verdict)
+	return err
+}
+
+// distribution leaves the slowest 10% of the samples out of the mean and the deviation, like
+// dudect crops them. They are mostly interrupts and garbage collection.
+func distribution(line int, samples []time.Duration) Distribution {
+	d := Distribution{Line: line, Samples: samples}
+	if len(samples) == 0 {
+		return d
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	d.Percentile10 = sorted[len(sorted)/10]
+	d.Median = sorted[len(sorted)/2]
+	d.Percentile90 = sorted[len(sorted)*9/10]
+
+	cropped := sorted[:max(1, len(sorted)*9/10)]
+
+	var sum float64
+	for _, s := range cropped {
+		sum += float64(s)
+	}
+	d.Mean = sum / float64(len(cropped))
+
+	var squares float64
+	for _, s := range cropped {
+		squares += (float64(s) - d.Mean) * (float64(s) - d.Mean)
+	}
+	if len(cropped) > 1 {
+		d.StdDev = math.Sqrt(squares / float64(len(cropped)-1))
+	}
+
+	return d
+}
@@ -0,0 +1,112 @@
+package timing
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestPlaintext(t *testing.T) {
+	k := key.Bit256().GetBytes()
+	r := rand.New(rand.NewSource(1))
+
+	for _, lineSize := range []int{64, 32} {
+		for line := 0; line < 256/lineSize; line++ {
+			for n := 0; n < 100; n++ {
+				p := Plaintext(k, line, lineSize, r)
+				for i := range p {
+					if got := int(p[i]^k[i]) / lineSize; got != line {
+						t.Fatalf("Byte %d of %x hits line %d, expected %d", i, p, got, line)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestWelchT(t *testing.T) {
+	tests := []struct {
+		name string
+
+		a, b []time.Duration
+
+		expected float64
+	}{
+		{
+			name: "same distribution",
+
+			a: []time.Duration{10, 11, 12, 10, 11, 12, 10, 11, 12, 100},
+			b: []time.Duration{10, 11, 12, 10, 11, 12, 10, 11, 12, 100},
+
+			expected: 0,
+		},
+		{
+			name: "different means",
+
+			// the slowest of each is cropped, leaving 9 samples with means of 11 and 21 and a variance of 0.75
+			a: []time.Duration{10, 11, 12, 10, 11, 12, 10, 11, 12, 100},
+			b: []time.Duration{20, 21, 22, 20, 21, 22, 20, 21, 22, 100},
+
+			expected: -10 / math.Sqrt(2*0.75/9),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := WelchT(distribution(0, test.a), distribution(1, test.b))
+			if math.Abs(got-test.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	k := key.Bit128()
+
+	for _, opts := range [][]aesgo.Option{nil, {aesgo.WithConstantTime()}} {
+		report, err := Measure(k, Config{Samples: 50, Seed: 1}, opts...)
+		if err != nil {
+			t.Fatalf("Error measuring: %s", err)
+		}
+
+		// timings are too noisy to check for a leak in a test, only the report is
+		if len(report.Classes) != 4 {
+			t.Fatalf("Expected 4 classes, got %d", len(report.Classes))
+		}
+		for i, d := range report.Classes {
+			if d.Line != i || len(d.Samples) != 50 || d.Mean <= 0 {
+				t.Errorf("Unexpected class %d: line %d, %d samples, mean %f", i, d.Line, len(d.Samples), d.Mean)
+			}
+			if d.Percentile10 > d.Median || d.Median > d.Percentile90 {
+				t.Errorf("Unexpected percentiles %s %s %s", d.Percentile10, d.Median, d.Percentile90)
+			}
+		}
+
+		var out bytes.Buffer
+		if err := WriteTable(&out, report); err != nil {
+			t.Fatalf("Error writing: %s", err)
+		}
+		if lines := strings.Count(out.String(), "\n"); lines != 6 || !strings.Contains(out.String(), "max |t| =") {
+			t.Errorf("Unexpected table:\n%s", out.String())
+		}
+	}
+}
+
+func TestMeasureErrors(t *testing.T) {
+	if _, err := Measure(key.Bit128(), Config{LineSize: 48}); err != ErrInvalidConfig {
+		t.Errorf("Expected %v, got %v", ErrInvalidConfig, err)
+	}
+
+	k := key.Bit128()
+	k.Destroy()
+	if _, err := Measure(k, Config{Samples: 1}); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
@@ -0,0 +1,118 @@
+// Package ctr shows why a CTR nonce must never be reused.
+//
+// CTR turns AES into a stream cipher: the ciphertext is the plain text XOR a keystream that only
+// depends on the key and the nonce. Two messages encrypted with the same nonce use the same keystream,
+// so XORing the ciphertexts cancels it out and leaves P1 ⊕ P2. Guessing a word of one message
+// (a crib) then reveals the other message at the same position.
+package ctr
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+)
+
+var ErrDifferentNonces = errors.New("The ciphertexts don't share the nonce")
+
+// SameNonce says if two aes-go CTR outputs (nonce + ciphertext) used the same nonce.
+func SameNonce(c1, c2 []byte) bool {
+	return len(c1) >= 16 && len(c2) >= 16 && bytes.Equal(c1[:16], c2[:16])
+}
+
+// XORCiphertexts returns P1 ⊕ P2 for two aes-go CTR outputs with the same nonce.
+// It is as long as the shorter message.
+func XORCiphertexts(c1, c2 []byte) ([]byte, error) {
+	if !SameNonce(c1, c2) {
+		return nil, ErrDifferentNonces
+	}
+	return xor(c1[16:], c2[16:]), nil
+}
+
+// RecoverKeystream returns the keystream used for ciphertext (without the nonce) when its plain text is known.
+// It decrypts any other message encrypted with the same nonce, up to len(plaintext) bytes.
+func RecoverKeystream(ciphertext, plaintext []byte) []byte {
+	return xor(ciphertext, plaintext)
+}
+
+// Decrypt XORs ciphertext (without the nonce) with a recovered keystream.
+func Decrypt(keystream, ciphertext []byte) []byte {
+	return xor(keystream, ciphertext)
+}
+
+// Match is the result of placing the crib at Offset.
+type Match struct {
+	Offset int
+	// Text is what the other message has at Offset, if the crib is really there.
+	Text []byte
+	// Score is how much Text looks like English, the higher the better.
+	Score float64
+}
+
+// CribDrag slides crib over P1 ⊕ P2 and returns every position sorted by score, best first.
+// A high score means the crib is probably at that position in one of the messages and Text is
+// the other message there.
+func CribDrag(xored, crib []byte) []Match {
+	if len(crib) == 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i := 0; i+len(crib) <= len(xored); i++ {
+		text := xor(xored[i:i+len(crib)], crib)
+		matches = append(matches, Match{Offset: i, Text: text, Score: score(text)})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	return matches
+}
+
+// score averages how common every character is in English text, normalized so a text of only
+// spaces (the most common character) would score 1. Garbage scores below 0.
+func score(b []byte) float64 {
+	total := 0.0
+	for _, c := range b {
+		total += frequencies[c]
+	}
+	return total / float64(len(b)) / frequencies[' ']
+}
+
+// frequencies has the percentage of each character in English text, uppercase letters count the same as lowercase.
+// Characters that are rare in text are negative, so garbage with a few spaces doesn't score well.
+var frequencies = func() [256]float64 {
+	var f [256]float64
+	for i := range f {
+		f[i] = -10
+	}
+
+	letters := map[byte]float64{
+		'a': 8.2, 'b': 1.5, 'c': 2.8, 'd': 4.3, 'e': 12.7, 'f': 2.2, 'g': 2.0, 'h': 6.1, 'i': 7.0,
+		'j': 0.15, 'k': 0.77, 'l': 4.0, 'm': 2.4, 'n': 6.7, 'o': 7.5, 'p': 1.9, 'q': 0.095, 'r': 6.0,
+		's': 6.3, 't': 9.1, 'u': 2.8, 'v': 0.98, 'w': 2.4, 'x': 0.15, 'y': 2.0, 'z': 0.074,
+	}
+	for c, v := range letters {
+		f[c] = v
+		f[c-'a'+'A'] = v
+	}
+
+	f[' '] = 19
+	for _, c := range []byte(".,'!?-\n") {
+		f[c] = 1
+	}
+	for c := '0'; c <= '9'; c++ {
+		f[c] = 0.5
+	}
+
+	return f
+}()
+
+func xor(a, b []byte) []byte {
+	n := min(len(a), len(b))
+	r := make([]byte, n)
+	for i := 0; i < n; i++ {
+		r[i] = a[i] ^ b[i]
+	}
+	return r
+}
@@ -0,0 +1,74 @@
+package ctr
+
+import (
+	"bytes"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// encryptWithNonce forces the nonce, aes-go always generates a new one.
+func encryptWithNonce(t *testing.T, k key.Key, nonce, plaintext []byte) []byte {
+	a, err := aesgo.NewCipher(k, aesgo.WithRandReader(bytes.NewReader(nonce)))
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+
+	encrypted, err := a.Encrypt(aesgo.CTR, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	return encrypted
+}
+
+func TestNonceReuse(t *testing.T) {
+	k := key.Bit128()
+	nonce := key.Bit128().GetBytes()
+
+	p1 := []byte("Attack at dawn, bring the secret documents to the bridge.")
+	p2 := []byte("The meeting is cancelled, nobody should leave the house!!")
+
+	c1 := encryptWithNonce(t, k, nonce, p1)
+	c2 := encryptWithNonce(t, k, nonce, p2)
+
+	xored, err := XORCiphertexts(c1, c2)
+	if err != nil {
+		t.Fatalf("Error xoring: %s", err)
+	}
+
+	if !bytes.Equal(xored, xor(p1, p2)) {
+		t.Errorf("Expected P1 ^ P2, got %x", xored)
+	}
+
+	// guessing " the secret " in the first message reveals the second one at the same place
+	matches := CribDrag(xored, []byte(" the secret "))
+	best := matches[0]
+	if best.Offset != 21 || string(best.Text) != string(p2[21:33]) {
+		t.Errorf("Expected offset 21 with %q, got %d with %q", p2[21:33], best.Offset, best.Text)
+	}
+
+	// knowing the first message decrypts the second one
+	keystream := RecoverKeystream(c1[16:], p1)
+	if got := Decrypt(keystream, c2[16:]); !bytes.Equal(got, p2) {
+		t.Errorf("Got: %s, Expected: %s", got, p2)
+	}
+}
+
+func TestDifferentNonces(t *testing.T) {
+	k := key.Bit128()
+	a := aesgo.New(k)
+
+	c1, err := a.Encrypt(aesgo.CTR, []byte("first message"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	c2, err := a.Encrypt(aesgo.CTR, []byte("second message"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	if _, err := XORCiphertexts(c1, c2); err != ErrDifferentNonces {
+		t.Errorf("Expected %v, got %v", ErrDifferentNonces, err)
+	}
+}
@@ -0,0 +1,220 @@
+// Package dfa implements the differential fault analysis of Piret and Quisquater on AES-128.
+//
+// A single byte fault injected before the 9th round goes through one MixColumns, so the difference
+// at the end of round 9 is one column with the pattern (2e, e, e, 3e) for some unknown e (rotated
+// depending on the faulty row). Round 10 has no MixColumns, so each of those 4 bytes only goes
+// through SubBytes and AddRoundKey with one byte of the last round key K. For a guess of K,
+//
+//	InvSBox(C ^ K) ^ InvSBox(C' ^ K)
+//
+// must follow the pattern, which only a few guesses do. Two faulty ciphertexts per column are usually
+// enough to leave one candidate for those 4 bytes of K, and the key schedule can be run backwards
+// from the last round key to the key itself.
+//
+// See "A Differential Fault Attack Technique against SPN Structures, with Application to the AES and KHAZAD".
+package dfa
+
+import (
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/gf256"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var (
+	ErrNotEnoughPairs = errors.New("Not enough faulty ciphertexts to find a single key")
+	ErrNoCandidate    = errors.New("No key matches the faulty ciphertexts, was the fault injected before round 9?")
+)
+
+// Pair is the output of the same plain text without and with a fault.
+type Pair struct {
+	Correct, Faulty [16]byte
+}
+
+// Encrypt plays the attacked device: it encrypts plaintext normally and again with the fault.
+func Encrypt(k key.Key, plaintext [16]byte, fault aesgo.Fault) (Pair, error) {
+	correct, err := aesgo.NewCipher(k)
+	if err != nil {
+		return Pair{}, err
+	}
+	faulty, err := aesgo.NewCipher(k, aesgo.WithFault(fault))
+	if err != nil {
+		return Pair{}, err
+	}
+
+	return Pair{
+		Correct: toArray(correct.EncryptBlock(plaintext)),
+		Faulty:  toArray(faulty.EncryptBlock(plaintext)),
+	}, nil
+}
+
+func toArray(m [4][4]byte) [16]byte {
+	var r [16]byte
+	for c := 0; c < 4; c++ {
+		for row := 0; row < 4; row++ {
+			r[c*4+row] = m[row][c]
+		}
+	}
+	return r
+}
+
+// mixColumns is the MixColumns matrix. A difference e in row r becomes column r of it times e.
+var mixColumns = [4][4]byte{
+	{2, 3, 1, 1},
+	{1, 2, 3, 1},
+	{1, 1, 2, 3},
+	{3, 1, 1, 2},
+}
+
+// diagonal returns the ciphertext indexes that come from column c of round 9.
+// Row i is moved from column c to column c - i by the last ShiftRows.
+func diagonal(c int) [4]int {
+	var d [4]int
+	for i := 0; i < 4; i++ {
+		d[i] = ((c-i+4)%4)*4 + i
+	}
+	return d
+}
+
+// Key recovers the AES-128 key from pairs with faults injected before round 9.
+func Key(pairs []Pair) ([16]byte, error) {
+	last, err := LastRoundKey(pairs)
+	if err != nil {
+		return [16]byte{}, err
+	}
+	return MasterKey(last), nil
+}
+
+// LastRoundKey finds the round 10 key, 4 bytes at a time. Pairs that don't look like a round 9 fault
+// (not exactly one diagonal changed) are ignored.
+func LastRoundKey(pairs []Pair) ([16]byte, error) {
+	var candidates [4]map[[4]byte]bool
+
+	for _, p := range pairs {
+		c, ok := faultyDiagonal(p)
+		if !ok {
+			continue
+		}
+
+		found := guesses(p, diagonal(c))
+		if candidates[c] != nil {
+			// only the guesses that work for every pair are left
+			for k := range candidates[c] {
+				if !found[k] {
+					delete(candidates[c], k)
+				}
+			}
+		} else {
+			candidates[c] = found
+		}
+	}
+
+	var k [16]byte
+	for c := 0; c < 4; c++ {
+		switch {
+		case candidates[c] == nil:
+			return k, ErrNotEnoughPairs
+		case len(candidates[c]) == 0:
+			return k, ErrNoCandidate
+		case len(candidates[c]) > 1:
+			return k, ErrNotEnoughPairs
+		}
+
+		for guess := range candidates[c] {
+			for i, idx := range diagonal(c) {
+				k[idx] = guess[i]
+			}
+		}
+	}
+
+	return k, nil
+}
+
+// faultyDiagonal returns the diagonal changed by the fault.
+func faultyDiagonal(p Pair) (int, bool) {
+	total := 0
+	for i := range p.Correct {
+		if p.Correct[i] != p.Faulty[i] {
+			total++
+		}
+	}
+	if total != 4 {
+		return 0, false
+	}
+
+	for c := 0; c < 4; c++ {
+		changed := 0
+		for _, idx := range diagonal(c) {
+			if p.Correct[idx] != p.Faulty[idx] {
+				changed++
+			}
+		}
+		if changed == 4 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// guesses returns every value of the 4 key bytes of the diagonal that explains the pair.
+func guesses(p Pair, d [4]int) map[[4]byte]bool {
+	// byDiff[i][x] has the key bytes k where InvSBox(C ^ k) ^ InvSBox(C' ^ k) = x
+	var byDiff [4][256][]byte
+	invSBox := primitives.InvSBox()
+	for i, idx := range d {
+		for k := 0; k < 256; k++ {
+			x := invSBox[p.Correct[idx]^byte(k)] ^ invSBox[p.Faulty[idx]^byte(k)]
+			byDiff[i][x] = append(byDiff[i][x], byte(k))
+		}
+	}
+
+	found := map[[4]byte]bool{}
+	for row := 0; row < 4; row++ {
+		for e := 1; e < 256; e++ {
+			var options [4][]byte
+			for i := 0; i < 4; i++ {
+				options[i] = byDiff[i][gf256.Mul(mixColumns[i][row], byte(e))]
+			}
+
+			for _, k0 := range options[0] {
+				for _, k1 := range options[1] {
+					for _, k2 := range options[2] {
+						for _, k3 := range options[3] {
+							found[[4]byte{k0, k1, k2, k3}] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// MasterKey runs the AES-128 key schedule backwards, from the round 10 key to the key.
+func MasterKey(lastRoundKey [16]byte) [16]byte {
+	var w [44][4]byte
+	for i := 0; i < 4; i++ {
+		copy(w[40+i][:], lastRoundKey[i*4:])
+	}
+
+	// forwards it is w[i] = w[i-4] ^ temp, so w[i-4] = w[i] ^ temp
+	for i := 43; i >= 4; i-- {
+		temp := w[i-1]
+		if i%4 == 0 {
+			temp = primitives.SubWord(primitives.RotWord(temp))
+			temp[0] ^= primitives.Rcon(i / 4)[0]
+		}
+		for j := 0; j < 4; j++ {
+			w[i-4][j] = w[i][j] ^ temp[j]
+		}
+	}
+
+	var k [16]byte
+	for i := 0; i < 4; i++ {
+		copy(k[i*4:], w[i][:])
+	}
+	return k
+}
@@ -0,0 +1,73 @@
+package dfa
+
+import (
+	"math/rand"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestMasterKey(t *testing.T) {
+	// FIPS-197 appendix A.1, the key 2b7e1516... has the round 10 key d014f9a8...
+	last := [16]byte{0xd0, 0x14, 0xf9, 0xa8, 0xc9, 0xee, 0x25, 0x89, 0xe1, 0x3f, 0x0c, 0xc8, 0xb6, 0x63, 0x0c, 0xa6}
+	expected := [16]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c}
+
+	if got := MasterKey(last); got != expected {
+		t.Errorf("Expected %x, got %x", expected, got)
+	}
+}
+
+func TestKey(t *testing.T) {
+	// a fixed key, with some keys two faults per column leave more than one candidate
+	// and the test would fail now and then
+	k := key.Bit128(key.WithRandReader(rand.New(rand.NewSource(2))))
+	r := rand.New(rand.NewSource(1))
+
+	// two random faults in every column of the state before round 9
+	var pairs []Pair
+	for c := 0; c < 4; c++ {
+		for i := 0; i < 2; i++ {
+			var plaintext [16]byte
+			r.Read(plaintext[:])
+
+			fault := aesgo.Fault{Round: 9, Row: r.Intn(4), Column: c, Mask: byte(r.Intn(255) + 1)}
+			p, err := Encrypt(k, plaintext, fault)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			pairs = append(pairs, p)
+		}
+	}
+
+	got, err := Key(pairs)
+	if err != nil {
+		t.Fatalf("Error recovering the key: %s", err)
+	}
+
+	if expected := [16]byte(k.GetBytes()); got != expected {
+		t.Errorf("Expected %x, got %x", expected, got)
+	}
+}
+
+func TestKeyErrors(t *testing.T) {
+	k := key.Bit128()
+
+	// one pair only gives one column
+	p, err := Encrypt(k, [16]byte{}, aesgo.Fault{Round: 9, Column: 1, Mask: 1})
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if _, err := Key([]Pair{p}); err != ErrNotEnoughPairs {
+		t.Errorf("Expected %v, got %v", ErrNotEnoughPairs, err)
+	}
+
+	// a fault in round 8 changes the whole block, there is nothing to use
+	p, err = Encrypt(k, [16]byte{}, aesgo.Fault{Round: 8, Mask: 1})
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if _, err := Key([]Pair{p}); err != ErrNotEnoughPairs {
+		t.Errorf("Expected %v, got %v", ErrNotEnoughPairs, err)
+	}
+}
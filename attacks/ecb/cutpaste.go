@@ -0,0 +1,106 @@
+package ecb
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var ErrInvalidProfile = errors.New("Invalid profile")
+
+// Profile is the user of the cut-and-paste demo. The server encodes it as
+// email=foo@bar.com&uid=10&role=user and hands it out encrypted with ECB, like a cookie.
+type Profile struct {
+	Email string
+	UID   string
+	Role  string
+}
+
+func (p Profile) Encode() string {
+	return "email=" + p.Email + "&uid=" + p.UID + "&role=" + p.Role
+}
+
+func ParseProfile(s string) (Profile, error) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return Profile{}, ErrInvalidProfile
+	}
+
+	p := Profile{Email: values.Get("email"), UID: values.Get("uid"), Role: values.Get("role")}
+	if p.Email == "" || p.Role == "" {
+		return Profile{}, ErrInvalidProfile
+	}
+	return p, nil
+}
+
+// ProfileServer plays the vulnerable server. It only lets users pick their email, the role is
+// always "user", and the cookie is encrypted so it can't be edited... or so it thinks.
+type ProfileServer struct {
+	aes *aesgo.AES
+}
+
+func NewProfileServer(k key.Key) (*ProfileServer, error) {
+	aes, err := aesgo.NewCipher(k, aesgo.WithInsecureModes())
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileServer{aes: aes}, nil
+}
+
+// ProfileFor returns the encrypted profile of a new user. & and = are removed from the email,
+// so the role can't be injected directly.
+func (s *ProfileServer) ProfileFor(email string) ([]byte, error) {
+	email = strings.NewReplacer("&", "", "=", "").Replace(email)
+	p := Profile{Email: email, UID: "10", Role: "user"}
+	return s.aes.Encrypt(aesgo.ECB, []byte(p.Encode()))
+}
+
+// Login decrypts the cookie and returns the profile in it.
+func (s *ProfileServer) Login(cookie []byte) (Profile, error) {
+	decrypted, err := s.aes.Decrypt(aesgo.ECB, cookie)
+	if err != nil {
+		return Profile{}, err
+	}
+	return ParseProfile(string(decrypted))
+}
+
+// CutAndPaste forges an admin cookie using only ProfileFor. It works because ECB encrypts every block
+// on its own, so blocks from different ciphertexts can be glued together:
+//
+//	email=fffoo@bar. | com&uid=10&role= | user<padding>      <- first cookie, the role starts a block
+//	email=xxxxxxxxxx | admin<padding>   | &uid=10&role=user  <- second cookie, "admin" padded in its own block
+//
+// The first two blocks of the first cookie followed by the second block of the second one decrypt
+// to email=fffoo@bar.com&uid=10&role=admin.
+func CutAndPaste(server *ProfileServer) ([]byte, error) {
+	prefix, suffix := "email=", "&uid=10&role="
+
+	// make "email=...&uid=10&role=" end at a block boundary
+	email := "foo@bar.com"
+	for (len(prefix)+len(email)+len(suffix))%16 != 0 {
+		email = "f" + email
+	}
+
+	first, err := server.ProfileFor(email)
+	if err != nil {
+		return nil, err
+	}
+	aligned := first[:len(prefix)+len(email)+len(suffix)]
+
+	// fill the first block, then "admin" with the padding it would have as the last block
+	admin := make([]byte, 16)
+	copy(admin, "admin")
+	copy(admin[5:], bytes.Repeat([]byte{11}, 11))
+
+	second, err := server.ProfileFor(strings.Repeat("x", 16-len(prefix)) + string(admin))
+	if err != nil {
+		return nil, err
+	}
+
+	forged := append([]byte{}, aligned...)
+	return append(forged, second[16:32]...), nil
+}
@@ -0,0 +1,26 @@
+// Package ecb has attacks on the ECB mode.
+//
+// ECB encrypts every block on its own, so equal plain text blocks give equal cipher text blocks.
+package ecb
+
+// RepeatedBlocks counts the 16 byte blocks that already appeared earlier in ciphertext.
+func RepeatedBlocks(ciphertext []byte) int {
+	seen := map[[16]byte]bool{}
+	repeated := 0
+
+	for i := 0; i+16 <= len(ciphertext); i += 16 {
+		block := [16]byte(ciphertext[i : i+16])
+		if seen[block] {
+			repeated++
+		}
+		seen[block] = true
+	}
+
+	return repeated
+}
+
+// Detect says if ciphertext was probably encrypted with ECB. It only works when the plain text
+// has repeated blocks, which is common in images and structured data.
+func Detect(ciphertext []byte) bool {
+	return RepeatedBlocks(ciphertext) > 0
+}
@@ -0,0 +1,119 @@
+package ecb
+
+import (
+	"bytes"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestDetect(t *testing.T) {
+	aes := aesgo.New(key.Bit128(), aesgo.WithInsecureModes())
+
+	// 4 equal blocks
+	plaintext := bytes.Repeat([]byte("YELLOW SUBMARINE"), 4)
+
+	tests := []struct {
+		name string
+
+		mode     aesgo.Mode
+		expected int
+	}{
+		{
+			name:     "ECB",
+			mode:     aesgo.ECB,
+			expected: 3,
+		},
+		{
+			name:     "CBC",
+			mode:     aesgo.CBC,
+			expected: 0,
+		},
+		{
+			name:     "CTR",
+			mode:     aesgo.CTR,
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted, err := aes.Encrypt(test.mode, plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+
+			if got := RepeatedBlocks(encrypted); got != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, got)
+			}
+			if Detect(encrypted) != (test.expected > 0) {
+				t.Errorf("Expected %v, got %v", test.expected > 0, Detect(encrypted))
+			}
+		})
+	}
+}
+
+func TestCutAndPaste(t *testing.T) {
+	server, err := NewProfileServer(key.Bit128())
+	if err != nil {
+		t.Fatalf("Error creating server: %s", err)
+	}
+
+	forged, err := CutAndPaste(server)
+	if err != nil {
+		t.Fatalf("Error forging: %s", err)
+	}
+
+	p, err := server.Login(forged)
+	if err != nil {
+		t.Fatalf("Error logging in: %s", err)
+	}
+
+	if p.Role != "admin" {
+		t.Errorf("Expected admin, got %s", p.Role)
+	}
+}
+
+func TestProfileFor(t *testing.T) {
+	server, err := NewProfileServer(key.Bit128())
+	if err != nil {
+		t.Fatalf("Error creating server: %s", err)
+	}
+
+	tests := []struct {
+		name string
+
+		email    string
+		expected Profile
+	}{
+		{
+			name:     "normal user",
+			email:    "foo@bar.com",
+			expected: Profile{Email: "foo@bar.com", UID: "10", Role: "user"},
+		},
+		{
+			name:     "role injection is removed",
+			email:    "foo@bar.com&role=admin",
+			expected: Profile{Email: "foo@bar.comroleadmin", UID: "10", Role: "user"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookie, err := server.ProfileFor(test.email)
+			if err != nil {
+				t.Fatalf("Error creating profile: %s", err)
+			}
+
+			p, err := server.Login(cookie)
+			if err != nil {
+				t.Fatalf("Error logging in: %s", err)
+			}
+
+			if p != test.expected {
+				t.Errorf("Expected %+v, got %+v", test.expected, p)
+			}
+		})
+	}
+}
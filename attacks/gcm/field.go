@@ -0,0 +1,60 @@
+package gcm
+
+// element is a GF(2^128) element with the GCM bit order: the most significant bit of the first
+// byte is the coefficient of x^0. Adding is XOR, multiplying is done modulo x^128 + x^7 + x^2 + x + 1.
+type element [16]byte
+
+var one = element{0x80}
+
+func (a element) add(b element) element {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+func (a element) isZero() bool {
+	return a == element{}
+}
+
+// mul is algorithm 1 of NIST SP 800-38D, the same one GHASH uses.
+func (a element) mul(b element) element {
+	var z element
+	v := b
+
+	for i := 0; i < 128; i++ {
+		if a[i/8]&(0x80>>(i%8)) != 0 {
+			z = z.add(v)
+		}
+
+		carry := v[15] & 1
+		for j := 15; j > 0; j-- {
+			v[j] = v[j]>>1 | v[j-1]<<7
+		}
+		v[0] >>= 1
+		if carry == 1 {
+			v[0] ^= 0xe1
+		}
+	}
+
+	return z
+}
+
+// inverse uses a^(2^128 - 2) = a^-1. 2^128 - 2 is 127 ones followed by a zero in binary,
+// so it is the product of a^(2^i) for i from 1 to 127.
+func (a element) inverse() element {
+	r := one
+	sq := a
+	for i := 1; i < 128; i++ {
+		sq = sq.mul(sq)
+		r = r.mul(sq)
+	}
+	return r
+}
+
+// basis returns x^i, used to try different elements when splitting polynomials.
+func basis(i int) element {
+	var e element
+	e[i/8] = 0x80 >> (i % 8)
+	return e
+}
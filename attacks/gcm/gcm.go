@@ -0,0 +1,140 @@
+// Package gcm implements Joux's "forbidden attack" on GCM nonce reuse.
+//
+// The GCM tag of a message is T = GHASH(H, A, C) + E(K, J0), where H = E(K, 0) is the hash key and J0 only
+// depends on the nonce. GHASH is the polynomial
+//
+//	GHASH(H, A, C) = X1*H^n + X2*H^(n-1) + ... + Xn*H
+//
+// where X1..Xn are the blocks of A, the blocks of C and a block with both lengths.
+// Two messages with the same nonce share E(K, J0), so adding both tags cancels it out:
+//
+//	T1 + T2 = GHASH(H, A1, C1) + GHASH(H, A2, C2)
+//
+// That is a polynomial equation where only H is unknown. Its roots are the candidates for H, and
+// with H, E(K, J0) = T1 + GHASH(H, A1, C1). Both together forge a valid tag for any ciphertext
+// with that nonce, without ever knowing the key.
+//
+// See https://eprint.iacr.org/2016/475.pdf (Nonce-Disrespecting Adversaries) for the real world impact.
+package gcm
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrSameMessage = errors.New("The messages must be different")
+	ErrNoCandidate = errors.New("Could not find the hash key, were the messages encrypted with the same nonce?")
+	ErrInvalidTag  = errors.New("Sealed message is shorter than the tag")
+)
+
+const tagSize = 16
+
+// Message is one GCM message seen by the attacker, all fields are public.
+type Message struct {
+	AdditionalData []byte
+	Ciphertext     []byte
+	Tag            [16]byte
+}
+
+// ParseSealed splits the output of aesgo SealGCM (ciphertext + tag).
+func ParseSealed(sealed, additionalData []byte) (Message, error) {
+	if len(sealed) < tagSize {
+		return Message{}, ErrInvalidTag
+	}
+
+	return Message{
+		AdditionalData: additionalData,
+		Ciphertext:     sealed[:len(sealed)-tagSize],
+		Tag:            [16]byte(sealed[len(sealed)-tagSize:]),
+	}, nil
+}
+
+// Forger has everything needed to create tags for the nonce of the attacked messages.
+type Forger struct {
+	// H is the GHASH key, the encryption of a zero block.
+	H [16]byte
+	// Mask is E(K, J0), the value added to GHASH to get the tag.
+	Mask [16]byte
+}
+
+// Tag returns the tag GCM would compute for additionalData and ciphertext.
+func (f *Forger) Tag(additionalData, ciphertext []byte) [16]byte {
+	return [16]byte(ghash(element(f.H), additionalData, ciphertext).add(element(f.Mask)))
+}
+
+// Forge returns ciphertext + tag, ready to be opened with the reused nonce.
+func (f *Forger) Forge(additionalData, ciphertext []byte) []byte {
+	tag := f.Tag(additionalData, ciphertext)
+	return append(append([]byte{}, ciphertext...), tag[:]...)
+}
+
+// Recover returns a Forger for every candidate H. Usually there are only a few, Filter with a third
+// message (or asking the server) tells which one is right.
+func Recover(m1, m2 Message) ([]*Forger, error) {
+	// T1 + T2 + GHASH1(H) + GHASH2(H) = 0
+	f := ghashPoly(m1).add(ghashPoly(m2)).add(poly{element(m1.Tag).add(element(m2.Tag))})
+	if f.degree() < 1 {
+		return nil, ErrSameMessage
+	}
+
+	var forgers []*Forger
+	for _, h := range roots(f) {
+		mask := ghash(h, m1.AdditionalData, m1.Ciphertext).add(element(m1.Tag))
+		forgers = append(forgers, &Forger{H: h, Mask: mask})
+	}
+
+	if len(forgers) == 0 {
+		return nil, ErrNoCandidate
+	}
+	return forgers, nil
+}
+
+// Filter keeps the forgers that compute the right tag for m, which must use the same nonce.
+func Filter(forgers []*Forger, m Message) []*Forger {
+	var r []*Forger
+	for _, f := range forgers {
+		if f.Tag(m.AdditionalData, m.Ciphertext) == m.Tag {
+			r = append(r, f)
+		}
+	}
+	return r
+}
+
+// blocks returns the GHASH input: A and C padded with zeros to full blocks, then the lengths in bits.
+func blocks(additionalData, ciphertext []byte) []element {
+	var r []element
+
+	for _, b := range [][]byte{additionalData, ciphertext} {
+		for i := 0; i < len(b); i += 16 {
+			var e element
+			copy(e[:], b[i:min(i+16, len(b))])
+			r = append(r, e)
+		}
+	}
+
+	var lengths element
+	binary.BigEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.BigEndian.PutUint64(lengths[8:], uint64(len(ciphertext))*8)
+
+	return append(r, lengths)
+}
+
+// ghashPoly returns GHASH of m as a polynomial in H: the first block is the coefficient of the highest power.
+func ghashPoly(m Message) poly {
+	b := blocks(m.AdditionalData, m.Ciphertext)
+
+	p := make(poly, len(b)+1)
+	for i, e := range b {
+		p[len(b)-i] = e
+	}
+	return p.trim()
+}
+
+func ghash(h element, additionalData, ciphertext []byte) element {
+	var y element
+	for _, b := range blocks(additionalData, ciphertext) {
+		y = y.add(b).mul(h)
+	}
+	return y
+}
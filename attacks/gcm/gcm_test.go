@@ -0,0 +1,134 @@
+package gcm
+
+import (
+	"bytes"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestField(t *testing.T) {
+	a := element{0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0, 1, 2, 3, 4, 5, 6, 7, 8}
+	b := element{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x42}
+
+	if a.mul(one) != a {
+		t.Errorf("Expected a * 1 = a, got %x", a.mul(one))
+	}
+	if a.mul(b) != b.mul(a) {
+		t.Errorf("Expected a * b = b * a")
+	}
+	if a.mul(a.inverse()) != one {
+		t.Errorf("Expected a * a^-1 = 1, got %x", a.mul(a.inverse()))
+	}
+}
+
+func TestRoots(t *testing.T) {
+	r1 := element{1, 2, 3}
+	r2 := element{0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x80}
+	r3 := basis(100)
+
+	// (X + r1)(X + r2)(X + r3)(X^2 + X + c), the last factor may or may not have roots depending on c
+	f := poly{r1, one}.mul(poly{r2, one}).mul(poly{r3, one}).mul(poly{basis(1), one, one})
+
+	got := roots(f)
+	for _, r := range got {
+		if v := eval(f, r); !v.isZero() {
+			t.Errorf("%x is not a root, f(r) = %x", r, v)
+		}
+	}
+
+	for _, expected := range []element{r1, r2, r3} {
+		found := false
+		for _, r := range got {
+			found = found || r == expected
+		}
+		if !found {
+			t.Errorf("Root %x not found in %x", expected, got)
+		}
+	}
+}
+
+func TestForbiddenAttack(t *testing.T) {
+	k := key.Bit128()
+	a := aesgo.New(k)
+
+	// the mistake: the same nonce for two messages
+	nonce := []byte("fixed nonce!")
+
+	p1 := []byte("transfer 100 dollars to alice")
+	p2 := []byte("transfer 5 dollars to bob, thanks")
+	ad := []byte("account 42")
+
+	seal := func(plaintext, ad []byte) Message {
+		sealed, err := a.SealGCM(nonce, plaintext, ad)
+		if err != nil {
+			t.Fatalf("Error sealing: %s", err)
+		}
+		m, err := ParseSealed(sealed, ad)
+		if err != nil {
+			t.Fatalf("Error parsing: %s", err)
+		}
+		return m
+	}
+
+	m1 := seal(p1, ad)
+	m2 := seal(p2, ad)
+	m3 := seal([]byte("a third message"), nil)
+
+	forgers, err := Recover(m1, m2)
+	if err != nil {
+		t.Fatalf("Error recovering H: %s", err)
+	}
+
+	forgers = Filter(forgers, m3)
+	if len(forgers) != 1 {
+		t.Fatalf("Expected 1 forger after filtering, got %d", len(forgers))
+	}
+
+	// CTR is malleable, knowing p1 the attacker changes the amount. The forged tag makes it look genuine.
+	forgedText := []byte("transfer 999 dollars to alice")
+	ciphertext := append([]byte{}, m1.Ciphertext...)
+	for i := range ciphertext {
+		ciphertext[i] ^= p1[i] ^ forgedText[i]
+	}
+
+	forged := forgers[0].Forge(ad, ciphertext)
+
+	opened, err := a.OpenGCM(nonce, forged, ad)
+	if err != nil {
+		t.Fatalf("Forged message was rejected: %s", err)
+	}
+	if !bytes.Equal(opened, forgedText) {
+		t.Errorf("Got: %s, Expected: %s", opened, forgedText)
+	}
+}
+
+func TestRecoverErrors(t *testing.T) {
+	a := aesgo.New(key.Bit128())
+
+	sealed, err := a.SealGCM([]byte("fixed nonce!"), []byte("message"), nil)
+	if err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+	m, err := ParseSealed(sealed, nil)
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+
+	if _, err := Recover(m, m); err != ErrSameMessage {
+		t.Errorf("Expected %v, got %v", ErrSameMessage, err)
+	}
+
+	if _, err := ParseSealed(make([]byte, 15), nil); err != ErrInvalidTag {
+		t.Errorf("Expected %v, got %v", ErrInvalidTag, err)
+	}
+}
+
+func eval(p poly, x element) element {
+	var r element
+	for i := len(p) - 1; i >= 0; i-- {
+		r = r.mul(x).add(p[i])
+	}
+	return r
+}
@@ -0,0 +1,160 @@
+package gcm
+
+// poly is a polynomial with coefficients in GF(2^128), from the constant term to the highest degree.
+// The zero polynomial is empty.
+type poly []element
+
+func (p poly) trim() poly {
+	for len(p) > 0 && p[len(p)-1].isZero() {
+		p = p[:len(p)-1]
+	}
+	return p
+}
+
+// degree of the zero polynomial is -1.
+func (p poly) degree() int {
+	return len(p.trim()) - 1
+}
+
+func (p poly) add(q poly) poly {
+	r := make(poly, max(len(p), len(q)))
+	copy(r, p)
+	for i := range q {
+		r[i] = r[i].add(q[i])
+	}
+	return r.trim()
+}
+
+func (p poly) mul(q poly) poly {
+	if len(p) == 0 || len(q) == 0 {
+		return nil
+	}
+
+	r := make(poly, len(p)+len(q)-1)
+	for i := range p {
+		for j := range q {
+			r[i+j] = r[i+j].add(p[i].mul(q[j]))
+		}
+	}
+	return r.trim()
+}
+
+// divMod is the long division taught in school, the field has inverses so any divisor works.
+func (p poly) divMod(q poly) (poly, poly) {
+	q = q.trim()
+	r := append(poly{}, p.trim()...)
+	if len(r) < len(q) {
+		return nil, r
+	}
+
+	quotient := make(poly, len(r)-len(q)+1)
+
+	// the inverse is slow, and most divisors here are monic
+	inv := one
+	if q[len(q)-1] != one {
+		inv = q[len(q)-1].inverse()
+	}
+
+	for len(r) >= len(q) {
+		shift := len(r) - len(q)
+		c := r[len(r)-1].mul(inv)
+		quotient[shift] = c
+
+		for i := range q {
+			r[shift+i] = r[shift+i].add(c.mul(q[i]))
+		}
+		r = r.trim()
+	}
+
+	return quotient.trim(), r
+}
+
+func (p poly) mod(q poly) poly {
+	_, r := p.divMod(q)
+	return r
+}
+
+// monic divides by the leading coefficient, so it becomes 1.
+func (p poly) monic() poly {
+	p = p.trim()
+	if len(p) == 0 {
+		return p
+	}
+
+	inv := p[len(p)-1].inverse()
+	r := make(poly, len(p))
+	for i := range p {
+		r[i] = p[i].mul(inv)
+	}
+	return r
+}
+
+func gcd(a, b poly) poly {
+	for b.degree() >= 0 {
+		a, b = b, a.mod(b)
+	}
+	return a.monic()
+}
+
+// frobenius returns p^(2^n) mod f by squaring n times.
+func frobenius(p, f poly, n int) poly {
+	for i := 0; i < n; i++ {
+		p = p.mul(p).mod(f)
+	}
+	return p
+}
+
+// roots finds every root of f in GF(2^128).
+//
+// Every element a of GF(2^128) is a root of X^(2^128) - X, so gcd(f, X^(2^128) - X) keeps only the
+// linear factors (X - a) of f. That product is then split until only single factors are left.
+func roots(f poly) []element {
+	f = f.monic()
+	if f.degree() < 1 {
+		return nil
+	}
+
+	x := poly{{}, one}
+	g := gcd(f, frobenius(x, f, 128).add(x))
+
+	var r []element
+	for _, factor := range split(g) {
+		// monic factor X + c has the root c (minus is plus in characteristic 2)
+		r = append(r, factor[0])
+	}
+	return r
+}
+
+// split breaks a product of distinct linear factors into the factors (equal degree factorization).
+//
+// The trace Tr(y) = y + y^2 + y^4 + ... + y^(2^127) is always 0 or 1. For a root a, Tr(e*a) is 0 for
+// about half of the elements e, so gcd(g, Tr(e*X) mod g) has only some of the roots.
+// Trying the basis elements x^i is enough: two different roots always differ for one of them.
+func split(g poly) []poly {
+	if g.degree() <= 0 {
+		return nil
+	}
+	if g.degree() == 1 {
+		return []poly{g.monic()}
+	}
+
+	for i := 0; i < 128; i++ {
+		y := poly{{}, basis(i)}
+
+		trace := y
+		term := y
+		for j := 1; j < 128; j++ {
+			term = term.mul(term).mod(g)
+			trace = trace.add(term)
+		}
+
+		d := gcd(g, trace)
+		if d.degree() > 0 && d.degree() < g.degree() {
+			other, _ := g.divMod(d)
+			return append(split(d), split(other.monic())...)
+		}
+	}
+
+	// only happens if g has repeated roots, which the gcd above removes
+	return nil
+}
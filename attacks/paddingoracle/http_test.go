@@ -0,0 +1,108 @@
+package paddingoracle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestAttackURL(t *testing.T) {
+	k := key.Bit128()
+	server := httptest.NewServer(Handler(NewKeyOracle(k)))
+	defer server.Close()
+
+	plaintext := "Let's test if this attack works over HTTP!"
+
+	aes := aesgo.New(k)
+	encrypted, err := aes.Encrypt(aesgo.CBC, []byte(plaintext))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	decrypted, err := AttackURL(server.URL, encrypted)
+	if err != nil {
+		t.Fatalf("Error attacking: %s", err)
+	}
+
+	decrypted, err = aesgo.RemovePadding(decrypted)
+	if err != nil {
+		t.Fatalf("Error removing padding: %s", err)
+	}
+	if string(decrypted) != plaintext {
+		t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	k := key.Bit128()
+	handler := Handler(NewKeyOracle(k))
+
+	aes := aesgo.New(k)
+	valid, err := aes.Encrypt(aesgo.CBC, []byte("valid"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	tests := []struct {
+		name string
+
+		method   string
+		body     string
+		expected int
+	}{
+		{
+			name: "valid padding",
+
+			method:   http.MethodPost,
+			body:     string(valid),
+			expected: http.StatusOK,
+		},
+		{
+			name: "invalid ciphertext",
+
+			method:   http.MethodPost,
+			body:     "too short",
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "wrong method",
+
+			method:   http.MethodGet,
+			expected: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "body too large",
+
+			method:   http.MethodPost,
+			body:     strings.Repeat("a", maxBodySize+1),
+			expected: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(test.method, "/", strings.NewReader(test.body)))
+
+			if w.Code != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestAttackURLServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := AttackURL(server.URL, make([]byte, 32))
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected the status in the error, got %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+// Package paddingoracle implements the padding oracle attack on AES CBC.
+//
+// The attack only needs to know if a ciphertext decrypts to a valid padding, it never sees the key
+// or the plain text. See https://www.nccgroup.com/au/research-blog/cryptopals-exploiting-cbc-padding-oracles/
+//
+// To attack your own oracle implement Oracle (or wrap a function with OracleFunc) and call Attack:
+//
+//	decrypted, err := paddingoracle.Attack(paddingoracle.OracleFunc(func(b []byte) error {
+//		return myServer.CheckCookie(b)
+//	}), cookie)
+package paddingoracle
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var (
+	ErrInvalidCiphertext  = errors.New("Ciphertext must be the IV followed by at least one block")
+	ErrNoPaddingByte      = errors.New("Could not find padding byte")
+	ErrInvalidConcurrency = errors.New("Concurrency must be at least 1")
+)
+
+// An oracle can be thought as a server the decrypt the output but doesn't return the plain text to its caller.
+// For example, a web server that decrypts a cookie to check for user permissions.
+// Decrypt returns nil when the padding is valid and an error otherwise.
+type Oracle interface {
+	Decrypt(encrypted []byte) error
+}
+
+// OracleFunc lets an ordinary function be used as an Oracle.
+type OracleFunc func(encrypted []byte) error
+
+func (f OracleFunc) Decrypt(encrypted []byte) error {
+	return f(encrypted)
+}
+
+// KeyOracle is an Oracle that has the key, it is the server side of the demo.
+type KeyOracle struct {
+	key key.Key
+}
+
+func NewKeyOracle(k key.Key) *KeyOracle {
+	return &KeyOracle{key: k}
+}
+
+func (o *KeyOracle) Decrypt(encrypted []byte) error {
+	aes, err := aesgo.NewCipher(o.key)
+	if err != nil {
+		return err
+	}
+	// ignoring decrypted output because the caller shouldn't have access to it
+	_, err = aes.Decrypt(aesgo.CBC, encrypted)
+	return err
+}
+
+// Option configures Attack.
+type Option func(*attacker)
+
+// WithConcurrency sets how many oracle calls can run at the same time. The blocks are independent,
+// and so are the 256 guesses for a byte, so both are spread across goroutines.
+// The oracle must be safe for concurrent use when n > 1. The default is 1, which is sequential.
+func WithConcurrency(n int) Option {
+	return func(a *attacker) {
+		a.concurrency = n
+	}
+}
+
+type attacker struct {
+	oracle      Oracle
+	concurrency int
+	// sem limits the oracle calls in flight, it is nil when running sequentially
+	sem chan struct{}
+}
+
+// Attack decrypts encrypted (the IV + the cyphertext) using only the oracle.
+// The result still has the padding, use aesgo.RemovePadding to remove it.
+func Attack(oracle Oracle, encrypted []byte, opts ...Option) ([]byte, error) {
+	a := &attacker{oracle: oracle, concurrency: 1}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.concurrency < 1 {
+		return nil, ErrInvalidConcurrency
+	}
+	if a.concurrency > 1 {
+		a.sem = make(chan struct{}, a.concurrency)
+	}
+
+	if len(encrypted) < 32 || len(encrypted)%16 != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	// encrypted is the IV + the cyphertext. So the first block is always the IV
+	blocks := split(encrypted)
+	decrypted := make([]byte, len(encrypted)-16)
+
+	if a.sem == nil {
+		for i := len(blocks) - 1; i >= 1; i-- {
+			if err := a.decryptBlock(blocks[i-1], blocks[i], decrypted[(i-1)*16:i*16]); err != nil {
+				return nil, err
+			}
+		}
+		return decrypted, nil
+	}
+
+	// each block only needs itself and the previous one, so they can all be attacked at once
+	var wg sync.WaitGroup
+	errs := make([]error, len(blocks))
+	for i := 1; i < len(blocks); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = a.decryptBlock(blocks[i-1], blocks[i], decrypted[(i-1)*16:i*16])
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return decrypted, nil
+}
+
+// decryptBlock recovers the plain text of last into out.
+func (a *attacker) decryptBlock(prev, last, out []byte) error {
+	dec := make([]byte, 16)
+
+	// copy previous to avoid modifying the original
+	p := make([]byte, 16)
+	copy(p, prev)
+
+	for z := 15; z >= 0; z-- {
+		// b is the byte that when xoring with the decrypted byte returns a valid padding byte.
+		// For example, if the last padding byte is 0x2e it means 0x2e ^ ? = 0x01.
+		// To find the actual decrypted byte then we do 0x2e ^ 0x01 = ?. Which in this case is 0x2f
+		b, err := a.findPaddingByte(p, last, dec, z)
+		if err != nil {
+			return err
+		}
+
+		// x is the decrypted byte. It is the result of the xor between the byte found and the padding value.
+		x := b ^ byte(16-z)
+
+		// dec is used to store the decrypted bytes.
+		// It is used to change the value from the previous block to get the previous valid bytes.
+		// For example, if dec[15] = 0x2f then when trying to find the byte number 14, we need to adjust the byte 15
+		// to also provide the correct padding value.
+		//
+		// To find the padding byte for the 15th byte the algorithm tried all bytes until it found 0x2e. Which is 0x2f ^ 0x01
+		// dec[15] = 0x2f ^ 0x01 = 0x2e
+		//
+		// To find the padding byte for the 14th byte the 15th should adjust its value.
+		// dec[15] = 0x2f ^ 0x02 = 0x2d
+		// dec[14] =  ?   ^ 0x02 = <algorithm will try all values until it finds the correct byte>
+		dec[z] = x
+
+		// the final step to decrypt in CBC is to XOR against the previous cyphertext.
+		// So we do that here to store the actual plain text byte
+		out[z] = x ^ prev[z]
+	}
+
+	return nil
+}
+
+// This function finds the padding byte by trying all possible values.
+func (a *attacker) findPaddingByte(prev, last, dec []byte, z int) (byte, error) {
+	paddingValue := byte(16 - z)
+
+	if paddingValue > 0x1 {
+		for x := 15; x > z; x-- {
+			prev[x] = dec[x] ^ paddingValue
+		}
+	}
+
+	if a.sem == nil {
+		for j := 0x0; j <= 0xff; j++ {
+			if a.validGuess(prev, last, z, byte(j)) {
+				return byte(j), nil
+			}
+		}
+		return 0, ErrNoPaddingByte
+	}
+
+	// try the guesses in parallel, stopping the ones not started yet once one is found
+	var wg sync.WaitGroup
+	var found atomic.Int32
+	found.Store(-1)
+
+	for j := 0x0; j <= 0xff; j++ {
+		wg.Add(1)
+		go func(j byte) {
+			defer wg.Done()
+
+			a.sem <- struct{}{}
+			defer func() { <-a.sem }()
+
+			if found.Load() >= 0 {
+				return
+			}
+			if a.validGuess(prev, last, z, j) {
+				found.CompareAndSwap(-1, int32(j))
+			}
+		}(byte(j))
+	}
+	wg.Wait()
+
+	if j := found.Load(); j >= 0 {
+		return byte(j), nil
+	}
+	return 0, ErrNoPaddingByte
+}
+
+// validGuess asks the oracle if prev with guess at position z gives a valid padding. prev isn't modified.
+func (a *attacker) validGuess(prev, last []byte, z int, guess byte) bool {
+	p := make([]byte, 16, 32)
+	copy(p, prev)
+	p[z] = guess
+
+	if err := a.oracle.Decrypt(append(p, last...)); err != nil {
+		return false
+	}
+
+	// for the last byte the padding could be 0x02 0x02 instead of 0x01 by chance.
+	// Changing the byte before tells them apart, only 0x01 is still valid.
+	if z == 15 {
+		p[14] ^= 1
+		if err := a.oracle.Decrypt(append(p, last...)); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+func split(b []byte) [][]byte {
+	var blocks [][]byte
+	for i := 0; i < len(b); i += 16 {
+		blocks = append(blocks, b[i:i+16])
+	}
+	return blocks
+}
@@ -0,0 +1,259 @@
+package paddingoracle
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestPaddingOracle(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+
+	oracle := NewKeyOracle(k)
+	aes := aesgo.New(k)
+
+	tests := []struct {
+		name string
+
+		input string
+	}{
+		{
+			name:  "Simple decryption test",
+			input: "Let's test if this is working!",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted, err := aes.Encrypt(aesgo.CBC, []byte(test.input))
+			if err != nil {
+				t.Errorf("Error encrypting: %s", err)
+			}
+
+			decrypted, err := Attack(oracle, encrypted)
+			if err != nil {
+				t.Fatalf("Error attacking: %s", err)
+			}
+			decrypted, err = aesgo.RemovePadding(decrypted)
+			if err != nil {
+				t.Errorf("Error removing padding: %s", err)
+			}
+			if string(decrypted) != test.input {
+				fmt.Printf("Got     : %s\n", string(decrypted))
+				fmt.Printf("Expected: %s\n", test.input)
+				t.Fail()
+			}
+		})
+	}
+}
+
+func TestAttack(t *testing.T) {
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+
+	oracle := NewKeyOracle(k)
+	a := aesgo.New(k)
+
+	tests := []struct {
+		name string
+
+		input string
+	}{
+		{
+			name:  "Full padding block",
+			input: "exactly 16 bytes",
+		},
+		{
+			name:  "Empty",
+			input: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encrypted, err := a.Encrypt(aesgo.CBC, []byte(test.input))
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			original := append([]byte{}, encrypted...)
+
+			decrypted, err := Attack(oracle, encrypted)
+			if err != nil {
+				t.Fatalf("Error attacking: %s", err)
+			}
+
+			decrypted, err = aesgo.RemovePadding(decrypted)
+			if err != nil {
+				t.Fatalf("Error removing padding: %s", err)
+			}
+			if string(decrypted) != test.input {
+				t.Errorf("Got: %s, Expected: %s", decrypted, test.input)
+			}
+
+			if !bytes.Equal(encrypted, original) {
+				t.Errorf("Attack modified the ciphertext")
+			}
+		})
+	}
+}
+
+type brokenOracle struct{}
+
+func (brokenOracle) Decrypt([]byte) error {
+	return aesgo.ErrInvalidIV
+}
+
+func TestAttackErrors(t *testing.T) {
+	if _, err := Attack(brokenOracle{}, make([]byte, 16)); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	if _, err := Attack(brokenOracle{}, make([]byte, 33)); err != ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	if _, err := Attack(brokenOracle{}, make([]byte, 32)); err != ErrNoPaddingByte {
+		t.Errorf("Expected %v, got %v", ErrNoPaddingByte, err)
+	}
+}
+
+func TestPaddingOracleAttackWithStdEncryption(t *testing.T) {
+	k := key.Bit128()
+	iv := key.Bit128().GetBytes()
+
+	plaintext := []byte("Let's test if this attack works!!")
+
+	o := NewKeyOracle(k)
+
+	stdEncrypted, err := stdCBCEncrypt(plaintext, k.GetBytes(), iv)
+	if err != nil {
+		t.Errorf("Error encrypting: %s", err)
+	}
+
+	decrypted, err := Attack(o, stdEncrypted)
+	if err != nil {
+		t.Fatalf("Error attacking: %s", err)
+	}
+
+	unpadded, err := aesgo.RemovePadding(decrypted)
+	if err != nil {
+		t.Errorf("Error removing padding: %s", err)
+	}
+
+	if plaintextStr := string(plaintext); plaintextStr != string(unpadded) {
+		t.Errorf("Decrypted text does not match plaintext. Got: %s, Expected: %s", unpadded, plaintextStr)
+	}
+}
+
+// Function to stdCBCEncrypt plaintext using AES in CBC mode
+func stdCBCEncrypt(plainText, key, iv []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pad the plaintext to be a multiple of the block size
+	plainText = pad(plainText, aes.BlockSize)
+
+	// Create a new CBC encrypter
+	mode := cipher.NewCBCEncrypter(block, iv)
+
+	// Encrypt the plaintext
+	cipherText := make([]byte, len(plainText))
+	mode.CryptBlocks(cipherText, plainText)
+
+	// Prepend the IV to the ciphertext for use in decryption
+	return append(iv, cipherText...), nil
+}
+
+func pad(src []byte, blockSize int) []byte {
+	padding := blockSize - len(src)%blockSize
+	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
+	return append(src, padtext...)
+}
+
+func TestOracleFunc(t *testing.T) {
+	k := key.Bit128()
+	a := aesgo.New(k)
+
+	encrypted, err := a.Encrypt(aesgo.CBC, []byte("my own oracle"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	// any function can be an oracle, for example one that only looks at the padding
+	calls := 0
+	oracle := OracleFunc(func(b []byte) error {
+		calls++
+		_, err := a.Decrypt(aesgo.CBC, b)
+		return err
+	})
+
+	decrypted, err := Attack(oracle, encrypted)
+	if err != nil {
+		t.Fatalf("Error attacking: %s", err)
+	}
+
+	unpadded, err := aesgo.RemovePadding(decrypted)
+	if err != nil {
+		t.Fatalf("Error removing padding: %s", err)
+	}
+	if string(unpadded) != "my own oracle" {
+		t.Errorf("Got: %s, Expected: my own oracle", unpadded)
+	}
+	if calls == 0 {
+		t.Errorf("Expected the oracle to be called")
+	}
+}
+
+func TestAttackConcurrency(t *testing.T) {
+	k := key.Bit128()
+	a := aesgo.New(k)
+
+	plaintext := bytes.Repeat([]byte("parallel padding oracle "), 10)
+	encrypted, err := a.Encrypt(aesgo.CBC, plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	sequential, err := Attack(NewKeyOracle(k), encrypted)
+	if err != nil {
+		t.Fatalf("Error attacking: %s", err)
+	}
+
+	for _, n := range []int{2, 16, 300} {
+		var inFlight, peak atomic.Int32
+		oracle := OracleFunc(func(b []byte) error {
+			current := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := peak.Load()
+				if current <= m || peak.CompareAndSwap(m, current) {
+					break
+				}
+			}
+			return NewKeyOracle(k).Decrypt(b)
+		})
+
+		parallel, err := Attack(oracle, encrypted, WithConcurrency(n))
+		if err != nil {
+			t.Fatalf("Error attacking with concurrency %d: %s", n, err)
+		}
+
+		if !bytes.Equal(parallel, sequential) {
+			t.Errorf("Concurrency %d. Got: %x, Expected: %x", n, parallel, sequential)
+		}
+		if peak.Load() > int32(n) {
+			t.Errorf("Expected at most %d oracle calls at the same time, got %d", n, peak.Load())
+		}
+	}
+
+	if _, err := Attack(NewKeyOracle(k), encrypted, WithConcurrency(0)); err != ErrInvalidConcurrency {
+		t.Errorf("Expected %v, got %v", ErrInvalidConcurrency, err)
+	}
+}
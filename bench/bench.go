@@ -0,0 +1,274 @@
+// Package bench measures how fast aesgo encrypts on the local machine, next to crypto/aes doing the
+// same work, to help decide whether the educational implementation is fast enough for a use case.
+// (Usually it isn't: expect it to be around a thousand times slower than crypto/aes with AES-NI.)
+//
+// Results can be written in the Go benchmark format, so runs can be compared with benchstat:
+//
+//	aesgo bench -count 10 > old.txt
+//	aesgo bench -count 10 > new.txt
+//	benchstat old.txt new.txt
+package bench
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var (
+	ErrInvalidSize = errors.New("Size must be a positive multiple of 16")
+	ErrInvalidMode = errors.New("Invalid mode")
+
+	ErrInvalidImplementation = errors.New("Invalid implementation")
+)
+
+var modes = []aesgo.Mode{aesgo.ECB, aesgo.CBC, aesgo.CTR, aesgo.GCM}
+
+// Implementation is the code being measured.
+type Implementation string
+
+const (
+	AESGo  Implementation = "aesgo"
+	Stdlib Implementation = "stdlib"
+)
+
+type Config struct {
+	// Modes defaults to ECB, CBC, CTR and GCM.
+	Modes []aesgo.Mode
+	// Sizes are the message sizes in bytes. They must be multiples of 16, nothing is padded so both
+	// implementations encrypt the same number of blocks. Defaults to 16, 1024 and 8192.
+	Sizes []int
+	// Duration is roughly how long each case runs. Defaults to one second.
+	Duration time.Duration
+	// Implementations defaults to both.
+	Implementations []Implementation
+}
+
+type Result struct {
+	Implementation Implementation
+	Mode           aesgo.Mode
+	Size           int
+
+	// N messages were encrypted in Elapsed
+	N       int
+	Elapsed time.Duration
+}
+
+func (r Result) NsPerOp() float64 {
+	return float64(r.Elapsed.Nanoseconds()) / float64(r.N)
+}
+
+// MBPerSec uses 10^6 bytes like the testing package.
+func (r Result) MBPerSec() float64 {
+	return float64(r.Size) * float64(r.N) / 1e6 / r.Elapsed.Seconds()
+}
+
+// Name is the benchmark name without the GOMAXPROCS suffix, Encrypt/impl=aesgo/mode=CBC/size=1024.
+func (r Result) Name() string {
+	return fmt.Sprintf("Encrypt/impl=%s/mode=%s/size=%d", r.Implementation, modeName(r.Mode), r.Size)
+}
+
+// Run measures every combination of the config, one after the other.
+func Run(c Config) ([]Result, error) {
+	c = c.withDefaults()
+
+	for _, s := range c.Sizes {
+		if s <= 0 || s%16 != 0 {
+			return nil, ErrInvalidSize
+		}
+	}
+	for _, m := range c.Modes {
+		if !slices.Contains(modes, m) {
+			return nil, ErrInvalidMode
+		}
+	}
+
+	k, err := key.Random(16)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, impl := range c.Implementations {
+		for _, mode := range c.Modes {
+			for _, size := range c.Sizes {
+				op, err := newOp(impl, mode, k, make([]byte, size))
+				if err != nil {
+					return nil, err
+				}
+
+				r, err := measure(op, c.Duration)
+				if err != nil {
+					return nil, err
+				}
+				r.Implementation, r.Mode, r.Size = impl, mode, size
+				results = append(results, r)
+			}
+		}
+	}
+	return results, nil
+}
+
+// WriteBenchstat writes the results in the format of go test -bench, which benchstat reads.
+func WriteBenchstat(w io.Writer, results []Result) error {
+	header := fmt.Sprintf("goos: %s\ngoarch: %s\npkg: github.com/mario-areias/aes-go/bench\n", runtime.GOOS, runtime.GOARCH)
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	procs := runtime.GOMAXPROCS(0)
+	for _, r := range results {
+		name := "Benchmark" + r.Name()
+		if procs > 1 {
+			name = fmt.Sprintf("%s-%d", name, procs)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%8d\t%12.1f ns/op\t%8.2f MB/s\n", name, r.N, r.NsPerOp(), r.MBPerSec()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseMode accepts the mode names in any case, like ecb or GCM.
+func ParseMode(s string) (aesgo.Mode, error) {
+	for _, m := range modes {
+		if strings.EqualFold(s, modeName(m)) {
+			return m, nil
+		}
+	}
+	return 0, ErrInvalidMode
+}
+
+func modeName(m aesgo.Mode) string {
+	switch m {
+	case aesgo.ECB:
+		return "ECB"
+	case aesgo.CBC:
+		return "CBC"
+	case aesgo.CTR:
+		return "CTR"
+	case aesgo.GCM:
+		return "GCM"
+	}
+	return fmt.Sprintf("Mode(%d)", m)
+}
+
+func (c Config) withDefaults() Config {
+	if len(c.Modes) == 0 {
+		c.Modes = modes
+	}
+	if len(c.Sizes) == 0 {
+		c.Sizes = []int{16, 1024, 8192}
+	}
+	if c.Duration <= 0 {
+		c.Duration = time.Second
+	}
+	if len(c.Implementations) == 0 {
+		c.Implementations = []Implementation{AESGo, Stdlib}
+	}
+	return c
+}
+
+// measure grows the number of iterations until they take about d, like testing.B does.
+func measure(op func() error, d time.Duration) (Result, error) {
+	n := 1
+	for {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := op(); err != nil {
+				return Result{}, err
+			}
+		}
+		elapsed := time.Since(start)
+
+		if elapsed >= d || n >= 1e9 {
+			return Result{N: n, Elapsed: elapsed}, nil
+		}
+
+		// aim 20% over the duration, at most 100 times more iterations at once
+		next := n * 100
+		if elapsed > 0 {
+			next = min(next, int(float64(n)*1.2*float64(d)/float64(elapsed)))
+		}
+		n = max(next, n+1)
+	}
+}
+
+// newOp returns a function encrypting one message. Every call reads a new IV or nonce, for both
+// implementations, so they do the same work.
+func newOp(impl Implementation, mode aesgo.Mode, k key.Key, message []byte) (func() error, error) {
+	switch impl {
+	case AESGo:
+		a, err := aesgo.NewCipher(k, aesgo.WithPadding(aesgo.NoPadding), aesgo.WithInsecureModes())
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, err := a.Encrypt(mode, message)
+			return err
+		}, nil
+	case Stdlib:
+		return stdlibOp(mode, k, message)
+	}
+	return nil, ErrInvalidImplementation
+}
+
+func stdlibOp(mode aesgo.Mode, k key.Key, message []byte) (func() error, error) {
+	block, err := aes.NewCipher(k.GetBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, len(message)+16)
+	iv := make([]byte, 16)
+
+	switch mode {
+	case aesgo.ECB:
+		return func() error {
+			for i := 0; i < len(message); i += 16 {
+				block.Encrypt(dst[i:], message[i:])
+			}
+			return nil
+		}, nil
+	case aesgo.CBC:
+		return func() error {
+			if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+				return err
+			}
+			cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst, message)
+			return nil
+		}, nil
+	case aesgo.CTR:
+		return func() error {
+			if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+				return err
+			}
+			cipher.NewCTR(block, iv).XORKeyStream(dst, message)
+			return nil
+		}, nil
+	case aesgo.GCM:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		return func() error {
+			nonce := iv[:gcm.NonceSize()]
+			if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+				return err
+			}
+			gcm.Seal(dst[:0], nonce, message, nil)
+			return nil
+		}, nil
+	}
+	return nil, ErrInvalidMode
+}
@@ -0,0 +1,120 @@
+package bench
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestRun(t *testing.T) {
+	results, err := Run(Config{Sizes: []int{16, 64}, Duration: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Error running: %s", err)
+	}
+
+	// 2 implementations, 4 modes and 2 sizes
+	if len(results) != 16 {
+		t.Fatalf("Expected 16 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.N < 1 || r.Elapsed < time.Millisecond {
+			t.Errorf("%s: ran %d times in %s", r.Name(), r.N, r.Elapsed)
+		}
+		if r.MBPerSec() <= 0 {
+			t.Errorf("%s: Expected a positive throughput, got %f", r.Name(), r.MBPerSec())
+		}
+	}
+}
+
+func TestWriteBenchstat(t *testing.T) {
+	results := []Result{
+		{Implementation: AESGo, Mode: aesgo.CBC, Size: 1024, N: 1000, Elapsed: time.Second},
+		{Implementation: Stdlib, Mode: aesgo.GCM, Size: 16, N: 2000000, Elapsed: time.Second},
+	}
+
+	var out bytes.Buffer
+	if err := WriteBenchstat(&out, results); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+
+	// the format of go test -bench: name, iterations and value unit pairs
+	line := regexp.MustCompile(`^Benchmark[^\s]+(-\d+)?\t\s*\d+\t\s*[\d.]+ ns/op\t\s*[\d.]+ MB/s$`)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var benchmarks []string
+	for _, l := range lines {
+		if strings.HasPrefix(l, "Benchmark") {
+			if !line.MatchString(l) {
+				t.Errorf("Invalid benchmark line: %q", l)
+			}
+			benchmarks = append(benchmarks, l)
+		}
+	}
+	if len(benchmarks) != 2 {
+		t.Fatalf("Expected 2 benchmark lines, got %d", len(benchmarks))
+	}
+
+	expected := []string{
+		"BenchmarkEncrypt/impl=aesgo/mode=CBC/size=1024",
+		"1000000.0 ns/op",
+		"1.02 MB/s",
+	}
+	for _, e := range expected {
+		if !strings.Contains(benchmarks[0], e) {
+			t.Errorf("Expected %q in %q", e, benchmarks[0])
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := ParseMode("gcm"); err != nil || m != aesgo.GCM {
+		t.Errorf("Expected %v, got %v (%v)", aesgo.GCM, m, err)
+	}
+	if _, err := ParseMode("xts"); err != ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", ErrInvalidMode, err)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+
+		config Config
+
+		expected error
+	}{
+		{
+			name: "size not aligned",
+
+			config: Config{Sizes: []int{10}},
+
+			expected: ErrInvalidSize,
+		},
+		{
+			name: "invalid mode",
+
+			config: Config{Modes: []aesgo.Mode{7}},
+
+			expected: ErrInvalidMode,
+		},
+		{
+			name: "invalid implementation",
+
+			config: Config{Implementations: []Implementation{"openssl"}},
+
+			expected: ErrInvalidImplementation,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Run(test.config); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+// Package cmac implements AES-CMAC (NIST SP 800-38B, RFC 4493), a MAC built only from the block
+// cipher: https://www.rfc-editor.org/rfc/rfc4493
+//
+// It is CBC-MAC with a twist. CBC-MAC alone is only safe for messages of a fixed length, so the last
+// block is XORed with one of two subkeys derived from the key, K1 when the block is full and K2 when
+// it had to be padded.
+package cmac
+
+import (
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+const (
+	Size      = 16
+	BlockSize = 16
+)
+
+// rb is the constant of the doubling in GF(2^128) for 128 bit blocks.
+const rb = 0x87
+
+// CMAC implements hash.Hash. It is not safe for concurrent use.
+type CMAC struct {
+	a      *aesgo.AES
+	k1, k2 [16]byte
+
+	// chaining value and the data not processed yet. The last block is held back until Sum,
+	// it is the one that gets a subkey
+	x   [16]byte
+	buf []byte
+}
+
+func New(k key.Key) (*CMAC, error) {
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &CMAC{a: a}
+
+	l := c.encrypt([16]byte{})
+	c.k1 = double(l)
+	c.k2 = double(c.k1)
+
+	return c, nil
+}
+
+// Sum returns the tag of msg.
+func Sum(k key.Key, msg []byte) ([Size]byte, error) {
+	c, err := New(k)
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	c.Write(msg)
+	return [Size]byte(c.Sum(nil)), nil
+}
+
+// Verify compares the tag of msg with tag in constant time.
+func Verify(k key.Key, msg, tag []byte) (bool, error) {
+	expected, err := Sum(k, msg)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(expected[:], tag) == 1, nil
+}
+
+func (c *CMAC) Write(p []byte) (int, error) {
+	c.buf = append(c.buf, p...)
+
+	// strictly more than a block, so there is always something left for Sum
+	for len(c.buf) > BlockSize {
+		c.x = c.encrypt(xor(c.x, [16]byte(c.buf[:BlockSize])))
+		c.buf = c.buf[BlockSize:]
+	}
+	c.buf = append(c.buf[:0:0], c.buf...)
+
+	return len(p), nil
+}
+
+// Sum appends the tag to b. It doesn't change the state, more data can be written after it.
+func (c *CMAC) Sum(b []byte) []byte {
+	var last [16]byte
+	copy(last[:], c.buf)
+
+	if len(c.buf) == BlockSize {
+		last = xor(last, c.k1)
+	} else {
+		last[len(c.buf)] = 0x80
+		last = xor(last, c.k2)
+	}
+
+	tag := c.encrypt(xor(c.x, last))
+	return append(b, tag[:]...)
+}
+
+func (c *CMAC) Reset() {
+	c.x = [16]byte{}
+	c.buf = nil
+}
+
+func (c *CMAC) Size() int {
+	return Size
+}
+
+func (c *CMAC) BlockSize() int {
+	return BlockSize
+}
+
+func (c *CMAC) encrypt(block [16]byte) [16]byte {
+	return primitives.FromState(c.a.EncryptBlock(block))
+}
+
+// double multiplies by x in GF(2^128): shift left by one bit, and reduce when a bit falls off.
+func double(b [16]byte) [16]byte {
+	var r [16]byte
+	for i := 0; i < 15; i++ {
+		r[i] = b[i]<<1 | b[i+1]>>7
+	}
+	r[15] = b[15] << 1
+
+	if b[0]&0x80 != 0 {
+		r[15] ^= rb
+	}
+	return r
+}
+
+func xor(a, b [16]byte) [16]byte {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
@@ -0,0 +1,101 @@
+package cmac
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// RFC 4493 section 4
+func TestRFC4493(t *testing.T) {
+	k := key.NewKey([16]byte(decode("2b7e151628aed2a6abf7158809cf4f3c")))
+	msg := decode("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710")
+
+	tests := []struct {
+		name string
+
+		length   int
+		expected string
+	}{
+		{name: "empty", length: 0, expected: "bb1d6929e95937287fa37d129b756746"},
+		{name: "one block", length: 16, expected: "070a16b46b4d4144f79bdd9dd04a287c"},
+		{name: "partial block", length: 40, expected: "dfa66747de9ae63030ca32611497c827"},
+		{name: "four blocks", length: 64, expected: "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tag, err := Sum(k, msg[:test.length])
+			if err != nil {
+				t.Fatalf("Error computing tag: %s", err)
+			}
+			if got := hex.EncodeToString(tag[:]); got != test.expected {
+				t.Errorf("Got: %s, Expected: %s", got, test.expected)
+			}
+
+			// written one byte at a time
+			c, _ := New(k)
+			for _, b := range msg[:test.length] {
+				c.Write([]byte{b})
+			}
+			if got := hex.EncodeToString(c.Sum(nil)); got != test.expected {
+				t.Errorf("Byte by byte: Got: %s, Expected: %s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestSubkeys(t *testing.T) {
+	c, _ := New(key.NewKey([16]byte(decode("2b7e151628aed2a6abf7158809cf4f3c"))))
+
+	if got := hex.EncodeToString(c.k1[:]); got != "fbeed618357133667c85e08f7236a8de" {
+		t.Errorf("Got: %s, Expected: %s", got, "fbeed618357133667c85e08f7236a8de")
+	}
+	if got := hex.EncodeToString(c.k2[:]); got != "f7ddac306ae266ccf90bc11ee46d513b" {
+		t.Errorf("Got: %s, Expected: %s", got, "f7ddac306ae266ccf90bc11ee46d513b")
+	}
+}
+
+func TestSumKeepsState(t *testing.T) {
+	k := key.Bit128()
+	c, _ := New(k)
+
+	c.Write([]byte("hello "))
+	c.Sum(nil)
+	c.Write([]byte("world"))
+
+	expected, _ := Sum(k, []byte("hello world"))
+	if got := c.Sum(nil); !bytes.Equal(got, expected[:]) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	c.Reset()
+	c.Write([]byte("hello world"))
+	if got := c.Sum(nil); !bytes.Equal(got, expected[:]) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	k := key.Bit256()
+	tag, _ := Sum(k, []byte("message"))
+
+	if ok, _ := Verify(k, []byte("message"), tag[:]); !ok {
+		t.Errorf("Expected the tag to verify")
+	}
+
+	tag[0] ^= 1
+	if ok, _ := Verify(k, []byte("message"), tag[:]); ok {
+		t.Errorf("Expected a modified tag to fail")
+	}
+}
+
+func decode(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
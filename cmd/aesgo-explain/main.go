@@ -0,0 +1,198 @@
+// Command aesgo-explain steps through the encryption of one block, showing the state matrix after
+// every transformation with the bytes that changed highlighted.
+//
+//	aesgo-explain
+//	aesgo-explain -key 000102030405060708090a0b0c0d0e0f -block 00112233445566778899aabbccddeeff
+//	aesgo-explain -rounds 2
+//
+// Without flags it uses the example of FIPS-197 Appendix B, so the values can be followed in the spec.
+// Type n (or just enter) for the next step, p for the previous one, a step number to jump and q to quit.
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	highlight   = "\x1b[1;33m"
+	reset       = "\x1b[0m"
+)
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "aesgo-explain: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("aesgo-explain", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	k := fs.String("key", "2b7e151628aed2a6abf7158809cf4f3c", "128 bit key in hex")
+	b := fs.String("block", "3243f6a8885a308d313198a2e0370734", "block to encrypt in hex")
+	rounds := fs.Int("rounds", 10, "number of rounds, fewer than 10 is a reduced (unsafe) AES")
+	plain := fs.Bool("plain", false, "no colours and no clearing the screen")
+
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	material, err := decodeBlock("-key", *k)
+	if err != nil {
+		return err
+	}
+	block, err := decodeBlock("-block", *b)
+	if err != nil {
+		return err
+	}
+
+	steps, err := record(key.NewKey(material), block, *rounds)
+	if err != nil {
+		return err
+	}
+
+	return explain(steps, stdin, stdout, *plain)
+}
+
+func decodeBlock(name, s string) ([16]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return [16]byte{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if len(b) != 16 {
+		return [16]byte{}, fmt.Errorf("invalid %s: expected 16 bytes, got %d", name, len(b))
+	}
+	return [16]byte(b), nil
+}
+
+// explain is the loop: render the current step, read a command, move.
+func explain(steps []step, stdin io.Reader, stdout io.Writer, plain bool) error {
+	scanner := bufio.NewScanner(stdin)
+	current := 0
+
+	for {
+		var previous *step
+		if current > 0 {
+			previous = &steps[current-1]
+		}
+
+		if !plain {
+			fmt.Fprint(stdout, clearScreen)
+		}
+		render(stdout, steps[current], previous, current, len(steps), plain)
+		fmt.Fprint(stdout, "\n[n]ext [p]revious [number] jump [q]uit > ")
+
+		if !scanner.Scan() {
+			fmt.Fprintln(stdout)
+			return scanner.Err()
+		}
+
+		switch cmd := strings.TrimSpace(scanner.Text()); cmd {
+		case "", "n":
+			current = min(current+1, len(steps)-1)
+		case "p":
+			current = max(current-1, 0)
+		case "q":
+			return nil
+		default:
+			n, err := strconv.Atoi(cmd)
+			if err == nil && n >= 1 && n <= len(steps) {
+				current = n - 1
+			}
+		}
+	}
+}
+
+// render prints the state like the figures in FIPS-197: 4 rows, one column per 4 bytes of the block.
+func render(w io.Writer, s step, previous *step, n, total int, plain bool) {
+	fmt.Fprintf(w, "Step %d of %d: round %d, %s\n\n", n+1, total, s.Round, s.Name)
+
+	for row := 0; row < 4; row++ {
+		fmt.Fprint(w, "  ")
+		for col := 0; col < 4; col++ {
+			i := col*4 + row
+			cell := fmt.Sprintf("%02x", s.State[i])
+
+			if previous != nil && previous.State[i] != s.State[i] {
+				if plain {
+					cell += "*"
+				} else {
+					cell = highlight + cell + reset + " "
+				}
+			} else {
+				cell += " "
+			}
+			fmt.Fprint(w, cell, " ")
+		}
+		fmt.Fprintln(w)
+	}
+
+	if s.RoundKey != nil {
+		fmt.Fprintf(w, "\nRound key: %x\n", *s.RoundKey)
+	}
+	fmt.Fprintf(w, "\n%s\n", s.Description)
+}
+
+type step struct {
+	Round       int
+	Name        string
+	Description string
+	State       [16]byte
+	RoundKey    *[16]byte
+}
+
+var descriptions = map[string]string{
+	"SubBytes":    "Every byte is replaced by its S-box entry, the only non linear step.",
+	"ShiftRows":   "Row i is rotated i positions to the left, spreading the columns.",
+	"MixColumns":  "Every column is multiplied by a fixed matrix, each output byte depends on the 4 bytes of its column.",
+	"AddRoundKey": "The round key is XORed into the state.",
+}
+
+func record(k key.Key, block [16]byte, rounds int) ([]step, error) {
+	a, err := aesgo.NewWithRounds(k, rounds)
+	if err != nil {
+		return nil, err
+	}
+	trace, err := a.EncryptBlockTrace(block)
+	if err != nil {
+		return nil, err
+	}
+
+	steps := []step{{Name: "Input", Description: "The block to encrypt, filling the state column by column.", State: block}}
+
+	for _, s := range trace.Steps {
+		st := step{Round: s.Round, Name: s.Name, Description: descriptions[s.Name], State: s.State}
+
+		if s.Name == "AddRoundKey" {
+			roundKey := [16]byte(trace.RoundKeys[s.Round])
+			st.RoundKey = &roundKey
+
+			if s.Round == rounds {
+				st.Description += " This is the last round, the state is the ciphertext."
+			} else if s.Round == rounds-1 {
+				st.Description += " The next round is the last one and skips MixColumns."
+			}
+		}
+
+		steps = append(steps, st)
+	}
+
+	return steps, nil
+}
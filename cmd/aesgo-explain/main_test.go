@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	tests := []struct {
+		name string
+
+		args  []string
+		input string
+
+		expected []string
+	}{
+		{
+			name: "first step",
+
+			args:  []string{"-plain"},
+			input: "q\n",
+
+			expected: []string{"Step 1 of 41: round 0, Input", "  32  88  31  e0"},
+		},
+		{
+			name: "after SubBytes of round 1",
+
+			// input, AddRoundKey, SubBytes
+			args:  []string{"-plain"},
+			input: "n\n\n",
+
+			// FIPS-197 Appendix B, every byte changes
+			expected: []string{"Step 3 of 41: round 1, SubBytes", "  d4* e0* b8* 1e*"},
+		},
+		{
+			name: "jump and go back",
+
+			args:  []string{"-plain"},
+			input: "41\np\n",
+
+			expected: []string{"Step 41 of 41: round 10, AddRoundKey", "  39* 02* dc* 19*", "Step 40 of 41: round 10, ShiftRows"},
+		},
+		{
+			name: "ShiftRows highlights only moved bytes",
+
+			args:  []string{"-plain"},
+			input: "4\n",
+
+			expected: []string{"  d4  e0  b8  1e", "  bf* b4* 41* 27*"},
+		},
+		{
+			name: "reduced rounds",
+
+			args:  []string{"-plain", "-rounds", "2"},
+			input: "",
+
+			expected: []string{"Step 1 of 9: round 0, Input"},
+		},
+		{
+			name: "colours",
+
+			args:  []string{},
+			input: "\n",
+
+			expected: []string{clearScreen, highlight + "19" + reset},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			if err := run(test.args, strings.NewReader(test.input), &stdout, &stderr); err != nil {
+				t.Fatalf("Error: %s %s", err, stderr.String())
+			}
+
+			for _, e := range test.expected {
+				if !strings.Contains(stdout.String(), e) {
+					t.Errorf("Expected output to contain %q, got:\n%s", e, stdout.String())
+				}
+			}
+		})
+	}
+}
+
+func TestExplainErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "short key", args: []string{"-key", "0011"}},
+		{name: "invalid block", args: []string{"-block", "zz"}},
+		{name: "too many rounds", args: []string{"-rounds", "11"}},
+		{name: "unknown flag", args: []string{"-unknown"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(test.args, strings.NewReader(""), &stdout, &stderr); err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+		})
+	}
+}
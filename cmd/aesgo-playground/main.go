@@ -0,0 +1,131 @@
+// Command aesgo-playground serves a page that shows every intermediate state of AES for a key and a block.
+//
+//	aesgo-playground -addr localhost:8080
+//
+// Open http://localhost:8080 in a browser. The page calls /api/trace, which can also be used directly:
+//
+//	curl 'http://localhost:8080/api/trace?key=2b7e151628aed2a6abf7158809cf4f3c&block=3243f6a8885a308d313198a2e0370734'
+//
+// It only needs Go, the page is embedded in the binary.
+package main
+
+import (
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+//go:embed static
+var static embed.FS
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "aesgo-playground: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	fset := flag.NewFlagSet("aesgo-playground", flag.ContinueOnError)
+	fset.SetOutput(stderr)
+	addr := fset.String("addr", "localhost:8080", "address to listen on")
+
+	if err := fset.Parse(args); err != nil {
+		return errUsage
+	}
+
+	fmt.Fprintf(stderr, "playground listening on http://%s\n", *addr)
+	return http.ListenAndServe(*addr, newHandler())
+}
+
+func newHandler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// the directory is embedded, it is always there
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(files)))
+	mux.HandleFunc("/api/trace", traceHandler)
+	return mux
+}
+
+// traceHandler reads key, block and rounds from the query string (or a form) and answers with
+// the aesgo.Trace as JSON.
+func traceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	material, err := decodeBlock("key", r.FormValue("key"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	block, err := decodeBlock("block", r.FormValue("block"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rounds := 10
+	if s := r.FormValue("rounds"); s != "" {
+		rounds, err = strconv.Atoi(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid rounds: %w", err))
+			return
+		}
+	}
+
+	t, err := trace(key.NewKey(material), block, rounds)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func decodeBlock(name, s string) ([16]byte, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return [16]byte{}, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	if len(b) != 16 {
+		return [16]byte{}, fmt.Errorf("invalid %s: expected 16 bytes, got %d", name, len(b))
+	}
+	return [16]byte(b), nil
+}
+
+func trace(k key.Key, block [16]byte, rounds int) (aesgo.Trace, error) {
+	a, err := aesgo.NewWithRounds(k, rounds)
+	if err != nil {
+		return aesgo.Trace{}, err
+	}
+	return a.EncryptBlockTrace(block)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestTrace(t *testing.T) {
+	server := httptest.NewServer(newHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/trace?key=2b7e151628aed2a6abf7158809cf4f3c&block=3243f6a8885a308d313198a2e0370734")
+	if err != nil {
+		t.Fatalf("Error calling the server: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	var got aesgo.Trace
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+
+	// FIPS-197 Appendix B
+	if output, _ := got.Output.MarshalText(); string(output) != "3925841d02dc09fbdc118597196a0b32" {
+		t.Errorf("Got: %s, Expected: %s", output, "3925841d02dc09fbdc118597196a0b32")
+	}
+	if len(got.RoundKeys) != 11 {
+		t.Fatalf("Expected 11 round keys, got %d", len(got.RoundKeys))
+	}
+
+	tests := []struct {
+		round int
+
+		roundKey string
+		state    string
+	}{
+		{0, "2b7e151628aed2a6abf7158809cf4f3c", "193de3bea0f4e22b9ac68d2ae9f84808"},
+		{1, "a0fafe1788542cb123a339392a6c7605", "a49c7ff2689f352b6b5bea43026a5049"},
+		{10, "d014f9a8c9ee2589e13f0cc8b6630ca6", "3925841d02dc09fbdc118597196a0b32"},
+	}
+
+	var addRoundKeys []aesgo.Step
+	for _, s := range got.Steps {
+		if s.Name == "AddRoundKey" {
+			addRoundKeys = append(addRoundKeys, s)
+		}
+	}
+
+	for _, test := range tests {
+		roundKey, _ := got.RoundKeys[test.round].MarshalText()
+		if string(roundKey) != test.roundKey {
+			t.Errorf("Round %d. Expected key %s, got %s", test.round, test.roundKey, roundKey)
+		}
+
+		s := addRoundKeys[test.round]
+		if state, _ := s.State.MarshalText(); s.Round != test.round || string(state) != test.state {
+			t.Errorf("Round %d. Expected AddRoundKey %s, got round %d %s", test.round, test.state, s.Round, state)
+		}
+	}
+}
+
+func TestTraceErrors(t *testing.T) {
+	server := httptest.NewServer(newHandler())
+	defer server.Close()
+
+	tests := []struct {
+		name string
+
+		method string
+		query  string
+
+		expected int
+	}{
+		{
+			name: "missing key",
+
+			method: http.MethodGet,
+			query:  "block=3243f6a8885a308d313198a2e0370734",
+
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "invalid rounds",
+
+			method: http.MethodGet,
+			query:  "key=2b7e151628aed2a6abf7158809cf4f3c&block=3243f6a8885a308d313198a2e0370734&rounds=-1",
+
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "wrong method",
+
+			method: http.MethodDelete,
+			query:  "",
+
+			expected: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, server.URL+"/api/trace?"+test.query, nil)
+			if err != nil {
+				t.Fatalf("Error creating request: %s", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Error calling the server: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != test.expected {
+				t.Errorf("Expected %d, got %d", test.expected, resp.StatusCode)
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body["error"] == "" {
+				t.Errorf("Expected a JSON error, got %v %v", body, err)
+			}
+		})
+	}
+}
+
+func TestStaticPage(t *testing.T) {
+	server := httptest.NewServer(newHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Error calling the server: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("Expected the html page, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,67 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+func main() {
+	js.Global().Set("aesgo", js.ValueOf(map[string]any{
+		"encrypt": js.FuncOf(cryptFunc(encrypt)),
+		"decrypt": js.FuncOf(cryptFunc(decrypt)),
+		"trace":   js.FuncOf(traceFunc),
+	}))
+
+	// the functions can only be called while the program runs
+	select {}
+}
+
+// cryptFunc wraps encrypt and decrypt: (mode string, key Uint8Array, data Uint8Array) => Uint8Array.
+func cryptFunc(fn func(mode string, k, data []byte) ([]byte, error)) func(js.Value, []js.Value) any {
+	return func(this js.Value, args []js.Value) any {
+		if len(args) != 3 {
+			return throw("expected 3 arguments: mode, key and data")
+		}
+
+		out, err := fn(args[0].String(), goBytes(args[1]), goBytes(args[2]))
+		if err != nil {
+			return throw(err.Error())
+		}
+		return uint8Array(out)
+	}
+}
+
+// traceFunc is (key Uint8Array, block Uint8Array, rounds number) => object, rounds is optional.
+func traceFunc(this js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return throw("expected 2 arguments: key and block")
+	}
+	rounds := 10
+	if len(args) > 2 && args[2].Type() == js.TypeNumber {
+		rounds = args[2].Int()
+	}
+
+	t, err := trace(goBytes(args[0]), goBytes(args[1]), rounds)
+	if err != nil {
+		return throw(err.Error())
+	}
+	return js.Global().Get("JSON").Call("parse", string(t))
+}
+
+func goBytes(v js.Value) []byte {
+	b := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(b, v)
+	return b
+}
+
+func uint8Array(b []byte) js.Value {
+	v := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(v, b)
+	return v
+}
+
+// throw returns an Error, the wrappers in index.html throw it. A panic would stop the Go program.
+func throw(msg string) js.Value {
+	return js.Global().Get("Error").New("aesgo: " + msg)
+}
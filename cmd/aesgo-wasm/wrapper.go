@@ -0,0 +1,101 @@
+// Command aesgo-wasm runs the cipher in the browser. Built for WebAssembly it sets a global aesgo
+// object with three functions, the keys and data are Uint8Arrays:
+//
+//	aesgo.encrypt(mode, key, data) // mode is "ecb", "cbc", "ctr" or "gcm"
+//	aesgo.decrypt(mode, key, data)
+//	aesgo.trace(key, block, rounds) // every intermediate state, like aesgo-playground
+//
+// They throw an Error when something is wrong. To build it and serve the demo page:
+//
+//	GOOS=js GOARCH=wasm go build -o cmd/aesgo-wasm/static/aesgo.wasm ./cmd/aesgo-wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" cmd/aesgo-wasm/static/
+//	python3 -m http.server -d cmd/aesgo-wasm/static
+//
+// The page needs to be served, browsers don't load WebAssembly from file:// URLs.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// The functions exposed to JavaScript, on plain Go types so they can be tested without a browser.
+
+func encrypt(mode string, k, data []byte) ([]byte, error) {
+	a, m, err := newCipher(mode, k)
+	if err != nil {
+		return nil, err
+	}
+	return a.Encrypt(m, data)
+}
+
+func decrypt(mode string, k, data []byte) ([]byte, error) {
+	a, m, err := newCipher(mode, k)
+	if err != nil {
+		return nil, err
+	}
+	return a.Decrypt(m, data)
+}
+
+// trace returns the aesgo.Trace of one block as JSON, for the visualisations.
+func trace(k, block []byte, rounds int) ([]byte, error) {
+	if len(block) != 16 {
+		return nil, fmt.Errorf("block must have 16 bytes, got %d", len(block))
+	}
+	kk, err := newKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := aesgo.NewWithRounds(kk, rounds)
+	if err != nil {
+		return nil, err
+	}
+	t, err := a.EncryptBlockTrace([16]byte(block))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(t)
+}
+
+func newCipher(mode string, k []byte) (*aesgo.AES, aesgo.Mode, error) {
+	m, err := parseMode(mode)
+	if err != nil {
+		return nil, 0, err
+	}
+	kk, err := newKey(k)
+	if err != nil {
+		return nil, 0, err
+	}
+	// asking for "ecb" is the opt-in
+	a, err := aesgo.NewCipher(kk, aesgo.WithInsecureModes())
+	return a, m, err
+}
+
+func newKey(k []byte) (key.Key, error) {
+	switch len(k) {
+	case 16:
+		return key.NewKey([16]byte(k)), nil
+	case 32:
+		return key.NewKey256([32]byte(k)), nil
+	}
+	return nil, key.ErrInvalidKeySize
+}
+
+func parseMode(s string) (aesgo.Mode, error) {
+	switch strings.ToLower(s) {
+	case "ecb":
+		return aesgo.ECB, nil
+	case "cbc":
+		return aesgo.CBC, nil
+	case "ctr":
+		return aesgo.CTR, nil
+	case "gcm":
+		return aesgo.GCM, nil
+	}
+	return 0, fmt.Errorf("unknown mode %q, expected ecb, cbc, ctr or gcm", s)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := []byte("attack at dawn")
+
+	for _, mode := range []string{"ecb", "cbc", "ctr", "gcm", "GCM"} {
+		for _, size := range []int{16, 32} {
+			k := bytes.Repeat([]byte{7}, size)
+
+			ciphertext, err := encrypt(mode, k, plaintext)
+			if err != nil {
+				t.Fatalf("%s with a %d byte key. Error encrypting: %s", mode, size, err)
+			}
+			got, err := decrypt(mode, k, ciphertext)
+			if err != nil {
+				t.Fatalf("%s with a %d byte key. Error decrypting: %s", mode, size, err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("%s with a %d byte key. Got: %x, Expected: %x", mode, size, got, plaintext)
+			}
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+
+		mode string
+		key  []byte
+	}{
+		{
+			name: "unknown mode",
+
+			mode: "ofb",
+			key:  make([]byte, 16),
+		},
+		{
+			name: "invalid key",
+
+			mode: "gcm",
+			key:  make([]byte, 24),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := encrypt(test.mode, test.key, []byte("data")); err == nil {
+				t.Errorf("Expected an error encrypting")
+			}
+			if _, err := decrypt(test.mode, test.key, make([]byte, 32)); err == nil {
+				t.Errorf("Expected an error decrypting")
+			}
+		})
+	}
+
+	if _, err := newKey(make([]byte, 24)); err != key.ErrInvalidKeySize {
+		t.Errorf("Expected %v, got %v", key.ErrInvalidKeySize, err)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	// FIPS-197 Appendix B
+	k, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := hex.DecodeString("3243f6a8885a308d313198a2e0370734")
+
+	b, err := trace(k, block, 10)
+	if err != nil {
+		t.Fatalf("Error tracing: %s", err)
+	}
+
+	var got aesgo.Trace
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+	if output, _ := got.Output.MarshalText(); string(output) != "3925841d02dc09fbdc118597196a0b32" {
+		t.Errorf("Got: %s, Expected: %s", output, "3925841d02dc09fbdc118597196a0b32")
+	}
+
+	if _, err := trace(k, block[:15], 10); err == nil {
+		t.Errorf("Expected an error for a short block")
+	}
+}
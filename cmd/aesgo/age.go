@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"io"
+
+	"github.com/mario-areias/aes-go/agefile"
+)
+
+// ageFlags switch to the agefile format, encrypted in 64 KiB authenticated chunks.
+type ageFlags struct {
+	enabled bool
+}
+
+func (a *ageFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&a.enabled, "age", false, "use the age style format (authenticated chunks), -mode is ignored")
+}
+
+// ageKey is used both to encrypt and to decrypt, Passphrase and RawKey are recipients and identities.
+type ageKey interface {
+	agefile.Recipient
+	agefile.Identity
+}
+
+func (a *ageFlags) recipient(kf keyFlags) (ageKey, error) {
+	if kf.passphrase != "" {
+		return agefile.NewPassphrase(kf.passphrase), nil
+	}
+
+	k, err := kf.rawKey()
+	if err != nil {
+		return nil, err
+	}
+	return agefile.NewRawKey(k), nil
+}
+
+func encryptAge(af ageFlags, kf keyFlags, iof ioFlags, pf progressFlags, stdin io.Reader, stdout, stderr io.Writer) error {
+	r, err := af.recipient(kf)
+	if err != nil {
+		return err
+	}
+
+	in, size, err := iof.open(stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := iof.create(stdout)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	w, err := agefile.Encrypt(out, r)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(w, pf.wrap(in, size, stderr)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return out.commit()
+}
+
+func decryptAge(af ageFlags, kf keyFlags, iof ioFlags, pf progressFlags, stdin io.Reader, stdout, stderr io.Writer) error {
+	id, err := af.recipient(kf)
+	if err != nil {
+		return err
+	}
+
+	in, size, err := iof.open(stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r, err := agefile.Decrypt(pf.wrap(in, size, stderr), id)
+	if err != nil {
+		return err
+	}
+
+	out, err := iof.create(stdout)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+
+	return out.commit()
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/attacks/ctr"
+	"github.com/mario-areias/aes-go/attacks/ecb"
+	"github.com/mario-areias/aes-go/attacks/paddingoracle"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const attackUsage = `Usage: aesgo attack <attack> [flags]
+
+Attacks:
+  padding-oracle   decrypt a CBC file using only a padding oracle (local key or remote URL)
+  oracle-server    run a vulnerable HTTP server to attack with padding-oracle -url
+  ecb-detect       look for repeated blocks that give away ECB
+  ecb-cut-paste    forge a role=admin profile by rearranging ECB blocks
+  ctr-crib-drag    recover text from two CTR files encrypted with the same nonce
+
+Run "aesgo attack <attack> -h" to see the flags of an attack.
+`
+
+func attack(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, attackUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "padding-oracle":
+		return attackPaddingOracle(args[1:], stdin, stdout, stderr)
+	case "oracle-server":
+		return attackOracleServer(args[1:], stdout, stderr)
+	case "ecb-detect":
+		return attackECBDetect(args[1:], stdin, stdout, stderr)
+	case "ecb-cut-paste":
+		return attackECBCutPaste(args[1:], stdout, stderr)
+	case "ctr-crib-drag":
+		return attackCTRCribDrag(args[1:], stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "unknown attack %q\n\n%s", args[0], attackUsage)
+	return errUsage
+}
+
+func attackPaddingOracle(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("attack padding-oracle", stderr)
+
+	var iof ioFlags
+	iof.register(fs)
+	hexKey := fs.String("key", "", "128 bit key in hex, used by a local oracle to simulate the server")
+	url := fs.String("url", "", "URL of a remote oracle, the ciphertext is POSTed and 200 means valid padding")
+	concurrency := fs.Int("concurrency", 1, "number of oracle requests in flight")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if (*hexKey == "") == (*url == "") {
+		return errors.New("exactly one of -key or -url is required")
+	}
+
+	input, err := iof.read(stdin)
+	if err != nil {
+		return err
+	}
+	encrypted, err := cbcCiphertext(input)
+	if err != nil {
+		return err
+	}
+
+	opts := []paddingoracle.Option{paddingoracle.WithConcurrency(*concurrency)}
+
+	var decrypted []byte
+	if *url != "" {
+		decrypted, err = paddingoracle.AttackURL(*url, encrypted, opts...)
+	} else {
+		k, kerr := (&keyFlags{key: *hexKey}).rawKey()
+		if kerr != nil {
+			return kerr
+		}
+		defer k.Destroy()
+		decrypted, err = paddingoracle.Attack(paddingoracle.NewKeyOracle(k), encrypted, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := aesgo.RemovePadding(decrypted)
+	if err != nil {
+		fmt.Fprintf(stderr, "warning: %s, writing the output with the padding\n", err)
+		plaintext = decrypted
+	}
+
+	return iof.write(stdout, plaintext)
+}
+
+// cbcCiphertext accepts an aes-go envelope or the raw IV + ciphertext.
+func cbcCiphertext(b []byte) ([]byte, error) {
+	c, err := aesgo.Unmarshal(b)
+	if err != nil {
+		return b, nil
+	}
+	if c.Mode != aesgo.CBC {
+		return nil, errors.New("the padding oracle attack only works on cbc")
+	}
+	return append(append([]byte{}, c.IV...), c.Body...), nil
+}
+
+func attackOracleServer(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("attack oracle-server", stderr)
+
+	var kf keyFlags
+	fs.StringVar(&kf.key, "key", "", "128 bit key in hex, the server decrypts with it")
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if kf.key == "" {
+		return errors.New("-key is required")
+	}
+
+	k, err := kf.rawKey()
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	mux := http.NewServeMux()
+	mux.Handle("/decrypt", paddingoracle.Handler(paddingoracle.NewKeyOracle(k)))
+
+	fmt.Fprintf(stdout, "padding oracle listening on http://%s/decrypt\n", *addr)
+	return http.ListenAndServe(*addr, mux)
+}
+
+func attackECBDetect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("attack ecb-detect", stderr)
+
+	var iof ioFlags
+	iof.register(fs)
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	encrypted, err := iof.read(stdin)
+	if err != nil {
+		return err
+	}
+	if c, err := aesgo.Unmarshal(encrypted); err == nil {
+		encrypted = c.Body
+	}
+
+	repeated := ecb.RepeatedBlocks(encrypted)
+	verdict := "no repeated blocks, probably not ECB"
+	if repeated > 0 {
+		verdict = "repeated blocks found, probably ECB"
+	}
+
+	fmt.Fprintf(stdout, "blocks: %d\nrepeated: %d\n%s\n", len(encrypted)/16, repeated, verdict)
+	return nil
+}
+
+func attackECBCutPaste(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("attack ecb-cut-paste", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// the key is random, the attack never needs it
+	k := key.Bit128()
+	defer k.Destroy()
+
+	server, err := ecb.NewProfileServer(k)
+	if err != nil {
+		return err
+	}
+
+	forged, err := ecb.CutAndPaste(server)
+	if err != nil {
+		return err
+	}
+
+	p, err := server.Login(forged)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "forged cookie: %x\nserver sees:   %s\n", forged, p.Encode())
+	return nil
+}
+
+func attackCTRCribDrag(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("attack ctr-crib-drag", stderr)
+
+	in1 := fs.String("in1", "", "first CTR file")
+	in2 := fs.String("in2", "", "second CTR file, encrypted with the same nonce")
+	crib := fs.String("crib", "", "text guessed to be in one of the files")
+	top := fs.Int("top", 5, "number of positions to show")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *in1 == "" || *in2 == "" || *crib == "" {
+		return errors.New("-in1, -in2 and -crib are required")
+	}
+
+	c1, err := ctrCiphertext(*in1)
+	if err != nil {
+		return err
+	}
+	c2, err := ctrCiphertext(*in2)
+	if err != nil {
+		return err
+	}
+
+	xored, err := ctr.XORCiphertexts(c1, c2)
+	if err != nil {
+		return err
+	}
+
+	matches := ctr.CribDrag(xored, []byte(*crib))
+	if len(matches) > *top {
+		matches = matches[:*top]
+	}
+
+	for _, m := range matches {
+		fmt.Fprintf(stdout, "offset %4d  score %5.2f  %q\n", m.Offset, m.Score, m.Text)
+	}
+	return nil
+}
+
+// ctrCiphertext reads an aes-go envelope or the raw nonce + ciphertext from a file.
+func ctrCiphertext(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := aesgo.Unmarshal(b)
+	if err != nil {
+		return b, nil
+	}
+	if c.Mode != aesgo.CTR {
+		return nil, fmt.Errorf("%s: expected a ctr file", path)
+	}
+	return append(append([]byte{}, c.IV...), c.Body...), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/attacks/paddingoracle"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestAttackPaddingOracle(t *testing.T) {
+	k := "000102030405060708090a0b0c0d0e0f"
+	plaintext := "Let's test if this attack works!!"
+
+	var encrypted, stderr bytes.Buffer
+	if err := run([]string{"encrypt", "-key", k}, strings.NewReader(plaintext), &encrypted, &stderr); err != nil {
+		t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+	}
+
+	oracle := paddingoracle.NewKeyOracle(key.NewKey([16]byte([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})))
+	server := httptest.NewServer(paddingoracle.Handler(oracle))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+
+		args []string
+	}{
+		{
+			name: "local oracle",
+			args: []string{"attack", "padding-oracle", "-key", k},
+		},
+		{
+			name: "remote oracle",
+			args: []string{"attack", "padding-oracle", "-url", server.URL},
+		},
+		{
+			name: "remote oracle in parallel",
+			args: []string{"attack", "padding-oracle", "-concurrency", "8", "-url", server.URL},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(test.args, bytes.NewReader(encrypted.Bytes()), &stdout, &stderr); err != nil {
+				t.Fatalf("Error attacking: %s %s", err, stderr.String())
+			}
+
+			if stdout.String() != plaintext {
+				t.Errorf("Got: %s, Expected: %s", stdout.String(), plaintext)
+			}
+		})
+	}
+}
+
+func TestAttackPaddingOracleServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"attack", "padding-oracle", "-url", server.URL}, bytes.NewReader(make([]byte, 32)), &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected the status in the error, got %v", err)
+	}
+}
+
+func TestAttackECBDetect(t *testing.T) {
+	k := "000102030405060708090a0b0c0d0e0f"
+	plaintext := strings.Repeat("YELLOW SUBMARINE", 3)
+
+	tests := []struct {
+		mode     string
+		expected string
+	}{
+		{"ecb", "repeated: 2\n"},
+		{"cbc", "repeated: 0\n"},
+	}
+
+	for _, test := range tests {
+		var encrypted, stdout, stderr bytes.Buffer
+		if err := run([]string{"encrypt", "-mode", test.mode, "-key", k}, strings.NewReader(plaintext), &encrypted, &stderr); err != nil {
+			t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+		}
+
+		if err := run([]string{"attack", "ecb-detect"}, &encrypted, &stdout, &stderr); err != nil {
+			t.Fatalf("Error detecting: %s %s", err, stderr.String())
+		}
+
+		if !strings.Contains(stdout.String(), test.expected) {
+			t.Errorf("Expected %q in %q", test.expected, stdout.String())
+		}
+	}
+}
+
+func TestAttackECBCutPaste(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"attack", "ecb-cut-paste"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error attacking: %s %s", err, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), "&role=admin\n") {
+		t.Errorf("Expected an admin profile, got %q", stdout.String())
+	}
+}
+
+func TestAttackCTRCribDrag(t *testing.T) {
+	dir := t.TempDir()
+	k := key.NewKey([16]byte([]byte("128bitsforkeysss")))
+	nonce := bytes.Repeat([]byte{7}, 16)
+
+	messages := []string{
+		"Attack at dawn, bring the secret documents to the bridge.",
+		"The meeting is cancelled, nobody should leave the house!!",
+	}
+
+	var files []string
+	for i, m := range messages {
+		// same nonce for both, which is the mistake being attacked
+		a, err := aesgo.NewCipher(k, aesgo.WithRandReader(bytes.NewReader(nonce)))
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+		c, err := a.EncryptCiphertext(aesgo.CTR, []byte(m))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("%d.bin", i))
+		if err := os.WriteFile(file, c.Marshal(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"attack", "ctr-crib-drag", "-in1", files[0], "-in2", files[1], "-crib", " the secret ", "-top", "1"}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error attacking: %s %s", err, stderr.String())
+	}
+
+	if !strings.Contains(stdout.String(), `offset   21`) || !strings.Contains(stdout.String(), `"led, nobody "`) {
+		t.Errorf("Expected the crib at offset 21, got %q", stdout.String())
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mario-areias/aes-go/bench"
+)
+
+func benchmark(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("bench", stderr)
+
+	modes := fs.String("mode", "ecb,cbc,ctr,gcm", "comma separated modes")
+	sizes := fs.String("size", "16,1024,8192", "comma separated message sizes in bytes, multiples of 16")
+	impls := fs.String("impl", "aesgo,stdlib", "comma separated implementations: aesgo, stdlib (crypto/aes)")
+	benchtime := fs.Duration("benchtime", time.Second, "roughly how long each case runs")
+	count := fs.Int("count", 1, "run every case this many times, benchstat needs several to compute the variation")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	c := bench.Config{Duration: *benchtime}
+	for _, s := range strings.Split(*modes, ",") {
+		m, err := bench.ParseMode(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid -mode %q: %w", s, err)
+		}
+		c.Modes = append(c.Modes, m)
+	}
+	for _, s := range strings.Split(*sizes, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("invalid -size %q: %w", s, err)
+		}
+		c.Sizes = append(c.Sizes, n)
+	}
+	for _, s := range strings.Split(*impls, ",") {
+		c.Implementations = append(c.Implementations, bench.Implementation(strings.TrimSpace(s)))
+	}
+	if *count < 1 {
+		return fmt.Errorf("invalid -count %d", *count)
+	}
+
+	var results []bench.Result
+	for i := 0; i < *count; i++ {
+		r, err := bench.Run(c)
+		if err != nil {
+			return err
+		}
+		results = append(results, r...)
+	}
+
+	return bench.WriteBenchstat(stdout, results)
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/openssl"
+)
+
+// keyFlags are shared by every command that needs a key.
+type keyFlags struct {
+	key        string
+	passphrase string
+	keystore   string
+}
+
+func (k *keyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&k.key, "key", "", "128 bit key in hex (32 characters)")
+	fs.StringVar(&k.passphrase, "passphrase", "", "passphrase to derive the key with scrypt")
+	fs.StringVar(&k.keystore, "keystore", "", "ID of a key in the key store of the OS, see aesgo keystore")
+}
+
+func (k *keyFlags) validate() error {
+	set := 0
+	for _, v := range []string{k.key, k.passphrase, k.keystore} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of -key, -passphrase or -keystore is required")
+	}
+	return nil
+}
+
+// rawKey returns the key of -key or -keystore.
+func (k *keyFlags) rawKey() (key.Key, error) {
+	if k.keystore != "" {
+		ks, err := openKeyStore()
+		if err != nil {
+			return nil, err
+		}
+		loaded, err := ks.Load(k.keystore)
+		if err != nil {
+			return nil, fmt.Errorf("-keystore %s: %w", k.keystore, err)
+		}
+		return loaded, nil
+	}
+
+	b, err := hex.DecodeString(k.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -key: %w", err)
+	}
+	if len(b) != 16 {
+		return nil, fmt.Errorf("invalid -key: expected 16 bytes, got %d", len(b))
+	}
+	return key.NewKey([16]byte(b)), nil
+}
+
+// opensslFlags switch the output to the "openssl enc -aes-128-cbc" format.
+type opensslFlags struct {
+	enabled bool
+	pbkdf2  bool
+	md      string
+}
+
+func (o *opensslFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.enabled, "openssl", false, "use the openssl enc format (Salted__ header), requires -passphrase")
+	fs.BoolVar(&o.pbkdf2, "pbkdf2", false, "with -openssl, derive the key with PBKDF2 like openssl enc -pbkdf2")
+	fs.StringVar(&o.md, "md", "sha256", "with -openssl, digest for EVP_BytesToKey: sha256 or md5")
+}
+
+func (o *opensslFlags) options(kf keyFlags) (openssl.Options, error) {
+	if kf.passphrase == "" {
+		return openssl.Options{}, errors.New("-openssl requires -passphrase")
+	}
+
+	opts := openssl.Options{PBKDF2: o.pbkdf2}
+	switch strings.ToLower(o.md) {
+	case "sha256":
+		opts.Digest = openssl.SHA256
+	case "md5":
+		opts.Digest = openssl.MD5
+	default:
+		return opts, fmt.Errorf("unknown digest %q, expected sha256 or md5", o.md)
+	}
+
+	return opts, nil
+}
+
+// ioFlags are the input and output files, stdin and stdout when empty.
+type ioFlags struct {
+	in  string
+	out string
+}
+
+func (f *ioFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.in, "in", "", "input file (default stdin)")
+	fs.StringVar(&f.out, "out", "", "output file (default stdout)")
+}
+
+func (f *ioFlags) read(stdin io.Reader) ([]byte, error) {
+	if f.in == "" || f.in == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(f.in)
+}
+
+func (f *ioFlags) write(stdout io.Writer, b []byte) error {
+	if f.out == "" || f.out == "-" {
+		_, err := stdout.Write(b)
+		return err
+	}
+	return os.WriteFile(f.out, b, 0600)
+}
+
+// open returns the input and its size, or -1 when the size is unknown (stdin).
+func (f *ioFlags) open(stdin io.Reader) (io.ReadCloser, int64, error) {
+	if f.in == "" || f.in == "-" {
+		return io.NopCloser(stdin), -1, nil
+	}
+
+	file, err := os.Open(f.in)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+
+	return file, info.Size(), nil
+}
+
+// create returns the output. Files are written to a temporary file and only renamed by commit,
+// so a failed decryption doesn't leave half a file behind.
+func (f *ioFlags) create(stdout io.Writer) (*output, error) {
+	if f.out == "" || f.out == "-" {
+		return &output{Writer: stdout}, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.out), "."+filepath.Base(f.out)+".*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{Writer: tmp, file: tmp, path: f.out}, nil
+}
+
+type output struct {
+	io.Writer
+	file *os.File
+	path string
+}
+
+func (o *output) commit() error {
+	if o.file == nil {
+		return nil
+	}
+	if err := o.file.Close(); err != nil {
+		os.Remove(o.file.Name())
+		return err
+	}
+	return os.Rename(o.file.Name(), o.path)
+}
+
+// discard removes the temporary file, it does nothing after commit.
+func (o *output) discard() {
+	if o.file == nil {
+		return
+	}
+	o.file.Close()
+	os.Remove(o.file.Name())
+}
+
+func parseMode(s string) (aesgo.Mode, error) {
+	switch strings.ToLower(s) {
+	case "ecb":
+		return aesgo.ECB, nil
+	case "cbc":
+		return aesgo.CBC, nil
+	case "ctr":
+		return aesgo.CTR, nil
+	}
+	return 0, fmt.Errorf("unknown mode %q, expected ecb, cbc or ctr", s)
+}
+
+// modeOptions allows ECB when it was asked for, by -mode or by the file being decrypted.
+func modeOptions(mode aesgo.Mode) []aesgo.Option {
+	if mode == aesgo.ECB {
+		return []aesgo.Option{aesgo.WithInsecureModes()}
+	}
+	return nil
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	// the flag package already printed the error and the usage
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		return errUsage
+	}
+	return nil
+}
+
+func encrypt(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("encrypt", stderr)
+
+	var kf keyFlags
+	var iof ioFlags
+	var of opensslFlags
+	var pf progressFlags
+	var af ageFlags
+	kf.register(fs)
+	iof.register(fs)
+	of.register(fs)
+	pf.register(fs)
+	af.register(fs)
+	modeName := fs.String("mode", "cbc", "mode: ecb, cbc or ctr")
+	parallel := fs.Int("parallel", 1, "encrypt ctr in chunks with this many goroutines")
+	compress := fs.Bool("compress", false, "gzip before encrypting, the size leaks how well the input compressed: not for input an attacker can influence (CRIME)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := kf.validate(); err != nil {
+		return err
+	}
+	if *parallel < 1 {
+		return errors.New("-parallel must be at least 1")
+	}
+	if af.enabled && of.enabled {
+		return errors.New("-age and -openssl can't be used together")
+	}
+	if *compress && (af.enabled || of.enabled) {
+		return errors.New("-compress only works with the aes-go format")
+	}
+	if af.enabled {
+		return encryptAge(af, kf, iof, pf, stdin, stdout, stderr)
+	}
+
+	mode, err := parseMode(*modeName)
+	if err != nil {
+		return err
+	}
+
+	if of.enabled {
+		if mode != aesgo.CBC {
+			return errors.New("-openssl only supports cbc")
+		}
+		opts, err := of.options(kf)
+		if err != nil {
+			return err
+		}
+		plaintext, err := iof.read(stdin)
+		if err != nil {
+			return err
+		}
+		encrypted, err := openssl.Encrypt([]byte(kf.passphrase), plaintext, opts)
+		if err != nil {
+			return err
+		}
+		return iof.write(stdout, encrypted)
+	}
+
+	var k key.Key
+	var params []byte
+
+	if kf.passphrase != "" {
+		p, err := key.NewScryptParams()
+		if err != nil {
+			return err
+		}
+		if k, err = key.FromPassphrase([]byte(kf.passphrase), p); err != nil {
+			return err
+		}
+		if params, err = p.MarshalBinary(); err != nil {
+			return err
+		}
+	} else {
+		if k, err = kf.rawKey(); err != nil {
+			return err
+		}
+	}
+	defer k.Destroy()
+
+	a, err := aesgo.NewCipher(k, modeOptions(mode)...)
+	if err != nil {
+		return err
+	}
+
+	in, size, err := iof.open(stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := iof.create(stdout)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	// on Ctrl-C the stream stops and the temporary file is removed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := &aesgo.Ciphertext{Mode: mode, KDFParams: params}
+	if *compress {
+		c.Compression = aesgo.Gzip
+	}
+
+	if err := a.EncryptParallel(ctx, out, pf.wrap(in, size, stderr), c, *parallel); err != nil {
+		return err
+	}
+
+	return out.commit()
+}
+
+func decrypt(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("decrypt", stderr)
+
+	var kf keyFlags
+	var iof ioFlags
+	var of opensslFlags
+	var pf progressFlags
+	var af ageFlags
+	kf.register(fs)
+	iof.register(fs)
+	of.register(fs)
+	pf.register(fs)
+	af.register(fs)
+	parallel := fs.Int("parallel", 1, "decrypt ctr in chunks with this many goroutines")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := kf.validate(); err != nil {
+		return err
+	}
+	if *parallel < 1 {
+		return errors.New("-parallel must be at least 1")
+	}
+	if af.enabled && of.enabled {
+		return errors.New("-age and -openssl can't be used together")
+	}
+	if af.enabled {
+		return decryptAge(af, kf, iof, pf, stdin, stdout, stderr)
+	}
+
+	if of.enabled {
+		opts, err := of.options(kf)
+		if err != nil {
+			return err
+		}
+		encrypted, err := iof.read(stdin)
+		if err != nil {
+			return err
+		}
+		plaintext, err := openssl.Decrypt([]byte(kf.passphrase), encrypted, opts)
+		if err != nil {
+			return err
+		}
+		return iof.write(stdout, plaintext)
+	}
+
+	in, size, err := iof.open(stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	r := pf.wrap(in, size, stderr)
+
+	c, err := aesgo.ReadHeader(r)
+	if err != nil {
+		return err
+	}
+
+	k, err := envelopeKey(kf, c)
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	a, err := aesgo.NewCipher(k, modeOptions(c.Mode)...)
+	if err != nil {
+		return err
+	}
+
+	out, err := iof.create(stdout)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := a.DecryptParallel(ctx, out, r, c, *parallel); err != nil {
+		return err
+	}
+
+	return out.commit()
+}
+
+// envelopeKey derives the key with the params stored in the envelope, or uses the raw key.
+func envelopeKey(kf keyFlags, c *aesgo.Ciphertext) (key.Key, error) {
+	if kf.passphrase == "" {
+		if len(c.KDFParams) > 0 {
+			return nil, errors.New("the file was encrypted with a passphrase, use -passphrase")
+		}
+		return kf.rawKey()
+	}
+
+	if len(c.KDFParams) == 0 {
+		return nil, errors.New("the file was encrypted with a raw key, use -key or -keystore")
+	}
+
+	var p key.KDFParams
+	if err := p.UnmarshalBinary(c.KDFParams); err != nil {
+		return nil, err
+	}
+
+	return key.FromPassphrase([]byte(kf.passphrase), p)
+}
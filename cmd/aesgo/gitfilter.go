@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/kbkdf"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/keyring"
+)
+
+const gitFilterUsage = `Usage: aesgo git-filter <command> [flags]
+
+Encrypts files in a git repository on commit and decrypts them on checkout, like git-crypt.
+
+Commands:
+  add-key    add a new key to the key file and make it the one that encrypts
+  clean      encrypt stdin to stdout, git runs it when a file is staged
+  smudge     decrypt stdin to stdout, git runs it when a file is checked out
+  textconv   decrypt a file for git diff and git log -p
+
+Setup, from the top of the repository:
+  aesgo git-filter add-key
+  git config filter.aesgo.clean "aesgo git-filter clean"
+  git config filter.aesgo.smudge "aesgo git-filter smudge"
+  git config filter.aesgo.required true
+  git config diff.aesgo.textconv "aesgo git-filter textconv"
+  echo 'secrets/** filter=aesgo diff=aesgo' >> .gitattributes
+
+Keys are read from .git/aesgo-keys, one "<id> <hex key>" per line. Share the file with anyone
+who needs to read the files, it isn't committed.
+`
+
+const defaultKeyFile = ".git/aesgo-keys"
+
+func gitFilter(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, gitFilterUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "add-key":
+		return gitFilterAddKey(args[1:], stdout, stderr)
+	case "clean":
+		return gitFilterClean(args[1:], stdin, stdout, stderr)
+	case "smudge":
+		return gitFilterSmudge(args[1:], stdin, stdout, stderr)
+	case "textconv":
+		return gitFilterTextconv(args[1:], stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "unknown git-filter command %q\n\n%s", args[0], gitFilterUsage)
+	return errUsage
+}
+
+func gitFilterAddKey(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("git-filter add-key", stderr)
+	keys := fs.String("keys", defaultKeyFile, "key file")
+	id := fs.String("id", "", "key ID (default the number of keys in the file plus one)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ids, _, err := readKeyFile(*keys)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if *id == "" {
+		*id = fmt.Sprint(len(ids) + 1)
+	}
+	if strings.ContainsAny(*id, " \t\n#") {
+		return fmt.Errorf("invalid key ID %q", *id)
+	}
+	for _, existing := range ids {
+		if existing == *id {
+			return keyring.ErrDuplicateKey
+		}
+	}
+
+	k, err := key.Random(16)
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	if err := os.MkdirAll(filepath.Dir(*keys), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(*keys, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(f, "# aesgo git-filter keys, the last one encrypts")
+	}
+	fmt.Fprintf(f, "%s %s\n", *id, hex.EncodeToString(k.GetBytes()))
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "added key %s to %s\n", *id, *keys)
+	return nil
+}
+
+func gitFilterClean(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("git-filter clean", stderr)
+	keys := fs.String("keys", defaultKeyFile, "key file")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	r, err := loadKeyFile(*keys)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := cleanFile(r, plaintext)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(encrypted)
+	return err
+}
+
+func gitFilterSmudge(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("git-filter smudge", stderr)
+	keys := fs.String("keys", defaultKeyFile, "key file")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	r, err := loadKeyFile(*keys)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := io.ReadAll(stdin)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := smudgeFile(r, encrypted)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(plaintext)
+	return err
+}
+
+func gitFilterTextconv(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("git-filter textconv", stderr)
+	keys := fs.String("keys", defaultKeyFile, "key file")
+
+	// git passes the file to convert as the only argument
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "Usage: aesgo git-filter textconv [-keys file] <file>")
+		return errUsage
+	}
+
+	r, err := loadKeyFile(*keys)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := smudgeFile(r, encrypted)
+	if err != nil {
+		return err
+	}
+	_, err = stdout.Write(plaintext)
+	return err
+}
+
+// cleanFile encrypts with GCM and a nonce computed from the plaintext. git runs clean every time
+// it looks at a file, a random nonce would make unchanged files look modified. The price is that
+// equal files give equal ciphertexts, which git would show anyway.
+func cleanFile(r *keyring.Keyring, plaintext []byte) ([]byte, error) {
+	id, k, err := r.Current()
+	if err != nil {
+		return nil, err
+	}
+
+	a, nonceKey, err := filterKeys(k)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, nonceKey)
+	mac.Write(plaintext)
+	nonce := mac.Sum(nil)[:aesgo.GCMNonceSize]
+
+	sealed, err := a.SealGCM(nonce, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &aesgo.Ciphertext{
+		Version: aesgo.CiphertextVersion,
+		Mode:    aesgo.GCM,
+		KeyID:   id,
+		IV:      nonce,
+		Body:    sealed[:len(sealed)-aesgo.GCMTagSize],
+		Tag:     sealed[len(sealed)-aesgo.GCMTagSize:],
+	}
+	return c.Marshal(), nil
+}
+
+// smudgeFile decrypts with the key named in the envelope. Files that aren't encrypted, committed
+// before the filter was set up, are returned as they are.
+func smudgeFile(r *keyring.Keyring, encrypted []byte) ([]byte, error) {
+	c, err := aesgo.Unmarshal(encrypted)
+	if err != nil {
+		return encrypted, nil
+	}
+	if c.Mode != aesgo.GCM {
+		return nil, aesgo.ErrInvalidMode
+	}
+
+	k, err := r.Get(c.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", err, c.KeyID)
+	}
+
+	a, _, err := filterKeys(k)
+	if err != nil {
+		return nil, err
+	}
+	return a.DecryptCiphertext(c)
+}
+
+// filterKeys derives the encryption key and the nonce key, so the keys in the key file are never
+// used directly.
+func filterKeys(k key.Key) (*aesgo.AES, []byte, error) {
+	encKey, err := kbkdf.DeriveKey(k, []byte("aes-go git-filter"), nil, 16)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonceKey, err := kbkdf.Derive(k, []byte("aes-go git-filter nonce"), nil, 32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := aesgo.NewCipher(encKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, nonceKey, nil
+}
+
+// loadKeyFile reads a key file into a keyring, the last key is current.
+func loadKeyFile(path string) (*keyring.Keyring, error) {
+	ids, keys, err := readKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s doesn't exist, create it with aesgo git-filter add-key", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%s has no keys", path)
+	}
+
+	r := keyring.New()
+	for i, id := range ids {
+		if err := r.Rotate(id, keys[i]); err != nil {
+			return nil, fmt.Errorf("%s: %w: %q", path, err, id)
+		}
+	}
+	return r, nil
+}
+
+// readKeyFile parses "<id> <hex key>" lines. Blank lines and lines starting with # are skipped.
+func readKeyFile(path string) ([]string, []key.Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ids []string
+	var keys []key.Key
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("%s:%d: expected <id> <hex key>", path, line)
+		}
+		material, err := hex.DecodeString(fields[1])
+		if err != nil || len(material) != 16 {
+			return nil, nil, fmt.Errorf("%s:%d: expected a 128 bit key in hex", path, line)
+		}
+
+		ids = append(ids, fields[0])
+		keys = append(keys, key.NewKey([16]byte(material)))
+		clear(material)
+	}
+	return ids, keys, nil
+}
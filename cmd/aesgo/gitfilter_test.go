@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestGitFilter(t *testing.T) {
+	keys := filepath.Join(t.TempDir(), ".git", "aesgo-keys")
+	plaintext := "DATABASE_PASSWORD=hunter2\n"
+
+	filter := func(args []string, stdin string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"git-filter", args[0], "-keys", keys}, args[1:]...)
+		err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	if _, err := filter([]string{"clean"}, plaintext); err == nil || !strings.Contains(err.Error(), "add-key") {
+		t.Errorf("Expected error pointing to add-key, got %v", err)
+	}
+	if _, err := filter([]string{"add-key"}, ""); err != nil {
+		t.Fatalf("Error adding key: %s", err)
+	}
+
+	cleaned, err := filter([]string{"clean"}, plaintext)
+	if err != nil {
+		t.Fatalf("Error cleaning: %s", err)
+	}
+	if strings.Contains(cleaned, "hunter2") {
+		t.Errorf("Expected the file to be encrypted, got %q", cleaned)
+	}
+
+	// git cleans a file every time it checks it, unchanged files must stay unchanged
+	again, _ := filter([]string{"clean"}, plaintext)
+	if again != cleaned {
+		t.Errorf("Expected clean to be deterministic, got %x and %x", again, cleaned)
+	}
+
+	smudged, err := filter([]string{"smudge"}, cleaned)
+	if err != nil || smudged != plaintext {
+		t.Errorf("Got: %s, Expected: %s (%v)", smudged, plaintext, err)
+	}
+
+	// files committed before the filter was set up are checked out as they are
+	smudged, err = filter([]string{"smudge"}, plaintext)
+	if err != nil || smudged != plaintext {
+		t.Errorf("Got: %s, Expected: %s (%v)", smudged, plaintext, err)
+	}
+
+	// after a rotation new files use the new key and old ones still decrypt
+	if _, err := filter([]string{"add-key", "-id", "v2"}, ""); err != nil {
+		t.Fatalf("Error adding key: %s", err)
+	}
+	rotated, _ := filter([]string{"clean"}, plaintext)
+	c, err := aesgo.Unmarshal([]byte(rotated))
+	if err != nil || c.KeyID != "v2" {
+		t.Errorf("Expected key v2, got %v (%v)", c, err)
+	}
+
+	file := filepath.Join(t.TempDir(), "old")
+	os.WriteFile(file, []byte(cleaned), 0600)
+	converted, err := filter([]string{"textconv", file}, "")
+	if err != nil || converted != plaintext {
+		t.Errorf("Got: %s, Expected: %s (%v)", converted, plaintext, err)
+	}
+
+	tampered := []byte(cleaned)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := filter([]string{"smudge"}, string(tampered)); err != aesgo.ErrAuthentication {
+		t.Errorf("Expected %v, got %v", aesgo.ErrAuthentication, err)
+	}
+}
+
+func TestGitFilterKeyFile(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+
+		expected string
+	}{
+		{
+			name:    "no keys",
+			content: "# nothing here\n",
+
+			expected: "has no keys",
+		},
+		{
+			name:    "missing key",
+			content: "v1\n",
+
+			expected: ":1: expected <id> <hex key>",
+		},
+		{
+			name:    "short key",
+			content: "# comment\n\nv1 0001\n",
+
+			expected: ":3: expected a 128 bit key",
+		},
+		{
+			name:    "duplicate ID",
+			content: "v1 000102030405060708090a0b0c0d0e0f\nv1 000102030405060708090a0b0c0d0e0f\n",
+
+			expected: "Key ID already in keyring",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i)))
+			os.WriteFile(path, []byte(test.content), 0600)
+
+			if _, err := loadKeyFile(path); err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected %s, got %v", test.expected, err)
+			}
+		})
+	}
+}
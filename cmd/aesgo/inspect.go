@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/agefile"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var modeNames = map[aesgo.Mode]string{
+	aesgo.ECB: "ECB",
+	aesgo.CBC: "CBC",
+	aesgo.CTR: "CTR",
+	aesgo.GCM: "GCM",
+}
+
+// inspect prints the header of a file written by encrypt, with or without -age.
+func inspect(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("inspect", stderr)
+
+	var iof ioFlags
+	fs.StringVar(&iof.in, "in", "", "input file (default stdin)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	b, err := iof.read(stdin)
+	if err != nil {
+		return err
+	}
+
+	var h aesgo.Header
+	if bytes.HasPrefix(b, []byte("aes-go/age/")) {
+		h, err = agefile.Inspect(b)
+	} else {
+		h, err = aesgo.Inspect(b)
+	}
+	if err != nil {
+		return fmt.Errorf("not an aesgo file: %w", err)
+	}
+
+	return writeHeader(stdout, h)
+}
+
+func writeHeader(w io.Writer, h aesgo.Header) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "format:\t%s (version %d)\n", h.Format, h.Version)
+	fmt.Fprintf(tw, "mode:\t%s\n", modeNames[h.Mode])
+	if len(h.IV) > 0 {
+		fmt.Fprintf(tw, "iv:\t%s\n", hex.EncodeToString(h.IV))
+	}
+	if h.KeyID != "" {
+		fmt.Fprintf(tw, "key id:\t%s\n", h.KeyID)
+	}
+	if h.KDF != nil {
+		fmt.Fprintf(tw, "kdf:\t%s\n", describeKDF(h.KDF))
+	}
+	if h.Epoch != 0 {
+		fmt.Fprintf(tw, "epoch:\t%d\n", h.Epoch)
+	}
+	if h.Compression != aesgo.NoCompression {
+		fmt.Fprintf(tw, "compression:\t%s\n", h.Compression)
+	}
+	if h.TagSize > 0 {
+		fmt.Fprintf(tw, "tag:\t%d bytes\n", h.TagSize)
+	}
+	fmt.Fprintf(tw, "header:\t%d bytes\n", h.HeaderSize)
+	fmt.Fprintf(tw, "payload:\t%d bytes\n", h.PayloadSize)
+	if h.ChunkSize > 0 {
+		fmt.Fprintf(tw, "chunks:\t%d of %d bytes\n", h.Chunks, h.ChunkSize)
+	}
+
+	return tw.Flush()
+}
+
+func describeKDF(p *key.KDFParams) string {
+	salt := hex.EncodeToString(p.Salt)
+	switch p.KDF {
+	case key.KDFPBKDF2:
+		return fmt.Sprintf("PBKDF2 (iterations=%d, salt=%s)", p.Iterations, salt)
+	case key.KDFScrypt:
+		return fmt.Sprintf("scrypt (N=%d, r=%d, p=%d, salt=%s)", p.N, p.R, p.P, salt)
+	}
+	return fmt.Sprintf("unknown (%d)", p.KDF)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mario-areias/aes-go/keysplit"
+)
+
+const keyUsage = `Usage: aesgo key <command> [flags]
+
+Backs up a key with Shamir's secret sharing: split gives n shares, any threshold of them join
+back into the key and fewer reveal nothing. Keep the fingerprint split prints to check the key
+join gives back, a wrong share gives a wrong key without an error.
+
+Commands:
+  split    split the key of -key or -keystore into shares, one per line
+  join     read shares, one per line, and print the key
+
+Run "aesgo key <command> -h" to see the flags of a command.
+`
+
+func keyCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, keyUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "split":
+		return keySplit(args[1:], stdout, stderr)
+	case "join":
+		return keyJoin(args[1:], stdin, stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "unknown key command %q\n\n%s", args[0], keyUsage)
+	return errUsage
+}
+
+func keySplit(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("key split", stderr)
+	var kf keyFlags
+	var iof ioFlags
+	fs.StringVar(&kf.key, "key", "", "128 bit key in hex (32 characters)")
+	fs.StringVar(&kf.keystore, "keystore", "", "ID of a key in the key store of the OS, see aesgo keystore")
+	fs.StringVar(&iof.out, "out", "", "output file (default stdout)")
+	n := fs.Int("shares", 5, "number of shares")
+	threshold := fs.Int("threshold", 3, "number of shares needed to join the key")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := kf.validate(); err != nil {
+		fmt.Fprintln(stderr, "exactly one of -key or -keystore is required")
+		return errUsage
+	}
+
+	k, err := kf.rawKey()
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	shares, err := keysplit.SplitKey(k, *n, *threshold)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	for _, s := range shares {
+		fmt.Fprintln(&out, s)
+	}
+	if err := iof.write(stdout, out.Bytes()); err != nil {
+		return err
+	}
+
+	fingerprint := k.Fingerprint()
+	fmt.Fprintf(stderr, "split key %x into %d shares, %d of them join it back\n", fingerprint[:8], *n, *threshold)
+	return nil
+}
+
+func keyJoin(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("key join", stderr)
+	var iof ioFlags
+	iof.register(fs)
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	input, err := iof.read(stdin)
+	if err != nil {
+		return err
+	}
+
+	var shares []keysplit.Share
+	for i, line := range bytes.Split(input, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		s, err := keysplit.ParseShare(string(line))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+1, err)
+		}
+		shares = append(shares, s)
+	}
+	if len(shares) == 0 {
+		return errors.New("no shares in the input")
+	}
+
+	k, err := keysplit.CombineKey(shares)
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	if err := iof.write(stdout, []byte(hex.EncodeToString(k.GetBytes())+"\n")); err != nil {
+		return err
+	}
+
+	fingerprint := k.Fingerprint()
+	fmt.Fprintf(stderr, "joined key %x\n", fingerprint[:8])
+	return nil
+}
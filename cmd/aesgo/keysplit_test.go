@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/keysplit"
+)
+
+func TestKeySplitJoin(t *testing.T) {
+	k := "000102030405060708090a0b0c0d0e0f"
+	var shares, stderr bytes.Buffer
+
+	if err := run([]string{"key", "split", "-key", k, "-shares", "4", "-threshold", "2"}, nil, &shares, &stderr); err != nil {
+		t.Fatalf("Error splitting: %s %s", err, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(shares.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 shares, got %d", len(lines))
+	}
+	fingerprint := strings.Fields(stderr.String())[2]
+
+	// any two shares, blank lines are ignored
+	var joined bytes.Buffer
+	stderr.Reset()
+	input := lines[3] + "\n\n" + lines[1] + "\n"
+	if err := run([]string{"key", "join"}, strings.NewReader(input), &joined, &stderr); err != nil {
+		t.Fatalf("Error joining: %s %s", err, stderr.String())
+	}
+	if strings.TrimSpace(joined.String()) != k {
+		t.Errorf("Got: %s, Expected: %s", joined.String(), k)
+	}
+	if !strings.Contains(stderr.String(), fingerprint) {
+		t.Errorf("Expected fingerprint %s, got %s", fingerprint, stderr.String())
+	}
+
+	err := run([]string{"key", "join"}, strings.NewReader(lines[0]), &joined, &stderr)
+	if err == nil || !strings.Contains(err.Error(), keysplit.ErrNotEnoughShares.Error()) {
+		t.Errorf("Expected %v, got %v", keysplit.ErrNotEnoughShares, err)
+	}
+
+	typo := strings.Replace(lines[0], "-", "-1", 2)
+	err = run([]string{"key", "join"}, strings.NewReader(typo+"\n"+lines[1]), &joined, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error on line 1, got %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"key"},
+		{"key", "split"},
+		{"key", "split", "-passphrase", "correct horse"},
+		{"key", "split", "-key", k, "-threshold", "5", "-shares", "3"},
+		{"key", "join", "extra"},
+	} {
+		if err := run(args, strings.NewReader(""), &joined, &stderr); err == nil {
+			t.Errorf("Expected error for %v, got nil", args)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/keystore"
+)
+
+const keystoreUsage = `Usage: aesgo keystore <command> [flags]
+
+Keeps keys in the key store of the OS (macOS Keychain, secret service on Linux, DPAPI on
+Windows) so they don't sit in files. Use them with -keystore <id> instead of -key.
+
+Commands:
+  add      store a new random key, or the one given with -key
+  delete   remove a key
+
+Run "aesgo keystore <command> -h" to see the flags of a command.
+`
+
+// keyStoreService is the service keys are stored under.
+const keyStoreService = "aes-go"
+
+// openKeyStore is replaced in tests.
+var openKeyStore = func() (keystore.KeyStore, error) {
+	return keystore.Default(keyStoreService)
+}
+
+func keystoreCommand(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, keystoreUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "add":
+		return keystoreAdd(args[1:], stdout, stderr)
+	case "delete":
+		return keystoreDelete(args[1:], stdout, stderr)
+	}
+
+	fmt.Fprintf(stderr, "unknown keystore command %q\n\n%s", args[0], keystoreUsage)
+	return errUsage
+}
+
+func keystoreAdd(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keystore add", stderr)
+	id := fs.String("id", "", "key ID")
+	hexKey := fs.String("key", "", "128 bit key in hex to store (default a new random key)")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *id == "" {
+		fmt.Fprintln(stderr, "-id is required")
+		return errUsage
+	}
+
+	var k key.Key
+	var err error
+	if *hexKey != "" {
+		k, err = (&keyFlags{key: *hexKey}).rawKey()
+	} else {
+		k, err = key.Random(16)
+	}
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	if err := ks.Store(*id, k); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "stored key %s, use it with -keystore %s\n", *id, *id)
+	return nil
+}
+
+func keystoreDelete(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("keystore delete", stderr)
+	id := fs.String("id", "", "key ID")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *id == "" {
+		fmt.Fprintln(stderr, "-id is required")
+		return errUsage
+	}
+
+	ks, err := openKeyStore()
+	if err != nil {
+		return err
+	}
+	if err := ks.Delete(*id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "deleted key %s\n", *id)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/keystore"
+)
+
+type memStore map[string]key.Key
+
+func (m memStore) Store(id string, k key.Key) error {
+	m[id] = key.NewKey([16]byte(k.GetBytes()))
+	return nil
+}
+
+func (m memStore) Load(id string) (key.Key, error) {
+	k, ok := m[id]
+	if !ok {
+		return nil, keystore.ErrNotFound
+	}
+	return key.NewKey([16]byte(k.GetBytes())), nil
+}
+
+func (m memStore) Delete(id string) error {
+	if _, ok := m[id]; !ok {
+		return keystore.ErrNotFound
+	}
+	delete(m, id)
+	return nil
+}
+
+func TestKeystore(t *testing.T) {
+	store := memStore{}
+	open := openKeyStore
+	openKeyStore = func() (keystore.KeyStore, error) { return store, nil }
+	t.Cleanup(func() { openKeyStore = open })
+
+	plaintext := "Let's test if this is working!"
+	var stdout, stderr bytes.Buffer
+
+	if err := run([]string{"keystore", "add", "-id", "backups"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error adding: %s %s", err, stderr.String())
+	}
+
+	var encrypted, decrypted bytes.Buffer
+	if err := run([]string{"encrypt", "-mode", "ctr", "-keystore", "backups"}, strings.NewReader(plaintext), &encrypted, &stderr); err != nil {
+		t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+	}
+	if err := run([]string{"decrypt", "-keystore", "backups"}, &encrypted, &decrypted, &stderr); err != nil {
+		t.Fatalf("Error decrypting: %s %s", err, stderr.String())
+	}
+	if decrypted.String() != plaintext {
+		t.Errorf("Got: %s, Expected: %s", decrypted.String(), plaintext)
+	}
+
+	// a given key decrypts what -key encrypted
+	k := "000102030405060708090a0b0c0d0e0f"
+	encrypted.Reset()
+	decrypted.Reset()
+	run([]string{"encrypt", "-age", "-key", k}, strings.NewReader(plaintext), &encrypted, &stderr)
+	if err := run([]string{"keystore", "add", "-id", "given", "-key", k}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error adding: %s %s", err, stderr.String())
+	}
+	if err := run([]string{"decrypt", "-age", "-keystore", "given"}, &encrypted, &decrypted, &stderr); err != nil || decrypted.String() != plaintext {
+		t.Errorf("Got: %s, Expected: %s (%v)", decrypted.String(), plaintext, err)
+	}
+
+	if err := run([]string{"keystore", "delete", "-id", "backups"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error deleting: %s", err)
+	}
+	err := run([]string{"encrypt", "-keystore", "backups"}, strings.NewReader(plaintext), &encrypted, &stderr)
+	if err == nil || !strings.Contains(err.Error(), keystore.ErrNotFound.Error()) {
+		t.Errorf("Expected %v, got %v", keystore.ErrNotFound, err)
+	}
+
+	for _, args := range [][]string{
+		{"keystore"},
+		{"keystore", "add"},
+		{"keystore", "delete", "-id", "missing"},
+		{"encrypt", "-key", k, "-keystore", "given"},
+	} {
+		if err := run(args, strings.NewReader("x"), &stdout, &stderr); err == nil {
+			t.Errorf("Expected error for %v, got nil", args)
+		}
+	}
+}
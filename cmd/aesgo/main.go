@@ -0,0 +1,90 @@
+// Command aesgo encrypts and decrypts files with the aes-go implementation.
+//
+// Remember this is a learning project, don't use it to protect anything that matters.
+//
+//	aesgo encrypt -key 000102030405060708090a0b0c0d0e0f -in plain.txt -out secret.bin
+//	aesgo decrypt -passphrase "correct horse" < secret.bin
+//	aesgo encrypt -mode ctr -parallel 8 -passphrase "correct horse" -in disk.img -out disk.img.bin
+//	aesgo encrypt -compress -keystore backups -in backup.sql -out backup.sql.bin
+//	aesgo encrypt -age -passphrase "correct horse" -in backup.tar -out backup.tar.age
+//	aesgo attack oracle-server -key 000102030405060708090a0b0c0d0e0f &
+//	aesgo attack padding-oracle -url http://localhost:8080/decrypt -in secret.bin
+//	aesgo bench -mode gcm -size 1024 -count 10 > gcm.txt
+//	aesgo inspect -in secret.bin
+//	aesgo platform
+//	aesgo git-filter add-key
+//	aesgo keystore add -id backups && aesgo encrypt -keystore backups -in backup.tar -out backup.tar.bin
+//	aesgo key split -keystore backups -shares 5 -threshold 3 > shares.txt
+//	aesgo rekey -keystore backups -new-keystore backups-2025 -dir /var/backups
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: aesgo <command> [flags]
+
+Commands:
+  encrypt     encrypt a file into the aes-go envelope format
+  decrypt     decrypt a file produced by encrypt
+  inspect     show the header of a file produced by encrypt without decrypting it
+  attack      run one of the educational attacks (padding-oracle, ecb-detect, ...)
+  bench       measure the throughput of aesgo and crypto/aes on this machine
+  git-filter  encrypt files in a git repository on commit, decrypt them on checkout
+  keystore    keep keys in the key store of the OS instead of files
+  key         split a key into shares for backup and join them back
+  rekey       decrypt files and encrypt them again with a new key
+  platform    show which optional features (mmap, mlock, ...) work on this machine and why not
+
+Run "aesgo <command> -h" to see the flags of a command.
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "aesgo: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "encrypt":
+		return encrypt(args[1:], stdin, stdout, stderr)
+	case "decrypt":
+		return decrypt(args[1:], stdin, stdout, stderr)
+	case "inspect":
+		return inspect(args[1:], stdin, stdout, stderr)
+	case "attack":
+		return attack(args[1:], stdin, stdout, stderr)
+	case "bench":
+		return benchmark(args[1:], stdout, stderr)
+	case "git-filter":
+		return gitFilter(args[1:], stdin, stdout, stderr)
+	case "keystore":
+		return keystoreCommand(args[1:], stdout, stderr)
+	case "key":
+		return keyCommand(args[1:], stdin, stdout, stderr)
+	case "rekey":
+		return rekey(args[1:], stdin, stdout, stderr)
+	case "platform":
+		return platformCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+	return errUsage
+}
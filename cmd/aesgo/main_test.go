@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	plaintext := "Let's test if this is working!"
+
+	tests := []struct {
+		name string
+
+		encrypt []string
+		decrypt []string
+	}{
+		{
+			name: "raw key with cbc",
+
+			encrypt: []string{"encrypt", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "raw key with ctr",
+
+			encrypt: []string{"encrypt", "-mode", "ctr", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "ctr in parallel",
+
+			encrypt: []string{"encrypt", "-mode", "ctr", "-parallel", "4", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-parallel", "4", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "compressed with cbc",
+
+			encrypt: []string{"encrypt", "-compress", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "compressed ctr in parallel",
+
+			encrypt: []string{"encrypt", "-compress", "-mode", "ctr", "-parallel", "4", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-parallel", "4", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "openssl format",
+
+			encrypt: []string{"encrypt", "-openssl", "-pbkdf2", "-passphrase", "correct horse"},
+			decrypt: []string{"decrypt", "-openssl", "-pbkdf2", "-passphrase", "correct horse"},
+		},
+		{
+			name: "passphrase",
+
+			encrypt: []string{"encrypt", "-passphrase", "correct horse"},
+			decrypt: []string{"decrypt", "-passphrase", "correct horse"},
+		},
+		{
+			name: "age format with raw key",
+
+			encrypt: []string{"encrypt", "-age", "-key", "000102030405060708090a0b0c0d0e0f"},
+			decrypt: []string{"decrypt", "-age", "-key", "000102030405060708090a0b0c0d0e0f"},
+		},
+		{
+			name: "age format with passphrase",
+
+			encrypt: []string{"encrypt", "-age", "-passphrase", "correct horse"},
+			decrypt: []string{"decrypt", "-age", "-passphrase", "correct horse"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var encrypted, decrypted, stderr bytes.Buffer
+
+			if err := run(test.encrypt, strings.NewReader(plaintext), &encrypted, &stderr); err != nil {
+				t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+			}
+
+			if err := run(test.decrypt, &encrypted, &decrypted, &stderr); err != nil {
+				t.Fatalf("Error decrypting: %s %s", err, stderr.String())
+			}
+
+			if decrypted.String() != plaintext {
+				t.Errorf("Got: %s, Expected: %s", decrypted.String(), plaintext)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "secret.bin")
+
+	var stdout, stderr bytes.Buffer
+	k := "000102030405060708090a0b0c0d0e0f"
+
+	if err := run([]string{"encrypt", "-key", k, "-out", out}, strings.NewReader("file content"), &stdout, &stderr); err != nil {
+		t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+	}
+
+	if err := run([]string{"decrypt", "-key", k, "-in", out}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error decrypting: %s %s", err, stderr.String())
+	}
+
+	if stdout.String() != "file content" {
+		t.Errorf("Got: %s, Expected: file content", stdout.String())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	inputs := [][]string{
+		{},
+		{"unknown"},
+		{"encrypt"},
+		{"encrypt", "-key", "00", "-passphrase", "both"},
+		{"encrypt", "-key", "0001"},
+		{"encrypt", "-mode", "xts", "-key", "000102030405060708090a0b0c0d0e0f"},
+		{"encrypt", "-openssl", "-key", "000102030405060708090a0b0c0d0e0f"},
+		{"encrypt", "-openssl", "-mode", "ctr", "-passphrase", "x"},
+		{"encrypt", "-age", "-openssl", "-passphrase", "x"},
+		{"encrypt", "-compress", "-openssl", "-passphrase", "x"},
+		{"encrypt", "-parallel", "0", "-key", "000102030405060708090a0b0c0d0e0f"},
+		{"attack"},
+		{"attack", "unknown"},
+		{"attack", "padding-oracle"},
+		{"attack", "oracle-server"},
+		{"attack", "padding-oracle", "-key", "000102030405060708090a0b0c0d0e0f"},
+	}
+
+	for _, args := range inputs {
+		var stdout, stderr bytes.Buffer
+		if err := run(args, strings.NewReader("x"), &stdout, &stderr); err == nil {
+			t.Errorf("Expected error for %v, got nil", args)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "plain.txt")
+	out := filepath.Join(dir, "secret.bin")
+
+	// bigger than the chunks of io.Copy, so there is more than one read
+	plaintext := bytes.Repeat([]byte("0123456789abcdef"), 10000)
+	if err := os.WriteFile(in, plaintext, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	k := "000102030405060708090a0b0c0d0e0f"
+
+	if err := run([]string{"encrypt", "-key", k, "-progress", "-in", in, "-out", out}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+	}
+
+	if !strings.Contains(stderr.String(), "156.2 KiB / 156.2 KiB  100%") {
+		t.Errorf("Expected the final progress line, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if err := run([]string{"decrypt", "-key", k, "-progress", "-in", out}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error decrypting: %s %s", err, stderr.String())
+	}
+
+	if !bytes.Equal(stdout.Bytes(), plaintext) {
+		t.Errorf("Decrypted file differs, got %d bytes, expected %d", stdout.Len(), len(plaintext))
+	}
+	if !strings.Contains(stderr.String(), "100%") {
+		t.Errorf("Expected the final progress line, got %q", stderr.String())
+	}
+}
+
+func TestFailedDecryptLeavesNoFile(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "plain.txt")
+
+	var encrypted, stdout, stderr bytes.Buffer
+	if err := run([]string{"encrypt", "-key", "000102030405060708090a0b0c0d0e0f"}, strings.NewReader(strings.Repeat("secret", 20000)), &encrypted, &stderr); err != nil {
+		t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+	}
+
+	// a truncated file only fails at the end, after the first blocks were written
+	truncated := bytes.NewReader(encrypted.Bytes()[:encrypted.Len()-1])
+	if err := run([]string{"decrypt", "-key", "000102030405060708090a0b0c0d0e0f", "-out", out}, truncated, &stdout, &stderr); err == nil {
+		t.Fatal("Expected error decrypting a truncated file, got nil")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected an empty directory, got %v", entries)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		input    int64
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 30, "5.0 GiB"},
+	}
+
+	for _, test := range tests {
+		if got := formatBytes(test.input); got != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, got)
+		}
+	}
+}
+
+func TestBench(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	args := []string{"bench", "-mode", "cbc,gcm", "-size", "32", "-impl", "aesgo", "-benchtime", "1ms", "-count", "2"}
+	if err := run(args, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error running bench: %s %s", err, stderr.String())
+	}
+
+	if n := strings.Count(stdout.String(), "\nBenchmarkEncrypt/impl=aesgo/mode="); n != 4 {
+		t.Errorf("Expected 4 benchmark lines, got %d:\n%s", n, stdout.String())
+	}
+
+	if err := run([]string{"bench", "-size", "10"}, nil, &stdout, &stderr); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name string
+
+		encrypt []string
+
+		expected []string
+	}{
+		{
+			name: "passphrase with ctr",
+
+			encrypt: []string{"encrypt", "-mode", "ctr", "-passphrase", "correct horse"},
+
+			expected: []string{"format:   aes-go (version 1)", "mode:     CTR", "kdf:      scrypt (N=32768, r=8, p=1", "payload:  30 bytes"},
+		},
+		{
+			name: "compressed",
+
+			encrypt: []string{"encrypt", "-compress", "-key", "000102030405060708090a0b0c0d0e0f"},
+
+			expected: []string{"mode:", "CBC", "compression:  gzip"},
+		},
+		{
+			name: "age with a raw key",
+
+			encrypt: []string{"encrypt", "-age", "-key", "000102030405060708090a0b0c0d0e0f"},
+
+			expected: []string{"format:   aes-go/age/v1", "mode:     GCM", "key id:", "tag:      16 bytes", "chunks:   1 of 65536 bytes"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var encrypted, stderr bytes.Buffer
+			if err := run(test.encrypt, strings.NewReader("Let's test if this is working!"), &encrypted, &stderr); err != nil {
+				t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+			}
+
+			var stdout bytes.Buffer
+			if err := run([]string{"inspect"}, &encrypted, &stdout, &stderr); err != nil {
+				t.Fatalf("Error inspecting: %s %s", err, stderr.String())
+			}
+
+			for _, line := range test.expected {
+				if !strings.Contains(stdout.String(), line) {
+					t.Errorf("Expected %q in:\n%s", line, stdout.String())
+				}
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"inspect"}, strings.NewReader("plaintext"), &stdout, &stderr); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"platform"}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Expected nil, got %v %s", err, stderr.String())
+	}
+
+	for _, line := range []string{"assembly: unavailable (", "mmap: ", "mlock: "} {
+		if !strings.Contains(stdout.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, stdout.String())
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mario-areias/aes-go/platform"
+)
+
+// platformCommand prints which optional features work on this machine, and what the library fell
+// back from so far.
+func platformCommand(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("platform", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	for _, s := range platform.Detect() {
+		fmt.Fprintln(stdout, s)
+	}
+
+	fallbacks := platform.Fallbacks()
+	if len(fallbacks) > 0 {
+		fmt.Fprintln(stdout, "\nfallbacks:")
+	}
+	for _, s := range fallbacks {
+		fmt.Fprintln(stdout, s)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressFlags enable the progress line printed to stderr.
+type progressFlags struct {
+	enabled bool
+}
+
+func (p *progressFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&p.enabled, "progress", false, "print progress and throughput to stderr")
+}
+
+// wrap returns r itself when progress is disabled.
+func (p *progressFlags) wrap(r io.Reader, size int64, stderr io.Writer) io.Reader {
+	if !p.enabled {
+		return r
+	}
+	return newProgressReader(r, size, stderr)
+}
+
+// progressInterval is how often the line is redrawn, printing on every read would slow down small reads.
+const progressInterval = 200 * time.Millisecond
+
+// progressReader counts the bytes read and prints how far it is, like:
+//
+//	512.0 MiB / 2.0 GiB  25%  310.4 MiB/s
+type progressReader struct {
+	r     io.Reader
+	w     io.Writer
+	total int64
+	read  int64
+
+	start time.Time
+	last  time.Time
+	done  bool
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+func newProgressReader(r io.Reader, total int64, w io.Writer) *progressReader {
+	start := time.Now()
+	return &progressReader{r: r, w: w, total: total, start: start, last: start, now: time.Now}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+
+	now := p.now()
+	switch {
+	case err == io.EOF && !p.done:
+		p.done = true
+		p.print(now)
+		fmt.Fprintln(p.w)
+	case now.Sub(p.last) >= progressInterval:
+		p.last = now
+		p.print(now)
+	}
+
+	return n, err
+}
+
+func (p *progressReader) print(now time.Time) {
+	line := formatBytes(p.read)
+	if p.total >= 0 {
+		line += " / " + formatBytes(p.total)
+		if p.total > 0 {
+			line += fmt.Sprintf("  %3d%%", p.read*100/p.total)
+		}
+	}
+
+	if elapsed := now.Sub(p.start).Seconds(); elapsed > 0 {
+		line += "  " + formatBytes(int64(float64(p.read)/elapsed)) + "/s"
+	}
+
+	// \r and the trailing spaces overwrite the previous line
+	fmt.Fprintf(p.w, "\r%s   ", line)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// errAlreadyRekeyed is for files that already have the new key ID, so -dir can be run again
+// after a failure.
+var errAlreadyRekeyed = errors.New("already rekeyed")
+
+// rekeyFlags are the key files are moved to.
+type rekeyFlags struct {
+	key      string
+	keystore string
+	keyID    string
+}
+
+func (r *rekeyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&r.key, "new-key", "", "new 128 bit key in hex (32 characters)")
+	fs.StringVar(&r.keystore, "new-keystore", "", "ID of the new key in the key store of the OS")
+	fs.StringVar(&r.keyID, "key-id", "", "key ID to record in the header (default the -new-keystore ID)")
+}
+
+func (r *rekeyFlags) newKey() (key.Key, string, error) {
+	if (r.key == "") == (r.keystore == "") {
+		return nil, "", errors.New("exactly one of -new-key or -new-keystore is required")
+	}
+
+	k, err := (&keyFlags{key: r.key, keystore: r.keystore}).rawKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("new key: %w", err)
+	}
+
+	id := r.keyID
+	if id == "" {
+		id = r.keystore
+	}
+	return k, id, nil
+}
+
+func rekey(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := newFlagSet("rekey", stderr)
+
+	var kf keyFlags
+	var rf rekeyFlags
+	var iof ioFlags
+	kf.register(fs)
+	rf.register(fs)
+	iof.register(fs)
+	dir := fs.String("dir", "", "rekey every file encrypted by aesgo encrypt under this directory, in place. "+
+		"CTR files can't tell a wrong -key, keep a backup until they decrypt with the new key")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := kf.validate(); err != nil {
+		return err
+	}
+	if *dir != "" && (iof.in != "" || iof.out != "") {
+		return errors.New("-dir can't be used with -in or -out")
+	}
+
+	newKey, id, err := rf.newKey()
+	if err != nil {
+		return err
+	}
+	defer newKey.Destroy()
+
+	// on Ctrl-C the file being written is removed, the others are already done
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *dir == "" {
+		return rekeyFile(ctx, kf, newKey, id, iof, stdin, stdout)
+	}
+
+	var rekeyed, skipped, done, failed int
+	err = filepath.WalkDir(*dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+
+		err = rekeyFile(ctx, kf, newKey, id, ioFlags{in: path, out: path}, nil, nil)
+		switch {
+		case errors.Is(err, aesgo.ErrInvalidCiphertext):
+			skipped++
+		case err == errAlreadyRekeyed:
+			done++
+		case err != nil:
+			failed++
+			fmt.Fprintf(stderr, "%s: %s\n", path, err)
+		default:
+			rekeyed++
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "rekeyed %d files, skipped %d that aren't from aesgo encrypt and %d already rekeyed\n", rekeyed, skipped, done)
+	if failed > 0 {
+		return fmt.Errorf("%d files failed", failed)
+	}
+	return nil
+}
+
+// rekeyFile moves one file to newKey. The old key comes from kf and the header, like decrypt.
+func rekeyFile(ctx context.Context, kf keyFlags, newKey key.Key, id string, iof ioFlags, stdin io.Reader, stdout io.Writer) error {
+	in, _, err := iof.open(stdin)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	c, err := aesgo.ReadHeader(in)
+	if err != nil {
+		return err
+	}
+	if id != "" && c.KeyID == id {
+		return errAlreadyRekeyed
+	}
+
+	oldKey, err := envelopeKey(kf, c)
+	if err != nil {
+		return err
+	}
+	defer oldKey.Destroy()
+
+	out, err := iof.create(stdout)
+	if err != nil {
+		return err
+	}
+	defer out.discard()
+
+	if err := aesgo.RekeyStream(ctx, out, in, c, oldKey, newKey, id, modeOptions(c.Mode)...); err != nil {
+		return err
+	}
+	return out.commit()
+}
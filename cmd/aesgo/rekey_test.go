@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestRekey(t *testing.T) {
+	oldKey := "000102030405060708090a0b0c0d0e0f"
+	newKey := "0f0e0d0c0b0a09080706050403020100"
+	plaintext := "Let's test if this is working!"
+	var stdout, stderr bytes.Buffer
+
+	// two files with the old key and one that isn't encrypted
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "nested"), 0700)
+	files := map[string][]string{
+		"a.bin":        {"encrypt", "-mode", "ctr", "-key", oldKey, "-out"},
+		"nested/b.bin": {"encrypt", "-mode", "cbc", "-key", oldKey, "-out"},
+	}
+	for name, args := range files {
+		if err := run(append(args, filepath.Join(dir, name)), strings.NewReader(plaintext), &stdout, &stderr); err != nil {
+			t.Fatalf("Error encrypting: %s %s", err, stderr.String())
+		}
+	}
+	os.WriteFile(filepath.Join(dir, "README"), []byte("not encrypted"), 0600)
+
+	stderr.Reset()
+	if err := run([]string{"rekey", "-key", oldKey, "-new-key", newKey, "-key-id", "v2", "-dir", dir}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("Error rekeying: %s %s", err, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "rekeyed 2 files, skipped 1 that aren't from aesgo encrypt and 0 already rekeyed") {
+		t.Errorf("Unexpected output %s", stderr.String())
+	}
+
+	for name := range files {
+		path := filepath.Join(dir, name)
+		encrypted, _ := os.ReadFile(path)
+		c, err := aesgo.Unmarshal(encrypted)
+		if err != nil || c.KeyID != "v2" {
+			t.Errorf("%s: Unexpected header %+v (%v)", name, c, err)
+		}
+
+		var decrypted bytes.Buffer
+		if err := run([]string{"decrypt", "-key", newKey, "-in", path}, nil, &decrypted, &stderr); err != nil || decrypted.String() != plaintext {
+			t.Errorf("%s: Got: %s, Expected: %s (%v)", name, decrypted.String(), plaintext, err)
+		}
+	}
+	if readme, _ := os.ReadFile(filepath.Join(dir, "README")); string(readme) != "not encrypted" {
+		t.Errorf("Expected README untouched, got %s", readme)
+	}
+
+	// from a passphrase, stdin to stdout
+	var encrypted, rekeyed, decrypted bytes.Buffer
+	run([]string{"encrypt", "-passphrase", "correct horse"}, strings.NewReader(plaintext), &encrypted, &stderr)
+	if err := run([]string{"rekey", "-passphrase", "correct horse", "-new-key", newKey}, &encrypted, &rekeyed, &stderr); err != nil {
+		t.Fatalf("Error rekeying: %s %s", err, stderr.String())
+	}
+	if err := run([]string{"decrypt", "-key", newKey}, &rekeyed, &decrypted, &stderr); err != nil || decrypted.String() != plaintext {
+		t.Errorf("Got: %s, Expected: %s (%v)", decrypted.String(), plaintext, err)
+	}
+
+	// running again skips what is done, and reports what fails
+	truncated := filepath.Join(dir, "truncated.bin")
+	run([]string{"encrypt", "-mode", "cbc", "-key", oldKey, "-out", truncated}, strings.NewReader(plaintext), &stdout, &stderr)
+	b, _ := os.ReadFile(truncated)
+	os.WriteFile(truncated, b[:len(b)-1], 0600)
+
+	stderr.Reset()
+	err := run([]string{"rekey", "-key", oldKey, "-new-key", newKey, "-key-id", "v2", "-dir", dir}, nil, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "1 files failed") {
+		t.Errorf("Expected 1 files failed, got %v", err)
+	}
+	if !strings.Contains(stderr.String(), "truncated.bin") || !strings.Contains(stderr.String(), "2 already rekeyed") {
+		t.Errorf("Unexpected output %s", stderr.String())
+	}
+
+	for _, args := range [][]string{
+		{"rekey", "-key", oldKey},
+		{"rekey", "-new-key", newKey},
+		{"rekey", "-key", oldKey, "-new-key", newKey, "-new-keystore", "backups"},
+		{"rekey", "-key", oldKey, "-new-key", newKey, "-dir", dir, "-in", "x"},
+	} {
+		if err := run(args, strings.NewReader(""), &stdout, &stderr); err == nil {
+			t.Errorf("Expected error for %v, got nil", args)
+		}
+	}
+}
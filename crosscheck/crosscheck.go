@@ -0,0 +1,197 @@
+// Package crosscheck compares this implementation with crypto/aes using random inputs.
+// When the outputs differ it shrinks the plaintext to the smallest input that still
+// diverges, so the bug is easier to reproduce.
+package crosscheck
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/rand"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+type Config struct {
+	// Iterations is the number of random inputs per mode. Defaults to 100.
+	Iterations int
+	// MaxLen is the maximum plaintext size in bytes. Defaults to 256.
+	MaxLen int
+	// Seed makes the inputs reproducible.
+	Seed int64
+	// Modes to check. Defaults to ECB, CBC and CTR.
+	Modes []aesgo.Mode
+
+	// encrypt is the implementation under test, it can be swapped in tests
+	encrypt func(mode aesgo.Mode, k, iv, plaintext []byte) ([]byte, error)
+}
+
+// Divergence is the first input where both implementations disagree.
+type Divergence struct {
+	Mode      aesgo.Mode
+	Key       []byte
+	IV        []byte
+	Plaintext []byte
+
+	Got      []byte
+	Expected []byte
+	Err      error
+}
+
+func (d *Divergence) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("mode %d, key %x, iv %x, plaintext %x: %s", d.Mode, d.Key, d.IV, d.Plaintext, d.Err)
+	}
+	return fmt.Sprintf("mode %d, key %x, iv %x, plaintext %x: got %x, expected %x", d.Mode, d.Key, d.IV, d.Plaintext, d.Got, d.Expected)
+}
+
+// Run returns nil when every input produced the same output on both implementations.
+func Run(cfg Config) *Divergence {
+	if cfg.Iterations == 0 {
+		cfg.Iterations = 100
+	}
+	if cfg.MaxLen == 0 {
+		cfg.MaxLen = 256
+	}
+	if cfg.Modes == nil {
+		cfg.Modes = []aesgo.Mode{aesgo.ECB, aesgo.CBC, aesgo.CTR}
+	}
+	if cfg.encrypt == nil {
+		cfg.encrypt = encrypt
+	}
+
+	r := rand.New(rand.NewSource(cfg.Seed))
+
+	for _, mode := range cfg.Modes {
+		for i := 0; i < cfg.Iterations; i++ {
+			k := randomBytes(r, 16)
+			iv := randomBytes(r, 16)
+			plaintext := randomBytes(r, r.Intn(cfg.MaxLen+1))
+
+			if d := check(cfg, mode, k, iv, plaintext); d != nil {
+				return minimize(cfg, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+func randomBytes(r *rand.Rand, n int) []byte {
+	b := make([]byte, n)
+	r.Read(b)
+	return b
+}
+
+func check(cfg Config, mode aesgo.Mode, k, iv, plaintext []byte) *Divergence {
+	d := &Divergence{Mode: mode, Key: k, IV: iv, Plaintext: plaintext}
+	if mode == aesgo.ECB {
+		d.IV = nil
+	}
+
+	expected, err := reference(mode, k, iv, plaintext)
+	if err != nil {
+		d.Err = err
+		return d
+	}
+
+	got, err := cfg.encrypt(mode, k, iv, plaintext)
+	if err != nil {
+		d.Err = err
+		return d
+	}
+
+	if !bytes.Equal(got, expected) {
+		d.Got, d.Expected = got, expected
+		return d
+	}
+
+	return nil
+}
+
+// minimize keeps cutting the plaintext while it still diverges. First from the end, then from the start.
+func minimize(cfg Config, d *Divergence) *Divergence {
+	for {
+		smaller := shrink(cfg, d)
+		if smaller == nil {
+			return d
+		}
+		d = smaller
+	}
+}
+
+func shrink(cfg Config, d *Divergence) *Divergence {
+	for _, cut := range []int{len(d.Plaintext) / 2, 16, 1} {
+		if cut == 0 || cut > len(d.Plaintext) {
+			continue
+		}
+
+		candidates := [][]byte{d.Plaintext[:len(d.Plaintext)-cut], d.Plaintext[cut:]}
+		for _, p := range candidates {
+			if s := check(cfg, d.Mode, d.Key, d.IV, p); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// encrypt runs this implementation, reading the IV from the rand reader so it matches the reference.
+func encrypt(mode aesgo.Mode, k, iv, plaintext []byte) ([]byte, error) {
+	a, err := aesgo.NewCipher(key.NewKey([16]byte(k)), aesgo.WithRandReader(bytes.NewReader(iv)), aesgo.WithInsecureModes())
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := a.Encrypt(mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	// decryption must give the plaintext back too
+	decrypted, err := a.Decrypt(mode, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting: %w", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		return nil, fmt.Errorf("decrypting: got %x back", decrypted)
+	}
+
+	return encrypted, nil
+}
+
+// reference produces the same layout as aesgo: IV (if any) followed by the ciphertext, PKCS7 for ECB and CBC.
+func reference(mode aesgo.Mode, k, iv, plaintext []byte) ([]byte, error) {
+	b, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case aesgo.ECB:
+		padded := pad(plaintext)
+		r := make([]byte, len(padded))
+		for i := 0; i < len(padded); i += 16 {
+			b.Encrypt(r[i:], padded[i:])
+		}
+		return r, nil
+	case aesgo.CBC:
+		padded := pad(plaintext)
+		r := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(b, iv).CryptBlocks(r, padded)
+		return append(append([]byte{}, iv...), r...), nil
+	case aesgo.CTR:
+		r := make([]byte, len(plaintext))
+		cipher.NewCTR(b, iv).XORKeyStream(r, plaintext)
+		return append(append([]byte{}, iv...), r...), nil
+	}
+
+	return nil, fmt.Errorf("no reference for mode %d", mode)
+}
+
+func pad(b []byte) []byte {
+	n := 16 - len(b)%16
+	return append(append([]byte{}, b...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
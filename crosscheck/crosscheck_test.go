@@ -0,0 +1,36 @@
+package crosscheck
+
+import (
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestRun(t *testing.T) {
+	if d := Run(Config{Iterations: 50, Seed: 1}); d != nil {
+		t.Errorf("Divergence found: %s", d)
+	}
+}
+
+func TestMinimize(t *testing.T) {
+	// a broken implementation that flips a bit of the output once the plaintext has 3 blocks or more
+	broken := func(mode aesgo.Mode, k, iv, plaintext []byte) ([]byte, error) {
+		r, err := encrypt(mode, k, iv, plaintext)
+		if err != nil {
+			return nil, err
+		}
+		if len(plaintext) >= 48 {
+			r[0] ^= 1
+		}
+		return r, nil
+	}
+
+	d := Run(Config{Seed: 1, MaxLen: 512, Modes: []aesgo.Mode{aesgo.CTR}, encrypt: broken})
+	if d == nil {
+		t.Fatalf("Expected a divergence")
+	}
+
+	if len(d.Plaintext) != 48 {
+		t.Errorf("Expected the plaintext to be minimized to 48 bytes, got %d", len(d.Plaintext))
+	}
+}
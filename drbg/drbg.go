@@ -0,0 +1,214 @@
+// Package drbg implements CTR_DRBG from NIST SP 800-90A Rev. 1, the deterministic random bit
+// generator built on a block cipher:
+// https://nvlpubs.nist.gov/nistpubs/SpecialPublications/NIST.SP.800-90Ar1.pdf
+//
+// It uses AES-256 from aesgo without the derivation function, so the entropy input is a full
+// seed (48 bytes) and the personalization string and additional inputs are at most 48 bytes.
+// The state is a key K and a counter V. Output is AES-CTR under K starting at V+1, and after every
+// request K and V are replaced (CTR_DRBG_Update), so an attacker who learns the state can't go back
+// and compute earlier outputs.
+//
+// Given the same entropy it produces the same bytes, which makes it handy for tests and demos.
+package drbg
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+const (
+	keySize = 32
+	// SeedSize is the key plus one block, the size of the entropy input.
+	SeedSize = keySize + 16
+
+	// MaxRequestSize is the most Generate returns in one call, 2^19 bits.
+	MaxRequestSize = 1 << 16
+	// ReseedInterval is the maximum number of requests between reseeds, 2^48.
+	ReseedInterval = 1 << 48
+)
+
+var (
+	ErrInputTooLong   = errors.New("Personalization string and additional input must be at most 48 bytes")
+	ErrRequestTooLong = errors.New("Request is longer than 65536 bytes")
+)
+
+// CTRDRBG is not safe for concurrent use.
+type CTRDRBG struct {
+	a *aesgo.AES
+	v [16]byte
+
+	reseedCounter  uint64
+	reseedInterval uint64
+	entropy        io.Reader
+}
+
+type Option func(*CTRDRBG)
+
+// WithEntropySource sets where entropy comes from when instantiating and reseeding. Defaults to crypto/rand.
+func WithEntropySource(r io.Reader) Option {
+	return func(d *CTRDRBG) {
+		d.entropy = r
+	}
+}
+
+// WithReseedInterval reseeds after n requests instead of ReseedInterval.
+func WithReseedInterval(n uint64) Option {
+	return func(d *CTRDRBG) {
+		d.reseedInterval = n
+	}
+}
+
+// New instantiates the generator with SeedSize bytes from the entropy source. personalization is
+// optional, it makes this instance different from others seeded with the same entropy.
+func New(personalization []byte, opts ...Option) (*CTRDRBG, error) {
+	if len(personalization) > SeedSize {
+		return nil, ErrInputTooLong
+	}
+
+	d := &CTRDRBG{reseedInterval: ReseedInterval, entropy: rand.Reader}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	seed, err := d.readEntropy()
+	if err != nil {
+		return nil, err
+	}
+	xor(seed[:], personalization)
+
+	// CTR_DRBG_Instantiate_algorithm: K and V start at 0
+	if err := d.setKey(make([]byte, keySize)); err != nil {
+		return nil, err
+	}
+	if err := d.update(seed); err != nil {
+		return nil, err
+	}
+	d.reseedCounter = 1
+
+	return d, nil
+}
+
+// Reseed mixes new entropy from the source and the optional additional input into the state.
+func (d *CTRDRBG) Reseed(additional []byte) error {
+	if len(additional) > SeedSize {
+		return ErrInputTooLong
+	}
+
+	seed, err := d.readEntropy()
+	if err != nil {
+		return err
+	}
+	xor(seed[:], additional)
+
+	if err := d.update(seed); err != nil {
+		return err
+	}
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate fills out with random bytes. additional is optional. When the reseed interval is
+// reached it reseeds from the entropy source first.
+func (d *CTRDRBG) Generate(out, additional []byte) error {
+	if len(out) > MaxRequestSize {
+		return ErrRequestTooLong
+	}
+	if len(additional) > SeedSize {
+		return ErrInputTooLong
+	}
+
+	if d.reseedCounter > d.reseedInterval {
+		// the additional input goes into the reseed and isn't used again (section 9.3.1)
+		if err := d.Reseed(additional); err != nil {
+			return err
+		}
+		additional = nil
+	}
+
+	var input [SeedSize]byte
+	copy(input[:], additional)
+
+	if len(additional) > 0 {
+		if err := d.update(input); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < len(out); i += 16 {
+		block := d.nextBlock()
+		copy(out[i:], block[:])
+	}
+
+	// without additional input the update is done with zeros
+	if err := d.update(input); err != nil {
+		return err
+	}
+	d.reseedCounter++
+
+	return nil
+}
+
+// Read makes the generator an io.Reader, splitting p in requests of MaxRequestSize.
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	for i := 0; i < len(p); i += MaxRequestSize {
+		if err := d.Generate(p[i:min(i+MaxRequestSize, len(p))], nil); err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+// update is CTR_DRBG_Update: SeedSize bytes of keystream XOR data become the new K and V.
+func (d *CTRDRBG) update(data [SeedSize]byte) error {
+	var temp [SeedSize]byte
+	for i := 0; i < SeedSize; i += 16 {
+		block := d.nextBlock()
+		copy(temp[i:], block[:])
+	}
+	xor(temp[:], data[:])
+
+	d.v = [16]byte(temp[keySize:])
+	return d.setKey(temp[:keySize])
+}
+
+// nextBlock increments V and encrypts it, V is incremented before it is used unlike plain CTR.
+func (d *CTRDRBG) nextBlock() [16]byte {
+	for i := len(d.v) - 1; i >= 0; i-- {
+		d.v[i]++
+		if d.v[i] != 0 {
+			break
+		}
+	}
+	return primitives.FromState(d.a.EncryptBlock(d.v))
+}
+
+func (d *CTRDRBG) setKey(k []byte) error {
+	a, err := aesgo.NewCipher(key.NewKey256([32]byte(k)))
+	if err != nil {
+		return err
+	}
+	if d.a != nil {
+		d.a.Destroy()
+	}
+	d.a = a
+	clear(k)
+	return nil
+}
+
+func (d *CTRDRBG) readEntropy() ([SeedSize]byte, error) {
+	var seed [SeedSize]byte
+	_, err := io.ReadFull(d.entropy, seed[:])
+	return seed, err
+}
+
+// xor XORs b into a, b can be shorter.
+func xor(a, b []byte) {
+	for i := range b {
+		a[i] ^= b[i]
+	}
+}
@@ -0,0 +1,285 @@
+// Package enclog is an append-only encrypted log, for audit logs and the like. Every record is
+// encrypted on its own with AES-GCM, so the log can be written one record at a time and read back
+// without holding it in memory.
+//
+// The file starts with a header, then the records:
+//
+//	header: "AGL" | version (1 byte) | log id (16 random bytes)
+//	record: kind (1 byte) | length (4 bytes, big endian) | ciphertext | tag (16 bytes)
+//
+// Records aren't numbered in the file. Record i is sealed with the nonce i (4 zero bytes and 8 bytes
+// big endian), and with the kind, the length and the tag of the record before it as additional data.
+// The tags form a chain: a record that is changed, removed, duplicated or moved makes every record
+// from there on fail to authenticate.
+//
+// Close appends a close record. A log that doesn't end with one was cut after the last record,
+// or the writer crashed. Cutting the log right after an earlier close record can't be told apart
+// from a log that was closed there, compare the Checkpoint with one kept somewhere else for that.
+//
+// The key of a log is derived from the master key and the log id with KBKDF, so logs that share
+// a master key never share nonces.
+package enclog
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/kbkdf"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	version    = 1
+	headerSize = 3 + 1 + 16
+	// kind and length
+	recordHeaderSize = 5
+
+	// MaxRecordSize keeps a corrupted length from allocating gigabytes.
+	MaxRecordSize = 1 << 24
+)
+
+const (
+	kindData  byte = 1
+	kindClose byte = 2
+)
+
+var (
+	ErrInvalidHeader  = errors.New("Not an encrypted log")
+	ErrRecordTooLarge = errors.New("Record is larger than MaxRecordSize")
+	ErrTruncated      = errors.New("Log is truncated")
+	ErrNotClosed      = errors.New("Log doesn't end with a close record, it was truncated or never closed")
+	ErrClosed         = errors.New("Log is closed")
+	ErrInvalidRecord  = errors.New("Invalid record kind or length")
+	ErrAuthentication = errors.New("Record doesn't authenticate, the log was modified, reordered or cut")
+	errCloseRecord    = errors.New("close record")
+)
+
+// Checkpoint is how far a log goes: the number of records, close records included, and the tag
+// of the last one. Keep it apart from the log to notice records cut from the end.
+type Checkpoint struct {
+	Records uint64
+	Chain   [aesgo.GCMTagSize]byte
+}
+
+// chain is the state shared by Writer and Reader.
+type chain struct {
+	a *aesgo.AES
+	Checkpoint
+}
+
+func newChain(master key.Key, id []byte) (*chain, error) {
+	k, err := kbkdf.DeriveKey(master, []byte("aes-go enclog"), id, master.Len())
+	if err != nil {
+		return nil, err
+	}
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return &chain{a: a}, nil
+}
+
+func (c *chain) nonce() []byte {
+	n := make([]byte, aesgo.GCMNonceSize)
+	binary.BigEndian.PutUint64(n[4:], c.Records)
+	return n
+}
+
+func (c *chain) additionalData(header []byte) []byte {
+	return append(append([]byte{}, header...), c.Chain[:]...)
+}
+
+func (c *chain) next(sealed []byte) {
+	c.Chain = [aesgo.GCMTagSize]byte(sealed[len(sealed)-aesgo.GCMTagSize:])
+	c.Records++
+}
+
+// Writer appends records to a log. It isn't safe for concurrent use.
+type Writer struct {
+	w io.Writer
+	c *chain
+
+	closed bool
+}
+
+// Create writes the header of a new log to w.
+func Create(w io.Writer, master key.Key) (*Writer, error) {
+	id := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+
+	c, err := newChain(master, id)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{'A', 'G', 'L', version}, id...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, c: c}, nil
+}
+
+// Resume reads and verifies the whole log from r and returns a writer that appends to w, usually
+// the same file opened with os.O_RDWR|os.O_APPEND. A log that wasn't closed is accepted, the
+// writer may have crashed, but not one that ends in the middle of a record.
+func Resume(r io.Reader, w io.Writer, master key.Key) (*Writer, error) {
+	lr, err := NewReader(r, master)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		_, err := lr.Next()
+		if err == io.EOF || err == ErrNotClosed {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Writer{w: w, c: lr.c}, nil
+}
+
+// Append encrypts record and writes it in a single Write.
+func (w *Writer) Append(record []byte) error {
+	if w.closed {
+		return ErrClosed
+	}
+	return w.write(kindData, record)
+}
+
+// Close appends the close record. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	return w.write(kindClose, nil)
+}
+
+// Checkpoint is the state after the last record written.
+func (w *Writer) Checkpoint() Checkpoint {
+	return w.c.Checkpoint
+}
+
+func (w *Writer) write(kind byte, plaintext []byte) error {
+	if len(plaintext) > MaxRecordSize {
+		return ErrRecordTooLarge
+	}
+
+	header := make([]byte, recordHeaderSize)
+	header[0] = kind
+	binary.BigEndian.PutUint32(header[1:], uint32(len(plaintext)+aesgo.GCMTagSize))
+
+	sealed, err := w.c.a.SealGCM(w.c.nonce(), plaintext, w.c.additionalData(header))
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(append(header, sealed...)); err != nil {
+		return err
+	}
+
+	w.c.next(sealed)
+	return nil
+}
+
+// Reader reads the records of a log in order, verifying the chain as it goes.
+type Reader struct {
+	r io.Reader
+	c *chain
+
+	// whether the last record was a close record
+	closed bool
+}
+
+// NewReader reads the header of the log from r.
+func NewReader(r io.Reader, master key.Key) (*Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrInvalidHeader
+	}
+	if string(header[:3]) != "AGL" || header[3] != version {
+		return nil, ErrInvalidHeader
+	}
+
+	c, err := newChain(master, header[4:])
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{r: r, c: c}, nil
+}
+
+// Next returns the next record. Close records in the middle of the log are skipped, the log was
+// resumed after them. At the end it returns io.EOF when the log was closed, ErrNotClosed
+// otherwise, and ErrTruncated when the last record is incomplete.
+func (r *Reader) Next() ([]byte, error) {
+	for {
+		record, err := r.read()
+		if err == errCloseRecord {
+			continue
+		}
+		return record, err
+	}
+}
+
+// Checkpoint is the state after the last record read.
+func (r *Reader) Checkpoint() Checkpoint {
+	return r.c.Checkpoint
+}
+
+func (r *Reader) read() ([]byte, error) {
+	header := make([]byte, recordHeaderSize)
+	_, err := io.ReadFull(r.r, header)
+	if err == io.EOF {
+		if !r.closed {
+			return nil, ErrNotClosed
+		}
+		return nil, io.EOF
+	}
+	if err == io.ErrUnexpectedEOF {
+		return nil, ErrTruncated
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	kind := header[0]
+	if kind != kindData && kind != kindClose {
+		return nil, ErrInvalidRecord
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size < aesgo.GCMTagSize {
+		return nil, ErrInvalidRecord
+	}
+	if size > MaxRecordSize+aesgo.GCMTagSize {
+		return nil, ErrRecordTooLarge
+	}
+
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncated
+		}
+		return nil, err
+	}
+
+	plaintext, err := r.c.a.OpenGCM(r.c.nonce(), sealed, r.c.additionalData(header))
+	if err != nil {
+		return nil, fmt.Errorf("%w: record %d", ErrAuthentication, r.c.Records)
+	}
+
+	r.c.next(sealed)
+	r.closed = kind == kindClose
+	if r.closed {
+		return nil, errCloseRecord
+	}
+	return plaintext, nil
+}
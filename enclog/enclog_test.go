@@ -0,0 +1,214 @@
+package enclog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+var entries = []string{"alice logged in", "", "bob deleted the backups", "alice logged out"}
+
+func writeLog(t *testing.T, k key.Key) ([]byte, Checkpoint) {
+	var buf bytes.Buffer
+	w, err := Create(&buf, k)
+	if err != nil {
+		t.Fatalf("Error creating log: %s", err)
+	}
+	for _, e := range entries {
+		if err := w.Append([]byte(e)); err != nil {
+			t.Fatalf("Error appending: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error closing: %s", err)
+	}
+	return buf.Bytes(), w.Checkpoint()
+}
+
+func readLog(log []byte, k key.Key) ([]string, Checkpoint, error) {
+	r, err := NewReader(bytes.NewReader(log), k)
+	if err != nil {
+		return nil, Checkpoint{}, err
+	}
+
+	var records []string
+	for {
+		record, err := r.Next()
+		if err == io.EOF {
+			return records, r.Checkpoint(), nil
+		}
+		if err != nil {
+			return records, r.Checkpoint(), err
+		}
+		records = append(records, string(record))
+	}
+}
+
+// records splits a log in its header and its records.
+func records(log []byte) [][]byte {
+	parts := [][]byte{log[:headerSize]}
+	for rest := log[headerSize:]; len(rest) > 0; {
+		n := recordHeaderSize + int(binary.BigEndian.Uint32(rest[1:]))
+		parts = append(parts, rest[:n])
+		rest = rest[n:]
+	}
+	return parts
+}
+
+func TestLog(t *testing.T) {
+	k := key.Bit128()
+	log, checkpoint := writeLog(t, k)
+
+	got, readCheckpoint, err := readLog(log, k)
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	if len(got) != len(entries) {
+		t.Fatalf("Expected %d records, got %d", len(entries), len(got))
+	}
+	for i := range got {
+		if got[i] != entries[i] {
+			t.Errorf("Got: %s, Expected: %s", got[i], entries[i])
+		}
+	}
+
+	if readCheckpoint != checkpoint || checkpoint.Records != uint64(len(entries)+1) {
+		t.Errorf("Expected %v, got %v", checkpoint, readCheckpoint)
+	}
+
+	// a second log with the same key uses another key and so other nonces
+	other, _ := writeLog(t, k)
+	if bytes.Equal(records(log)[1], records(other)[1]) {
+		t.Errorf("Expected different ciphertexts for different logs")
+	}
+}
+
+func TestTampering(t *testing.T) {
+	k := key.Bit128()
+	log, _ := writeLog(t, k)
+	parts := records(log)
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	flipped := append([]byte{}, parts[2]...)
+	flipped[recordHeaderSize] ^= 1
+
+	tests := []struct {
+		name string
+
+		log []byte
+
+		expected error
+	}{
+		{
+			name: "records swapped",
+
+			log: join(parts[0], parts[2], parts[1], parts[3], parts[4], parts[5]),
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "record removed",
+
+			log: join(parts[0], parts[1], parts[3], parts[4], parts[5]),
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "record duplicated",
+
+			log: join(parts[0], parts[1], parts[1], parts[2], parts[3], parts[4], parts[5]),
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "cut after a record",
+
+			log: join(parts[0], parts[1], parts[2]),
+
+			expected: ErrNotClosed,
+		},
+		{
+			name: "cut in a record",
+
+			log: log[:len(log)-3],
+
+			expected: ErrTruncated,
+		},
+		{
+			name: "bit flipped",
+
+			log: join(parts[0], parts[1], flipped, parts[3], parts[4], parts[5]),
+
+			expected: ErrAuthentication,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := readLog(test.log, k); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	if _, _, err := readLog(log, key.Bit128()); !errors.Is(err, ErrAuthentication) {
+		t.Errorf("Expected %v, got %v", ErrAuthentication, err)
+	}
+	if _, _, err := readLog([]byte("not a log at all, no"), k); err != ErrInvalidHeader {
+		t.Errorf("Expected %v, got %v", ErrInvalidHeader, err)
+	}
+}
+
+func TestResume(t *testing.T) {
+	k := key.Bit128()
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	f, _ := os.Create(path)
+	w, err := Create(f, k)
+	if err != nil {
+		t.Fatalf("Error creating log: %s", err)
+	}
+	w.Append([]byte("first run"))
+	// the process dies without Close
+	f.Close()
+
+	for _, entry := range []string{"second run", "third run"} {
+		f, _ := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0)
+		w, err := Resume(f, f, k)
+		if err != nil {
+			t.Fatalf("Error resuming: %s", err)
+		}
+		w.Append([]byte(entry))
+		w.Close()
+		f.Close()
+	}
+
+	log, _ := os.ReadFile(path)
+	got, _, err := readLog(log, k)
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	expected := []string{"first run", "second run", "third run"}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] || got[2] != expected[2] {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if _, err := Resume(bytes.NewReader(log[:len(log)-1]), io.Discard, k); err != ErrTruncated {
+		t.Errorf("Expected %v, got %v", ErrTruncated, err)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	w, _ := Create(io.Discard, key.Bit128())
+	w.Close()
+	if err := w.Append([]byte("late")); err != ErrClosed {
+		t.Errorf("Expected %v, got %v", ErrClosed, err)
+	}
+}
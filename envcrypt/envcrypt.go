@@ -0,0 +1,201 @@
+// Package envcrypt loads configuration that is encrypted on disk, so secrets only exist in plaintext
+// in the memory of the process that needs them.
+//
+// Two kinds of files are read:
+//
+//   - A .env file (or any other file) encrypted with the agefile format, which is what
+//     "aesgo encrypt -age -key ... -in .env -out .env.enc" writes. If the decrypted content is a
+//     JSON object it is read as JSON, otherwise as KEY=VALUE lines.
+//   - A JSON file with its values encrypted by the treecrypt package.
+//
+// Nested JSON keys are joined with dots, {"db": {"password": "x"}} is "db.password".
+//
+// The key is 128 or 256 bits in hex and comes from the AESGO_KEY environment variable, or from the
+// file named by AESGO_KEY_FILE, unless WithKey or WithKeyFile is used:
+//
+//	cfg, err := envcrypt.Load(".env.enc")
+//	password := cfg.Get("DATABASE_PASSWORD")
+//
+// Files that aren't encrypted are rejected with ErrNotEncrypted.
+package envcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mario-areias/aes-go/agefile"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/treecrypt"
+)
+
+const (
+	KeyEnv     = "AESGO_KEY"
+	KeyFileEnv = "AESGO_KEY_FILE"
+)
+
+var (
+	ErrNoKey        = errors.New("No key, set AESGO_KEY or AESGO_KEY_FILE")
+	ErrInvalidKey   = errors.New("Key must be 16 or 32 bytes in hex")
+	ErrNotEncrypted = errors.New("Config file isn't encrypted")
+)
+
+// Config holds the decrypted values. It is safe for concurrent reads.
+type Config struct {
+	values map[string]string
+}
+
+type Option func(*options)
+
+type options struct {
+	key     key.Key
+	keyFile string
+}
+
+// WithKey uses k instead of looking for a key.
+func WithKey(k key.Key) Option {
+	return func(o *options) {
+		o.key = k
+	}
+}
+
+// WithKeyFile reads the key in hex from path instead of the environment.
+func WithKeyFile(path string) Option {
+	return func(o *options) {
+		o.keyFile = path
+	}
+}
+
+// Load decrypts and parses the config file at path.
+func Load(path string, opts ...Option) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b, opts...)
+}
+
+// Parse decrypts and parses an encrypted config.
+func Parse(encrypted []byte, opts ...Option) (*Config, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	k, err := o.resolveKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+	switch {
+	case bytes.HasPrefix(encrypted, []byte("aes-go/age/")):
+		r, err := agefile.Decrypt(bytes.NewReader(encrypted), agefile.NewRawKey(k))
+		if err != nil {
+			return nil, err
+		}
+		if plaintext, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	case isJSON(encrypted):
+		if plaintext, err = treecrypt.Decrypt(k, encrypted); err != nil {
+			if err == treecrypt.ErrNotEncrypted {
+				return nil, ErrNotEncrypted
+			}
+			return nil, err
+		}
+	default:
+		return nil, ErrNotEncrypted
+	}
+
+	var values map[string]string
+	if isJSON(plaintext) {
+		values, err = parseJSON(plaintext)
+	} else {
+		values, err = parseDotenv(plaintext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{values: values}, nil
+}
+
+func (o *options) resolveKey() (key.Key, error) {
+	if o.key != nil {
+		return o.key, nil
+	}
+
+	if o.keyFile == "" {
+		if s := os.Getenv(KeyEnv); s != "" {
+			return parseKey(s)
+		}
+		o.keyFile = os.Getenv(KeyFileEnv)
+	}
+	if o.keyFile == "" {
+		return nil, ErrNoKey
+	}
+
+	b, err := os.ReadFile(o.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(b)
+	return parseKey(string(b))
+}
+
+func parseKey(s string) (key.Key, error) {
+	material, err := hex.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	defer clear(material)
+
+	switch len(material) {
+	case 16:
+		return key.NewKey([16]byte(material)), nil
+	case 32:
+		return key.NewKey256([32]byte(material)), nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// Get returns the value of name, or an empty string.
+func (c *Config) Get(name string) string {
+	return c.values[name]
+}
+
+// Lookup returns the value of name and whether it is set.
+func (c *Config) Lookup(name string) (string, bool) {
+	v, ok := c.values[name]
+	return v, ok
+}
+
+// Keys returns the names of every value, sorted.
+func (c *Config) Keys() []string {
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Setenv copies the values that aren't set yet into the environment of the process, for code that
+// reads os.Getenv. Variables already set win, like most .env loaders. Child processes inherit them.
+func (c *Config) Setenv() error {
+	for _, k := range c.Keys() {
+		if _, ok := os.LookupEnv(k); ok {
+			continue
+		}
+		if err := os.Setenv(k, c.values[k]); err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package envcrypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/agefile"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/treecrypt"
+)
+
+func encryptAge(t *testing.T, k key.Key, plaintext string) []byte {
+	var buf bytes.Buffer
+	w, err := agefile.Encrypt(&buf, agefile.NewRawKey(k))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	w.Write([]byte(plaintext))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	k := key.Bit128()
+
+	tree, err := treecrypt.Encrypt(k, "", []byte(`{"db": {"password": "hunter2", "port": 5432}}`))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		encrypted []byte
+
+		expected map[string]string
+	}{
+		{
+			name:      "dotenv",
+			encrypted: encryptAge(t, k, "DB_PASSWORD=hunter2\nDB_PORT=5432\n"),
+
+			expected: map[string]string{"DB_PASSWORD": "hunter2", "DB_PORT": "5432"},
+		},
+		{
+			name:      "JSON in agefile",
+			encrypted: encryptAge(t, k, `{"db": {"password": "hunter2", "port": 5432}}`),
+
+			expected: map[string]string{"db.password": "hunter2", "db.port": "5432"},
+		},
+		{
+			name:      "treecrypt JSON",
+			encrypted: tree,
+
+			expected: map[string]string{"db.password": "hunter2", "db.port": "5432"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := Parse(test.encrypted, WithKey(k))
+			if err != nil {
+				t.Fatalf("Error parsing: %s", err)
+			}
+
+			if len(cfg.Keys()) != len(test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, cfg.Keys())
+			}
+			for name, value := range test.expected {
+				if v, ok := cfg.Lookup(name); !ok || v != value {
+					t.Errorf("Got: %s, Expected: %s", v, value)
+				}
+			}
+		})
+	}
+}
+
+func TestKeySources(t *testing.T) {
+	k := key.Bit256()
+	hexKey := hex.EncodeToString(k.GetBytes())
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, ".env.enc")
+	os.WriteFile(config, encryptAge(t, k, "TOKEN=abc\n"), 0600)
+	keyFile := filepath.Join(dir, "key")
+	os.WriteFile(keyFile, []byte(hexKey+"\n"), 0600)
+
+	t.Setenv(KeyEnv, "")
+	t.Setenv(KeyFileEnv, "")
+	if _, err := Load(config); err != ErrNoKey {
+		t.Errorf("Expected %v, got %v", ErrNoKey, err)
+	}
+
+	cfg, err := Load(config, WithKeyFile(keyFile))
+	if err != nil || cfg.Get("TOKEN") != "abc" {
+		t.Errorf("Expected abc with WithKeyFile, got %v (%v)", cfg, err)
+	}
+
+	t.Setenv(KeyFileEnv, keyFile)
+	cfg, err = Load(config)
+	if err != nil || cfg.Get("TOKEN") != "abc" {
+		t.Errorf("Expected abc with %s, got %v (%v)", KeyFileEnv, cfg, err)
+	}
+
+	t.Setenv(KeyEnv, hexKey)
+	t.Setenv(KeyFileEnv, "")
+	cfg, err = Load(config)
+	if err != nil || cfg.Get("TOKEN") != "abc" {
+		t.Errorf("Expected abc with %s, got %v (%v)", KeyEnv, cfg, err)
+	}
+
+	t.Setenv(KeyEnv, "0011")
+	if _, err := Load(config); err != ErrInvalidKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidKey, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	k := key.Bit128()
+
+	if _, err := Parse([]byte("DB_PASSWORD=hunter2\n"), WithKey(k)); err != ErrNotEncrypted {
+		t.Errorf("Expected %v, got %v", ErrNotEncrypted, err)
+	}
+	if _, err := Parse([]byte(`{"db": "hunter2"}`), WithKey(k)); err != ErrNotEncrypted {
+		t.Errorf("Expected %v, got %v", ErrNotEncrypted, err)
+	}
+	if _, err := Parse(encryptAge(t, k, "A=1"), WithKey(key.Bit128())); !errors.Is(err, agefile.ErrNoIdentity) {
+		t.Errorf("Expected %v, got %v", agefile.ErrNoIdentity, err)
+	}
+	if _, err := Parse(encryptAge(t, k, "not a line"), WithKey(k)); err == nil {
+		t.Errorf("Expected error for an invalid line, got nil")
+	}
+}
+
+func TestSetenv(t *testing.T) {
+	k := key.Bit128()
+	t.Setenv("ENVCRYPT_SET", "already")
+	t.Setenv("ENVCRYPT_NEW", "")
+	os.Unsetenv("ENVCRYPT_NEW")
+
+	cfg, err := Parse(encryptAge(t, k, "ENVCRYPT_SET=from file\nENVCRYPT_NEW=from file\n"), WithKey(k))
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+	if err := cfg.Setenv(); err != nil {
+		t.Fatalf("Error setting: %s", err)
+	}
+
+	if v := os.Getenv("ENVCRYPT_SET"); v != "already" {
+		t.Errorf("Got: %s, Expected: %s", v, "already")
+	}
+	if v := os.Getenv("ENVCRYPT_NEW"); v != "from file" {
+		t.Errorf("Got: %s, Expected: %s", v, "from file")
+	}
+}
@@ -0,0 +1,122 @@
+package envcrypt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func isJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	return len(b) > 0 && b[0] == '{'
+}
+
+// parseDotenv reads KEY=VALUE lines. Lines can start with "export", # starts a comment, and values
+// can be in single quotes (taken as they are) or double quotes (with \n, \" and \\ escapes).
+func parseDotenv(b []byte) (map[string]string, error) {
+	values := make(map[string]string)
+
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", line)
+		}
+
+		v, err := parseDotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		values[name] = v
+	}
+
+	return values, s.Err()
+}
+
+func parseDotenvValue(v string) (string, error) {
+	switch {
+	case strings.HasPrefix(v, `"`):
+		end := closingQuote(v)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		return strconv.Unquote(v[:end+1])
+	case strings.HasPrefix(v, "'"):
+		end := strings.IndexByte(v[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote")
+		}
+		return v[1 : end+1], nil
+	}
+
+	// unquoted values end at a comment
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v), nil
+}
+
+// closingQuote returns the index of the double quote ending v, skipping escaped ones.
+func closingQuote(v string) int {
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseJSON flattens an object, nested keys are joined with dots and array elements use their
+// index. Nulls are skipped.
+func parseJSON(b []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flatten(values, "", doc)
+	return values, nil
+}
+
+func flatten(values map[string]string, prefix string, v any) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flatten(values, join(k), child)
+		}
+	case []any:
+		for i, child := range v {
+			flatten(values, join(strconv.Itoa(i)), child)
+		}
+	case string:
+		values[prefix] = v
+	case json.Number:
+		values[prefix] = v.String()
+	case bool:
+		values[prefix] = strconv.FormatBool(v)
+	}
+}
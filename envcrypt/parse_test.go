@@ -0,0 +1,77 @@
+package envcrypt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:  "plain values",
+			input: "A=1\nB = two words \n",
+
+			expected: map[string]string{"A": "1", "B": "two words"},
+		},
+		{
+			name:  "comments and export",
+			input: "# database\n\nexport A=1 # the first one\nB=#not a comment\n",
+
+			expected: map[string]string{"A": "1", "B": "#not a comment"},
+		},
+		{
+			name:  "quotes",
+			input: `A="line\nbreak \"quoted\"" # comment` + "\n" + `B='single \n # kept'` + "\nC=\n",
+
+			expected: map[string]string{"A": "line\nbreak \"quoted\"", "B": `single \n # kept`, "C": ""},
+		},
+		{
+			name:  "missing equals",
+			input: "A=1\nB\n",
+
+			err: true,
+		},
+		{
+			name:  "unterminated quote",
+			input: `A="open`,
+
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := parseDotenv([]byte(test.input))
+			if test.err {
+				if err == nil {
+					t.Errorf("Expected error, got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Error parsing: %s", err)
+			}
+			if !reflect.DeepEqual(values, test.expected) {
+				t.Errorf("Got: %v, Expected: %v", values, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	values, err := parseJSON([]byte(`{"a": {"b": [1, true, null, "x"]}, "c": 1.5e3}`))
+	if err != nil {
+		t.Fatalf("Error parsing: %s", err)
+	}
+
+	expected := map[string]string{"a.b.0": "1", "a.b.1": "true", "a.b.3": "x", "c": "1.5e3"}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Got: %v, Expected: %v", values, expected)
+	}
+}
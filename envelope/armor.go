@@ -0,0 +1,110 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// ArmorType is the PEM type of armored blobs: -----BEGIN AESGO MESSAGE-----
+const ArmorType = "AESGO MESSAGE"
+
+const (
+	HeaderMode           = "Mode"
+	HeaderKDF            = "KDF"
+	HeaderKEKID          = "KEK-ID"
+	HeaderKeyFingerprint = "Key-Fingerprint"
+)
+
+var (
+	ErrInvalidArmor = errors.New("Invalid AESGO MESSAGE armor")
+	ErrWrongKEK     = errors.New("Blob was sealed with another key encryption key")
+)
+
+var modeNames = map[aesgo.Mode]string{
+	aesgo.ECB: "ECB",
+	aesgo.CBC: "CBC",
+	aesgo.CTR: "CTR",
+	aesgo.GCM: "GCM",
+}
+
+var kdfNames = map[key.KDF]string{
+	key.KDFPBKDF2: "PBKDF2",
+	key.KDFScrypt: "scrypt",
+}
+
+// Armor wraps a blob from Seal in a PEM block, so it can be pasted into configs, emails or tickets.
+// The headers are only a description for humans, everything needed to open it is in the blob.
+// kek is optional, when given its fingerprint goes into the Key-Fingerprint header.
+func Armor(b []byte, kek key.Key) ([]byte, error) {
+	blob, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{HeaderMode: modeNames[blob.Payload.Mode]}
+
+	if len(blob.Payload.KDFParams) > 0 {
+		var p key.KDFParams
+		if err := p.UnmarshalBinary(blob.Payload.KDFParams); err != nil {
+			return nil, err
+		}
+		headers[HeaderKDF] = kdfNames[p.KDF]
+	}
+	if blob.KEKID != "" {
+		headers[HeaderKEKID] = blob.KEKID
+	}
+	if kek != nil {
+		fingerprint := kek.Fingerprint()
+		headers[HeaderKeyFingerprint] = hex.EncodeToString(fingerprint[:])
+	}
+
+	// nil when a header can't be encoded, like a KEK ID with a new line
+	armored := pem.EncodeToMemory(&pem.Block{Type: ArmorType, Headers: headers, Bytes: b})
+	if armored == nil {
+		return nil, ErrInvalidArmor
+	}
+	return armored, nil
+}
+
+// Dearmor returns the blob and the headers of the first AESGO MESSAGE block. The Mode header must
+// match the blob, a message edited by hand shouldn't say something the blob doesn't.
+func Dearmor(armored []byte) ([]byte, map[string]string, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(armored))
+	if block == nil || block.Type != ArmorType {
+		return nil, nil, ErrInvalidArmor
+	}
+
+	blob, err := Parse(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mode, ok := block.Headers[HeaderMode]; ok && mode != modeNames[blob.Payload.Mode] {
+		return nil, nil, ErrInvalidArmor
+	}
+
+	return block.Bytes, block.Headers, nil
+}
+
+// OpenArmored dearmors and opens. When there is a Key-Fingerprint header it is checked first, so a
+// wrong kek gives ErrWrongKEK instead of a failed unwrap.
+func OpenArmored(kek key.Key, armored []byte) ([]byte, error) {
+	b, headers, err := Dearmor(armored)
+	if err != nil {
+		return nil, err
+	}
+
+	if fp, ok := headers[HeaderKeyFingerprint]; ok {
+		fingerprint := kek.Fingerprint()
+		if fp != hex.EncodeToString(fingerprint[:]) {
+			return nil, ErrWrongKEK
+		}
+	}
+
+	return Open(kek, b)
+}
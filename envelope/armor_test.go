@@ -0,0 +1,112 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestArmor(t *testing.T) {
+	kek := key.Bit128()
+	plaintext := []byte("Let's test if this is working!")
+
+	sealed, err := Seal(kek, "kek-1", aesgo.CTR, plaintext)
+	if err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+
+	armored, err := Armor(sealed, kek)
+	if err != nil {
+		t.Fatalf("Error armoring: %s", err)
+	}
+
+	s := string(armored)
+	if !strings.HasPrefix(s, "-----BEGIN AESGO MESSAGE-----\n") || !strings.HasSuffix(s, "-----END AESGO MESSAGE-----\n") {
+		t.Errorf("Missing PEM boundaries: %s", s)
+	}
+
+	b, headers, err := Dearmor(armored)
+	if err != nil {
+		t.Fatalf("Error dearmoring: %s", err)
+	}
+	if !bytes.Equal(b, sealed) {
+		t.Errorf("Got: %x, Expected: %x", b, sealed)
+	}
+
+	fingerprint := kek.Fingerprint()
+	expected := map[string]string{
+		HeaderMode:           "CTR",
+		HeaderKEKID:          "kek-1",
+		HeaderKeyFingerprint: hex.EncodeToString(fingerprint[:]),
+	}
+	for k, v := range expected {
+		if headers[k] != v {
+			t.Errorf("%s: Got: %s, Expected: %s", k, headers[k], v)
+		}
+	}
+
+	// pasted somewhere that added blank lines around it
+	opened, err := OpenArmored(kek, []byte("\n\n"+s+"\n"))
+	if err != nil {
+		t.Fatalf("Error opening: %s", err)
+	}
+	if !bytes.Equal(opened, plaintext) {
+		t.Errorf("Got: %s, Expected: %s", opened, plaintext)
+	}
+}
+
+func TestDearmorErrors(t *testing.T) {
+	kek := key.Bit128()
+
+	sealed, _ := Seal(kek, "", aesgo.CBC, []byte("hello"))
+	armored, _ := Armor(sealed, kek)
+
+	tests := []struct {
+		name string
+
+		armored string
+
+		expected error
+	}{
+		{
+			name: "not PEM",
+
+			armored: "hello",
+
+			expected: ErrInvalidArmor,
+		},
+		{
+			name: "other PEM type",
+
+			armored: strings.ReplaceAll(string(armored), "AESGO MESSAGE", "PRIVATE KEY"),
+
+			expected: ErrInvalidArmor,
+		},
+		{
+			name: "mode header doesn't match the blob",
+
+			armored: strings.Replace(string(armored), "Mode: CBC", "Mode: GCM", 1),
+
+			expected: ErrInvalidArmor,
+		},
+		{
+			name: "other kek",
+
+			armored: string(armored),
+
+			expected: ErrWrongKEK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := OpenArmored(key.Bit128(), []byte(test.armored)); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package envelope implements envelope encryption: every payload is encrypted with a fresh
+// data key (DEK) and the data key is wrapped with a key encryption key (KEK) using AES Key Wrap.
+// Only the KEK has to be stored somewhere safe, and rotating it only means re-wrapping data keys.
+package envelope
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const Version = 1
+
+var magic = [4]byte{'A', 'G', 'D', 'K'}
+
+var ErrInvalidBlob = errors.New("Invalid envelope blob")
+
+// Blob is the self describing output of Seal.
+//
+// The binary format is:
+//
+//	magic "AGDK" (4 bytes) | version (1 byte) | uvarint len(KEKID) | KEKID |
+//	uvarint len(WrappedKey) | WrappedKey | payload (a marshaled aesgo.Ciphertext)
+type Blob struct {
+	Version byte
+	// KEKID is an optional hint of which key encryption key wrapped the data key.
+	KEKID      string
+	WrappedKey []byte
+	Payload    *aesgo.Ciphertext
+}
+
+func (b *Blob) Marshal() []byte {
+	r := append([]byte{}, magic[:]...)
+	r = append(r, b.Version)
+
+	r = binary.AppendUvarint(r, uint64(len(b.KEKID)))
+	r = append(r, b.KEKID...)
+
+	r = binary.AppendUvarint(r, uint64(len(b.WrappedKey)))
+	r = append(r, b.WrappedKey...)
+
+	return append(r, b.Payload.Marshal()...)
+}
+
+func Parse(b []byte) (*Blob, error) {
+	if len(b) < 5 || [4]byte(b[:4]) != magic || b[4] != Version {
+		return nil, ErrInvalidBlob
+	}
+
+	blob := &Blob{Version: b[4]}
+	rest := b[5:]
+
+	id, rest, err := readField(rest)
+	if err != nil {
+		return nil, err
+	}
+	blob.KEKID = string(id)
+
+	wrapped, rest, err := readField(rest)
+	if err != nil {
+		return nil, err
+	}
+	blob.WrappedKey = wrapped
+
+	payload, err := aesgo.Unmarshal(rest)
+	if err != nil {
+		return nil, err
+	}
+	blob.Payload = payload
+
+	return blob, nil
+}
+
+func readField(b []byte) ([]byte, []byte, error) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || l > uint64(len(b)-n) {
+		return nil, nil, ErrInvalidBlob
+	}
+	b = b[n:]
+	return b[:l], b[l:], nil
+}
+
+// Option configures Seal.
+type Option func(*sealOptions)
+
+type sealOptions struct {
+	rand io.Reader
+}
+
+// WithRandReader sets where the data key and the IV come from. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *sealOptions) {
+		o.rand = r
+	}
+}
+
+// Seal encrypts the plaintext with a new random data key and wraps that key with the kek.
+func Seal(kek key.Key, kekID string, mode aesgo.Mode, plaintext []byte, opts ...Option) ([]byte, error) {
+	o := sealOptions{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dek, err := key.Random(16, key.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+	defer dek.Destroy()
+
+	wrapped, err := Wrap(kek, dek.GetBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := aesgo.NewCipher(dek, aesgo.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := a.EncryptCiphertext(mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := &Blob{Version: Version, KEKID: kekID, WrappedKey: wrapped, Payload: payload}
+	return blob.Marshal(), nil
+}
+
+// Open unwraps the data key with the kek and decrypts the payload.
+func Open(kek key.Key, b []byte) ([]byte, error) {
+	blob, err := Parse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	material, err := Unwrap(kek, blob.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(material) != 16 {
+		return nil, ErrInvalidBlob
+	}
+
+	dek := key.NewKey([16]byte(material))
+	defer dek.Destroy()
+	clear(material)
+
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.DecryptCiphertext(blob.Payload)
+}
@@ -0,0 +1,100 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestWrap(t *testing.T) {
+	// RFC 3394, section 4.1: wrap 128 bits of key data with a 128 bit KEK
+	material, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	kek := key.NewKey([16]byte(material))
+
+	data, _ := hex.DecodeString("00112233445566778899aabbccddeeff")
+	expected := "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5"
+
+	wrapped, err := Wrap(kek, data)
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if result := hex.EncodeToString(wrapped); result != expected {
+		t.Errorf("Got: %s, Expected: %s", result, expected)
+	}
+
+	unwrapped, err := Unwrap(kek, wrapped)
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if !bytes.Equal(unwrapped, data) {
+		t.Errorf("Got: %02x, Expected: %02x", unwrapped, data)
+	}
+
+	wrapped[0] ^= 1
+	if _, err := Unwrap(kek, wrapped); err != ErrUnwrap {
+		t.Errorf("Expected %v, got %v", ErrUnwrap, err)
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	kek := key.Bit128()
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, mode := range []aesgo.Mode{aesgo.CBC, aesgo.CTR} {
+		sealed, err := Seal(kek, "kek-1", mode, plaintext)
+		if err != nil {
+			t.Fatalf("Error sealing: %s", err)
+		}
+
+		blob, err := Parse(sealed)
+		if err != nil {
+			t.Fatalf("Error parsing: %s", err)
+		}
+
+		if blob.KEKID != "kek-1" || blob.Payload.Mode != mode || len(blob.WrappedKey) != 24 {
+			t.Errorf("Unexpected blob: %+v", blob)
+		}
+
+		opened, err := Open(kek, sealed)
+		if err != nil {
+			t.Fatalf("Error opening: %s", err)
+		}
+
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", opened, plaintext)
+		}
+
+		if _, err := Open(key.Bit128(), sealed); err != ErrUnwrap {
+			t.Errorf("Expected %v, got %v", ErrUnwrap, err)
+		}
+	}
+}
+
+func TestSealRandReader(t *testing.T) {
+	kek := key.Bit128()
+	source := bytes.Repeat([]byte{0x42}, 32)
+
+	// the same source gives the same data key and IV, so the same blob
+	var blobs [2][]byte
+	for i := range blobs {
+		b, err := Seal(kek, "kek-1", aesgo.CBC, []byte("hello"), WithRandReader(bytes.NewReader(source)))
+		if err != nil {
+			t.Fatalf("Error sealing: %s", err)
+		}
+		blobs[i] = b
+	}
+
+	if !bytes.Equal(blobs[0], blobs[1]) {
+		t.Errorf("Got: %x, Expected: %x", blobs[1], blobs[0])
+	}
+
+	// 16 bytes are enough for the data key but not for the IV
+	if _, err := Seal(kek, "", aesgo.CBC, []byte("hello"), WithRandReader(bytes.NewReader(source[:16]))); err == nil {
+		t.Errorf("Expected an error when the source runs out")
+	}
+}
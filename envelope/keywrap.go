@@ -0,0 +1,101 @@
+package envelope
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+var (
+	ErrInvalidKeyData = errors.New("Key data must be at least 16 bytes and a multiple of 8")
+	ErrUnwrap         = errors.New("Could not unwrap key: integrity check failed")
+)
+
+// defaultIV is the initial value from RFC 3394, section 2.2.3.1
+var defaultIV = [8]byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// Wrap encrypts key material with the key encryption key using AES Key Wrap (RFC 3394).
+// The output is 8 bytes longer than the input, those bytes are used to check integrity when unwrapping.
+func Wrap(kek key.Key, plaintext []byte) ([]byte, error) {
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, ErrInvalidKeyData
+	}
+
+	b, err := aesgo.NewBlock(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(plaintext) / 8
+	r := make([]byte, 8+len(plaintext))
+	copy(r[8:], plaintext)
+
+	a := defaultIV
+	var buf [16]byte
+
+	for j := 0; j <= 5; j++ {
+		for i := 1; i <= n; i++ {
+			// B = AES(K, A | R[i])
+			copy(buf[:8], a[:])
+			copy(buf[8:], r[i*8:i*8+8])
+			b.Encrypt(buf[:], buf[:])
+
+			// A = MSB(64, B) ^ t where t = (n*j)+i
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(a[:], binary.BigEndian.Uint64(buf[:8])^t)
+
+			// R[i] = LSB(64, B)
+			copy(r[i*8:], buf[8:])
+		}
+	}
+
+	copy(r[:8], a[:])
+	return r, nil
+}
+
+// Unwrap reverses Wrap and fails if the integrity check value doesn't match.
+func Unwrap(kek key.Key, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, ErrInvalidKeyData
+	}
+
+	b, err := aesgo.NewBlock(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return unwrap(b, ciphertext)
+}
+
+func unwrap(b cipher.Block, ciphertext []byte) ([]byte, error) {
+	n := len(ciphertext)/8 - 1
+	r := make([]byte, len(ciphertext))
+	copy(r, ciphertext)
+
+	var a [8]byte
+	copy(a[:], r[:8])
+	var buf [16]byte
+
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			// B = AES-1(K, (A ^ t) | R[i]) where t = n*j+i
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(a[:])^t)
+			copy(buf[8:], r[i*8:i*8+8])
+			b.Decrypt(buf[:], buf[:])
+
+			copy(a[:], buf[:8])
+			copy(r[i*8:], buf[8:])
+		}
+	}
+
+	if subtle.ConstantTimeCompare(a[:], defaultIV[:]) != 1 {
+		return nil, ErrUnwrap
+	}
+
+	return r[8:], nil
+}
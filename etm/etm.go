@@ -0,0 +1,104 @@
+// Package etm is Encrypt-then-MAC: AES-CBC or AES-CTR followed by HMAC-SHA-256 over the IV and the ciphertext.
+//
+// The MAC is checked before anything is decrypted, so a tampered message never reaches the padding
+// check and the padding oracle attack (see attacks/paddingoracle) doesn't work.
+//
+// The encryption and the MAC keys are derived with HKDF from a single master key, using a key for
+// both would be a mistake. The output is IV (16 bytes) || ciphertext || tag (32 bytes).
+package etm
+
+import (
+	"crypto/sha256"
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const TagSize = sha256.Size
+
+var (
+	ErrInvalidMode    = errors.New("Encrypt-then-MAC only supports CBC and CTR")
+	ErrTruncated      = errors.New("Message is shorter than the IV and the tag")
+	ErrAuthentication = errors.New("Message authentication failed")
+)
+
+// ETM encrypts and authenticates with keys derived from the master key.
+type ETM struct {
+	mode   aesgo.Mode
+	aes    *aesgo.AES
+	macKey []byte
+}
+
+// New derives the keys from master. mode must be aesgo.CBC or aesgo.CTR.
+func New(master key.Key, mode aesgo.Mode) (*ETM, error) {
+	if master.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	var name string
+	switch mode {
+	case aesgo.CBC:
+		name = "cbc"
+	case aesgo.CTR:
+		name = "ctr"
+	default:
+		return nil, ErrInvalidMode
+	}
+
+	// the mode is part of info, the same master key gives different keys for CBC and CTR
+	encKey, err := key.HKDF(sha256.New, master.GetBytes(), nil, []byte("aes-go etm "+name+" encryption"), 16)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := key.HKDF(sha256.New, master.GetBytes(), nil, []byte("aes-go etm "+name+" mac"), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := aesgo.NewCipher(key.NewKey([16]byte(encKey)), aesgo.WithMode(mode))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ETM{mode: mode, aes: a, macKey: macKey}, nil
+}
+
+// Seal encrypts plaintext and appends the tag.
+func (e *ETM) Seal(plaintext []byte) ([]byte, error) {
+	encrypted, err := e.aes.Encrypt(e.mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(encrypted, e.tag(encrypted)...), nil
+}
+
+// Open checks the tag in constant time and only then decrypts.
+func (e *ETM) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < 16+TagSize {
+		return nil, ErrTruncated
+	}
+
+	encrypted := sealed[:len(sealed)-TagSize]
+	tag := sealed[len(sealed)-TagSize:]
+
+	if !hmac.Equal(tag, e.tag(encrypted)) {
+		return nil, ErrAuthentication
+	}
+
+	return e.aes.Decrypt(e.mode, encrypted)
+}
+
+// Destroy wipes the derived keys.
+func (e *ETM) Destroy() {
+	e.aes.Destroy()
+	clear(e.macKey)
+}
+
+func (e *ETM) tag(encrypted []byte) []byte {
+	mac := hmac.New(sha256.New, e.macKey)
+	mac.Write(encrypted)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,113 @@
+package etm
+
+import (
+	"bytes"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestSealOpen(t *testing.T) {
+	tests := []struct {
+		name string
+
+		mode      aesgo.Mode
+		plaintext []byte
+	}{
+		{
+			name: "cbc",
+
+			mode:      aesgo.CBC,
+			plaintext: []byte("Let's test if this is working!"),
+		},
+		{
+			name: "ctr",
+
+			mode:      aesgo.CTR,
+			plaintext: []byte("Let's test if this is working!"),
+		},
+		{
+			name: "empty plaintext",
+
+			mode:      aesgo.CBC,
+			plaintext: []byte{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			e, err := New(key.Bit128(), test.mode)
+			if err != nil {
+				t.Fatalf("Error creating: %s", err)
+			}
+
+			sealed, err := e.Seal(test.plaintext)
+			if err != nil {
+				t.Fatalf("Error sealing: %s", err)
+			}
+
+			opened, err := e.Open(sealed)
+			if err != nil {
+				t.Fatalf("Error opening: %s", err)
+			}
+			if !bytes.Equal(opened, test.plaintext) {
+				t.Errorf("Got: %s, Expected: %s", opened, test.plaintext)
+			}
+		})
+	}
+}
+
+func TestTampering(t *testing.T) {
+	master := key.Bit128()
+	e, err := New(master, aesgo.CBC)
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+
+	sealed, err := e.Seal([]byte("transfer 100 dollars to alice"))
+	if err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+
+	// flipping any bit, in the IV, the ciphertext or the tag, must be detected
+	for _, i := range []int{0, 15, 16, len(sealed) - TagSize - 1, len(sealed) - 1} {
+		tampered := append([]byte{}, sealed...)
+		tampered[i] ^= 1
+
+		if _, err := e.Open(tampered); err != ErrAuthentication {
+			t.Errorf("Byte %d. Expected %v, got %v", i, ErrAuthentication, err)
+		}
+	}
+
+	if _, err := e.Open(sealed[:16+TagSize-1]); err != ErrTruncated {
+		t.Errorf("Expected %v, got %v", ErrTruncated, err)
+	}
+
+	// the same master key with the other mode has other keys
+	other, err := New(master, aesgo.CTR)
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+	if _, err := other.Open(sealed); err != ErrAuthentication {
+		t.Errorf("Expected %v, got %v", ErrAuthentication, err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	if _, err := New(key.Bit128(), aesgo.ECB); err != ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", ErrInvalidMode, err)
+	}
+
+	k := key.Bit128()
+	k.Destroy()
+	if _, err := New(k, aesgo.CBC); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+
+	e, _ := New(key.Bit128(), aesgo.CBC)
+	e.Destroy()
+	if _, err := e.Seal([]byte("message")); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
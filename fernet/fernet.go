@@ -0,0 +1,160 @@
+// Package fernet implements Fernet tokens (https://github.com/fernet/spec) on top of aesgo.
+//
+// A token is the URL safe base64 of
+//
+//	version (0x80) | timestamp (8 bytes) | IV (16 bytes) | AES-128-CBC ciphertext | HMAC-SHA256 (32 bytes)
+//
+// The HMAC covers everything before it. The 32 byte secret is split: the first half signs and
+// the second half encrypts. Tokens are compatible with other Fernet implementations, like the one
+// in Python's cryptography package.
+package fernet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const version = 0x80
+
+// maxClockSkew is how far in the future a token timestamp can be, as in the spec.
+const maxClockSkew = 60 * time.Second
+
+// the smallest token: version, timestamp, IV, one block and the HMAC
+const minTokenSize = 1 + 8 + 16 + 16 + sha256.Size
+
+var (
+	ErrInvalidKey   = errors.New("Fernet key must be 32 bytes in URL safe base64")
+	ErrInvalidToken = errors.New("Invalid Fernet token")
+	ErrExpiredToken = errors.New("Fernet token has expired")
+)
+
+type Fernet struct {
+	signingKey    []byte
+	encryptionKey [16]byte
+
+	now  func() time.Time
+	rand io.Reader
+}
+
+type Option func(*Fernet)
+
+// WithClock replaces time.Now, for tests and for the test vectors of the spec.
+func WithClock(now func() time.Time) Option {
+	return func(f *Fernet) {
+		f.now = now
+	}
+}
+
+// WithRandReader sets where the IVs come from. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(f *Fernet) {
+		f.rand = r
+	}
+}
+
+// GenerateKey returns a new random secret, already encoded.
+func GenerateKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// New takes the secret encoded in URL safe base64, like the output of GenerateKey.
+func New(secret string, opts ...Option) (*Fernet, error) {
+	b, err := base64.URLEncoding.DecodeString(secret)
+	if err != nil || len(b) != 32 {
+		return nil, ErrInvalidKey
+	}
+
+	f := &Fernet{
+		signingKey:    b[:16],
+		encryptionKey: [16]byte(b[16:]),
+		now:           time.Now,
+		rand:          rand.Reader,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f, nil
+}
+
+// Generate encrypts message into a token with the current time.
+func (f *Fernet) Generate(message []byte) (string, error) {
+	a, err := aesgo.NewCipher(key.NewKey(f.encryptionKey), aesgo.WithRandReader(f.rand))
+	if err != nil {
+		return "", err
+	}
+
+	// aesgo returns IV | ciphertext, which is what goes after the timestamp
+	encrypted, err := a.Encrypt(aesgo.CBC, message)
+	if err != nil {
+		return "", err
+	}
+
+	token := make([]byte, 9, 9+len(encrypted)+sha256.Size)
+	token[0] = version
+	binary.BigEndian.PutUint64(token[1:9], uint64(f.now().Unix()))
+	token = append(token, encrypted...)
+	token = append(token, f.sign(token)...)
+
+	return base64.URLEncoding.EncodeToString(token), nil
+}
+
+// Verify checks the token and returns the message. With a ttl above 0, tokens older than ttl are rejected.
+func (f *Fernet) Verify(token string, ttl time.Duration) ([]byte, error) {
+	b, err := base64.URLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if len(b) < minTokenSize || (len(b)-minTokenSize)%16 != 0 {
+		return nil, fmt.Errorf("%w: wrong size", ErrInvalidToken)
+	}
+	if b[0] != version {
+		return nil, fmt.Errorf("%w: unknown version %#x", ErrInvalidToken, b[0])
+	}
+
+	signed := b[:len(b)-sha256.Size]
+	if !hmac.Equal(b[len(b)-sha256.Size:], f.sign(signed)) {
+		return nil, fmt.Errorf("%w: incorrect HMAC", ErrInvalidToken)
+	}
+
+	now := f.now()
+	timestamp := time.Unix(int64(binary.BigEndian.Uint64(b[1:9])), 0)
+	if timestamp.After(now.Add(maxClockSkew)) {
+		return nil, fmt.Errorf("%w: timestamp too far in the future", ErrInvalidToken)
+	}
+	if ttl > 0 && now.After(timestamp.Add(ttl)) {
+		return nil, ErrExpiredToken
+	}
+
+	a, err := aesgo.NewCipher(key.NewKey(f.encryptionKey))
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := a.Decrypt(aesgo.CBC, signed[9:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	return message, nil
+}
+
+func (f *Fernet) sign(b []byte) []byte {
+	mac := hmac.New(sha256.New, f.signingKey)
+	mac.Write(b)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,185 @@
+package fernet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+// generate.json and verify.json from https://github.com/fernet/spec
+const (
+	specSecret = "cw_0x689RpI-jtRR7oE8h_eQsKImvJapLeSbXpwF4e4="
+	specToken  = "gAAAAAAdwJ6wAAECAwQFBgcICQoLDA0ODy021cpGVWKZ_eEwCGM4BLLF_5CV9dOPmrhuVUPgJobwOz7JcbmrR64jVmpU4IwqDA=="
+)
+
+var specIV = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func clock(s string) func() time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return func() time.Time { return t }
+}
+
+func TestGenerate(t *testing.T) {
+	f, err := New(specSecret, WithClock(clock("1985-10-26T01:20:00-07:00")), WithRandReader(bytes.NewReader(specIV)))
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+
+	token, err := f.Generate([]byte("hello"))
+	if err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+	if token != specToken {
+		t.Errorf("Got: %s, Expected: %s", token, specToken)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f, err := New(specSecret, WithClock(clock("1985-10-26T01:20:01-07:00")))
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+
+	message, err := f.Verify(specToken, 60*time.Second)
+	if err != nil {
+		t.Fatalf("Error verifying: %s", err)
+	}
+	if string(message) != "hello" {
+		t.Errorf("Got: %s, Expected: %s", message, "hello")
+	}
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	raw, _ := base64.URLEncoding.DecodeString(specToken)
+
+	encode := func(change func(b []byte) []byte) string {
+		return base64.URLEncoding.EncodeToString(change(append([]byte{}, raw...)))
+	}
+
+	tests := []struct {
+		name string
+
+		token string
+		now   string
+		ttl   time.Duration
+
+		expected error
+	}{
+		{
+			name: "incorrect mac",
+
+			token: encode(func(b []byte) []byte { b[len(b)-1] ^= 1; return b }),
+			now:   "1985-10-26T01:20:01-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "too short",
+
+			token: encode(func(b []byte) []byte { return b[:len(b)-33] }),
+			now:   "1985-10-26T01:20:01-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "invalid base64",
+
+			token: "%%%%" + specToken[4:],
+			now:   "1985-10-26T01:20:01-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "payload size not multiple of block size",
+
+			token: encode(func(b []byte) []byte { return append(b, 0) }),
+			now:   "1985-10-26T01:20:01-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "unknown version",
+
+			token: encode(func(b []byte) []byte { b[0] = 0x81; return b }),
+			now:   "1985-10-26T01:20:01-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "far-future timestamp",
+
+			token: specToken,
+			now:   "1985-10-26T01:18:59-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "expired ttl",
+
+			token: specToken,
+			now:   "1985-10-26T01:21:31-07:00",
+			ttl:   time.Minute,
+
+			expected: ErrExpiredToken,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := New(specSecret, WithClock(clock(test.now)))
+			if err != nil {
+				t.Fatalf("Error creating: %s", err)
+			}
+
+			if _, err := f.Verify(test.token, test.ttl); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	secret, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	f, err := New(secret)
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+
+	token, err := f.Generate([]byte("Let's test if this is working!"))
+	if err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+
+	// no ttl, the token never expires
+	message, err := f.Verify(token, 0)
+	if err != nil {
+		t.Fatalf("Error verifying: %s", err)
+	}
+	if string(message) != "Let's test if this is working!" {
+		t.Errorf("Got: %s, Expected: %s", message, "Let's test if this is working!")
+	}
+
+	other, _ := GenerateKey()
+	o, _ := New(other)
+	if _, err := o.Verify(token, 0); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected %v, got %v", ErrInvalidToken, err)
+	}
+
+	if _, err := New("short"); err != ErrInvalidKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidKey, err)
+	}
+}
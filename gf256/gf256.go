@@ -0,0 +1,90 @@
+// Package gf256 is the arithmetic of GF(2^8), the field AES works in (FIPS-197 section 4).
+//
+// A byte is a polynomial of degree 7 at most, bit i is the coefficient of x^i. Adding is XOR, and
+// multiplying is multiplying the polynomials modulo the irreducible polynomial x^8 + x^4 + x^3 + x + 1.
+package gf256
+
+// Poly is the AES irreducible polynomial x^8 + x^4 + x^3 + x + 1.
+const Poly = 0x11b
+
+// Generator is a generator of the multiplicative group: its powers go through all 255 non zero bytes.
+const Generator = 0x03
+
+// Add adds two elements, which is the same as subtracting them.
+func Add(a, b byte) byte {
+	return a ^ b
+}
+
+// Mul performs Galois Field (256) multiplication of two bytes.
+// implementation taking from wikipedia
+func Mul(a, b byte) byte {
+	var p byte = 0
+
+	for counter := 0; counter < 8; counter++ {
+		if (b & 1) != 0 {
+			p ^= a
+		}
+
+		hiBitSet := (a & 0x80) != 0
+		a <<= 1
+		if hiBitSet {
+			a ^= 0x1B // x^8 + x^4 + x^3 + x + 1
+		}
+		b >>= 1
+	}
+
+	return p
+}
+
+// Reduce returns p modulo Poly. p is a polynomial of degree 15 at most, like the product of two
+// bytes before reduction.
+func Reduce(p uint16) byte {
+	for i := 15; i >= 8; i-- {
+		if p&(1<<i) != 0 {
+			p ^= Poly << (i - 8)
+		}
+	}
+	return byte(p)
+}
+
+// Inverse returns the multiplicative inverse of a. 0 has no inverse, AES maps it to 0 and so does Inverse.
+func Inverse(a byte) byte {
+	if a == 0 {
+		return 0
+	}
+	// g^255 = 1, so the inverse of g^i is g^(255 - i)
+	return Exp(255 - Log(a))
+}
+
+// Exp returns Generator^i.
+func Exp(i int) byte {
+	i %= 255
+	if i < 0 {
+		i += 255
+	}
+	return expTable[i]
+}
+
+// Log returns i where Generator^i = a, between 0 and 254. The log of 0 isn't defined and Log panics.
+func Log(a byte) int {
+	if a == 0 {
+		panic("gf256: log of 0")
+	}
+	return int(logTable[a])
+}
+
+var expTable, logTable = tables()
+
+func tables() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+		x = Mul(x, Generator)
+	}
+
+	return exp, log
+}
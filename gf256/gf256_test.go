@@ -0,0 +1,95 @@
+package gf256
+
+import "testing"
+
+func TestMul(t *testing.T) {
+	// FIPS-197 section 4.2
+	tests := []struct {
+		name string
+
+		a, b     byte
+		expected byte
+	}{
+		{name: "57 * 83", a: 0x57, b: 0x83, expected: 0xc1},
+		{name: "57 * 13", a: 0x57, b: 0x13, expected: 0xfe},
+		{name: "57 * 02", a: 0x57, b: 0x02, expected: 0xae},
+		{name: "by one", a: 0x57, b: 0x01, expected: 0x57},
+		{name: "by zero", a: 0x57, b: 0x00, expected: 0x00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Mul(tt.a, tt.b); got != tt.expected {
+				t.Errorf("Expected %02x, got %02x", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReduce(t *testing.T) {
+	// 57 * 83 before reduction is x^13 + x^11 + x^9 + x^8 + x^6 + x^5 + x^4 + x^3 + 1
+	if got := Reduce(0x2b79); got != 0xc1 {
+		t.Errorf("Expected c1, got %02x", got)
+	}
+
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if Reduce(clmul(byte(a), byte(b))) != Mul(byte(a), byte(b)) {
+				t.Fatalf("Reduce and Mul disagree for %02x * %02x", a, b)
+			}
+		}
+	}
+}
+
+func TestInverse(t *testing.T) {
+	if Inverse(0) != 0 {
+		t.Errorf("Expected 0, got %02x", Inverse(0))
+	}
+	// FIPS-197 section 4.4
+	if Inverse(0x53) != 0xca {
+		t.Errorf("Expected ca, got %02x", Inverse(0x53))
+	}
+
+	for a := 1; a < 256; a++ {
+		if got := Mul(byte(a), Inverse(byte(a))); got != 1 {
+			t.Fatalf("%02x * %02x = %02x, expected 1", a, Inverse(byte(a)), got)
+		}
+	}
+}
+
+func TestExpLog(t *testing.T) {
+	seen := map[byte]bool{}
+	for i := 0; i < 255; i++ {
+		seen[Exp(i)] = true
+		if Log(Exp(i)) != i {
+			t.Errorf("Expected log(exp(%d)) = %d, got %d", i, i, Log(Exp(i)))
+		}
+	}
+	if len(seen) != 255 {
+		t.Errorf("Expected the generator to produce 255 elements, got %d", len(seen))
+	}
+
+	if Exp(255) != 1 || Exp(-1) != Exp(254) {
+		t.Errorf("Expected exponents modulo 255")
+	}
+
+	// a * b = g^(log a + log b)
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if Exp(Log(byte(a))+Log(byte(b))) != Mul(byte(a), byte(b)) {
+				t.Fatalf("Exp/Log and Mul disagree for %02x * %02x", a, b)
+			}
+		}
+	}
+}
+
+// clmul multiplies the polynomials without reducing.
+func clmul(a, b byte) uint16 {
+	var p uint16
+	for i := 0; i < 8; i++ {
+		if b&(1<<i) != 0 {
+			p ^= uint16(a) << i
+		}
+	}
+	return p
+}
@@ -0,0 +1,134 @@
+// Package ghash is the universal hash of GCM, on its own so it can be studied, benchmarked and
+// reused (GMAC is GCM without plaintext). Defined in NIST SP 800-38D section 6.4:
+// https://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38d.pdf
+//
+// GHASH evaluates a polynomial at the hash key H in GF(2^128). Every block is added to the result
+// and the result is multiplied by H:
+//
+//	y = (y + block) * H
+//
+// so for blocks X1..Xn the result is X1*H^n + X2*H^(n-1) + ... + Xn*H. It is only secure as part of
+// a MAC, with a secret H and the result masked (GCM adds E(K, J0)). On its own it is linear and
+// anyone who knows H can find collisions.
+package ghash
+
+import "encoding/binary"
+
+const (
+	Size      = 16
+	BlockSize = 16
+)
+
+// GHASH hashes whatever is written to it, zero padding the last block. It is not safe for concurrent use.
+type GHASH struct {
+	h   [16]byte
+	y   [16]byte
+	buf []byte
+}
+
+func New(h [16]byte) *GHASH {
+	return &GHASH{h: h}
+}
+
+// GCM is GHASH(H, A, C) as used by GCM: the additional data and the ciphertext, each one padded
+// to full blocks, followed by a block with both lengths in bits.
+func GCM(h [16]byte, additionalData, ciphertext []byte) [16]byte {
+	g := New(h)
+	g.Write(additionalData)
+	g.Pad()
+	g.Write(ciphertext)
+	g.Pad()
+
+	var lengths [16]byte
+	binary.BigEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.BigEndian.PutUint64(lengths[8:], uint64(len(ciphertext))*8)
+	g.Write(lengths[:])
+
+	return [16]byte(g.Sum(nil))
+}
+
+func (g *GHASH) Write(p []byte) (int, error) {
+	g.buf = append(g.buf, p...)
+
+	for len(g.buf) >= BlockSize {
+		g.block([16]byte(g.buf[:BlockSize]))
+		g.buf = g.buf[BlockSize:]
+	}
+	g.buf = append(g.buf[:0:0], g.buf...)
+
+	return len(p), nil
+}
+
+// Pad fills the pending partial block with zeros and hashes it. GCM pads the additional data
+// before the ciphertext starts.
+func (g *GHASH) Pad() {
+	if len(g.buf) == 0 {
+		return
+	}
+
+	var last [16]byte
+	copy(last[:], g.buf)
+	g.block(last)
+	g.buf = nil
+}
+
+// Sum appends the hash to b, zero padding a partial block. It doesn't change the state.
+func (g *GHASH) Sum(b []byte) []byte {
+	c := *g
+	c.Pad()
+	return append(b, c.y[:]...)
+}
+
+func (g *GHASH) Reset() {
+	g.y = [16]byte{}
+	g.buf = nil
+}
+
+func (g *GHASH) Size() int {
+	return Size
+}
+
+func (g *GHASH) BlockSize() int {
+	return BlockSize
+}
+
+func (g *GHASH) block(b [16]byte) {
+	for i := range g.y {
+		g.y[i] ^= b[i]
+	}
+	g.y = Mul(g.y, g.h)
+}
+
+// Mul multiplies two elements of GF(2^128) with the GCM bit order: the first bit (the most significant bit of
+// the first byte) is the coefficient of x^0. It is algorithm 1 of SP 800-38D, one bit at a time.
+func Mul(x, y [16]byte) [16]byte {
+	var z [16]byte
+	v := y
+
+	for i := 0; i < 128; i++ {
+		// add v when the bit i of x is set
+		if x[i/8]&(0x80>>(i%8)) != 0 {
+			for j := range z {
+				z[j] ^= v[j]
+			}
+		}
+
+		v = MulX(v)
+	}
+
+	return z
+}
+
+// MulX multiplies by x. Shifting right is multiplying by x with this bit order, the bit shifted out is x^128
+// which is reduced with x^128 = x^7 + x^2 + x + 1 (0xe1 in the first byte)
+func MulX(v [16]byte) [16]byte {
+	carry := v[15] & 1
+	for j := 15; j > 0; j-- {
+		v[j] = v[j]>>1 | v[j-1]<<7
+	}
+	v[0] >>= 1
+	if carry == 1 {
+		v[0] ^= 0xe1
+	}
+	return v
+}
@@ -0,0 +1,117 @@
+package ghash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test case 2 of the GCM specification: key and plaintext are a zero block.
+// https://csrc.nist.rip/groups/ST/toolkit/BCM/documents/proposedmodes/gcm/gcm-spec.pdf
+func TestGCM(t *testing.T) {
+	h := [16]byte(decode("66e94bd4ef8a2c3b884cfa59ca342b2e"))
+	ciphertext := decode("0388dace60b6a392f328c2b971b2fe78")
+	expected := "f38cbb1ad69223dcc3457ae5b6b0f885"
+
+	got := GCM(h, nil, ciphertext)
+	if hex.EncodeToString(got[:]) != expected {
+		t.Errorf("Got: %x, Expected: %s", got, expected)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	h := [16]byte(decode("66e94bd4ef8a2c3b884cfa59ca342b2e"))
+	data := bytes.Repeat([]byte("ghash"), 13)
+
+	one := New(h)
+	one.Write(data)
+	expected := one.Sum(nil)
+
+	// one byte at a time, with a Sum in the middle that must not change anything
+	g := New(h)
+	for i, b := range data {
+		g.Write([]byte{b})
+		if i == 20 {
+			g.Sum(nil)
+		}
+	}
+	if got := g.Sum(nil); !bytes.Equal(got, expected) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	// a partial block is the same as the block padded with zeros
+	padded := New(h)
+	padded.Write(append(append([]byte{}, data...), make([]byte, 16-len(data)%16)...))
+	if got := padded.Sum(nil); !bytes.Equal(got, expected) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	g.Reset()
+	g.Write(data)
+	if got := g.Sum(nil); !bytes.Equal(got, expected) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+}
+
+// GHASH of the blocks X1, X2 is X1*H^2 + X2*H.
+func TestPolynomial(t *testing.T) {
+	h := [16]byte(decode("b83b533708bf535d0aa6e52980d53b78"))
+	x1 := [16]byte(decode("42831ec2217774244b7221b784d0d49c"))
+	x2 := [16]byte(decode("e3aa212f2c02a4e035c17e2329aca12e"))
+
+	g := New(h)
+	g.Write(x1[:])
+	g.Write(x2[:])
+
+	expected := add(Mul(x1, Mul(h, h)), Mul(x2, h))
+	if got := g.Sum(nil); !bytes.Equal(got, expected[:]) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+}
+
+func TestMul(t *testing.T) {
+	a := [16]byte(decode("66e94bd4ef8a2c3b884cfa59ca342b2e"))
+	b := [16]byte(decode("0388dace60b6a392f328c2b971b2fe78"))
+	c := [16]byte(decode("b83b533708bf535d0aa6e52980d53b78"))
+
+	// 1 is the first bit with this bit order and x is the second
+	one := [16]byte{0x80}
+	x := [16]byte{0x40}
+
+	tests := []struct {
+		name string
+
+		got      [16]byte
+		expected [16]byte
+	}{
+		{name: "identity", got: Mul(a, one), expected: a},
+		{name: "zero", got: Mul(a, [16]byte{}), expected: [16]byte{}},
+		{name: "commutative", got: Mul(a, b), expected: Mul(b, a)},
+		{name: "associative", got: Mul(Mul(a, b), c), expected: Mul(a, Mul(b, c))},
+		{name: "distributive", got: Mul(a, add(b, c)), expected: add(Mul(a, b), Mul(a, c))},
+		{name: "times x", got: Mul(a, x), expected: MulX(a)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.expected {
+				t.Errorf("Got: %x, Expected: %x", test.got, test.expected)
+			}
+		})
+	}
+}
+
+func add(a, b [16]byte) [16]byte {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
+
+func decode(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
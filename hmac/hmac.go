@@ -0,0 +1,104 @@
+// Package hmac implements HMAC (RFC 2104, FIPS 198-1): https://www.rfc-editor.org/rfc/rfc2104
+//
+// A plain hash of key || message isn't a MAC: with SHA-256 anyone can append to the message and
+// compute the new hash (length extension). HMAC hashes twice with the key mixed in both times:
+//
+//	HMAC(K, m) = H((K' XOR opad) || H((K' XOR ipad) || m))
+//
+// where K' is the key padded with zeros to the block size of the hash (or hashed first when it is
+// longer than a block), ipad is 0x36 repeated and opad is 0x5c repeated. The outer hash is over a
+// fixed size input, so there is nothing to extend.
+//
+// It has the same API as crypto/hmac and works with any hash.Hash.
+package hmac
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+const (
+	ipad = 0x36
+	opad = 0x5c
+)
+
+type digest struct {
+	inner, outer hash.Hash
+	// the padded key XORed with ipad and opad
+	ipad, opad []byte
+}
+
+// New returns a hash.Hash computing HMAC with h and key.
+func New(h func() hash.Hash, key []byte) hash.Hash {
+	d := &digest{inner: h(), outer: h()}
+	blockSize := d.inner.BlockSize()
+
+	// keys longer than a block are hashed first
+	if len(key) > blockSize {
+		d.outer.Write(key)
+		key = d.outer.Sum(nil)
+		d.outer.Reset()
+	}
+
+	d.ipad = make([]byte, blockSize)
+	d.opad = make([]byte, blockSize)
+	copy(d.ipad, key)
+	copy(d.opad, key)
+	for i := range d.ipad {
+		d.ipad[i] ^= ipad
+		d.opad[i] ^= opad
+	}
+
+	d.inner.Write(d.ipad)
+	return d
+}
+
+// SHA256 returns HMAC-SHA256 of msg.
+func SHA256(key, msg []byte) [sha256.Size]byte {
+	mac := New(sha256.New, key)
+	mac.Write(msg)
+	return [sha256.Size]byte(mac.Sum(nil))
+}
+
+// SHA512 returns HMAC-SHA512 of msg.
+func SHA512(key, msg []byte) [sha512.Size]byte {
+	mac := New(sha512.New, key)
+	mac.Write(msg)
+	return [sha512.Size]byte(mac.Sum(nil))
+}
+
+// Equal compares two MACs in constant time. Comparing them with bytes.Equal tells an attacker
+// how many bytes of a forged tag are right.
+func Equal(mac1, mac2 []byte) bool {
+	return subtle.ConstantTimeCompare(mac1, mac2) == 1
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	return d.inner.Write(p)
+}
+
+// Sum appends the MAC to b. The inner hash is left alone, so more data can be written.
+func (d *digest) Sum(b []byte) []byte {
+	in := d.inner.Sum(nil)
+
+	d.outer.Reset()
+	d.outer.Write(d.opad)
+	d.outer.Write(in)
+	return d.outer.Sum(b)
+}
+
+func (d *digest) Reset() {
+	d.inner.Reset()
+	d.inner.Write(d.ipad)
+}
+
+func (d *digest) Size() int {
+	return d.outer.Size()
+}
+
+func (d *digest) BlockSize() int {
+	return d.inner.BlockSize()
+}
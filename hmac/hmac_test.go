@@ -0,0 +1,115 @@
+package hmac
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+// RFC 4231 test cases 1, 2 and 6
+func TestRFC4231(t *testing.T) {
+	tests := []struct {
+		name string
+
+		key  []byte
+		data string
+
+		sha256 string
+		sha512 string
+	}{
+		{
+			name: "test case 1",
+
+			key:  bytes.Repeat([]byte{0x0b}, 20),
+			data: "Hi There",
+
+			sha256: "b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7",
+			sha512: "87aa7cdea5ef619d4ff0b4241a1d6cb02379f4e2ce4ec2787ad0b30545e17cdedaa833b7d6b8a702038b274eaea3f4e4be9d914eeb61f1702e696c203a126854",
+		},
+		{
+			name: "key shorter than the output",
+
+			key:  []byte("Jefe"),
+			data: "what do ya want for nothing?",
+
+			sha256: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+			sha512: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+		},
+		{
+			name: "key larger than a block",
+
+			key:  bytes.Repeat([]byte{0xaa}, 131),
+			data: "Test Using Larger Than Block-Size Key - Hash Key First",
+
+			sha256: "60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54",
+			sha512: "80b24263c7c1a3ebb71493c1dd7be8b49b46d1f41b4aeec1121b013783f8f3526b56d037e05f2598bd0fd2215d6a1e5295e64f73f63f0aec8b915a985d786598",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s256 := SHA256(test.key, []byte(test.data))
+			if got := hex.EncodeToString(s256[:]); got != test.sha256 {
+				t.Errorf("Got: %s, Expected: %s", got, test.sha256)
+			}
+
+			s512 := SHA512(test.key, []byte(test.data))
+			if got := hex.EncodeToString(s512[:]); got != test.sha512 {
+				t.Errorf("Got: %s, Expected: %s", got, test.sha512)
+			}
+		})
+	}
+}
+
+func TestStd(t *testing.T) {
+	for _, h := range []func() hash.Hash{sha1.New, sha256.New, sha512.New384, sha512.New} {
+		for _, keySize := range []int{0, 16, 64, 128, 200} {
+			k := bytes.Repeat([]byte{0x42}, keySize)
+			data := bytes.Repeat([]byte("hmac"), 100)
+
+			mac := New(h, k)
+			mac.Write(data[:150])
+			mac.Sum(nil)
+			mac.Write(data[150:])
+
+			std := stdhmac.New(h, k)
+			std.Write(data)
+			expected := std.Sum(nil)
+
+			if got := mac.Sum(nil); !bytes.Equal(got, expected) {
+				t.Errorf("key size %d: Got: %x, Expected: %x", keySize, got, expected)
+			}
+			if mac.Size() != std.Size() || mac.BlockSize() != std.BlockSize() {
+				t.Errorf("Expected %v/%v, got %v/%v", std.Size(), std.BlockSize(), mac.Size(), mac.BlockSize())
+			}
+
+			mac.Reset()
+			mac.Write(data)
+			if got := mac.Sum(nil); !bytes.Equal(got, expected) {
+				t.Errorf("After reset: Got: %x, Expected: %x", got, expected)
+			}
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	mac := SHA256([]byte("key"), []byte("message"))
+
+	if !Equal(mac[:], mac[:]) {
+		t.Errorf("Expected equal MACs")
+	}
+
+	other := mac
+	other[31] ^= 1
+	if Equal(mac[:], other[:]) {
+		t.Errorf("Expected different MACs")
+	}
+	if Equal(mac[:], mac[:16]) {
+		t.Errorf("Expected MACs of different length to be different")
+	}
+}
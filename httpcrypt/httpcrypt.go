@@ -0,0 +1,246 @@
+// Package httpcrypt encrypts HTTP bodies at the application layer, on top of (or instead of) TLS,
+// with a key shared by the client and the server. It is for demos: TLS is what protects HTTP.
+//
+// Bodies are sealed with GCM in the envelope format and marked with "Content-Encoding: aesgo-gcm",
+// the Content-Type stays the one of the plaintext. On the client Transport encrypts requests and
+// decrypts responses, on the server Handler does the opposite:
+//
+//	c, _ := httpcrypt.New(k)
+//	client := &http.Client{Transport: c.Transport(nil)}
+//	http.ListenAndServe(":8080", c.Handler(mux))
+//
+// Headers, the method and the URL are left alone. A body isn't bound to its request either, an
+// attacker in the middle can replay an old request or swap two responses.
+//
+// There is no gRPC interceptor: it would pull google.golang.org/grpc into a module that has no
+// dependencies. A gRPC codec wrapping the proto codec can call Seal and Open the same way.
+package httpcrypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// Encoding is the Content-Encoding of an encrypted body.
+const Encoding = "aesgo-gcm"
+
+// MaxBodySize is the largest body read, bodies are encrypted in one piece.
+const MaxBodySize = 32 << 20
+
+var (
+	ErrNotEncrypted = errors.New("Body isn't encrypted")
+	ErrTooLarge     = errors.New("Body is larger than MaxBodySize")
+)
+
+// Codec is safe for concurrent use, the ciphers come from an aesgo.Pool.
+type Codec struct {
+	pool *aesgo.Pool
+}
+
+func New(k key.Key) (*Codec, error) {
+	p, err := aesgo.NewPool(k)
+	if err != nil {
+		return nil, err
+	}
+	return &Codec{pool: p}, nil
+}
+
+// Seal returns the envelope of a body.
+func (c *Codec) Seal(body []byte) ([]byte, error) {
+	a := c.pool.Get()
+	defer c.pool.Put(a)
+
+	ct, err := a.EncryptCiphertext(aesgo.GCM, body)
+	if err != nil {
+		return nil, err
+	}
+	return ct.Marshal(), nil
+}
+
+// Open returns the body of an envelope. Only GCM is accepted, an attacker could otherwise change
+// the mode to CTR and flip bits of the body.
+func (c *Codec) Open(envelope []byte) ([]byte, error) {
+	ct, err := aesgo.Unmarshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if ct.Mode != aesgo.GCM {
+		return nil, aesgo.ErrInvalidMode
+	}
+
+	a := c.pool.Get()
+	defer c.pool.Put(a)
+
+	return a.DecryptCiphertext(ct)
+}
+
+// Transport returns a RoundTripper that encrypts request bodies and decrypts response bodies.
+// base defaults to http.DefaultTransport. A response with a body that isn't encrypted fails with
+// ErrNotEncrypted.
+func (c *Codec) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{c: c, base: base}
+}
+
+type transport struct {
+	c    *Codec
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not change the request it was given
+	req = req.Clone(req.Context())
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := readBody(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		sealed, err := t.c.Seal(body)
+		if err != nil {
+			return nil, err
+		}
+		setBody(req.Header, sealed)
+		req.ContentLength = int64(len(sealed))
+		req.Body = io.NopCloser(bytes.NewReader(sealed))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(sealed)), nil
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		resp.Body = http.NoBody
+		return resp, nil
+	}
+	if resp.Header.Get("Content-Encoding") != Encoding {
+		return nil, fmt.Errorf("%w: response %s", ErrNotEncrypted, resp.Status)
+	}
+
+	opened, err := t.c.Open(body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Set("Content-Length", strconv.Itoa(len(opened)))
+	resp.ContentLength = int64(len(opened))
+	resp.Body = io.NopCloser(bytes.NewReader(opened))
+
+	return resp, nil
+}
+
+// Handler decrypts request bodies before next sees them and encrypts what next writes. Requests
+// with a body that isn't encrypted get 415 Unsupported Media Type, ones that don't decrypt 400.
+// The response is buffered until next returns.
+func (c *Codec) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(r.Body)
+		if err == ErrTooLarge {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(body) > 0 {
+			if r.Header.Get("Content-Encoding") != Encoding {
+				http.Error(w, ErrNotEncrypted.Error(), http.StatusUnsupportedMediaType)
+				return
+			}
+			if body, err = c.Open(body); err != nil {
+				http.Error(w, "Invalid encrypted body", http.StatusBadRequest)
+				return
+			}
+			r.Header.Del("Content-Encoding")
+		}
+		r.ContentLength = int64(len(body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &recorder{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		if rec.body.Len() > 0 {
+			sealed, err := c.Seal(rec.body.Bytes())
+			if err != nil {
+				http.Error(w, "Encryption failed", http.StatusInternalServerError)
+				return
+			}
+			setBody(w.Header(), sealed)
+			w.WriteHeader(rec.status)
+			w.Write(sealed)
+			return
+		}
+		w.WriteHeader(rec.status)
+	})
+}
+
+// recorder keeps the response of the wrapped handler so it can be encrypted in one piece.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wrote {
+		return
+	}
+	r.wrote = true
+	r.status = status
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wrote = true
+	if r.body.Len()+len(b) > MaxBodySize {
+		return 0, ErrTooLarge
+	}
+	return r.body.Write(b)
+}
+
+func setBody(h http.Header, sealed []byte) {
+	h.Set("Content-Encoding", Encoding)
+	h.Set("Content-Length", strconv.Itoa(len(sealed)))
+}
+
+func readBody(body io.ReadCloser) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	defer body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(body, MaxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > MaxBodySize {
+		return nil, ErrTooLarge
+	}
+	return b, nil
+}
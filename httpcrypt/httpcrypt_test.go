@@ -0,0 +1,140 @@
+package httpcrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// echo answers with the request body in upper case, and what it saw of the encoding.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Seen-Encoding", r.Header.Get("Content-Encoding"))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(bytes.ToUpper(body))
+})
+
+func TestRoundTrip(t *testing.T) {
+	c, _ := New(key.Bit128())
+
+	// what goes over the wire
+	var wire []byte
+	spy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wire, _ = io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(wire))
+		c.Handler(echo).ServeHTTP(w, r)
+	})
+	server := httptest.NewServer(spy)
+	defer server.Close()
+
+	client := &http.Client{Transport: c.Transport(nil)}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("attack at dawn"))
+	if err != nil {
+		t.Fatalf("Error posting: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "ATTACK AT DAWN" {
+		t.Errorf("Got: %s, Expected: %s", body, "ATTACK AT DAWN")
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("Expected 201 text/plain, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Seen-Encoding") != "" {
+		t.Errorf("Expected the handler to see a plain body, got %s", resp.Header.Get("Seen-Encoding"))
+	}
+	if bytes.Contains(wire, []byte("attack")) {
+		t.Errorf("Expected the request to be encrypted, got %q", wire)
+	}
+
+	// no body at all
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Error getting: %s", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHandlerErrors(t *testing.T) {
+	c, _ := New(key.Bit128())
+	other, _ := New(key.Bit128())
+	sealed, _ := other.Seal([]byte("wrong key"))
+
+	tests := []struct {
+		name string
+
+		body     []byte
+		encoding string
+
+		expected int
+	}{
+		{
+			name: "plaintext",
+
+			body: []byte("plain"),
+
+			expected: http.StatusUnsupportedMediaType,
+		},
+		{
+			name: "wrong key",
+
+			body:     sealed,
+			encoding: Encoding,
+
+			expected: http.StatusBadRequest,
+		},
+		{
+			name: "too large",
+
+			body:     make([]byte, MaxBodySize+1),
+			encoding: Encoding,
+
+			expected: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			r.Header.Set("Content-Encoding", test.encoding)
+			w := httptest.NewRecorder()
+
+			c.Handler(echo).ServeHTTP(w, r)
+			if w.Code != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestTransportRejectsPlainResponses(t *testing.T) {
+	c, _ := New(key.Bit128())
+	server := httptest.NewServer(echo)
+	defer server.Close()
+
+	client := &http.Client{Transport: c.Transport(nil)}
+	_, err := client.Post(server.URL, "text/plain", strings.NewReader("hello"))
+	if !errors.Is(err, ErrNotEncrypted) {
+		t.Errorf("Expected %v, got %v", ErrNotEncrypted, err)
+	}
+}
+
+func TestOpenRejectsOtherModes(t *testing.T) {
+	c, _ := New(key.Bit128())
+	sealed, _ := c.Seal([]byte("body"))
+
+	// the mode byte of the envelope, after the magic and the version
+	sealed[3] = byte(aesgo.CTR)
+	if _, err := c.Open(sealed); err != aesgo.ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", aesgo.ErrInvalidMode, err)
+	}
+}
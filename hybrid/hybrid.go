@@ -0,0 +1,213 @@
+// Package hybrid encrypts payloads to an RSA public key. RSA can only encrypt a few hundred bytes,
+// so the payload is encrypted with a random AES data key and only the data key is encrypted with
+// RSA-OAEP (SHA-256), the same idea as the envelope package with a public key as the KEK.
+//
+// The output is one envelope:
+//
+//	magic "AGRH" (4 bytes) | version (1 byte) | mode (1 byte) | key ID (8 bytes) |
+//	uint16 len(wrapped key) | wrapped key | payload
+//
+// The payload is AES-256-GCM (nonce || ciphertext || tag) with the header as additional data, or
+// AES-CTR with HMAC-SHA-256 from the etm package. The key ID is the start of the SHA-256 of the
+// public key, so Decrypt can tell a message for another key apart from a corrupted one. The mode
+// is the OAEP label, a data key wrapped for GCM doesn't unwrap as a CTR one.
+//
+// EncryptX25519 and DecryptX25519 do the same with an ephemeral X25519 key instead of RSA, for
+// smaller envelopes.
+//
+// Anyone with the public key can encrypt, there is no sender authentication. Sign the envelope if
+// the recipient needs to know who sent it.
+package hybrid
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/etm"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const Version = 1
+
+// MinKeySize is the smallest RSA modulus accepted, in bits.
+const MinKeySize = 2048
+
+var magic = [4]byte{'A', 'G', 'R', 'H'}
+
+var (
+	ErrInvalidEnvelope = errors.New("Invalid hybrid envelope")
+	ErrKeyTooSmall     = errors.New("RSA key must have at least 2048 bits")
+	ErrWrongKey        = errors.New("Envelope was encrypted to another public key")
+	ErrInvalidMode     = errors.New("Hybrid encryption only supports GCM and CTR")
+)
+
+type Option func(*options)
+
+type options struct {
+	mode aesgo.Mode
+	rand io.Reader
+}
+
+// WithMode picks aesgo.GCM (the default) or aesgo.CTR, which is CTR with HMAC-SHA-256.
+func WithMode(mode aesgo.Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithRandReader sets where the data key, the nonce and the OAEP seed come from. Defaults to
+// crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// KeyID identifies a public key, it is the first 8 bytes of the SHA-256 of its PKIX encoding.
+func KeyID(pub *rsa.PublicKey) ([8]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return [8]byte{}, err
+	}
+	sum := sha256.Sum256(der)
+	return [8]byte(sum[:8]), nil
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped for pub.
+func Encrypt(pub *rsa.PublicKey, plaintext []byte, opts ...Option) ([]byte, error) {
+	o := options{mode: aesgo.GCM, rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	label, err := oaepLabel(o.mode)
+	if err != nil {
+		return nil, err
+	}
+	if pub.N.BitLen() < MinKeySize {
+		return nil, ErrKeyTooSmall
+	}
+	id, err := KeyID(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := key.Random(32, key.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+	defer dek.Destroy()
+
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), o.rand, pub, dek.GetBytes(), label)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, magic[:]...)
+	header = append(header, Version, byte(o.mode))
+	header = append(header, id[:]...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	payload, err := seal(o, dek, header, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, payload...), nil
+}
+
+func seal(o options, dek key.Key, header, plaintext []byte) ([]byte, error) {
+	if o.mode == aesgo.CTR {
+		e, err := etm.New(dek, aesgo.CTR)
+		if err != nil {
+			return nil, err
+		}
+		defer e.Destroy()
+		return e.Seal(plaintext)
+	}
+
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aesgo.GCMNonceSize)
+	if _, err := io.ReadFull(o.rand, nonce); err != nil {
+		return nil, err
+	}
+	sealed, err := a.SealGCM(nonce, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, sealed...), nil
+}
+
+// Decrypt unwraps the data key with priv and decrypts the payload.
+func Decrypt(priv *rsa.PrivateKey, envelope []byte) ([]byte, error) {
+	if len(envelope) < 16 || [4]byte(envelope[:4]) != magic || envelope[4] != Version {
+		return nil, ErrInvalidEnvelope
+	}
+
+	mode := aesgo.Mode(envelope[5])
+	label, err := oaepLabel(mode)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+
+	id, err := KeyID(&priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(envelope[6:14], id[:]) {
+		return nil, fmt.Errorf("%w: %x", ErrWrongKey, envelope[6:14])
+	}
+
+	l := int(binary.BigEndian.Uint16(envelope[14:16]))
+	if len(envelope) < 16+l {
+		return nil, ErrInvalidEnvelope
+	}
+	header, payload := envelope[:16+l], envelope[16+l:]
+
+	material, err := rsa.DecryptOAEP(sha256.New(), nil, priv, header[16:], label)
+	if err != nil || len(material) != 32 {
+		return nil, ErrInvalidEnvelope
+	}
+	dek := key.NewKey256([32]byte(material))
+	defer dek.Destroy()
+	clear(material)
+
+	if mode == aesgo.CTR {
+		e, err := etm.New(dek, aesgo.CTR)
+		if err != nil {
+			return nil, err
+		}
+		defer e.Destroy()
+		return e.Open(payload)
+	}
+
+	if len(payload) < aesgo.GCMNonceSize {
+		return nil, aesgo.ErrTruncatedCiphertext
+	}
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	return a.OpenGCM(payload[:aesgo.GCMNonceSize], payload[aesgo.GCMNonceSize:], header)
+}
+
+func oaepLabel(mode aesgo.Mode) ([]byte, error) {
+	switch mode {
+	case aesgo.GCM:
+		return []byte("aes-go hybrid gcm"), nil
+	case aesgo.CTR:
+		return []byte("aes-go hybrid ctr-hmac"), nil
+	}
+	return nil, ErrInvalidMode
+}
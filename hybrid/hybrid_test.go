@@ -0,0 +1,146 @@
+package hybrid
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"sync"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/etm"
+)
+
+var (
+	keysOnce sync.Once
+	alice    *rsa.PrivateKey
+	bob      *rsa.PrivateKey
+)
+
+// rsaKeys generates the keys once, 2048 bit keys are slow to generate.
+func rsaKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	keysOnce.Do(func() {
+		alice, _ = rsa.GenerateKey(rand.Reader, 2048)
+		bob, _ = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if alice == nil || bob == nil {
+		t.Fatalf("Error generating RSA keys")
+	}
+	return alice, bob
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	alice, _ := rsaKeys(t)
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, mode := range []aesgo.Mode{aesgo.GCM, aesgo.CTR} {
+		envelope, err := Encrypt(&alice.PublicKey, plaintext, WithMode(mode))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		if envelope[5] != byte(mode) || bytes.Contains(envelope, plaintext) {
+			t.Errorf("Mode %d. Unexpected envelope %x", mode, envelope)
+		}
+
+		decrypted, err := Decrypt(alice, envelope)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	alice, bob := rsaKeys(t)
+	gcm, _ := Encrypt(&alice.PublicKey, []byte("payload"))
+	ctr, _ := Encrypt(&alice.PublicKey, []byte("payload"), WithMode(aesgo.CTR))
+
+	// modify returns a copy of envelope with fn applied
+	modify := func(envelope []byte, fn func(b []byte)) []byte {
+		b := append([]byte{}, envelope...)
+		fn(b)
+		return b
+	}
+
+	tests := []struct {
+		name     string
+		priv     *rsa.PrivateKey
+		envelope []byte
+
+		expected error
+	}{
+		{
+			name:     "another key",
+			priv:     bob,
+			envelope: gcm,
+
+			expected: ErrWrongKey,
+		},
+		{
+			name:     "tampered payload",
+			priv:     alice,
+			envelope: modify(gcm, func(b []byte) { b[len(b)-20] ^= 1 }),
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name:     "tampered ctr payload",
+			priv:     alice,
+			envelope: modify(ctr, func(b []byte) { b[len(b)-40] ^= 1 }),
+
+			expected: etm.ErrAuthentication,
+		},
+		{
+			name:     "mode relabelled",
+			priv:     alice,
+			envelope: modify(gcm, func(b []byte) { b[5] = byte(aesgo.CTR) }),
+
+			expected: ErrInvalidEnvelope,
+		},
+		{
+			name:     "tampered wrapped key",
+			priv:     alice,
+			envelope: modify(gcm, func(b []byte) { b[20] ^= 1 }),
+
+			expected: ErrInvalidEnvelope,
+		},
+		{
+			name:     "truncated",
+			priv:     alice,
+			envelope: gcm[:100],
+
+			expected: ErrInvalidEnvelope,
+		},
+		{
+			name:     "not an envelope",
+			priv:     alice,
+			envelope: []byte("AGRX and some more bytes"),
+
+			expected: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decrypt(test.priv, test.envelope); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestEncryptErrors(t *testing.T) {
+	alice, _ := rsaKeys(t)
+
+	if _, err := Encrypt(&alice.PublicKey, nil, WithMode(aesgo.CBC)); err != ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", ErrInvalidMode, err)
+	}
+
+	small, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err := Encrypt(&small.PublicKey, nil); err != ErrKeyTooSmall {
+		t.Errorf("Expected %v, got %v", ErrKeyTooSmall, err)
+	}
+}
@@ -0,0 +1,114 @@
+package hybrid
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var magicX25519 = [4]byte{'A', 'G', 'R', 'X'}
+
+const x25519HeaderSize = 4 + 1 + 32
+
+var ErrInvalidPublicKey = errors.New("Public key must be X25519")
+
+// EncryptX25519 encrypts plaintext to an X25519 public key, the KEM/DEM split of HPKE without its
+// modes and suites. Every message has a new ephemeral key, its Diffie-Hellman with pub goes through
+// HKDF-SHA256, salted with both public keys, and gives the AES-256 key and the GCM nonce:
+//
+//	magic "AGRX" (4 bytes) | version (1 byte) | ephemeral public key (32 bytes) | ciphertext | tag
+//
+// That is 53 bytes on top of the plaintext, against more than 270 with RSA-2048. The header is the
+// additional data. The ephemeral key is thrown away, so a leaked sender key reveals nothing, but
+// the recipient key still opens every message sent to it, rotate it to limit that.
+//
+// Only GCM is supported, WithRandReader is where the ephemeral key comes from.
+func EncryptX25519(pub *ecdh.PublicKey, plaintext []byte, opts ...Option) ([]byte, error) {
+	o := options{mode: aesgo.GCM, rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.mode != aesgo.GCM {
+		return nil, ErrInvalidMode
+	}
+	if pub.Curve() != ecdh.X25519() {
+		return nil, ErrInvalidPublicKey
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(o.rand)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, magicX25519[:]...)
+	header = append(header, Version)
+	header = append(header, ephemeral.PublicKey().Bytes()...)
+
+	a, nonce, err := x25519Cipher(shared, ephemeral.PublicKey().Bytes(), pub.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer a.Destroy()
+
+	sealed, err := a.SealGCM(nonce, plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+	return append(header, sealed...), nil
+}
+
+// DecryptX25519 decrypts an envelope of EncryptX25519.
+func DecryptX25519(priv *ecdh.PrivateKey, envelope []byte) ([]byte, error) {
+	if priv.Curve() != ecdh.X25519() {
+		return nil, ErrInvalidPublicKey
+	}
+	if len(envelope) < x25519HeaderSize+aesgo.GCMTagSize || [4]byte(envelope[:4]) != magicX25519 || envelope[4] != Version {
+		return nil, ErrInvalidEnvelope
+	}
+	header := envelope[:x25519HeaderSize]
+
+	ephemeral, err := ecdh.X25519().NewPublicKey(header[5:])
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+	// fails for low order points, which would give a shared secret known to everyone
+	shared, err := priv.ECDH(ephemeral)
+	if err != nil {
+		return nil, ErrInvalidEnvelope
+	}
+
+	a, nonce, err := x25519Cipher(shared, ephemeral.Bytes(), priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer a.Destroy()
+
+	return a.OpenGCM(nonce, envelope[x25519HeaderSize:], header)
+}
+
+// x25519Cipher derives the key and the nonce. Both public keys are in the salt so the key is bound
+// to this exchange, as in the HPKE KEM.
+func x25519Cipher(shared, ephemeral, recipient []byte) (*aesgo.AES, []byte, error) {
+	defer clear(shared)
+
+	salt := append(append([]byte{}, ephemeral...), recipient...)
+	okm, err := key.HKDF(sha256.New, shared, salt, []byte("aes-go hybrid x25519"), 32+aesgo.GCMNonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer clear(okm[:32])
+
+	a, err := aesgo.NewCipher(key.NewKey256([32]byte(okm)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, okm[32:], nil
+}
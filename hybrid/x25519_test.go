@@ -0,0 +1,122 @@
+package hybrid
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestX25519EncryptDecrypt(t *testing.T) {
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	plaintext := []byte("Let's test if this is working!")
+
+	envelope, err := EncryptX25519(alice.PublicKey(), plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if len(envelope) != len(plaintext)+x25519HeaderSize+aesgo.GCMTagSize || bytes.Contains(envelope, plaintext) {
+		t.Errorf("Unexpected envelope %x", envelope)
+	}
+
+	decrypted, err := DecryptX25519(alice, envelope)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+	}
+
+	// a new ephemeral key every time
+	again, _ := EncryptX25519(alice.PublicKey(), plaintext)
+	if bytes.Equal(envelope[:x25519HeaderSize], again[:x25519HeaderSize]) {
+		t.Errorf("Expected a different ephemeral key, got %x twice", envelope[5:x25519HeaderSize])
+	}
+}
+
+func TestX25519DecryptErrors(t *testing.T) {
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	bob, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	envelope, _ := EncryptX25519(alice.PublicKey(), []byte("payload"))
+
+	modify := func(fn func(b []byte)) []byte {
+		b := append([]byte{}, envelope...)
+		fn(b)
+		return b
+	}
+
+	tests := []struct {
+		name     string
+		priv     *ecdh.PrivateKey
+		envelope []byte
+
+		expected error
+	}{
+		{
+			name:     "another key",
+			priv:     bob,
+			envelope: envelope,
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name:     "tampered payload",
+			priv:     alice,
+			envelope: modify(func(b []byte) { b[len(b)-20] ^= 1 }),
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name:     "tampered ephemeral key",
+			priv:     alice,
+			envelope: modify(func(b []byte) { b[10] ^= 1 }),
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name:     "low order ephemeral key",
+			priv:     alice,
+			envelope: modify(func(b []byte) { clear(b[5:x25519HeaderSize]) }),
+
+			expected: ErrInvalidEnvelope,
+		},
+		{
+			name:     "truncated",
+			priv:     alice,
+			envelope: envelope[:x25519HeaderSize+aesgo.GCMTagSize-1],
+
+			expected: ErrInvalidEnvelope,
+		},
+		{
+			name:     "rsa envelope",
+			priv:     alice,
+			envelope: modify(func(b []byte) { b[3] = 'H' }),
+
+			expected: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := DecryptX25519(test.priv, test.envelope); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestX25519EncryptErrors(t *testing.T) {
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+
+	if _, err := EncryptX25519(alice.PublicKey(), nil, WithMode(aesgo.CTR)); err != ErrInvalidMode {
+		t.Errorf("Expected %v, got %v", ErrInvalidMode, err)
+	}
+
+	p256, _ := ecdh.P256().GenerateKey(rand.Reader)
+	if _, err := EncryptX25519(p256.PublicKey(), nil); err != ErrInvalidPublicKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidPublicKey, err)
+	}
+}
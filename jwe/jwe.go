@@ -0,0 +1,99 @@
+// Package jwe is the A128CBC-HS256 content encryption algorithm of JWE (RFC 7518, section 5.2.3).
+//
+// It is AES-128-CBC followed by HMAC-SHA-256, like etm, but with the exact layout of the RFC so
+// the output can be used by JOSE libraries:
+//
+//   - the 32 byte key is split: the first half is the MAC key, the second half the AES key
+//   - the MAC covers AAD || IV || ciphertext || AL, where AL is the AAD length in bits (64 bit big endian)
+//   - the tag is the first 16 bytes of the HMAC
+//
+// In a JWE the AAD is the ASCII of the encoded protected header. Building and parsing the compact
+// serialization is left to the caller.
+package jwe
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	KeySize = 32
+	IVSize  = 16
+	TagSize = 16
+)
+
+var (
+	ErrInvalidKeySize = errors.New("A128CBC-HS256 key must have 32 bytes")
+	ErrInvalidIV      = errors.New("A128CBC-HS256 IV must have 16 bytes")
+	ErrAuthentication = errors.New("Message authentication failed")
+)
+
+// Encrypt returns the JWE ciphertext and authentication tag. iv must be random and never reused,
+// crypto/rand is the place to get it.
+func Encrypt(k, iv, plaintext, aad []byte) ([]byte, []byte, error) {
+	macKey, a, err := split(k, iv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encrypted, err := a.Encrypt(aesgo.CBC, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	// aesgo puts the IV in front, JWE keeps it in its own field
+	ciphertext := encrypted[IVSize:]
+
+	return ciphertext, tag(macKey, aad, iv, ciphertext), nil
+}
+
+// Decrypt checks the tag in constant time and only then decrypts.
+func Decrypt(k, iv, ciphertext, aad, authTag []byte) ([]byte, error) {
+	macKey, a, err := split(k, iv)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hmac.Equal(authTag, tag(macKey, aad, iv, ciphertext)) {
+		return nil, ErrAuthentication
+	}
+
+	return a.Decrypt(aesgo.CBC, append(append([]byte{}, iv...), ciphertext...))
+}
+
+// split returns MAC_KEY and AES with ENC_KEY. The IV is given to aesgo as its random source,
+// so Encrypt uses it instead of generating one.
+func split(k, iv []byte) ([]byte, *aesgo.AES, error) {
+	if len(k) != KeySize {
+		return nil, nil, ErrInvalidKeySize
+	}
+	if len(iv) != IVSize {
+		return nil, nil, ErrInvalidIV
+	}
+
+	encKey := key.NewKey([16]byte(k[16:]))
+	a, err := aesgo.NewCipher(encKey, aesgo.WithMode(aesgo.CBC), aesgo.WithRandReader(bytes.NewReader(iv)))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return k[:16], a, nil
+}
+
+func tag(macKey, aad, iv, ciphertext []byte) []byte {
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(aad))*8)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(aad)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(al[:])
+
+	return mac.Sum(nil)[:TagSize]
+}
@@ -0,0 +1,124 @@
+package jwe
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// RFC 7518, appendix B.1
+var (
+	rfcKey        = decode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	rfcIV         = decode("1af38c2dc2b96ffdd86694092341bc04")
+	rfcPlaintext  = []byte("A cipher system must not be required to be secret, and it must be able to fall into the hands of the enemy without inconvenience")
+	rfcAAD        = []byte("The second principle of Auguste Kerckhoffs")
+	rfcCiphertext = decode(strings.Join([]string{
+		"c80edfa32ddf39d5ef00c0b468834279a2e46a1b8049f792f76bfe54b903a9c9",
+		"a94ac9b47ad2655c5f10f9aef71427e2fc6f9b3f399a221489f16362c7032336",
+		"09d45ac69864e3321cf82935ac4096c86e133314c54019e8ca7980dfa4b9cf1b",
+		"384c486f3a54c51078158ee5d79de59fbd34d848b3d69550a67646344427ade5",
+		"4b8851ffb598f7f80074b9473c82e2db",
+	}, ""))
+	rfcTag = decode("652c3fa36b0a7c5b3219fab3a30bc1c4")
+)
+
+func TestRFCVector(t *testing.T) {
+	ciphertext, tag, err := Encrypt(rfcKey, rfcIV, rfcPlaintext, rfcAAD)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if !bytes.Equal(ciphertext, rfcCiphertext) {
+		t.Errorf("Got: %x, Expected: %x", ciphertext, rfcCiphertext)
+	}
+	if !bytes.Equal(tag, rfcTag) {
+		t.Errorf("Got: %x, Expected: %x", tag, rfcTag)
+	}
+
+	plaintext, err := Decrypt(rfcKey, rfcIV, rfcCiphertext, rfcAAD, rfcTag)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if !bytes.Equal(plaintext, rfcPlaintext) {
+		t.Errorf("Got: %s, Expected: %s", plaintext, rfcPlaintext)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	flip := func(b []byte, i int) []byte {
+		c := append([]byte{}, b...)
+		c[i] ^= 1
+		return c
+	}
+
+	tests := []struct {
+		name string
+
+		key        []byte
+		iv         []byte
+		ciphertext []byte
+		aad        []byte
+		tag        []byte
+
+		expected error
+	}{
+		{
+			name: "tampered ciphertext",
+
+			key: rfcKey, iv: rfcIV, ciphertext: flip(rfcCiphertext, 0), aad: rfcAAD, tag: rfcTag,
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "tampered aad",
+
+			key: rfcKey, iv: rfcIV, ciphertext: rfcCiphertext, aad: flip(rfcAAD, 0), tag: rfcTag,
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "tampered iv",
+
+			key: rfcKey, iv: flip(rfcIV, 15), ciphertext: rfcCiphertext, aad: rfcAAD, tag: rfcTag,
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "truncated tag",
+
+			key: rfcKey, iv: rfcIV, ciphertext: rfcCiphertext, aad: rfcAAD, tag: rfcTag[:15],
+
+			expected: ErrAuthentication,
+		},
+		{
+			name: "short key",
+
+			key: rfcKey[:16], iv: rfcIV, ciphertext: rfcCiphertext, aad: rfcAAD, tag: rfcTag,
+
+			expected: ErrInvalidKeySize,
+		},
+		{
+			name: "short iv",
+
+			key: rfcKey, iv: rfcIV[:12], ciphertext: rfcCiphertext, aad: rfcAAD, tag: rfcTag,
+
+			expected: ErrInvalidIV,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decrypt(test.key, test.iv, test.ciphertext, test.aad, test.tag); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func decode(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,70 @@
+// Package kbkdf derives keys from a master key with the counter mode KDF of NIST SP 800-108r1,
+// using AES-CMAC as the PRF: https://nvlpubs.nist.gov/nistpubs/SpecialPublications/NIST.SP.800-108r1-upd1.pdf
+//
+// Unlike HKDF it needs nothing but AES. Every block of output is
+//
+//	CMAC(master, [i]_32 || Label || 0x00 || Context || [L]_32)
+//
+// where i counts from 1 and L is the output length in bits. Label says what the key is for and
+// Context ties it to a session, so the same master key gives unrelated keys for each purpose.
+// It is the same as "openssl kdf ... -kdfopt mac:CMAC KBKDF" with salt as the label and info as
+// the context.
+package kbkdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/mario-areias/aes-go/cmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var ErrInvalidLength = errors.New("Invalid length for KBKDF output")
+
+// Derive returns length bytes derived from master.
+func Derive(master key.Key, label, context []byte, length int) ([]byte, error) {
+	// L is encoded in 32 bits and the counter can't wrap
+	if length <= 0 || uint64(length)*8 > math.MaxUint32 {
+		return nil, ErrInvalidLength
+	}
+
+	prf, err := cmac.New(master)
+	if err != nil {
+		return nil, err
+	}
+
+	fixed := make([]byte, 0, len(label)+1+len(context)+4)
+	fixed = append(fixed, label...)
+	fixed = append(fixed, 0x00)
+	fixed = append(fixed, context...)
+	fixed = binary.BigEndian.AppendUint32(fixed, uint32(length*8))
+
+	out := make([]byte, 0, length+cmac.Size)
+	for i := uint32(1); len(out) < length; i++ {
+		prf.Reset()
+		prf.Write(binary.BigEndian.AppendUint32(nil, i))
+		prf.Write(fixed)
+		out = prf.Sum(out)
+	}
+
+	return out[:length], nil
+}
+
+// DeriveKey derives an AES key of size bytes, 16 or 32.
+func DeriveKey(master key.Key, label, context []byte, size int) (key.Key, error) {
+	if size != 16 && size != 32 {
+		return nil, key.ErrInvalidKeySize
+	}
+
+	material, err := Derive(master, label, context, size)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(material)
+
+	if size == 16 {
+		return key.NewKey([16]byte(material)), nil
+	}
+	return key.NewKey256([32]byte(material)), nil
+}
@@ -0,0 +1,113 @@
+package kbkdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// The expected values come from
+// openssl kdf -keylen <length> -kdfopt mac:CMAC -kdfopt cipher:<cipher> -kdfopt hexkey:<master> -kdfopt salt:<label> -kdfopt info:<context> KBKDF
+func TestDerive(t *testing.T) {
+	tests := []struct {
+		name string
+
+		master  string
+		label   string
+		context string
+		length  int
+
+		expected string
+	}{
+		{
+			name: "AES-128, three blocks",
+
+			master:  "000102030405060708090a0b0c0d0e0f",
+			label:   "encryption",
+			context: "session-42",
+			length:  40,
+
+			expected: "ff92ce8b5a1b0b39f7fc052e7bdaf819c11cfe38d4f3489391eebe757095ee4709a57bbe49ab1cd2",
+		},
+		{
+			name: "AES-128, one block",
+
+			master:  "000102030405060708090a0b0c0d0e0f",
+			label:   "encryption",
+			context: "session-42",
+			length:  16,
+
+			expected: "bbcc6877aad2cf61563dd1773fd30141",
+		},
+		{
+			name: "AES-256",
+
+			master:  "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			label:   "mac",
+			context: "ctx",
+			length:  32,
+
+			expected: "a34f3d6813d44407c4359381ae0a78ecd8803afffd53c371788d88b0691f54b0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			master, _ := hex.DecodeString(test.master)
+			var k key.Key
+			if len(master) == 16 {
+				k = key.NewKey([16]byte(master))
+			} else {
+				k = key.NewKey256([32]byte(master))
+			}
+
+			out, err := Derive(k, []byte(test.label), []byte(test.context), test.length)
+			if err != nil {
+				t.Fatalf("Error deriving: %s", err)
+			}
+			if got := hex.EncodeToString(out); got != test.expected {
+				t.Errorf("Got: %s, Expected: %s", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	master := key.Bit128()
+
+	enc, err := DeriveKey(master, []byte("encryption"), []byte("session"), 16)
+	if err != nil {
+		t.Fatalf("Error deriving: %s", err)
+	}
+	mac, _ := DeriveKey(master, []byte("mac"), []byte("session"), 32)
+	other, _ := DeriveKey(master, []byte("encryption"), []byte("another session"), 16)
+
+	if mac.Len() != 32 {
+		t.Errorf("Expected %v, got %v", 32, mac.Len())
+	}
+	if bytes.Equal(enc.GetBytes(), other.GetBytes()) || bytes.Equal(enc.GetBytes(), mac.GetBytes()[:16]) {
+		t.Errorf("Different labels and contexts must give different keys")
+	}
+
+	again, _ := DeriveKey(master, []byte("encryption"), []byte("session"), 16)
+	if !bytes.Equal(enc.GetBytes(), again.GetBytes()) {
+		t.Errorf("Got: %x, Expected: %x", again.GetBytes(), enc.GetBytes())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := Derive(key.Bit128(), nil, nil, 0); err != ErrInvalidLength {
+		t.Errorf("Expected %v, got %v", ErrInvalidLength, err)
+	}
+	if _, err := DeriveKey(key.Bit128(), nil, nil, 24); err != key.ErrInvalidKeySize {
+		t.Errorf("Expected %v, got %v", key.ErrInvalidKeySize, err)
+	}
+
+	destroyed := key.Bit128()
+	destroyed.Destroy()
+	if _, err := Derive(destroyed, nil, nil, 16); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
@@ -0,0 +1,50 @@
+package key
+
+import (
+	"errors"
+	"hash"
+
+	"github.com/mario-areias/aes-go/hmac"
+)
+
+var ErrHKDFLength = errors.New("HKDF can't derive more than 255 times the hash size")
+
+// HKDF derives keyLen bytes from secret (RFC 5869). It is meant for secrets that are already random,
+// like a master key, not for passphrases (use FromPassphrase for those).
+// info binds the output to its purpose, so different infos give independent keys.
+func HKDF(h func() hash.Hash, secret, salt, info []byte, keyLen int) ([]byte, error) {
+	prk := HKDFExtract(h, secret, salt)
+	return HKDFExpand(h, prk, info, keyLen)
+}
+
+// HKDFExtract is the first step: PRK = HMAC(salt, secret). An empty salt is a salt of zeros.
+func HKDFExtract(h func() hash.Hash, secret, salt []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, h().Size())
+	}
+	mac := hmac.New(h, salt)
+	mac.Write(secret)
+	return mac.Sum(nil)
+}
+
+// HKDFExpand is the second step: T(i) = HMAC(PRK, T(i-1) || info || i), the output is T(1) || T(2) || ...
+func HKDFExpand(h func() hash.Hash, prk, info []byte, keyLen int) ([]byte, error) {
+	mac := hmac.New(h, prk)
+	if keyLen > 255*mac.Size() {
+		return nil, ErrHKDFLength
+	}
+
+	okm := make([]byte, 0, keyLen+mac.Size())
+	var t []byte
+
+	for i := byte(1); len(okm) < keyLen; i++ {
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		t = mac.Sum(nil)
+		okm = append(okm, t...)
+	}
+
+	return okm[:keyLen], nil
+}
@@ -0,0 +1,76 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHKDF(t *testing.T) {
+	// Test vectors from RFC 5869, appendix A
+	tests := []struct {
+		name string
+
+		secret string
+		salt   string
+		info   string
+		length int
+
+		prk      string
+		expected string
+	}{
+		{
+			name: "basic test case",
+
+			secret: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+			salt:   "000102030405060708090a0b0c",
+			info:   "f0f1f2f3f4f5f6f7f8f9",
+			length: 42,
+
+			prk:      "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+			expected: "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		},
+		{
+			name: "zero length salt and info",
+
+			secret: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+			length: 42,
+
+			prk:      "19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+			expected: "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			secret, _ := hex.DecodeString(test.secret)
+			salt, _ := hex.DecodeString(test.salt)
+			info, _ := hex.DecodeString(test.info)
+
+			if prk := hex.EncodeToString(HKDFExtract(sha256.New, secret, salt)); prk != test.prk {
+				t.Errorf("Got: %s, Expected: %s", prk, test.prk)
+			}
+
+			output, err := HKDF(sha256.New, secret, salt, info, test.length)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+			if result := hex.EncodeToString(output); result != test.expected {
+				t.Errorf("Got: %s, Expected: %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestHKDFInfo(t *testing.T) {
+	a, _ := HKDF(sha256.New, []byte("master"), nil, []byte("encryption"), 16)
+	b, _ := HKDF(sha256.New, []byte("master"), nil, []byte("mac"), 16)
+	if bytes.Equal(a, b) {
+		t.Errorf("Expected different keys for different info")
+	}
+
+	if _, err := HKDF(sha256.New, []byte("master"), nil, nil, 255*32+1); err != ErrHKDFLength {
+		t.Errorf("Expected %v, got %v", ErrHKDFLength, err)
+	}
+}
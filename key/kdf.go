@@ -0,0 +1,203 @@
+package key
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// KDF identifies the function used to derive a key from a passphrase.
+type KDF byte
+
+const (
+	KDFPBKDF2 KDF = iota + 1
+	KDFScrypt
+)
+
+const (
+	DefaultPBKDF2Iterations = 600000
+
+	DefaultScryptN = 1 << 15
+	DefaultScryptR = 8
+	DefaultScryptP = 1
+
+	saltSize    = 16
+	minSaltSize = 8
+)
+
+// The params are read from ciphertext headers, so they are limited before anything is allocated
+// or computed: a crafted header must not take all the memory or hours of CPU.
+const (
+	MaxPBKDF2Iterations = 10000000
+
+	// MaxScryptMemory is the most 128 * N * r can be, in bytes.
+	MaxScryptMemory = 1 << 30
+	MaxScryptP      = 16
+)
+
+var ErrInvalidKDFParams = errors.New("Invalid KDF parameters")
+
+// KDFParams has everything needed to derive the same key again from a passphrase.
+// It is stored next to the ciphertext, so only the passphrase must be kept secret.
+//
+// The binary format is the same for every KDF:
+//
+//	kdf (1 byte) | len(salt) (1 byte) | salt | number of params (1 byte) | params (4 bytes each, big endian)
+//
+// PBKDF2 has one param (iterations), scrypt has three (N, r, p).
+//
+// There is no Argon2. It is built on BLAKE2b, which isn't in the standard library, and this module
+// has no dependencies. Its params (time, memory, threads) would fit the format as a new KDF.
+type KDFParams struct {
+	KDF  KDF
+	Salt []byte
+
+	// PBKDF2
+	Iterations int
+
+	// scrypt
+	N, R, P int
+}
+
+// NewPBKDF2Params returns PBKDF2-HMAC-SHA256 params with a random salt.
+func NewPBKDF2Params(opts ...Option) (KDFParams, error) {
+	salt, err := randomSalt(opts)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return KDFParams{KDF: KDFPBKDF2, Salt: salt, Iterations: DefaultPBKDF2Iterations}, nil
+}
+
+// NewScryptParams returns scrypt params with a random salt and the defaults recommended for interactive logins.
+func NewScryptParams(opts ...Option) (KDFParams, error) {
+	salt, err := randomSalt(opts)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return KDFParams{KDF: KDFScrypt, Salt: salt, N: DefaultScryptN, R: DefaultScryptR, P: DefaultScryptP}, nil
+}
+
+func randomSalt(opts []Option) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(newOptions(opts).rand, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func (p KDFParams) Validate() error {
+	if len(p.Salt) < minSaltSize || len(p.Salt) > 255 {
+		return ErrInvalidKDFParams
+	}
+
+	switch p.KDF {
+	case KDFPBKDF2:
+		if p.Iterations < 1 || p.Iterations > MaxPBKDF2Iterations {
+			return ErrInvalidKDFParams
+		}
+	case KDFScrypt:
+		// divided instead of multiplied so it can't overflow
+		if p.R > 0 && p.N > MaxScryptMemory/128/p.R || p.P > MaxScryptP {
+			return ErrInvalidKDFParams
+		}
+		if err := validateScrypt(p.N, p.R, p.P); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidKDFParams
+	}
+
+	return nil
+}
+
+func (p KDFParams) params() []int {
+	switch p.KDF {
+	case KDFPBKDF2:
+		return []int{p.Iterations}
+	case KDFScrypt:
+		return []int{p.N, p.R, p.P}
+	}
+	return nil
+}
+
+func (p KDFParams) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := p.params()
+
+	b := []byte{byte(p.KDF), byte(len(p.Salt))}
+	b = append(b, p.Salt...)
+	b = append(b, byte(len(params)))
+	for _, v := range params {
+		b = binary.BigEndian.AppendUint32(b, uint32(v))
+	}
+
+	return b, nil
+}
+
+func (p *KDFParams) UnmarshalBinary(b []byte) error {
+	if len(b) < 2 {
+		return ErrInvalidKDFParams
+	}
+
+	kdf := KDF(b[0])
+	saltLen := int(b[1])
+	b = b[2:]
+
+	if len(b) < saltLen+1 {
+		return ErrInvalidKDFParams
+	}
+	salt := append([]byte{}, b[:saltLen]...)
+	b = b[saltLen:]
+
+	n := int(b[0])
+	b = b[1:]
+	if len(b) != n*4 {
+		return ErrInvalidKDFParams
+	}
+
+	params := make([]int, n)
+	for i := range params {
+		params[i] = int(binary.BigEndian.Uint32(b[i*4:]))
+	}
+
+	r := KDFParams{KDF: kdf, Salt: salt}
+	switch {
+	case kdf == KDFPBKDF2 && n == 1:
+		r.Iterations = params[0]
+	case kdf == KDFScrypt && n == 3:
+		r.N, r.R, r.P = params[0], params[1], params[2]
+	default:
+		return ErrInvalidKDFParams
+	}
+
+	if err := r.Validate(); err != nil {
+		return err
+	}
+
+	*p = r
+	return nil
+}
+
+// FromPassphrase derives a 128 bit key from the passphrase using the given params.
+func FromPassphrase(passphrase []byte, p KDFParams) (Key, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	var material []byte
+	switch p.KDF {
+	case KDFPBKDF2:
+		material = PBKDF2(passphrase, p.Salt, p.Iterations, 16)
+	case KDFScrypt:
+		m, err := Scrypt(passphrase, p.Salt, p.N, p.R, p.P, 16)
+		if err != nil {
+			return nil, err
+		}
+		material = m
+	}
+
+	return NewKey([16]byte(material)), nil
+}
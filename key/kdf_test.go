@@ -0,0 +1,260 @@
+package key
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+)
+
+func TestScrypt(t *testing.T) {
+	// Test vectors from RFC 7914, section 12
+	tests := []struct {
+		name string
+
+		password string
+		salt     string
+		N, r, p  int
+
+		expected string
+	}{
+		{
+			name: "empty password",
+
+			N: 16, r: 1, p: 1,
+
+			expected: "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906",
+		},
+		{
+			name: "password with NaCl salt",
+
+			password: "password",
+			salt:     "NaCl",
+			N:        1024, r: 8, p: 16,
+
+			expected: "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := Scrypt([]byte(test.password), []byte(test.salt), test.N, test.r, test.p, 64)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if result := hex.EncodeToString(output); result != test.expected {
+				t.Errorf("Got: %s, Expected: %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256 from RFC 7914, section 11 and the usual RFC 6070 inputs
+	tests := []struct {
+		name string
+
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+
+		expected string
+	}{
+		{
+			name: "1 iteration",
+
+			password: "password", salt: "salt", iterations: 1, keyLen: 32,
+
+			expected: "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b",
+		},
+		{
+			name: "4096 iterations",
+
+			password: "password", salt: "salt", iterations: 4096, keyLen: 32,
+
+			expected: "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a",
+		},
+		{
+			name: "output longer than the hash",
+
+			password: "passwordPASSWORDpassword", salt: "saltSALTsaltSALTsaltSALTsaltSALTsalt", iterations: 4096, keyLen: 40,
+
+			expected: "348c89dbcbd32b2f32d814b8116e84cf2b17347ebc1800181c4e2a1fb8dd53e1c635518c7dac47e9",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := PBKDF2([]byte(test.password), []byte(test.salt), test.iterations, test.keyLen)
+			if result := hex.EncodeToString(output); result != test.expected {
+				t.Errorf("Got: %s, Expected: %s", result, test.expected)
+			}
+		})
+	}
+}
+
+func TestPBKDF2SHA1(t *testing.T) {
+	// RFC 6070
+	expected := map[int]string{
+		1:    "0c60c80f961f0e71f3a9b524af6012062fe037a6",
+		4096: "4b007901b765489abead49d926f721d065a429c1",
+	}
+
+	for iterations, e := range expected {
+		output := PBKDF2Hash(sha1.New, []byte("password"), []byte("salt"), iterations, 20)
+		if result := hex.EncodeToString(output); result != e {
+			t.Errorf("Got: %s, Expected: %s", result, e)
+		}
+	}
+}
+
+func TestKDFParams(t *testing.T) {
+	tests := []struct {
+		name string
+
+		params KDFParams
+		error  bool
+	}{
+		{
+			name: "pbkdf2",
+
+			params: KDFParams{KDF: KDFPBKDF2, Salt: []byte("saltsalt"), Iterations: 1000},
+		},
+		{
+			name: "scrypt",
+
+			params: KDFParams{KDF: KDFScrypt, Salt: []byte("saltsalt"), N: 1024, R: 8, P: 1},
+		},
+		{
+			name: "scrypt N not a power of 2",
+
+			params: KDFParams{KDF: KDFScrypt, Salt: []byte("saltsalt"), N: 1000, R: 8, P: 1},
+			error:  true,
+		},
+		{
+			name: "short salt",
+
+			params: KDFParams{KDF: KDFPBKDF2, Salt: []byte("salt"), Iterations: 1000},
+			error:  true,
+		},
+		{
+			name: "unknown kdf",
+
+			params: KDFParams{KDF: 42, Salt: []byte("saltsalt")},
+			error:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b, err := test.params.MarshalBinary()
+
+			switch {
+			case test.error && err == nil:
+				t.Fatalf("Expected error, got nil")
+			case !test.error && err != nil:
+				t.Fatalf("Expected nil, got %v", err)
+			case test.error:
+				return
+			}
+
+			var parsed KDFParams
+			if err := parsed.UnmarshalBinary(b); err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			k1, err := FromPassphrase([]byte("correct horse"), test.params)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			k2, err := FromPassphrase([]byte("correct horse"), parsed)
+			if err != nil {
+				t.Fatalf("Expected nil, got %v", err)
+			}
+
+			if !bytes.Equal(k1.GetBytes(), k2.GetBytes()) {
+				t.Errorf("Keys derived from the serialized params differ")
+			}
+		})
+	}
+}
+
+func TestKDFParamsUnmarshalInvalid(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{byte(KDFScrypt), 8},
+		{byte(KDFPBKDF2), 8, 's', 'a', 'l', 't', 's', 'a', 'l', 't', 1, 0, 0},
+		{byte(KDFScrypt), 8, 's', 'a', 'l', 't', 's', 'a', 'l', 't', 1, 0, 0, 4, 0},
+	}
+
+	for _, input := range inputs {
+		var p KDFParams
+		if err := p.UnmarshalBinary(input); err == nil {
+			t.Errorf("Expected error for %02x, got nil", input)
+		}
+	}
+}
+
+func TestKDFParamsLimits(t *testing.T) {
+	salt := []byte("saltsalt")
+
+	tests := []struct {
+		name string
+
+		params KDFParams
+	}{
+		{
+			name: "too many iterations",
+
+			params: KDFParams{KDF: KDFPBKDF2, Salt: salt, Iterations: MaxPBKDF2Iterations + 1},
+		},
+		{
+			name: "scrypt N too big",
+
+			params: KDFParams{KDF: KDFScrypt, Salt: salt, N: 1 << 30, R: 8, P: 1},
+		},
+		{
+			name: "scrypt r too big",
+
+			params: KDFParams{KDF: KDFScrypt, Salt: salt, N: 1 << 10, R: MaxScryptMemory, P: 1},
+		},
+		{
+			name: "scrypt p too big",
+
+			params: KDFParams{KDF: KDFScrypt, Salt: salt, N: 1 << 10, R: 8, P: MaxScryptP + 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.params.Validate(); err != ErrInvalidKDFParams {
+				t.Errorf("Expected %v, got %v", ErrInvalidKDFParams, err)
+			}
+
+			// a header is checked before anything is derived
+			b := []byte{byte(test.params.KDF), byte(len(salt))}
+			b = append(b, salt...)
+			b = append(b, byte(len(test.params.params())))
+			for _, v := range test.params.params() {
+				b = binary.BigEndian.AppendUint32(b, uint32(v))
+			}
+			var parsed KDFParams
+			if err := parsed.UnmarshalBinary(b); err != ErrInvalidKDFParams {
+				t.Errorf("Expected %v, got %v", ErrInvalidKDFParams, err)
+			}
+			if _, err := FromPassphrase([]byte("correct horse"), test.params); err != ErrInvalidKDFParams {
+				t.Errorf("Expected %v, got %v", ErrInvalidKDFParams, err)
+			}
+		})
+	}
+
+	// the largest scrypt allowed is still valid
+	max := KDFParams{KDF: KDFScrypt, Salt: salt, N: MaxScryptMemory / 128 / 8, R: 8, P: MaxScryptP}
+	if err := max.Validate(); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+}
@@ -2,41 +2,131 @@ package key
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+)
+
+var (
+	ErrDestroyed      = errors.New("Key has been destroyed")
+	ErrInvalidKeySize = errors.New("Key size must be 16 or 32 bytes")
 )
 
 type Key interface {
 	GetBytes() []byte
 	Len() int
+
+	// Destroy wipes the key material. Anything trying to use the key afterwards should check Destroyed
+	// and return ErrDestroyed, GetBytes and Fingerprint panic.
+	Destroy()
+	Destroyed() bool
+
+	// Fingerprint identifies the key without exposing it. It is the SHA-256 of the material.
+	Fingerprint() [32]byte
 }
 
-type key128 struct {
-	material [16]byte
+// symmetricKey keeps its material in SecureBytes, so GetBytes panics once the key is destroyed.
+type symmetricKey struct {
+	material *SecureBytes
 }
 
-func (k *key128) GetBytes() []byte {
-	return k.material[:]
+func (k *symmetricKey) GetBytes() []byte {
+	return k.material.Bytes()
 }
 
-func (k *key128) Len() int {
-	return len(k.material)
+func (k *symmetricKey) Len() int {
+	return k.material.Len()
 }
 
-func Bit128() Key {
-	b := generateRandomBytes(16)
-	return &key128{material: [16]byte(b)}
+func (k *symmetricKey) Destroy() {
+	k.material.Close()
 }
 
-func NewKey(material [16]byte) Key {
-	return &key128{material: material}
+func (k *symmetricKey) Destroyed() bool {
+	return k.material.Closed()
+}
+
+func (k *symmetricKey) Fingerprint() [32]byte {
+	return sha256.Sum256(k.material.Bytes())
+}
+
+// Option configures where random keys and salts come from, and how keys are kept in memory.
+type Option func(*options)
+
+type options struct {
+	rand  io.Reader
+	mlock bool
+}
+
+// WithRandReader replaces crypto/rand, for deterministic tests or an entropy source of the platform.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// WithMlock locks the memory of keys from Random, Bit128 and Bit256, and of NewSecureBytes, so it
+// is never swapped to disk. They fail with ErrMlockUnavailable when it can't be locked, and
+// platform.Fallbacks records why.
+func WithMlock() Option {
+	return func(o *options) {
+		o.mlock = true
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
-func generateRandomBytes(n int) []byte {
-	randBytes := make([]byte, n)
+// Random returns a random key of size bytes, 16 for AES-128 or 32 for AES-256.
+func Random(size int, opts ...Option) (Key, error) {
+	if size != 16 && size != 32 {
+		return nil, ErrInvalidKeySize
+	}
 
-	i, err := rand.Read(randBytes)
-	if i != n || err != nil {
-		panic("Could not generate random bytes")
+	material, err := NewSecureBytes(size, opts...)
+	if err != nil {
+		return nil, err
 	}
+	if _, err := io.ReadFull(newOptions(opts).rand, material.Bytes()); err != nil {
+		material.Close()
+		return nil, err
+	}
+	return &symmetricKey{material: material}, nil
+}
 
-	return randBytes
+// Bit128 is a random key for AES-128. It panics if the random source fails, Random returns the error instead.
+func Bit128(opts ...Option) Key {
+	return mustRandom(16, opts)
+}
+
+func NewKey(material [16]byte) Key {
+	return newKey(material[:])
+}
+
+// Bit256 is a random key for AES-256.
+func Bit256(opts ...Option) Key {
+	return mustRandom(32, opts)
+}
+
+func NewKey256(material [32]byte) Key {
+	return newKey(material[:])
+}
+
+// newKey copies material, which is a copy of the caller's array, and wipes it.
+func newKey(material []byte) Key {
+	s, _ := SecureBytesFrom(material)
+	return &symmetricKey{material: s}
+}
+
+func mustRandom(size int, opts []Option) Key {
+	k, err := Random(size, opts...)
+	if err != nil {
+		panic("Could not generate key: " + err.Error())
+	}
+	return k
 }
@@ -0,0 +1,58 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func TestRandom(t *testing.T) {
+	source := bytes.Repeat([]byte{0xab}, 32)
+
+	for _, size := range []int{16, 32} {
+		k, err := Random(size, WithRandReader(bytes.NewReader(source)))
+		if err != nil {
+			t.Fatalf("Error generating: %s", err)
+		}
+		if !bytes.Equal(k.GetBytes(), source[:size]) {
+			t.Errorf("Got: %x, Expected: %x", k.GetBytes(), source[:size])
+		}
+	}
+
+	if _, err := Random(24); err != ErrInvalidKeySize {
+		t.Errorf("Expected %v, got %v", ErrInvalidKeySize, err)
+	}
+
+	failing := errors.New("no entropy")
+	if _, err := Random(16, WithRandReader(iotest.ErrReader(failing))); err != failing {
+		t.Errorf("Expected %v, got %v", failing, err)
+	}
+
+	// without options it is crypto/rand
+	if a, b := Bit128(), Bit128(); bytes.Equal(a.GetBytes(), b.GetBytes()) {
+		t.Errorf("Two random keys are equal: %x", a.GetBytes())
+	}
+}
+
+func TestBit128Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic when the random source fails")
+		}
+	}()
+
+	Bit128(WithRandReader(bytes.NewReader(make([]byte, 15))))
+}
+
+func TestParamsRandReader(t *testing.T) {
+	source := bytes.Repeat([]byte{7}, saltSize)
+
+	p, err := NewScryptParams(WithRandReader(bytes.NewReader(source)))
+	if err != nil {
+		t.Fatalf("Error creating params: %s", err)
+	}
+	if !bytes.Equal(p.Salt, source) {
+		t.Errorf("Got: %x, Expected: %x", p.Salt, source)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || darwin)
+
+package key
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/mario-areias/aes-go/platform"
+)
+
+// the syscall package only has Mlock on Linux and macOS
+func lockedAlloc(size int) ([]byte, error) {
+	platform.Fallback(platform.Mlock, fmt.Sprintf("no mlock on %s, WithMlock returns ErrMlockUnavailable", runtime.GOOS))
+	return nil, ErrMlockUnavailable
+}
+
+func lockedFree(b []byte) error {
+	return nil
+}
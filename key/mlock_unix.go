@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+package key
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/mario-areias/aes-go/platform"
+)
+
+// lockedAlloc maps pages of its own for the secret, locking part of a Go heap page would unlock
+// the neighbours too when it is freed.
+func lockedAlloc(size int) ([]byte, error) {
+	page := syscall.Getpagesize()
+	length := (size + page - 1) / page * page
+	if length == 0 {
+		length = page
+	}
+
+	b, err := syscall.Mmap(-1, 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, mlockFailed(err)
+	}
+	// usually RLIMIT_MEMLOCK is too low or the process lacks permission
+	if err := syscall.Mlock(b); err != nil {
+		syscall.Munmap(b)
+		return nil, mlockFailed(err)
+	}
+
+	return b[:size], nil
+}
+
+func lockedFree(b []byte) error {
+	b = b[:cap(b)]
+	syscall.Munlock(b)
+	return syscall.Munmap(b)
+}
+
+func mlockFailed(err error) error {
+	platform.Fallback(platform.Mlock, fmt.Sprintf("mlock failed: %s, WithMlock returns ErrMlockUnavailable", err))
+	return fmt.Errorf("%w: %s", ErrMlockUnavailable, err)
+}
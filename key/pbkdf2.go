@@ -0,0 +1,49 @@
+package key
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	"github.com/mario-areias/aes-go/hmac"
+)
+
+// PBKDF2 derives keyLen bytes from the password using HMAC-SHA256 as the PRF (RFC 8018, section 5.2).
+func PBKDF2(password, salt []byte, iterations, keyLen int) []byte {
+	return PBKDF2Hash(sha256.New, password, salt, iterations, keyLen)
+}
+
+// PBKDF2Hash is PBKDF2 with HMAC of another hash as the PRF, like the HMAC-SHA1 still found in PKCS#5 files.
+func PBKDF2Hash(h func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	hashLen := prf.Size()
+	numBlocks := (keyLen + hashLen - 1) / hashLen
+
+	var buf [4]byte
+	dk := make([]byte, 0, numBlocks*hashLen)
+	u := make([]byte, hashLen)
+
+	for block := 1; block <= numBlocks; block++ {
+		// U1 = PRF(password, salt || INT(block))
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf[:], uint32(block))
+		prf.Write(buf[:4])
+		dk = prf.Sum(dk)
+		t := dk[len(dk)-hashLen:]
+		copy(u, t)
+
+		// Un = PRF(password, Un-1), T = U1 ^ U2 ^ ... ^ Un
+		for n := 2; n <= iterations; n++ {
+			prf.Reset()
+			prf.Write(u)
+			u = u[:0]
+			u = prf.Sum(u)
+			for x := range u {
+				t[x] ^= u[x]
+			}
+		}
+	}
+
+	return dk[:keyLen]
+}
@@ -0,0 +1,153 @@
+package key
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+var ErrInvalidScryptParams = errors.New("Invalid scrypt parameters")
+
+// Scrypt derives keyLen bytes from the password as described in RFC 7914.
+// N is the CPU/memory cost and must be a power of 2 greater than 1, r is the block size and p the parallelization.
+func Scrypt(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	if err := validateScrypt(N, r, p); err != nil {
+		return nil, err
+	}
+
+	// B = PBKDF2(password, salt, 1, p * 128 * r)
+	b := PBKDF2(password, salt, 1, p*128*r)
+
+	xy := make([]uint32, 64*r)
+	v := make([]uint32, 32*N*r)
+
+	for i := 0; i < p; i++ {
+		roMix(b[i*128*r:], r, N, v, xy)
+	}
+
+	return PBKDF2(password, b, 1, keyLen), nil
+}
+
+func validateScrypt(N, r, p int) error {
+	switch {
+	case N <= 1 || N&(N-1) != 0:
+		return ErrInvalidScryptParams
+	case r <= 0 || p <= 0:
+		return ErrInvalidScryptParams
+	case uint64(r)*uint64(p) >= 1<<30:
+		return ErrInvalidScryptParams
+	// N must be less than 2^(128 * r / 8)
+	case r < 16 && bits.Len(uint(N))-1 >= 16*r:
+		return ErrInvalidScryptParams
+	// keep memory (128 * r * N bytes) under 1GB
+	case uint64(r)*uint64(N) > 1<<23:
+		return ErrInvalidScryptParams
+	}
+	return nil
+}
+
+// roMix is the scryptROMix function from RFC 7914, section 5. It works on 32 bit words.
+func roMix(b []byte, r, N int, v, xy []uint32) {
+	x := xy[:32*r]
+	y := xy[32*r:]
+
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+
+	// fill V with the successive outputs of blockMix
+	for i := 0; i < N; i++ {
+		copy(v[i*32*r:], x)
+		blockMix(x, y, r)
+	}
+
+	// read V back in a pseudo random order that depends on the password
+	for i := 0; i < N; i++ {
+		j := int(integerify(x, r) & uint64(N-1))
+		for k := range x {
+			x[k] ^= v[j*32*r+k]
+		}
+		blockMix(x, y, r)
+	}
+
+	for i, w := range x {
+		binary.LittleEndian.PutUint32(b[i*4:], w)
+	}
+}
+
+// blockMix is scryptBlockMix from RFC 7914, section 4. The result is written back into b.
+func blockMix(b, y []uint32, r int) {
+	var x [16]uint32
+	copy(x[:], b[(2*r-1)*16:])
+
+	for i := 0; i < 2*r; i++ {
+		for j := range x {
+			x[j] ^= b[i*16+j]
+		}
+		salsa208(&x)
+
+		// even blocks go to the first half, odd blocks to the second
+		offset := (i/2)*16 + (i%2)*r*16
+		copy(y[offset:], x[:])
+	}
+
+	copy(b, y[:32*r])
+}
+
+func integerify(b []uint32, r int) uint64 {
+	j := (2*r - 1) * 16
+	return uint64(b[j]) | uint64(b[j+1])<<32
+}
+
+// salsa208 is the Salsa20/8 core, 8 rounds instead of the usual 20.
+func salsa208(b *[16]uint32) {
+	x := *b
+
+	for i := 0; i < 8; i += 2 {
+		// columns
+		x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+		x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+		x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+		x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+
+		x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+		x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+		x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+		x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+
+		x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+		x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+		x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+		x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+
+		x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+		x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+		x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+		x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+
+		// rows
+		x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+		x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+		x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+		x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+
+		x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+		x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+		x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+		x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+
+		x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+		x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+		x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+		x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+
+		x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+		x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+		x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+		x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+	}
+
+	for i := range b {
+		b[i] += x[i]
+	}
+}
@@ -0,0 +1,95 @@
+package key
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrMlockUnavailable = errors.New("Memory can't be locked on this platform")
+
+// SecureBytes holds secrets, keys or plaintext, and wipes them on Close. Using it after Close
+// panics, a secret that is silently all zeros is worse than a crash.
+//
+// Nothing is wiped or unlocked without Close. A finalizer can't do it: Bytes returns the secret
+// itself, which callers keep using after the SecureBytes is unreachable.
+//
+// With WithMlock the bytes live in their own pages, locked so they are never written to swap.
+// Copies made with append or string conversions aren't protected, keep them short lived.
+type SecureBytes struct {
+	mu     sync.Mutex
+	b      []byte
+	locked bool
+	closed bool
+}
+
+// NewSecureBytes returns size zeroed bytes. WithMlock is the only option used.
+func NewSecureBytes(size int, opts ...Option) (*SecureBytes, error) {
+	s := &SecureBytes{}
+
+	if newOptions(opts).mlock {
+		b, err := lockedAlloc(size)
+		if err != nil {
+			return nil, err
+		}
+		s.b, s.locked = b, true
+	} else {
+		s.b = make([]byte, size)
+	}
+
+	return s, nil
+}
+
+// SecureBytesFrom copies b into new SecureBytes and wipes b.
+func SecureBytesFrom(b []byte, opts ...Option) (*SecureBytes, error) {
+	s, err := NewSecureBytes(len(b), opts...)
+	if err != nil {
+		return nil, err
+	}
+	copy(s.b, b)
+	clear(b)
+	return s, nil
+}
+
+// Bytes returns the secret itself, not a copy. It panics after Close.
+func (s *SecureBytes) Bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		panic("key: use of SecureBytes after Close")
+	}
+	return s.b
+}
+
+func (s *SecureBytes) Len() int {
+	return len(s.b)
+}
+
+// Locked reports whether the memory is locked.
+func (s *SecureBytes) Locked() bool {
+	return s.locked
+}
+
+func (s *SecureBytes) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Close wipes the bytes and unlocks them. Closing twice does nothing.
+func (s *SecureBytes) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	clear(s.b)
+	if s.locked {
+		return lockedFree(s.b)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package key
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestSecureBytes(t *testing.T) {
+	source := []byte("correct horse battery staple")
+
+	s, err := SecureBytesFrom(source)
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+	if !bytes.Equal(source, make([]byte, len(source))) {
+		t.Errorf("Expected the source to be wiped, got %q", source)
+	}
+	if string(s.Bytes()) != "correct horse battery staple" || s.Len() != 28 || s.Locked() {
+		t.Errorf("Unexpected secure bytes %q", s.Bytes())
+	}
+
+	b := s.Bytes()
+	if err := s.Close(); err != nil {
+		t.Fatalf("Error closing: %s", err)
+	}
+	if !bytes.Equal(b, make([]byte, len(b))) {
+		t.Errorf("Expected the bytes to be wiped, got %q", b)
+	}
+	if !s.Closed() {
+		t.Errorf("Expected closed")
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Expected a second Close to do nothing, got %v", err)
+	}
+
+	assertPanics(t, func() { s.Bytes() })
+}
+
+func TestSecureBytesMlock(t *testing.T) {
+	s, err := NewSecureBytes(32, WithMlock())
+	if errors.Is(err, ErrMlockUnavailable) {
+		t.Skipf("mlock isn't available: %s", err)
+	}
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+	if !s.Locked() || s.Len() != 32 || !bytes.Equal(s.Bytes(), make([]byte, 32)) {
+		t.Errorf("Expected 32 locked zero bytes, got %x (locked %v)", s.Bytes(), s.Locked())
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Error closing: %s", err)
+	}
+
+	k, err := Random(16, WithMlock(), WithRandReader(bytes.NewReader(bytes.Repeat([]byte{1}, 16))))
+	if err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+	if !k.(*symmetricKey).material.Locked() || !bytes.Equal(k.GetBytes(), bytes.Repeat([]byte{1}, 16)) {
+		t.Errorf("Expected a locked key, got %x", k.GetBytes())
+	}
+	k.Destroy()
+}
+
+// The bytes of a key that is no longer referenced are still used, e.g. as an IV.
+func TestBytesOutliveKey(t *testing.T) {
+	iv := Bit128().GetBytes()
+	expected := append([]byte{}, iv...)
+
+	runtime.GC()
+	runtime.GC()
+
+	if !bytes.Equal(iv, expected) {
+		t.Errorf("Got: %x, Expected: %x", iv, expected)
+	}
+}
+
+func TestKeyPanicsAfterDestroy(t *testing.T) {
+	k := Bit128()
+	k.Destroy()
+	k.Destroy()
+
+	if !k.Destroyed() {
+		t.Errorf("Expected the key to be destroyed")
+	}
+	assertPanics(t, func() { k.GetBytes() })
+	assertPanics(t, func() { k.Fingerprint() })
+}
+
+func assertPanics(t *testing.T, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic")
+		}
+	}()
+	fn()
+}
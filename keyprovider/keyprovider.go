@@ -0,0 +1,183 @@
+// Package keyprovider runs the modes of operation against a key that the process doesn't hold, in
+// an HSM (PKCS#11), a KMS or another process, the way crypto.Signer separates signing from the
+// private key.
+//
+// A KeyProvider only encrypts and decrypts raw blocks. Cipher builds GCM and CTR on top of it and
+// asks for every block of a message in a single call, so a remote provider costs one round trip
+// per message. Providers that do whole messages themselves, like a KMS, also implement Sealer and
+// Cipher hands GCM to them.
+//
+// Without a Sealer the GHASH key H = E(K, 0) is computed by the provider and used locally. H can
+// forge tags for that key but doesn't reveal the key, the same trade-off as any software GCM on
+// top of an HSM that only does ECB.
+//
+// Local is the software provider and MockRemote a fake device for tests.
+package keyprovider
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/ghash"
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+const BlockSize = 16
+
+var ErrNotFullBlocks = errors.New("Input must be a multiple of the block size")
+
+// KeyProvider does block operations with a key it doesn't give out. Every 16 byte block of src is
+// processed on its own, like ECB, into dst. dst and src have the same length.
+type KeyProvider interface {
+	EncryptBlocks(ctx context.Context, dst, src []byte) error
+	DecryptBlocks(ctx context.Context, dst, src []byte) error
+}
+
+// Sealer is implemented by providers that do GCM themselves. The output is the same as
+// aesgo.AES.SealGCM: the ciphertext followed by the tag.
+type Sealer interface {
+	SealGCM(ctx context.Context, nonce, plaintext, additionalData []byte) ([]byte, error)
+	OpenGCM(ctx context.Context, nonce, sealed, additionalData []byte) ([]byte, error)
+}
+
+// Cipher is safe for concurrent use if its provider is.
+type Cipher struct {
+	p KeyProvider
+}
+
+func NewCipher(p KeyProvider) *Cipher {
+	return &Cipher{p: p}
+}
+
+// SealGCM is aesgo.AES.SealGCM through the provider.
+func (c *Cipher) SealGCM(ctx context.Context, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	if s, ok := c.p.(Sealer); ok {
+		return s.SealGCM(ctx, nonce, plaintext, additionalData)
+	}
+
+	h, mask, keystream, err := c.gcmBlocks(ctx, nonce, len(plaintext))
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, len(plaintext), len(plaintext)+aesgo.GCMTagSize)
+	xor(encrypted, plaintext, keystream)
+	tag := gcmTag(h, mask, additionalData, encrypted)
+
+	return append(encrypted, tag[:]...), nil
+}
+
+// OpenGCM is aesgo.AES.OpenGCM through the provider.
+func (c *Cipher) OpenGCM(ctx context.Context, nonce, sealed, additionalData []byte) ([]byte, error) {
+	if s, ok := c.p.(Sealer); ok {
+		return s.OpenGCM(ctx, nonce, sealed, additionalData)
+	}
+	if len(sealed) < aesgo.GCMTagSize {
+		return nil, aesgo.ErrTruncatedCiphertext
+	}
+
+	body := sealed[:len(sealed)-aesgo.GCMTagSize]
+	h, mask, keystream, err := c.gcmBlocks(ctx, nonce, len(body))
+	if err != nil {
+		return nil, err
+	}
+
+	expected := gcmTag(h, mask, additionalData, body)
+	if subtle.ConstantTimeCompare(expected[:], sealed[len(body):]) != 1 {
+		return nil, aesgo.ErrAuthentication
+	}
+
+	plaintext := make([]byte, len(body))
+	xor(plaintext, body, keystream)
+	return plaintext, nil
+}
+
+// gcmBlocks returns H, E(K, J0) and the keystream from J0 + 1, all in one call to the provider.
+func (c *Cipher) gcmBlocks(ctx context.Context, nonce []byte, n int) ([16]byte, [16]byte, []byte, error) {
+	var h, mask [16]byte
+
+	if len(nonce) != aesgo.GCMNonceSize {
+		return h, mask, nil, aesgo.ErrInvalidNonce
+	}
+
+	blocks := 2 + (n+BlockSize-1)/BlockSize
+	src := make([]byte, blocks*BlockSize)
+
+	// the first block stays zero for H, then J0 = nonce || 1, J0 + 1, ...
+	counter := src[BlockSize:]
+	for i := 0; i < blocks-1; i++ {
+		block := counter[i*BlockSize : (i+1)*BlockSize]
+		copy(block, nonce)
+		binary.BigEndian.PutUint32(block[12:], uint32(i+1))
+	}
+
+	dst := make([]byte, len(src))
+	if err := c.p.EncryptBlocks(ctx, dst, src); err != nil {
+		return h, mask, nil, err
+	}
+
+	copy(h[:], dst)
+	copy(mask[:], dst[BlockSize:])
+	return h, mask, dst[2*BlockSize:], nil
+}
+
+func gcmTag(h, mask [16]byte, additionalData, encrypted []byte) [16]byte {
+	s := ghash.GCM(h, additionalData, encrypted)
+	for i := range s {
+		s[i] ^= mask[i]
+	}
+	return s
+}
+
+// XORKeyStreamCTR encrypts or decrypts src with CTR from iv, the same as aesgo CTR with the default
+// 128 bit counter. dst must be at least as long as src.
+func (c *Cipher) XORKeyStreamCTR(ctx context.Context, dst, src, iv []byte) error {
+	if len(iv) != BlockSize {
+		return aesgo.ErrInvalidIV
+	}
+	if len(dst) < len(src) {
+		return fmt.Errorf("dst is shorter than src: %d < %d", len(dst), len(src))
+	}
+
+	blocks := (len(src) + BlockSize - 1) / BlockSize
+	counters := make([]byte, blocks*BlockSize)
+
+	counter := [16]byte(iv)
+	for i := 0; i < blocks; i++ {
+		copy(counters[i*BlockSize:], counter[:])
+		increment(&counter)
+	}
+
+	keystream := make([]byte, len(counters))
+	if err := c.p.EncryptBlocks(ctx, keystream, counters); err != nil {
+		return err
+	}
+
+	xor(dst, src, keystream)
+	return nil
+}
+
+func increment(counter *[16]byte) {
+	for i := len(counter) - 1; i >= 0; i-- {
+		counter[i]++
+		if counter[i] != 0 {
+			return
+		}
+	}
+}
+
+func xor(dst, src, keystream []byte) {
+	for i := range src {
+		dst[i] = src[i] ^ keystream[i]
+	}
+}
+
+func checkBlocks(dst, src []byte) error {
+	if len(src)%BlockSize != 0 || len(dst) != len(src) {
+		return ErrNotFullBlocks
+	}
+	return nil
+}
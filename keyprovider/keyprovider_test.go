@@ -0,0 +1,162 @@
+package keyprovider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestCipher(t *testing.T) {
+	k := key.Bit256()
+	a, _ := aesgo.NewCipher(k)
+
+	local, _ := NewLocal(k)
+	remote, _ := NewMockRemote(k)
+	defer remote.Close()
+	kms, _ := NewMockKMS(k)
+	defer kms.Close()
+
+	nonce := bytes.Repeat([]byte{7}, aesgo.GCMNonceSize)
+	iv := append(bytes.Repeat([]byte{0xff}, 15), 0xfe)
+	plaintext := []byte("Let's test if this is working with a few blocks!")
+	additionalData := []byte("header")
+
+	expectedGCM, _ := a.SealGCM(nonce, plaintext, additionalData)
+
+	tests := []struct {
+		name     string
+		provider KeyProvider
+	}{
+		{
+			name:     "local",
+			provider: local,
+		},
+		{
+			name:     "remote blocks",
+			provider: remote,
+		},
+		{
+			name:     "remote messages",
+			provider: kms,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewCipher(test.provider)
+
+			sealed, err := c.SealGCM(ctx, nonce, plaintext, additionalData)
+			if err != nil {
+				t.Fatalf("Error sealing: %s", err)
+			}
+			if !bytes.Equal(sealed, expectedGCM) {
+				t.Errorf("Got: %x, Expected: %x", sealed, expectedGCM)
+			}
+
+			opened, err := c.OpenGCM(ctx, nonce, sealed, additionalData)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Errorf("Got: %s, Expected: %s (%v)", opened, plaintext, err)
+			}
+
+			sealed[0] ^= 1
+			if _, err := c.OpenGCM(ctx, nonce, sealed, additionalData); err != aesgo.ErrAuthentication {
+				t.Errorf("Expected %v, got %v", aesgo.ErrAuthentication, err)
+			}
+
+			// the counter wraps around like aesgo CTR
+			encrypted := make([]byte, len(plaintext))
+			if err := c.XORKeyStreamCTR(ctx, encrypted, plaintext, iv); err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			decrypted, _ := a.Decrypt(aesgo.CTR, append(append([]byte{}, iv...), encrypted...))
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+			}
+
+			block := make([]byte, 32)
+			if err := test.provider.EncryptBlocks(ctx, block, make([]byte, 32)); err != nil {
+				t.Fatalf("Error encrypting blocks: %s", err)
+			}
+			if err := test.provider.DecryptBlocks(ctx, block, block); err != nil || !bytes.Equal(block, make([]byte, 32)) {
+				t.Errorf("Expected zero blocks back, got %x (%v)", block, err)
+			}
+		})
+	}
+}
+
+func TestMockRemote(t *testing.T) {
+	k := key.Bit128()
+	remote, _ := NewMockRemote(k, WithLatency(20*time.Millisecond))
+	c := NewCipher(remote)
+	ctx := context.Background()
+	nonce := make([]byte, aesgo.GCMNonceSize)
+
+	// a message of many blocks is a single round trip
+	if _, err := c.SealGCM(ctx, nonce, make([]byte, 1000), nil); err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+	if remote.Calls() != 1 {
+		t.Errorf("Expected 1 call, got %d", remote.Calls())
+	}
+
+	// the device has its own copy of the key
+	k.Destroy()
+	if _, err := c.SealGCM(ctx, nonce, []byte("x"), nil); err != nil {
+		t.Errorf("Expected the device to keep working, got %v", err)
+	}
+
+	unreachable := errors.New("device unreachable")
+	remote.Fail(unreachable)
+	if _, err := c.SealGCM(ctx, nonce, []byte("x"), nil); err != unreachable {
+		t.Errorf("Expected %v, got %v", unreachable, err)
+	}
+	remote.Fail(nil)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	if _, err := c.SealGCM(timeout, nonce, []byte("x"), nil); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	remote.Close()
+	if _, err := c.SealGCM(ctx, nonce, []byte("x"), nil); err != ErrClosed {
+		t.Errorf("Expected %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	k := key.Bit128()
+	local, _ := NewLocal(k)
+	c := NewCipher(local)
+	ctx := context.Background()
+
+	if _, err := c.SealGCM(ctx, make([]byte, 8), nil, nil); err != aesgo.ErrInvalidNonce {
+		t.Errorf("Expected %v, got %v", aesgo.ErrInvalidNonce, err)
+	}
+	if _, err := c.OpenGCM(ctx, make([]byte, 12), make([]byte, 15), nil); err != aesgo.ErrTruncatedCiphertext {
+		t.Errorf("Expected %v, got %v", aesgo.ErrTruncatedCiphertext, err)
+	}
+	if err := c.XORKeyStreamCTR(ctx, make([]byte, 4), make([]byte, 4), make([]byte, 8)); err != aesgo.ErrInvalidIV {
+		t.Errorf("Expected %v, got %v", aesgo.ErrInvalidIV, err)
+	}
+	if err := local.EncryptBlocks(ctx, make([]byte, 15), make([]byte, 15)); err != ErrNotFullBlocks {
+		t.Errorf("Expected %v, got %v", ErrNotFullBlocks, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := local.EncryptBlocks(cancelled, make([]byte, 16), make([]byte, 16)); err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+
+	local.Destroy()
+	if _, err := c.SealGCM(ctx, make([]byte, 12), nil, nil); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
@@ -0,0 +1,58 @@
+package keyprovider
+
+import (
+	"context"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// Local is the software provider, the key is in memory next to everything else. It is for code
+// written against KeyProvider that runs without an HSM, in development or tests.
+type Local struct {
+	k    key.Key
+	pool *aesgo.Pool
+}
+
+func NewLocal(k key.Key) (*Local, error) {
+	p, err := aesgo.NewPool(k)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{k: k, pool: p}, nil
+}
+
+func (l *Local) EncryptBlocks(ctx context.Context, dst, src []byte) error {
+	return l.blocks(ctx, dst, src, (*aesgo.AES).EncryptBlock)
+}
+
+func (l *Local) DecryptBlocks(ctx context.Context, dst, src []byte) error {
+	return l.blocks(ctx, dst, src, (*aesgo.AES).DecryptBlock)
+}
+
+func (l *Local) blocks(ctx context.Context, dst, src []byte, fn func(*aesgo.AES, [16]byte) [4][4]byte) error {
+	if err := checkBlocks(dst, src); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if l.k.Destroyed() {
+		return key.ErrDestroyed
+	}
+
+	a := l.pool.Get()
+	defer l.pool.Put(a)
+
+	for i := 0; i < len(src); i += BlockSize {
+		out := primitives.FromState(fn(a, [16]byte(src[i:])))
+		copy(dst[i:], out[:])
+	}
+	return nil
+}
+
+// Destroy wipes the key.
+func (l *Local) Destroy() {
+	l.pool.Destroy()
+}
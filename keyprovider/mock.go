@@ -0,0 +1,200 @@
+package keyprovider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var ErrClosed = errors.New("Provider is closed")
+
+// MockRemote is a fake HSM for tests. The key lives in a goroutine that serves requests one at a
+// time, like a device behind a network, and nothing else can reach it. Calls counts the requests,
+// WithLatency slows them down and Fail makes them fail.
+type MockRemote struct {
+	requests chan request
+	done     chan struct{}
+	once     sync.Once
+	latency  time.Duration
+
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+// MockKMS is a MockRemote that also does whole GCM messages, so Cipher hands them over.
+type MockKMS struct {
+	*MockRemote
+}
+
+type MockOption func(*MockRemote)
+
+// WithLatency adds d to every request.
+func WithLatency(d time.Duration) MockOption {
+	return func(m *MockRemote) {
+		m.latency = d
+	}
+}
+
+type request struct {
+	op                    string
+	nonce, in, additional []byte
+	reply                 chan response
+}
+
+type response struct {
+	out []byte
+	err error
+}
+
+// NewMockRemote starts the device with its own copy of k.
+func NewMockRemote(k key.Key, opts ...MockOption) (*MockRemote, error) {
+	// the device keeps a copy, destroying k doesn't reach into it
+	a, err := aesgo.NewCipher(copyKey(k))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MockRemote{requests: make(chan request), done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	go m.serve(a)
+	return m, nil
+}
+
+func NewMockKMS(k key.Key, opts ...MockOption) (*MockKMS, error) {
+	m, err := NewMockRemote(k, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MockKMS{MockRemote: m}, nil
+}
+
+func copyKey(k key.Key) key.Key {
+	if k.Len() == 32 {
+		return key.NewKey256([32]byte(k.GetBytes()))
+	}
+	return key.NewKey([16]byte(k.GetBytes()))
+}
+
+func (m *MockRemote) serve(a *aesgo.AES) {
+	defer a.Destroy()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case req := <-m.requests:
+			if m.latency > 0 {
+				time.Sleep(m.latency)
+			}
+			out, err := handle(a, req)
+			req.reply <- response{out: out, err: err}
+		}
+	}
+}
+
+func handle(a *aesgo.AES, req request) ([]byte, error) {
+	switch req.op {
+	case "seal":
+		return a.SealGCM(req.nonce, req.in, req.additional)
+	case "open":
+		return a.OpenGCM(req.nonce, req.in, req.additional)
+	}
+
+	fn := a.EncryptBlock
+	if req.op == "decrypt" {
+		fn = a.DecryptBlock
+	}
+	out := make([]byte, len(req.in))
+	for i := 0; i < len(req.in); i += BlockSize {
+		b := primitives.FromState(fn([16]byte(req.in[i:])))
+		copy(out[i:], b[:])
+	}
+	return out, nil
+}
+
+func (m *MockRemote) call(ctx context.Context, req request) ([]byte, error) {
+	m.mu.Lock()
+	m.calls++
+	err := m.err
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	req.reply = make(chan response, 1)
+	select {
+	case m.requests <- req:
+	case <-m.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case resp := <-req.reply:
+		return resp.out, resp.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (m *MockRemote) EncryptBlocks(ctx context.Context, dst, src []byte) error {
+	return m.blocks(ctx, "encrypt", dst, src)
+}
+
+func (m *MockRemote) DecryptBlocks(ctx context.Context, dst, src []byte) error {
+	return m.blocks(ctx, "decrypt", dst, src)
+}
+
+func (m *MockRemote) blocks(ctx context.Context, op string, dst, src []byte) error {
+	if err := checkBlocks(dst, src); err != nil {
+		return err
+	}
+
+	// the request is sent over the "network", it must not share memory with the caller
+	out, err := m.call(ctx, request{op: op, in: append([]byte{}, src...)})
+	if err != nil {
+		return err
+	}
+	copy(dst, out)
+	return nil
+}
+
+func (m *MockKMS) SealGCM(ctx context.Context, nonce, plaintext, additionalData []byte) ([]byte, error) {
+	return m.call(ctx, request{op: "seal", nonce: nonce, in: plaintext, additional: additionalData})
+}
+
+func (m *MockKMS) OpenGCM(ctx context.Context, nonce, sealed, additionalData []byte) ([]byte, error) {
+	return m.call(ctx, request{op: "open", nonce: nonce, in: sealed, additional: additionalData})
+}
+
+// Calls returns the number of requests so far, failed ones included.
+func (m *MockRemote) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.calls
+}
+
+// Fail makes every request fail with err, like an unreachable device. Fail(nil) recovers.
+func (m *MockRemote) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+// Close stops the device and wipes its key.
+func (m *MockRemote) Close() error {
+	m.once.Do(func() { close(m.done) })
+	return nil
+}
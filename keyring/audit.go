@@ -0,0 +1,96 @@
+package keyring
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Operations in an Event and a KeyError.
+const (
+	OpEncrypt = "encrypt"
+	OpDecrypt = "decrypt"
+)
+
+// Event is one use of a key. KeyID is empty when there was no key to use, no current key or an
+// envelope that doesn't unmarshal. Err is nil on success.
+type Event struct {
+	Time    time.Time
+	KeyID   string
+	Op      string
+	Context string
+	Err     error
+}
+
+// AuditSink receives the events of a keyring. Record is called synchronously from Encrypt and
+// Decrypt, possibly from several goroutines, so it must be fast and safe for concurrent use.
+type AuditSink interface {
+	Record(Event)
+}
+
+// AuditFunc is an AuditSink from a function.
+type AuditFunc func(Event)
+
+func (f AuditFunc) Record(e Event) {
+	f(e)
+}
+
+// WithAudit records every Encrypt and Decrypt to sink.
+func WithAudit(sink AuditSink) Option {
+	return func(r *Keyring) {
+		r.audit = sink
+	}
+}
+
+type auditContextKey struct{}
+
+// AuditContext returns a copy of ctx that labels the events of EncryptContext and DecryptContext
+// with s, like the caller or the request ID.
+func AuditContext(ctx context.Context, s string) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, s)
+}
+
+func (r *Keyring) record(ctx context.Context, op, id string, err error) {
+	if r.audit == nil {
+		return
+	}
+	s, _ := ctx.Value(auditContextKey{}).(string)
+	r.audit.Record(Event{Time: r.now(), KeyID: id, Op: op, Context: s, Err: err})
+}
+
+// JSONSink writes every event as a line of JSON:
+//
+//	{"time":"2024-01-01T00:00:00Z","key_id":"v1","op":"encrypt","context":"billing","success":true}
+//
+// A failure has "success":false and the error in "error". Write errors are ignored, wrap w if
+// they matter.
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+type jsonEvent struct {
+	Time    time.Time `json:"time"`
+	KeyID   string    `json:"key_id"`
+	Op      string    `json:"op"`
+	Context string    `json:"context,omitempty"`
+	Success bool      `json:"success"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func (s *JSONSink) Record(e Event) {
+	j := jsonEvent{Time: e.Time, KeyID: e.KeyID, Op: e.Op, Context: e.Context, Success: e.Err == nil}
+	if e.Err != nil {
+		j.Error = e.Err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(j)
+}
@@ -0,0 +1,113 @@
+package keyring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var events []Event
+	r := New(
+		WithClock(func() time.Time { return now }),
+		WithAudit(AuditFunc(func(e Event) { events = append(events, e) })),
+	)
+
+	ctx := AuditContext(context.Background(), "billing")
+	if _, err := r.EncryptContext(ctx, aesgo.GCM, []byte("no key yet")); err != ErrNoCurrentKey {
+		t.Errorf("Expected %v, got %v", ErrNoCurrentKey, err)
+	}
+
+	r.Rotate("v1", key.Bit128())
+	encrypted, _ := r.EncryptContext(ctx, aesgo.GCM, []byte("message"))
+	r.Decrypt(encrypted)
+
+	encrypted[len(encrypted)-1] ^= 1
+	r.DecryptContext(ctx, encrypted)
+	r.Decrypt([]byte("not an envelope"))
+
+	expected := []Event{
+		{Time: now, KeyID: "", Op: OpEncrypt, Context: "billing", Err: ErrNoCurrentKey},
+		{Time: now, KeyID: "v1", Op: OpEncrypt, Context: "billing"},
+		{Time: now, KeyID: "v1", Op: OpDecrypt},
+		{Time: now, KeyID: "v1", Op: OpDecrypt, Context: "billing", Err: aesgo.ErrAuthentication},
+		{Time: now, KeyID: "", Op: OpDecrypt},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, e := range expected {
+		got := events[i]
+		// the last one fails with whatever Unmarshal returns
+		if i == len(expected)-1 {
+			if got.Err == nil {
+				t.Errorf("Event %d: Expected an error, got nil", i)
+			}
+			got.Err = nil
+		}
+		if got != e {
+			t.Errorf("Event %d: Expected %+v, got %+v", i, e, got)
+		}
+	}
+}
+
+func TestAuditRekey(t *testing.T) {
+	var events []Event
+	r := New(
+		WithLimits(aesgo.Limits{Messages: 1}),
+		WithRekey(func() (string, key.Key, error) { return "auto", key.Bit128(), nil }),
+		WithAudit(AuditFunc(func(e Event) { events = append(events, e) })),
+	)
+	r.Rotate("v1", key.Bit128())
+
+	r.Encrypt(aesgo.CTR, []byte("x"))
+	r.Encrypt(aesgo.CTR, []byte("x"))
+
+	// the key that was used, not the exhausted one
+	if len(events) != 2 || events[0].KeyID != "v1" || events[1].KeyID != "auto" || events[1].Err != nil {
+		t.Errorf("Unexpected events %+v", events)
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sink.Record(Event{Time: now, KeyID: "v1", Op: OpEncrypt, Context: "billing"})
+		}()
+	}
+	wg.Wait()
+	sink.Record(Event{Time: now, KeyID: "v1", Op: OpDecrypt, Err: &KeyError{ID: "v1", Op: OpDecrypt, Err: ErrKeyCompromised}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 11 {
+		t.Fatalf("Expected 11 lines, got %d", len(lines))
+	}
+
+	expected := `{"time":"2024-01-01T00:00:00Z","key_id":"v1","op":"encrypt","context":"billing","success":true}`
+	if lines[0] != expected {
+		t.Errorf("Got: %s, Expected: %s", lines[0], expected)
+	}
+
+	var failure map[string]any
+	if err := json.Unmarshal([]byte(lines[10]), &failure); err != nil {
+		t.Fatalf("Error unmarshaling: %s", err)
+	}
+	if failure["success"] != false || failure["error"] != "decrypt with key v1: Key is compromised" {
+		t.Errorf("Unexpected failure %s", lines[10])
+	}
+}
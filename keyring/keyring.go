@@ -0,0 +1,469 @@
+// Package keyring keeps several keys under string IDs so they can be rotated.
+// New data is always encrypted with the current key, and the key ID is recorded in the
+// ciphertext envelope, so data encrypted with retired keys can still be decrypted.
+//
+// Every key has an aesgo.Budget counting what was encrypted with it. With WithRekey a new key is
+// generated and made current when the budget of the current one runs out, or when it expires.
+//
+// Every key also has Metadata: when it was added, when it expires, what it may be used for and its
+// State. Encrypt refuses keys that are expired, decrypt only, retired or compromised, and Decrypt
+// refuses compromised keys. The errors are a *KeyError with the ID of the key, wrapping one of
+// ErrKeyExpired, ErrKeyDecryptOnly, ErrKeyEncryptOnly, ErrKeyRetired or ErrKeyCompromised.
+//
+// WithAudit records every Encrypt and Decrypt, successful or not, as an Event.
+package keyring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("Key not found in keyring")
+	ErrDuplicateKey = errors.New("Key ID already in keyring")
+	ErrNoCurrentKey = errors.New("Keyring has no current key")
+	ErrInvalidKeyID = errors.New("Key ID can't be empty")
+
+	ErrKeyExpired     = errors.New("Key has expired")
+	ErrKeyDecryptOnly = errors.New("Key can only decrypt")
+	ErrKeyEncryptOnly = errors.New("Key can only encrypt")
+	ErrKeyRetired     = errors.New("Key is retired")
+	ErrKeyCompromised = errors.New("Key is compromised")
+)
+
+// KeyError is returned when a key can't be used for an operation because of its metadata.
+type KeyError struct {
+	ID  string
+	Op  string // OpEncrypt or OpDecrypt
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("%s with key %s: %s", e.Op, e.ID, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// Purpose is what a key may be used for, a set of bits.
+type Purpose uint8
+
+const (
+	PurposeEncrypt Purpose = 1 << iota
+	PurposeDecrypt
+
+	PurposeAll = PurposeEncrypt | PurposeDecrypt
+)
+
+type State int
+
+const (
+	// StateActive keys can encrypt and decrypt, if their purposes allow it.
+	StateActive State = iota
+	// StateRetired keys only decrypt. SetCurrent retires the key it replaces.
+	StateRetired
+	// StateCompromised keys can't be used at all, but stay in the keyring to know what was
+	// encrypted with them.
+	StateCompromised
+)
+
+func (s State) String() string {
+	switch s {
+	case StateActive:
+		return "active"
+	case StateRetired:
+		return "retired"
+	case StateCompromised:
+		return "compromised"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Metadata describes a key. A zero Expires never expires.
+type Metadata struct {
+	Created  time.Time
+	Expires  time.Time
+	Purposes Purpose
+	State    State
+}
+
+// Expired reports if the key is expired at now.
+func (m Metadata) Expired(now time.Time) bool {
+	return !m.Expires.IsZero() && !now.Before(m.Expires)
+}
+
+// KeyOption sets the metadata of a key when it is added.
+type KeyOption func(*Metadata)
+
+// WithExpiry makes the key refuse to encrypt from t on. It still decrypts.
+func WithExpiry(t time.Time) KeyOption {
+	return func(m *Metadata) {
+		m.Expires = t
+	}
+}
+
+// WithPurposes limits what the key is used for. Defaults to PurposeAll.
+func WithPurposes(p Purpose) KeyOption {
+	return func(m *Metadata) {
+		m.Purposes = p
+	}
+}
+
+type entry struct {
+	key    key.Key
+	budget *aesgo.Budget
+	meta   Metadata
+}
+
+type Keyring struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	current string
+
+	limits aesgo.Limits
+	rekey  func() (string, key.Key, error)
+	now    func() time.Time
+	audit  AuditSink
+}
+
+type Option func(*Keyring)
+
+// WithLimits sets the usage limits of every key. Defaults to no limits, usage is still counted.
+func WithLimits(l aesgo.Limits) Option {
+	return func(r *Keyring) {
+		r.limits = l
+	}
+}
+
+// WithRekey calls generate for a new key when the current one reaches its limits or expires,
+// instead of failing with aesgo.ErrBudgetExhausted or ErrKeyExpired.
+func WithRekey(generate func() (string, key.Key, error)) Option {
+	return func(r *Keyring) {
+		r.rekey = generate
+	}
+}
+
+// WithClock replaces time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(r *Keyring) {
+		r.now = now
+	}
+}
+
+func New(opts ...Option) *Keyring {
+	r := &Keyring{entries: make(map[string]*entry), now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Add stores an active key without making it current.
+func (r *Keyring) Add(id string, k key.Key, opts ...KeyOption) error {
+	if id == "" {
+		return ErrInvalidKeyID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.add(id, k, opts...)
+}
+
+func (r *Keyring) add(id string, k key.Key, opts ...KeyOption) error {
+	if _, ok := r.entries[id]; ok {
+		return ErrDuplicateKey
+	}
+
+	meta := Metadata{Created: r.now(), Purposes: PurposeAll}
+	for _, opt := range opts {
+		opt(&meta)
+	}
+	r.entries[id] = &entry{key: k, budget: aesgo.NewBudget(r.limits), meta: meta}
+
+	return nil
+}
+
+// SetCurrent makes an existing key the one used to encrypt, it must be able to. The previous key
+// is retired but stays in the keyring for decryption.
+func (r *Keyring) SetCurrent(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.setCurrent(id)
+}
+
+func (r *Keyring) setCurrent(id string) error {
+	e, ok := r.entries[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	// a retired key can come back, the state only changes when it is usable
+	meta := e.meta
+	if meta.State == StateRetired {
+		meta.State = StateActive
+	}
+	if err := r.usable(id, meta, PurposeEncrypt); err != nil {
+		return err
+	}
+	e.meta = meta
+
+	if previous, ok := r.entries[r.current]; ok && r.current != id && previous.meta.State == StateActive {
+		previous.meta.State = StateRetired
+	}
+	r.current = id
+
+	return nil
+}
+
+// Rotate adds a new key and makes it current.
+func (r *Keyring) Rotate(id string, k key.Key, opts ...KeyOption) error {
+	if id == "" {
+		return ErrInvalidKeyID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.add(id, k, opts...); err != nil {
+		return err
+	}
+	if err := r.setCurrent(id); err != nil {
+		delete(r.entries, id)
+		return err
+	}
+	return nil
+}
+
+// usable checks if meta allows purpose.
+func (r *Keyring) usable(id string, meta Metadata, purpose Purpose) error {
+	op := OpEncrypt
+	if purpose == PurposeDecrypt {
+		op = OpDecrypt
+	}
+
+	var err error
+	switch {
+	case meta.State == StateCompromised:
+		err = ErrKeyCompromised
+	case purpose == PurposeEncrypt && meta.State == StateRetired:
+		err = ErrKeyRetired
+	case meta.Purposes&purpose == 0 && purpose == PurposeEncrypt:
+		err = ErrKeyDecryptOnly
+	case meta.Purposes&purpose == 0:
+		err = ErrKeyEncryptOnly
+	case purpose == PurposeEncrypt && meta.Expired(r.now()):
+		err = ErrKeyExpired
+	}
+	if err != nil {
+		return &KeyError{ID: id, Op: op, Err: err}
+	}
+	return nil
+}
+
+// Metadata returns the metadata of a key.
+func (r *Keyring) Metadata(id string) (Metadata, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return Metadata{}, ErrKeyNotFound
+	}
+	return e.meta, nil
+}
+
+// SetState changes the state of a key, for example to StateCompromised after a leak. A current
+// key that isn't active anymore stays current, but Encrypt fails until SetCurrent or Rotate.
+func (r *Keyring) SetState(id string, state State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	e.meta.State = state
+
+	return nil
+}
+
+// Remove drops a key for good. Anything encrypted with it can't be decrypted anymore.
+func (r *Keyring) Remove(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.entries[id]; !ok {
+		return ErrKeyNotFound
+	}
+	delete(r.entries, id)
+
+	if r.current == id {
+		r.current = ""
+	}
+
+	return nil
+}
+
+// Get returns a key whatever its metadata says.
+func (r *Keyring) Get(id string) (key.Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return e.key, nil
+}
+
+// Current returns the ID and key used for encryption.
+func (r *Keyring) Current() (string, key.Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.current == "" {
+		return "", nil, ErrNoCurrentKey
+	}
+	return r.current, r.entries[r.current].key, nil
+}
+
+// Usage returns what was encrypted with a key so far.
+func (r *Keyring) Usage(id string) (aesgo.Usage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[id]
+	if !ok {
+		return aesgo.Usage{}, ErrKeyNotFound
+	}
+	return e.budget.Usage(), nil
+}
+
+// Encrypt encrypts with the current key and returns a marshaled envelope tagged with its ID.
+func (r *Keyring) Encrypt(mode aesgo.Mode, plaintext []byte) ([]byte, error) {
+	return r.EncryptContext(context.Background(), mode, plaintext)
+}
+
+// EncryptContext is Encrypt with the audit context of ctx, see AuditContext.
+func (r *Keyring) EncryptContext(ctx context.Context, mode aesgo.Mode, plaintext []byte) ([]byte, error) {
+	encrypted, id, err := r.encrypt(mode, plaintext)
+	if (err == aesgo.ErrBudgetExhausted || errors.Is(err, ErrKeyExpired)) && r.rekey != nil {
+		if err = r.rotateExhausted(id); err == nil {
+			encrypted, id, err = r.encrypt(mode, plaintext)
+		}
+	}
+
+	r.record(ctx, OpEncrypt, id, err)
+	if err != nil {
+		return nil, err
+	}
+	return encrypted, nil
+}
+
+// encrypt also returns the ID of the key it used.
+func (r *Keyring) encrypt(mode aesgo.Mode, plaintext []byte) ([]byte, string, error) {
+	r.mu.RLock()
+	id, e := r.current, r.entries[r.current]
+	var err error
+	if e != nil {
+		err = r.usable(id, e.meta, PurposeEncrypt)
+	}
+	r.mu.RUnlock()
+
+	if id == "" {
+		return nil, "", ErrNoCurrentKey
+	}
+	if err != nil {
+		return nil, id, err
+	}
+
+	a, err := aesgo.NewCipher(e.key, aesgo.WithBudget(e.budget))
+	if err != nil {
+		return nil, id, err
+	}
+
+	c, err := a.EncryptCiphertext(mode, plaintext)
+	if err != nil {
+		return nil, id, err
+	}
+	c.KeyID = id
+
+	return c.Marshal(), id, nil
+}
+
+// rotateExhausted replaces the exhausted key, unless another goroutine already did it.
+func (r *Keyring) rotateExhausted(exhausted string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current != exhausted {
+		return nil
+	}
+
+	id, k, err := r.rekey()
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return ErrInvalidKeyID
+	}
+	if err := r.add(id, k); err != nil {
+		return err
+	}
+	if err := r.setCurrent(id); err != nil {
+		delete(r.entries, id)
+		return err
+	}
+	return nil
+}
+
+// Decrypt reads the key ID from the envelope and decrypts with that key, current, retired or
+// expired.
+func (r *Keyring) Decrypt(encrypted []byte) ([]byte, error) {
+	return r.DecryptContext(context.Background(), encrypted)
+}
+
+// DecryptContext is Decrypt with the audit context of ctx, see AuditContext.
+func (r *Keyring) DecryptContext(ctx context.Context, encrypted []byte) ([]byte, error) {
+	plaintext, id, err := r.decrypt(encrypted)
+	r.record(ctx, OpDecrypt, id, err)
+	return plaintext, err
+}
+
+// decrypt also returns the ID of the key in the envelope.
+func (r *Keyring) decrypt(encrypted []byte) ([]byte, string, error) {
+	c, err := aesgo.Unmarshal(encrypted)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.mu.RLock()
+	e, ok := r.entries[c.KeyID]
+	if ok {
+		err = r.usable(c.KeyID, e.meta, PurposeDecrypt)
+	}
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, c.KeyID, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, c.KeyID, err
+	}
+
+	a, err := aesgo.NewCipher(e.key)
+	if err != nil {
+		return nil, c.KeyID, err
+	}
+
+	plaintext, err := a.DecryptCiphertext(c)
+	return plaintext, c.KeyID, err
+}
@@ -0,0 +1,283 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestRotation(t *testing.T) {
+	r := New()
+
+	if _, err := r.Encrypt(aesgo.CBC, []byte("no key yet")); err != ErrNoCurrentKey {
+		t.Errorf("Expected %v, got %v", ErrNoCurrentKey, err)
+	}
+
+	if err := r.Rotate("v1", key.Bit128()); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	old, err := r.Encrypt(aesgo.CBC, []byte("encrypted with v1"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	if err := r.Rotate("v2", key.Bit128()); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	current, err := r.Encrypt(aesgo.CTR, []byte("encrypted with v2"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	c, err := aesgo.Unmarshal(current)
+	if err != nil {
+		t.Fatalf("Error unmarshaling: %s", err)
+	}
+	if c.KeyID != "v2" {
+		t.Errorf("Expected key ID v2, got %s", c.KeyID)
+	}
+
+	for expected, encrypted := range map[string][]byte{"encrypted with v1": old, "encrypted with v2": current} {
+		decrypted, err := r.Decrypt(encrypted)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted, []byte(expected)) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, expected)
+		}
+	}
+
+	if err := r.Remove("v1"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if _, err := r.Decrypt(old); err != ErrKeyNotFound {
+		t.Errorf("Expected %v, got %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestKeyringErrors(t *testing.T) {
+	r := New()
+
+	if err := r.Add("", key.Bit128()); err != ErrInvalidKeyID {
+		t.Errorf("Expected %v, got %v", ErrInvalidKeyID, err)
+	}
+
+	if err := r.Add("a", key.Bit128()); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if err := r.Add("a", key.Bit128()); err != ErrDuplicateKey {
+		t.Errorf("Expected %v, got %v", ErrDuplicateKey, err)
+	}
+
+	if err := r.SetCurrent("b"); err != ErrKeyNotFound {
+		t.Errorf("Expected %v, got %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	generated := 0
+	r := New(
+		WithLimits(aesgo.Limits{Messages: 2}),
+		WithRekey(func() (string, key.Key, error) {
+			generated++
+			return fmt.Sprintf("auto-%d", generated), key.Bit128(), nil
+		}),
+	)
+	r.Rotate("v1", key.Bit128())
+
+	var encrypted [][]byte
+	for i := 0; i < 5; i++ {
+		e, err := r.Encrypt(aesgo.GCM, []byte("message"))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		encrypted = append(encrypted, e)
+	}
+
+	// v1, v1, auto-1, auto-1, auto-2
+	if id, _, _ := r.Current(); id != "auto-2" {
+		t.Errorf("Expected auto-2, got %s", id)
+	}
+	for _, id := range []string{"v1", "auto-1"} {
+		if u, _ := r.Usage(id); u.Messages != 2 {
+			t.Errorf("Key %s: Expected %v, got %v", id, 2, u.Messages)
+		}
+	}
+
+	// the old keys still decrypt
+	for _, e := range encrypted {
+		if _, err := r.Decrypt(e); err != nil {
+			t.Errorf("Error decrypting: %s", err)
+		}
+	}
+}
+
+func TestLimitsWithoutRekey(t *testing.T) {
+	r := New(WithLimits(aesgo.Limits{Bytes: 10}))
+	r.Rotate("v1", key.Bit128())
+
+	if _, err := r.Encrypt(aesgo.CTR, make([]byte, 10)); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if _, err := r.Encrypt(aesgo.CTR, make([]byte, 1)); err != aesgo.ErrBudgetExhausted {
+		t.Errorf("Expected %v, got %v", aesgo.ErrBudgetExhausted, err)
+	}
+
+	if _, err := r.Usage("missing"); err != ErrKeyNotFound {
+		t.Errorf("Expected %v, got %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(WithClock(func() time.Time { return now }))
+
+	if err := r.Rotate("v1", key.Bit128(), WithExpiry(now.Add(time.Hour))); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	m, _ := r.Metadata("v1")
+	if !m.Created.Equal(now) || m.Purposes != PurposeAll || m.State != StateActive {
+		t.Errorf("Unexpected metadata %+v", m)
+	}
+
+	old, err := r.Encrypt(aesgo.GCM, []byte("before expiry"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	now = now.Add(time.Hour)
+	_, err = r.Encrypt(aesgo.GCM, []byte("after expiry"))
+	var keyErr *KeyError
+	if !errors.As(err, &keyErr) || keyErr.ID != "v1" || keyErr.Op != "encrypt" || !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("Expected %v for v1, got %v", ErrKeyExpired, err)
+	}
+	// expired keys still decrypt
+	if _, err := r.Decrypt(old); err != nil {
+		t.Errorf("Error decrypting: %s", err)
+	}
+
+	r.Rotate("v2", key.Bit128())
+	if m, _ := r.Metadata("v1"); m.State != StateRetired {
+		t.Errorf("Expected %v, got %v", StateRetired, m.State)
+	}
+
+	r.SetState("v1", StateCompromised)
+	if _, err := r.Decrypt(old); !errors.Is(err, ErrKeyCompromised) {
+		t.Errorf("Expected %v, got %v", ErrKeyCompromised, err)
+	}
+}
+
+func TestKeyStates(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		opts  []KeyOption
+		state State
+
+		expected error
+	}{
+		{
+			name:  "decrypt only",
+			opts:  []KeyOption{WithPurposes(PurposeDecrypt)},
+			state: StateActive,
+
+			expected: ErrKeyDecryptOnly,
+		},
+		{
+			name:  "compromised",
+			state: StateCompromised,
+
+			expected: ErrKeyCompromised,
+		},
+		{
+			name:  "expired",
+			opts:  []KeyOption{WithExpiry(now)},
+			state: StateActive,
+
+			expected: ErrKeyExpired,
+		},
+		{
+			name:  "retired and expired",
+			opts:  []KeyOption{WithExpiry(now)},
+			state: StateRetired,
+
+			expected: ErrKeyExpired,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := New(WithClock(func() time.Time { return now }))
+			r.Add("k", key.Bit128(), test.opts...)
+			r.SetState("k", test.state)
+
+			if err := r.SetCurrent("k"); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+			// a failed SetCurrent leaves the key as it was
+			if m, _ := r.Metadata("k"); m.State != test.state {
+				t.Errorf("Expected %v, got %v", test.state, m.State)
+			}
+		})
+	}
+
+	// a retired current key refuses to encrypt until SetCurrent brings it back
+	r := New()
+	r.Rotate("v1", key.Bit128())
+	r.SetState("v1", StateRetired)
+	if _, err := r.Encrypt(aesgo.CTR, []byte("x")); !errors.Is(err, ErrKeyRetired) {
+		t.Errorf("Expected %v, got %v", ErrKeyRetired, err)
+	}
+	if err := r.SetCurrent("v1"); err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+	if _, err := r.Encrypt(aesgo.CTR, []byte("x")); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	// an encrypt only key encrypts for someone else to decrypt
+	r.Rotate("outgoing", key.Bit128(), WithPurposes(PurposeEncrypt))
+	encrypted, err := r.Encrypt(aesgo.CTR, []byte("x"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if _, err := r.Decrypt(encrypted); !errors.Is(err, ErrKeyEncryptOnly) {
+		t.Errorf("Expected %v, got %v", ErrKeyEncryptOnly, err)
+	}
+
+	if err := r.Rotate("expired", key.Bit128(), WithExpiry(time.Now().Add(-time.Hour))); !errors.Is(err, ErrKeyExpired) {
+		t.Errorf("Expected %v, got %v", ErrKeyExpired, err)
+	}
+	if _, err := r.Metadata("expired"); err != ErrKeyNotFound {
+		t.Errorf("Expected %v, got %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestRekeyOnExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := New(
+		WithClock(func() time.Time { return now }),
+		WithRekey(func() (string, key.Key, error) {
+			return "auto", key.Bit128(), nil
+		}),
+	)
+	r.Rotate("v1", key.Bit128(), WithExpiry(now.Add(time.Minute)))
+
+	now = now.Add(time.Minute)
+	if _, err := r.Encrypt(aesgo.GCM, []byte("message")); err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if id, _, _ := r.Current(); id != "auto" {
+		t.Errorf("Expected auto, got %s", id)
+	}
+}
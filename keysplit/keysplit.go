@@ -0,0 +1,235 @@
+// Package keysplit splits a key into shares with Shamir's secret sharing, so a backup can be
+// spread between people or places and any threshold of them brings it back.
+//
+// Every byte of the secret is the constant term of a random polynomial of degree threshold - 1
+// over GF(2^8), the field of the gf256 package, and share x is that polynomial evaluated at x.
+// Threshold points give the polynomial back with Lagrange interpolation, fewer reveal nothing
+// about the secret: every value is equally likely.
+//
+// The shares aren't authenticated. A wrong or corrupted share gives a wrong secret without an
+// error, the checksum of String only catches typos. Compare the fingerprint of the key after
+// Combine with the one taken before Split.
+//
+// gf256.Mul branches on its inputs, so this isn't constant time.
+package keysplit
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/mario-areias/aes-go/gf256"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// MaxShares is the number of non zero elements of GF(2^8), x = 0 is the secret itself.
+const MaxShares = 255
+
+const sharePrefix = "aesgo-share-"
+
+var (
+	ErrInvalidThreshold  = errors.New("Threshold must be between 2 and the number of shares")
+	ErrTooManyShares     = errors.New("There can't be more than 255 shares")
+	ErrEmptySecret       = errors.New("Secret is empty")
+	ErrNotEnoughShares   = errors.New("Not enough shares to reach the threshold")
+	ErrDuplicateShare    = errors.New("Same share given twice")
+	ErrMismatchedShares  = errors.New("Shares are not from the same split")
+	ErrInvalidShare      = errors.New("Invalid share")
+	ErrChecksumMismatch  = errors.New("Share checksum doesn't match, check it for typos")
+	ErrInvalidSecretSize = errors.New("Combined secret is not a 128 or 256 bit key")
+)
+
+// Share is one point of every polynomial. X is never 0.
+type Share struct {
+	Threshold int
+	X         byte
+	Y         []byte
+}
+
+type Option func(*options)
+
+type options struct {
+	rand io.Reader
+}
+
+// WithRandReader sets where the coefficients come from. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// Split splits secret into n shares, any threshold of them give it back.
+func Split(secret []byte, n, threshold int, opts ...Option) ([]Share, error) {
+	o := options{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+	if n > MaxShares {
+		return nil, ErrTooManyShares
+	}
+	if threshold < 2 || threshold > n {
+		return nil, ErrInvalidThreshold
+	}
+
+	// coefficients[0] is the secret, the others are random. The same x is used for every byte.
+	coefficients := make([]byte, threshold)
+	defer clear(coefficients)
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Threshold: threshold, X: byte(i + 1), Y: make([]byte, len(secret))}
+	}
+
+	for b := range secret {
+		coefficients[0] = secret[b]
+		if _, err := io.ReadFull(o.rand, coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			shares[i].Y[b] = evaluate(coefficients, shares[i].X)
+		}
+	}
+
+	return shares, nil
+}
+
+// evaluate returns the polynomial at x with Horner's method.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = gf256.Add(gf256.Mul(y, x), coefficients[i])
+	}
+	return y
+}
+
+// Combine gives the secret back from at least threshold shares. Only the first threshold shares
+// are used.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, ErrNotEnoughShares
+	}
+
+	threshold := shares[0].Threshold
+	if threshold < 2 || threshold > MaxShares {
+		return nil, ErrInvalidShare
+	}
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("%w: got %d of %d", ErrNotEnoughShares, len(shares), threshold)
+	}
+	shares = shares[:threshold]
+
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if s.X == 0 || len(s.Y) == 0 {
+			return nil, ErrInvalidShare
+		}
+		if s.Threshold != threshold || len(s.Y) != len(shares[0].Y) {
+			return nil, ErrMismatchedShares
+		}
+		if seen[s.X] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateShare, s.X)
+		}
+		seen[s.X] = true
+	}
+
+	// the Lagrange basis polynomials at 0 only depend on the x, work them out once
+	basis := make([]byte, threshold)
+	for j := range shares {
+		l := byte(1)
+		for m := range shares {
+			if m == j {
+				continue
+			}
+			// x_m / (x_m - x_j), subtracting is adding
+			l = gf256.Mul(l, gf256.Mul(shares[m].X, gf256.Inverse(gf256.Add(shares[m].X, shares[j].X))))
+		}
+		basis[j] = l
+	}
+
+	secret := make([]byte, len(shares[0].Y))
+	for b := range secret {
+		for j, s := range shares {
+			secret[b] = gf256.Add(secret[b], gf256.Mul(s.Y[b], basis[j]))
+		}
+	}
+	return secret, nil
+}
+
+// SplitKey splits the material of k.
+func SplitKey(k key.Key, n, threshold int, opts ...Option) ([]Share, error) {
+	return Split(k.GetBytes(), n, threshold, opts...)
+}
+
+// CombineKey is Combine for shares of a 128 or 256 bit key.
+func CombineKey(shares []Share) (key.Key, error) {
+	secret, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(secret)
+
+	switch len(secret) {
+	case 16:
+		return key.NewKey([16]byte(secret)), nil
+	case 32:
+		return key.NewKey256([32]byte(secret)), nil
+	}
+	return nil, ErrInvalidSecretSize
+}
+
+// String encodes the share as text to print or write down:
+//
+//	aesgo-share-<threshold>-<x>-<y in hex>-<checksum>
+//
+// The checksum is the first 4 bytes of the SHA-256 of the rest, it catches typos and nothing else.
+func (s Share) String() string {
+	return fmt.Sprintf("%s%d-%d-%x-%x", sharePrefix, s.Threshold, s.X, s.Y, s.checksum())
+}
+
+func (s Share) checksum() []byte {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d-%d-%x", s.Threshold, s.X, s.Y)))
+	return sum[:4]
+}
+
+// ParseShare decodes the output of Share.String.
+func ParseShare(s string) (Share, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), sharePrefix)
+	fields := strings.Split(rest, "-")
+	if !ok || len(fields) != 4 {
+		return Share{}, ErrInvalidShare
+	}
+
+	threshold, err := strconv.Atoi(fields[0])
+	if err != nil || threshold < 2 || threshold > MaxShares {
+		return Share{}, ErrInvalidShare
+	}
+	x, err := strconv.ParseUint(fields[1], 10, 8)
+	if err != nil || x == 0 {
+		return Share{}, ErrInvalidShare
+	}
+	y, err := hex.DecodeString(fields[2])
+	if err != nil || len(y) == 0 {
+		return Share{}, ErrInvalidShare
+	}
+	check, err := hex.DecodeString(fields[3])
+	if err != nil {
+		return Share{}, ErrInvalidShare
+	}
+
+	share := Share{Threshold: threshold, X: byte(x), Y: y}
+	if !bytes.Equal(check, share.checksum()) {
+		return Share{}, ErrChecksumMismatch
+	}
+	return share, nil
+}
@@ -0,0 +1,292 @@
+package keysplit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("Let's test if this is working!")
+
+	tests := []struct {
+		name      string
+		n         int
+		threshold int
+	}{
+		{
+			name:      "2 of 2",
+			n:         2,
+			threshold: 2,
+		},
+		{
+			name:      "3 of 5",
+			n:         5,
+			threshold: 3,
+		},
+		{
+			name:      "255 of 255",
+			n:         255,
+			threshold: 255,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shares, err := Split(secret, test.n, test.threshold)
+			if err != nil {
+				t.Fatalf("Error splitting: %s", err)
+			}
+			if len(shares) != test.n {
+				t.Fatalf("Expected %d shares, got %d", test.n, len(shares))
+			}
+
+			// any threshold shares, in any order
+			for start := 0; start+test.threshold <= test.n; start++ {
+				subset := append([]Share{}, shares[start:start+test.threshold]...)
+				subset[0], subset[len(subset)-1] = subset[len(subset)-1], subset[0]
+
+				combined, err := Combine(subset)
+				if err != nil {
+					t.Fatalf("Error combining: %s", err)
+				}
+				if !bytes.Equal(combined, secret) {
+					t.Errorf("Got: %x, Expected: %x", combined, secret)
+				}
+			}
+
+			// one share less gives something else
+			if combined := combineBelow(shares[:test.threshold-1]); bytes.Equal(combined, secret) {
+				t.Errorf("Expected %d shares to not reveal the secret", test.threshold-1)
+			}
+		})
+	}
+}
+
+// combineBelow interpolates fewer shares than the threshold by pretending the threshold is lower.
+func combineBelow(shares []Share) []byte {
+	if len(shares) < 2 {
+		return nil
+	}
+	lowered := make([]Share, len(shares))
+	for i, s := range shares {
+		lowered[i] = Share{Threshold: len(shares), X: s.X, Y: s.Y}
+	}
+	combined, _ := Combine(lowered)
+	return combined
+}
+
+func TestPolynomial(t *testing.T) {
+	// 0x03 + 0x05x at x = 1 and x = 2 is 0x06 and 0x03 ^ gf256.Mul(0x05, 0x02) = 0x09
+	shares := []Share{
+		{Threshold: 2, X: 1, Y: []byte{0x06}},
+		{Threshold: 2, X: 2, Y: []byte{0x09}},
+	}
+
+	if y := evaluate([]byte{0x03, 0x05}, 2); y != 0x09 {
+		t.Errorf("Got: %x, Expected: %x", y, 0x09)
+	}
+	combined, err := Combine(shares)
+	if err != nil || !bytes.Equal(combined, []byte{0x03}) {
+		t.Errorf("Got: %x, Expected: %x (%v)", combined, []byte{0x03}, err)
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	shares, _ := Split([]byte("secret"), 5, 3)
+	other, _ := Split([]byte("secret"), 5, 2)
+	longer, _ := Split([]byte("longer secret"), 5, 3)
+
+	tests := []struct {
+		name   string
+		shares []Share
+
+		expected error
+	}{
+		{
+			name:   "no shares",
+			shares: nil,
+
+			expected: ErrNotEnoughShares,
+		},
+		{
+			name:   "below threshold",
+			shares: shares[:2],
+
+			expected: ErrNotEnoughShares,
+		},
+		{
+			name:   "duplicate",
+			shares: []Share{shares[0], shares[1], shares[0]},
+
+			expected: ErrDuplicateShare,
+		},
+		{
+			name:   "another threshold",
+			shares: []Share{shares[0], shares[1], other[2]},
+
+			expected: ErrMismatchedShares,
+		},
+		{
+			name:   "another length",
+			shares: []Share{shares[0], shares[1], longer[2]},
+
+			expected: ErrMismatchedShares,
+		},
+		{
+			name:   "x is zero",
+			shares: []Share{shares[0], shares[1], {Threshold: 3, X: 0, Y: shares[2].Y}},
+
+			expected: ErrInvalidShare,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Combine(test.shares); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    []byte
+		n         int
+		threshold int
+
+		expected error
+	}{
+		{
+			name:      "empty secret",
+			secret:    nil,
+			n:         3,
+			threshold: 2,
+
+			expected: ErrEmptySecret,
+		},
+		{
+			name:      "threshold of 1",
+			secret:    []byte("secret"),
+			n:         3,
+			threshold: 1,
+
+			expected: ErrInvalidThreshold,
+		},
+		{
+			name:      "threshold above n",
+			secret:    []byte("secret"),
+			n:         3,
+			threshold: 4,
+
+			expected: ErrInvalidThreshold,
+		},
+		{
+			name:      "too many shares",
+			secret:    []byte("secret"),
+			n:         256,
+			threshold: 2,
+
+			expected: ErrTooManyShares,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Split(test.secret, test.n, test.threshold); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	if _, err := Split([]byte("secret"), 3, 2, WithRandReader(strings.NewReader(""))); err == nil {
+		t.Errorf("Expected an error from an empty rand reader")
+	}
+}
+
+func TestKey(t *testing.T) {
+	for _, k := range []key.Key{key.Bit128(), key.Bit256()} {
+		shares, err := SplitKey(k, 3, 2)
+		if err != nil {
+			t.Fatalf("Error splitting: %s", err)
+		}
+
+		combined, err := CombineKey(shares[1:])
+		if err != nil {
+			t.Fatalf("Error combining: %s", err)
+		}
+		if combined.Fingerprint() != k.Fingerprint() {
+			t.Errorf("Got: %x, Expected: %x", combined.GetBytes(), k.GetBytes())
+		}
+	}
+
+	shares, _ := Split([]byte("not a key"), 2, 2)
+	if _, err := CombineKey(shares); err != ErrInvalidSecretSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidSecretSize, err)
+	}
+}
+
+func TestParseShare(t *testing.T) {
+	share := Share{Threshold: 3, X: 200, Y: []byte{0xde, 0xad, 0xbe, 0xef}}
+	encoded := share.String()
+
+	if !strings.HasPrefix(encoded, "aesgo-share-3-200-deadbeef-") {
+		t.Errorf("Unexpected encoding %s", encoded)
+	}
+
+	parsed, err := ParseShare(encoded + "\n")
+	if err != nil || parsed.Threshold != share.Threshold || parsed.X != share.X || !bytes.Equal(parsed.Y, share.Y) {
+		t.Errorf("Got: %+v, Expected: %+v (%v)", parsed, share, err)
+	}
+
+	tests := []struct {
+		name  string
+		share string
+
+		expected error
+	}{
+		{
+			name:  "typo",
+			share: strings.Replace(encoded, "deadbeef", "deadbeaf", 1),
+
+			expected: ErrChecksumMismatch,
+		},
+		{
+			name:  "no checksum",
+			share: "aesgo-share-3-200-deadbeef",
+
+			expected: ErrInvalidShare,
+		},
+		{
+			name:  "x is zero",
+			share: "aesgo-share-3-0-deadbeef-00000000",
+
+			expected: ErrInvalidShare,
+		},
+		{
+			name:  "x too big",
+			share: "aesgo-share-3-256-deadbeef-00000000",
+
+			expected: ErrInvalidShare,
+		},
+		{
+			name:  "not hex",
+			share: "aesgo-share-3-1-xyz-00000000",
+
+			expected: ErrInvalidShare,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseShare(test.share); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package keystore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// DPAPI stores keys in files encrypted with the Windows Data Protection API, which ties them to
+// the user account. The files are in %AppData%\<service>\keys.
+type DPAPI struct {
+	dir string
+
+	protect   func([]byte) ([]byte, error)
+	unprotect func([]byte) ([]byte, error)
+}
+
+// NewDPAPI fails with ErrUnsupported on other platforms than Windows.
+func NewDPAPI(service string) (*DPAPI, error) {
+	if err := validID(service); err != nil {
+		return nil, err
+	}
+
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return newDPAPI(filepath.Join(config, service, "keys"))
+}
+
+func (d *DPAPI) Store(id string, k key.Key) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+
+	encoded := encodeKey(k)
+	defer clear(encoded)
+
+	blob, err := d.protect(encoded)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(d.dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(d.path(id), blob, 0600)
+}
+
+func (d *DPAPI) Load(id string) (key.Key, error) {
+	if err := validID(id); err != nil {
+		return nil, err
+	}
+
+	blob, err := os.ReadFile(d.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := d.unprotect(blob)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(encoded)
+
+	return decodeKey(encoded)
+}
+
+func (d *DPAPI) Delete(id string) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+
+	err := os.Remove(d.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (d *DPAPI) path(id string) string {
+	return filepath.Join(d.dir, id+".dpapi")
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package keystore
+
+func newDPAPI(dir string) (*DPAPI, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,58 @@
+package keystore
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestDPAPI(t *testing.T) {
+	// stands in for CryptProtectData, which only exists on Windows
+	xor := func(b []byte) ([]byte, error) {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[i] = b[i] ^ 0x5a
+		}
+		return out, nil
+	}
+
+	dir := filepath.Join(t.TempDir(), "keys")
+	d := &DPAPI{dir: dir, protect: xor, unprotect: xor}
+	k := key.Bit128()
+
+	if err := d.Store("backups", k); err != nil {
+		t.Fatalf("Error storing: %s", err)
+	}
+
+	blob, err := os.ReadFile(filepath.Join(dir, "backups.dpapi"))
+	if err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+	if bytes.Contains(blob, k.GetBytes()) || len(blob) != 32 {
+		t.Errorf("Expected the protected key in hex, got %x", blob)
+	}
+
+	loaded, err := d.Load("backups")
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+	if !bytes.Equal(loaded.GetBytes(), k.GetBytes()) {
+		t.Errorf("Got: %x, Expected: %x", loaded.GetBytes(), k.GetBytes())
+	}
+
+	if err := d.Delete("backups"); err != nil {
+		t.Fatalf("Error deleting: %s", err)
+	}
+	if _, err := d.Load("backups"); err != ErrNotFound {
+		t.Errorf("Expected %v, got %v", ErrNotFound, err)
+	}
+	if err := d.Delete("backups"); err != ErrNotFound {
+		t.Errorf("Expected %v, got %v", ErrNotFound, err)
+	}
+	if err := d.Store("../escape", k); err != ErrInvalidKeyID {
+		t.Errorf("Expected %v, got %v", ErrInvalidKeyID, err)
+	}
+}
@@ -0,0 +1,67 @@
+//go:build windows
+
+package keystore
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	crypt32           = syscall.NewLazyDLL("crypt32.dll")
+	kernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procProtectData   = crypt32.NewProc("CryptProtectData")
+	procUnprotectData = crypt32.NewProc("CryptUnprotectData")
+	procLocalFree     = kernel32.NewProc("LocalFree")
+)
+
+// cryptProtectUIForbidden fails instead of showing a prompt.
+const cryptProtectUIForbidden = 0x1
+
+// dataBlob is DATA_BLOB.
+type dataBlob struct {
+	size uint32
+	data *byte
+}
+
+func newBlob(b []byte) *dataBlob {
+	if len(b) == 0 {
+		return &dataBlob{}
+	}
+	return &dataBlob{size: uint32(len(b)), data: &b[0]}
+}
+
+// free copies the blob Windows allocated, wipes it and frees it.
+func (b *dataBlob) free() []byte {
+	if b.data == nil {
+		return nil
+	}
+	src := unsafe.Slice(b.data, b.size)
+	out := make([]byte, b.size)
+	copy(out, src)
+	clear(src)
+	procLocalFree.Call(uintptr(unsafe.Pointer(b.data)))
+	return out
+}
+
+func newDPAPI(dir string) (*DPAPI, error) {
+	return &DPAPI{dir: dir, protect: protectData, unprotect: unprotectData}, nil
+}
+
+func protectData(b []byte) ([]byte, error) {
+	var out dataBlob
+	r, _, err := procProtectData.Call(uintptr(unsafe.Pointer(newBlob(b))), 0, 0, 0, 0, cryptProtectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return nil, err
+	}
+	return out.free(), nil
+}
+
+func unprotectData(b []byte) ([]byte, error) {
+	var out dataBlob
+	r, _, err := procUnprotectData.Call(uintptr(unsafe.Pointer(newBlob(b))), 0, 0, 0, 0, cryptProtectUIForbidden, uintptr(unsafe.Pointer(&out)))
+	if r == 0 {
+		return nil, err
+	}
+	return out.free(), nil
+}
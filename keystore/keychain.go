@@ -0,0 +1,76 @@
+package keystore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// exitItemNotFound is errSecItemNotFound, the exit status of security when there is no such item.
+const exitItemNotFound = 44
+
+// Keychain stores keys as generic passwords in the login keychain of macOS, the key ID is the
+// account and service the service.
+type Keychain struct {
+	service string
+	run     runner
+}
+
+func NewKeychain(service string) *Keychain {
+	return &Keychain{service: service, run: run}
+}
+
+func (kc *Keychain) Store(id string, k key.Key) error {
+	if err := kc.valid(id); err != nil {
+		return err
+	}
+
+	// in interactive mode the command is read from stdin, so the key isn't visible in ps
+	cmd := fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -w \"%s\"\n", kc.service, id, encodeKey(k))
+	_, err := kc.run([]byte(cmd), "security", "-i")
+	return err
+}
+
+func (kc *Keychain) Load(id string) (key.Key, error) {
+	if err := kc.valid(id); err != nil {
+		return nil, err
+	}
+
+	out, err := kc.run(nil, "security", "find-generic-password", "-s", kc.service, "-a", id, "-w")
+	if exitCode(err) == exitItemNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeKey(out)
+}
+
+func (kc *Keychain) Delete(id string) error {
+	if err := kc.valid(id); err != nil {
+		return err
+	}
+
+	_, err := kc.run(nil, "security", "delete-generic-password", "-s", kc.service, "-a", id)
+	if exitCode(err) == exitItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (kc *Keychain) valid(id string) error {
+	if err := validID(kc.service); err != nil {
+		return fmt.Errorf("service: %w", err)
+	}
+	return validID(id)
+}
+
+// exitCode returns the exit status of a failed command, or -1.
+func exitCode(err error) int {
+	var exit interface{ ExitCode() int }
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	return -1
+}
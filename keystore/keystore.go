@@ -0,0 +1,115 @@
+// Package keystore keeps keys in the key store of the operating system instead of files on disk:
+// the macOS Keychain, the secret service of Linux desktops (GNOME Keyring, KWallet) and DPAPI on
+// Windows.
+//
+// There are no dependencies. The Keychain is driven with the security command, the secret service
+// with secret-tool (from libsecret), and DPAPI is called from crypt32.dll. Keys are stored in hex.
+//
+//	ks, err := keystore.Default("aes-go")
+//	err = ks.Store("backups", k)
+//	k, err = ks.Load("backups")
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+var (
+	ErrNotFound     = errors.New("Key not found in the key store")
+	ErrUnsupported  = errors.New("No key store on this platform")
+	ErrInvalidKeyID = errors.New("Key ID can't be empty or contain spaces, quotes or slashes")
+	ErrInvalidKey   = errors.New("Stored key isn't a 128 or 256 bit key in hex")
+)
+
+// KeyStore stores keys under an ID. Store replaces a key that already exists.
+type KeyStore interface {
+	Store(id string, k key.Key) error
+	Load(id string) (key.Key, error)
+	Delete(id string) error
+}
+
+// Default returns the key store of this platform, keys are kept under service.
+func Default(service string) (KeyStore, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return NewKeychain(service), nil
+	case "windows":
+		return NewDPAPI(service)
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return NewSecretService(service), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, runtime.GOOS)
+}
+
+// runner runs a command with stdin and returns its stdout. Replaced in tests.
+type runner func(stdin []byte, name string, args ...string) ([]byte, error)
+
+func run(stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s isn't installed", ErrUnsupported, name)
+	}
+	if err != nil {
+		return out, &commandError{name: name, err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return out, nil
+}
+
+type commandError struct {
+	name   string
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("%s: %s", e.name, e.err)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.name, e.err, e.stderr)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// validID keeps IDs safe to pass to the commands and to use as file names.
+func validID(id string) error {
+	if id == "" || strings.ContainsAny(id, " \t\n\"'\\/") {
+		return ErrInvalidKeyID
+	}
+	return nil
+}
+
+func encodeKey(k key.Key) []byte {
+	return []byte(hex.EncodeToString(k.GetBytes()))
+}
+
+func decodeKey(b []byte) (key.Key, error) {
+	material, err := hex.DecodeString(string(bytes.TrimSpace(b)))
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	defer clear(material)
+
+	switch len(material) {
+	case 16:
+		return key.NewKey([16]byte(material)), nil
+	case 32:
+		return key.NewKey256([32]byte(material)), nil
+	}
+	return nil, ErrInvalidKey
+}
@@ -0,0 +1,171 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+type exitError int
+
+func (e exitError) Error() string {
+	return "exit status"
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
+
+// fakeCommands keeps secrets the way security and secret-tool would, and records the calls.
+type fakeCommands struct {
+	secrets map[string]string
+	calls   []string
+	stdin   []string
+}
+
+func (f *fakeCommands) run(stdin []byte, name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, name+" "+strings.Join(args, " "))
+	f.stdin = append(f.stdin, string(stdin))
+
+	switch {
+	case name == "security" && args[0] == "-i":
+		// add-generic-password -U -s "service" -a "id" -w "hex"
+		fields := strings.Fields(strings.ReplaceAll(string(stdin), `"`, ""))
+		f.secrets[fields[5]] = fields[7]
+		return nil, nil
+	case name == "secret-tool" && args[0] == "store":
+		f.secrets[args[6]] = string(stdin)
+		return nil, nil
+	}
+
+	id := args[len(args)-1]
+	if name == "security" {
+		id = args[4]
+	}
+	secret, ok := f.secrets[id]
+	if !ok {
+		if name == "security" {
+			return nil, exitError(exitItemNotFound)
+		}
+		if args[0] == "clear" {
+			return nil, nil
+		}
+		return nil, exitError(1)
+	}
+
+	switch args[0] {
+	case "find-generic-password", "lookup":
+		return []byte(secret + "\n"), nil
+	case "delete-generic-password", "clear":
+		delete(f.secrets, id)
+	}
+	return nil, nil
+}
+
+func TestCommandStores(t *testing.T) {
+	k := key.Bit256()
+
+	tests := []struct {
+		name  string
+		store func(*fakeCommands) KeyStore
+
+		expected []string
+	}{
+		{
+			name: "keychain",
+			store: func(f *fakeCommands) KeyStore {
+				return &Keychain{service: "aes-go", run: f.run}
+			},
+
+			expected: []string{
+				"security -i",
+				"security find-generic-password -s aes-go -a backups -w",
+				"security delete-generic-password -s aes-go -a backups",
+			},
+		},
+		{
+			name: "secret service",
+			store: func(f *fakeCommands) KeyStore {
+				return &SecretService{service: "aes-go", run: f.run}
+			},
+
+			expected: []string{
+				"secret-tool store --label aes-go key backups service aes-go key backups",
+				"secret-tool lookup service aes-go key backups",
+				"secret-tool lookup service aes-go key backups",
+				"secret-tool clear service aes-go key backups",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := &fakeCommands{secrets: make(map[string]string)}
+			ks := test.store(f)
+
+			if err := ks.Store("backups", k); err != nil {
+				t.Fatalf("Error storing: %s", err)
+			}
+			loaded, err := ks.Load("backups")
+			if err != nil {
+				t.Fatalf("Error loading: %s", err)
+			}
+			if !bytes.Equal(loaded.GetBytes(), k.GetBytes()) {
+				t.Errorf("Got: %x, Expected: %x", loaded.GetBytes(), k.GetBytes())
+			}
+			if err := ks.Delete("backups"); err != nil {
+				t.Fatalf("Error deleting: %s", err)
+			}
+
+			for i, call := range test.expected {
+				if i >= len(f.calls) || f.calls[i] != call {
+					t.Errorf("Expected calls %q, got %q", test.expected, f.calls)
+					break
+				}
+			}
+			// the key is never on the command line
+			for _, call := range f.calls {
+				if strings.Contains(call, hex.EncodeToString(k.GetBytes())) {
+					t.Errorf("Key passed as an argument: %s", call)
+				}
+			}
+
+			if _, err := ks.Load("backups"); err != ErrNotFound {
+				t.Errorf("Expected %v, got %v", ErrNotFound, err)
+			}
+			if err := ks.Delete("backups"); err != ErrNotFound {
+				t.Errorf("Expected %v, got %v", ErrNotFound, err)
+			}
+			if err := ks.Store(`bad"id`, k); err != ErrInvalidKeyID {
+				t.Errorf("Expected %v, got %v", ErrInvalidKeyID, err)
+			}
+		})
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	failing := func(stdin []byte, name string, args ...string) ([]byte, error) {
+		return nil, &commandError{name: name, err: exitError(2), stderr: "daemon not running"}
+	}
+
+	ks := &SecretService{service: "aes-go", run: failing}
+	if _, err := ks.Load("backups"); err == nil || !strings.Contains(err.Error(), "daemon not running") {
+		t.Errorf("Expected the stderr of the command, got %v", err)
+	}
+
+	garbage := func(stdin []byte, name string, args ...string) ([]byte, error) {
+		return []byte("not hex"), nil
+	}
+	kc := &Keychain{service: "aes-go", run: garbage}
+	if _, err := kc.Load("backups"); err != ErrInvalidKey {
+		t.Errorf("Expected %v, got %v", ErrInvalidKey, err)
+	}
+
+	if _, err := run(nil, "aesgo-command-that-does-not-exist"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected %v, got %v", ErrUnsupported, err)
+	}
+}
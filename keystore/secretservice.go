@@ -0,0 +1,57 @@
+package keystore
+
+import (
+	"fmt"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+// SecretService stores keys with the freedesktop secret service (GNOME Keyring, KWallet), through
+// secret-tool. Items have two attributes, service and key, the key ID.
+type SecretService struct {
+	service string
+	run     runner
+}
+
+func NewSecretService(service string) *SecretService {
+	return &SecretService{service: service, run: run}
+}
+
+func (s *SecretService) Store(id string, k key.Key) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+
+	// secret-tool reads the secret from stdin
+	label := fmt.Sprintf("%s key %s", s.service, id)
+	_, err := s.run(encodeKey(k), "secret-tool", "store", "--label", label, "service", s.service, "key", id)
+	return err
+}
+
+func (s *SecretService) Load(id string) (key.Key, error) {
+	if err := validID(id); err != nil {
+		return nil, err
+	}
+
+	out, err := s.run(nil, "secret-tool", "lookup", "service", s.service, "key", id)
+	// lookup exits with 1 and prints nothing when there is no match
+	if exitCode(err) == 1 && len(out) == 0 {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeKey(out)
+}
+
+// Delete looks the key up first, secret-tool clear succeeds when nothing matches.
+func (s *SecretService) Delete(id string) error {
+	k, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+	k.Destroy()
+
+	_, err = s.run(nil, "secret-tool", "clear", "service", s.service, "key", id)
+	return err
+}
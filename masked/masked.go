@@ -0,0 +1,161 @@
+// Package masked is AES with first-order Boolean masking, the usual countermeasure against
+// differential power analysis (DPA) on smart cards and hardware.
+//
+// DPA works because the power used by the device correlates with the values it handles, like the
+// output of the S-box in the first round, which depends on a single key byte. With enough traces the
+// attacker guesses the key byte whose predicted values correlate with the measurements.
+// Masking never handles those values: every intermediate value is XORed with a random mask that is
+// fresh for every block, so on its own it is uniformly random and doesn't correlate with anything.
+//
+// The scheme is the one of Herbst, Oswald and Mangard (ACNS 2006):
+//
+//   - m and m' mask the input and output of the S-box. A masked table S'(x ^ m) = S(x) ^ m' is
+//     computed for every block.
+//   - m1, m2, m3 and m4 mask the rows before MixColumns. With the same mask on every row, MixColumns
+//     would XOR two bytes with the same mask and remove it. MixColumns is linear, so the output is
+//     masked with MixColumns(m1, m2, m3, m4).
+//   - the round keys are XORed with the masks to remove the old ones and restore m for the next round.
+//
+// It only protects against first-order attacks, combining two points of the same trace removes
+// the masks. The round keys aren't masked either, a real implementation masks the key schedule too.
+// Like the rest of the project it is for learning, Go gives no control over what the CPU leaks.
+package masked
+
+import (
+	"crypto/rand"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+// Cipher isn't safe for concurrent use, the masks and the masked table are per block.
+type Cipher struct {
+	roundKeys [][16]byte
+	rand      io.Reader
+	probe     func(round int, state [16]byte)
+
+	// recomputed for every block
+	table     [256]byte
+	maskedKey [][16]byte
+}
+
+type Option func(*Cipher)
+
+// WithRandReader sets where the masks come from. Defaults to crypto/rand. A reader of zeros turns
+// the masking off, which is handy to compare.
+func WithRandReader(r io.Reader) Option {
+	return func(c *Cipher) {
+		c.rand = r
+	}
+}
+
+// WithProbe calls probe with the state after the SubBytes of every round, as it is in memory. It's
+// what a power analysis sees: with masks it changes from block to block even for the same input.
+func WithProbe(probe func(round int, state [16]byte)) Option {
+	return func(c *Cipher) {
+		c.probe = probe
+	}
+}
+
+func New(k key.Key, opts ...Option) (*Cipher, error) {
+	roundKeys, err := aesgo.ExpandKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cipher{roundKeys: roundKeys, rand: rand.Reader, maskedKey: make([][16]byte, len(roundKeys))}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// EncryptBlock encrypts one block with fresh masks. It only fails when reading the masks fails.
+func (c *Cipher) EncryptBlock(b [16]byte) ([16]byte, error) {
+	var masks [6]byte
+	if _, err := io.ReadFull(c.rand, masks[:]); err != nil {
+		return [16]byte{}, err
+	}
+	m, mOut := masks[0], masks[1]
+	rows := [4]byte(masks[2:6])
+
+	c.setup(m, mOut, rows)
+	rounds := len(c.roundKeys) - 1
+
+	// the plaintext is only XORed with the masked key, so the state is masked with m from the start
+	s := xor(b, c.maskedKey[0])
+
+	for r := 1; r <= rounds; r++ {
+		// masked with m, then m' after the table
+		for i := range s {
+			s[i] = c.table[s[i]]
+		}
+		if c.probe != nil {
+			c.probe(r, s)
+		}
+
+		s = shiftRows(s)
+
+		if r < rounds {
+			// row i goes from m' to mi, MixColumns gives the masks of the masked round key
+			for i := range s {
+				s[i] ^= mOut ^ rows[i%4]
+			}
+			s = primitives.FromState(primitives.MixColumns(primitives.ToState(s)))
+		}
+
+		// removes the masks left and adds m for the next round, or nothing after the last one
+		s = xor(s, c.maskedKey[r])
+	}
+
+	return s, nil
+}
+
+// setup computes the masked table and round keys for the masks of a block.
+func (c *Cipher) setup(m, mOut byte, rows [4]byte) {
+	sbox := primitives.SBox()
+	for x := 0; x < 256; x++ {
+		c.table[byte(x)^m] = sbox[x] ^ mOut
+	}
+
+	// every column of the state is masked with the rows, and so is every column after MixColumns
+	var rowMasks [16]byte
+	for i := range rowMasks {
+		rowMasks[i] = rows[i%4]
+	}
+	mixed := primitives.FromState(primitives.MixColumns(primitives.ToState(rowMasks)))
+
+	last := len(c.roundKeys) - 1
+	for r, rk := range c.roundKeys {
+		for i := range rk {
+			switch r {
+			case 0:
+				c.maskedKey[r][i] = rk[i] ^ m
+			case last:
+				c.maskedKey[r][i] = rk[i] ^ mOut
+			default:
+				c.maskedKey[r][i] = rk[i] ^ mixed[i] ^ m
+			}
+		}
+	}
+}
+
+// shiftRows works on the block, byte i is row i%4 of column i/4.
+func shiftRows(b [16]byte) [16]byte {
+	var s [16]byte
+	for c := 0; c < 4; c++ {
+		for r := 0; r < 4; r++ {
+			s[c*4+r] = b[((c+r)%4)*4+r]
+		}
+	}
+	return s
+}
+
+func xor(a, b [16]byte) [16]byte {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
@@ -0,0 +1,132 @@
+package masked
+
+import (
+	"bytes"
+	"crypto/aes"
+	"io"
+	"math/rand"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+func TestMaskedMatchesUnmasked(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for _, size := range []int{16, 32} {
+		k, _ := key.Random(size, key.WithRandReader(r))
+
+		c, err := New(k, WithRandReader(r))
+		if err != nil {
+			t.Fatalf("Error creating cipher: %s", err)
+		}
+		a, _ := aesgo.NewCipher(k)
+		std, _ := aes.NewCipher(k.GetBytes())
+
+		for i := 0; i < 100; i++ {
+			var b [16]byte
+			r.Read(b[:])
+
+			got, err := c.EncryptBlock(b)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+
+			expected := primitives.FromState(a.EncryptBlock(b))
+			if got != expected {
+				t.Fatalf("AES-%d of %x. Expected %x, got %x", size*8, b, expected, got)
+			}
+
+			var fromStd [16]byte
+			std.Encrypt(fromStd[:], b[:])
+			if got != fromStd {
+				t.Fatalf("AES-%d of %x. Expected %x, got %x", size*8, b, fromStd, got)
+			}
+		}
+	}
+}
+
+func TestFIPSVector(t *testing.T) {
+	// FIPS-197 Appendix B
+	k := key.NewKey([16]byte{0x2b, 0x7e, 0x15, 0x16, 0x28, 0xae, 0xd2, 0xa6, 0xab, 0xf7, 0x15, 0x88, 0x09, 0xcf, 0x4f, 0x3c})
+	input := [16]byte{0x32, 0x43, 0xf6, 0xa8, 0x88, 0x5a, 0x30, 0x8d, 0x31, 0x31, 0x98, 0xa2, 0xe0, 0x37, 0x07, 0x34}
+	expected := [16]byte{0x39, 0x25, 0x84, 0x1d, 0x02, 0xdc, 0x09, 0xfb, 0xdc, 0x11, 0x85, 0x97, 0x19, 0x6a, 0x0b, 0x32}
+
+	c, _ := New(k)
+	if got, _ := c.EncryptBlock(input); got != expected {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+}
+
+// The probe sees what a power trace would. Without masks the state after the first SubBytes is
+// always the same for the same input, with masks it's random (m' has 256 values, a few repeat).
+func TestMasksHideIntermediateValues(t *testing.T) {
+	k := key.Bit128()
+	input := [16]byte{1, 2, 3}
+
+	tests := []struct {
+		name string
+
+		rand io.Reader
+
+		minDistinct, maxDistinct int
+	}{
+		{
+			name: "masked",
+
+			rand: rand.New(rand.NewSource(1)),
+
+			minDistinct: 40,
+			maxDistinct: 50,
+		},
+		{
+			name: "unmasked",
+
+			rand: bytes.NewReader(make([]byte, 6*50)),
+
+			minDistinct: 1,
+			maxDistinct: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seen := make(map[[16]byte]bool)
+			probe := func(round int, state [16]byte) {
+				if round == 1 {
+					seen[state] = true
+				}
+			}
+
+			c, _ := New(k, WithRandReader(test.rand), WithProbe(probe))
+			for i := 0; i < 50; i++ {
+				if _, err := c.EncryptBlock(input); err != nil {
+					t.Fatalf("Error encrypting: %s", err)
+				}
+			}
+
+			if len(seen) < test.minDistinct || len(seen) > test.maxDistinct {
+				t.Errorf("Expected between %d and %d distinct states, got %d", test.minDistinct, test.maxDistinct, len(seen))
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(key.NewKey256([32]byte{}), WithRandReader(bytes.NewReader(nil))); err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+
+	c, _ := New(key.Bit128(), WithRandReader(bytes.NewReader(make([]byte, 3))))
+	if _, err := c.EncryptBlock([16]byte{}); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	k := key.Bit128()
+	k.Destroy()
+	if _, err := New(k); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package mmo is a hash function built from AES with the Matyas-Meyer-Oseas construction.
+// It is here to learn from, don't use it for anything real.
+//
+// The compression function turns the chaining value H and a 16 byte message block m into the next
+// chaining value:
+//
+//	H' = AES(key = H, m) XOR m
+//
+// The XOR is what makes it one-way: without it anyone could decrypt H' with the key H and get m back.
+// The blocks are chained with Merkle-Damgard: the message is padded with 0x80, zeros and its length
+// in bits (64 bits), and the last chaining value is the hash.
+//
+// The hash is only as big as the block, 128 bits, so by the birthday bound a collision takes
+// about 2^64 evaluations. That was fine in the 90s but it isn't a comfortable margin now, which is
+// why SHA-256 has a 256 bit output. See the tests, collisions of a truncated hash are found in
+// the time predicted.
+package mmo
+
+import (
+	"encoding/binary"
+	"hash"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+const (
+	Size      = 16
+	BlockSize = 16
+)
+
+// iv is the first chaining value, any fixed value works. These are the first digits of pi.
+var iv = [16]byte{0x24, 0x3f, 0x6a, 0x88, 0x85, 0xa3, 0x08, 0xd3, 0x13, 0x19, 0x8a, 0x2e, 0x03, 0x70, 0x73, 0x44}
+
+type digest struct {
+	h   [16]byte
+	buf []byte
+	len uint64
+}
+
+// New returns a hash.Hash computing the MMO hash.
+func New() hash.Hash {
+	d := &digest{}
+	d.Reset()
+	return d
+}
+
+func Sum(data []byte) [Size]byte {
+	d := New()
+	d.Write(data)
+	return [Size]byte(d.Sum(nil))
+}
+
+// Compress is the compression function on its own.
+func Compress(h, m [16]byte) [16]byte {
+	// NewCipher only fails for a bad key size, the key here is always 16 bytes
+	a, err := aesgo.NewCipher(key.NewKey(h))
+	if err != nil {
+		panic(err)
+	}
+
+	c := primitives.FromState(a.EncryptBlock(m))
+	for i := range c {
+		c[i] ^= m[i]
+	}
+	return c
+}
+
+func (d *digest) Write(p []byte) (int, error) {
+	d.len += uint64(len(p))
+	d.buf = append(d.buf, p...)
+
+	for len(d.buf) >= BlockSize {
+		d.h = Compress(d.h, [16]byte(d.buf[:BlockSize]))
+		d.buf = d.buf[BlockSize:]
+	}
+	d.buf = append(d.buf[:0:0], d.buf...)
+
+	return len(p), nil
+}
+
+// Sum pads a copy of the state, more data can be written afterwards.
+func (d *digest) Sum(b []byte) []byte {
+	c := *d
+	c.buf = append([]byte{}, d.buf...)
+
+	// 0x80, zeros until 8 bytes are left in the block, and the length in bits
+	padding := make([]byte, 1, 2*BlockSize)
+	padding[0] = 0x80
+	for (len(c.buf)+len(padding))%BlockSize != BlockSize-8 {
+		padding = append(padding, 0)
+	}
+	padding = binary.BigEndian.AppendUint64(padding, d.len*8)
+	c.Write(padding)
+
+	return append(b, c.h[:]...)
+}
+
+func (d *digest) Reset() {
+	d.h = iv
+	d.buf = nil
+	d.len = 0
+}
+
+func (d *digest) Size() int {
+	return Size
+}
+
+func (d *digest) BlockSize() int {
+	return BlockSize
+}
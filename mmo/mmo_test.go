@@ -0,0 +1,125 @@
+package mmo
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// reference is the same construction written with crypto/aes.
+func reference(data []byte) []byte {
+	padded := append([]byte{}, data...)
+	padded = append(padded, 0x80)
+	for len(padded)%16 != 8 {
+		padded = append(padded, 0)
+	}
+	padded = binary.BigEndian.AppendUint64(padded, uint64(len(data))*8)
+
+	h := iv[:]
+	for i := 0; i < len(padded); i += 16 {
+		block, _ := aes.NewCipher(h)
+		next := make([]byte, 16)
+		block.Encrypt(next, padded[i:i+16])
+		for j := range next {
+			next[j] ^= padded[i+j]
+		}
+		h = next
+	}
+	return h
+}
+
+func TestSum(t *testing.T) {
+	for _, size := range []int{0, 1, 7, 8, 15, 16, 17, 100} {
+		data := bytes.Repeat([]byte{0xab}, size)
+
+		got := Sum(data)
+		if expected := reference(data); !bytes.Equal(got[:], expected) {
+			t.Errorf("size %d: Got: %x, Expected: %x", size, got, expected)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	data := []byte("The quick brown fox jumps over the lazy dog")
+	expected := Sum(data)
+
+	h := New()
+	for _, b := range data {
+		h.Write([]byte{b})
+		// Sum in the middle doesn't change anything
+		h.Sum(nil)
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, expected[:]) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	h.Reset()
+	h.Write(data)
+	if got := h.Sum(nil); !bytes.Equal(got, expected[:]) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	// the padding includes the length, so trailing zeros change the hash
+	if Sum([]byte("abc")) == Sum([]byte("abc\x00")) {
+		t.Errorf("Expected different hashes")
+	}
+}
+
+// Without the XOR the compression function can be inverted with the key: decrypting the
+// output gives the message block back. With it, the decryption is of AES(H, m) XOR m, which is
+// no use without knowing m already.
+func TestCompressIsNotInvertible(t *testing.T) {
+	h := [16]byte{1}
+	m := [16]byte([]byte("a secret block!!"))
+
+	out := Compress(h, m)
+
+	block, _ := aes.NewCipher(h[:])
+	var decrypted [16]byte
+	block.Decrypt(decrypted[:], out[:])
+	if decrypted == m {
+		t.Errorf("Compression output decrypts to the message")
+	}
+
+	// what the XOR hides: E(h, m) = out XOR m
+	var encrypted [16]byte
+	block.Encrypt(encrypted[:], m[:])
+	for i := range encrypted {
+		encrypted[i] ^= m[i]
+	}
+	if encrypted != out {
+		t.Errorf("Got: %x, Expected: %x", out, encrypted)
+	}
+}
+
+// A generic collision search on the hash cut to n bits needs about 2^(n/2) tries (the birthday
+// bound). The search below finds one for 24 bits after a few thousand hashes. The full 128 bits
+// need about 2^64, out of reach for a test but not for a determined attacker with enough hardware.
+func TestBirthdayBound(t *testing.T) {
+	const bits = 24
+
+	seen := make(map[uint32]string)
+	for i := 0; ; i++ {
+		msg := fmt.Sprintf("message %d", i)
+		h := Sum([]byte(msg))
+		prefix := binary.BigEndian.Uint32(h[:4]) >> (32 - bits)
+
+		if other, ok := seen[prefix]; ok {
+			t.Logf("%q and %q collide on %d bits after %d hashes, the bound is about %.0f", other, msg, bits, i+1, math.Sqrt(math.Pow(2, bits)))
+
+			// the full hashes are still different
+			if Sum([]byte(other)) == h {
+				t.Errorf("Full collision found: %q and %q", other, msg)
+			}
+			// way more than expected means something is off with the output distribution
+			if i > 16*(1<<(bits/2)) {
+				t.Errorf("Expected a collision after about %d hashes, took %d", 1<<(bits/2), i+1)
+			}
+			return
+		}
+		seen[prefix] = msg
+	}
+}
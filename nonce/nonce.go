@@ -0,0 +1,154 @@
+// Package nonce generates nonces and catches them being reused.
+//
+// Reusing a nonce with the same key is the most common way AES goes wrong in practice: with CTR
+// and GCM the same keystream comes out twice, so the XOR of two ciphertexts is the XOR of the
+// plaintexts, and GCM also gives away the authentication key. There are three generators, pick
+// one by how the key is used:
+//
+//   - Random: fresh random bytes every time. Fine while the number of messages per key stays well
+//     below the birthday bound, 2^32 for 12 byte nonces.
+//   - Counter: a random prefix and a counter. Never repeats under one generator but it must be the
+//     only one for the key, and the counter must not go back (e.g. after a restart).
+//   - Timestamp: the time in seconds followed by random bytes, so nonces from different processes
+//     or restarts only collide if they are from the same second.
+//
+// Tracker remembers the nonces seen for each key and returns ErrReuse when one comes back.
+package nonce
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// Size is the nonce size of GCM.
+	Size = 12
+
+	counterSize   = 8
+	timestampSize = 4
+)
+
+var (
+	ErrInvalidSize = errors.New("Nonce size is too small for this generator")
+	ErrExhausted   = errors.New("Nonce counter is exhausted, the key must be replaced")
+)
+
+type Generator interface {
+	// Next returns a nonce that wasn't returned before.
+	Next() ([]byte, error)
+}
+
+type Option func(*options)
+
+type options struct {
+	rand io.Reader
+	now  func() time.Time
+}
+
+// WithRandReader replaces crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// WithClock replaces time.Now in the Timestamp generator, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{rand: rand.Reader, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type Random struct {
+	size int
+	rand io.Reader
+}
+
+func NewRandom(size int, opts ...Option) (*Random, error) {
+	if size < Size {
+		return nil, ErrInvalidSize
+	}
+	return &Random{size: size, rand: newOptions(opts).rand}, nil
+}
+
+func (g *Random) Next() ([]byte, error) {
+	n := make([]byte, g.size)
+	if _, err := io.ReadFull(g.rand, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Counter is a random prefix followed by a big endian 64 bit counter. It is safe for concurrent use.
+type Counter struct {
+	mu      sync.Mutex
+	prefix  []byte
+	counter uint64
+	done    bool
+}
+
+// NewCounter picks the random prefix, size-8 bytes. size must be at least 8, with exactly 8 there is no prefix.
+func NewCounter(size int, opts ...Option) (*Counter, error) {
+	if size < counterSize {
+		return nil, ErrInvalidSize
+	}
+
+	prefix := make([]byte, size-counterSize)
+	if _, err := io.ReadFull(newOptions(opts).rand, prefix); err != nil {
+		return nil, err
+	}
+	return &Counter{prefix: prefix}, nil
+}
+
+// Next returns ErrExhausted after 2^64 nonces instead of wrapping around.
+func (g *Counter) Next() ([]byte, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.done {
+		return nil, ErrExhausted
+	}
+
+	n := binary.BigEndian.AppendUint64(append([]byte{}, g.prefix...), g.counter)
+	g.counter++
+	g.done = g.counter == 0
+	return n, nil
+}
+
+// Timestamp is the unix time in seconds, big endian in 4 bytes, followed by random bytes.
+type Timestamp struct {
+	size int
+	rand io.Reader
+	now  func() time.Time
+}
+
+// NewTimestamp needs at least 12 bytes so there are 8 random bytes after the time.
+func NewTimestamp(size int, opts ...Option) (*Timestamp, error) {
+	if size < Size {
+		return nil, ErrInvalidSize
+	}
+	o := newOptions(opts)
+	return &Timestamp{size: size, rand: o.rand, now: o.now}, nil
+}
+
+func (g *Timestamp) Next() ([]byte, error) {
+	n := make([]byte, g.size)
+	// wraps in 2106
+	binary.BigEndian.PutUint32(n, uint32(g.now().Unix()))
+	if _, err := io.ReadFull(g.rand, n[timestampSize:]); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
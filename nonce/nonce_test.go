@@ -0,0 +1,107 @@
+package nonce
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		name string
+
+		new func(size int) (Generator, error)
+	}{
+		{name: "random", new: func(size int) (Generator, error) { return NewRandom(size) }},
+		{name: "counter", new: func(size int) (Generator, error) { return NewCounter(size) }},
+		{name: "timestamp", new: func(size int) (Generator, error) { return NewTimestamp(size) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, size := range []int{Size, 16} {
+				g, err := test.new(size)
+				if err != nil {
+					t.Fatalf("Error creating generator: %s", err)
+				}
+
+				seen := make(map[string]bool)
+				for i := 0; i < 1000; i++ {
+					n, err := g.Next()
+					if err != nil {
+						t.Fatalf("Error generating: %s", err)
+					}
+					if len(n) != size {
+						t.Fatalf("Expected %v, got %v", size, len(n))
+					}
+					if seen[string(n)] {
+						t.Fatalf("Nonce repeated: %x", n)
+					}
+					seen[string(n)] = true
+				}
+			}
+		})
+	}
+}
+
+func TestCounter(t *testing.T) {
+	prefix := []byte{1, 2, 3, 4}
+	g, err := NewCounter(Size, WithRandReader(bytes.NewReader(prefix)))
+	if err != nil {
+		t.Fatalf("Error creating generator: %s", err)
+	}
+
+	for i := uint64(0); i < 3; i++ {
+		n, _ := g.Next()
+		expected := binary.BigEndian.AppendUint64([]byte{1, 2, 3, 4}, i)
+		if !bytes.Equal(n, expected) {
+			t.Errorf("Got: %x, Expected: %x", n, expected)
+		}
+	}
+
+	// the last counter value is used, then it stops
+	g.counter = math.MaxUint64
+	if _, err := g.Next(); err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+	if _, err := g.Next(); err != ErrExhausted {
+		t.Errorf("Expected %v, got %v", ErrExhausted, err)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	random := bytes.Repeat([]byte{0xaa}, 8)
+
+	g, _ := NewTimestamp(Size, WithClock(func() time.Time { return now }), WithRandReader(bytes.NewReader(random)))
+	n, err := g.Next()
+	if err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+
+	if ts := binary.BigEndian.Uint32(n); int64(ts) != now.Unix() {
+		t.Errorf("Expected %v, got %v", now.Unix(), ts)
+	}
+	if !bytes.Equal(n[timestampSize:], random) {
+		t.Errorf("Got: %x, Expected: %x", n[timestampSize:], random)
+	}
+
+	// the random source is empty now
+	if _, err := g.Next(); err == nil {
+		t.Errorf("Expected an error when the source runs out")
+	}
+}
+
+func TestInvalidSize(t *testing.T) {
+	if _, err := NewRandom(8); err != ErrInvalidSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidSize, err)
+	}
+	if _, err := NewCounter(7); err != ErrInvalidSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidSize, err)
+	}
+	if _, err := NewTimestamp(11); err != ErrInvalidSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidSize, err)
+	}
+}
@@ -0,0 +1,95 @@
+package nonce
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+var ErrReuse = errors.New("Nonce was already used with this key")
+
+// Tracker keeps every nonce used with each key in memory, it grows with every message. Use it
+// in tests and for keys with a bounded number of messages, and call Forget when a key is retired,
+// before it is destroyed.
+// It is safe for concurrent use.
+type Tracker struct {
+	mu   sync.Mutex
+	seen map[[32]byte]map[string]struct{}
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{seen: make(map[[32]byte]map[string]struct{})}
+}
+
+// Use records nonce for k and returns ErrReuse if it was recorded before. Keys are told apart by
+// their fingerprint.
+func (t *Tracker) Use(k key.Key, nonce []byte) error {
+	if k.Destroyed() {
+		return key.ErrDestroyed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fpr := k.Fingerprint()
+	nonces, ok := t.seen[fpr]
+	if !ok {
+		nonces = make(map[string]struct{})
+		t.seen[fpr] = nonces
+	}
+
+	if _, ok := nonces[string(nonce)]; ok {
+		return ErrReuse
+	}
+	nonces[string(nonce)] = struct{}{}
+	return nil
+}
+
+// Seen is the number of nonces recorded for k.
+func (t *Tracker) Seen(k key.Key) (int, error) {
+	if k.Destroyed() {
+		return 0, key.ErrDestroyed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.seen[k.Fingerprint()]), nil
+}
+
+// Forget drops the nonces of k. A destroyed key has no fingerprint, so its nonces can't be found
+// anymore and it returns key.ErrDestroyed.
+func (t *Tracker) Forget(k key.Key) error {
+	if k.Destroyed() {
+		return key.ErrDestroyed
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.seen, k.Fingerprint())
+	return nil
+}
+
+// Checked wraps g so every nonce it returns is recorded for k first.
+func (t *Tracker) Checked(k key.Key, g Generator) Generator {
+	return &checked{t: t, k: k, g: g}
+}
+
+type checked struct {
+	t *Tracker
+	k key.Key
+	g Generator
+}
+
+func (c *checked) Next() ([]byte, error) {
+	n, err := c.g.Next()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.t.Use(c.k, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
@@ -0,0 +1,71 @@
+package nonce
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+	k1, k2 := key.Bit128(), key.Bit128()
+	n := make([]byte, Size)
+
+	if err := tr.Use(k1, n); err != nil {
+		t.Fatalf("Error using nonce: %s", err)
+	}
+	if err := tr.Use(k1, n); err != ErrReuse {
+		t.Errorf("Expected %v, got %v", ErrReuse, err)
+	}
+
+	// the same nonce under another key is fine
+	if err := tr.Use(k2, n); err != nil {
+		t.Errorf("Expected %v, got %v", nil, err)
+	}
+
+	// a copy of the key is the same key
+	copied := key.NewKey([16]byte(k1.GetBytes()))
+	if err := tr.Use(copied, n); err != ErrReuse {
+		t.Errorf("Expected %v, got %v", ErrReuse, err)
+	}
+
+	if seen, err := tr.Seen(k1); seen != 1 || err != nil {
+		t.Errorf("Expected %v, got %v (%v)", 1, seen, err)
+	}
+	if err := tr.Forget(k1); err != nil {
+		t.Errorf("Expected %v, got %v", nil, err)
+	}
+	if err := tr.Use(k1, n); err != nil {
+		t.Errorf("Expected %v, got %v", nil, err)
+	}
+
+	// destroyed keys return an error instead of panicking on the fingerprint
+	k1.Destroy()
+	if err := tr.Use(k1, n); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+	if _, err := tr.Seen(k1); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+	if err := tr.Forget(k1); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
+
+func TestChecked(t *testing.T) {
+	tr := NewTracker()
+	k := key.Bit128()
+
+	// a broken source that returns the same bytes every time
+	repeated := bytes.Repeat([]byte{1}, 2*Size)
+	g, _ := NewRandom(Size, WithRandReader(bytes.NewReader(repeated)))
+	checked := tr.Checked(k, g)
+
+	if _, err := checked.Next(); err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+	if _, err := checked.Next(); err != ErrReuse {
+		t.Errorf("Expected %v, got %v", ErrReuse, err)
+	}
+}
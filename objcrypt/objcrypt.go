@@ -0,0 +1,230 @@
+// Package objcrypt encrypts objects on the client before they are uploaded to an object store,
+// in the style of the S3 encryption client. Every object gets a fresh data key, the data key is
+// wrapped with a key encryption key (see the envelope package), and everything needed to decrypt
+// goes in the object metadata next to the encrypted body:
+//
+//	aesgo-key           base64 of the wrapped data key
+//	aesgo-kek-id        which key encryption key wrapped it
+//	aesgo-iv            base64 of the IV or nonce
+//	aesgo-cek-alg       AES/GCM/NoPadding, AES/CBC/PKCS5Padding or AES/CTR/NoPadding
+//	aesgo-tag-len       128 with GCM, the tag is at the end of the body
+//	aesgo-unencrypted-content-length
+//
+// Over HTTP the metadata travels in x-amz-meta- headers, see Header and FromResponse. Rotating the
+// key encryption key only means rewriting the metadata, the bodies stay as they are.
+//
+// The metadata isn't authenticated. Decrypt only accepts the mode it is told to expect, otherwise
+// an attacker could relabel a GCM object as CTR and change its body without being noticed.
+package objcrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/envelope"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	MetaKey           = "aesgo-key"
+	MetaKEKID         = "aesgo-kek-id"
+	MetaIV            = "aesgo-iv"
+	MetaAlgorithm     = "aesgo-cek-alg"
+	MetaTagLength     = "aesgo-tag-len"
+	MetaContentLength = "aesgo-unencrypted-content-length"
+)
+
+// MetaPrefix is how S3 passes user metadata in HTTP headers.
+const MetaPrefix = "X-Amz-Meta-"
+
+var (
+	ErrMissingMetadata = errors.New("Object metadata is missing or invalid")
+	ErrModeMismatch    = errors.New("Object was encrypted with another mode than the expected one")
+)
+
+var algorithms = map[aesgo.Mode]string{
+	aesgo.GCM: "AES/GCM/NoPadding",
+	aesgo.CBC: "AES/CBC/PKCS5Padding",
+	aesgo.CTR: "AES/CTR/NoPadding",
+}
+
+// Object is what gets uploaded: the encrypted body and its metadata.
+type Object struct {
+	Body     []byte
+	Metadata map[string]string
+}
+
+type Option func(*options)
+
+type options struct {
+	mode aesgo.Mode
+	rand io.Reader
+}
+
+// WithMode sets the mode Encrypt uses and Decrypt expects. Defaults to GCM, the only one that
+// detects a changed body.
+func WithMode(mode aesgo.Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithRandReader sets where the data key and the IV come from. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+func newOptions(opts []Option) (options, error) {
+	o := options{mode: aesgo.GCM, rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if _, ok := algorithms[o.mode]; !ok {
+		return o, aesgo.ErrInvalidMode
+	}
+	return o, nil
+}
+
+// Encrypt encrypts plaintext with a new data key wrapped by kek.
+func Encrypt(kek key.Key, kekID string, plaintext []byte, opts ...Option) (*Object, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := key.Random(16, key.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+	defer dek.Destroy()
+
+	wrapped, err := envelope.Wrap(kek, dek.GetBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := aesgo.NewCipher(dek, aesgo.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+	c, err := a.EncryptCiphertext(o.mode, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := map[string]string{
+		MetaKey:           base64.StdEncoding.EncodeToString(wrapped),
+		MetaKEKID:         kekID,
+		MetaIV:            base64.StdEncoding.EncodeToString(c.IV),
+		MetaAlgorithm:     algorithms[o.mode],
+		MetaContentLength: strconv.Itoa(len(plaintext)),
+	}
+	if o.mode == aesgo.GCM {
+		meta[MetaTagLength] = strconv.Itoa(aesgo.GCMTagSize * 8)
+	}
+
+	return &Object{Body: append(c.Body, c.Tag...), Metadata: meta}, nil
+}
+
+// Decrypt unwraps the data key of obj with kek and decrypts the body. The KEK ID is in
+// obj.Metadata[MetaKEKID] to pick kek.
+func Decrypt(kek key.Key, obj *Object, opts ...Option) ([]byte, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.Metadata[MetaAlgorithm] != algorithms[o.mode] {
+		return nil, fmt.Errorf("%w: expected %s, got %q", ErrModeMismatch, algorithms[o.mode], obj.Metadata[MetaAlgorithm])
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(obj.Metadata[MetaKey])
+	if err != nil || len(wrapped) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingMetadata, MetaKey)
+	}
+	iv, err := base64.StdEncoding.DecodeString(obj.Metadata[MetaIV])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingMetadata, MetaIV)
+	}
+
+	material, err := envelope.Unwrap(kek, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(material) != 16 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingMetadata, MetaKey)
+	}
+	dek := key.NewKey([16]byte(material))
+	defer dek.Destroy()
+	clear(material)
+
+	c := &aesgo.Ciphertext{Version: aesgo.CiphertextVersion, Mode: o.mode, IV: iv, Body: obj.Body}
+	if o.mode == aesgo.GCM {
+		if len(obj.Body) < aesgo.GCMTagSize {
+			return nil, aesgo.ErrTruncatedCiphertext
+		}
+		c.Body = obj.Body[:len(obj.Body)-aesgo.GCMTagSize]
+		c.Tag = obj.Body[len(obj.Body)-aesgo.GCMTagSize:]
+	}
+
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	return a.DecryptCiphertext(c)
+}
+
+// Header returns the metadata as x-amz-meta- headers, for a PUT to a presigned URL.
+func (obj *Object) Header() http.Header {
+	h := make(http.Header)
+	for k, v := range obj.Metadata {
+		h.Set(MetaPrefix+k, v)
+	}
+	return h
+}
+
+// NewPutRequest returns a PUT of obj to url with its metadata headers.
+func (obj *Object) NewPutRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(obj.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range obj.Header() {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return req, nil
+}
+
+// FromResponse reads the body and the metadata headers of a GET, ready for Decrypt.
+func FromResponse(resp *http.Response) (*Object, error) {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := make(map[string]string)
+	for k := range resp.Header {
+		if name, ok := strings.CutPrefix(k, MetaPrefix); ok {
+			meta[strings.ToLower(name)] = resp.Header.Get(k)
+		}
+	}
+
+	return &Object{Body: body, Metadata: meta}, nil
+}
@@ -0,0 +1,142 @@
+package objcrypt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	kek := key.Bit128()
+	plaintext := []byte("quarterly-report.pdf contents")
+
+	for _, mode := range []aesgo.Mode{aesgo.GCM, aesgo.CBC, aesgo.CTR} {
+		obj, err := Encrypt(kek, "kek-1", plaintext, WithMode(mode))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		if obj.Metadata[MetaKEKID] != "kek-1" || obj.Metadata[MetaContentLength] != "29" {
+			t.Errorf("Mode %d. Unexpected metadata %v", mode, obj.Metadata)
+		}
+
+		decrypted, err := Decrypt(kek, obj, WithMode(mode))
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	kek := key.Bit128()
+	obj, _ := Encrypt(kek, "kek-1", []byte("payload"))
+
+	// relabelled as CTR, which wouldn't notice a changed body
+	relabelled := &Object{Body: obj.Body, Metadata: map[string]string{}}
+	for k, v := range obj.Metadata {
+		relabelled.Metadata[k] = v
+	}
+	relabelled.Metadata[MetaAlgorithm] = algorithms[aesgo.CTR]
+	if _, err := Decrypt(kek, relabelled); !errors.Is(err, ErrModeMismatch) {
+		t.Errorf("Expected %v, got %v", ErrModeMismatch, err)
+	}
+
+	tampered := &Object{Body: append([]byte{}, obj.Body...), Metadata: obj.Metadata}
+	tampered.Body[0] ^= 1
+	if _, err := Decrypt(kek, tampered); err != aesgo.ErrAuthentication {
+		t.Errorf("Expected %v, got %v", aesgo.ErrAuthentication, err)
+	}
+
+	if _, err := Decrypt(key.Bit128(), obj); err == nil {
+		t.Errorf("Expected error with another KEK, got nil")
+	}
+
+	missing := &Object{Body: obj.Body, Metadata: map[string]string{MetaAlgorithm: obj.Metadata[MetaAlgorithm]}}
+	if _, err := Decrypt(kek, missing); !errors.Is(err, ErrMissingMetadata) {
+		t.Errorf("Expected %v, got %v", ErrMissingMetadata, err)
+	}
+}
+
+// bucket keeps objects and their x-amz-meta- headers, like S3 does.
+type bucket struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+}
+
+func (b *bucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		b.objects[r.URL.Path] = body
+		b.headers[r.URL.Path] = make(http.Header)
+		for k, v := range r.Header {
+			if strings.HasPrefix(k, MetaPrefix) {
+				b.headers[r.URL.Path][k] = v
+			}
+		}
+	case http.MethodGet:
+		body, ok := b.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		for k, v := range b.headers[r.URL.Path] {
+			w.Header()[k] = v
+		}
+		w.Write(body)
+	}
+}
+
+func TestUploadDownload(t *testing.T) {
+	server := httptest.NewServer(&bucket{objects: make(map[string][]byte), headers: make(map[string]http.Header)})
+	defer server.Close()
+
+	kek := key.Bit128()
+	obj, _ := Encrypt(kek, "kek-1", []byte("uploaded"))
+
+	req, err := obj.NewPutRequest(server.URL + "/reports/q1")
+	if err != nil {
+		t.Fatalf("Error creating request: %s", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Error uploading: %s", err)
+	}
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/reports/q1")
+	if err != nil {
+		t.Fatalf("Error downloading: %s", err)
+	}
+	downloaded, err := FromResponse(resp)
+	if err != nil {
+		t.Fatalf("Error reading response: %s", err)
+	}
+
+	decrypted, err := Decrypt(kek, downloaded)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(decrypted) != "uploaded" {
+		t.Errorf("Got: %s, Expected: %s", decrypted, "uploaded")
+	}
+
+	resp, _ = http.Get(server.URL + "/missing")
+	if _, err := FromResponse(resp); err == nil {
+		t.Errorf("Expected error for a missing object, got nil")
+	}
+}
@@ -0,0 +1,136 @@
+// Package openssl reads and writes the format used by "openssl enc -aes-128-cbc", so files can be
+// exchanged with OpenSSL:
+//
+//	"Salted__" | salt (8 bytes) | AES-128-CBC ciphertext with PKCS7 padding
+//
+// The key and IV are derived from the passphrase and salt, either with EVP_BytesToKey (the
+// default, weak and only here for compatibility) or with PBKDF2 when -pbkdf2 is used.
+package openssl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	saltHeader = "Salted__"
+	saltSize   = 8
+
+	// DefaultIterations is what "openssl enc -pbkdf2" uses when -iter isn't given
+	DefaultIterations = 10000
+)
+
+var ErrInvalidFormat = errors.New("Invalid OpenSSL format, missing Salted__ header")
+
+type Digest int
+
+const (
+	// SHA256 is the default digest since OpenSSL 1.1.0
+	SHA256 Digest = iota
+	// MD5 was the default before OpenSSL 1.1.0, use "-md md5" on the openssl side
+	MD5
+)
+
+type Options struct {
+	// PBKDF2 matches "openssl enc -pbkdf2". PBKDF2 always uses SHA256 here, as OpenSSL does by default.
+	PBKDF2 bool
+	// Iterations for PBKDF2, defaults to DefaultIterations.
+	Iterations int
+	// Digest used by EVP_BytesToKey when PBKDF2 is false.
+	Digest Digest
+}
+
+// EVPBytesToKey is OpenSSL's legacy key derivation with a single iteration:
+// D_i = HASH(D_(i-1) || password || salt), concatenated until there are enough bytes for key and IV.
+func EVPBytesToKey(newHash func() hash.Hash, password, salt []byte, keyLen, ivLen int) ([]byte, []byte) {
+	var derived, prev []byte
+
+	for len(derived) < keyLen+ivLen {
+		h := newHash()
+		h.Write(prev)
+		h.Write(password)
+		h.Write(salt)
+		prev = h.Sum(nil)
+		derived = append(derived, prev...)
+	}
+
+	return derived[:keyLen], derived[keyLen : keyLen+ivLen]
+}
+
+func deriveKeyAndIV(passphrase, salt []byte, opts Options) (key.Key, []byte) {
+	var k, iv []byte
+
+	if opts.PBKDF2 {
+		iterations := opts.Iterations
+		if iterations == 0 {
+			iterations = DefaultIterations
+		}
+		derived := key.PBKDF2(passphrase, salt, iterations, 32)
+		k, iv = derived[:16], derived[16:]
+	} else {
+		newHash := sha256.New
+		if opts.Digest == MD5 {
+			newHash = md5.New
+		}
+		k, iv = EVPBytesToKey(newHash, passphrase, salt, 16, 16)
+	}
+
+	return key.NewKey([16]byte(k)), iv
+}
+
+// Encrypt produces the same output as "openssl enc -aes-128-cbc" (without -a).
+func Encrypt(passphrase, plaintext []byte, opts Options) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	return encrypt(passphrase, salt, plaintext, opts)
+}
+
+func encrypt(passphrase, salt, plaintext []byte, opts Options) ([]byte, error) {
+	k, iv := deriveKeyAndIV(passphrase, salt, opts)
+	defer k.Destroy()
+
+	// OpenSSL doesn't write the IV, it is derived. So it is "generated" from the derived bytes and then removed.
+	a, err := aesgo.NewCipher(k, aesgo.WithRandReader(bytes.NewReader(iv)))
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := a.Encrypt(aesgo.CBC, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	r := append([]byte(saltHeader), salt...)
+	return append(r, encrypted[16:]...), nil
+}
+
+// Decrypt reads the output of "openssl enc -aes-128-cbc" (without -a).
+func Decrypt(passphrase, data []byte, opts Options) ([]byte, error) {
+	if len(data) < len(saltHeader)+saltSize || string(data[:len(saltHeader)]) != saltHeader {
+		return nil, ErrInvalidFormat
+	}
+
+	salt := data[len(saltHeader) : len(saltHeader)+saltSize]
+	encrypted := data[len(saltHeader)+saltSize:]
+
+	k, iv := deriveKeyAndIV(passphrase, salt, opts)
+	defer k.Destroy()
+
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Decrypt(aesgo.CBC, append(iv, encrypted...))
+}
@@ -0,0 +1,115 @@
+package openssl
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	plaintext := []byte("Let's test if this is working!")
+
+	for _, opts := range []Options{{}, {Digest: MD5}, {PBKDF2: true}, {PBKDF2: true, Iterations: 1000}} {
+		encrypted, err := Encrypt([]byte("secret"), plaintext, opts)
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+
+		if !bytes.HasPrefix(encrypted, []byte("Salted__")) {
+			t.Errorf("Missing Salted__ header: %02x", encrypted[:16])
+		}
+
+		decrypted, err := Decrypt([]byte("secret"), encrypted, opts)
+		if err != nil {
+			t.Fatalf("Error decrypting: %s", err)
+		}
+
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+		}
+	}
+
+	if _, err := Decrypt([]byte("secret"), []byte("not salted"), Options{}); err != ErrInvalidFormat {
+		t.Errorf("Expected %v, got %v", ErrInvalidFormat, err)
+	}
+}
+
+// TestOpenSSL checks both directions against the openssl binary, when it is installed.
+func TestOpenSSL(t *testing.T) {
+	bin, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not found")
+	}
+
+	plaintext := []byte("The quick brown fox jumps over the lazy dog 1234")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+
+		opts Options
+		args []string
+	}{
+		{
+			name: "EVP_BytesToKey with sha256",
+
+			args: []string{"-md", "sha256"},
+		},
+		{
+			name: "EVP_BytesToKey with md5",
+
+			opts: Options{Digest: MD5},
+			args: []string{"-md", "md5"},
+		},
+		{
+			name: "pbkdf2",
+
+			opts: Options{PBKDF2: true},
+			args: []string{"-pbkdf2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := filepath.Join(dir, "in")
+			out := filepath.Join(dir, "out")
+
+			// aesgo -> openssl
+			encrypted, err := Encrypt([]byte("secret"), plaintext, test.opts)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			os.WriteFile(in, encrypted, 0600)
+
+			args := append([]string{"enc", "-d", "-aes-128-cbc", "-pass", "pass:secret", "-in", in, "-out", out}, test.args...)
+			if output, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+				t.Fatalf("openssl failed to decrypt: %s %s", err, output)
+			}
+
+			decrypted, _ := os.ReadFile(out)
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("openssl got: %s, Expected: %s", decrypted, plaintext)
+			}
+
+			// openssl -> aesgo
+			os.WriteFile(in, plaintext, 0600)
+
+			args = append([]string{"enc", "-aes-128-cbc", "-pass", "pass:secret", "-in", in, "-out", out}, test.args...)
+			if output, err := exec.Command(bin, args...).CombinedOutput(); err != nil {
+				t.Fatalf("openssl failed to encrypt: %s %s", err, output)
+			}
+
+			encrypted, _ = os.ReadFile(out)
+			decrypted, err = Decrypt([]byte("secret"), encrypted, test.opts)
+			if err != nil {
+				t.Fatalf("Error decrypting: %s", err)
+			}
+
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Got: %s, Expected: %s", decrypted, plaintext)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package paseto implements v3.local PASETO tokens (https://github.com/paseto-standard/paseto-spec).
+//
+// v3.local is AES-256-CTR for the message and HMAC-SHA384 for authentication. Both keys are derived
+// with HKDF-SHA384 from the 32 byte key and a random 32 byte nonce, so every token uses new keys:
+//
+//	Ek || n2 = HKDF-SHA384(key, info = "paseto-encryption-key" || n)   (32 + 16 bytes)
+//	Ak       = HKDF-SHA384(key, info = "paseto-auth-key-for-aead" || n)
+//	c        = AES-256-CTR(Ek, counter = n2, message)
+//	t        = HMAC-SHA384(Ak, PAE("v3.local.", n, c, footer, implicit))
+//
+// The token is "v3.local." + base64url(n || c || t), plus "." + base64url(footer) when there is a footer.
+// The implicit assertion is authenticated but not part of the token, both sides must know it.
+package paseto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const (
+	KeySize = 32
+
+	header    = "v3.local."
+	nonceSize = 32
+	tagSize   = sha512.Size384
+)
+
+var (
+	ErrInvalidKey   = errors.New("v3.local needs a 32 byte key")
+	ErrInvalidToken = errors.New("Invalid v3.local token")
+)
+
+// Encrypt returns a v3.local token. footer and implicit are optional.
+func Encrypt(k key.Key, message, footer, implicit []byte) (string, error) {
+	n := make([]byte, nonceSize)
+	if _, err := io.ReadFull(rand.Reader, n); err != nil {
+		return "", err
+	}
+	return encrypt(k, message, footer, implicit, n)
+}
+
+func encrypt(k key.Key, message, footer, implicit, n []byte) (string, error) {
+	ek, n2, ak, err := deriveKeys(k, n)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := ctr(ek, n2, message)
+	if err != nil {
+		return "", err
+	}
+
+	t := tag(ak, n, c, footer, implicit)
+
+	body := append(append(append([]byte{}, n...), c...), t...)
+	token := header + base64.RawURLEncoding.EncodeToString(body)
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+	return token, nil
+}
+
+// Decrypt verifies the token and returns the message and the footer.
+func Decrypt(k key.Key, token string, implicit []byte) ([]byte, []byte, error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(token[len(header):], ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < nonceSize+tagSize {
+		return nil, nil, ErrInvalidToken
+	}
+
+	var footer []byte
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	n := body[:nonceSize]
+	c := body[nonceSize : len(body)-tagSize]
+	t := body[len(body)-tagSize:]
+
+	ek, n2, ak, err := deriveKeys(k, n)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !hmac.Equal(t, tag(ak, n, c, footer, implicit)) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	message, err := ctr(ek, n2, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	return message, footer, nil
+}
+
+// deriveKeys returns the encryption key, the CTR nonce and the authentication key.
+func deriveKeys(k key.Key, n []byte) ([]byte, []byte, []byte, error) {
+	if k.Destroyed() {
+		return nil, nil, nil, key.ErrDestroyed
+	}
+	if k.Len() != KeySize {
+		return nil, nil, nil, ErrInvalidKey
+	}
+
+	tmp, err := key.HKDF(sha512.New384, k.GetBytes(), nil, append([]byte("paseto-encryption-key"), n...), 48)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ak, err := key.HKDF(sha512.New384, k.GetBytes(), nil, append([]byte("paseto-auth-key-for-aead"), n...), 48)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return tmp[:32], tmp[32:], ak, nil
+}
+
+// ctr is AES-256-CTR starting at the counter n2. aesgo takes the nonce from its random reader.
+func ctr(ek, n2, in []byte) ([]byte, error) {
+	a, err := aesgo.NewCipher(key.NewKey256([32]byte(ek)), aesgo.WithRandReader(bytes.NewReader(n2)))
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := a.Encrypt(aesgo.CTR, in)
+	if err != nil {
+		return nil, err
+	}
+	// aesgo puts the nonce in front
+	return out[len(n2):], nil
+}
+
+func tag(ak, n, c, footer, implicit []byte) []byte {
+	mac := hmac.New(sha512.New384, ak)
+	mac.Write(pae([]byte(header), n, c, footer, implicit))
+	return mac.Sum(nil)
+}
+
+// pae is the pre-authentication encoding: the number of pieces, then the length and the content of each
+// one, lengths as 64 bit little endian with the top bit cleared. It stops pieces from being shifted
+// between each other.
+func pae(pieces ...[]byte) []byte {
+	le64 := func(n int) []byte {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(n)&(1<<63-1))
+		return b[:]
+	}
+
+	r := le64(len(pieces))
+	for _, p := range pieces {
+		r = append(r, le64(len(p))...)
+		r = append(r, p...)
+	}
+	return r
+}
@@ -0,0 +1,242 @@
+package paseto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestPAE(t *testing.T) {
+	tests := []struct {
+		name string
+
+		pieces [][]byte
+
+		expected []byte
+	}{
+		{
+			name: "no pieces",
+
+			pieces: [][]byte{},
+
+			expected: []byte{0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "one empty piece",
+
+			pieces: [][]byte{{}},
+
+			expected: []byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "test",
+
+			pieces: [][]byte{[]byte("test")},
+
+			expected: append([]byte{1, 0, 0, 0, 0, 0, 0, 0, 4, 0, 0, 0, 0, 0, 0, 0}, "test"...),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := pae(test.pieces...); !bytes.Equal(got, test.expected) {
+				t.Errorf("Got: %x, Expected: %x", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	k := key.Bit256()
+
+	tests := []struct {
+		name string
+
+		message  []byte
+		footer   []byte
+		implicit []byte
+	}{
+		{
+			name: "message only",
+
+			message: []byte("Let's test if this is working!"),
+		},
+		{
+			name: "empty message",
+
+			message: []byte{},
+		},
+		{
+			name: "footer and implicit assertion",
+
+			message:  []byte(`{"data":"this is a secret message"}`),
+			footer:   []byte(`{"kid":"1"}`),
+			implicit: []byte("user 42"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, err := Encrypt(k, test.message, test.footer, test.implicit)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			if !strings.HasPrefix(token, "v3.local.") {
+				t.Errorf("Token without header: %s", token)
+			}
+
+			message, footer, err := Decrypt(k, token, test.implicit)
+			if err != nil {
+				t.Fatalf("Error decrypting: %s", err)
+			}
+			if !bytes.Equal(message, test.message) {
+				t.Errorf("Got: %s, Expected: %s", message, test.message)
+			}
+			if !bytes.Equal(footer, test.footer) {
+				t.Errorf("Got: %s, Expected: %s", footer, test.footer)
+			}
+		})
+	}
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	k := key.Bit256()
+	implicit := []byte("implicit")
+
+	token, err := Encrypt(k, []byte("hello"), []byte("footer"), implicit)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	parts := strings.Split(token, ".")
+	body, _ := base64.RawURLEncoding.DecodeString(parts[2])
+
+	withBody := func(change func(b []byte) []byte) string {
+		b := change(append([]byte{}, body...))
+		return "v3.local." + base64.RawURLEncoding.EncodeToString(b) + "." + parts[3]
+	}
+
+	tests := []struct {
+		name string
+
+		key      key.Key
+		token    string
+		implicit []byte
+
+		expected error
+	}{
+		{
+			name: "wrong key",
+
+			key:      key.Bit256(),
+			token:    token,
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "wrong implicit assertion",
+
+			key:      k,
+			token:    token,
+			implicit: []byte("other"),
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "tampered ciphertext",
+
+			key:      k,
+			token:    withBody(func(b []byte) []byte { b[nonceSize] ^= 1; return b }),
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "tampered nonce",
+
+			key:      k,
+			token:    withBody(func(b []byte) []byte { b[0] ^= 1; return b }),
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "tampered footer",
+
+			key:      k,
+			token:    strings.Join(parts[:3], ".") + "." + base64.RawURLEncoding.EncodeToString([]byte("other")),
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "too short",
+
+			key:      k,
+			token:    withBody(func(b []byte) []byte { return b[:nonceSize+tagSize-1] }),
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "wrong header",
+
+			key:      k,
+			token:    "v4.local." + strings.Join(parts[2:], "."),
+			implicit: implicit,
+
+			expected: ErrInvalidToken,
+		},
+		{
+			name: "128 bit key",
+
+			key:      key.Bit128(),
+			token:    token,
+			implicit: implicit,
+
+			expected: ErrInvalidKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := Decrypt(test.key, test.token, test.implicit); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+// The ciphertext must be the same as AES-256-CTR from the standard library with the derived keys.
+func TestCTRStd(t *testing.T) {
+	k := key.Bit256()
+	n := bytes.Repeat([]byte{0x42}, nonceSize)
+	message := []byte("The quick brown fox jumps over the lazy dog, twice or more")
+
+	token, err := encrypt(k, message, nil, nil, n)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	body, _ := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, "v3.local."))
+	c := body[nonceSize : len(body)-tagSize]
+
+	tmp, _ := key.HKDF(sha512.New384, k.GetBytes(), nil, append([]byte("paseto-encryption-key"), n...), 48)
+	block, err := aes.NewCipher(tmp[:32])
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+	expected := make([]byte, len(message))
+	cipher.NewCTR(block, tmp[32:]).XORKeyStream(expected, message)
+
+	if !bytes.Equal(c, expected) {
+		t.Errorf("Got: %x, Expected: %x", c, expected)
+	}
+}
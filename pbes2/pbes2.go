@@ -0,0 +1,264 @@
+// Package pbes2 reads and writes password encrypted blobs in the PBES2 format of PKCS#5 (RFC 8018),
+// the EncryptedPrivateKeyInfo of PKCS#8:
+//
+//	SEQUENCE {
+//	  SEQUENCE { id-PBES2, SEQUENCE {
+//	    SEQUENCE { id-PBKDF2, SEQUENCE { salt, iterations, [key length], [prf] } },
+//	    SEQUENCE { aes128-CBC or aes256-CBC, iv } } }
+//	  OCTET STRING ciphertext }
+//
+// It is what "openssl pkcs8 -topk8 -v2 aes-128-cbc" writes, so encrypted private keys can be exchanged
+// with OpenSSL. Blobs are written with HMAC-SHA256 as the PBKDF2 PRF, HMAC-SHA1 is accepted when reading.
+package pbes2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"hash"
+	"io"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// PEMType is the PEM block type used by OpenSSL for encrypted private keys.
+const PEMType = "ENCRYPTED PRIVATE KEY"
+
+const (
+	DefaultIterations = key.DefaultPBKDF2Iterations
+
+	saltSize = 16
+	// a blob with a huge iteration count would keep Decrypt busy forever
+	maxIterations = 10_000_000
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var (
+	ErrInvalidFormat  = errors.New("Invalid PBES2 structure")
+	ErrUnsupported    = errors.New("Unsupported PBES2 algorithm, only PBKDF2 with AES-128-CBC or AES-256-CBC")
+	ErrInvalidKeySize = errors.New("Key size must be 16 or 32 bytes")
+	ErrDecryption     = errors.New("Decryption failed, wrong password or corrupted data")
+)
+
+type Options struct {
+	// Iterations for PBKDF2, defaults to DefaultIterations.
+	Iterations int
+	// KeySize is 16 (AES-128-CBC, the default) or 32 (AES-256-CBC).
+	KeySize int
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// Encrypt returns the DER encoded EncryptedPrivateKeyInfo of plaintext.
+func Encrypt(password, plaintext []byte, opts Options) ([]byte, error) {
+	if opts.Iterations == 0 {
+		opts.Iterations = DefaultIterations
+	}
+	if opts.KeySize == 0 {
+		opts.KeySize = 16
+	}
+
+	cipherOID, err := cipherFor(opts.KeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	return encrypt(password, salt, iv, plaintext, opts, cipherOID)
+}
+
+func encrypt(password, salt, iv, plaintext []byte, opts Options, cipherOID asn1.ObjectIdentifier) ([]byte, error) {
+	k := newKey(key.PBKDF2(password, salt, opts.Iterations, opts.KeySize))
+	defer k.Destroy()
+
+	a, err := aesgo.NewCipher(k, aesgo.WithRandReader(bytes.NewReader(iv)))
+	if err != nil {
+		return nil, err
+	}
+
+	// IV | ciphertext, the IV goes into the params
+	encrypted, err := a.Encrypt(aesgo.CBC, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	kdf, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: opts.Iterations,
+		PRF:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdf}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: cipherOID, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted[16:],
+	})
+}
+
+// Decrypt reads a DER encoded EncryptedPrivateKeyInfo and returns the plaintext.
+func Decrypt(password, der []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if err := unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrUnsupported
+	}
+
+	var params pbes2Params
+	if err := unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	var keySize int
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		keySize = 16
+	case scheme.Equal(oidAES256CBC):
+		keySize = 32
+	default:
+		return nil, ErrUnsupported
+	}
+
+	var iv []byte
+	if err := unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != 16 {
+		return nil, ErrInvalidFormat
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, ErrUnsupported
+	}
+
+	var kdf pbkdf2Params
+	if err := unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, err
+	}
+	if kdf.Iterations < 1 || kdf.Iterations > maxIterations || (kdf.KeyLength != 0 && kdf.KeyLength != keySize) {
+		return nil, ErrInvalidFormat
+	}
+
+	var h func() hash.Hash
+	switch prf := kdf.PRF.Algorithm; {
+	// HMAC-SHA1 is the default when the PRF is missing
+	case len(prf) == 0 || prf.Equal(oidHMACWithSHA1):
+		h = sha1.New
+	case prf.Equal(oidHMACWithSHA256):
+		h = sha256.New
+	default:
+		return nil, ErrUnsupported
+	}
+
+	k := newKey(key.PBKDF2Hash(h, password, kdf.Salt, kdf.Iterations, keySize))
+	defer k.Destroy()
+
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := a.Decrypt(aesgo.CBC, append(iv, info.EncryptedData...))
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	return plaintext, nil
+}
+
+// EncryptPEM is Encrypt with the output in an "ENCRYPTED PRIVATE KEY" PEM block.
+func EncryptPEM(password, plaintext []byte, opts Options) ([]byte, error) {
+	der, err := Encrypt(password, plaintext, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMType, Bytes: der}), nil
+}
+
+// DecryptPEM reads the first "ENCRYPTED PRIVATE KEY" PEM block.
+func DecryptPEM(password, b []byte) ([]byte, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != PEMType {
+		return nil, ErrInvalidFormat
+	}
+	return Decrypt(password, block.Bytes)
+}
+
+func cipherFor(keySize int) (asn1.ObjectIdentifier, error) {
+	switch keySize {
+	case 16:
+		return oidAES128CBC, nil
+	case 32:
+		return oidAES256CBC, nil
+	}
+	return nil, ErrInvalidKeySize
+}
+
+func newKey(b []byte) key.Key {
+	defer clear(b)
+	if len(b) == 32 {
+		return key.NewKey256([32]byte(b))
+	}
+	return key.NewKey([16]byte(b))
+}
+
+// unmarshal fails on trailing data too, asn1.Unmarshal only returns it.
+func unmarshal(b []byte, v any) error {
+	rest, err := asn1.Unmarshal(b, v)
+	if err != nil || len(rest) > 0 {
+		return ErrInvalidFormat
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin)
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// the syscall package only has Mlock on Linux and macOS
+func probeMlock() Status {
+	return Status{Capability: Mlock, Reason: fmt.Sprintf("not supported on %s, memory may be swapped", runtime.GOOS)}
+}
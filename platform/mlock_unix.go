@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package platform
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func probeMlock() Status {
+	b := make([]byte, syscall.Getpagesize())
+	if err := syscall.Mlock(b); err != nil {
+		// usually RLIMIT_MEMLOCK is too low or the process lacks permission
+		return Status{Capability: Mlock, Reason: fmt.Sprintf("mlock failed: %s, memory may be swapped", err)}
+	}
+	syscall.Munlock(b)
+
+	return Status{Capability: Mlock, Available: true}
+}
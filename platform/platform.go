@@ -0,0 +1,104 @@
+// Package platform reports which optional features are available on the current machine.
+// Everything in the library works without them, this package only tells callers
+// (and users of the CLI, see aesgo platform) which path is going to be used and why. The aes-go
+// and key packages record through Fallback when they actually do without one.
+package platform
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+type Capability string
+
+const (
+	// Assembly backends for the block cipher. There are none, AES here is always pure Go.
+	Assembly Capability = "assembly"
+	// Mmap allows files to be memory mapped instead of copied through buffers.
+	Mmap Capability = "mmap"
+	// Mlock allows memory holding keys to be locked so it is never swapped to disk.
+	Mlock Capability = "mlock"
+)
+
+// Status describes a capability. When it's not available, Reason explains why and what is used instead.
+type Status struct {
+	Capability Capability
+	Available  bool
+	Reason     string
+}
+
+func (s Status) String() string {
+	if s.Available {
+		return fmt.Sprintf("%s: available", s.Capability)
+	}
+	return fmt.Sprintf("%s: unavailable (%s)", s.Capability, s.Reason)
+}
+
+var (
+	once     sync.Once
+	statuses []Status
+)
+
+// Detect probes the platform once and returns the status of every capability.
+func Detect() []Status {
+	once.Do(func() {
+		statuses = []Status{
+			{
+				Capability: Assembly,
+				Reason:     fmt.Sprintf("no assembly implementation for %s/%s, falling back to pure Go", runtime.GOOS, runtime.GOARCH),
+			},
+			probeMmap(),
+			probeMlock(),
+		}
+	})
+
+	r := make([]Status, len(statuses))
+	copy(r, statuses)
+	return r
+}
+
+// Lookup returns the status of a single capability.
+func Lookup(c Capability) Status {
+	for _, s := range Detect() {
+		if s.Capability == c {
+			return s
+		}
+	}
+	return Status{Capability: c, Reason: "unknown capability"}
+}
+
+// Available is a shortcut for Lookup(c).Available.
+func Available(c Capability) bool {
+	return Lookup(c).Available
+}
+
+var (
+	mu        sync.Mutex
+	fallbacks []Status
+)
+
+// Fallback records that the library needed c and couldn't use it, reason says why and what it did
+// instead. It is called where that happens, the same reason is only recorded once.
+func Fallback(c Capability, reason string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, f := range fallbacks {
+		if f.Capability == c && f.Reason == reason {
+			return
+		}
+	}
+	fallbacks = append(fallbacks, Status{Capability: c, Reason: reason})
+}
+
+// Fallbacks returns what Fallback recorded so far in this process, oldest first. Detect tells
+// what should work, Fallbacks what actually didn't.
+func Fallbacks() []Status {
+	mu.Lock()
+	defer mu.Unlock()
+
+	r := make([]Status, len(fallbacks))
+	copy(r, fallbacks)
+	return r
+}
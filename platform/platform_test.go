@@ -0,0 +1,46 @@
+package platform
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	statuses := Detect()
+
+	for _, c := range []Capability{Assembly, Mmap, Mlock} {
+		s := Lookup(c)
+		if s.Capability != c {
+			t.Errorf("Expected %s, got %s", c, s.Capability)
+		}
+
+		if !s.Available && s.Reason == "" {
+			t.Errorf("Unavailable capability %s must have a reason", c)
+		}
+	}
+
+	if len(statuses) != 3 {
+		t.Errorf("Expected 3 capabilities, got %d", len(statuses))
+	}
+
+	if Available(Assembly) {
+		t.Errorf("There is no assembly backend, it should never be available")
+	}
+
+	if s := Lookup("gpu"); s.Available || s.Reason == "" {
+		t.Errorf("Unknown capabilities must be unavailable with a reason, got %v", s)
+	}
+}
+
+func TestFallbacks(t *testing.T) {
+	before := len(Fallbacks())
+
+	Fallback(Mmap, "test reason")
+	Fallback(Mmap, "test reason")
+	Fallback(Mlock, "test reason")
+
+	f := Fallbacks()
+	if len(f) != before+2 {
+		t.Fatalf("Expected %d fallbacks, got %d", before+2, len(f))
+	}
+	if s := f[len(f)-2]; s.Capability != Mmap || s.Available || s.Reason != "test reason" {
+		t.Errorf("Unexpected fallback %v", s)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func probeMmap() Status {
+	return Status{Capability: Mmap, Reason: fmt.Sprintf("not supported on %s, falling back to buffered IO", runtime.GOOS)}
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+
+package platform
+
+import (
+	"fmt"
+	"syscall"
+)
+
+func probeMmap() Status {
+	b, err := syscall.Mmap(-1, 0, syscall.Getpagesize(), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return Status{Capability: Mmap, Reason: fmt.Sprintf("mmap failed: %s, falling back to buffered IO", err)}
+	}
+	syscall.Munmap(b)
+
+	return Status{Capability: Mmap, Available: true}
+}
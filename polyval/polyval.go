@@ -0,0 +1,87 @@
+// Package polyval is the universal hash of AES-GCM-SIV, defined in RFC 8452 section 3:
+// https://www.rfc-editor.org/rfc/rfc8452#section-3
+//
+// POLYVAL is GHASH with the bytes the other way around. GHASH reads bits in a reflected order
+// (the first bit is x^0), which is awkward for CPUs. POLYVAL uses little endian and the field
+// x^128 + x^127 + x^126 + x^121 + 1, and multiplies with dot(a, b) = a * b * x^-128.
+//
+// Appendix A of the RFC shows both are the same function after reversing bytes:
+//
+//	POLYVAL(H, X1..Xn) = reverse(GHASH(mulX(reverse(H)), reverse(X1)..reverse(Xn)))
+//
+// and that is how it is implemented here, on top of package ghash.
+package polyval
+
+import "github.com/mario-areias/aes-go/ghash"
+
+const (
+	Size      = 16
+	BlockSize = 16
+)
+
+// POLYVAL hashes whatever is written to it, zero padding the last block. It is not safe for concurrent use.
+type POLYVAL struct {
+	g   *ghash.GHASH
+	buf []byte
+}
+
+func New(h [16]byte) *POLYVAL {
+	return &POLYVAL{g: ghash.New(ghash.MulX(reverse(h)))}
+}
+
+func (p *POLYVAL) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for len(p.buf) >= BlockSize {
+		block := reverse([16]byte(p.buf[:BlockSize]))
+		p.g.Write(block[:])
+		p.buf = p.buf[BlockSize:]
+	}
+	p.buf = append(p.buf[:0:0], p.buf...)
+
+	return len(b), nil
+}
+
+// Pad fills the pending partial block with zeros and hashes it. GCM-SIV pads the additional data
+// and the plaintext separately.
+func (p *POLYVAL) Pad() {
+	if len(p.buf) == 0 {
+		return
+	}
+
+	var last [16]byte
+	copy(last[:], p.buf)
+	last = reverse(last)
+	p.g.Write(last[:])
+	p.buf = nil
+}
+
+// Sum appends the hash to b, zero padding a partial block. It doesn't change the state.
+func (p *POLYVAL) Sum(b []byte) []byte {
+	g := *p.g
+	c := POLYVAL{g: &g, buf: p.buf}
+	c.Pad()
+
+	s := reverse([16]byte(c.g.Sum(nil)))
+	return append(b, s[:]...)
+}
+
+func (p *POLYVAL) Reset() {
+	p.g.Reset()
+	p.buf = nil
+}
+
+func (p *POLYVAL) Size() int {
+	return Size
+}
+
+func (p *POLYVAL) BlockSize() int {
+	return BlockSize
+}
+
+func reverse(b [16]byte) [16]byte {
+	for i := 0; i < 8; i++ {
+		b[i], b[15-i] = b[15-i], b[i]
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+package polyval
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+// RFC 8452 appendix A
+func TestRFC8452(t *testing.T) {
+	h := [16]byte(decode("25629347589242761d31f826ba4b757b"))
+	x1 := decode("4f4f95668c83dfb6401762bb2d01a262")
+	x2 := decode("d1a24ddd2721d006bbe45f20d3c9f362")
+	expected := "f7a3b47b846119fae5b7866cf5e5b77e"
+
+	p := New(h)
+	p.Write(x1)
+	p.Write(x2)
+
+	if got := hex.EncodeToString(p.Sum(nil)); got != expected {
+		t.Errorf("Got: %s, Expected: %s", got, expected)
+	}
+}
+
+// reference computes POLYVAL straight from the definition in RFC 8452: S = dot(S + X, H) with
+// dot(a, b) = a * b * x^-128 mod x^128 + x^127 + x^126 + x^121 + 1, bytes in little endian.
+func reference(h [16]byte, data []byte) []byte {
+	poly := new(big.Int).SetBit(new(big.Int), 128, 1)
+	for _, bit := range []int{127, 126, 121, 0} {
+		poly.SetBit(poly, bit, 1)
+	}
+
+	toInt := func(b []byte) *big.Int {
+		r := reverse([16]byte(b))
+		return new(big.Int).SetBytes(r[:])
+	}
+
+	dot := func(a, b *big.Int) *big.Int {
+		// carry-less multiplication, reduced as it goes
+		r := new(big.Int)
+		for i := 0; i < 128; i++ {
+			if b.Bit(i) == 1 {
+				r.Xor(r, new(big.Int).Lsh(a, uint(i)))
+			}
+		}
+		for i := r.BitLen() - 1; i >= 128; i-- {
+			if r.Bit(i) == 1 {
+				r.Xor(r, new(big.Int).Lsh(poly, uint(i-128)))
+			}
+		}
+
+		// times x^-1, 128 times: make it even by adding the polynomial, then divide by x
+		for i := 0; i < 128; i++ {
+			if r.Bit(0) == 1 {
+				r.Xor(r, poly)
+			}
+			r.Rsh(r, 1)
+		}
+		return r
+	}
+
+	s := new(big.Int)
+	for i := 0; i < len(data); i += 16 {
+		var block [16]byte
+		copy(block[:], data[i:min(i+16, len(data))])
+		s = dot(s.Xor(s, toInt(block[:])), toInt(h[:]))
+	}
+
+	var out [16]byte
+	s.FillBytes(out[:])
+	r := reverse(out)
+	return r[:]
+}
+
+func TestReference(t *testing.T) {
+	h := [16]byte(decode("25629347589242761d31f826ba4b757b"))
+
+	for _, size := range []int{0, 1, 16, 17, 32, 100} {
+		data := bytes.Repeat([]byte{0x5a, 0xc3, 0x01}, size/3+1)[:size]
+
+		p := New(h)
+		// split in odd sizes, the blocks are reversed only when complete
+		for d := data; len(d) > 0; {
+			n := min(len(d), 7)
+			p.Write(d[:n])
+			d = d[n:]
+		}
+
+		if got, expected := p.Sum(nil), reference(h, data); !bytes.Equal(got, expected) {
+			t.Errorf("size %d: Got: %x, Expected: %x", size, got, expected)
+		}
+	}
+}
+
+func TestPadAndReset(t *testing.T) {
+	h := [16]byte(decode("25629347589242761d31f826ba4b757b"))
+
+	// padding in the middle is the same as writing the zeros
+	p := New(h)
+	p.Write([]byte("aad"))
+	p.Pad()
+	p.Write([]byte("plaintext"))
+
+	expected := New(h)
+	expected.Write(append([]byte("aad"), make([]byte, 13)...))
+	expected.Write([]byte("plaintext"))
+
+	if !bytes.Equal(p.Sum(nil), expected.Sum(nil)) {
+		t.Errorf("Got: %x, Expected: %x", p.Sum(nil), expected.Sum(nil))
+	}
+
+	p.Reset()
+	if got := p.Sum(nil); !bytes.Equal(got, make([]byte, 16)) {
+		t.Errorf("Got: %x, Expected: %x", got, make([]byte, 16))
+	}
+}
+
+func decode(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
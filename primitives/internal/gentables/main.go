@@ -0,0 +1,100 @@
+// Command gentables writes the lookup tables of the primitives package. Nothing is typed by hand,
+// every table is computed from the GF(2^8) arithmetic of FIPS-197:
+//
+//   - the S-box is S(x) = affine(x^-1), section 5.1.1, and the inverse S-box is its inverse
+//   - the round constants are the powers of x, section 5.2
+//   - the multiplication tables are {02}, {03}, {09}, {0b}, {0d} and {0e} times every byte, the
+//     coefficients of MixColumns and InvMixColumns
+//
+// Run it with go generate ./primitives.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+
+	"github.com/mario-areias/aes-go/gf256"
+)
+
+func main() {
+	out := flag.String("o", "tables.go", "output file")
+	flag.Parse()
+
+	src, err := generate()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gentables. DO NOT EDIT.\n\npackage primitives\n")
+
+	s, inv := sbox()
+
+	writeTable(&b, "sbox is the S-box of FIPS-197 figure 7.", "sbox", s[:])
+	writeTable(&b, "invSBox is the inverse S-box of FIPS-197 figure 14.", "invSBox", inv[:])
+
+	rcon := rcon()
+	writeTable(&b, "rcon[i] is x^i, the first byte of the round constant of round i+1.", "rcon", rcon[:])
+
+	for _, c := range []byte{0x02, 0x03, 0x09, 0x0b, 0x0d, 0x0e} {
+		t := mulTable(c)
+		writeTable(&b, fmt.Sprintf("mul%d[x] is {%02x} times x.", c, c), fmt.Sprintf("mul%d", c), t[:])
+	}
+
+	return format.Source(b.Bytes())
+}
+
+func sbox() ([256]byte, [256]byte) {
+	var s, inv [256]byte
+	for x := 0; x < 256; x++ {
+		b := gf256.Inverse(byte(x))
+		// the affine transformation, b XOR its rotations by 1 to 4 bits XOR {63}
+		y := b ^ rotl(b, 1) ^ rotl(b, 2) ^ rotl(b, 3) ^ rotl(b, 4) ^ 0x63
+
+		s[x] = y
+		inv[y] = byte(x)
+	}
+	return s, inv
+}
+
+func rcon() [10]byte {
+	var r [10]byte
+	x := byte(1)
+	for i := range r {
+		r[i] = x
+		x = gf256.Mul(x, 0x02)
+	}
+	return r
+}
+
+func mulTable(c byte) [256]byte {
+	var t [256]byte
+	for x := 0; x < 256; x++ {
+		t[x] = gf256.Mul(c, byte(x))
+	}
+	return t
+}
+
+func writeTable(b *bytes.Buffer, doc, name string, t []byte) {
+	fmt.Fprintf(b, "\n// %s\nvar %s = [%d]byte{", doc, name, len(t))
+	for i, v := range t {
+		if i%16 == 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "0x%02x, ", v)
+	}
+	b.WriteString("\n}\n")
+}
+
+func rotl(b byte, n int) byte {
+	return b<<n | b>>(8-n)
+}
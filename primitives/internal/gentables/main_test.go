@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// The checked in file must be what the generator writes, run go generate ./primitives otherwise.
+func TestGeneratedFileIsUpToDate(t *testing.T) {
+	expected, err := generate()
+	if err != nil {
+		t.Fatalf("Error generating: %s", err)
+	}
+
+	got, err := os.ReadFile("../../tables.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, expected) {
+		t.Errorf("primitives/tables.go is out of date, run go generate ./primitives")
+	}
+}
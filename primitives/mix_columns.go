@@ -0,0 +1,68 @@
+package primitives
+
+import "github.com/mario-areias/aes-go/gf256"
+
+// GMul performs Galois Field (256) multiplication of two bytes, see the gf256 package for the rest of the field.
+func GMul(a, b byte) byte {
+	return gf256.Mul(a, b)
+}
+
+// MixColumns mixes the columns of the state matrix. Each column is multiplied by the fixed
+// polynomial {03}x^3 + {01}x^2 + {01}x + {02} (FIPS-197 section 5.1.3).
+// The coefficients are fixed, so the multiplications are lookups in the tables of tables.go
+// instead of GMul, which loops over the 8 bits every time.
+func MixColumns(s [4][4]byte) [4][4]byte {
+	// Temporary matrix to hold the results
+	var ss [4][4]byte
+
+	for c := 0; c < 4; c++ {
+		ss[0][c] = mul2[s[0][c]] ^ mul3[s[1][c]] ^ s[2][c] ^ s[3][c]
+		ss[1][c] = s[0][c] ^ mul2[s[1][c]] ^ mul3[s[2][c]] ^ s[3][c]
+		ss[2][c] = s[0][c] ^ s[1][c] ^ mul2[s[2][c]] ^ mul3[s[3][c]]
+		ss[3][c] = mul3[s[0][c]] ^ s[1][c] ^ s[2][c] ^ mul2[s[3][c]]
+	}
+
+	// Copy the results back to the original state matrix
+	return ss
+}
+
+// InvMixColumns undoes MixColumns, the column is multiplied by {0b}x^3 + {0d}x^2 + {09}x + {0e}.
+func InvMixColumns(s [4][4]byte) [4][4]byte {
+	// Temporary matrix to hold the results
+	var ss [4][4]byte
+
+	for c := 0; c < 4; c++ {
+		ss[0][c] = mul14[s[0][c]] ^ mul11[s[1][c]] ^ mul13[s[2][c]] ^ mul9[s[3][c]]
+		ss[1][c] = mul9[s[0][c]] ^ mul14[s[1][c]] ^ mul11[s[2][c]] ^ mul13[s[3][c]]
+		ss[2][c] = mul13[s[0][c]] ^ mul9[s[1][c]] ^ mul14[s[2][c]] ^ mul11[s[3][c]]
+		ss[3][c] = mul11[s[0][c]] ^ mul13[s[1][c]] ^ mul9[s[2][c]] ^ mul14[s[3][c]]
+	}
+
+	// Copy the results back to the original state matrix
+	return ss
+}
+
+// MixColumnsGMul is MixColumns computing the products with GMul. It's slower, but unlike the
+// tables no memory access depends on the state, see WithConstantTime in aesgo.
+func MixColumnsGMul(s [4][4]byte) [4][4]byte {
+	var ss [4][4]byte
+	for c := 0; c < 4; c++ {
+		ss[0][c] = GMul(0x02, s[0][c]) ^ GMul(0x03, s[1][c]) ^ s[2][c] ^ s[3][c]
+		ss[1][c] = s[0][c] ^ GMul(0x02, s[1][c]) ^ GMul(0x03, s[2][c]) ^ s[3][c]
+		ss[2][c] = s[0][c] ^ s[1][c] ^ GMul(0x02, s[2][c]) ^ GMul(0x03, s[3][c])
+		ss[3][c] = GMul(0x03, s[0][c]) ^ s[1][c] ^ s[2][c] ^ GMul(0x02, s[3][c])
+	}
+	return ss
+}
+
+// InvMixColumnsGMul is InvMixColumns computing the products with GMul.
+func InvMixColumnsGMul(s [4][4]byte) [4][4]byte {
+	var ss [4][4]byte
+	for c := 0; c < 4; c++ {
+		ss[0][c] = GMul(0x0e, s[0][c]) ^ GMul(0x0b, s[1][c]) ^ GMul(0x0d, s[2][c]) ^ GMul(0x09, s[3][c])
+		ss[1][c] = GMul(0x09, s[0][c]) ^ GMul(0x0e, s[1][c]) ^ GMul(0x0b, s[2][c]) ^ GMul(0x0d, s[3][c])
+		ss[2][c] = GMul(0x0d, s[0][c]) ^ GMul(0x09, s[1][c]) ^ GMul(0x0e, s[2][c]) ^ GMul(0x0b, s[3][c])
+		ss[3][c] = GMul(0x0b, s[0][c]) ^ GMul(0x0d, s[1][c]) ^ GMul(0x09, s[2][c]) ^ GMul(0x0e, s[3][c])
+	}
+	return ss
+}
@@ -0,0 +1,48 @@
+package primitives
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMixColumnsTables(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 1000; i++ {
+		var b [16]byte
+		r.Read(b[:])
+		s := ToState(b)
+
+		if got, expected := MixColumns(s), MixColumnsGMul(s); got != expected {
+			t.Fatalf("MixColumns(%x). Expected %x, got %x", b, FromState(expected), FromState(got))
+		}
+		if got, expected := InvMixColumns(s), InvMixColumnsGMul(s); got != expected {
+			t.Fatalf("InvMixColumns(%x). Expected %x, got %x", b, FromState(expected), FromState(got))
+		}
+	}
+}
+
+// go test -bench MixColumns ./primitives
+func BenchmarkMixColumns(b *testing.B) {
+	benchmarkState(b, MixColumns)
+}
+
+func BenchmarkMixColumnsGMul(b *testing.B) {
+	benchmarkState(b, MixColumnsGMul)
+}
+
+func BenchmarkInvMixColumns(b *testing.B) {
+	benchmarkState(b, InvMixColumns)
+}
+
+func BenchmarkInvMixColumnsGMul(b *testing.B) {
+	benchmarkState(b, InvMixColumnsGMul)
+}
+
+func benchmarkState(b *testing.B, fn func([4][4]byte) [4][4]byte) {
+	s := ToState(block("00112233445566778899aabbccddeeff"))
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		s = fn(s)
+	}
+}
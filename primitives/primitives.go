@@ -0,0 +1,125 @@
+// Package primitives exposes each step of AES on its own, so they can be called and inspected
+// individually. aesgo is built on top of them.
+//
+// The state is a 4x4 matrix indexed by [row][column], as in FIPS-197 section 3.4. A 16 byte block
+// fills the state column by column: block[0..3] is the first column, block[4..7] the second and so on.
+// Use ToState and FromState to convert between both.
+//
+// A round of encryption is:
+//
+//	s = SubBytes(s)
+//	s = ShiftRows(s)
+//	s = MixColumns(s) // except on the last round
+//	s = AddRoundKey(s, ToState(roundKey))
+//
+// These functions are not constant time, see aesgo.WithConstantTime for that.
+package primitives
+
+// ToState converts a block to the state matrix.
+func ToState(b [16]byte) [4][4]byte {
+	var r [4][4]byte
+
+	r[0] = [4]byte{b[0], b[4], b[8], b[12]}
+	r[1] = [4]byte{b[1], b[5], b[9], b[13]}
+	r[2] = [4]byte{b[2], b[6], b[10], b[14]}
+	r[3] = [4]byte{b[3], b[7], b[11], b[15]}
+
+	return r
+}
+
+// FromState converts the state matrix back to a block.
+func FromState(m [4][4]byte) [16]byte {
+	var r [16]byte
+	r[0] = m[0][0]
+	r[1] = m[1][0]
+	r[2] = m[2][0]
+	r[3] = m[3][0]
+	r[4] = m[0][1]
+	r[5] = m[1][1]
+	r[6] = m[2][1]
+	r[7] = m[3][1]
+	r[8] = m[0][2]
+	r[9] = m[1][2]
+	r[10] = m[2][2]
+	r[11] = m[3][2]
+	r[12] = m[0][3]
+	r[13] = m[1][3]
+	r[14] = m[2][3]
+	r[15] = m[3][3]
+	return r
+}
+
+// SubBytes replaces every byte of the state with its S-box entry.
+func SubBytes(state [4][4]byte) [4][4]byte {
+	return substitute(state, SBox())
+}
+
+// InvSubBytes replaces every byte of the state with its inverse S-box entry.
+func InvSubBytes(state [4][4]byte) [4][4]byte {
+	return substitute(state, InvSBox())
+}
+
+func substitute(state [4][4]byte, table [256]byte) [4][4]byte {
+	var s [4][4]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			s[i][j] = table[state[i][j]]
+		}
+	}
+	return s
+}
+
+// ShiftRows rotates row r of the state r positions to the left.
+func ShiftRows(state [4][4]byte) [4][4]byte {
+	var s [4][4]byte
+	s[0] = state[0]
+
+	s[1] = [4]byte{state[1][1], state[1][2], state[1][3], state[1][0]}
+	s[2] = [4]byte{state[2][2], state[2][3], state[2][0], state[2][1]}
+	s[3] = [4]byte{state[3][3], state[3][0], state[3][1], state[3][2]}
+
+	return s
+}
+
+// InvShiftRows rotates row r of the state r positions to the right.
+func InvShiftRows(state [4][4]byte) [4][4]byte {
+	var s [4][4]byte
+	s[0] = state[0]
+
+	s[1] = [4]byte{state[1][3], state[1][0], state[1][1], state[1][2]}
+	s[2] = [4]byte{state[2][2], state[2][3], state[2][0], state[2][1]}
+	s[3] = [4]byte{state[3][1], state[3][2], state[3][3], state[3][0]}
+
+	return s
+}
+
+// AddRoundKey XORs the state with the round key. It is its own inverse.
+func AddRoundKey(state [4][4]byte, key [4][4]byte) [4][4]byte {
+	var x [4][4]byte
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			x[i][j] = state[i][j] ^ key[i][j]
+		}
+	}
+	return x
+}
+
+// RotWord is the cyclic permutation of the key schedule: [a0, a1, a2, a3] becomes [a1, a2, a3, a0].
+func RotWord(word [4]byte) [4]byte {
+	return [4]byte{word[1], word[2], word[3], word[0]}
+}
+
+// SubWord applies the S-box to each byte of a key schedule word.
+func SubWord(word [4]byte) [4]byte {
+	var s [4]byte
+	for i := 0; i < 4; i++ {
+		s[i] = SBox()[word[i]]
+	}
+	return s
+}
+
+// Rcon returns the round constant word [x^(round-1), 0, 0, 0] for rounds 1 to 10.
+// It panics for any other round.
+func Rcon(round int) [4]byte {
+	return [4]byte{rcon[round-1]}
+}
@@ -0,0 +1,186 @@
+package primitives
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/mario-areias/aes-go/gf256"
+)
+
+// FIPS-197 appendix B, first round of the example
+const (
+	start       = "193de3bea0f4e22b9ac68d2ae9f84808"
+	afterSub    = "d42711aee0bf98f1b8b45de51e415230"
+	afterShift  = "d4bf5d30e0b452aeb84111f11e2798e5"
+	afterMix    = "046681e5e0cb199a48f8d37a2806264c"
+	roundKey1   = "a0fafe1788542cb123a339392a6c7605"
+	startRound2 = "a49c7ff2689f352b6b5bea43026a5049"
+)
+
+func TestTransformations(t *testing.T) {
+	tests := []struct {
+		name string
+
+		fn func([4][4]byte) [4][4]byte
+
+		input    string
+		expected string
+	}{
+		{
+			name: "SubBytes",
+
+			fn: SubBytes,
+
+			input:    start,
+			expected: afterSub,
+		},
+		{
+			name: "InvSubBytes",
+
+			fn: InvSubBytes,
+
+			input:    afterSub,
+			expected: start,
+		},
+		{
+			name: "ShiftRows",
+
+			fn: ShiftRows,
+
+			input:    afterSub,
+			expected: afterShift,
+		},
+		{
+			name: "InvShiftRows",
+
+			fn: InvShiftRows,
+
+			input:    afterShift,
+			expected: afterSub,
+		},
+		{
+			name: "MixColumns",
+
+			fn: MixColumns,
+
+			input:    afterShift,
+			expected: afterMix,
+		},
+		{
+			name: "InvMixColumns",
+
+			fn: InvMixColumns,
+
+			input:    afterMix,
+			expected: afterShift,
+		},
+		{
+			name: "AddRoundKey",
+
+			fn: func(s [4][4]byte) [4][4]byte { return AddRoundKey(s, ToState(block(roundKey1))) },
+
+			input:    afterMix,
+			expected: startRound2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromState(tt.fn(ToState(block(tt.input))))
+			if hex.EncodeToString(got[:]) != tt.expected {
+				t.Errorf("Got: %x, Expected: %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestState(t *testing.T) {
+	b := block("000102030405060708090a0b0c0d0e0f")
+	s := ToState(b)
+
+	// the block fills the state column by column
+	if s[0] != [4]byte{0x00, 0x04, 0x08, 0x0c} || s[1][0] != 0x01 || s[3][3] != 0x0f {
+		t.Errorf("Unexpected state %x", s)
+	}
+	if FromState(s) != b {
+		t.Errorf("Expected %x, got %x", b, FromState(s))
+	}
+}
+
+func TestSBox(t *testing.T) {
+	s, inv := SBox(), InvSBox()
+
+	if s[0x53] != 0xed {
+		t.Errorf("Expected ed, got %x", s[0x53])
+	}
+	for x := 0; x < 256; x++ {
+		if inv[s[x]] != byte(x) {
+			t.Fatalf("Expected InvSBox(SBox(%x)) = %x, got %x", x, x, inv[s[x]])
+		}
+	}
+}
+
+// The table is S(x) = affine(x^-1), FIPS-197 section 5.1.1. Generating it proves the hard-coded one.
+func TestSBoxFromAffineTransform(t *testing.T) {
+	s := SBox()
+
+	for x := 0; x < 256; x++ {
+		b := gf256.Inverse(byte(x))
+		expected := b ^ rotl(b, 1) ^ rotl(b, 2) ^ rotl(b, 3) ^ rotl(b, 4) ^ 0x63
+
+		if s[x] != expected {
+			t.Errorf("S(%02x). Expected %02x, got %02x", x, expected, s[x])
+		}
+	}
+}
+
+func TestGMul(t *testing.T) {
+	// FIPS-197 section 4.2
+	tests := []struct {
+		a, b     byte
+		expected byte
+	}{
+		{0x57, 0x83, 0xc1},
+		{0x57, 0x13, 0xfe},
+		{0x57, 0x02, 0xae},
+		{0x57, 0x01, 0x57},
+		{0x57, 0x00, 0x00},
+	}
+
+	for _, tt := range tests {
+		if got := GMul(tt.a, tt.b); got != tt.expected {
+			t.Errorf("GMul(%x, %x): expected %x, got %x", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
+
+func TestKeyScheduleWords(t *testing.T) {
+	// FIPS-197 appendix A.1, computing w4 from w3 = 09cf4f3c
+	w3 := [4]byte{0x09, 0xcf, 0x4f, 0x3c}
+
+	rot := RotWord(w3)
+	if rot != [4]byte{0xcf, 0x4f, 0x3c, 0x09} {
+		t.Errorf("RotWord: got %x", rot)
+	}
+
+	sub := SubWord(rot)
+	if sub != [4]byte{0x8a, 0x84, 0xeb, 0x01} {
+		t.Errorf("SubWord: got %x", sub)
+	}
+
+	if Rcon(1) != [4]byte{0x01} || Rcon(9) != [4]byte{0x1b} || Rcon(10) != [4]byte{0x36} {
+		t.Errorf("Unexpected Rcon values %x %x %x", Rcon(1), Rcon(9), Rcon(10))
+	}
+}
+
+func block(s string) [16]byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return [16]byte(b)
+}
+
+func rotl(b byte, n int) byte {
+	return b<<n | b>>(8-n)
+}
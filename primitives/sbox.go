@@ -0,0 +1,14 @@
+package primitives
+
+// The tables are computed from the GF(2^8) arithmetic by internal/gentables, see tables.go.
+//go:generate go run ./internal/gentables -o tables.go
+
+// SBox returns the substitution table of FIPS-197 figure 7. SBox()[0x53] is 0xed.
+func SBox() [256]byte {
+	return sbox
+}
+
+// InvSBox returns the inverse table (FIPS-197 figure 14), InvSBox()[SBox()[x]] == x.
+func InvSBox() [256]byte {
+	return invSBox
+}
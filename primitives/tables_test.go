@@ -0,0 +1,39 @@
+package primitives
+
+import (
+	"testing"
+
+	"github.com/mario-areias/aes-go/gf256"
+)
+
+func TestMulTables(t *testing.T) {
+	tables := map[byte][256]byte{
+		0x02: mul2,
+		0x03: mul3,
+		0x09: mul9,
+		0x0b: mul11,
+		0x0d: mul13,
+		0x0e: mul14,
+	}
+
+	for c, table := range tables {
+		for x := 0; x < 256; x++ {
+			if expected := gf256.Mul(c, byte(x)); table[x] != expected {
+				t.Errorf("{%02x} times %02x. Expected %02x, got %02x", c, x, expected, table[x])
+			}
+		}
+	}
+
+	// FIPS-197 section 4.2.1, {57} times {02} is {ae}
+	if mul2[0x57] != 0xae {
+		t.Errorf("Expected ae, got %02x", mul2[0x57])
+	}
+}
+
+func TestRcon(t *testing.T) {
+	// FIPS-197 section 5.2
+	expected := [10]byte{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x1b, 0x36}
+	if rcon != expected {
+		t.Errorf("Expected %x, got %x", expected, rcon)
+	}
+}
@@ -0,0 +1,183 @@
+// Package ratchet replaces the key of a long-lived channel every few messages.
+//
+// Epoch 0 uses the root key. Every interval messages the sender moves to the next epoch, whose key
+// is HKDF-SHA256 of the previous one, and erases the previous key. HKDF can't be reversed, so
+// someone who steals the key of epoch n can decrypt epoch n and later, but not the earlier epochs.
+//
+// Messages are GCM envelopes (aesgo.Ciphertext) with the epoch in the Epoch field. The receiver
+// moves forward when a message of a later epoch authenticates, after that the older messages
+// can't be decrypted anymore: late messages from a previous epoch fail with ErrOldEpoch.
+//
+// A Ratchet only goes in one direction, use one for each side of a two way channel.
+package ratchet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// MaxSkip is how many epochs a received message can be ahead. Each epoch is one HKDF call, the
+// limit stops a forged epoch from keeping the receiver busy.
+const MaxSkip = 1024
+
+var (
+	ErrInvalidInterval = errors.New("Ratchet interval must be at least 1")
+	ErrOldEpoch        = errors.New("Message is from an epoch whose key was erased")
+	ErrEpochTooFar     = errors.New("Message is too many epochs ahead")
+)
+
+var info = []byte("aes-go ratchet")
+
+type Ratchet struct {
+	mu sync.Mutex
+
+	current  key.Key
+	epoch    uint64
+	messages uint64
+	interval uint64
+}
+
+// New starts at epoch 0 with a copy of root, moving to the next epoch every interval messages.
+// Destroy root once both sides have their ratchet, otherwise it can decrypt epoch 0 and derive
+// every later key.
+func New(root key.Key, interval uint64) (*Ratchet, error) {
+	if root.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if interval == 0 {
+		return nil, ErrInvalidInterval
+	}
+
+	k, err := newKey(append([]byte{}, root.GetBytes()...))
+	if err != nil {
+		return nil, err
+	}
+	return &Ratchet{current: k, interval: interval}, nil
+}
+
+// Epoch is the current epoch, for the sender it is the epoch of the next message.
+func (r *Ratchet) Epoch() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.epoch
+}
+
+// Encrypt returns a marshaled aesgo.Ciphertext, moving to the next epoch first when the current
+// one already encrypted interval messages.
+func (r *Ratchet) Encrypt(plaintext []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.messages == r.interval {
+		next, err := advance(r.current, 1)
+		if err != nil {
+			return nil, err
+		}
+		r.current.Destroy()
+		r.current = next
+		r.epoch++
+		r.messages = 0
+	}
+
+	a, err := aesgo.NewCipher(r.current)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := a.EncryptCiphertext(aesgo.GCM, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	c.Epoch = r.epoch
+	r.messages++
+
+	return c.Marshal(), nil
+}
+
+// Decrypt derives the key of the epoch in the envelope. The ratchet only moves forward when the
+// message authenticates, so a forged epoch doesn't erase keys that are still needed.
+func (r *Ratchet) Decrypt(encrypted []byte) ([]byte, error) {
+	c, err := aesgo.Unmarshal(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if c.Mode != aesgo.GCM {
+		return nil, aesgo.ErrInvalidCiphertext
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case c.Epoch < r.epoch:
+		return nil, ErrOldEpoch
+	case c.Epoch-r.epoch > MaxSkip:
+		return nil, ErrEpochTooFar
+	}
+
+	k := r.current
+	if c.Epoch > r.epoch {
+		if k, err = advance(r.current, c.Epoch-r.epoch); err != nil {
+			return nil, err
+		}
+	}
+
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := a.DecryptCiphertext(c)
+	if err != nil {
+		if k != r.current {
+			k.Destroy()
+		}
+		return nil, err
+	}
+
+	if k != r.current {
+		r.current.Destroy()
+		r.current = k
+		r.epoch = c.Epoch
+	}
+
+	return plaintext, nil
+}
+
+// advance derives the key n epochs after k, erasing the keys in between. k is left alone.
+func advance(k key.Key, n uint64) (key.Key, error) {
+	for i := uint64(0); i < n; i++ {
+		material, err := key.HKDF(sha256.New, k.GetBytes(), nil, info, k.Len())
+		if err != nil {
+			return nil, err
+		}
+
+		next, err := newKey(material)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			k.Destroy()
+		}
+		k = next
+	}
+	return k, nil
+}
+
+// newKey takes over material and wipes it, the key has its own copy.
+func newKey(material []byte) (key.Key, error) {
+	defer clear(material)
+
+	switch len(material) {
+	case 16:
+		return key.NewKey([16]byte(material)), nil
+	case 32:
+		return key.NewKey256([32]byte(material)), nil
+	}
+	return nil, key.ErrInvalidKeySize
+}
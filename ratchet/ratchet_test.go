@@ -0,0 +1,173 @@
+package ratchet
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func newPair(t *testing.T, root key.Key, interval uint64) (*Ratchet, *Ratchet) {
+	t.Helper()
+
+	sender, err := New(root, interval)
+	if err != nil {
+		t.Fatalf("Error creating ratchet: %s", err)
+	}
+	receiver, err := New(root, interval)
+	if err != nil {
+		t.Fatalf("Error creating ratchet: %s", err)
+	}
+	return sender, receiver
+}
+
+func encrypt(t *testing.T, r *Ratchet, n int) [][]byte {
+	t.Helper()
+
+	messages := make([][]byte, n)
+	for i := range messages {
+		m, err := r.Encrypt([]byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Fatalf("Error encrypting: %s", err)
+		}
+		messages[i] = m
+	}
+	return messages
+}
+
+func TestRatchet(t *testing.T) {
+	for _, root := range []key.Key{key.Bit128(), key.Bit256()} {
+		sender, receiver := newPair(t, root, 3)
+
+		for i, m := range encrypt(t, sender, 10) {
+			c, _ := aesgo.Unmarshal(m)
+			if expected := uint64(i / 3); c.Epoch != expected {
+				t.Errorf("Message %d: Expected %v, got %v", i, expected, c.Epoch)
+			}
+
+			plaintext, err := receiver.Decrypt(m)
+			if err != nil {
+				t.Fatalf("Message %d: Error decrypting: %s", i, err)
+			}
+			if expected := fmt.Sprintf("message %d", i); string(plaintext) != expected {
+				t.Errorf("Got: %s, Expected: %s", plaintext, expected)
+			}
+		}
+
+		if sender.Epoch() != 3 || receiver.Epoch() != 3 {
+			t.Errorf("Expected %v, got %v and %v", 3, sender.Epoch(), receiver.Epoch())
+		}
+	}
+}
+
+// The key of an epoch is HKDF of the previous one, nothing else.
+func TestDerivation(t *testing.T) {
+	root := key.Bit128()
+	sender, _ := newPair(t, root, 1)
+	messages := encrypt(t, sender, 3)
+
+	k := root.GetBytes()
+	for i := 0; i < 2; i++ {
+		k, _ = key.HKDF(sha256.New, k, nil, []byte("aes-go ratchet"), 16)
+	}
+
+	a, _ := aesgo.NewCipher(key.NewKey([16]byte(k)))
+	c, _ := aesgo.Unmarshal(messages[2])
+	plaintext, err := a.DecryptCiphertext(c)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(plaintext) != "message 2" {
+		t.Errorf("Got: %s, Expected: %s", plaintext, "message 2")
+	}
+
+	// the root key doesn't open later epochs
+	a, _ = aesgo.NewCipher(root)
+	if _, err := a.DecryptCiphertext(c); err != aesgo.ErrAuthentication {
+		t.Errorf("Expected %v, got %v", aesgo.ErrAuthentication, err)
+	}
+}
+
+func TestSkippedEpochs(t *testing.T) {
+	sender, receiver := newPair(t, key.Bit128(), 2)
+	messages := encrypt(t, sender, 9)
+
+	// 0 and 1 are lost, the receiver jumps from epoch 0 to 4
+	if _, err := receiver.Decrypt(messages[8]); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if receiver.Epoch() != 4 {
+		t.Errorf("Expected %v, got %v", 4, receiver.Epoch())
+	}
+
+	// the keys of the earlier epochs are gone
+	if _, err := receiver.Decrypt(messages[7]); err != ErrOldEpoch {
+		t.Errorf("Expected %v, got %v", ErrOldEpoch, err)
+	}
+}
+
+func TestForgedEpoch(t *testing.T) {
+	sender, receiver := newPair(t, key.Bit128(), 10)
+	messages := encrypt(t, sender, 2)
+
+	tests := []struct {
+		name string
+
+		epoch    uint64
+		expected error
+	}{
+		{name: "next epoch", epoch: 1, expected: aesgo.ErrAuthentication},
+		{name: "last epoch allowed", epoch: MaxSkip, expected: aesgo.ErrAuthentication},
+		{name: "too far", epoch: MaxSkip + 1, expected: ErrEpochTooFar},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, _ := aesgo.Unmarshal(messages[0])
+			c.Epoch = test.epoch
+
+			if _, err := receiver.Decrypt(c.Marshal()); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	// the forgeries didn't move the receiver, the real messages still decrypt
+	for _, m := range messages {
+		if _, err := receiver.Decrypt(m); err != nil {
+			t.Errorf("Error decrypting: %s", err)
+		}
+	}
+	if receiver.Epoch() != 0 {
+		t.Errorf("Expected %v, got %v", 0, receiver.Epoch())
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(key.Bit128(), 0); err != ErrInvalidInterval {
+		t.Errorf("Expected %v, got %v", ErrInvalidInterval, err)
+	}
+
+	destroyed := key.Bit128()
+	destroyed.Destroy()
+	if _, err := New(destroyed, 1); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+
+	// only GCM envelopes are accepted, the epoch must be authenticated
+	root := key.Bit128()
+	r, _ := New(root, 1)
+	a, _ := aesgo.NewCipher(root)
+	c, _ := a.EncryptCiphertext(aesgo.CBC, []byte("not authenticated"))
+	if _, err := r.Decrypt(c.Marshal()); err != aesgo.ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", aesgo.ErrInvalidCiphertext, err)
+	}
+
+	// the ratchet has its own copy of the root key
+	root.Destroy()
+	if _, err := r.Encrypt([]byte("still works")); err != nil {
+		t.Errorf("Error encrypting: %s", err)
+	}
+}
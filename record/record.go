@@ -0,0 +1,127 @@
+// Package record is a small record layer for a channel between two peers, in the spirit of TLS and
+// DTLS records. Every record is AES-GCM encrypted and carries its sequence number:
+//
+//	sequence number (8 bytes, big endian) | ciphertext | tag (16 bytes)
+//
+// The sequence number is the GCM nonce (4 zero bytes and the 8 bytes) and it is also authenticated
+// as additional data, so an attacker can't change it. The receiver keeps an anti-replay Window and
+// rejects records that were replayed, or that arrive out of order.
+//
+// A nonce must never repeat under the same key, so each direction of a channel needs its own key.
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const headerSize = 8
+
+var (
+	ErrTruncated         = errors.New("Record is shorter than the header and the tag")
+	ErrSequenceExhausted = errors.New("Sequence numbers exhausted, the key must be replaced")
+)
+
+// Sealer encrypts the records sent to the peer.
+type Sealer struct {
+	a   *aesgo.AES
+	seq uint64
+}
+
+func NewSealer(k key.Key) (*Sealer, error) {
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealer{a: a}, nil
+}
+
+// Seal returns the next record.
+func (s *Sealer) Seal(plaintext []byte) ([]byte, error) {
+	// the last number is never used, the receiver window counts one past the highest
+	if s.seq == math.MaxUint64 {
+		return nil, ErrSequenceExhausted
+	}
+
+	header := binary.BigEndian.AppendUint64(nil, s.seq)
+	encrypted, err := s.a.SealGCM(nonce(s.seq), plaintext, header)
+	if err != nil {
+		return nil, err
+	}
+
+	s.seq++
+	return append(header, encrypted...), nil
+}
+
+// Opener decrypts the records received from the peer.
+type Opener struct {
+	a      *aesgo.AES
+	window *Window
+}
+
+type Option func(*Opener) error
+
+// WithWindow accepts records up to size positions behind the newest one, for transports that
+// reorder packets like UDP. The default is 0: records must arrive in order, which is right for TCP.
+func WithWindow(size int) Option {
+	return func(o *Opener) error {
+		w, err := NewWindow(size)
+		if err != nil {
+			return err
+		}
+		o.window = w
+		return nil
+	}
+}
+
+func NewOpener(k key.Key, opts ...Option) (*Opener, error) {
+	a, err := aesgo.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Opener{a: a}
+	o.window, _ = NewWindow(0)
+
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// Open authenticates and decrypts a record. Rejected records don't change the state, the next
+// valid record is still accepted.
+func (o *Opener) Open(record []byte) ([]byte, error) {
+	if len(record) < headerSize+aesgo.GCMTagSize {
+		return nil, ErrTruncated
+	}
+
+	header := record[:headerSize]
+	seq := binary.BigEndian.Uint64(header)
+
+	// cheap check first, replays don't even get decrypted
+	if err := o.window.Check(seq); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := o.a.OpenGCM(nonce(seq), record[headerSize:], header)
+	if err != nil {
+		return nil, err
+	}
+
+	o.window.Accept(seq)
+	return plaintext, nil
+}
+
+func nonce(seq uint64) []byte {
+	n := make([]byte, aesgo.GCMNonceSize)
+	binary.BigEndian.PutUint64(n[4:], seq)
+	return n
+}
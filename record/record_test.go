@@ -0,0 +1,223 @@
+package record
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name string
+
+		size     int
+		accepted []uint64
+		seq      uint64
+
+		expected error
+	}{
+		{name: "first record", size: 0, seq: 0, expected: nil},
+		{name: "in order", size: 0, accepted: []uint64{0, 1}, seq: 2, expected: nil},
+		{name: "gap without window", size: 0, accepted: []uint64{0}, seq: 2, expected: ErrReordered},
+		{name: "replay without window", size: 0, accepted: []uint64{0, 1}, seq: 1, expected: ErrReplay},
+		{name: "gap with window", size: 64, accepted: []uint64{0}, seq: 5, expected: nil},
+		{name: "late record inside the window", size: 64, accepted: []uint64{0, 5}, seq: 3, expected: nil},
+		{name: "replay inside the window", size: 64, accepted: []uint64{0, 5, 3}, seq: 3, expected: ErrReplay},
+		{name: "replay of the newest", size: 64, accepted: []uint64{0, 5}, seq: 5, expected: ErrReplay},
+		{name: "older than the window", size: 64, accepted: []uint64{0, 100}, seq: 36, expected: ErrTooOld},
+		{name: "edge of the window", size: 64, accepted: []uint64{0, 100}, seq: 37, expected: nil},
+		{name: "replay across words", size: 128, accepted: []uint64{10, 80, 150}, seq: 80, expected: ErrReplay},
+		{name: "late record across words", size: 128, accepted: []uint64{10, 80, 150}, seq: 81, expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, err := NewWindow(test.size)
+			if err != nil {
+				t.Fatalf("Error creating window: %s", err)
+			}
+
+			for _, seq := range test.accepted {
+				if err := w.Check(seq); err != nil {
+					t.Fatalf("Error accepting %d: %s", seq, err)
+				}
+				w.Accept(seq)
+			}
+
+			if err := w.Check(test.seq); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+
+	if _, err := NewWindow(MaxWindowSize + 1); err != ErrInvalidWindowSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidWindowSize, err)
+	}
+}
+
+func newPair(t *testing.T, opts ...Option) (*Sealer, *Opener) {
+	t.Helper()
+
+	k := key.Bit128()
+	s, err := NewSealer(k)
+	if err != nil {
+		t.Fatalf("Error creating sealer: %s", err)
+	}
+	o, err := NewOpener(k, opts...)
+	if err != nil {
+		t.Fatalf("Error creating opener: %s", err)
+	}
+	return s, o
+}
+
+func seal(t *testing.T, s *Sealer, n int) [][]byte {
+	t.Helper()
+
+	records := make([][]byte, n)
+	for i := range records {
+		r, err := s.Seal([]byte(fmt.Sprintf("record %d", i)))
+		if err != nil {
+			t.Fatalf("Error sealing: %s", err)
+		}
+		records[i] = r
+	}
+	return records
+}
+
+func TestInOrder(t *testing.T) {
+	s, o := newPair(t)
+
+	for i, r := range seal(t, s, 5) {
+		plaintext, err := o.Open(r)
+		if err != nil {
+			t.Fatalf("Error opening: %s", err)
+		}
+		if expected := fmt.Sprintf("record %d", i); string(plaintext) != expected {
+			t.Errorf("Got: %s, Expected: %s", plaintext, expected)
+		}
+	}
+}
+
+// The attacker sits between the peers and can replay, reorder, drop and modify records.
+func TestActiveAttacker(t *testing.T) {
+	tests := []struct {
+		name string
+
+		opts []Option
+		// attack returns the records in the order the receiver gets them
+		attack func(r [][]byte) [][]byte
+
+		// errors for each delivered record, nil when it must be accepted
+		expected []error
+	}{
+		{
+			name: "replay",
+
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[1], r[1], r[2]} },
+			expected: []error{nil, nil, ErrReplay, nil},
+		},
+		{
+			name: "replay of an old record",
+
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[1], r[2], r[0]} },
+			expected: []error{nil, nil, nil, ErrReplay},
+		},
+		{
+			name: "reorder",
+
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[2], r[1]} },
+			expected: []error{nil, ErrReordered, nil},
+		},
+		{
+			name: "drop",
+
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[2]} },
+			expected: []error{nil, ErrReordered},
+		},
+		{
+			name: "sequence number changed to look new",
+
+			attack: func(r [][]byte) [][]byte {
+				forged := append([]byte{}, r[0]...)
+				binary.BigEndian.PutUint64(forged, 1)
+				return [][]byte{r[0], forged, r[1]}
+			},
+			expected: []error{nil, aesgo.ErrAuthentication, nil},
+		},
+		{
+			name: "tampered ciphertext",
+
+			attack: func(r [][]byte) [][]byte {
+				forged := append([]byte{}, r[1]...)
+				forged[headerSize] ^= 1
+				return [][]byte{r[0], forged, r[1]}
+			},
+			expected: []error{nil, aesgo.ErrAuthentication, nil},
+		},
+		{
+			name: "truncated",
+
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0][:headerSize+15], r[0]} },
+			expected: []error{ErrTruncated, nil},
+		},
+		{
+			name: "reorder with a window",
+
+			opts:     []Option{WithWindow(64)},
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[2], r[1], r[1]} },
+			expected: []error{nil, nil, nil, ErrReplay},
+		},
+		{
+			name: "drop with a window",
+
+			opts:     []Option{WithWindow(64)},
+			attack:   func(r [][]byte) [][]byte { return [][]byte{r[0], r[2], r[2]} },
+			expected: []error{nil, nil, ErrReplay},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, o := newPair(t, test.opts...)
+			delivered := test.attack(seal(t, s, 3))
+
+			for i, r := range delivered {
+				if _, err := o.Open(r); err != test.expected[i] {
+					t.Errorf("Record %d: Expected %v, got %v", i, test.expected[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceExhausted(t *testing.T) {
+	s, _ := newPair(t)
+	s.seq = math.MaxUint64 - 1
+
+	if _, err := s.Seal([]byte("last")); err != nil {
+		t.Fatalf("Error sealing: %s", err)
+	}
+	if _, err := s.Seal([]byte("one too many")); err != ErrSequenceExhausted {
+		t.Errorf("Expected %v, got %v", ErrSequenceExhausted, err)
+	}
+}
+
+func TestRecordFormat(t *testing.T) {
+	s, _ := newPair(t)
+	records := seal(t, s, 2)
+
+	if seq := binary.BigEndian.Uint64(records[1]); seq != 1 {
+		t.Errorf("Expected %v, got %v", 1, seq)
+	}
+	if l := len(records[0]); l != headerSize+len("record 0")+aesgo.GCMTagSize {
+		t.Errorf("Expected %v, got %v", headerSize+len("record 0")+aesgo.GCMTagSize, l)
+	}
+	if bytes.Contains(records[0], []byte("record")) {
+		t.Errorf("Plaintext in the record: %x", records[0])
+	}
+}
@@ -0,0 +1,91 @@
+package record
+
+import "errors"
+
+// MaxWindowSize is the largest anti-replay window, in records.
+const MaxWindowSize = 1024
+
+var (
+	ErrInvalidWindowSize = errors.New("Window size must be between 0 and 1024")
+	ErrReplay            = errors.New("Record was already received")
+	ErrTooOld            = errors.New("Record is older than the anti-replay window")
+	ErrReordered         = errors.New("Record arrived out of order")
+)
+
+// Window is the sliding anti-replay window of IPsec (RFC 4303, section 3.4.3) and DTLS: it remembers
+// the highest sequence number received and which of the size numbers below it were seen.
+//
+// With size 0 there is no window, every record must be the one right after the previous.
+type Window struct {
+	size int
+	// next is the highest sequence number accepted plus 1, 0 means nothing was received yet.
+	next uint64
+	// bit i is set when next-1-i was received
+	bitmap []uint64
+}
+
+func NewWindow(size int) (*Window, error) {
+	if size < 0 || size > MaxWindowSize {
+		return nil, ErrInvalidWindowSize
+	}
+	return &Window{size: size, bitmap: make([]uint64, (size+63)/64)}, nil
+}
+
+// Check tells if seq can be accepted without changing the window. Only call Accept after the
+// record is authenticated, otherwise forged records would move the window.
+func (w *Window) Check(seq uint64) error {
+	if seq >= w.next {
+		if w.size == 0 && seq != w.next {
+			return ErrReordered
+		}
+		return nil
+	}
+
+	if w.size == 0 {
+		return ErrReplay
+	}
+
+	diff := w.next - 1 - seq
+	if diff >= uint64(w.size) {
+		return ErrTooOld
+	}
+	if w.bitmap[diff/64]&(1<<(diff%64)) != 0 {
+		return ErrReplay
+	}
+	return nil
+}
+
+// Accept marks seq as received. It must pass Check first.
+func (w *Window) Accept(seq uint64) {
+	if seq < w.next {
+		diff := w.next - 1 - seq
+		w.bitmap[diff/64] |= 1 << (diff % 64)
+		return
+	}
+
+	w.shift(seq - w.next + 1)
+	w.next = seq + 1
+	if w.size > 0 {
+		w.bitmap[0] |= 1
+	}
+}
+
+// shift moves the bitmap n positions, dropping what falls out of the window.
+func (w *Window) shift(n uint64) {
+	if n >= uint64(w.size) {
+		clear(w.bitmap)
+		return
+	}
+
+	words, bits := int(n/64), n%64
+	for i := len(w.bitmap) - 1; i >= 0; i-- {
+		var v uint64
+		if i-words >= 0 {
+			v = w.bitmap[i-words] << bits
+			if bits > 0 && i-words-1 >= 0 {
+				v |= w.bitmap[i-words-1] >> (64 - bits)
+			}
+		}
+		w.bitmap[i] = v
+	}
+}
@@ -0,0 +1,242 @@
+// Package rijndael is the original Rijndael cipher, with blocks of 128, 192 or 256 bits.
+//
+// AES is Rijndael with the block size fixed at 128 bits. The larger blocks make the state wider
+// (Nb = 6 or 8 columns instead of 4), change the ShiftRows offsets and add rounds:
+//
+//	Nb  rounds  ShiftRows offsets
+//	4   10      0, 1, 2, 3
+//	6   12      0, 1, 2, 3
+//	8   14      0, 1, 3, 4
+//
+// The number of rounds is max(Nb, Nk) + 6, where Nk is the key length in words. The key schedule
+// generates Nb words per round, so one round key can span parts of two "AES sized" keys.
+//
+// Only use it to learn, like the rest of this project. The large block variants were never standardised.
+package rijndael
+
+import (
+	"errors"
+
+	"github.com/mario-areias/aes-go/gf256"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var (
+	ErrInvalidBlockSize = errors.New("Block size must be 16, 24 or 32 bytes")
+	ErrInvalidKeySize   = errors.New("Key size must be 16, 24 or 32 bytes")
+	ErrInvalidBlock     = errors.New("Block length doesn't match the block size")
+)
+
+// Cipher encrypts blocks of a single size. Unlike aesgo.AES it keeps no state between blocks,
+// so it is safe to use from many goroutines.
+type Cipher struct {
+	nb     int
+	rounds int
+
+	// the key schedule words, nb of them per round
+	words [][4]byte
+}
+
+// state is indexed by [row][column], only the first nb columns are used.
+type state [4][8]byte
+
+// New returns Rijndael with blockSize bytes blocks: 16, 24 or 32.
+func New(k key.Key, blockSize int) (*Cipher, error) {
+	if k.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	if !validSize(blockSize) {
+		return nil, ErrInvalidBlockSize
+	}
+	if !validSize(k.Len()) {
+		return nil, ErrInvalidKeySize
+	}
+
+	nb := blockSize / 4
+	nk := k.Len() / 4
+
+	c := &Cipher{nb: nb, rounds: max(nb, nk) + 6}
+	c.words = expandKey(k.GetBytes(), nb, c.rounds)
+
+	return c, nil
+}
+
+func validSize(s int) bool {
+	return s == 16 || s == 24 || s == 32
+}
+
+// BlockSize returns the block size in bytes.
+func (c *Cipher) BlockSize() int {
+	return c.nb * 4
+}
+
+// Rounds returns the number of rounds.
+func (c *Cipher) Rounds() int {
+	return c.rounds
+}
+
+// EncryptBlock encrypts a single block, which must have BlockSize bytes.
+func (c *Cipher) EncryptBlock(b []byte) ([]byte, error) {
+	if len(b) != c.BlockSize() {
+		return nil, ErrInvalidBlock
+	}
+
+	s := c.toState(b)
+	s = c.addRoundKey(s, 0)
+
+	for round := 1; round <= c.rounds; round++ {
+		s = c.subBytes(s, primitives.SBox())
+		s = c.shiftRows(s)
+		if round < c.rounds {
+			// mix columns don't apply to the last round
+			s = c.mixColumns(s, mix)
+		}
+		s = c.addRoundKey(s, round)
+	}
+
+	return c.fromState(s), nil
+}
+
+// DecryptBlock decrypts a single block, which must have BlockSize bytes.
+func (c *Cipher) DecryptBlock(b []byte) ([]byte, error) {
+	if len(b) != c.BlockSize() {
+		return nil, ErrInvalidBlock
+	}
+
+	s := c.toState(b)
+	s = c.addRoundKey(s, c.rounds)
+
+	// Decrypting works in reverse order
+	for round := c.rounds - 1; round >= 0; round-- {
+		s = c.invShiftRows(s)
+		s = c.subBytes(s, primitives.InvSBox())
+		s = c.addRoundKey(s, round)
+		if round > 0 {
+			s = c.mixColumns(s, invMix)
+		}
+	}
+
+	return c.fromState(s), nil
+}
+
+// expandKey is the key schedule of FIPS-197 section 5.2, generating nb words per round instead of 4.
+func expandKey(k []byte, nb, rounds int) [][4]byte {
+	nk := len(k) / 4
+	w := make([][4]byte, nb*(rounds+1))
+
+	for i := 0; i < nk; i++ {
+		w[i] = [4]byte(k[i*4 : i*4+4])
+	}
+
+	// the round constants go past the 10 AES needs, so they are computed: x^(i-1)
+	rcon := byte(1)
+
+	for i := nk; i < len(w); i++ {
+		temp := w[i-1]
+		if i%nk == 0 {
+			temp = primitives.SubWord(primitives.RotWord(temp))
+			temp[0] ^= rcon
+			rcon = gf256.Mul(rcon, 2)
+		} else if nk > 6 && i%nk == 4 {
+			temp = primitives.SubWord(temp)
+		}
+
+		for j := 0; j < 4; j++ {
+			w[i][j] = w[i-nk][j] ^ temp[j]
+		}
+	}
+
+	return w
+}
+
+func (c *Cipher) toState(b []byte) state {
+	var s state
+	for i := range b {
+		s[i%4][i/4] = b[i]
+	}
+	return s
+}
+
+func (c *Cipher) fromState(s state) []byte {
+	b := make([]byte, c.BlockSize())
+	for i := range b {
+		b[i] = s[i%4][i/4]
+	}
+	return b
+}
+
+func (c *Cipher) addRoundKey(s state, round int) state {
+	for col := 0; col < c.nb; col++ {
+		w := c.words[round*c.nb+col]
+		for row := 0; row < 4; row++ {
+			s[row][col] ^= w[row]
+		}
+	}
+	return s
+}
+
+func (c *Cipher) subBytes(s state, table [256]byte) state {
+	for row := 0; row < 4; row++ {
+		for col := 0; col < c.nb; col++ {
+			s[row][col] = table[s[row][col]]
+		}
+	}
+	return s
+}
+
+// offsets returns how many positions each row is rotated by ShiftRows.
+func (c *Cipher) offsets() [4]int {
+	if c.nb == 8 {
+		return [4]int{0, 1, 3, 4}
+	}
+	return [4]int{0, 1, 2, 3}
+}
+
+func (c *Cipher) shiftRows(s state) state {
+	var r state
+	for row, offset := range c.offsets() {
+		for col := 0; col < c.nb; col++ {
+			r[row][col] = s[row][(col+offset)%c.nb]
+		}
+	}
+	return r
+}
+
+func (c *Cipher) invShiftRows(s state) state {
+	var r state
+	for row, offset := range c.offsets() {
+		for col := 0; col < c.nb; col++ {
+			r[row][(col+offset)%c.nb] = s[row][col]
+		}
+	}
+	return r
+}
+
+// the MixColumns matrices, the same as AES. Every column is mixed the same way regardless of nb.
+var (
+	mix = [4][4]byte{
+		{0x02, 0x03, 0x01, 0x01},
+		{0x01, 0x02, 0x03, 0x01},
+		{0x01, 0x01, 0x02, 0x03},
+		{0x03, 0x01, 0x01, 0x02},
+	}
+	invMix = [4][4]byte{
+		{0x0e, 0x0b, 0x0d, 0x09},
+		{0x09, 0x0e, 0x0b, 0x0d},
+		{0x0d, 0x09, 0x0e, 0x0b},
+		{0x0b, 0x0d, 0x09, 0x0e},
+	}
+)
+
+func (c *Cipher) mixColumns(s state, m [4][4]byte) state {
+	var r state
+	for col := 0; col < c.nb; col++ {
+		for row := 0; row < 4; row++ {
+			for i := 0; i < 4; i++ {
+				r[row][col] ^= gf256.Mul(m[row][i], s[i][col])
+			}
+		}
+	}
+	return r
+}
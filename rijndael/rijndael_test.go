@@ -0,0 +1,171 @@
+package rijndael
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestAESBlockSize(t *testing.T) {
+	// FIPS-197 appendix B
+	k := key.NewKey([16]byte(decode("2b7e151628aed2a6abf7158809cf4f3c")))
+	plaintext := decode("3243f6a8885a308d313198a2e0370734")
+	expected := decode("3925841d02dc09fbdc118597196a0b32")
+
+	c, err := New(k, 16)
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+
+	got, err := c.EncryptBlock(plaintext)
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Got: %x, Expected: %x", got, expected)
+	}
+
+	// with 128 bit blocks it must be AES
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		material := make([]byte, 16)
+		block := make([]byte, 16)
+		r.Read(material)
+		r.Read(block)
+
+		c, _ := New(key.NewKey([16]byte(material)), 16)
+		got, _ := c.EncryptBlock(block)
+
+		std, _ := aes.NewCipher(material)
+		expected := make([]byte, 16)
+		std.Encrypt(expected, block)
+
+		if !bytes.Equal(got, expected) {
+			t.Fatalf("Key %x, block %x. Got: %x, Expected: %x", material, block, got, expected)
+		}
+	}
+}
+
+func TestLargeBlocks(t *testing.T) {
+	tests := []struct {
+		name string
+
+		blockSize int
+		rounds    int
+		offsets   [4]int
+	}{
+		{
+			name: "128 bits",
+
+			blockSize: 16,
+			rounds:    10,
+			offsets:   [4]int{0, 1, 2, 3},
+		},
+		{
+			name: "192 bits",
+
+			blockSize: 24,
+			rounds:    12,
+			offsets:   [4]int{0, 1, 2, 3},
+		},
+		{
+			name: "256 bits",
+
+			blockSize: 32,
+			rounds:    14,
+			offsets:   [4]int{0, 1, 3, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(key.Bit128(), tt.blockSize)
+			if err != nil {
+				t.Fatalf("Error creating cipher: %s", err)
+			}
+
+			if c.Rounds() != tt.rounds {
+				t.Errorf("Expected %d rounds, got %d", tt.rounds, c.Rounds())
+			}
+			if len(c.words) != c.nb*(tt.rounds+1) {
+				t.Errorf("Expected %d key schedule words, got %d", c.nb*(tt.rounds+1), len(c.words))
+			}
+			if c.offsets() != tt.offsets {
+				t.Errorf("Expected offsets %v, got %v", tt.offsets, c.offsets())
+			}
+
+			plaintext := bytes.Repeat([]byte{0x42}, tt.blockSize)
+			encrypted, err := c.EncryptBlock(plaintext)
+			if err != nil {
+				t.Fatalf("Error encrypting: %s", err)
+			}
+			if bytes.Equal(encrypted, plaintext) {
+				t.Errorf("Expected the block to change")
+			}
+
+			decrypted, err := c.DecryptBlock(encrypted)
+			if err != nil {
+				t.Fatalf("Error decrypting: %s", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("Got: %x, Expected: %x", decrypted, plaintext)
+			}
+		})
+	}
+}
+
+func TestShiftRows(t *testing.T) {
+	c := &Cipher{nb: 8}
+
+	var s state
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 8; col++ {
+			s[row][col] = byte(row<<4 | col)
+		}
+	}
+
+	r := c.shiftRows(s)
+
+	// row 2 moves 3 positions and row 3 moves 4 positions with 256 bit blocks
+	if r[0][0] != 0x00 || r[1][0] != 0x11 || r[2][0] != 0x23 || r[3][0] != 0x34 || r[3][7] != 0x33 {
+		t.Errorf("Unexpected state after ShiftRows %x", r)
+	}
+	if c.invShiftRows(r) != s {
+		t.Errorf("Expected InvShiftRows to undo ShiftRows")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := New(key.Bit128(), 20); err != ErrInvalidBlockSize {
+		t.Errorf("Expected %v, got %v", ErrInvalidBlockSize, err)
+	}
+
+	k := key.Bit128()
+	c, err := New(k, 24)
+	if err != nil {
+		t.Fatalf("Error creating cipher: %s", err)
+	}
+	if _, err := c.EncryptBlock(make([]byte, 16)); err != ErrInvalidBlock {
+		t.Errorf("Expected %v, got %v", ErrInvalidBlock, err)
+	}
+	if _, err := c.DecryptBlock(make([]byte, 32)); err != ErrInvalidBlock {
+		t.Errorf("Expected %v, got %v", ErrInvalidBlock, err)
+	}
+
+	k.Destroy()
+	if _, err := New(k, 24); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
+
+func decode(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
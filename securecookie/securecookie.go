@@ -0,0 +1,175 @@
+// Package securecookie encrypts and authenticates cookie values, for use in net/http handlers.
+//
+// The value is sealed with the envelope format (a fresh data key wrapped with the key encryption key),
+// and the cookie is the URL safe base64 of
+//
+//	timestamp (8 bytes) | envelope blob | HMAC-SHA256 (32 bytes)
+//
+// The HMAC covers the cookie name too, so a value can't be moved to another cookie. The timestamp
+// is when the value was encoded, cookies older than the max age are rejected even if the browser
+// still sends them.
+package securecookie
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/envelope"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// MaxLength is the longest encoded value, browsers don't keep cookies above 4096 bytes.
+const MaxLength = 4096
+
+const DefaultMaxAge = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidCookie = errors.New("Invalid cookie value")
+	ErrExpiredCookie = errors.New("Cookie has expired")
+	ErrTooLong       = errors.New("Encoded cookie is longer than 4096 bytes")
+)
+
+type SecureCookie struct {
+	kek    key.Key
+	macKey []byte
+	mode   aesgo.Mode
+	maxAge time.Duration
+	now    func() time.Time
+}
+
+type Option func(*SecureCookie)
+
+// WithMaxAge sets how long a value is valid. It is also the Max-Age of the cookies from Cookie.
+func WithMaxAge(d time.Duration) Option {
+	return func(s *SecureCookie) {
+		s.maxAge = d
+	}
+}
+
+// WithMode sets the mode of the envelope payload. Defaults to CTR, it doesn't need padding.
+func WithMode(mode aesgo.Mode) Option {
+	return func(s *SecureCookie) {
+		s.mode = mode
+	}
+}
+
+// WithClock replaces time.Now, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(s *SecureCookie) {
+		s.now = now
+	}
+}
+
+// New uses kek to wrap the data keys. The HMAC key is derived from it with HKDF.
+func New(kek key.Key, opts ...Option) (*SecureCookie, error) {
+	if kek.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+
+	macKey, err := key.HKDF(sha256.New, kek.GetBytes(), nil, []byte("aes-go securecookie mac"), 32)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SecureCookie{
+		kek:    kek,
+		macKey: macKey,
+		mode:   aesgo.CTR,
+		maxAge: DefaultMaxAge,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Encode encrypts value for the cookie called name.
+func (s *SecureCookie) Encode(name string, value []byte) (string, error) {
+	blob, err := envelope.Seal(s.kek, "", s.mode, value)
+	if err != nil {
+		return "", err
+	}
+
+	b := binary.BigEndian.AppendUint64(nil, uint64(s.now().Unix()))
+	b = append(b, blob...)
+	b = append(b, s.sign(name, b)...)
+
+	encoded := base64.RawURLEncoding.EncodeToString(b)
+	if len(encoded) > MaxLength {
+		return "", ErrTooLong
+	}
+	return encoded, nil
+}
+
+// Decode checks and decrypts a value from Encode. name must be the same used to encode it.
+func (s *SecureCookie) Decode(name, encoded string) ([]byte, error) {
+	if len(encoded) > MaxLength {
+		return nil, ErrTooLong
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(b) < 8+sha256.Size {
+		return nil, ErrInvalidCookie
+	}
+
+	signed := b[:len(b)-sha256.Size]
+	if !hmac.Equal(b[len(b)-sha256.Size:], s.sign(name, signed)) {
+		return nil, ErrInvalidCookie
+	}
+
+	timestamp := time.Unix(int64(binary.BigEndian.Uint64(signed[:8])), 0)
+	if s.maxAge > 0 && s.now().After(timestamp.Add(s.maxAge)) {
+		return nil, ErrExpiredCookie
+	}
+
+	value, err := envelope.Open(s.kek, signed[8:])
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+	return value, nil
+}
+
+// Cookie returns an HttpOnly, Secure and SameSite=Lax cookie with the encoded value, ready for http.SetCookie.
+func (s *SecureCookie) Cookie(name string, value []byte) (*http.Cookie, error) {
+	encoded, err := s.Encode(name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    encoded,
+		Path:     "/",
+		MaxAge:   int(s.maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}, nil
+}
+
+// Read decodes the cookie called name from the request. A missing cookie returns http.ErrNoCookie.
+func (s *SecureCookie) Read(r *http.Request, name string) ([]byte, error) {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Decode(name, c.Value)
+}
+
+func (s *SecureCookie) sign(name string, b []byte) []byte {
+	mac := hmac.New(sha256.New, s.macKey)
+	// the name length first, otherwise the end of the name could be the start of the timestamp
+	mac.Write(binary.AppendUvarint(nil, uint64(len(name))))
+	mac.Write([]byte(name))
+	mac.Write(b)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,207 @@
+package securecookie
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	kek := key.Bit128()
+
+	for _, mode := range []aesgo.Mode{aesgo.CBC, aesgo.CTR} {
+		s, err := New(kek, WithMode(mode))
+		if err != nil {
+			t.Fatalf("Error creating: %s", err)
+		}
+
+		encoded, err := s.Encode("session", []byte("user=42"))
+		if err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+
+		value, err := s.Decode("session", encoded)
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if string(value) != "user=42" {
+			t.Errorf("Got: %s, Expected: %s", value, "user=42")
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	kek := key.Bit128()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s, _ := New(kek, WithMaxAge(time.Hour), WithClock(func() time.Time { return now }))
+	encoded, err := s.Encode("session", []byte("user=42"))
+	if err != nil {
+		t.Fatalf("Error encoding: %s", err)
+	}
+
+	raw, _ := base64.RawURLEncoding.DecodeString(encoded)
+	change := func(f func(b []byte) []byte) string {
+		return base64.RawURLEncoding.EncodeToString(f(append([]byte{}, raw...)))
+	}
+
+	other, _ := New(key.Bit128(), WithClock(func() time.Time { return now }))
+	later, _ := New(kek, WithMaxAge(time.Hour), WithClock(func() time.Time { return now.Add(2 * time.Hour) }))
+
+	tests := []struct {
+		name string
+
+		s          *SecureCookie
+		cookieName string
+		encoded    string
+
+		expected error
+	}{
+		{
+			name: "other cookie name",
+
+			s:          s,
+			cookieName: "admin",
+			encoded:    encoded,
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "other key",
+
+			s:          other,
+			cookieName: "session",
+			encoded:    encoded,
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "tampered timestamp",
+
+			s:          s,
+			cookieName: "session",
+			encoded:    change(func(b []byte) []byte { b[7] ^= 1; return b }),
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "tampered blob",
+
+			s:          s,
+			cookieName: "session",
+			encoded:    change(func(b []byte) []byte { b[len(b)-33] ^= 1; return b }),
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "too short",
+
+			s:          s,
+			cookieName: "session",
+			encoded:    change(func(b []byte) []byte { return b[:39] }),
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "invalid base64",
+
+			s:          s,
+			cookieName: "session",
+			encoded:    "%%%%" + encoded,
+
+			expected: ErrInvalidCookie,
+		},
+		{
+			name: "expired",
+
+			s:          later,
+			cookieName: "session",
+			encoded:    encoded,
+
+			expected: ErrExpiredCookie,
+		},
+		{
+			name: "too long",
+
+			s:          s,
+			cookieName: "session",
+			encoded:    strings.Repeat("A", MaxLength+1),
+
+			expected: ErrTooLong,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := test.s.Decode(test.cookieName, test.encoded); !errors.Is(err, test.expected) {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	s, _ := New(key.Bit128())
+	if _, err := s.Encode("session", make([]byte, MaxLength)); err != ErrTooLong {
+		t.Errorf("Expected %v, got %v", ErrTooLong, err)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	s, err := New(key.Bit256(), WithMaxAge(time.Hour))
+	if err != nil {
+		t.Fatalf("Error creating: %s", err)
+	}
+
+	set := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := s.Cookie("session", []byte("user=42"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, c)
+	})
+
+	get := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, err := s.Read(r, "session")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		w.Write(value)
+	})
+
+	rec := httptest.NewRecorder()
+	set.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected 1 cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if !c.HttpOnly || !c.Secure || c.MaxAge != 3600 {
+		t.Errorf("Unexpected cookie attributes: %v", c)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(c)
+	rec = httptest.NewRecorder()
+	get.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "user=42" {
+		t.Errorf("Got: %d %s, Expected: 200 user=42", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	get.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected %d, got %d", http.StatusUnauthorized, rec.Code)
+	}
+}
@@ -0,0 +1,193 @@
+// Package siv is AES-SIV (RFC 5297), deterministic authenticated encryption:
+// https://www.rfc-editor.org/rfc/rfc5297
+//
+// Everything else in aes-go is randomized on purpose: the same plaintext encrypted twice gives two
+// unrelated ciphertexts. SIV doesn't take a nonce. The IV is synthetic, it is a CMAC of the
+// associated data and the plaintext (S2V), and it doubles as the tag. The same key, associated data
+// and plaintext always give the same ciphertext, which is what deduplication, lookups on encrypted
+// columns and key wrapping need.
+//
+// That is also the warning: deterministic encryption leaks equality. Anyone who sees two
+// ciphertexts knows if the plaintexts are the same, can count how often each one occurs, and can
+// confirm a guess of a plaintext from a small set ("yes", "no") by encrypting it, if they can get
+// things encrypted. Only use it when that is acceptable. Adding a random nonce as the last piece
+// of associated data makes it randomized again, and SIV then survives a repeated nonce by only
+// leaking equality instead of the keystream.
+//
+// The key is 32 bytes, the first half is the CMAC key and the second the CTR key (AES-SIV-CMAC-256).
+package siv
+
+import (
+	"errors"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/cmac"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/subtle"
+)
+
+// TagSize is the size of the synthetic IV at the start of the output.
+const TagSize = 16
+
+// MaxAssociatedData is the most associated data S2V can take, 126 pieces plus the plaintext.
+const MaxAssociatedData = 126
+
+var (
+	ErrInvalidKeySize        = errors.New("SIV key must be 32 bytes")
+	ErrTooManyAssociatedData = errors.New("SIV takes at most 126 pieces of associated data")
+	ErrTruncated             = errors.New("Message is shorter than the synthetic IV")
+	ErrAuthentication        = errors.New("Message authentication failed")
+)
+
+// SIV is safe for concurrent use, it builds a cipher for every message.
+type SIV struct {
+	macKey key.Key
+	ctrKey key.Key
+}
+
+func New(k key.Key) (*SIV, error) {
+	if k.Destroyed() {
+		return nil, key.ErrDestroyed
+	}
+	material := k.GetBytes()
+	if len(material) != 32 {
+		return nil, ErrInvalidKeySize
+	}
+
+	return &SIV{
+		macKey: key.NewKey([16]byte(material[:16])),
+		ctrKey: key.NewKey([16]byte(material[16:])),
+	}, nil
+}
+
+// Seal returns the synthetic IV followed by the ciphertext. The associated data is authenticated
+// but not encrypted, each piece on its own: ("a", "bc") and ("ab", "c") are different.
+func (s *SIV) Seal(plaintext []byte, associatedData ...[]byte) ([]byte, error) {
+	if len(associatedData) > MaxAssociatedData {
+		return nil, ErrTooManyAssociatedData
+	}
+
+	v, err := s.s2v(plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, TagSize+len(plaintext))
+	copy(sealed, v[:])
+	if err := s.xorKeyStream(sealed[TagSize:], plaintext, v); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// Open checks the synthetic IV and returns the plaintext. The associated data must be the same
+// pieces given to Seal.
+func (s *SIV) Open(sealed []byte, associatedData ...[]byte) ([]byte, error) {
+	if len(associatedData) > MaxAssociatedData {
+		return nil, ErrTooManyAssociatedData
+	}
+	if len(sealed) < TagSize {
+		return nil, ErrTruncated
+	}
+
+	v := [16]byte(sealed[:TagSize])
+	plaintext := make([]byte, len(sealed)-TagSize)
+	if err := s.xorKeyStream(plaintext, sealed[TagSize:], v); err != nil {
+		return nil, err
+	}
+
+	expected, err := s.s2v(plaintext, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		clear(plaintext)
+		return nil, ErrAuthentication
+	}
+	return plaintext, nil
+}
+
+func (s *SIV) Destroy() {
+	s.macKey.Destroy()
+	s.ctrKey.Destroy()
+}
+
+// s2v turns several strings into one 16 byte value with CMAC (section 2.4). Each string is
+// MACed, and the results are chained with doubling, so their order and boundaries matter.
+func (s *SIV) s2v(plaintext []byte, associatedData [][]byte) ([16]byte, error) {
+	mac, err := cmac.New(s.macKey)
+	if err != nil {
+		return [16]byte{}, err
+	}
+	sum := func(b []byte) [16]byte {
+		mac.Reset()
+		mac.Write(b)
+		return [16]byte(mac.Sum(nil))
+	}
+
+	d := sum(make([]byte, 16))
+	for _, ad := range associatedData {
+		d = xor(double(d), sum(ad))
+	}
+
+	// the plaintext is the last string, XORed into its end when it is a block or longer,
+	// otherwise padded like CMAC
+	if len(plaintext) >= 16 {
+		t := append([]byte{}, plaintext...)
+		tail := t[len(t)-16:]
+		for i := range tail {
+			tail[i] ^= d[i]
+		}
+		v := sum(t)
+		clear(t)
+		return v, nil
+	}
+
+	var padded [16]byte
+	copy(padded[:], plaintext)
+	padded[len(plaintext)] = 0x80
+	t := xor(double(d), padded)
+	v := sum(t[:])
+	clear(padded[:])
+	clear(t[:])
+	return v, nil
+}
+
+// xorKeyStream is CTR from the synthetic IV with two bits cleared, so implementations with a 32
+// or 64 bit counter give the same result (section 2.5).
+func (s *SIV) xorKeyStream(dst, src []byte, v [16]byte) error {
+	q := v
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+
+	ks, err := aesgo.NewKeystreamReader(s.ctrKey, q[:])
+	if err != nil {
+		return err
+	}
+	ks.Read(dst[:len(src)])
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+	return nil
+}
+
+// double multiplies by x in GF(2^128), like the CMAC subkeys.
+func double(b [16]byte) [16]byte {
+	var r [16]byte
+	for i := 0; i < 15; i++ {
+		r[i] = b[i]<<1 | b[i+1]>>7
+	}
+	r[15] = b[15] << 1
+
+	if b[0]&0x80 != 0 {
+		r[15] ^= 0x87
+	}
+	return r
+}
+
+func xor(a, b [16]byte) [16]byte {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+	return a
+}
@@ -0,0 +1,202 @@
+package siv
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/mario-areias/aes-go/key"
+)
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// RFC 5297 appendix A
+func TestVectors(t *testing.T) {
+	tests := []struct {
+		name           string
+		key            string
+		associatedData []string
+		plaintext      string
+
+		expected string
+	}{
+		{
+			name:           "A.1 deterministic",
+			key:            "fffefdfc fbfaf9f8 f7f6f5f4 f3f2f1f0 f0f1f2f3 f4f5f6f7 f8f9fafb fcfdfeff",
+			associatedData: []string{"10111213 14151617 18191a1b 1c1d1e1f 20212223 24252627"},
+			plaintext:      "11223344 55667788 99aabbcc ddee",
+
+			expected: "85632d07 c6e8f37f 950acd32 0a2ecc93 40c02b96 90c4dc04 daef7f6a fe5c",
+		},
+		{
+			name: "A.2 nonce based",
+			key:  "7f7e7d7c 7b7a7978 77767574 73727170 40414243 44454647 48494a4b 4c4d4e4f",
+			associatedData: []string{
+				"00112233 44556677 8899aabb ccddeeff deaddada deaddada ffeeddcc bbaa9988 77665544 33221100",
+				"10203040 50607080 90a0",
+				// the nonce is the last piece of associated data
+				"09f91102 9d74e35b d84156c5 635688c0",
+			},
+			plaintext: "74686973 20697320 736f6d65 20706c61 696e7465 78742074 6f20656e 63727970 74207573 696e6720 5349562d 414553",
+
+			expected: "7bdb6e3b 432667eb 06f4d14b ff2fbd0f cb900f2f ddbe4043 26601965 c889bf17 dba77ceb 094fa663 b7a3f748 ba8af829 ea64ad54 4a272e9c 485b62a3 fd5c0d",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := New(key.NewKey256([32]byte(decodeHex(test.key))))
+			if err != nil {
+				t.Fatalf("Error creating SIV: %s", err)
+			}
+
+			var associatedData [][]byte
+			for _, ad := range test.associatedData {
+				associatedData = append(associatedData, decodeHex(ad))
+			}
+			plaintext := decodeHex(test.plaintext)
+			expected := decodeHex(test.expected)
+
+			sealed, err := s.Seal(plaintext, associatedData...)
+			if err != nil {
+				t.Fatalf("Error sealing: %s", err)
+			}
+			if !bytes.Equal(sealed, expected) {
+				t.Errorf("Got: %x, Expected: %x", sealed, expected)
+			}
+
+			opened, err := s.Open(sealed, associatedData...)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Errorf("Got: %x, Expected: %x (%v)", opened, plaintext, err)
+			}
+		})
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	s, _ := New(key.Bit256())
+
+	for _, size := range []int{0, 1, 15, 16, 17, 100} {
+		plaintext := bytes.Repeat([]byte{'a'}, size)
+
+		first, _ := s.Seal(plaintext, []byte("header"))
+		second, _ := s.Seal(plaintext, []byte("header"))
+		if !bytes.Equal(first, second) {
+			t.Errorf("Size %d: Expected the same ciphertext, got %x and %x", size, first, second)
+		}
+		if len(first) != TagSize+size {
+			t.Errorf("Size %d: Expected %d bytes, got %d", size, TagSize+size, len(first))
+		}
+
+		// the associated data changes everything
+		other, _ := s.Seal(plaintext, []byte("other"))
+		if bytes.Equal(first[:TagSize], other[:TagSize]) {
+			t.Errorf("Size %d: Expected a different IV for other associated data", size)
+		}
+
+		opened, err := s.Open(first, []byte("header"))
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Errorf("Size %d: Got: %x, Expected: %x (%v)", size, opened, plaintext, err)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	s, _ := New(key.Bit256())
+	sealed, _ := s.Seal([]byte("Let's test if this is working!"), []byte("a"), []byte("bc"))
+
+	tamper := func(i int) []byte {
+		b := append([]byte{}, sealed...)
+		b[i] ^= 1
+		return b
+	}
+
+	tests := []struct {
+		name           string
+		sealed         []byte
+		associatedData [][]byte
+
+		expected error
+	}{
+		{
+			name:           "tampered iv",
+			sealed:         tamper(0),
+			associatedData: [][]byte{[]byte("a"), []byte("bc")},
+
+			expected: ErrAuthentication,
+		},
+		{
+			name:           "tampered ciphertext",
+			sealed:         tamper(len(sealed) - 1),
+			associatedData: [][]byte{[]byte("a"), []byte("bc")},
+
+			expected: ErrAuthentication,
+		},
+		{
+			name:           "pieces split differently",
+			sealed:         sealed,
+			associatedData: [][]byte{[]byte("ab"), []byte("c")},
+
+			expected: ErrAuthentication,
+		},
+		{
+			name:           "missing associated data",
+			sealed:         sealed,
+			associatedData: [][]byte{[]byte("a")},
+
+			expected: ErrAuthentication,
+		},
+		{
+			name:   "truncated",
+			sealed: sealed[:TagSize-1],
+
+			expected: ErrTruncated,
+		},
+		{
+			name:           "too much associated data",
+			sealed:         sealed,
+			associatedData: make([][]byte, MaxAssociatedData+1),
+
+			expected: ErrTooManyAssociatedData,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := s.Open(test.sealed, test.associatedData...); err != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	if _, err := New(key.Bit128()); err != ErrInvalidKeySize {
+		t.Errorf("Expected %v, got %v", ErrInvalidKeySize, err)
+	}
+
+	k := key.Bit256()
+	s, _ := New(k)
+	sealed, _ := s.Seal([]byte("message"))
+
+	// SIV has its own copy of the key
+	k.Destroy()
+	if _, err := s.Open(sealed); err != nil {
+		t.Errorf("Expected nil, got %v", err)
+	}
+
+	s.Destroy()
+	if _, err := s.Seal([]byte("message")); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+	if _, err := New(k); err != key.ErrDestroyed {
+		t.Errorf("Expected %v, got %v", key.ErrDestroyed, err)
+	}
+}
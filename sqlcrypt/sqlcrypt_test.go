@@ -0,0 +1,102 @@
+package sqlcrypt
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+	"github.com/mario-areias/aes-go/keyring"
+)
+
+func newKeyring(t *testing.T) *keyring.Keyring {
+	r := keyring.New()
+	if err := r.Rotate("v1", key.Bit128()); err != nil {
+		t.Fatalf("Error rotating: %s", err)
+	}
+	SetKeyring(r)
+	t.Cleanup(func() { SetKeyring(nil) })
+	return r
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := newKeyring(t)
+
+	v, err := EncryptedString("alice@example.com").Value()
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if !driver.IsValue(v) {
+		t.Fatalf("Expected a driver value, got %T", v)
+	}
+	if bytes.Contains(v.([]byte), []byte("alice")) {
+		t.Errorf("Expected the value to be encrypted, got %q", v)
+	}
+
+	// rows written before a rotation are still read
+	r.Rotate("v2", key.Bit128())
+
+	var s EncryptedString
+	if err := s.Scan(v); err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if s != "alice@example.com" {
+		t.Errorf("Got: %s, Expected: %s", s, "alice@example.com")
+	}
+
+	b, _ := EncryptedBytes{1, 2, 3}.Value()
+	c, _ := aesgo.Unmarshal(b.([]byte))
+	if c.KeyID != "v2" || c.Mode != aesgo.GCM {
+		t.Errorf("Expected GCM with v2, got mode %d with %s", c.Mode, c.KeyID)
+	}
+
+	// drivers may return text
+	var decrypted EncryptedBytes
+	if err := decrypted.Scan(string(b.([]byte))); err != nil || !bytes.Equal(decrypted, []byte{1, 2, 3}) {
+		t.Errorf("Got: %x, Expected: %x (%v)", decrypted, []byte{1, 2, 3}, err)
+	}
+}
+
+func TestNull(t *testing.T) {
+	newKeyring(t)
+
+	s := EncryptedString("leftover")
+	if err := s.Scan(nil); err != nil || s != "" {
+		t.Errorf("Expected an empty string, got %q (%v)", s, err)
+	}
+
+	var n sql.Null[EncryptedString]
+	if err := n.Scan(nil); err != nil || n.Valid {
+		t.Errorf("Expected NULL, got %v (%v)", n, err)
+	}
+
+	v, _ := EncryptedString("set").Value()
+	if err := n.Scan(v); err != nil || !n.Valid || n.V != "set" {
+		t.Errorf("Expected set, got %v (%v)", n, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, err := EncryptedString("x").Value(); err != ErrNoKeyring {
+		t.Errorf("Expected %v, got %v", ErrNoKeyring, err)
+	}
+
+	newKeyring(t)
+
+	var s EncryptedString
+	if err := s.Scan(42); err == nil {
+		t.Errorf("Expected error for an int, got nil")
+	}
+	if err := s.Scan([]byte("not an envelope")); err != aesgo.ErrInvalidCiphertext {
+		t.Errorf("Expected %v, got %v", aesgo.ErrInvalidCiphertext, err)
+	}
+
+	v, _ := EncryptedString("x").Value()
+	tampered := append([]byte{}, v.([]byte)...)
+	tampered[len(tampered)-1] ^= 1
+	if err := s.Scan(tampered); err != aesgo.ErrAuthentication {
+		t.Errorf("Expected %v, got %v", aesgo.ErrAuthentication, err)
+	}
+}
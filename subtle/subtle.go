@@ -0,0 +1,75 @@
+// Package subtle has the constant-time helpers used to check tags and padding, the same idea as
+// crypto/subtle.
+//
+// "Constant time" means the time taken depends only on the lengths, never on the secret values.
+// bytes.Equal returns at the first difference, so measuring how long the comparison of a forged
+// tag takes tells an attacker how many of its first bytes are right, and the tag can be guessed
+// one byte at a time. The functions here go through every byte and build the answer with bit
+// operations instead of branches.
+//
+// Go doesn't promise the compiler keeps it that way, this is best effort like crypto/subtle.
+package subtle
+
+// step is called on every iteration of the loops, so tests can check nothing returns early.
+var step func()
+
+// ConstantTimeCompare returns 1 if x and y are equal and 0 otherwise. The lengths aren't secret,
+// different lengths return 0 right away.
+func ConstantTimeCompare(x, y []byte) int {
+	if len(x) != len(y) {
+		return 0
+	}
+
+	var v byte
+	for i := range x {
+		if step != nil {
+			step()
+		}
+		v |= x[i] ^ y[i]
+	}
+
+	return ConstantTimeByteEq(v, 0)
+}
+
+// Equal is ConstantTimeCompare as a bool.
+func Equal(x, y []byte) bool {
+	return ConstantTimeCompare(x, y) == 1
+}
+
+// ConstantTimeByteEq returns 1 if x == y and 0 otherwise.
+func ConstantTimeByteEq(x, y uint8) int {
+	// x^y is 0 only when they are equal, and 0 - 1 is the only value with the top bit set
+	return int((uint32(x^y) - 1) >> 31)
+}
+
+// ConstantTimeEq returns 1 if x == y and 0 otherwise.
+func ConstantTimeEq(x, y int32) int {
+	return int((uint64(uint32(x^y)) - 1) >> 63)
+}
+
+// ConstantTimeSelect returns x if v is 1 and y if v is 0. Any other v is undefined.
+func ConstantTimeSelect(v, x, y int) int {
+	return ^(v-1)&x | (v-1)&y
+}
+
+// ConstantTimeLessOrEq returns 1 if x <= y and 0 otherwise. x and y must be between -2^62 and 2^62.
+func ConstantTimeLessOrEq(x, y int) int {
+	// x - y - 1 is negative exactly when x <= y
+	return int(uint64(int64(x)-int64(y)-1) >> 63)
+}
+
+// ConstantTimeCopy copies src into dst if v is 1 and leaves dst alone if v is 0. dst and src
+// must have the same length, it panics otherwise.
+func ConstantTimeCopy(v int, dst, src []byte) {
+	if len(dst) != len(src) {
+		panic("subtle: slices have different lengths")
+	}
+
+	mask := byte(-v)
+	for i := range dst {
+		if step != nil {
+			step()
+		}
+		dst[i] = dst[i]&^mask | src[i]&mask
+	}
+}
@@ -0,0 +1,135 @@
+package subtle
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"math"
+	"testing"
+)
+
+func TestConstantTimeCompare(t *testing.T) {
+	tests := []struct {
+		name string
+
+		x, y []byte
+
+		expected int
+	}{
+		{name: "equal", x: []byte("same tag"), y: []byte("same tag"), expected: 1},
+		{name: "first byte", x: []byte("same tag"), y: []byte("Same tag"), expected: 0},
+		{name: "last byte", x: []byte("same tag"), y: []byte("same taG"), expected: 0},
+		{name: "different length", x: []byte("same tag"), y: []byte("same ta"), expected: 0},
+		{name: "empty", x: []byte{}, y: nil, expected: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ConstantTimeCompare(test.x, test.y); got != test.expected {
+				t.Errorf("Expected %v, got %v", test.expected, got)
+			}
+			if got := Equal(test.x, test.y); got != (test.expected == 1) {
+				t.Errorf("Expected %v, got %v", test.expected == 1, got)
+			}
+		})
+	}
+}
+
+// Wherever the difference is, every byte is looked at.
+func TestNoEarlyExit(t *testing.T) {
+	steps := 0
+	step = func() { steps++ }
+	defer func() { step = nil }()
+
+	tag := bytes.Repeat([]byte{0xaa}, 32)
+
+	for i := -1; i < len(tag); i++ {
+		forged := append([]byte{}, tag...)
+		if i >= 0 {
+			forged[i] ^= 0xff
+		}
+
+		steps = 0
+		ConstantTimeCompare(tag, forged)
+		if steps != len(tag) {
+			t.Errorf("Difference at %d: Expected %v steps, got %v", i, len(tag), steps)
+		}
+
+		for _, v := range []int{0, 1} {
+			steps = 0
+			ConstantTimeCopy(v, append([]byte{}, tag...), forged)
+			if steps != len(tag) {
+				t.Errorf("Copy %d: Expected %v steps, got %v", v, len(tag), steps)
+			}
+		}
+	}
+}
+
+// Every combination of two bytes, checked against crypto/subtle.
+func TestConstantTimeByteEq(t *testing.T) {
+	for x := 0; x < 256; x++ {
+		for y := 0; y < 256; y++ {
+			if got, expected := ConstantTimeByteEq(uint8(x), uint8(y)), subtle.ConstantTimeByteEq(uint8(x), uint8(y)); got != expected {
+				t.Fatalf("%d %d: Expected %v, got %v", x, y, expected, got)
+			}
+		}
+	}
+}
+
+func TestConstantTimeEq(t *testing.T) {
+	values := []int32{0, 1, -1, 2, 1 << 30, -1 << 31, 1<<31 - 1}
+
+	for _, x := range values {
+		for _, y := range values {
+			expected := 0
+			if x == y {
+				expected = 1
+			}
+			if got := ConstantTimeEq(x, y); got != expected {
+				t.Errorf("%d %d: Expected %v, got %v", x, y, expected, got)
+			}
+		}
+	}
+}
+
+func TestConstantTimeLessOrEq(t *testing.T) {
+	// math.MaxInt >> 1 is past 32 bits where int has 64
+	values := []int{0, 1, -1, 15, 16, 17, 255, -16, math.MaxInt32, math.MaxInt >> 1}
+
+	for _, x := range values {
+		for _, y := range values {
+			expected := 0
+			if x <= y {
+				expected = 1
+			}
+			if got := ConstantTimeLessOrEq(x, y); got != expected {
+				t.Errorf("%d <= %d: Expected %v, got %v", x, y, expected, got)
+			}
+		}
+	}
+}
+
+func TestConstantTimeSelectAndCopy(t *testing.T) {
+	if got := ConstantTimeSelect(1, 42, 7); got != 42 {
+		t.Errorf("Expected %v, got %v", 42, got)
+	}
+	if got := ConstantTimeSelect(0, 42, 7); got != 7 {
+		t.Errorf("Expected %v, got %v", 7, got)
+	}
+
+	dst := []byte("original")
+	ConstantTimeCopy(0, dst, []byte("replaced"))
+	if string(dst) != "original" {
+		t.Errorf("Got: %s, Expected: %s", dst, "original")
+	}
+	ConstantTimeCopy(1, dst, []byte("replaced"))
+	if string(dst) != "replaced" {
+		t.Errorf("Got: %s, Expected: %s", dst, "replaced")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic for different lengths")
+		}
+	}()
+	ConstantTimeCopy(1, dst, []byte("short"))
+}
@@ -0,0 +1,31 @@
+//go:build !tinygo
+
+package tiny
+
+import "testing"
+
+// TinyGo's testing package doesn't count allocations, gc's escape analysis is checked instead.
+func TestNoAllocations(t *testing.T) {
+	var k [16]byte
+	var in, out [16]byte
+
+	allocs := testing.AllocsPerRun(100, func() {
+		var c Cipher
+		c.Init128(&k)
+		c.EncryptBlock(&out, &in)
+		c.DecryptBlock(&in, &out)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected 0 allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkEncryptBlock(b *testing.B) {
+	var c Cipher
+	c.Init128(&[16]byte{})
+	var block [16]byte
+	b.SetBytes(16)
+	for i := 0; i < b.N; i++ {
+		c.EncryptBlock(&block, &block)
+	}
+}
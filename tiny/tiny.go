@@ -0,0 +1,156 @@
+// Package tiny is block encryption for microcontrollers, it builds with TinyGo. Nothing is
+// allocated on the heap: the cipher is a fixed size struct, the key schedule fills an array and
+// the blocks are passed as pointers to arrays. A Cipher can live in a global variable:
+//
+//	var c tiny.Cipher
+//
+//	func init() {
+//		c.Init128(&secret)
+//	}
+//
+//	c.EncryptBlock(&out, &in)
+//
+// There are no modes, no padding and no options, only the block cipher. It runs the same word
+// oriented rounds as aesgo and isn't constant time either.
+package tiny
+
+import (
+	"math/bits"
+
+	"github.com/mario-areias/aes-go/primitives"
+)
+
+var sbox, invSBox = primitives.SBox(), primitives.InvSBox()
+
+// Cipher is AES-128 or AES-256, depending on how it was initialised. The zero value has no key.
+type Cipher struct {
+	// 4 words per round key, AES-256 has 15 round keys
+	words  [60]uint32
+	rounds int
+}
+
+// Init128 expands a 128 bit key.
+func (c *Cipher) Init128(k *[16]byte) {
+	c.expand(k[:], 10)
+}
+
+// Init256 expands a 256 bit key.
+func (c *Cipher) Init256(k *[32]byte) {
+	c.expand(k[:], 14)
+}
+
+// Reset clears the round keys.
+func (c *Cipher) Reset() {
+	c.words = [60]uint32{}
+	c.rounds = 0
+}
+
+func (c *Cipher) expand(k []byte, rounds int) {
+	c.rounds = rounds
+	nk := len(k) / 4
+	n := 4 * (rounds + 1)
+	w := &c.words
+
+	for i := 0; i < nk; i++ {
+		w[i] = load(k[4*i:])
+	}
+	for i := nk; i < n; i++ {
+		t := w[i-1]
+		switch {
+		case i%nk == 0:
+			t = subWord(bits.RotateLeft32(t, 8)) ^ uint32(primitives.Rcon(i / nk)[0])<<24
+		case nk > 6 && i%nk == 4:
+			t = subWord(t)
+		}
+		w[i] = w[i-nk] ^ t
+	}
+}
+
+// EncryptBlock encrypts src into dst, they can be the same block.
+func (c *Cipher) EncryptBlock(dst, src *[16]byte) {
+	w := &c.words
+
+	s0, s1, s2, s3 := load(src[0:])^w[0], load(src[4:])^w[1], load(src[8:])^w[2], load(src[12:])^w[3]
+
+	for r := 1; r <= c.rounds; r++ {
+		t0 := subShift(s0, s1, s2, s3, &sbox)
+		t1 := subShift(s1, s2, s3, s0, &sbox)
+		t2 := subShift(s2, s3, s0, s1, &sbox)
+		t3 := subShift(s3, s0, s1, s2, &sbox)
+
+		if r < c.rounds {
+			t0, t1, t2, t3 = mixColumn(t0), mixColumn(t1), mixColumn(t2), mixColumn(t3)
+		}
+
+		s0, s1, s2, s3 = t0^w[4*r], t1^w[4*r+1], t2^w[4*r+2], t3^w[4*r+3]
+	}
+
+	store(dst[0:], s0)
+	store(dst[4:], s1)
+	store(dst[8:], s2)
+	store(dst[12:], s3)
+}
+
+// DecryptBlock decrypts src into dst, they can be the same block.
+func (c *Cipher) DecryptBlock(dst, src *[16]byte) {
+	w := &c.words
+	last := 4 * c.rounds
+
+	s0, s1, s2, s3 := load(src[0:])^w[last], load(src[4:])^w[last+1], load(src[8:])^w[last+2], load(src[12:])^w[last+3]
+
+	for r := c.rounds - 1; r >= 0; r-- {
+		t0 := subShift(s0, s3, s2, s1, &invSBox) ^ w[4*r]
+		t1 := subShift(s1, s0, s3, s2, &invSBox) ^ w[4*r+1]
+		t2 := subShift(s2, s1, s0, s3, &invSBox) ^ w[4*r+2]
+		t3 := subShift(s3, s2, s1, s0, &invSBox) ^ w[4*r+3]
+
+		if r > 0 {
+			t0, t1, t2, t3 = invMixColumn(t0), invMixColumn(t1), invMixColumn(t2), invMixColumn(t3)
+		}
+
+		s0, s1, s2, s3 = t0, t1, t2, t3
+	}
+
+	store(dst[0:], s0)
+	store(dst[4:], s1)
+	store(dst[8:], s2)
+	store(dst[12:], s3)
+}
+
+// load and store are big endian, encoding/binary would bring reflect into the binary.
+func load(b []byte) uint32 {
+	_ = b[3]
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+}
+
+func store(b []byte, v uint32) {
+	_ = b[3]
+	b[0], b[1], b[2], b[3] = byte(v>>24), byte(v>>16), byte(v>>8), byte(v)
+}
+
+func subWord(w uint32) uint32 {
+	return subShift(w, w, w, w, &sbox)
+}
+
+// The rest is aesgo's words.go, see there for how they work.
+
+func subShift(c0, c1, c2, c3 uint32, table *[256]byte) uint32 {
+	return uint32(table[c0>>24])<<24 |
+		uint32(table[c1>>16&0xff])<<16 |
+		uint32(table[c2>>8&0xff])<<8 |
+		uint32(table[c3&0xff])
+}
+
+func xtime(w uint32) uint32 {
+	return (w&0x7f7f7f7f)<<1 ^ (w>>7&0x01010101)*0x1b
+}
+
+func mixColumn(w uint32) uint32 {
+	x := xtime(w)
+	return x ^ bits.RotateLeft32(x^w, 8) ^ bits.RotateLeft32(w, 16) ^ bits.RotateLeft32(w, 24)
+}
+
+func invMixColumn(w uint32) uint32 {
+	t := xtime(xtime(w))
+	return mixColumn(w ^ t ^ bits.RotateLeft32(t, 16))
+}
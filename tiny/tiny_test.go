@@ -0,0 +1,96 @@
+package tiny
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestFIPSVectors(t *testing.T) {
+	// FIPS-197 Appendix C
+	tests := []struct {
+		name string
+
+		key        string
+		plaintext  string
+		ciphertext string
+	}{
+		{
+			name: "AES-128",
+
+			key:        "000102030405060708090a0b0c0d0e0f",
+			plaintext:  "00112233445566778899aabbccddeeff",
+			ciphertext: "69c4e0d86a7b0430d8cdb78070b4c55a",
+		},
+		{
+			name: "AES-256",
+
+			key:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			plaintext:  "00112233445566778899aabbccddeeff",
+			ciphertext: "8ea2b7ca516745bfeafc49904b496089",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k, _ := hex.DecodeString(test.key)
+			var c Cipher
+			if len(k) == 16 {
+				c.Init128((*[16]byte)(k))
+			} else {
+				c.Init256((*[32]byte)(k))
+			}
+
+			var in, out, back [16]byte
+			hex.Decode(in[:], []byte(test.plaintext))
+
+			c.EncryptBlock(&out, &in)
+			if got := hex.EncodeToString(out[:]); got != test.ciphertext {
+				t.Errorf("Got: %s, Expected: %s", got, test.ciphertext)
+			}
+
+			c.DecryptBlock(&back, &out)
+			if back != in {
+				t.Errorf("Got: %x, Expected: %x", back, in)
+			}
+		})
+	}
+}
+
+func TestMatchesStdlib(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 100; i++ {
+		var k [32]byte
+		r.Read(k[:])
+		var c Cipher
+		c.Init256(&k)
+		std, _ := aes.NewCipher(k[:])
+
+		var b, expected [16]byte
+		r.Read(b[:])
+		std.Encrypt(expected[:], b[:])
+
+		// in place
+		got := b
+		c.EncryptBlock(&got, &got)
+		if got != expected {
+			t.Fatalf("Got: %x, Expected: %x", got, expected)
+		}
+		c.DecryptBlock(&got, &got)
+		if got != b {
+			t.Fatalf("Got: %x, Expected: %x", got, b)
+		}
+	}
+}
+
+func TestReset(t *testing.T) {
+	var c Cipher
+	c.Init128(&[16]byte{1})
+	c.Reset()
+
+	if c != (Cipher{}) {
+		t.Errorf("Expected the round keys to be cleared")
+	}
+}
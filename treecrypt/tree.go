@@ -0,0 +1,169 @@
+package treecrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidDocument = errors.New("Document must be a JSON object")
+
+// node is a JSON value that keeps the order of the keys, so the output diffs against the input.
+// Objects have keys and values, arrays only values, and leaves a string, json.Number, bool or nil.
+type node struct {
+	kind   byte // '{', '[' or 0 for a leaf
+	keys   []string
+	values []*node
+	leaf   any
+}
+
+func parse(b []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	n, err := parseValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrInvalidDocument
+	}
+	if n.kind != '{' {
+		return nil, ErrInvalidDocument
+	}
+	return n, nil
+}
+
+func parseValue(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return &node{leaf: tok}, nil
+	}
+
+	n := &node{kind: byte(d)}
+	for dec.More() {
+		if n.kind == '{' {
+			k, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, k.(string))
+		}
+
+		v, err := parseValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, v)
+	}
+
+	// the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// get returns the value of key in an object, or nil.
+func (n *node) get(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+func (n *node) remove(key string) {
+	for i, k := range n.keys {
+		if k == key {
+			n.keys = append(n.keys[:i], n.keys[i+1:]...)
+			n.values = append(n.values[:i], n.values[i+1:]...)
+			return
+		}
+	}
+}
+
+// walk calls fn for every leaf in document order with the keys and indexes leading to it.
+func (n *node) walk(path []string, fn func(path []string, leaf *node) error) error {
+	if n.kind == 0 {
+		return fn(path, n)
+	}
+
+	for i, v := range n.values {
+		elem := strconv.Itoa(i)
+		if n.kind == '{' {
+			elem = n.keys[i]
+		}
+		if err := v.walk(append(path[:len(path):len(path)], elem), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshal indents with two spaces like json.MarshalIndent.
+func (n *node) marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := n.write(&buf, 0); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func (n *node) write(buf *bytes.Buffer, depth int) error {
+	if n.kind == 0 {
+		return writeLeaf(buf, n.leaf)
+	}
+
+	open, end := byte('{'), byte('}')
+	if n.kind == '[' {
+		open, end = '[', ']'
+	}
+
+	buf.WriteByte(open)
+	if len(n.values) == 0 {
+		buf.WriteByte(end)
+		return nil
+	}
+
+	indent := strings.Repeat("  ", depth+1)
+	for i, v := range n.values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString("\n" + indent)
+		if n.kind == '{' {
+			if err := writeLeaf(buf, n.keys[i]); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+		}
+		if err := v.write(buf, depth+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("\n" + strings.Repeat("  ", depth))
+	buf.WriteByte(end)
+	return nil
+}
+
+func writeLeaf(buf *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Encode adds a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
@@ -0,0 +1,376 @@
+// Package treecrypt encrypts the values of a JSON document and leaves its keys readable, like
+// SOPS does. A config file can be committed encrypted and a diff still shows which settings
+// changed:
+//
+//	{
+//	  "database": {
+//	    "user": "ENC[AES256_GCM,data:b2Rv,iv:...,tag:...,type:str]",
+//	    "port": "ENC[AES256_GCM,data:NTQz,iv:...,tag:...,type:num]"
+//	  },
+//	  "aesgo": {
+//	    "version": 1,
+//	    "kek_id": "ops",
+//	    "wrapped_key": "...",
+//	    "mac": "..."
+//	  }
+//	}
+//
+// Every file has its own 256 bit data key, wrapped with a key encryption key (see the envelope
+// package) under "aesgo". Every value is sealed with GCM and a random nonce, and its path ("database:port:")
+// as additional data, so a value can't be moved to another key. Strings, numbers and booleans are
+// encrypted, null is left alone.
+//
+// A value can also be removed, or copied from an older version of the file, without breaking its
+// own tag. The MAC is an HMAC-SHA256 over every path and plaintext value in order, keyed from the
+// data key, and catches both.
+//
+// Only JSON is supported, YAML would need a parser this module doesn't have.
+package treecrypt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/envelope"
+	"github.com/mario-areias/aes-go/hmac"
+	"github.com/mario-areias/aes-go/key"
+)
+
+// MetadataKey is the top level key holding the wrapped data key and the MAC.
+const MetadataKey = "aesgo"
+
+const version = 1
+
+var (
+	ErrAlreadyEncrypted = errors.New("Document already has an aesgo key")
+	ErrNotEncrypted     = errors.New("Document has no aesgo metadata")
+	ErrInvalidValue     = errors.New("Invalid encrypted value")
+	ErrMACMismatch      = errors.New("MAC doesn't match, values were removed, added or replaced")
+)
+
+type metadata struct {
+	Version           int    `json:"version"`
+	KEKID             string `json:"kek_id,omitempty"`
+	WrappedKey        string `json:"wrapped_key"`
+	MAC               string `json:"mac"`
+	UnencryptedSuffix string `json:"unencrypted_suffix,omitempty"`
+}
+
+type Option func(*options)
+
+type options struct {
+	suffix string
+	rand   io.Reader
+}
+
+// WithUnencryptedSuffix leaves the values of keys ending with suffix, and everything under them,
+// in plaintext. They are still covered by the MAC.
+func WithUnencryptedSuffix(suffix string) Option {
+	return func(o *options) {
+		o.suffix = suffix
+	}
+}
+
+// WithRandReader sets where the data key and the nonces come from. Defaults to crypto/rand.
+func WithRandReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = r
+	}
+}
+
+// Encrypt encrypts every value of the JSON object doc with a new data key wrapped by kek.
+func Encrypt(kek key.Key, kekID string, doc []byte, opts ...Option) ([]byte, error) {
+	o := options{rand: rand.Reader}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tree, err := parse(doc)
+	if err != nil {
+		return nil, err
+	}
+	if tree.get(MetadataKey) != nil {
+		return nil, ErrAlreadyEncrypted
+	}
+
+	dek, err := key.Random(32, key.WithRandReader(o.rand))
+	if err != nil {
+		return nil, err
+	}
+	defer dek.Destroy()
+
+	wrapped, err := envelope.Wrap(kek, dek.GetBytes())
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := newMAC(dek, o.suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tree.walk(nil, func(path []string, leaf *node) error {
+		plaintext, typ, ok := encodeLeaf(leaf.leaf)
+		writeMAC(mac, path, typ, plaintext)
+		if !ok || unencrypted(path, o.suffix) {
+			return nil
+		}
+
+		nonce := make([]byte, aesgo.GCMNonceSize)
+		if _, err := io.ReadFull(o.rand, nonce); err != nil {
+			return err
+		}
+		sealed, err := a.SealGCM(nonce, plaintext, additionalData(path))
+		if err != nil {
+			return err
+		}
+
+		leaf.leaf = formatValue(sealed, nonce, typ)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta := metadata{
+		Version:           version,
+		KEKID:             kekID,
+		WrappedKey:        base64.StdEncoding.EncodeToString(wrapped),
+		MAC:               hex.EncodeToString(mac.Sum(nil)),
+		UnencryptedSuffix: o.suffix,
+	}
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return nil, err
+	}
+	metaNode, err := parse(b)
+	if err != nil {
+		return nil, err
+	}
+	tree.keys = append(tree.keys, MetadataKey)
+	tree.values = append(tree.values, metaNode)
+
+	return tree.marshal()
+}
+
+// Decrypt decrypts every value of a document from Encrypt and checks the MAC. The KEK ID is in
+// the metadata, see KEKID.
+func Decrypt(kek key.Key, doc []byte) ([]byte, error) {
+	tree, meta, err := parseEncrypted(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(meta.WrappedKey)
+	if err != nil {
+		return nil, ErrNotEncrypted
+	}
+	material, err := envelope.Unwrap(kek, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(material) != 32 {
+		return nil, ErrNotEncrypted
+	}
+	dek := key.NewKey256([32]byte(material))
+	defer dek.Destroy()
+	clear(material)
+
+	a, err := aesgo.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := newMAC(dek, meta.UnencryptedSuffix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tree.walk(nil, func(path []string, leaf *node) error {
+		s, ok := leaf.leaf.(string)
+		if ok && strings.HasPrefix(s, "ENC[") && !unencrypted(path, meta.UnencryptedSuffix) {
+			sealed, nonce, typ, err := parseEncryptedValue(s)
+			if err != nil {
+				return fmt.Errorf("%w at %s", err, strings.Join(path, ":"))
+			}
+			plaintext, err := a.OpenGCM(nonce, sealed, additionalData(path))
+			if err != nil {
+				return fmt.Errorf("%w at %s", err, strings.Join(path, ":"))
+			}
+			if leaf.leaf, err = decodeLeaf(plaintext, typ); err != nil {
+				return err
+			}
+		}
+
+		plaintext, typ, _ := encodeLeaf(leaf.leaf)
+		writeMAC(mac, path, typ, plaintext)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expected, _ := hex.DecodeString(meta.MAC)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return nil, ErrMACMismatch
+	}
+
+	return tree.marshal()
+}
+
+// KEKID returns the ID of the key encryption key of an encrypted document, to pick it.
+func KEKID(doc []byte) (string, error) {
+	_, meta, err := parseEncrypted(doc)
+	if err != nil {
+		return "", err
+	}
+	return meta.KEKID, nil
+}
+
+func parseEncrypted(doc []byte) (*node, metadata, error) {
+	var meta metadata
+
+	tree, err := parse(doc)
+	if err != nil {
+		return nil, meta, err
+	}
+	m := tree.get(MetadataKey)
+	if m == nil {
+		return nil, meta, ErrNotEncrypted
+	}
+	tree.remove(MetadataKey)
+
+	b, err := m.marshal()
+	if err != nil {
+		return nil, meta, err
+	}
+	if err := json.Unmarshal(b, &meta); err != nil || meta.Version != version {
+		return nil, meta, ErrNotEncrypted
+	}
+	return tree, meta, nil
+}
+
+// encodeLeaf returns the bytes encrypted for a value and its type. ok is false for null.
+func encodeLeaf(v any) ([]byte, string, bool) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), "str", true
+	case json.Number:
+		return []byte(v), "num", true
+	case bool:
+		if v {
+			return []byte("true"), "bool", true
+		}
+		return []byte("false"), "bool", true
+	}
+	return nil, "null", false
+}
+
+func decodeLeaf(b []byte, typ string) (any, error) {
+	switch typ {
+	case "str":
+		return string(b), nil
+	case "num":
+		var n json.Number
+		if err := json.Unmarshal(b, &n); err != nil {
+			return nil, ErrInvalidValue
+		}
+		return n, nil
+	case "bool":
+		return string(b) == "true", nil
+	}
+	return nil, ErrInvalidValue
+}
+
+// formatValue is the SOPS format: ENC[AES256_GCM,data:...,iv:...,tag:...,type:...]
+func formatValue(sealed, nonce []byte, typ string) string {
+	body, tag := sealed[:len(sealed)-aesgo.GCMTagSize], sealed[len(sealed)-aesgo.GCMTagSize:]
+	b64 := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", b64(body), b64(nonce), b64(tag), typ)
+}
+
+func parseEncryptedValue(s string) ([]byte, []byte, string, error) {
+	inner, ok := strings.CutPrefix(s, "ENC[AES256_GCM,")
+	if !ok || !strings.HasSuffix(inner, "]") {
+		return nil, nil, "", ErrInvalidValue
+	}
+
+	fields := make(map[string]string)
+	for _, f := range strings.Split(strings.TrimSuffix(inner, "]"), ",") {
+		k, v, ok := strings.Cut(f, ":")
+		if !ok {
+			return nil, nil, "", ErrInvalidValue
+		}
+		fields[k] = v
+	}
+
+	body, err1 := base64.StdEncoding.DecodeString(fields["data"])
+	nonce, err2 := base64.StdEncoding.DecodeString(fields["iv"])
+	tag, err3 := base64.StdEncoding.DecodeString(fields["tag"])
+	if err1 != nil || err2 != nil || err3 != nil || len(nonce) != aesgo.GCMNonceSize || len(tag) != aesgo.GCMTagSize {
+		return nil, nil, "", ErrInvalidValue
+	}
+
+	return append(body, tag...), nonce, fields["type"], nil
+}
+
+// additionalData is the path with a colon after every element, "database:port:".
+func additionalData(path []string) []byte {
+	var b strings.Builder
+	for _, p := range path {
+		b.WriteString(p)
+		b.WriteByte(':')
+	}
+	return []byte(b.String())
+}
+
+func unencrypted(path []string, suffix string) bool {
+	if suffix == "" {
+		return false
+	}
+	for _, p := range path {
+		if strings.HasSuffix(p, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newMAC(dek key.Key, suffix string) (hash.Hash, error) {
+	macKey, err := key.HKDF(sha256.New, dek.GetBytes(), nil, []byte("aes-go treecrypt mac"), 32)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, macKey)
+	writeField(mac, suffix)
+	return mac, nil
+}
+
+// writeMAC writes a leaf to the MAC. Every field is prefixed with its length so a key can't be
+// shifted into a value, and the path with its number of elements so a leaf can't change depth.
+func writeMAC(mac hash.Hash, path []string, typ string, plaintext []byte) {
+	binary.Write(mac, binary.BigEndian, uint64(len(path)))
+	for _, p := range path {
+		writeField(mac, p)
+	}
+	writeField(mac, typ)
+	writeField(mac, string(plaintext))
+}
+
+func writeField(mac hash.Hash, s string) {
+	binary.Write(mac, binary.BigEndian, uint64(len(s)))
+	mac.Write([]byte(s))
+}
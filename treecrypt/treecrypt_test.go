@@ -0,0 +1,179 @@
+package treecrypt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+const config = `{
+  "database": {
+    "user": "app",
+    "password": "hunter2",
+    "port": 5432,
+    "tls": true,
+    "replica": null
+  },
+  "hosts": [
+    "a.internal",
+    "b.internal"
+  ],
+  "region_unencrypted": "eu-west-1",
+  "empty": {}
+}
+`
+
+func TestEncryptDecrypt(t *testing.T) {
+	kek := key.Bit128()
+
+	encrypted, err := Encrypt(kek, "ops", []byte(config))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+
+	for _, s := range []string{`"database": {`, `"password": "ENC[AES256_GCM,`, `type:num]"`, `"replica": null`, `"kek_id": "ops"`} {
+		if !strings.Contains(string(encrypted), s) {
+			t.Errorf("Expected %s in\n%s", s, encrypted)
+		}
+	}
+	if strings.Contains(string(encrypted), "hunter2") || strings.Contains(string(encrypted), "eu-west-1") {
+		t.Errorf("Expected the values to be encrypted, got\n%s", encrypted)
+	}
+
+	id, err := KEKID(encrypted)
+	if err != nil || id != "ops" {
+		t.Errorf("Expected ops, got %s (%v)", id, err)
+	}
+
+	decrypted, err := Decrypt(kek, encrypted)
+	if err != nil {
+		t.Fatalf("Error decrypting: %s", err)
+	}
+	if string(decrypted) != config {
+		t.Errorf("Got:\n%s\nExpected:\n%s", decrypted, config)
+	}
+}
+
+func TestUnencryptedSuffix(t *testing.T) {
+	kek := key.Bit128()
+
+	encrypted, err := Encrypt(kek, "ops", []byte(config), WithUnencryptedSuffix("_unencrypted"))
+	if err != nil {
+		t.Fatalf("Error encrypting: %s", err)
+	}
+	if !strings.Contains(string(encrypted), `"region_unencrypted": "eu-west-1"`) {
+		t.Errorf("Expected the region in plaintext, got\n%s", encrypted)
+	}
+
+	// still covered by the MAC
+	edited := bytes.Replace(encrypted, []byte("eu-west-1"), []byte("us-east-1"), 1)
+	if _, err := Decrypt(kek, edited); err != ErrMACMismatch {
+		t.Errorf("Expected %v, got %v", ErrMACMismatch, err)
+	}
+
+	decrypted, err := Decrypt(kek, encrypted)
+	if err != nil || string(decrypted) != config {
+		t.Errorf("Got:\n%s\nExpected:\n%s (%v)", decrypted, config, err)
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	kek := key.Bit128()
+	encrypted, _ := Encrypt(kek, "ops", []byte(config))
+
+	// edit applies fn to the parsed encrypted document
+	edit := func(fn func(database *node)) []byte {
+		tree, _ := parse(encrypted)
+		fn(tree.get("database"))
+		b, _ := tree.marshal()
+		return b
+	}
+
+	tests := []struct {
+		name string
+		doc  []byte
+		kek  key.Key
+
+		expected error
+	}{
+		{
+			name: "Tampered value",
+			doc: edit(func(db *node) {
+				s := db.get("password").leaf.(string)
+				db.get("password").leaf = strings.Replace(s, "data:", "data:AAAA", 1)
+			}),
+			kek: kek,
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name: "Value moved to another key",
+			doc: edit(func(db *node) {
+				db.get("user").leaf = db.get("password").leaf
+			}),
+			kek: kek,
+
+			expected: aesgo.ErrAuthentication,
+		},
+		{
+			name: "Removed key",
+			doc: edit(func(db *node) {
+				db.remove("tls")
+			}),
+			kek: kek,
+
+			expected: ErrMACMismatch,
+		},
+		{
+			name: "Null replaced",
+			doc: edit(func(db *node) {
+				db.get("replica").leaf = "replica.internal"
+			}),
+			kek: kek,
+
+			expected: ErrMACMismatch,
+		},
+		{
+			name: "Invalid value",
+			doc: edit(func(db *node) {
+				db.get("user").leaf = "ENC[AES256_GCM,data:!]"
+			}),
+			kek: kek,
+
+			expected: ErrInvalidValue,
+		},
+		{
+			name: "Not encrypted",
+			doc:  []byte(config),
+			kek:  kek,
+
+			expected: ErrNotEncrypted,
+		},
+		{
+			name: "Not an object",
+			doc:  []byte(`["a"]`),
+			kek:  kek,
+
+			expected: ErrInvalidDocument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Decrypt(tt.kek, tt.doc); !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	if _, err := Decrypt(key.Bit128(), encrypted); err == nil {
+		t.Errorf("Expected error with the wrong KEK, got nil")
+	}
+	if _, err := Encrypt(kek, "ops", encrypted); err != ErrAlreadyEncrypted {
+		t.Errorf("Expected %v, got %v", ErrAlreadyEncrypted, err)
+	}
+}
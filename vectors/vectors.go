@@ -0,0 +1,257 @@
+// Package vectors runs the NIST CAVP response files (.rsp) against this implementation.
+//
+// The files can be downloaded from https://csrc.nist.gov/projects/cryptographic-algorithm-validation-program/block-ciphers
+// (AESAVS KAT and MMT). Only 128 bit keys are supported, vectors with other key sizes are skipped.
+// The mode is taken from the file name (ECBGFSbox128.rsp, CBCMMT128.rsp, ...).
+package vectors
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+	"github.com/mario-areias/aes-go/key"
+)
+
+var ErrUnknownMode = errors.New("Could not find the mode in the file name")
+
+// Vector is a single COUNT entry of a response file.
+type Vector struct {
+	File    string
+	Line    int
+	Encrypt bool
+	Count   int
+
+	Key        []byte
+	IV         []byte
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+func (v Vector) String() string {
+	direction := "DECRYPT"
+	if v.Encrypt {
+		direction = "ENCRYPT"
+	}
+	return fmt.Sprintf("%s:%d [%s] COUNT = %d", filepath.Base(v.File), v.Line, direction, v.Count)
+}
+
+type Failure struct {
+	Vector   Vector
+	Got      []byte
+	Expected []byte
+	Err      error
+}
+
+func (f Failure) String() string {
+	if f.Err != nil {
+		return fmt.Sprintf("%s: %s", f.Vector, f.Err)
+	}
+	return fmt.Sprintf("%s: got %x, expected %x", f.Vector, f.Got, f.Expected)
+}
+
+type Result struct {
+	Total    int
+	Passed   int
+	Skipped  int
+	Failures []Failure
+}
+
+func (r *Result) add(o Result) {
+	r.Total += o.Total
+	r.Passed += o.Passed
+	r.Skipped += o.Skipped
+	r.Failures = append(r.Failures, o.Failures...)
+}
+
+// RunVectors runs a single .rsp file, or every .rsp file when path is a directory.
+func RunVectors(path string) (Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.rsp"))
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	var result Result
+	for _, file := range files {
+		r, err := runFile(file)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", file, err)
+		}
+		result.add(r)
+	}
+
+	return result, nil
+}
+
+func runFile(file string) (Result, error) {
+	mode, err := ModeFromFilename(file)
+	if err != nil {
+		return Result{}, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+
+	vectors, err := Parse(f, file)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Run(mode, vectors), nil
+}
+
+// ModeFromFilename uses the prefix of NIST file names to find the mode.
+func ModeFromFilename(file string) (aesgo.Mode, error) {
+	name := strings.ToUpper(filepath.Base(file))
+	switch {
+	case strings.HasPrefix(name, "ECB"):
+		return aesgo.ECB, nil
+	case strings.HasPrefix(name, "CBC"):
+		return aesgo.CBC, nil
+	case strings.HasPrefix(name, "CTR"):
+		return aesgo.CTR, nil
+	}
+	return 0, ErrUnknownMode
+}
+
+// Parse reads the vectors of a response file. Comments and unknown fields are ignored.
+func Parse(r io.Reader, file string) ([]Vector, error) {
+	var vectors []Vector
+	var current *Vector
+
+	encrypt := true
+	scanner := bufio.NewScanner(r)
+	// MMT files have long lines
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "" || strings.HasPrefix(text, "#"):
+			continue
+		case text == "[ENCRYPT]":
+			encrypt = true
+			continue
+		case text == "[DECRYPT]":
+			encrypt = false
+			continue
+		case strings.HasPrefix(text, "["):
+			continue
+		}
+
+		name, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME = VALUE, got %q", line, text)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+
+		if name == "COUNT" {
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			vectors = append(vectors, Vector{File: file, Line: line, Encrypt: encrypt, Count: count})
+			current = &vectors[len(vectors)-1]
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %s before COUNT", line, name)
+		}
+
+		var dst *[]byte
+		switch name {
+		case "KEY":
+			dst = &current.Key
+		case "IV":
+			dst = &current.IV
+		case "PLAINTEXT":
+			dst = &current.Plaintext
+		case "CIPHERTEXT":
+			dst = &current.Ciphertext
+		default:
+			continue
+		}
+
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		*dst = b
+	}
+
+	return vectors, scanner.Err()
+}
+
+// Run checks every vector with the given mode.
+func Run(mode aesgo.Mode, vectors []Vector) Result {
+	var result Result
+
+	for _, v := range vectors {
+		result.Total++
+
+		if len(v.Key) != 16 {
+			result.Skipped++
+			continue
+		}
+
+		got, expected, err := run(mode, v)
+		switch {
+		case err != nil:
+			result.Failures = append(result.Failures, Failure{Vector: v, Err: err})
+		case !bytes.Equal(got, expected):
+			result.Failures = append(result.Failures, Failure{Vector: v, Got: got, Expected: expected})
+		default:
+			result.Passed++
+		}
+	}
+
+	return result
+}
+
+func run(mode aesgo.Mode, v Vector) ([]byte, []byte, error) {
+	// the IV is "generated" by reading it from the vector, so the output matches
+	a, err := aesgo.NewCipher(key.NewKey([16]byte(v.Key)),
+		aesgo.WithPadding(aesgo.NoPadding),
+		aesgo.WithRandReader(bytes.NewReader(v.IV)),
+		aesgo.WithInsecureModes(),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if v.Encrypt {
+		encrypted, err := a.Encrypt(mode, v.Plaintext)
+		if err != nil {
+			return nil, nil, err
+		}
+		// ECB has no IV, for the other modes it is the first block
+		return encrypted[len(v.IV):], v.Ciphertext, nil
+	}
+
+	decrypted, err := a.Decrypt(mode, append(append([]byte{}, v.IV...), v.Ciphertext...))
+	return decrypted, v.Plaintext, err
+}
@@ -0,0 +1,81 @@
+package vectors
+
+import (
+	"strings"
+	"testing"
+
+	aesgo "github.com/mario-areias/aes-go/aes-go"
+)
+
+func TestRunVectors(t *testing.T) {
+	result, err := RunVectors("testdata")
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	for _, f := range result.Failures {
+		t.Errorf("%s", f)
+	}
+
+	if result.Passed == 0 || result.Passed != result.Total-result.Skipped {
+		t.Errorf("Expected every vector to pass, got %d passed of %d", result.Passed, result.Total)
+	}
+}
+
+func TestParse(t *testing.T) {
+	input := `# AESVS VarKey test data for ECB
+# Key Length : 256
+
+[ENCRYPT]
+
+COUNT = 0
+KEY = 8000000000000000000000000000000000000000000000000000000000000000
+PLAINTEXT = 00000000000000000000000000000000
+CIPHERTEXT = e35a6dcb19b201a01ebcfa8aa22b5759
+
+[DECRYPT]
+
+COUNT = 0
+KEY = 00000000000000000000000000000000
+CIPHERTEXT = 0336763e966d92595a567cc9ce537f5e
+PLAINTEXT = 00000000000000000000000000000000
+`
+
+	vectors, err := Parse(strings.NewReader(input), "ECBVarKey256.rsp")
+	if err != nil {
+		t.Fatalf("Expected nil, got %v", err)
+	}
+
+	if len(vectors) != 2 || !vectors[0].Encrypt || vectors[1].Encrypt {
+		t.Fatalf("Unexpected vectors: %+v", vectors)
+	}
+
+	result := Run(aesgo.ECB, vectors)
+	if result.Skipped != 1 {
+		t.Errorf("Expected the 256 bit key to be skipped, got %d skipped", result.Skipped)
+	}
+
+	// the second vector has the wrong plaintext, it must be reported
+	if len(result.Failures) != 1 {
+		t.Errorf("Expected 1 failure, got %d", len(result.Failures))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		"KEY = 00",
+		"COUNT = 0\nKEY = zz",
+		"COUNT = x",
+		"COUNT = 0\nnot a field",
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse(strings.NewReader(input), "ECB.rsp"); err == nil {
+			t.Errorf("Expected error for %q, got nil", input)
+		}
+	}
+
+	if _, err := ModeFromFilename("XTSGenAES128.rsp"); err != ErrUnknownMode {
+		t.Errorf("Expected %v, got %v", ErrUnknownMode, err)
+	}
+}